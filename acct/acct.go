@@ -0,0 +1,37 @@
+// Package acct provides the models used to record the double-entry
+// accounting postings derived from other GOBL documents.
+package acct
+
+import (
+	"github.com/invopop/gobl/cbc"
+	"github.com/invopop/gobl/schema"
+)
+
+func init() {
+	schema.Register(schema.GOBL.Add("acct"),
+		Entry{},
+		Chart{},
+	)
+}
+
+// Books used to classify journal entries.
+const (
+	// BookSales is used for invoices issued to customers.
+	BookSales cbc.Key = "sales"
+	// BookPurchases is used for invoices received from suppliers.
+	BookPurchases cbc.Key = "purchases"
+)
+
+// Books contains the list of supported books.
+var Books = []cbc.Key{
+	BookSales,
+	BookPurchases,
+}
+
+func bookList() []any {
+	list := make([]any, len(Books))
+	for i, b := range Books {
+		list[i] = b
+	}
+	return list
+}
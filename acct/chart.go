@@ -0,0 +1,186 @@
+package acct
+
+import (
+	"context"
+
+	"github.com/invopop/gobl/cbc"
+	"github.com/invopop/gobl/org"
+	"github.com/invopop/gobl/tax"
+	"github.com/invopop/gobl/uuid"
+	"github.com/invopop/validation"
+)
+
+// Chart maps the concepts of a document to the codes of the accounts used in
+// a chart of accounts.
+type Chart struct {
+	uuid.Identify
+	// Name of the chart.
+	Name string `json:"name,omitempty" jsonschema:"title=Name"`
+	// Account used for the lines of invoices issued.
+	Revenue cbc.Code `json:"revenue,omitempty" jsonschema:"title=Revenue"`
+	// Account used for the lines of invoices received.
+	Expense cbc.Code `json:"expense,omitempty" jsonschema:"title=Expense"`
+	// Account used for amounts owed by customers.
+	Receivable cbc.Code `json:"receivable,omitempty" jsonschema:"title=Receivable"`
+	// Account used for amounts owed to suppliers.
+	Payable cbc.Code `json:"payable,omitempty" jsonschema:"title=Payable"`
+	// Account used for outlays paid on behalf of the customer.
+	Outlays cbc.Code `json:"outlays,omitempty" jsonschema:"title=Outlays"`
+	// Account used for cash rounding differences.
+	Rounding cbc.Code `json:"rounding,omitempty" jsonschema:"title=Rounding"`
+	// Accounts for each tax category and rate.
+	Taxes []*TaxAccount `json:"taxes,omitempty" jsonschema:"title=Taxes"`
+	// Accounts for document level discounts by key, the revenue or expense
+	// account is used otherwise.
+	Discounts []*KeyAccount `json:"discounts,omitempty" jsonschema:"title=Discounts"`
+	// Accounts for document level charges by key, the revenue or expense
+	// account is used otherwise.
+	Charges []*KeyAccount `json:"charges,omitempty" jsonschema:"title=Charges"`
+	// Accounts for advances by payment means key, the receivable or payable
+	// account is used otherwise.
+	Means []*KeyAccount `json:"means,omitempty" jsonschema:"title=Means"`
+	// Accounts for specific customers or suppliers, that override the
+	// receivable or payable accounts.
+	Parties []*PartyAccount `json:"parties,omitempty" jsonschema:"title=Parties"`
+}
+
+// TaxAccount defines the accounts used for the amounts of a tax category and,
+// optionally, a specific rate.
+type TaxAccount struct {
+	// Tax category code.
+	Category cbc.Code `json:"cat" jsonschema:"title=Category"`
+	// Rate key, or empty to match any rate of the category.
+	Rate cbc.Key `json:"rate,omitempty" jsonschema:"title=Rate"`
+	// Account used in sales, usually for tax payable, or for tax receivable
+	// when the tax is retained by the customer.
+	Sales cbc.Code `json:"sales,omitempty" jsonschema:"title=Sales"`
+	// Account used in purchases, usually for tax receivable, or for tax
+	// payable when the tax is retained from the supplier.
+	Purchases cbc.Code `json:"purchases,omitempty" jsonschema:"title=Purchases"`
+}
+
+// KeyAccount defines the account used for a key.
+type KeyAccount struct {
+	// Key to match.
+	Key cbc.Key `json:"key" jsonschema:"title=Key"`
+	// Account code.
+	Account cbc.Code `json:"account" jsonschema:"title=Account"`
+}
+
+// PartyAccount defines the account used for a party identified either by its
+// UUID or tax ID.
+type PartyAccount struct {
+	// UUID of the party.
+	UUID uuid.UUID `json:"uuid,omitempty" jsonschema:"title=UUID"`
+	// Tax identity of the party.
+	TaxID *tax.Identity `json:"tax_id,omitempty" jsonschema:"title=Tax ID"`
+	// Account code.
+	Account cbc.Code `json:"account" jsonschema:"title=Account"`
+}
+
+// Validate ensures the chart contains everything it needs.
+func (c *Chart) Validate() error {
+	return c.ValidateWithContext(context.Background())
+}
+
+// ValidateWithContext ensures the chart contains everything it needs.
+func (c *Chart) ValidateWithContext(ctx context.Context) error {
+	return validation.ValidateStructWithContext(ctx, c,
+		validation.Field(&c.UUID),
+		validation.Field(&c.Revenue),
+		validation.Field(&c.Expense),
+		validation.Field(&c.Receivable),
+		validation.Field(&c.Payable),
+		validation.Field(&c.Outlays),
+		validation.Field(&c.Rounding),
+		validation.Field(&c.Taxes),
+		validation.Field(&c.Discounts),
+		validation.Field(&c.Charges),
+		validation.Field(&c.Means),
+		validation.Field(&c.Parties),
+	)
+}
+
+// ValidateWithContext checks the tax account's contents.
+func (ta *TaxAccount) ValidateWithContext(ctx context.Context) error {
+	return validation.ValidateStructWithContext(ctx, ta,
+		validation.Field(&ta.Category, validation.Required),
+		validation.Field(&ta.Rate),
+		validation.Field(&ta.Sales),
+		validation.Field(&ta.Purchases),
+	)
+}
+
+// ValidateWithContext checks the key account's contents.
+func (ka *KeyAccount) ValidateWithContext(ctx context.Context) error {
+	return validation.ValidateStructWithContext(ctx, ka,
+		validation.Field(&ka.Key, validation.Required),
+		validation.Field(&ka.Account, validation.Required),
+	)
+}
+
+// ValidateWithContext checks the party account's contents.
+func (pa *PartyAccount) ValidateWithContext(ctx context.Context) error {
+	return validation.ValidateStructWithContext(ctx, pa,
+		validation.Field(&pa.UUID, validation.When(pa.TaxID == nil, validation.Required)),
+		validation.Field(&pa.TaxID),
+		validation.Field(&pa.Account, validation.Required),
+	)
+}
+
+// TaxAccountFor provides the account for the tax category and rate in the
+// book, preferring definitions that match the rate. An empty code is
+// returned if there is no match.
+func (c *Chart) TaxAccountFor(book cbc.Key, cat cbc.Code, rate cbc.Key) cbc.Code {
+	var match *TaxAccount
+	for _, ta := range c.Taxes {
+		if ta.Category != cat {
+			continue
+		}
+		if ta.Rate == rate && rate != cbc.KeyEmpty {
+			match = ta
+			break
+		}
+		if ta.Rate == cbc.KeyEmpty && match == nil {
+			match = ta
+		}
+	}
+	if match == nil {
+		return cbc.CodeEmpty
+	}
+	if book == BookPurchases {
+		return match.Purchases
+	}
+	return match.Sales
+}
+
+// PartyAccountFor provides the account for the party in the book, using the
+// receivable or payable account if there is no specific match.
+func (c *Chart) PartyAccountFor(book cbc.Key, p *org.Party) cbc.Code {
+	if p != nil {
+		for _, pa := range c.Parties {
+			if !pa.UUID.IsZero() && pa.UUID == p.UUID {
+				return pa.Account
+			}
+			if pa.TaxID != nil && p.TaxID != nil &&
+				pa.TaxID.Country == p.TaxID.Country && pa.TaxID.Code == p.TaxID.Code {
+				return pa.Account
+			}
+		}
+	}
+	if book == BookPurchases {
+		return c.Payable
+	}
+	return c.Receivable
+}
+
+func keyAccount(list []*KeyAccount, key cbc.Key, def cbc.Code) cbc.Code {
+	if key != cbc.KeyEmpty {
+		for _, ka := range list {
+			if ka.Key == key {
+				return ka.Account
+			}
+		}
+	}
+	return def
+}
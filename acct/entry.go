@@ -0,0 +1,113 @@
+package acct
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/invopop/gobl/cal"
+	"github.com/invopop/gobl/cbc"
+	"github.com/invopop/gobl/currency"
+	"github.com/invopop/gobl/num"
+	"github.com/invopop/gobl/org"
+	"github.com/invopop/gobl/uuid"
+	"github.com/invopop/validation"
+)
+
+// Entry is a journal entry containing a balanced set of debit and credit
+// lines posted to the accounts of a chart.
+type Entry struct {
+	uuid.Identify
+	// Book the entry belongs to.
+	Book cbc.Key `json:"book" jsonschema:"title=Book"`
+	// Date the entry is posted on.
+	Date cal.Date `json:"date" jsonschema:"title=Date"`
+	// Currency used for all amounts.
+	Currency currency.Code `json:"currency" jsonschema:"title=Currency"`
+	// Description of the entry.
+	Description string `json:"description,omitempty" jsonschema:"title=Description"`
+	// Reference to the document the entry was generated from.
+	Source *org.DocumentRef `json:"source,omitempty" jsonschema:"title=Source"`
+	// Lines posted to each account.
+	Lines []*Line `json:"lines" jsonschema:"title=Lines"`
+	// Sum of all the debit amounts.
+	Debit num.Amount `json:"debit" jsonschema:"title=Debit" jsonschema_extras:"calculated=true"`
+	// Sum of all the credit amounts.
+	Credit num.Amount `json:"credit" jsonschema:"title=Credit" jsonschema_extras:"calculated=true"`
+}
+
+// Line contains the amount posted to an account as either a debit or credit.
+type Line struct {
+	// Line number inside the entry (calculated).
+	Index int `json:"i" jsonschema:"title=Index" jsonschema_extras:"calculated=true"`
+	// Code of the account in the chart.
+	Account cbc.Code `json:"account" jsonschema:"title=Account"`
+	// Amount debited to the account.
+	Debit *num.Amount `json:"debit,omitempty" jsonschema:"title=Debit"`
+	// Amount credited to the account.
+	Credit *num.Amount `json:"credit,omitempty" jsonschema:"title=Credit"`
+	// Description of the line.
+	Description string `json:"description,omitempty" jsonschema:"title=Description"`
+	// UUID of the object in the source document the line was derived from,
+	// like an invoice line, discount, or party.
+	Ref uuid.UUID `json:"ref,omitempty" jsonschema:"title=Reference"`
+}
+
+// Calculate determines the line indexes and the debit and credit totals.
+func (e *Entry) Calculate() error {
+	zero := e.Currency.Def().Zero()
+	e.Debit = zero
+	e.Credit = zero
+	for i, l := range e.Lines {
+		if l == nil {
+			continue
+		}
+		l.Index = i + 1
+		if l.Debit != nil {
+			e.Debit = e.Debit.Add(*l.Debit)
+		}
+		if l.Credit != nil {
+			e.Credit = e.Credit.Add(*l.Credit)
+		}
+	}
+	return nil
+}
+
+// Validate ensures the entry contains everything it needs and is balanced.
+func (e *Entry) Validate() error {
+	return e.ValidateWithContext(context.Background())
+}
+
+// ValidateWithContext ensures the entry contains everything it needs and
+// is balanced.
+func (e *Entry) ValidateWithContext(ctx context.Context) error {
+	return validation.ValidateStructWithContext(ctx, e,
+		validation.Field(&e.UUID),
+		validation.Field(&e.Book, validation.Required, validation.In(bookList()...)),
+		validation.Field(&e.Date, cal.DateNotZero()),
+		validation.Field(&e.Currency, validation.Required),
+		validation.Field(&e.Source),
+		validation.Field(&e.Lines, validation.Required),
+		validation.Field(&e.Credit, validation.By(e.balanced)),
+	)
+}
+
+func (e *Entry) balanced(_ any) error {
+	if !e.Debit.Equals(e.Credit) {
+		return fmt.Errorf("does not match debit %s", e.Debit)
+	}
+	return nil
+}
+
+// ValidateWithContext checks the line's contents.
+func (l *Line) ValidateWithContext(ctx context.Context) error {
+	return validation.ValidateStructWithContext(ctx, l,
+		validation.Field(&l.Account, validation.Required),
+		validation.Field(&l.Debit,
+			validation.When(l.Credit == nil, validation.Required),
+			validation.When(l.Credit != nil, validation.Nil),
+			num.Positive,
+		),
+		validation.Field(&l.Credit, num.Positive),
+		validation.Field(&l.Ref),
+	)
+}
@@ -0,0 +1,45 @@
+package acct_test
+
+import (
+	"testing"
+
+	"github.com/invopop/gobl/acct"
+	"github.com/invopop/gobl/cal"
+	"github.com/invopop/gobl/currency"
+	"github.com/invopop/gobl/num"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEntryValidation(t *testing.T) {
+	amount := func(v int64) *num.Amount {
+		return num.NewAmount(v, 2)
+	}
+	e := &acct.Entry{
+		Book:     acct.BookSales,
+		Date:     cal.MakeDate(2024, 5, 10),
+		Currency: currency.EUR,
+		Lines: []*acct.Line{
+			{Account: "430", Debit: amount(12100)},
+			{Account: "700", Credit: amount(10000)},
+			{Account: "477", Credit: amount(2100)},
+		},
+	}
+	require.NoError(t, e.Calculate())
+	assert.Equal(t, 3, e.Lines[2].Index)
+	assert.Equal(t, "121.00", e.Debit.String())
+	assert.NoError(t, e.Validate())
+
+	e.Lines[2].Credit = amount(2000)
+	require.NoError(t, e.Calculate())
+	assert.ErrorContains(t, e.Validate(), "credit: does not match debit 121.00")
+
+	e.Lines[2].Debit = amount(100)
+	assert.ErrorContains(t, e.Validate(), "lines: (2: (debit: must be blank.).)")
+
+	e.Lines[2] = &acct.Line{Account: "477"}
+	assert.ErrorContains(t, e.Validate(), "lines: (2: (debit: cannot be blank.).)")
+
+	e.Book = "foo"
+	assert.ErrorContains(t, e.Validate(), "book: must be a valid value")
+}
@@ -0,0 +1,257 @@
+package acct
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/invopop/gobl/bill"
+	"github.com/invopop/gobl/cal"
+	"github.com/invopop/gobl/cbc"
+	"github.com/invopop/gobl/num"
+	"github.com/invopop/gobl/org"
+	"github.com/invopop/gobl/tax"
+	"github.com/invopop/gobl/uuid"
+)
+
+// NewInvoiceEntry generates the journal entry for the calculated invoice
+// using the accounts of the chart, as recorded in the sales or purchases
+// book. Sales credit revenue and taxes, and debit the customer's receivable
+// account, while purchases do the opposite. Credit notes reverse all the
+// lines. Any rounding differences are applied to the last invoice line.
+func NewInvoiceEntry(inv *bill.Invoice, chart *Chart, book cbc.Key) (*Entry, error) {
+	if inv == nil || inv.Totals == nil {
+		return nil, errors.New("calculated invoice required")
+	}
+	if chart == nil {
+		return nil, errors.New("chart required")
+	}
+	if !book.In(Books...) {
+		return nil, fmt.Errorf("invalid book '%s'", book)
+	}
+	b := &entryBuilder{
+		chart: chart,
+		book:  book,
+		// purchases and credit notes both reverse the sides of sales
+		reverse: (book == BookPurchases) != inv.Type.In(bill.InvoiceTypeCreditNote),
+		exp:     inv.Currency.Def().Subunits,
+		entry: &Entry{
+			Book:     book,
+			Date:     inv.IssueDate,
+			Currency: inv.Currency,
+			Source: &org.DocumentRef{
+				Identify:  uuid.Identify{UUID: inv.UUID},
+				Type:      inv.Type,
+				IssueDate: cal.NewDate(inv.IssueDate.Year, inv.IssueDate.Month, inv.IssueDate.Day),
+				Series:    inv.Series,
+				Code:      inv.Code,
+			},
+		},
+	}
+	b.entry.Description = invoiceDescription(inv)
+	if err := b.invoice(inv); err != nil {
+		return nil, err
+	}
+	if err := b.entry.Calculate(); err != nil {
+		return nil, err
+	}
+	return b.entry, nil
+}
+
+type entryBuilder struct {
+	chart   *Chart
+	book    cbc.Key
+	reverse bool
+	exp     uint32
+	entry   *Entry
+	last    *Line // last item line, used for rounding differences
+	balance num.Amount
+}
+
+func (b *entryBuilder) invoice(inv *bill.Invoice) error {
+	t := inv.Totals
+	b.balance = num.MakeAmount(0, b.exp)
+	main := b.chart.Revenue
+	counterpart := inv.Customer
+	if b.book == BookPurchases {
+		main = b.chart.Expense
+		counterpart = inv.Supplier
+	}
+
+	// Lines, discounts, and charges
+	for _, l := range inv.Lines {
+		if l == nil {
+			continue
+		}
+		desc := ""
+		if l.Item != nil {
+			desc = l.Item.Name
+		}
+		if err := b.post(main, l.Total, false, desc, l.UUID); err != nil {
+			return fmt.Errorf("line %d: %w", l.Index, err)
+		}
+		if n := len(b.entry.Lines); n > 0 {
+			b.last = b.entry.Lines[n-1]
+		}
+	}
+	for _, d := range inv.Discounts {
+		acc := keyAccount(b.chart.Discounts, d.Key, main)
+		if err := b.post(acc, d.Amount, true, d.Reason, d.UUID); err != nil {
+			return fmt.Errorf("discount %d: %w", d.Index, err)
+		}
+	}
+	for _, c := range inv.Charges {
+		acc := keyAccount(b.chart.Charges, c.Key, main)
+		if err := b.post(acc, c.Amount, false, c.Reason, c.UUID); err != nil {
+			return fmt.Errorf("charge %d: %w", c.Index, err)
+		}
+	}
+	if t.TaxIncluded != nil {
+		// line totals include taxes that are posted separately
+		if err := b.post(main, *t.TaxIncluded, true, "Tax included in prices", uuid.Zero); err != nil {
+			return fmt.Errorf("tax included: %w", err)
+		}
+	}
+
+	// Taxes
+	if t.Taxes != nil {
+		for _, ct := range t.Taxes.Categories {
+			for _, rt := range ct.Rates {
+				if err := b.tax(ct, rt); err != nil {
+					return err
+				}
+			}
+		}
+	}
+
+	// Outlays and rounding
+	for _, o := range inv.Outlays {
+		if err := b.post(b.chart.Outlays, o.Amount, false, o.Description, o.UUID); err != nil {
+			return fmt.Errorf("outlay %d: %w", o.Index, err)
+		}
+	}
+	if t.Rounding != nil {
+		if err := b.post(b.chart.Rounding, *t.Rounding, false, "Rounding", uuid.Zero); err != nil {
+			return fmt.Errorf("rounding: %w", err)
+		}
+	}
+
+	// Advances and the amount due from the counterpart
+	due := t.Payable
+	if inv.Payment != nil {
+		for _, a := range inv.Payment.Advances {
+			acc := keyAccount(b.chart.Means, a.Key, cbc.CodeEmpty)
+			if acc == cbc.CodeEmpty {
+				continue // remains in the counterpart's account
+			}
+			if err := b.post(acc, a.Amount, true, a.Description, a.UUID); err != nil {
+				return fmt.Errorf("advance: %w", err)
+			}
+			due = due.Subtract(a.Amount)
+		}
+	}
+	var ref uuid.UUID
+	desc := ""
+	if counterpart != nil {
+		ref = counterpart.UUID
+		desc = counterpart.Name
+	}
+	if err := b.post(b.chart.PartyAccountFor(b.book, counterpart), due, true, desc, ref); err != nil {
+		return fmt.Errorf("%s: %w", b.counterpartLabel(), err)
+	}
+
+	return b.settle()
+}
+
+func (b *entryBuilder) tax(ct *tax.CategoryTotal, rt *tax.RateTotal) error {
+	acc := b.chart.TaxAccountFor(b.book, ct.Code, rt.Key)
+	desc := ct.Code.String()
+	if rt.Percent != nil {
+		desc = fmt.Sprintf("%s %s", desc, rt.Percent.String())
+	}
+	// retained taxes are deducted from the amount due by the customer
+	debit := ct.Retained
+	if err := b.post(acc, rt.Amount, debit, desc, uuid.Zero); err != nil {
+		return fmt.Errorf("tax %s: %w", desc, err)
+	}
+	if rt.Surcharge != nil {
+		desc = fmt.Sprintf("%s surcharge %s", ct.Code, rt.Surcharge.Percent.String())
+		if err := b.post(acc, rt.Surcharge.Amount, debit, desc, uuid.Zero); err != nil {
+			return fmt.Errorf("tax %s: %w", desc, err)
+		}
+	}
+	return nil
+}
+
+// post adds a new line to the entry with the amount as a debit or credit
+// according to the side used in sales. Negative amounts are posted to the
+// opposite side, and zero amounts are ignored.
+func (b *entryBuilder) post(acc cbc.Code, amount num.Amount, debit bool, desc string, ref uuid.UUID) error {
+	amount = amount.Rescale(b.exp)
+	if amount.IsZero() {
+		return nil
+	}
+	if acc == cbc.CodeEmpty {
+		return errors.New("missing account")
+	}
+	if b.reverse {
+		debit = !debit
+	}
+	if amount.IsNegative() {
+		amount = amount.Invert()
+		debit = !debit
+	}
+	l := &Line{
+		Account:     acc,
+		Description: desc,
+		Ref:         ref,
+	}
+	if debit {
+		l.Debit = &amount
+		b.balance = b.balance.Add(amount)
+	} else {
+		l.Credit = &amount
+		b.balance = b.balance.Subtract(amount)
+	}
+	b.entry.Lines = append(b.entry.Lines, l)
+	return nil
+}
+
+// settle applies any difference between the debit and credit amounts caused
+// by rounding to the last item line. Only differences of up to one minor unit
+// per line are expected from rounding, anything more implies the invoice's
+// totals are inconsistent.
+func (b *entryBuilder) settle() error {
+	if b.balance.IsZero() {
+		return nil
+	}
+	tolerance := num.MakeAmount(int64(len(b.entry.Lines)), b.exp)
+	if b.last == nil || b.balance.Abs().Compare(tolerance) > 0 {
+		return fmt.Errorf("entry not balanced by %s", b.balance)
+	}
+	if b.last.Debit != nil {
+		a := b.last.Debit.Subtract(b.balance)
+		b.last.Debit = &a
+	} else {
+		a := b.last.Credit.Add(b.balance)
+		b.last.Credit = &a
+	}
+	return nil
+}
+
+func (b *entryBuilder) counterpartLabel() string {
+	if b.book == BookPurchases {
+		return "supplier"
+	}
+	return "customer"
+}
+
+func invoiceDescription(inv *bill.Invoice) string {
+	code := inv.Code.String()
+	if inv.Series != "" {
+		code = fmt.Sprintf("%s-%s", inv.Series, inv.Code)
+	}
+	if inv.Type.In(bill.InvoiceTypeCreditNote) {
+		return fmt.Sprintf("Credit note %s", code)
+	}
+	return fmt.Sprintf("Invoice %s", code)
+}
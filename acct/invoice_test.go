@@ -0,0 +1,283 @@
+package acct_test
+
+import (
+	"testing"
+
+	"github.com/invopop/gobl/acct"
+	"github.com/invopop/gobl/bill"
+	"github.com/invopop/gobl/cal"
+	"github.com/invopop/gobl/cbc"
+	"github.com/invopop/gobl/num"
+	"github.com/invopop/gobl/org"
+	"github.com/invopop/gobl/pay"
+	"github.com/invopop/gobl/regimes/es"
+	"github.com/invopop/gobl/tax"
+	"github.com/invopop/gobl/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	_ "github.com/invopop/gobl"
+)
+
+func testChart() *acct.Chart {
+	return &acct.Chart{
+		Revenue:    "700",
+		Expense:    "600",
+		Receivable: "430",
+		Payable:    "400",
+		Outlays:    "4709",
+		Taxes: []*acct.TaxAccount{
+			{Category: tax.CategoryVAT, Sales: "477", Purchases: "472"},
+			{Category: tax.CategoryVAT, Rate: tax.RateReduced, Sales: "4771", Purchases: "4721"},
+			{Category: es.TaxCategoryIRPF, Sales: "473", Purchases: "4751"},
+		},
+		Discounts: []*acct.KeyAccount{
+			{Key: bill.DiscountKeyEarlyCompletion, Account: "706"},
+		},
+		Means: []*acct.KeyAccount{
+			{Key: pay.MeansKeyCreditTransfer, Account: "572"},
+		},
+		Parties: []*acct.PartyAccount{
+			{TaxID: &tax.Identity{Country: "ES", Code: "54387763P"}, Account: "430001"},
+		},
+	}
+}
+
+func testInvoice(t *testing.T) *bill.Invoice {
+	t.Helper()
+	inv := &bill.Invoice{
+		Regime:    tax.WithRegime("ES"),
+		Code:      "123",
+		Series:    "SAMPLE",
+		IssueDate: cal.MakeDate(2024, 5, 10),
+		Supplier: &org.Party{
+			Name:  "Provide One S.L.",
+			TaxID: &tax.Identity{Country: "ES", Code: "B98602642"},
+		},
+		Customer: &org.Party{
+			Name:  "Sample Consumer",
+			TaxID: &tax.Identity{Country: "ES", Code: "54387763P"},
+		},
+		Lines: []*bill.Line{
+			{
+				Quantity: num.MakeAmount(10, 0),
+				Item: &org.Item{
+					Name:  "Development services",
+					Price: num.MakeAmount(9000, 2),
+				},
+				Taxes: tax.Set{
+					{Category: tax.CategoryVAT, Rate: tax.RateStandard},
+					{Category: es.TaxCategoryIRPF, Rate: es.TaxRatePro},
+				},
+			},
+			{
+				Quantity: num.MakeAmount(2, 0),
+				Item: &org.Item{
+					Name:  "Books",
+					Price: num.MakeAmount(1500, 2),
+				},
+				Taxes: tax.Set{
+					{Category: tax.CategoryVAT, Rate: tax.RateReduced},
+				},
+			},
+		},
+		Discounts: []*bill.Discount{
+			{
+				Key:    bill.DiscountKeyEarlyCompletion,
+				Reason: "Early payment",
+				Amount: num.MakeAmount(1000, 2),
+				Taxes: tax.Set{
+					{Category: tax.CategoryVAT, Rate: tax.RateStandard},
+				},
+			},
+		},
+		Charges: []*bill.Charge{
+			{
+				Reason: "Handling",
+				Amount: num.MakeAmount(500, 2),
+				Taxes: tax.Set{
+					{Category: tax.CategoryVAT, Rate: tax.RateStandard},
+				},
+			},
+		},
+		Payment: &bill.PaymentDetails{
+			Advances: []*pay.Advance{
+				{
+					Key:         pay.MeansKeyCreditTransfer,
+					Description: "Deposit",
+					Amount:      num.MakeAmount(20000, 2),
+				},
+			},
+		},
+	}
+	require.NoError(t, inv.Calculate())
+	return inv
+}
+
+type posting struct {
+	account cbc.Code
+	debit   string
+	credit  string
+}
+
+func postings(e *acct.Entry) []posting {
+	out := make([]posting, len(e.Lines))
+	for i, l := range e.Lines {
+		p := posting{account: l.Account}
+		if l.Debit != nil {
+			p.debit = l.Debit.String()
+		}
+		if l.Credit != nil {
+			p.credit = l.Credit.String()
+		}
+		out[i] = p
+	}
+	return out
+}
+
+func TestNewInvoiceEntry(t *testing.T) {
+	t.Run("sales", func(t *testing.T) {
+		inv := testInvoice(t)
+		e, err := acct.NewInvoiceEntry(inv, testChart(), acct.BookSales)
+		require.NoError(t, err)
+		assert.NoError(t, e.Validate())
+		assert.Equal(t, acct.BookSales, e.Book)
+		assert.Equal(t, "Invoice SAMPLE-123", e.Description)
+		assert.Equal(t, inv.UUID, e.Source.UUID)
+		assert.Equal(t, cbc.Code("123"), e.Source.Code)
+		assert.Equal(t, []posting{
+			{account: "700", credit: "900.00"},
+			{account: "700", credit: "30.00"},
+			{account: "706", debit: "10.00"},
+			{account: "700", credit: "5.00"},
+			{account: "477", credit: "187.95"},
+			{account: "4771", credit: "3.00"},
+			{account: "473", debit: "135.00"},
+			{account: "572", debit: "200.00"},
+			{account: "430001", debit: "780.95"},
+		}, postings(e))
+		assert.Equal(t, inv.Lines[0].UUID, e.Lines[0].Ref)
+		assert.Equal(t, inv.Discounts[0].UUID, e.Lines[2].Ref)
+		assert.Equal(t, inv.Customer.UUID, e.Lines[8].Ref)
+		assert.Equal(t, "VAT 21.0%", e.Lines[4].Description)
+		assert.Equal(t, 9, e.Lines[8].Index)
+		assert.Equal(t, "1125.95", e.Debit.String())
+		assert.Equal(t, "1125.95", e.Credit.String())
+	})
+	t.Run("purchases", func(t *testing.T) {
+		inv := testInvoice(t)
+		chart := testChart()
+		chart.Parties = nil
+		e, err := acct.NewInvoiceEntry(inv, chart, acct.BookPurchases)
+		require.NoError(t, err)
+		assert.NoError(t, e.Validate())
+		assert.Equal(t, []posting{
+			{account: "600", debit: "900.00"},
+			{account: "600", debit: "30.00"},
+			{account: "706", credit: "10.00"},
+			{account: "600", debit: "5.00"},
+			{account: "472", debit: "187.95"},
+			{account: "4721", debit: "3.00"},
+			{account: "4751", credit: "135.00"},
+			{account: "572", credit: "200.00"},
+			{account: "400", credit: "780.95"},
+		}, postings(e))
+	})
+	t.Run("credit note", func(t *testing.T) {
+		inv := testInvoice(t)
+		inv.Type = bill.InvoiceTypeCreditNote
+		e, err := acct.NewInvoiceEntry(inv, testChart(), acct.BookSales)
+		require.NoError(t, err)
+		assert.NoError(t, e.Validate())
+		assert.Equal(t, "Credit note SAMPLE-123", e.Description)
+		assert.Equal(t, posting{account: "700", debit: "900.00"}, postings(e)[0])
+		assert.Equal(t, posting{account: "430001", credit: "780.95"}, postings(e)[8])
+	})
+	t.Run("prices include tax", func(t *testing.T) {
+		inv := testInvoice(t)
+		inv.Tax = &bill.Tax{PricesInclude: tax.CategoryVAT}
+		require.NoError(t, inv.Calculate())
+		e, err := acct.NewInvoiceEntry(inv, testChart(), acct.BookSales)
+		require.NoError(t, err)
+		assert.NoError(t, e.Validate())
+		assert.Equal(t, posting{account: "700", debit: inv.Totals.TaxIncluded.String()}, postings(e)[4])
+	})
+	t.Run("outlays", func(t *testing.T) {
+		inv := testInvoice(t)
+		inv.Outlays = []*bill.Outlay{
+			{Description: "Notary fees", Amount: num.MakeAmount(5000, 2)},
+		}
+		require.NoError(t, inv.Calculate())
+		e, err := acct.NewInvoiceEntry(inv, testChart(), acct.BookSales)
+		require.NoError(t, err)
+		assert.NoError(t, e.Validate())
+		assert.Contains(t, postings(e), posting{account: "4709", credit: "50.00"})
+		assert.Contains(t, postings(e), posting{account: "430001", debit: "830.95"})
+	})
+	t.Run("rounding differences", func(t *testing.T) {
+		inv := testInvoice(t)
+		inv.Discounts = nil
+		inv.Charges = nil
+		inv.Payment = nil
+		inv.Lines = nil
+		for i := 0; i < 3; i++ {
+			inv.Lines = append(inv.Lines, &bill.Line{
+				Quantity: num.MakeAmount(1, 0),
+				Item: &org.Item{
+					Name:  "Books",
+					Price: num.MakeAmount(3333, 4),
+				},
+				Taxes: tax.Set{
+					{Category: tax.CategoryVAT, Rate: tax.RateReduced},
+				},
+			})
+		}
+		require.NoError(t, inv.Calculate())
+		e, err := acct.NewInvoiceEntry(inv, testChart(), acct.BookSales)
+		require.NoError(t, err)
+		assert.NoError(t, e.Validate())
+		assert.Equal(t, []posting{
+			{account: "700", credit: "0.33"},
+			{account: "700", credit: "0.33"},
+			{account: "700", credit: "0.34"},
+			{account: "4771", credit: "0.10"},
+			{account: "430001", debit: "1.10"},
+		}, postings(e))
+	})
+	t.Run("inconsistent totals", func(t *testing.T) {
+		inv := testInvoice(t)
+		inv.Totals.Payable = inv.Totals.Payable.Add(num.MakeAmount(1000, 2))
+		_, err := acct.NewInvoiceEntry(inv, testChart(), acct.BookSales)
+		assert.ErrorContains(t, err, "entry not balanced by 10.00")
+	})
+	t.Run("missing accounts", func(t *testing.T) {
+		inv := testInvoice(t)
+		chart := testChart()
+		chart.Taxes = chart.Taxes[1:]
+		_, err := acct.NewInvoiceEntry(inv, chart, acct.BookSales)
+		assert.ErrorContains(t, err, "tax VAT 21.0%: missing account")
+
+		chart = testChart()
+		chart.Receivable = ""
+		chart.Parties = nil
+		_, err = acct.NewInvoiceEntry(inv, chart, acct.BookSales)
+		assert.ErrorContains(t, err, "customer: missing account")
+	})
+	t.Run("invalid arguments", func(t *testing.T) {
+		_, err := acct.NewInvoiceEntry(&bill.Invoice{}, testChart(), acct.BookSales)
+		assert.ErrorContains(t, err, "calculated invoice required")
+		_, err = acct.NewInvoiceEntry(testInvoice(t), nil, acct.BookSales)
+		assert.ErrorContains(t, err, "chart required")
+		_, err = acct.NewInvoiceEntry(testInvoice(t), testChart(), "foo")
+		assert.ErrorContains(t, err, "invalid book 'foo'")
+	})
+}
+
+func TestChartPartyAccountFor(t *testing.T) {
+	id := uuid.V7()
+	chart := testChart()
+	chart.Parties = append(chart.Parties, &acct.PartyAccount{UUID: id, Account: "400002"})
+	assert.Equal(t, cbc.Code("400002"), chart.PartyAccountFor(acct.BookPurchases, &org.Party{Identify: uuid.Identify{UUID: id}}))
+	assert.Equal(t, cbc.Code("400"), chart.PartyAccountFor(acct.BookPurchases, &org.Party{Name: "Other"}))
+	assert.Equal(t, cbc.Code("430"), chart.PartyAccountFor(acct.BookSales, nil))
+}
@@ -10,11 +10,14 @@ package addons
 import (
 	// Import all the addons to ensure they're ready to use.
 	_ "github.com/invopop/gobl/addons/br/nfse"
+	_ "github.com/invopop/gobl/addons/ch/qrbill"
 	_ "github.com/invopop/gobl/addons/co/dian"
 	_ "github.com/invopop/gobl/addons/de/xrechnung"
 	_ "github.com/invopop/gobl/addons/es/facturae"
 	_ "github.com/invopop/gobl/addons/es/tbai"
 	_ "github.com/invopop/gobl/addons/eu/en16931"
+	_ "github.com/invopop/gobl/addons/eu/intrastat"
+	_ "github.com/invopop/gobl/addons/eu/peppol"
 	_ "github.com/invopop/gobl/addons/gr/mydata"
 	_ "github.com/invopop/gobl/addons/it/sdi"
 	_ "github.com/invopop/gobl/addons/mx/cfdi"
@@ -0,0 +1,287 @@
+package qrbill
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/invopop/gobl/bill"
+	"github.com/invopop/gobl/cbc"
+	"github.com/invopop/gobl/currency"
+	"github.com/invopop/gobl/num"
+	"github.com/invopop/gobl/org"
+	"github.com/invopop/gobl/pkg/qr"
+	"github.com/invopop/validation"
+)
+
+// Header of the Swiss Payment Code.
+const (
+	QRType   = "SPC"
+	Version  = "0200"
+	Coding   = "1" // UTF-8 restricted to the Latin character set
+	Trailer  = "EPD"
+	AddrType = "S" // structured addresses
+)
+
+// Reference types.
+const (
+	ReferenceTypeQR       = "QRR"
+	ReferenceTypeCreditor = "SCOR"
+	ReferenceTypeNone     = "NON"
+)
+
+// Limits defined by the guidelines.
+const (
+	MaxNameLength     = 70
+	MaxStreetLength   = 70
+	MaxNumberLength   = 16
+	MaxPostCodeLength = 16
+	MaxTownLength     = 35
+	MaxMessageLength  = 140
+	MaxPayloadLength  = 997
+)
+
+var (
+	minAmount = num.MakeAmount(1, 2)
+	maxAmount = num.MakeAmount(99999999999, 2)
+)
+
+// Address of the creditor or debtor of a QR-bill.
+type Address struct {
+	Name     string
+	Street   string
+	Number   string
+	PostCode string
+	Town     string
+	Country  string
+}
+
+// Bill contains the data of the payment part of a QR-bill, which is
+// encoded as the Swiss Payment Code in the QR code.
+type Bill struct {
+	// IBAN or QR-IBAN of the creditor's account.
+	IBAN     string
+	Creditor *Address
+	// Amount to pay, or empty to be completed by the debtor.
+	Amount   *num.Amount
+	Currency currency.Code
+	// Debtor, or empty to be completed by hand.
+	Debtor        *Address
+	ReferenceType string
+	Reference     string
+	// Message with unstructured information for the debtor.
+	Message string
+}
+
+// FromInvoice prepares the bill to pay the invoice's due amount, or the
+// payable amount if there were no advances, to the account of the first
+// credit transfer in the payment instructions. The creditor is the
+// invoice's payee or supplier, and the debtor the customer when they have
+// an address. References that are not structured are included in the
+// message, which otherwise contains the invoice's code.
+func FromInvoice(inv *bill.Invoice) (*Bill, error) {
+	if inv.Type.In(bill.InvoiceTypeCreditNote) {
+		return nil, errors.New("credit notes cannot be paid")
+	}
+	if inv.Totals == nil {
+		return nil, errors.New("missing totals, invoice must be calculated")
+	}
+	if inv.Payment == nil || inv.Payment.Instructions == nil {
+		return nil, errors.New("missing payment instructions")
+	}
+	instr := inv.Payment.Instructions
+	b := &Bill{
+		IBAN:     accountIBAN(instr),
+		Currency: inv.Currency,
+	}
+	if b.IBAN == "" {
+		return nil, errors.New("missing credit transfer with an IBAN")
+	}
+	creditor := inv.Supplier
+	if inv.Payment.Payee != nil {
+		creditor = inv.Payment.Payee
+	}
+	b.Creditor = newAddress(creditor)
+	if inv.Customer != nil && len(inv.Customer.Addresses) > 0 {
+		b.Debtor = newAddress(inv.Customer)
+	}
+	amount := inv.Totals.Payable
+	if inv.Totals.Due != nil {
+		amount = *inv.Totals.Due
+	}
+	b.Amount = &amount
+
+	ref := cleanReference(instr.Ref.String())
+	switch {
+	case IsQRIBAN(b.IBAN):
+		b.ReferenceType = ReferenceTypeQR
+		b.Reference = ref
+	case IsCreditorReference(ref):
+		b.ReferenceType = ReferenceTypeCreditor
+		b.Reference = ref
+	default:
+		b.ReferenceType = ReferenceTypeNone
+		b.Message = ref
+	}
+	if b.Message == "" {
+		b.Message = inv.Code.String()
+		if inv.Series != cbc.CodeEmpty {
+			b.Message = inv.Series.String() + "-" + b.Message
+		}
+	}
+	b.Normalize()
+	if err := b.Validate(); err != nil {
+		return nil, err
+	}
+	return b, nil
+}
+
+func newAddress(p *org.Party) *Address {
+	if p == nil {
+		return nil
+	}
+	a := &Address{Name: p.Name}
+	if len(p.Addresses) > 0 {
+		addr := p.Addresses[0]
+		a.Street = addr.Street
+		a.Number = addr.Number
+		a.PostCode = addr.Code.String()
+		a.Town = addr.Locality
+		a.Country = addr.Country.String()
+	}
+	return a
+}
+
+// Normalize removes spaces from the account and references, and rounds
+// the amount to cents.
+func (b *Bill) Normalize() {
+	b.IBAN = strings.ToUpper(strings.ReplaceAll(b.IBAN, " ", ""))
+	b.Reference = strings.ToUpper(strings.ReplaceAll(b.Reference, " ", ""))
+	b.Message = strings.TrimSpace(b.Message)
+	if b.Amount != nil {
+		a := b.Amount.Rescale(2)
+		b.Amount = &a
+	}
+}
+
+// Validate checks the bill against the rules and limits of the
+// guidelines.
+func (b *Bill) Validate() error {
+	err := validation.ValidateStruct(b,
+		validation.Field(&b.IBAN,
+			validation.Required,
+			validation.By(checkIBAN),
+		),
+		validation.Field(&b.Creditor, validation.Required),
+		validation.Field(&b.Amount,
+			num.Min(minAmount),
+			num.Max(maxAmount),
+		),
+		validation.Field(&b.Currency,
+			validation.Required,
+			validation.In(currency.CHF, currency.EUR),
+		),
+		validation.Field(&b.Debtor),
+		validation.Field(&b.ReferenceType,
+			validation.Required,
+			validation.When(
+				IsQRIBAN(b.IBAN),
+				validation.In(ReferenceTypeQR).Error("must be QRR with a QR-IBAN"),
+			).Else(
+				validation.In(ReferenceTypeCreditor, ReferenceTypeNone).Error("must be SCOR or NON without a QR-IBAN"),
+			),
+		),
+		validation.Field(&b.Reference,
+			validation.When(
+				b.ReferenceType == ReferenceTypeQR,
+				validation.Required,
+				validation.By(func(any) error {
+					if !IsQRReference(b.Reference) {
+						return errors.New("must be a valid QR reference")
+					}
+					return nil
+				}),
+			),
+			validation.When(
+				b.ReferenceType == ReferenceTypeCreditor,
+				validation.Required,
+				validation.By(func(any) error {
+					if !IsCreditorReference(b.Reference) {
+						return errors.New("must be a valid creditor reference")
+					}
+					return nil
+				}),
+			),
+			validation.When(
+				b.ReferenceType == ReferenceTypeNone,
+				validation.Empty,
+			),
+		),
+		validation.Field(&b.Message, validation.RuneLength(0, MaxMessageLength)),
+	)
+	if err != nil {
+		return err
+	}
+	if n := len([]rune(b.Payload())); n > MaxPayloadLength {
+		return fmt.Errorf("payload of %d characters exceeds the maximum of %d", n, MaxPayloadLength)
+	}
+	return nil
+}
+
+// Validate checks the address contains the fields required for
+// structured addresses.
+func (a *Address) Validate() error {
+	return validation.ValidateStruct(a,
+		validation.Field(&a.Name,
+			validation.Required,
+			validation.RuneLength(0, MaxNameLength),
+		),
+		validation.Field(&a.Street, validation.RuneLength(0, MaxStreetLength)),
+		validation.Field(&a.Number, validation.RuneLength(0, MaxNumberLength)),
+		validation.Field(&a.PostCode,
+			validation.Required,
+			validation.RuneLength(0, MaxPostCodeLength),
+		),
+		validation.Field(&a.Town,
+			validation.Required,
+			validation.RuneLength(0, MaxTownLength),
+		),
+		validation.Field(&a.Country,
+			validation.Required,
+			validation.Length(2, 2),
+		),
+	)
+}
+
+// Payload provides the Swiss Payment Code to encode in the QR code, with
+// one field per line.
+func (b *Bill) Payload() string {
+	amount := ""
+	if b.Amount != nil {
+		amount = b.Amount.String()
+	}
+	lines := []string{QRType, Version, Coding, b.IBAN}
+	lines = append(lines, b.Creditor.lines()...)
+	lines = append(lines, "", "", "", "", "", "", "") // ultimate creditor, reserved
+	lines = append(lines, amount, b.Currency.String())
+	lines = append(lines, b.Debtor.lines()...)
+	lines = append(lines, b.ReferenceType, b.Reference, b.Message, Trailer)
+	return strings.Join(lines, "\n")
+}
+
+func (a *Address) lines() []string {
+	if a == nil {
+		return []string{"", "", "", "", "", "", ""}
+	}
+	return []string{AddrType, a.Name, a.Street, a.Number, a.PostCode, a.Town, a.Country}
+}
+
+// QR encodes the payload using the medium error correction level
+// required by the guidelines. The Swiss cross is only added to the
+// images of the payment part.
+func (b *Bill) QR() (*qr.Code, error) {
+	if err := b.Validate(); err != nil {
+		return nil, err
+	}
+	return qr.Encode([]byte(b.Payload()), qr.LevelM)
+}
@@ -0,0 +1,280 @@
+package qrbill_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/invopop/gobl/addons/ch/qrbill"
+	"github.com/invopop/gobl/bill"
+	"github.com/invopop/gobl/i18n"
+	"github.com/invopop/gobl/num"
+	"github.com/invopop/gobl/pay"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// Example from the Swiss Implementation Guidelines for the QR-bill.
+const samplePayload = `SPC
+0200
+1
+CH4431999123000889012
+S
+Robert Schneider AG
+Rue du Lac
+1268
+2501
+Biel
+CH
+
+
+
+
+
+
+
+1949.75
+CHF
+S
+Pia-Maria Rutschmann-Schnyder
+Grosse Marktgasse
+28
+9400
+Rorschach
+CH
+QRR
+210000000003139471430009017
+Order dated 18.06.2020
+EPD`
+
+func sampleBill() *qrbill.Bill {
+	amount := num.MakeAmount(194975, 2)
+	return &qrbill.Bill{
+		IBAN: "CH4431999123000889012",
+		Creditor: &qrbill.Address{
+			Name:     "Robert Schneider AG",
+			Street:   "Rue du Lac",
+			Number:   "1268",
+			PostCode: "2501",
+			Town:     "Biel",
+			Country:  "CH",
+		},
+		Amount:   &amount,
+		Currency: "CHF",
+		Debtor: &qrbill.Address{
+			Name:     "Pia-Maria Rutschmann-Schnyder",
+			Street:   "Grosse Marktgasse",
+			Number:   "28",
+			PostCode: "9400",
+			Town:     "Rorschach",
+			Country:  "CH",
+		},
+		ReferenceType: qrbill.ReferenceTypeQR,
+		Reference:     "210000000003139471430009017",
+		Message:       "Order dated 18.06.2020",
+	}
+}
+
+func TestBillPayload(t *testing.T) {
+	b := sampleBill()
+	require.NoError(t, b.Validate())
+	assert.Equal(t, samplePayload, b.Payload())
+
+	t.Run("without debtor or amount", func(t *testing.T) {
+		b := sampleBill()
+		b.Debtor = nil
+		b.Amount = nil
+		require.NoError(t, b.Validate())
+		lines := strings.Split(b.Payload(), "\n")
+		assert.Len(t, lines, 31)
+		assert.Equal(t, "", lines[18])
+		assert.Equal(t, "CHF", lines[19])
+		assert.Equal(t, []string{"", "", "", "", "", "", ""}, lines[20:27])
+	})
+
+	t.Run("qr code", func(t *testing.T) {
+		code, err := sampleBill().QR()
+		require.NoError(t, err)
+		assert.Equal(t, 17+4*code.Version, code.Size)
+	})
+}
+
+func TestBillValidate(t *testing.T) {
+	tests := []struct {
+		name string
+		fn   func(b *qrbill.Bill)
+		err  string
+	}{
+		{
+			name: "missing creditor",
+			fn:   func(b *qrbill.Bill) { b.Creditor = nil },
+			err:  "Creditor: cannot be blank",
+		},
+		{
+			name: "invalid iban",
+			fn:   func(b *qrbill.Bill) { b.IBAN = "DE89370400440532013000" },
+			err:  "IBAN: must be a valid Swiss or Liechtenstein IBAN",
+		},
+		{
+			name: "currency",
+			fn:   func(b *qrbill.Bill) { b.Currency = "USD" },
+			err:  "Currency: must be a valid value",
+		},
+		{
+			name: "amount too large",
+			fn: func(b *qrbill.Bill) {
+				a := num.MakeAmount(100000000000, 2)
+				b.Amount = &a
+			},
+			err: "Amount: must be no greater than 999999999.99",
+		},
+		{
+			name: "qr reference without qr-iban",
+			fn:   func(b *qrbill.Bill) { b.IBAN = "CH9300762011623852957" },
+			err:  "ReferenceType: must be SCOR or NON without a QR-IBAN",
+		},
+		{
+			name: "missing reference",
+			fn:   func(b *qrbill.Bill) { b.Reference = "" },
+			err:  "Reference: cannot be blank",
+		},
+		{
+			name: "reference with none type",
+			fn: func(b *qrbill.Bill) {
+				b.IBAN = "CH9300762011623852957"
+				b.ReferenceType = qrbill.ReferenceTypeNone
+			},
+			err: "Reference: must be blank",
+		},
+		{
+			name: "town too long",
+			fn:   func(b *qrbill.Bill) { b.Creditor.Town = strings.Repeat("ü", 36) },
+			err:  "Town: the length must be no more than 35",
+		},
+		{
+			name: "message too long",
+			fn:   func(b *qrbill.Bill) { b.Message = strings.Repeat("x", 141) },
+			err:  "Message: the length must be no more than 140",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b := sampleBill()
+			tt.fn(b)
+			assert.ErrorContains(t, b.Validate(), tt.err)
+		})
+	}
+}
+
+func TestFromInvoice(t *testing.T) {
+	inv := testInvoice(t)
+	require.NoError(t, inv.Calculate())
+	require.NoError(t, inv.Validate())
+
+	b, err := qrbill.FromInvoice(inv)
+	require.NoError(t, err)
+	assert.Equal(t, "CH4431999123000889012", b.IBAN)
+	assert.Equal(t, "Robert Schneider AG", b.Creditor.Name)
+	assert.Equal(t, "Biel", b.Creditor.Town)
+	assert.Equal(t, "1949.75", b.Amount.String())
+	assert.Equal(t, "Rorschach", b.Debtor.Town)
+	assert.Equal(t, qrbill.ReferenceTypeQR, b.ReferenceType)
+	assert.Equal(t, "210000000003139471430009017", b.Reference)
+	assert.Equal(t, "1000", b.Message)
+
+	t.Run("creditor reference", func(t *testing.T) {
+		inv := testInvoice(t)
+		inv.Payment.Instructions.CreditTransfer[0].IBAN = "CH9300762011623852957"
+		inv.Payment.Instructions.Ref = "RF18 5390 0754 7034"
+		require.NoError(t, inv.Calculate())
+		b, err := qrbill.FromInvoice(inv)
+		require.NoError(t, err)
+		assert.Equal(t, qrbill.ReferenceTypeCreditor, b.ReferenceType)
+		assert.Equal(t, "RF18539007547034", b.Reference)
+	})
+
+	t.Run("without reference", func(t *testing.T) {
+		inv := testInvoice(t)
+		inv.Series = "INV"
+		inv.Customer.Addresses = nil
+		inv.Payment.Instructions.CreditTransfer[0].IBAN = "CH9300762011623852957"
+		inv.Payment.Instructions.Ref = ""
+		require.NoError(t, inv.Calculate())
+		b, err := qrbill.FromInvoice(inv)
+		require.NoError(t, err)
+		assert.Equal(t, qrbill.ReferenceTypeNone, b.ReferenceType)
+		assert.Empty(t, b.Reference)
+		assert.Equal(t, "INV-1000", b.Message)
+		assert.Nil(t, b.Debtor)
+	})
+
+	t.Run("due amount", func(t *testing.T) {
+		inv := testInvoice(t)
+		inv.Payment.Advances = []*pay.Advance{
+			{Description: "Deposit", Amount: num.MakeAmount(94975, 2)},
+		}
+		require.NoError(t, inv.Calculate())
+		b, err := qrbill.FromInvoice(inv)
+		require.NoError(t, err)
+		assert.Equal(t, "1000.00", b.Amount.String())
+	})
+
+	t.Run("credit note", func(t *testing.T) {
+		inv := testInvoice(t)
+		inv.Type = bill.InvoiceTypeCreditNote
+		_, err := qrbill.FromInvoice(inv)
+		assert.ErrorContains(t, err, "credit notes cannot be paid")
+	})
+
+	t.Run("not calculated", func(t *testing.T) {
+		_, err := qrbill.FromInvoice(testInvoice(t))
+		assert.ErrorContains(t, err, "missing totals")
+	})
+
+	t.Run("missing instructions", func(t *testing.T) {
+		inv := testInvoice(t)
+		inv.Payment = nil
+		require.NoError(t, inv.Calculate())
+		_, err := qrbill.FromInvoice(inv)
+		assert.ErrorContains(t, err, "missing payment instructions")
+	})
+}
+
+func TestBillSVG(t *testing.T) {
+	b := sampleBill()
+	data, err := b.SVG(i18n.EN)
+	require.NoError(t, err)
+	out := string(data)
+	assert.True(t, strings.HasPrefix(out, "<svg"))
+	assert.Contains(t, out, `width="210mm" height="105mm"`)
+	assert.Contains(t, out, "Receipt")
+	assert.Contains(t, out, "Payment part")
+	assert.Contains(t, out, "CH44 3199 9123 0008 8901 2")
+	assert.Contains(t, out, "21 00000 00003 13947 14300 09017")
+	assert.Contains(t, out, "1 949.75")
+	assert.Contains(t, out, "Pia-Maria Rutschmann-Schnyder")
+	assert.Contains(t, out, "Order dated 18.06.2020")
+
+	t.Run("german", func(t *testing.T) {
+		data, err := b.SVG(i18n.DE)
+		require.NoError(t, err)
+		assert.Contains(t, string(data), "Empfangsschein")
+		assert.Contains(t, string(data), "Zahlteil")
+	})
+
+	t.Run("blank fields", func(t *testing.T) {
+		b := sampleBill()
+		b.Amount = nil
+		b.Debtor = nil
+		data, err := b.SVG(i18n.EN)
+		require.NoError(t, err)
+		assert.Contains(t, string(data), "Payable by (name/address)")
+		assert.NotContains(t, string(data), "1 949.75")
+	})
+
+	t.Run("invalid", func(t *testing.T) {
+		b := sampleBill()
+		b.Creditor = nil
+		_, err := b.SVG(i18n.EN)
+		assert.Error(t, err)
+	})
+}
@@ -0,0 +1,98 @@
+package qrbill
+
+import (
+	"errors"
+	"strings"
+
+	"github.com/invopop/gobl/cbc"
+	"github.com/invopop/gobl/pay"
+	"github.com/invopop/validation"
+)
+
+func normalizeInstructions(instr *pay.Instructions) {
+	if instr == nil {
+		return
+	}
+	for _, ct := range instr.CreditTransfer {
+		if ct != nil {
+			ct.IBAN = strings.ToUpper(strings.ReplaceAll(ct.IBAN, " ", ""))
+		}
+	}
+	instr.Ref = cbc.Code(cleanReference(instr.Ref.String()))
+}
+
+func validateInstructions(instr *pay.Instructions) error {
+	qrIBAN := IsQRIBAN(accountIBAN(instr))
+	return validation.ValidateStruct(instr,
+		validation.Field(&instr.CreditTransfer,
+			validation.When(
+				instr.Key.Has(pay.MeansKeyCreditTransfer),
+				validation.Required,
+			),
+			validation.Each(validation.By(validateCreditTransfer)),
+			validation.Skip,
+		),
+		validation.Field(&instr.Ref,
+			validation.When(
+				qrIBAN,
+				validation.Required.Error("required with a QR-IBAN"),
+				validation.By(checkQRReference),
+			),
+			validation.When(
+				!qrIBAN,
+				validation.By(checkReference),
+			),
+		),
+	)
+}
+
+func validateCreditTransfer(value any) error {
+	ct, ok := value.(*pay.CreditTransfer)
+	if !ok || ct == nil {
+		return nil
+	}
+	return validation.ValidateStruct(ct,
+		validation.Field(&ct.IBAN,
+			validation.Required,
+			validation.By(checkIBAN),
+		),
+	)
+}
+
+// accountIBAN provides the IBAN of the first credit transfer, which is
+// the one used in the QR-bill.
+func accountIBAN(instr *pay.Instructions) string {
+	for _, ct := range instr.CreditTransfer {
+		if ct != nil && ct.IBAN != "" {
+			return ct.IBAN
+		}
+	}
+	return ""
+}
+
+func checkIBAN(value any) error {
+	code, _ := value.(string)
+	if code == "" || IsIBAN(code) {
+		return nil
+	}
+	return errors.New("must be a valid Swiss or Liechtenstein IBAN")
+}
+
+func checkQRReference(value any) error {
+	code, _ := value.(cbc.Code)
+	if code == cbc.CodeEmpty || IsQRReference(code.String()) {
+		return nil
+	}
+	return errors.New("must be a valid QR reference")
+}
+
+func checkReference(value any) error {
+	code, _ := value.(cbc.Code)
+	switch {
+	case qrReferencePattern.MatchString(code.String()):
+		return errors.New("QR reference requires a QR-IBAN")
+	case creditorReferencePattern.MatchString(code.String()) && !IsCreditorReference(code.String()):
+		return errors.New("invalid creditor reference check digits")
+	}
+	return nil
+}
@@ -0,0 +1,147 @@
+package qrbill_test
+
+import (
+	"testing"
+
+	"github.com/invopop/gobl/addons/ch/qrbill"
+	"github.com/invopop/gobl/cbc"
+	"github.com/invopop/gobl/pay"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNormalizeInstructions(t *testing.T) {
+	inv := testInvoice(t)
+	require.NoError(t, inv.Calculate())
+	instr := inv.Payment.Instructions
+	assert.Equal(t, "CH4431999123000889012", instr.CreditTransfer[0].IBAN)
+	assert.Equal(t, "210000000003139471430009017", instr.Ref.String())
+
+	t.Run("free text", func(t *testing.T) {
+		inv := testInvoice(t)
+		inv.Payment.Instructions.CreditTransfer[0].IBAN = "CH93 0076 2011 6238 5295 7"
+		inv.Payment.Instructions.Ref = "Order 18 June"
+		require.NoError(t, inv.Calculate())
+		assert.Equal(t, "Order 18 June", inv.Payment.Instructions.Ref.String())
+	})
+}
+
+func TestValidateInstructions(t *testing.T) {
+	tests := []struct {
+		name string
+		iban string
+		ref  string
+		err  string
+	}{
+		{
+			name: "qr-iban with qr reference",
+			iban: "CH4431999123000889012",
+			ref:  "210000000003139471430009017",
+		},
+		{
+			name: "qr-iban without reference",
+			iban: "CH4431999123000889012",
+			err:  "ref: required with a QR-IBAN",
+		},
+		{
+			name: "qr-iban with invalid check digit",
+			iban: "CH4431999123000889012",
+			ref:  "210000000003139471430009018",
+			err:  "ref: must be a valid QR reference",
+		},
+		{
+			name: "qr-iban with creditor reference",
+			iban: "CH4431999123000889012",
+			ref:  "RF18539007547034",
+			err:  "ref: must be a valid QR reference",
+		},
+		{
+			name: "iban with creditor reference",
+			iban: "CH9300762011623852957",
+			ref:  "RF18 5390 0754 7034",
+		},
+		{
+			name: "iban without reference",
+			iban: "CH9300762011623852957",
+		},
+		{
+			name: "iban with text reference",
+			iban: "CH9300762011623852957",
+			ref:  "INV-1000",
+		},
+		{
+			name: "iban with invalid creditor reference",
+			iban: "CH9300762011623852957",
+			ref:  "RF19539007547034",
+			err:  "ref: invalid creditor reference check digits",
+		},
+		{
+			name: "iban with qr reference",
+			iban: "CH9300762011623852957",
+			ref:  "210000000003139471430009017",
+			err:  "ref: QR reference requires a QR-IBAN",
+		},
+		{
+			name: "liechtenstein iban",
+			iban: "LI21088100002324013AA",
+		},
+		{
+			name: "invalid iban check digits",
+			iban: "CH9300762011623852958",
+			err:  "iban: must be a valid Swiss or Liechtenstein IBAN",
+		},
+		{
+			name: "foreign iban",
+			iban: "DE89370400440532013000",
+			err:  "iban: must be a valid Swiss or Liechtenstein IBAN",
+		},
+		{
+			name: "missing iban",
+			err:  "iban: cannot be blank",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			inv := testInvoice(t)
+			inv.Payment.Instructions.CreditTransfer[0].IBAN = tt.iban
+			inv.Payment.Instructions.Ref = ""
+			if tt.ref != "" {
+				inv.Payment.Instructions.Ref = cbc.Code(tt.ref)
+			}
+			require.NoError(t, inv.Calculate())
+			err := inv.Validate()
+			if tt.err == "" {
+				assert.NoError(t, err)
+				return
+			}
+			assert.ErrorContains(t, err, tt.err)
+		})
+	}
+
+	t.Run("missing credit transfer", func(t *testing.T) {
+		inv := testInvoice(t)
+		inv.Payment.Instructions.CreditTransfer = nil
+		inv.Payment.Instructions.Ref = ""
+		require.NoError(t, inv.Calculate())
+		assert.ErrorContains(t, inv.Validate(), "credit_transfer: cannot be blank")
+	})
+
+	t.Run("other means", func(t *testing.T) {
+		inv := testInvoice(t)
+		inv.Payment.Instructions = &pay.Instructions{Key: pay.MeansKeyCash}
+		require.NoError(t, inv.Calculate())
+		assert.NoError(t, inv.Validate())
+	})
+}
+
+func TestReferences(t *testing.T) {
+	assert.True(t, qrbill.IsIBAN("CH9300762011623852957"))
+	assert.False(t, qrbill.IsQRIBAN("CH9300762011623852957"))
+	assert.True(t, qrbill.IsQRIBAN("CH4431999123000889012"))
+	assert.False(t, qrbill.IsIBAN("DE89370400440532013000"))
+	assert.True(t, qrbill.IsQRReference("210000000003139471430009017"))
+	assert.True(t, qrbill.IsQRReference("000000000000000000000012347"))
+	assert.False(t, qrbill.IsQRReference("21000000000313947143000901"))
+	assert.True(t, qrbill.IsCreditorReference("RF18539007547034"))
+	assert.False(t, qrbill.IsCreditorReference("RF18539007547035"))
+}
@@ -0,0 +1,71 @@
+package qrbill
+
+import (
+	"github.com/invopop/gobl/bill"
+	"github.com/invopop/gobl/currency"
+	"github.com/invopop/gobl/org"
+	"github.com/invopop/validation"
+)
+
+func validateInvoice(inv *bill.Invoice) error {
+	payee := inv.Payment != nil && inv.Payment.Payee != nil
+	return validation.ValidateStruct(inv,
+		validation.Field(&inv.Currency,
+			validation.In(currency.CHF, currency.EUR),
+		),
+		// the creditor of the QR-bill
+		validation.Field(&inv.Supplier,
+			validation.When(
+				!payee,
+				validation.By(validateCreditor),
+			),
+			validation.Skip,
+		),
+		validation.Field(&inv.Payment,
+			validation.When(
+				payee,
+				validation.By(validatePayment),
+			),
+			validation.Skip,
+		),
+	)
+}
+
+func validatePayment(value any) error {
+	p, ok := value.(*bill.PaymentDetails)
+	if !ok || p == nil {
+		return nil
+	}
+	return validation.ValidateStruct(p,
+		validation.Field(&p.Payee,
+			validation.By(validateCreditor),
+			validation.Skip,
+		),
+	)
+}
+
+func validateCreditor(value any) error {
+	party, ok := value.(*org.Party)
+	if !ok || party == nil {
+		return nil
+	}
+	return validation.ValidateStruct(party,
+		validation.Field(&party.Addresses,
+			validation.Required,
+			validation.Each(validation.By(validateCreditorAddress)),
+			validation.Skip,
+		),
+	)
+}
+
+func validateCreditorAddress(value any) error {
+	a, ok := value.(*org.Address)
+	if !ok || a == nil {
+		return nil
+	}
+	return validation.ValidateStruct(a,
+		validation.Field(&a.Code, validation.Required),
+		validation.Field(&a.Locality, validation.Required),
+		validation.Field(&a.Country, validation.Required),
+	)
+}
@@ -0,0 +1,68 @@
+package qrbill_test
+
+import (
+	"testing"
+
+	"github.com/invopop/gobl/currency"
+	"github.com/invopop/gobl/num"
+	"github.com/invopop/gobl/org"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestValidateInvoice(t *testing.T) {
+	t.Run("valid", func(t *testing.T) {
+		inv := testInvoice(t)
+		require.NoError(t, inv.Calculate())
+		assert.NoError(t, inv.Validate())
+	})
+
+	t.Run("currency", func(t *testing.T) {
+		inv := testInvoice(t)
+		inv.Currency = "USD"
+		inv.ExchangeRates = []*currency.ExchangeRate{
+			{From: "USD", To: "CHF", Amount: num.MakeAmount(88, 2)},
+		}
+		require.NoError(t, inv.Calculate())
+		assert.ErrorContains(t, inv.Validate(), "currency: must be a valid value")
+	})
+
+	t.Run("euros", func(t *testing.T) {
+		inv := testInvoice(t)
+		inv.Currency = "EUR"
+		inv.ExchangeRates = []*currency.ExchangeRate{
+			{From: "EUR", To: "CHF", Amount: num.MakeAmount(94, 2)},
+		}
+		require.NoError(t, inv.Calculate())
+		assert.NoError(t, inv.Validate())
+	})
+
+	t.Run("missing supplier address", func(t *testing.T) {
+		inv := testInvoice(t)
+		inv.Supplier.Addresses = nil
+		require.NoError(t, inv.Calculate())
+		assert.ErrorContains(t, inv.Validate(), "supplier: (addresses: cannot be blank.)")
+	})
+
+	t.Run("incomplete supplier address", func(t *testing.T) {
+		inv := testInvoice(t)
+		inv.Supplier.Addresses[0].Code = ""
+		require.NoError(t, inv.Calculate())
+		assert.ErrorContains(t, inv.Validate(), "supplier: (addresses: (0: (code: cannot be blank.).).)")
+	})
+
+	t.Run("payee address", func(t *testing.T) {
+		inv := testInvoice(t)
+		inv.Supplier.Addresses = nil
+		inv.Payment.Payee = &org.Party{
+			Name: "Factoring AG",
+			Addresses: []*org.Address{
+				{Locality: "Zürich", Country: "CH"},
+			},
+		}
+		require.NoError(t, inv.Calculate())
+		err := inv.Validate()
+		assert.ErrorContains(t, err, "payment: (payee: (addresses: (0: (code: cannot be blank.).).).)")
+		assert.NotContains(t, err.Error(), "supplier")
+	})
+}
@@ -0,0 +1,75 @@
+// Package qrbill defines an addon for Swiss QR-bills, the payment slips
+// with a QR code that replaced the orange and red inpayment slips in
+// Switzerland and Liechtenstein.
+package qrbill
+
+import (
+	"github.com/invopop/gobl/bill"
+	"github.com/invopop/gobl/cbc"
+	"github.com/invopop/gobl/i18n"
+	"github.com/invopop/gobl/pay"
+	"github.com/invopop/gobl/pkg/here"
+	"github.com/invopop/gobl/tax"
+)
+
+const (
+	// V2 is the key for version 2 of the Swiss Implementation Guidelines
+	// for the QR-bill.
+	V2 cbc.Key = "ch-qrbill-v2"
+)
+
+func init() {
+	tax.RegisterAddonDef(newAddon())
+}
+
+func newAddon() *tax.AddonDef {
+	return &tax.AddonDef{
+		Key: V2,
+		Name: i18n.String{
+			i18n.EN: "Swiss QR-bill",
+			i18n.DE: "Schweizer QR-Rechnung",
+			i18n.FR: "QR-facture suisse",
+			i18n.IT: "QR-fattura svizzera",
+		},
+		Description: i18n.String{
+			i18n.EN: here.Doc(`
+				Support for the Swiss QR-bill defined by the Swiss Implementation Guidelines
+				version 2, used to pay invoices in CHF or EUR to accounts in Switzerland and
+				Liechtenstein.
+
+				Credit transfer instructions must use a Swiss or Liechtenstein IBAN. When the
+				IBAN is a QR-IBAN, the instructions' reference must be a 27 digit QR reference
+				with a valid check digit. Other IBANs may be used with an ISO 11649 creditor
+				reference starting with "RF", or any other text that will be included as an
+				unstructured message. Spaces are removed from structured references.
+
+				The supplier, or payee when present, must have an address with a postal code,
+				locality, and country so that it can be printed as the creditor.
+
+				The Swiss Payment Code of the QR code and the payment part can be generated
+				from calculated invoices with the "Bill" type.
+
+				For more information, visit [www.six-group.com](https://www.six-group.com/en/products-services/banking-services/payment-standardization/standards/qr-bill.html).
+			`),
+		},
+		Normalizer: normalize,
+		Validator:  validate,
+	}
+}
+
+func normalize(doc any) {
+	switch obj := doc.(type) {
+	case *pay.Instructions:
+		normalizeInstructions(obj)
+	}
+}
+
+func validate(doc any) error {
+	switch obj := doc.(type) {
+	case *bill.Invoice:
+		return validateInvoice(obj)
+	case *pay.Instructions:
+		return validateInstructions(obj)
+	}
+	return nil
+}
@@ -0,0 +1,79 @@
+package qrbill_test
+
+import (
+	"testing"
+
+	_ "github.com/invopop/gobl"
+	"github.com/invopop/gobl/addons/ch/qrbill"
+	"github.com/invopop/gobl/bill"
+	"github.com/invopop/gobl/cal"
+	"github.com/invopop/gobl/num"
+	"github.com/invopop/gobl/org"
+	"github.com/invopop/gobl/pay"
+	"github.com/invopop/gobl/tax"
+)
+
+func testInvoice(t *testing.T) *bill.Invoice {
+	t.Helper()
+	return &bill.Invoice{
+		Regime:    tax.WithRegime("CH"),
+		Addons:    tax.WithAddons(qrbill.V2),
+		IssueDate: cal.MakeDate(2024, 6, 18),
+		Currency:  "CHF",
+		Code:      "1000",
+		Supplier: &org.Party{
+			Name: "Robert Schneider AG",
+			TaxID: &tax.Identity{
+				Country: "CH",
+				Code:    "E100416306",
+			},
+			Addresses: []*org.Address{
+				{
+					Street:   "Rue du Lac",
+					Number:   "1268",
+					Code:     "2501",
+					Locality: "Biel",
+					Country:  "CH",
+				},
+			},
+		},
+		Customer: &org.Party{
+			Name: "Pia-Maria Rutschmann-Schnyder",
+			Addresses: []*org.Address{
+				{
+					Street:   "Grosse Marktgasse",
+					Number:   "28",
+					Code:     "9400",
+					Locality: "Rorschach",
+					Country:  "CH",
+				},
+			},
+		},
+		Lines: []*bill.Line{
+			{
+				Quantity: num.MakeAmount(1, 0),
+				Item: &org.Item{
+					Name:  "Garden furniture",
+					Price: num.MakeAmount(180365, 2),
+				},
+				Taxes: tax.Set{
+					{
+						Category: "VAT",
+						Rate:     "standard",
+					},
+				},
+			},
+		},
+		Payment: &bill.PaymentDetails{
+			Instructions: &pay.Instructions{
+				Key: pay.MeansKeyCreditTransfer,
+				Ref: "21 00000 00003 13947 14300 09017",
+				CreditTransfer: []*pay.CreditTransfer{
+					{
+						IBAN: "CH44 3199 9123 0008 8901 2",
+					},
+				},
+			},
+		},
+	}
+}
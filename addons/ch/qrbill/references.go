@@ -0,0 +1,116 @@
+package qrbill
+
+import (
+	"math/big"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+var (
+	ibanPattern              = regexp.MustCompile(`^(CH|LI)[0-9]{2}[0-9]{5}[A-Z0-9]{12}$`)
+	qrReferencePattern       = regexp.MustCompile(`^[0-9]{27}$`)
+	creditorReferencePattern = regexp.MustCompile(`^RF[0-9]{2}[A-Z0-9]{1,21}$`)
+)
+
+// QR-IBANs are identified by an institution ID in this range.
+const (
+	qrIIDMin = 30000
+	qrIIDMax = 31999
+)
+
+// mod10Table is used to calculate the check digit of QR references using
+// the recursive modulo 10 algorithm.
+var mod10Table = [10]int{0, 9, 4, 6, 8, 2, 7, 1, 3, 5}
+
+// IsIBAN returns true if the code is a Swiss or Liechtenstein IBAN with
+// valid check digits.
+func IsIBAN(code string) bool {
+	return ibanPattern.MatchString(code) && isMod97(code)
+}
+
+// IsQRIBAN returns true if the code is a valid IBAN reserved for payments
+// with QR references.
+func IsQRIBAN(code string) bool {
+	if !IsIBAN(code) {
+		return false
+	}
+	iid, _ := strconv.Atoi(code[4:9])
+	return iid >= qrIIDMin && iid <= qrIIDMax
+}
+
+// IsQRReference returns true if the code is a 27 digit QR reference with
+// a valid check digit.
+func IsQRReference(code string) bool {
+	if !qrReferencePattern.MatchString(code) {
+		return false
+	}
+	return checkDigit(code[:26]) == int(code[26]-'0')
+}
+
+// IsCreditorReference returns true if the code is a valid ISO 11649
+// creditor reference.
+func IsCreditorReference(code string) bool {
+	return creditorReferencePattern.MatchString(code) && isMod97(code)
+}
+
+// checkDigit calculates the recursive modulo 10 check digit of the
+// digits.
+func checkDigit(digits string) int {
+	carry := 0
+	for _, r := range digits {
+		carry = mod10Table[(carry+int(r-'0'))%10]
+	}
+	return (10 - carry) % 10
+}
+
+// isMod97 checks the digits of IBANs and creditor references, calculated
+// after moving the first four characters to the end and replacing letters
+// with numbers.
+func isMod97(code string) bool {
+	var sb strings.Builder
+	for _, r := range code[4:] + code[:4] {
+		switch {
+		case r >= '0' && r <= '9':
+			sb.WriteRune(r)
+		case r >= 'A' && r <= 'Z':
+			sb.WriteString(strconv.Itoa(int(r-'A') + 10))
+		default:
+			return false
+		}
+	}
+	n, ok := new(big.Int).SetString(sb.String(), 10)
+	if !ok {
+		return false
+	}
+	return new(big.Int).Mod(n, big.NewInt(97)).Int64() == 1
+}
+
+// cleanReference removes spaces from structured references, leaving other
+// text as is.
+func cleanReference(ref string) string {
+	s := strings.ToUpper(strings.ReplaceAll(ref, " ", ""))
+	if qrReferencePattern.MatchString(s) || creditorReferencePattern.MatchString(s) {
+		return s
+	}
+	return ref
+}
+
+// formatReference groups the characters of references to make them easier
+// to read: QR references in blocks of five from the right, and others in
+// blocks of four from the left.
+func formatReference(ref string) string {
+	if qrReferencePattern.MatchString(ref) {
+		return ref[:2] + " " + group(ref[2:], 5)
+	}
+	return group(ref, 4)
+}
+
+// group splits the text into blocks of n characters separated by spaces.
+func group(s string, n int) string {
+	parts := make([]string, 0, len(s)/n+1)
+	for i := 0; i < len(s); i += n {
+		parts = append(parts, s[i:min(i+n, len(s))])
+	}
+	return strings.Join(parts, " ")
+}
@@ -0,0 +1,266 @@
+package qrbill
+
+import (
+	"bytes"
+	"fmt"
+	"html"
+	"strings"
+
+	"github.com/invopop/gobl/i18n"
+	"github.com/invopop/gobl/num"
+)
+
+// Dimensions in millimetres of the payment part, including the receipt,
+// according to the style guide of the QR-bill.
+const (
+	svgWidth        = 210.0
+	svgHeight       = 105.0
+	receiptWidth    = 62.0
+	margin          = 5.0
+	qrX             = receiptWidth + margin
+	qrY             = 17.0
+	qrSize          = 46.0
+	crossSize       = 7.0
+	amountY         = 68.0
+	infoX           = 118.0
+	acceptanceY     = 82.0
+	ptToMM          = 0.3528
+	fontFamily      = "Helvetica, Arial, 'Liberation Sans', sans-serif"
+	strokeDasharray = "1 0.5"
+)
+
+var labels = map[string]i18n.String{
+	"receipt": {
+		i18n.EN: "Receipt",
+		i18n.DE: "Empfangsschein",
+		i18n.FR: "Récépissé",
+		i18n.IT: "Ricevuta",
+	},
+	"payment-part": {
+		i18n.EN: "Payment part",
+		i18n.DE: "Zahlteil",
+		i18n.FR: "Section paiement",
+		i18n.IT: "Sezione pagamento",
+	},
+	"account": {
+		i18n.EN: "Account / Payable to",
+		i18n.DE: "Konto / Zahlbar an",
+		i18n.FR: "Compte / Payable à",
+		i18n.IT: "Conto / Pagabile a",
+	},
+	"reference": {
+		i18n.EN: "Reference",
+		i18n.DE: "Referenz",
+		i18n.FR: "Référence",
+		i18n.IT: "Riferimento",
+	},
+	"information": {
+		i18n.EN: "Additional information",
+		i18n.DE: "Zusätzliche Informationen",
+		i18n.FR: "Informations supplémentaires",
+		i18n.IT: "Informazioni supplementari",
+	},
+	"payable-by": {
+		i18n.EN: "Payable by",
+		i18n.DE: "Zahlbar durch",
+		i18n.FR: "Payable par",
+		i18n.IT: "Pagabile da",
+	},
+	"payable-by-blank": {
+		i18n.EN: "Payable by (name/address)",
+		i18n.DE: "Zahlbar durch (Name/Adresse)",
+		i18n.FR: "Payable par (nom/adresse)",
+		i18n.IT: "Pagabile da (nome/indirizzo)",
+	},
+	"currency": {
+		i18n.EN: "Currency",
+		i18n.DE: "Währung",
+		i18n.FR: "Monnaie",
+		i18n.IT: "Valuta",
+	},
+	"amount": {
+		i18n.EN: "Amount",
+		i18n.DE: "Betrag",
+		i18n.FR: "Montant",
+		i18n.IT: "Importo",
+	},
+	"acceptance": {
+		i18n.EN: "Acceptance point",
+		i18n.DE: "Annahmestelle",
+		i18n.FR: "Point de dépôt",
+		i18n.IT: "Punto di accettazione",
+	},
+}
+
+// SVG provides the payment part with the receipt, measuring 210 by 105
+// millimetres, so that it can be added to the bottom of an A4 invoice.
+// Texts are in English unless German, French, or Italian are requested.
+func (b *Bill) SVG(lang i18n.Lang) ([]byte, error) {
+	q, err := b.QR()
+	if err != nil {
+		return nil, err
+	}
+	w := &svgWriter{lang: lang}
+	fmt.Fprintf(&w.buf, `<svg xmlns="http://www.w3.org/2000/svg" width="%gmm" height="%gmm" viewBox="0 0 %g %g" font-family="%s">`, svgWidth, svgHeight, svgWidth, svgHeight, fontFamily)
+	fmt.Fprintf(&w.buf, `<rect width="%g" height="%g" fill="#fff"/>`, svgWidth, svgHeight)
+	fmt.Fprintf(&w.buf, `<path d="M0 0.1H%gM%g 0V%g" stroke="#000" stroke-width="0.2" stroke-dasharray="%s"/>`, svgWidth, receiptWidth, svgHeight, strokeDasharray)
+
+	// Receipt
+	w.title(margin, margin, "receipt")
+	y := 12.0
+	y = w.section(margin, y, 6, 8, "account", b.accountLines())
+	if b.Reference != "" {
+		y = w.section(margin, y, 6, 8, "reference", []string{formatReference(b.Reference)})
+	}
+	if b.Debtor != nil {
+		w.section(margin, y, 6, 8, "payable-by", b.Debtor.textLines())
+	} else {
+		w.heading(margin, y, 6, "payable-by-blank")
+		w.corners(margin, y+1.5, 52, 20)
+	}
+	w.amount(b, margin, amountY, 6, 8, 30, 10)
+	w.text(receiptWidth-margin, acceptanceY, 6, true, "end", labels["acceptance"].In(lang))
+
+	// Payment part
+	w.title(qrX, margin, "payment-part")
+	n := float64(q.Size)
+	module := qrSize / n
+	svg := string(q.SVG())
+	fmt.Fprintf(&w.buf, `<svg x="%.3f" y="%.3f" width="%.3f" height="%.3f"%s`,
+		qrX-4*module, qrY-4*module, (n+8)*module, (n+8)*module, strings.TrimPrefix(svg, "<svg"))
+	w.cross(qrX+qrSize/2, qrY+qrSize/2)
+	w.amount(b, qrX, amountY, 8, 10, 36, 15)
+
+	y = margin
+	y = w.section(infoX, y, 8, 10, "account", b.accountLines())
+	if b.Reference != "" {
+		y = w.section(infoX, y, 8, 10, "reference", []string{formatReference(b.Reference)})
+	}
+	if b.Message != "" {
+		y = w.section(infoX, y, 8, 10, "information", []string{b.Message})
+	}
+	if b.Debtor != nil {
+		w.section(infoX, y, 8, 10, "payable-by", b.Debtor.textLines())
+	} else {
+		w.heading(infoX, y, 8, "payable-by-blank")
+		w.corners(infoX, y+1.5, 65, 25)
+	}
+	w.buf.WriteString(`</svg>`)
+	return w.buf.Bytes(), nil
+}
+
+func (b *Bill) accountLines() []string {
+	return append([]string{group(b.IBAN, 4)}, b.Creditor.textLines()...)
+}
+
+// textLines provides the lines of the address to print.
+func (a *Address) textLines() []string {
+	lines := []string{a.Name}
+	if s := strings.TrimSpace(a.Street + " " + a.Number); s != "" {
+		lines = append(lines, s)
+	}
+	town := a.PostCode + " " + a.Town
+	if a.Country != "CH" && a.Country != "LI" {
+		town = a.Country + "-" + town
+	}
+	return append(lines, town)
+}
+
+type svgWriter struct {
+	buf  bytes.Buffer
+	lang i18n.Lang
+}
+
+func (w *svgWriter) text(x, y, size float64, bold bool, anchor, s string) {
+	fmt.Fprintf(&w.buf, `<text x="%.2f" y="%.2f" font-size="%.2f"`, x, y, size*ptToMM)
+	if bold {
+		w.buf.WriteString(` font-weight="bold"`)
+	}
+	if anchor != "" {
+		fmt.Fprintf(&w.buf, ` text-anchor="%s"`, anchor)
+	}
+	fmt.Fprintf(&w.buf, `>%s</text>`, html.EscapeString(s))
+}
+
+// title adds the 11pt title at the top of each part.
+func (w *svgWriter) title(x, y float64, key string) {
+	w.text(x, y+11*ptToMM, 11, true, "", labels[key].In(w.lang))
+}
+
+// heading adds the heading at the y position, and provides the position
+// of the next line.
+func (w *svgWriter) heading(x, y, size float64, key string) float64 {
+	y += size * ptToMM
+	w.text(x, y, size, true, "", labels[key].In(w.lang))
+	return y
+}
+
+// section adds the heading and its lines, and provides the position of
+// the next section after a blank line.
+func (w *svgWriter) section(x, y, hSize, vSize float64, key string, lines []string) float64 {
+	y = w.heading(x, y, hSize, key)
+	for _, l := range lines {
+		y += (vSize + 1) * ptToMM
+		w.text(x, y, vSize, false, "", l)
+	}
+	return y + (vSize+1)*ptToMM
+}
+
+// amount adds the currency and amount headings and values at the y
+// position, or a blank field of the given size to complete the amount by
+// hand.
+func (w *svgWriter) amount(b *Bill, x, y, hSize, vSize, width, height float64) {
+	ax := x + 13
+	w.heading(x, y, hSize, "currency")
+	w.heading(ax, y, hSize, "amount")
+	vy := y + hSize*ptToMM + (vSize+1)*ptToMM
+	w.text(x, vy, vSize, false, "", b.Currency.String())
+	if b.Amount != nil {
+		w.text(ax, vy, vSize, false, "", formatAmount(*b.Amount))
+		return
+	}
+	w.corners(ax, y+hSize*ptToMM+1, width, height)
+}
+
+// corners draws the marks of a blank field to be completed by hand.
+func (w *svgWriter) corners(x, y, width, height float64) {
+	const l = 3.0
+	fmt.Fprintf(&w.buf, `<path d="M%.2f %.2fV%.2fH%.2fM%.2f %.2fH%.2fV%.2fM%.2f %.2fV%.2fH%.2fM%.2f %.2fH%.2fV%.2f" fill="none" stroke="#000" stroke-width="0.25"/>`,
+		x, y+l, y, x+l,
+		x+width-l, y, x+width, y+l,
+		x+width, y+height-l, y+height, x+width-l,
+		x+l, y+height, x, y+height-l,
+	)
+}
+
+// cross draws the Swiss cross in the center of the QR code.
+func (w *svgWriter) cross(cx, cy float64) {
+	const (
+		inner = crossSize - 1.0
+		arm   = inner * 0.64
+		bar   = inner * 0.2
+	)
+	fmt.Fprintf(&w.buf, `<rect x="%.2f" y="%.2f" width="%g" height="%g" fill="#fff"/>`, cx-crossSize/2, cy-crossSize/2, crossSize, crossSize)
+	fmt.Fprintf(&w.buf, `<rect x="%.2f" y="%.2f" width="%g" height="%g" fill="#000"/>`, cx-inner/2, cy-inner/2, inner, inner)
+	fmt.Fprintf(&w.buf, `<path d="M%.2f %.2fh%.2fv%.2fh%.2fz" fill="#fff"/>`, cx-bar/2, cy-arm/2, bar, arm, -bar)
+	fmt.Fprintf(&w.buf, `<path d="M%.2f %.2fh%.2fv%.2fh%.2fz" fill="#fff"/>`, cx-arm/2, cy-bar/2, arm, bar, -arm)
+}
+
+// formatAmount uses a space to separate thousands and a point for the
+// decimals, as required on the payment part.
+func formatAmount(a num.Amount) string {
+	s := a.String()
+	intPart, dec, _ := strings.Cut(s, ".")
+	var sb strings.Builder
+	for i, r := range intPart {
+		if i > 0 && (len(intPart)-i)%3 == 0 {
+			sb.WriteByte(' ')
+		}
+		sb.WriteRune(r)
+	}
+	if dec != "" {
+		sb.WriteByte('.')
+		sb.WriteString(dec)
+	}
+	return sb.String()
+}
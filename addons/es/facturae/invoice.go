@@ -32,6 +32,12 @@ func validateInvoice(inv *bill.Invoice) error {
 			validation.By(validateInvoiceTax),
 			validation.Skip,
 		),
+		validation.Field(&inv.Outlays,
+			validation.Each(
+				validation.By(validateInvoiceOutlay),
+			),
+			validation.Skip,
+		),
 		validation.Field(&inv.Preceding,
 			validation.When(
 				inv.Type.In(es.InvoiceCorrectionTypes...),
@@ -81,6 +87,18 @@ func validateInvoiceTax(val any) error {
 	)
 }
 
+func validateInvoiceOutlay(val any) error {
+	o, ok := val.(*bill.Outlay)
+	if !ok || o == nil {
+		return nil
+	}
+	// Reimbursable expenses in FacturaE are identified by the number
+	// of the invoice issued by the third party.
+	return validation.ValidateStruct(o,
+		validation.Field(&o.Code, validation.Required),
+	)
+}
+
 func validateInvoicePreceding(val any) error {
 	p, ok := val.(*org.DocumentRef)
 	if !ok {
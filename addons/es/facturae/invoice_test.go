@@ -44,6 +44,23 @@ func TestInvoiceValidation(t *testing.T) {
 		assert.ErrorContains(t, err, "tax: (ext: (es-facturae-doc-type: required.).)")
 	})
 
+	t.Run("outlay code", func(t *testing.T) {
+		inv := testInvoiceStandard(t)
+		inv.Outlays = []*bill.Outlay{
+			{
+				Description: "Notary fees",
+				Amount:      num.MakeAmount(5000, 2),
+			},
+		}
+		require.NoError(t, inv.Calculate())
+		err := inv.Validate()
+		assert.ErrorContains(t, err, "outlays: (0: (code: cannot be blank.).)")
+
+		inv.Outlays[0].Code = "N-123"
+		require.NoError(t, inv.Calculate())
+		assert.NoError(t, inv.Validate())
+	})
+
 }
 
 func TestInvoicePrecedingValidation(t *testing.T) {
@@ -3,6 +3,7 @@ package en16931
 import (
 	"github.com/invopop/gobl/bill"
 	"github.com/invopop/gobl/catalogues/untdid"
+	"github.com/invopop/gobl/cbc"
 	"github.com/invopop/gobl/tax"
 	"github.com/invopop/validation"
 )
@@ -40,6 +41,31 @@ var chargeKeyMap = tax.Extensions{
 	bill.ChargeKeyCleaning:  "CG",
 }
 
+// DiscountKey provides the discount key that maps to the UNTDID 5189
+// allowance code, if any.
+func DiscountKey(code tax.ExtValue) cbc.Key {
+	return keyFor(discountKeyMap, code)
+}
+
+// ChargeKey provides the charge key that maps to the UNTDID 7161 charge
+// code, if any.
+func ChargeKey(code tax.ExtValue) cbc.Key {
+	return keyFor(chargeKeyMap, code)
+}
+
+// keyFor performs a reverse lookup of the code in the map of keys.
+func keyFor(m tax.Extensions, code tax.ExtValue) cbc.Key {
+	if code == "" {
+		return cbc.KeyEmpty
+	}
+	for k, v := range m {
+		if v == code {
+			return k
+		}
+	}
+	return cbc.KeyEmpty
+}
+
 func normalizeBillDiscount(m *bill.Discount) {
 	if val, ok := discountKeyMap[m.Key]; ok {
 		m.Ext = m.Ext.Merge(tax.Extensions{
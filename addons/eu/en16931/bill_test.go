@@ -7,6 +7,7 @@ import (
 	"github.com/invopop/gobl/addons/eu/en16931"
 	"github.com/invopop/gobl/bill"
 	"github.com/invopop/gobl/catalogues/untdid"
+	"github.com/invopop/gobl/cbc"
 	"github.com/invopop/gobl/num"
 	"github.com/invopop/gobl/org"
 	"github.com/invopop/gobl/tax"
@@ -198,3 +199,10 @@ func TestNormalizeBillCharge(t *testing.T) {
 		assert.Nil(t, l.Ext)
 	})
 }
+
+func TestDiscountAndChargeKeys(t *testing.T) {
+	assert.Equal(t, bill.DiscountKeyEarlyCompletion, en16931.DiscountKey("41"))
+	assert.Equal(t, cbc.KeyEmpty, en16931.DiscountKey("999"))
+	assert.Equal(t, bill.ChargeKeyDelivery, en16931.ChargeKey("DL"))
+	assert.Equal(t, cbc.KeyEmpty, en16931.ChargeKey(""))
+}
@@ -2,6 +2,7 @@ package en16931
 
 import (
 	"github.com/invopop/gobl/catalogues/untdid"
+	"github.com/invopop/gobl/cbc"
 	"github.com/invopop/gobl/pay"
 	"github.com/invopop/gobl/tax"
 	"github.com/invopop/validation"
@@ -23,6 +24,15 @@ var paymentMeansMap = tax.Extensions{
 	pay.MeansKeyDirectDebit.With(pay.MeansKeySEPA):    "59",
 }
 
+// PaymentMeansKey provides the payment means key that maps to the UNTDID 4461
+// code, or the generic "any" key if there is no match.
+func PaymentMeansKey(code tax.ExtValue) cbc.Key {
+	if k := keyFor(paymentMeansMap, code); k != cbc.KeyEmpty {
+		return k
+	}
+	return pay.MeansKeyAny
+}
+
 func normalizePayAdvance(adv *pay.Advance) {
 	if adv == nil {
 		return
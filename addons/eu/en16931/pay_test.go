@@ -33,7 +33,7 @@ func TestPayAdvances(t *testing.T) {
 
 	t.Run("validation", func(t *testing.T) {
 		inv := testInvoiceStandard(t)
-		inv.Payment = &bill.Payment{
+		inv.Payment = &bill.PaymentDetails{
 			Advances: []*pay.Advance{
 				{
 					Key:         pay.MeansKeyCreditTransfer,
@@ -69,7 +69,7 @@ func TestPayInstructions(t *testing.T) {
 
 	t.Run("validation", func(t *testing.T) {
 		inv := testInvoiceStandard(t)
-		inv.Payment = &bill.Payment{
+		inv.Payment = &bill.PaymentDetails{
 			Instructions: &pay.Instructions{
 				Key: pay.MeansKeyCreditTransfer,
 			},
@@ -80,3 +80,10 @@ func TestPayInstructions(t *testing.T) {
 		assert.NoError(t, err)
 	})
 }
+
+func TestPaymentMeansKey(t *testing.T) {
+	assert.Equal(t, pay.MeansKeyCreditTransfer.With(pay.MeansKeySEPA), en16931.PaymentMeansKey("58"))
+	assert.Equal(t, pay.MeansKeyCard, en16931.PaymentMeansKey("48"))
+	assert.Equal(t, pay.MeansKeyAny, en16931.PaymentMeansKey("999"))
+	assert.Equal(t, pay.MeansKeyAny, en16931.PaymentMeansKey(""))
+}
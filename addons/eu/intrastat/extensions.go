@@ -0,0 +1,47 @@
+package intrastat
+
+import (
+	"github.com/invopop/gobl/cbc"
+	"github.com/invopop/gobl/i18n"
+	"github.com/invopop/gobl/pkg/here"
+)
+
+// Extension keys used by Intrastat declarations.
+const (
+	ExtKeyCN      cbc.Key = "eu-intrastat-cn"
+	ExtKeyNetMass cbc.Key = "eu-intrastat-net-mass"
+)
+
+var extensions = []*cbc.KeyDefinition{
+	{
+		Key: ExtKeyCN,
+		Name: i18n.String{
+			i18n.EN: "Combined Nomenclature Code",
+		},
+		Desc: i18n.String{
+			i18n.EN: here.Doc(`
+				The 8 digit commodity code from the Combined Nomenclature (CN8) used to
+				classify goods in Intrastat declarations, without spaces or dots.
+
+				The list of codes is updated every year by the European Commission:
+
+				* https://taxation-customs.ec.europa.eu/customs-4/calculation-customs-duties/customs-tariff/combined-nomenclature_en
+			`),
+		},
+		Pattern: `^\d{8}$`,
+	},
+	{
+		Key: ExtKeyNetMass,
+		Name: i18n.String{
+			i18n.EN: "Net Mass",
+		},
+		Desc: i18n.String{
+			i18n.EN: here.Doc(`
+				Net mass in kilograms of a single unit of the item, excluding any packaging,
+				used to determine the total mass of the goods declared. Decimals must be
+				separated with a dot.
+			`),
+		},
+		Pattern: `^\d+(\.\d+)?$`,
+	},
+}
@@ -0,0 +1,60 @@
+// Package intrastat provides the extensions and validation rules required to
+// include the goods of an invoice in the Intrastat declarations of movements
+// of goods between member states of the European Union.
+package intrastat
+
+import (
+	"github.com/invopop/gobl/cbc"
+	"github.com/invopop/gobl/i18n"
+	"github.com/invopop/gobl/org"
+	"github.com/invopop/gobl/pkg/here"
+	"github.com/invopop/gobl/tax"
+)
+
+const (
+	// V1 is the key for the Intrastat addon.
+	V1 cbc.Key = "eu-intrastat-v1"
+)
+
+// Item keys used to classify the items of an invoice.
+const (
+	// ItemKeyGoods identifies items that are goods, and must be declared.
+	ItemKeyGoods cbc.Key = "goods"
+	// ItemKeyServices identifies items that are services, and do not require
+	// any of the Intrastat details.
+	ItemKeyServices cbc.Key = "services"
+)
+
+func init() {
+	tax.RegisterAddonDef(newAddon())
+}
+
+func newAddon() *tax.AddonDef {
+	return &tax.AddonDef{
+		Key: V1,
+		Name: i18n.String{
+			i18n.EN: "EU Intrastat",
+		},
+		Description: i18n.String{
+			i18n.EN: here.Doc(`
+				Support for the statistical data required by Intrastat declarations of goods
+				moved between member states of the European Union, as defined by Regulation
+				(EU) 2019/2152.
+
+				Items are expected to be goods unless their key is set to "services", and each
+				good must provide the 8 digit Combined Nomenclature (CN) commodity code, the
+				net mass of a single unit, and the country of origin.
+			`),
+		},
+		Extensions: extensions,
+		Validator:  validate,
+	}
+}
+
+func validate(doc any) error {
+	switch obj := doc.(type) {
+	case *org.Item:
+		return validateItem(obj)
+	}
+	return nil
+}
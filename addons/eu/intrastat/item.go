@@ -0,0 +1,20 @@
+package intrastat
+
+import (
+	"github.com/invopop/gobl/org"
+	"github.com/invopop/gobl/tax"
+	"github.com/invopop/validation"
+)
+
+func validateItem(item *org.Item) error {
+	if item == nil || item.Key == ItemKeyServices {
+		return nil
+	}
+	return validation.ValidateStruct(item,
+		validation.Field(&item.Origin, validation.Required),
+		validation.Field(&item.Ext,
+			tax.ExtensionsRequires(ExtKeyCN, ExtKeyNetMass),
+			validation.Skip,
+		),
+	)
+}
@@ -0,0 +1,91 @@
+package intrastat_test
+
+import (
+	"testing"
+
+	"github.com/invopop/gobl/addons/eu/intrastat"
+	"github.com/invopop/gobl/org"
+	"github.com/invopop/gobl/tax"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestItemValidation(t *testing.T) {
+	tests := []struct {
+		name string
+		item *org.Item
+		err  string
+	}{
+		{
+			name: "valid item",
+			item: &org.Item{
+				Origin: "DE",
+				Ext: tax.Extensions{
+					intrastat.ExtKeyCN:      "84713000",
+					intrastat.ExtKeyNetMass: "1.25",
+				},
+			},
+		},
+		{
+			name: "nil item",
+			item: nil,
+		},
+		{
+			name: "services",
+			item: &org.Item{
+				Key: intrastat.ItemKeyServices,
+			},
+		},
+		{
+			name: "missing origin",
+			item: &org.Item{
+				Ext: tax.Extensions{
+					intrastat.ExtKeyCN:      "84713000",
+					intrastat.ExtKeyNetMass: "1.25",
+				},
+			},
+			err: "origin: cannot be blank",
+		},
+		{
+			name: "missing commodity code",
+			item: &org.Item{
+				Origin: "DE",
+				Ext: tax.Extensions{
+					intrastat.ExtKeyNetMass: "1.25",
+				},
+			},
+			err: "ext: (eu-intrastat-cn: required.)",
+		},
+		{
+			name: "missing extensions",
+			item: &org.Item{
+				Key:    intrastat.ItemKeyGoods,
+				Origin: "DE",
+			},
+			err: "eu-intrastat-net-mass: required",
+		},
+	}
+
+	addon := tax.AddonForKey(intrastat.V1)
+	for _, ts := range tests {
+		t.Run(ts.name, func(t *testing.T) {
+			err := addon.Validator(ts.item)
+			if ts.err == "" {
+				assert.NoError(t, err)
+			} else {
+				if assert.Error(t, err) {
+					assert.Contains(t, err.Error(), ts.err)
+				}
+			}
+		})
+	}
+}
+
+func TestExtensionPatterns(t *testing.T) {
+	ext := tax.Extensions{
+		intrastat.ExtKeyCN:      "8471.30",
+		intrastat.ExtKeyNetMass: "1,25",
+	}
+	err := ext.Validate()
+	assert.ErrorContains(t, err, "eu-intrastat-cn: does not match pattern")
+	assert.ErrorContains(t, err, "eu-intrastat-net-mass: does not match pattern")
+}
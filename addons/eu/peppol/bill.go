@@ -0,0 +1,117 @@
+package peppol
+
+import (
+	"errors"
+
+	"github.com/invopop/gobl/bill"
+	"github.com/invopop/gobl/catalogues/untdid"
+	"github.com/invopop/gobl/cbc"
+	"github.com/invopop/gobl/org"
+	"github.com/invopop/gobl/regimes/common"
+	"github.com/invopop/gobl/tax"
+	"github.com/invopop/validation"
+)
+
+var invoiceCorrectionDefinitions = tax.CorrectionSet{
+	{
+		Schema: bill.ShortSchemaInvoice,
+		Types: []cbc.Key{
+			bill.InvoiceTypeCreditNote,
+			bill.InvoiceTypeCorrective,
+			bill.InvoiceTypeDebitNote,
+		},
+		Extensions: []cbc.Key{
+			untdid.ExtKeyDocumentType,
+		},
+	},
+}
+
+// PEPPOL-EN16931-P0100 and P0101 - document type codes allowed for invoices
+// and credit notes.
+var validDocumentTypeValues = []tax.ExtValue{
+	// Invoices
+	"71", "80", "82", "84", "102", "218", "219", "331", "380", "382", "383",
+	"386", "388", "393", "395", "553", "575", "623", "780", "817", "870",
+	"875", "876", "877",
+	// Credit notes
+	"81", "83", "261", "262", "296", "308", "381", "396", "420", "458", "532",
+}
+
+func validateInvoice(inv *bill.Invoice) error {
+	return validation.ValidateStruct(inv,
+		// PEPPOL-EN16931-P0100, P0101
+		validation.Field(&inv.Tax,
+			validation.By(validateInvoiceTax),
+			validation.Skip,
+		),
+		// PEPPOL-EN16931-R003
+		validation.Field(&inv.Ordering,
+			validation.Required.Error("buyer reference or purchase order required"),
+			validation.By(validateInvoiceOrdering),
+			validation.Skip,
+		),
+		// PEPPOL-EN16931-R020
+		validation.Field(&inv.Supplier,
+			validation.By(validateInvoiceParty),
+			validation.Skip,
+		),
+		// PEPPOL-EN16931-R010
+		validation.Field(&inv.Customer,
+			validation.Required,
+			validation.By(validateInvoiceParty),
+			validation.Skip,
+		),
+	)
+}
+
+func validateInvoiceTax(value any) error {
+	tx, ok := value.(*bill.Tax)
+	if !ok || tx == nil {
+		return nil
+	}
+	return validation.ValidateStruct(tx,
+		validation.Field(&tx.Ext,
+			tax.ExtensionsHasValues(untdid.ExtKeyDocumentType, validDocumentTypeValues...),
+			validation.Skip,
+		),
+	)
+}
+
+func validateInvoiceOrdering(value any) error {
+	o, ok := value.(*bill.Ordering)
+	if !ok || o == nil {
+		return nil
+	}
+	return validation.ValidateStruct(o,
+		validation.Field(&o.Code,
+			validation.When(
+				len(o.Purchases) == 0,
+				validation.Required.Error("required without purchase orders"),
+			),
+			validation.Skip,
+		),
+	)
+}
+
+func validateInvoiceParty(value any) error {
+	p, ok := value.(*org.Party)
+	if !ok || p == nil {
+		return nil
+	}
+	return validation.ValidateStruct(p,
+		validation.Field(&p.Inboxes,
+			validation.By(hasPeppolInbox),
+			validation.Skip,
+		),
+	)
+}
+
+func hasPeppolInbox(value any) error {
+	inboxes, _ := value.([]*org.Inbox)
+	for _, ib := range inboxes {
+		if ib != nil && ib.Key == common.InboxKeyPEPPOL {
+			return nil
+		}
+	}
+	return errors.New("peppol inbox required")
+}
@@ -0,0 +1,149 @@
+package peppol_test
+
+import (
+	"testing"
+
+	_ "github.com/invopop/gobl"
+	"github.com/invopop/gobl/addons/eu/peppol"
+	"github.com/invopop/gobl/bill"
+	"github.com/invopop/gobl/cal"
+	"github.com/invopop/gobl/catalogues/untdid"
+	"github.com/invopop/gobl/num"
+	"github.com/invopop/gobl/org"
+	"github.com/invopop/gobl/tax"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func testInvoiceStandard(t *testing.T) *bill.Invoice {
+	t.Helper()
+	return &bill.Invoice{
+		Regime:    tax.WithRegime("BE"),
+		Addons:    tax.WithAddons(peppol.V3),
+		IssueDate: cal.MakeDate(2024, 1, 1),
+		Type:      "standard",
+		Currency:  "EUR",
+		Series:    "2024",
+		Code:      "1000",
+		Supplier: &org.Party{
+			Name: "Provide One BV",
+			TaxID: &tax.Identity{
+				Country: "BE",
+				Code:    "0414445663",
+			},
+			Inboxes: []*org.Inbox{
+				{
+					Key:  "peppol-id",
+					Code: "0208:0414445663",
+				},
+			},
+		},
+		Customer: &org.Party{
+			Name: "Sample Consumer",
+			TaxID: &tax.Identity{
+				Country: "NL",
+				Code:    "000099995B57",
+			},
+			Inboxes: []*org.Inbox{
+				{
+					Key:  "peppol-id",
+					Code: "5790000435968",
+					Ext: tax.Extensions{
+						"iso-scheme-id": "0088",
+					},
+				},
+			},
+		},
+		Ordering: &bill.Ordering{
+			Code: "PO-1234",
+		},
+		Lines: []*bill.Line{
+			{
+				Quantity: num.MakeAmount(10, 0),
+				Item: &org.Item{
+					Name:  "Test Item",
+					Price: num.MakeAmount(10000, 2),
+				},
+				Taxes: tax.Set{
+					{
+						Category: "VAT",
+						Rate:     "standard",
+					},
+				},
+			},
+		},
+	}
+}
+
+func TestInvoiceValidation(t *testing.T) {
+	t.Run("standard invoice", func(t *testing.T) {
+		inv := testInvoiceStandard(t)
+		require.NoError(t, inv.Calculate())
+		require.NoError(t, inv.Validate())
+		assert.Contains(t, inv.GetAddons(), peppol.V3)
+		assert.Equal(t, "380", inv.Tax.Ext[untdid.ExtKeyDocumentType].String())
+	})
+	t.Run("purchase order instead of buyer reference", func(t *testing.T) {
+		inv := testInvoiceStandard(t)
+		inv.Ordering = &bill.Ordering{
+			Purchases: []*org.DocumentRef{{Code: "PO-1234"}},
+		}
+		require.NoError(t, inv.Calculate())
+		assert.NoError(t, inv.Validate())
+	})
+	t.Run("missing ordering", func(t *testing.T) {
+		inv := testInvoiceStandard(t)
+		inv.Ordering = nil
+		require.NoError(t, inv.Calculate())
+		assert.ErrorContains(t, inv.Validate(), "ordering: buyer reference or purchase order required")
+	})
+	t.Run("missing buyer reference", func(t *testing.T) {
+		inv := testInvoiceStandard(t)
+		inv.Ordering = &bill.Ordering{
+			Period: &cal.Period{
+				Start: cal.MakeDate(2023, 12, 1),
+				End:   cal.MakeDate(2023, 12, 31),
+			},
+		}
+		require.NoError(t, inv.Calculate())
+		assert.ErrorContains(t, inv.Validate(), "ordering: (code: required without purchase orders.)")
+	})
+	t.Run("missing supplier inbox", func(t *testing.T) {
+		inv := testInvoiceStandard(t)
+		inv.Supplier.Inboxes = nil
+		require.NoError(t, inv.Calculate())
+		assert.ErrorContains(t, inv.Validate(), "supplier: (inboxes: peppol inbox required.)")
+	})
+	t.Run("missing customer", func(t *testing.T) {
+		inv := testInvoiceStandard(t)
+		inv.Customer = nil
+		require.NoError(t, inv.Calculate())
+		assert.ErrorContains(t, inv.Validate(), "customer: cannot be blank")
+	})
+	t.Run("missing customer inbox", func(t *testing.T) {
+		inv := testInvoiceStandard(t)
+		inv.Customer.Inboxes = []*org.Inbox{
+			{Key: "other", Code: "12345"},
+		}
+		require.NoError(t, inv.Calculate())
+		assert.ErrorContains(t, inv.Validate(), "customer: (inboxes: peppol inbox required.)")
+	})
+	t.Run("unsupported document type", func(t *testing.T) {
+		inv := testInvoiceStandard(t)
+		inv.Type = bill.InvoiceTypeProforma
+		require.NoError(t, inv.Calculate())
+		assert.ErrorContains(t, inv.Validate(), "tax: (ext: (untdid-document-type: invalid value.).)")
+	})
+}
+
+func TestInvoiceCorrections(t *testing.T) {
+	inv := testInvoiceStandard(t)
+	require.NoError(t, inv.Calculate())
+	require.NoError(t, inv.Validate())
+
+	require.NoError(t, inv.Correct(bill.Credit, bill.WithReason("Returned goods")))
+	assert.Equal(t, bill.InvoiceTypeCreditNote, inv.Type)
+	assert.Equal(t, "381", inv.Tax.Ext[untdid.ExtKeyDocumentType].String())
+	require.Len(t, inv.Preceding, 1)
+	assert.Equal(t, "1000", inv.Preceding[0].Code.String())
+}
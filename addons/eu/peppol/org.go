@@ -0,0 +1,47 @@
+package peppol
+
+import (
+	"regexp"
+
+	"github.com/invopop/gobl/catalogues/iso"
+	"github.com/invopop/gobl/cbc"
+	"github.com/invopop/gobl/org"
+	"github.com/invopop/gobl/regimes/common"
+	"github.com/invopop/gobl/tax"
+	"github.com/invopop/validation"
+)
+
+// schemePrefixPattern matches endpoint IDs that include the ISO 6523 scheme,
+// such as "0088:5790000435968".
+var schemePrefixPattern = regexp.MustCompile(`^(\d{4}):(.+)$`)
+
+func normalizeInbox(ib *org.Inbox) {
+	if ib == nil || ib.Key != common.InboxKeyPEPPOL {
+		return
+	}
+	m := schemePrefixPattern.FindStringSubmatch(ib.Code.String())
+	if m == nil {
+		return
+	}
+	ib.Code = cbc.Code(m[2])
+	ib.Ext = ib.Ext.Merge(tax.Extensions{
+		iso.ExtKeySchemeID: tax.ExtValue(m[1]),
+	})
+}
+
+// PEPPOL-EN16931-R020, R010 - electronic addresses must have a scheme.
+func validateInbox(ib *org.Inbox) error {
+	if ib == nil || ib.Key != common.InboxKeyPEPPOL {
+		return nil
+	}
+	return validation.ValidateStruct(ib,
+		validation.Field(&ib.Code,
+			validation.Required,
+			validation.Skip,
+		),
+		validation.Field(&ib.Ext,
+			tax.ExtensionsRequires(iso.ExtKeySchemeID),
+			validation.Skip,
+		),
+	)
+}
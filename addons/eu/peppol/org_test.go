@@ -0,0 +1,60 @@
+package peppol_test
+
+import (
+	"testing"
+
+	"github.com/invopop/gobl/addons/eu/peppol"
+	"github.com/invopop/gobl/catalogues/iso"
+	"github.com/invopop/gobl/org"
+	"github.com/invopop/gobl/tax"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestInboxNormalization(t *testing.T) {
+	ad := tax.AddonForKey(peppol.V3)
+	t.Run("scheme prefix", func(t *testing.T) {
+		ib := &org.Inbox{Key: "peppol-id", Code: "0088:5790000435968"}
+		ad.Normalizer(ib)
+		assert.Equal(t, "5790000435968", ib.Code.String())
+		assert.Equal(t, "0088", ib.Ext[iso.ExtKeySchemeID].String())
+	})
+	t.Run("no prefix", func(t *testing.T) {
+		ib := &org.Inbox{Key: "peppol-id", Code: "5790000435968"}
+		ad.Normalizer(ib)
+		assert.Equal(t, "5790000435968", ib.Code.String())
+		assert.Empty(t, ib.Ext)
+	})
+	t.Run("other inbox", func(t *testing.T) {
+		ib := &org.Inbox{Key: "other", Code: "0088:5790000435968"}
+		ad.Normalizer(ib)
+		assert.Equal(t, "0088:5790000435968", ib.Code.String())
+	})
+}
+
+func TestInboxValidation(t *testing.T) {
+	ad := tax.AddonForKey(peppol.V3)
+	t.Run("valid", func(t *testing.T) {
+		ib := &org.Inbox{
+			Key:  "peppol-id",
+			Code: "5790000435968",
+			Ext:  tax.Extensions{iso.ExtKeySchemeID: "0088"},
+		}
+		assert.NoError(t, ad.Validator(ib))
+	})
+	t.Run("missing scheme", func(t *testing.T) {
+		ib := &org.Inbox{Key: "peppol-id", Code: "5790000435968"}
+		assert.ErrorContains(t, ad.Validator(ib), "ext: (iso-scheme-id: required.)")
+	})
+	t.Run("missing code", func(t *testing.T) {
+		ib := &org.Inbox{
+			Key: "peppol-id",
+			URL: "https://example.com/inbox",
+			Ext: tax.Extensions{iso.ExtKeySchemeID: "0088"},
+		}
+		assert.ErrorContains(t, ad.Validator(ib), "code: cannot be blank")
+	})
+	t.Run("other inbox", func(t *testing.T) {
+		ib := &org.Inbox{Key: "other", Code: "12345"}
+		assert.NoError(t, ad.Validator(ib))
+	})
+}
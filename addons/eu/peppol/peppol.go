@@ -0,0 +1,70 @@
+// Package peppol defines an addon that applies the Peppol BIS Billing 3.0
+// rules to GOBL documents, on top of those from the EN 16931 specification.
+package peppol
+
+import (
+	"github.com/invopop/gobl/addons/eu/en16931"
+	"github.com/invopop/gobl/bill"
+	"github.com/invopop/gobl/cbc"
+	"github.com/invopop/gobl/i18n"
+	"github.com/invopop/gobl/org"
+	"github.com/invopop/gobl/pkg/here"
+	"github.com/invopop/gobl/tax"
+)
+
+const (
+	// V3 is the key for the Peppol BIS Billing 3.0 specification.
+	V3 cbc.Key = "eu-peppol-bis3"
+)
+
+func init() {
+	tax.RegisterAddonDef(newAddon())
+}
+
+func newAddon() *tax.AddonDef {
+	return &tax.AddonDef{
+		Key: V3,
+		Name: i18n.String{
+			i18n.EN: "Peppol BIS Billing 3.0",
+		},
+		Requires: []cbc.Key{
+			en16931.V2017,
+		},
+		Description: i18n.String{
+			i18n.EN: here.Doc(`
+				Support for the Peppol BIS Billing 3.0 specification used to exchange invoices
+				and credit notes through the Peppol network. The rules extend those of EN 16931
+				with the Peppol specific requirements, such as a buyer reference or purchase
+				order reference, a restricted set of document type codes, and an electronic
+				address for both the supplier and customer.
+
+				Electronic addresses are defined as inboxes with the "peppol-id" key, whose code
+				is the endpoint ID and the "iso-scheme-id" extension the scheme it belongs to. A
+				code prefixed with the scheme, such as "0088:5790000435968", will be split
+				automatically.
+
+				For more information, visit [docs.peppol.eu](https://docs.peppol.eu/poacc/billing/3.0/).
+			`),
+		},
+		Corrections: invoiceCorrectionDefinitions,
+		Normalizer:  normalize,
+		Validator:   validate,
+	}
+}
+
+func normalize(doc any) {
+	switch obj := doc.(type) {
+	case *org.Inbox:
+		normalizeInbox(obj)
+	}
+}
+
+func validate(doc any) error {
+	switch obj := doc.(type) {
+	case *bill.Invoice:
+		return validateInvoice(obj)
+	case *org.Inbox:
+		return validateInbox(obj)
+	}
+	return nil
+}
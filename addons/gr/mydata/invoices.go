@@ -154,7 +154,7 @@ func validateInvoiceItem(value any) error {
 }
 
 func validateInvoicePayment(value any) error {
-	p, ok := value.(*bill.Payment)
+	p, ok := value.(*bill.PaymentDetails)
 	if !ok || p == nil {
 		return nil
 	}
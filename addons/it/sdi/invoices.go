@@ -134,7 +134,7 @@ func validateCustomer(value interface{}) error {
 }
 
 func validateInvoicePayment(val any) error {
-	p, _ := val.(*bill.Payment)
+	p, _ := val.(*bill.PaymentDetails)
 	if p == nil {
 		return nil
 	}
@@ -51,6 +51,8 @@ func normalize(doc any) {
 	switch obj := doc.(type) {
 	case *bill.Invoice:
 		normalizeInvoice(obj)
+	case *bill.Payment:
+		normalizePayment(obj)
 	case *org.Party:
 		normalizeParty(obj)
 	case *org.Item:
@@ -66,6 +68,8 @@ func validate(doc any) error {
 	switch obj := doc.(type) {
 	case *bill.Invoice:
 		return validateInvoice(obj)
+	case *bill.Payment:
+		return validatePayment(obj)
 	case *org.Item:
 		return validateItem(obj)
 	case *pay.Instructions:
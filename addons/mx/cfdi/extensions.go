@@ -41,6 +41,13 @@ var extensions = []*cbc.KeyDefinition{
 					i18n.ES: "Comprobante de Egreso",
 				},
 			},
+			{
+				Value: "P",
+				Name: i18n.String{
+					i18n.EN: "Payment Receipt",
+					i18n.ES: "Comprobante de Recepción de Pagos",
+				},
+			},
 		},
 	},
 	{
@@ -0,0 +1,103 @@
+package cfdi
+
+import (
+	"github.com/invopop/gobl/bill"
+	"github.com/invopop/gobl/head"
+	"github.com/invopop/gobl/org"
+	"github.com/invopop/gobl/regimes/mx"
+	"github.com/invopop/gobl/tax"
+	"github.com/invopop/validation"
+)
+
+// Fixed values required for the CFDI "Complemento para Recepción de Pagos".
+const (
+	paymentDocType tax.ExtValue = "P"
+	paymentUse     tax.ExtValue = "CP01"
+)
+
+func normalizePayment(pmt *bill.Payment) {
+	pmt.Ext = pmt.Ext.Merge(tax.Extensions{
+		ExtKeyDocType: paymentDocType,
+	})
+	// Use the supplier's post code as the issue place, as for invoices
+	if !pmt.Ext.Has(ExtKeyIssuePlace) && pmt.Supplier != nil && len(pmt.Supplier.Addresses) > 0 {
+		if addr := pmt.Supplier.Addresses[0]; addr != nil && addr.Code != "" {
+			pmt.Ext[ExtKeyIssuePlace] = tax.ExtValue(addr.Code)
+		}
+	}
+	if pmt.Customer != nil {
+		pmt.Customer.Ext = pmt.Customer.Ext.Merge(tax.Extensions{
+			ExtKeyUse: paymentUse,
+		})
+	}
+}
+
+func validatePayment(pmt *bill.Payment) error {
+	return validation.ValidateStruct(pmt,
+		validation.Field(&pmt.Type,
+			validation.In(bill.PaymentTypeReceipt),
+			validation.Skip,
+		),
+		validation.Field(&pmt.Ext,
+			tax.ExtensionsRequires(
+				ExtKeyDocType,
+				ExtKeyIssuePlace,
+			),
+			tax.ExtensionsHasValues(ExtKeyDocType, paymentDocType),
+			validation.Skip,
+		),
+		validation.Field(&pmt.Supplier,
+			validation.By(validateInvoiceSupplier),
+			validation.Skip,
+		),
+		validation.Field(&pmt.Customer,
+			validation.By(validateInvoiceCustomer),
+			validation.Skip,
+		),
+		validation.Field(&pmt.Method,
+			validation.Required,
+			validation.Skip,
+		),
+		validation.Field(&pmt.Lines,
+			validation.Each(
+				validation.By(validatePaymentLine),
+				validation.Skip,
+			),
+			validation.Skip,
+		),
+	)
+}
+
+func validatePaymentLine(value any) error {
+	line, _ := value.(*bill.PaymentLine)
+	if line == nil {
+		return nil
+	}
+	return validation.ValidateStruct(line,
+		validation.Field(&line.Document,
+			validation.By(validatePaymentDocument),
+			validation.Skip,
+		),
+		validation.Field(&line.Installment,
+			validation.Required,
+			validation.Skip,
+		),
+		validation.Field(&line.Payable,
+			validation.Required,
+			validation.Skip,
+		),
+	)
+}
+
+func validatePaymentDocument(value any) error {
+	doc, _ := value.(*org.DocumentRef)
+	if doc == nil {
+		return nil
+	}
+	return validation.ValidateStruct(doc,
+		validation.Field(&doc.Stamps,
+			head.StampsHas(mx.StampSATUUID),
+			validation.Skip,
+		),
+	)
+}
@@ -0,0 +1,139 @@
+package cfdi_test
+
+import (
+	"testing"
+
+	"github.com/invopop/gobl/addons/mx/cfdi"
+	"github.com/invopop/gobl/bill"
+	"github.com/invopop/gobl/cal"
+	"github.com/invopop/gobl/head"
+	"github.com/invopop/gobl/num"
+	"github.com/invopop/gobl/org"
+	"github.com/invopop/gobl/pay"
+	"github.com/invopop/gobl/regimes/mx"
+	"github.com/invopop/gobl/tax"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func validPayment() *bill.Payment {
+	payable := num.MakeAmount(11600, 2)
+	return &bill.Payment{
+		Addons:    tax.WithAddons(cfdi.V4),
+		Code:      "P-123",
+		IssueDate: cal.MakeDate(2023, 2, 1),
+		Ext: tax.Extensions{
+			cfdi.ExtKeyIssuePlace: "21000",
+		},
+		Supplier: &org.Party{
+			Name: "Test Supplier",
+			Ext: tax.Extensions{
+				cfdi.ExtKeyFiscalRegime: "601",
+			},
+			TaxID: &tax.Identity{
+				Country: "MX",
+				Code:    "AAA010101AAA",
+			},
+		},
+		Customer: &org.Party{
+			Name: "Test Customer",
+			Ext: tax.Extensions{
+				cfdi.ExtKeyFiscalRegime: "608",
+			},
+			Addresses: []*org.Address{
+				{
+					Code: "65000",
+				},
+			},
+			TaxID: &tax.Identity{
+				Country: "MX",
+				Code:    "ZZZ010101ZZZ",
+			},
+		},
+		Method: &pay.Instructions{
+			Key: pay.MeansKeyCreditTransfer,
+		},
+		Lines: []*bill.PaymentLine{
+			{
+				Document: &org.DocumentRef{
+					Code: "123",
+					Stamps: []*head.Stamp{
+						{
+							Provider: mx.StampSATUUID,
+							Value:    "1fac4464-1111-0000-1111-cd37179db12e",
+						},
+					},
+				},
+				Installment: 1,
+				Payable:     &payable,
+				Amount:      num.MakeAmount(5800, 2),
+			},
+		},
+	}
+}
+
+func TestValidPayment(t *testing.T) {
+	pmt := validPayment()
+	require.NoError(t, pmt.Calculate())
+	require.NoError(t, pmt.Validate())
+}
+
+func TestNormalizePayment(t *testing.T) {
+	pmt := validPayment()
+	require.NoError(t, pmt.Calculate())
+	assert.Equal(t, tax.ExtValue("P"), pmt.Ext[cfdi.ExtKeyDocType])
+	assert.Equal(t, tax.ExtValue("CP01"), pmt.Customer.Ext[cfdi.ExtKeyUse])
+	assert.Equal(t, tax.ExtValue("03"), pmt.Method.Ext[cfdi.ExtKeyPaymentMeans])
+	assert.Equal(t, "58.00", pmt.Lines[0].Due.String())
+	assert.Equal(t, tax.ExtValue("21000"), pmt.Ext[cfdi.ExtKeyIssuePlace])
+
+	t.Run("issue place from supplier", func(t *testing.T) {
+		pmt := validPayment()
+		pmt.Ext = nil
+		pmt.Supplier.Addresses = []*org.Address{
+			{
+				Code: "22000",
+			},
+		}
+		require.NoError(t, pmt.Calculate())
+		assert.Equal(t, tax.ExtValue("22000"), pmt.Ext[cfdi.ExtKeyIssuePlace])
+		require.NoError(t, pmt.Validate())
+	})
+}
+
+func TestPaymentValidation(t *testing.T) {
+	t.Run("type", func(t *testing.T) {
+		pmt := validPayment()
+		pmt.Type = bill.PaymentTypeAdvice
+		assertPaymentValidationError(t, pmt, "type: must be a valid value")
+	})
+	t.Run("issue place", func(t *testing.T) {
+		pmt := validPayment()
+		pmt.Ext = nil
+		assertPaymentValidationError(t, pmt, "ext: (mx-cfdi-issue-place: required.)")
+	})
+	t.Run("method", func(t *testing.T) {
+		pmt := validPayment()
+		pmt.Method = nil
+		assertPaymentValidationError(t, pmt, "method: cannot be blank")
+	})
+	t.Run("line document stamp", func(t *testing.T) {
+		pmt := validPayment()
+		pmt.Lines[0].Document.Stamps = nil
+		assertPaymentValidationError(t, pmt, "lines: (0: (document: (stamps: missing sat-uuid stamp.).).)")
+	})
+	t.Run("line installment and payable", func(t *testing.T) {
+		pmt := validPayment()
+		pmt.Lines[0].Installment = 0
+		pmt.Lines[0].Payable = nil
+		assertPaymentValidationError(t, pmt, "lines: (0: (installment: cannot be blank; payable: cannot be blank.).)")
+	})
+}
+
+func assertPaymentValidationError(t *testing.T, pmt *bill.Payment, expected string) {
+	t.Helper()
+	require.NoError(t, pmt.Calculate())
+	err := pmt.Validate()
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), expected)
+}
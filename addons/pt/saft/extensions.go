@@ -10,6 +10,7 @@ const (
 	ExtKeyExemption   cbc.Key = "pt-saft-exemption"
 	ExtKeyTaxRate     cbc.Key = "pt-saft-tax-rate"
 	ExtKeyInvoiceType cbc.Key = "pt-saft-invoice-type"
+	ExtKeyPaymentType cbc.Key = "pt-saft-payment-type"
 )
 
 var extensions = []*cbc.KeyDefinition{
@@ -57,6 +58,29 @@ var extensions = []*cbc.KeyDefinition{
 			},
 		},
 	},
+	{
+		Key: ExtKeyPaymentType,
+		Name: i18n.String{
+			i18n.EN: "Payment Type",
+			i18n.PT: "Tipo de Recibo",
+		},
+		Values: []*cbc.ValueDefinition{
+			{
+				Value: "RC",
+				Name: i18n.String{
+					i18n.EN: "Receipt under the VAT cash accounting scheme",
+					i18n.PT: "Recibo no âmbito do regime de IVA de Caixa",
+				},
+			},
+			{
+				Value: "RG",
+				Name: i18n.String{
+					i18n.EN: "Other receipt",
+					i18n.PT: "Outros recibos",
+				},
+			},
+		},
+	},
 	{
 		Key: ExtKeyTaxRate,
 		Name: i18n.String{
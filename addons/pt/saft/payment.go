@@ -0,0 +1,88 @@
+package saft
+
+import (
+	"github.com/invopop/gobl/bill"
+	"github.com/invopop/gobl/org"
+	"github.com/invopop/gobl/tax"
+	"github.com/invopop/validation"
+)
+
+// SAF-T payment type codes.
+const (
+	paymentTypeCash  tax.ExtValue = "RC"
+	paymentTypeOther tax.ExtValue = "RG"
+)
+
+func normalizePayment(pmt *bill.Payment) {
+	if pmt.Ext.Has(ExtKeyPaymentType) {
+		return
+	}
+	// Receipts with a tax breakdown are assumed to be issued under
+	// the VAT cash accounting scheme.
+	val := paymentTypeOther
+	for _, l := range pmt.Lines {
+		if l != nil && l.Tax != nil {
+			val = paymentTypeCash
+			break
+		}
+	}
+	pmt.Ext = pmt.Ext.Merge(tax.Extensions{
+		ExtKeyPaymentType: val,
+	})
+}
+
+func validatePayment(pmt *bill.Payment) error {
+	cash := pmt.Ext[ExtKeyPaymentType] == paymentTypeCash
+	return validation.ValidateStruct(pmt,
+		validation.Field(&pmt.Type,
+			validation.In(bill.PaymentTypeReceipt),
+			validation.Skip,
+		),
+		validation.Field(&pmt.Ext,
+			tax.ExtensionsRequires(ExtKeyPaymentType),
+			validation.Skip,
+		),
+		validation.Field(&pmt.Lines,
+			validation.Each(
+				validation.By(validatePaymentLine(cash)),
+				validation.Skip,
+			),
+			validation.Skip,
+		),
+	)
+}
+
+func validatePaymentLine(cash bool) validation.RuleFunc {
+	return func(value any) error {
+		line, _ := value.(*bill.PaymentLine)
+		if line == nil {
+			return nil
+		}
+		return validation.ValidateStruct(line,
+			validation.Field(&line.Document,
+				validation.By(validatePaymentDocument),
+				validation.Skip,
+			),
+			validation.Field(&line.Tax,
+				validation.When(
+					cash,
+					validation.Required.Error("required for cash accounting receipts"),
+				),
+				validation.Skip,
+			),
+		)
+	}
+}
+
+func validatePaymentDocument(value any) error {
+	doc, _ := value.(*org.DocumentRef)
+	if doc == nil {
+		return nil
+	}
+	return validation.ValidateStruct(doc,
+		validation.Field(&doc.IssueDate,
+			validation.Required,
+			validation.Skip,
+		),
+	)
+}
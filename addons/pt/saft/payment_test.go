@@ -0,0 +1,106 @@
+package saft_test
+
+import (
+	"testing"
+
+	"github.com/invopop/gobl/addons/pt/saft"
+	"github.com/invopop/gobl/bill"
+	"github.com/invopop/gobl/cal"
+	"github.com/invopop/gobl/num"
+	"github.com/invopop/gobl/org"
+	"github.com/invopop/gobl/pay"
+	"github.com/invopop/gobl/tax"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func validPayment() *bill.Payment {
+	return &bill.Payment{
+		Regime:    tax.WithRegime("PT"),
+		Addons:    tax.WithAddons(saft.V1),
+		Code:      "RG/1",
+		IssueDate: cal.MakeDate(2023, 2, 1),
+		Supplier: &org.Party{
+			TaxID: &tax.Identity{
+				Code:    "123456789",
+				Country: "PT",
+			},
+			Name: "Test Supplier",
+		},
+		Customer: &org.Party{
+			Name: "Test Customer",
+		},
+		Method: &pay.Instructions{
+			Key: pay.MeansKeyCash,
+		},
+		Lines: []*bill.PaymentLine{
+			{
+				Document: &org.DocumentRef{
+					Code:      "FT SERIES-A/123",
+					IssueDate: cal.NewDate(2023, 1, 1),
+				},
+				Amount: num.MakeAmount(12300, 2),
+			},
+		},
+	}
+}
+
+func TestPaymentNormalization(t *testing.T) {
+	t.Run("other receipt", func(t *testing.T) {
+		pmt := validPayment()
+		require.NoError(t, pmt.Calculate())
+		assert.Equal(t, tax.ExtValue("RG"), pmt.Ext[saft.ExtKeyPaymentType])
+		require.NoError(t, pmt.Validate())
+	})
+	t.Run("cash accounting receipt", func(t *testing.T) {
+		pmt := validPayment()
+		pmt.Lines[0].Tax = &tax.Total{
+			Categories: []*tax.CategoryTotal{
+				{
+					Code: tax.CategoryVAT,
+					Rates: []*tax.RateTotal{
+						{
+							Key:     tax.RateStandard,
+							Base:    num.MakeAmount(10000, 2),
+							Percent: num.NewPercentage(23, 2),
+							Amount:  num.MakeAmount(2300, 2),
+						},
+					},
+					Amount: num.MakeAmount(2300, 2),
+				},
+			},
+			Sum: num.MakeAmount(2300, 2),
+		}
+		require.NoError(t, pmt.Calculate())
+		assert.Equal(t, tax.ExtValue("RC"), pmt.Ext[saft.ExtKeyPaymentType])
+		assert.Equal(t, "23.00", pmt.Tax.Sum.String())
+		require.NoError(t, pmt.Validate())
+	})
+	t.Run("keeps existing", func(t *testing.T) {
+		pmt := validPayment()
+		pmt.Ext = tax.Extensions{saft.ExtKeyPaymentType: "RC"}
+		require.NoError(t, pmt.Calculate())
+		assert.Equal(t, tax.ExtValue("RC"), pmt.Ext[saft.ExtKeyPaymentType])
+	})
+}
+
+func TestPaymentValidation(t *testing.T) {
+	t.Run("type", func(t *testing.T) {
+		pmt := validPayment()
+		pmt.Type = bill.PaymentTypeRequest
+		require.NoError(t, pmt.Calculate())
+		assert.ErrorContains(t, pmt.Validate(), "type: must be a valid value")
+	})
+	t.Run("missing document date", func(t *testing.T) {
+		pmt := validPayment()
+		pmt.Lines[0].Document.IssueDate = nil
+		require.NoError(t, pmt.Calculate())
+		assert.ErrorContains(t, pmt.Validate(), "lines: (0: (document: (issue_date: cannot be blank.).).)")
+	})
+	t.Run("cash accounting without tax", func(t *testing.T) {
+		pmt := validPayment()
+		pmt.Ext = tax.Extensions{saft.ExtKeyPaymentType: "RC"}
+		require.NoError(t, pmt.Calculate())
+		assert.ErrorContains(t, pmt.Validate(), "lines: (0: (tax: required for cash accounting receipts.).)")
+	})
+}
@@ -2,6 +2,7 @@
 package saft
 
 import (
+	"github.com/invopop/gobl/bill"
 	"github.com/invopop/gobl/cbc"
 	"github.com/invopop/gobl/i18n"
 	"github.com/invopop/gobl/tax"
@@ -33,6 +34,8 @@ func normalize(doc any) {
 	switch obj := doc.(type) {
 	case *tax.Combo:
 		normalizeTaxCombo(obj)
+	case *bill.Payment:
+		normalizePayment(obj)
 	}
 }
 
@@ -40,6 +43,8 @@ func validate(doc any) error {
 	switch obj := doc.(type) {
 	case *tax.Combo:
 		return validateTaxCombo(obj)
+	case *bill.Payment:
+		return validatePayment(obj)
 	}
 	return nil
 }
@@ -1,4 +1,5 @@
-// Package bill provides models for dealing with Billing and specifically invoicing.
+// Package bill provides models for dealing with Billing and specifically invoicing,
+// ordering, deliveries, and payments.
 package bill
 
 import (
@@ -8,6 +9,9 @@ import (
 func init() {
 	schema.Register(schema.GOBL.Add("bill"),
 		Invoice{},
+		Order{},
+		Delivery{},
+		Payment{},
 		CorrectionOptions{},
 	)
 }
@@ -0,0 +1,245 @@
+package bill
+
+import (
+	"errors"
+
+	"github.com/invopop/gobl/cal"
+	"github.com/invopop/gobl/cbc"
+	"github.com/invopop/gobl/currency"
+	"github.com/invopop/gobl/l10n"
+	"github.com/invopop/gobl/org"
+	"github.com/invopop/gobl/schema"
+	"github.com/invopop/gobl/tax"
+	"github.com/invopop/validation"
+)
+
+// billable defines the methods a document must provide in order to re-use
+// the line, discount, charge, and tax calculation pipeline shared between
+// the billing documents.
+type billable interface {
+	RegimeDef() *tax.RegimeDef
+	GetRegime() l10n.TaxCountryCode
+	GetTags() []cbc.Key
+	HasTags(keys ...cbc.Key) bool
+
+	getIssueDate() cal.Date
+	getValueDate() *cal.Date
+	getTax() *Tax
+	getCustomer() *org.Party
+	getCurrency() currency.Code
+	getExchangeRates() []*currency.ExchangeRate
+	getLines() []*Line
+	getDiscounts() []*Discount
+	getCharges() []*Charge
+	getOutlays() []*Outlay
+	getPayment() *PaymentDetails
+	getTotals() *Totals
+	getComplements() []*schema.Object
+
+	setIssueDate(cal.Date)
+	setCurrency(currency.Code)
+	setTotals(*Totals)
+}
+
+// calculate does not assume that the tax regime is available.
+func calculate(doc billable) error {
+	r := doc.RegimeDef() // may be nil!
+
+	// Normalize data
+	if doc.getIssueDate().IsZero() {
+		doc.setIssueDate(cal.TodayIn(r.TimeLocation()))
+	}
+	date := doc.getValueDate()
+	if date == nil {
+		d := doc.getIssueDate()
+		date = &d
+	}
+
+	// Convert empty or invalid currency to the regime's currency
+	if cur := doc.getCurrency(); cur == currency.CodeEmpty || cur.Def() == nil {
+		if r == nil {
+			return validation.Errors{"currency": errors.New("missing")}
+		}
+		doc.setCurrency(r.Currency)
+	}
+	cur := doc.getCurrency()
+
+	// Prepare the totals we'll need with amounts based on currency
+	if doc.getTotals() == nil {
+		doc.setTotals(new(Totals))
+	}
+	t := doc.getTotals()
+	zero := cur.Def().Zero()
+	t.reset(zero)
+
+	// Do we need to deal with the customer-rates tag?
+	if doc.HasTags(tax.TagCustomerRates) {
+		applyCustomerRates(doc)
+	}
+
+	// Lines
+	lines := doc.getLines()
+	if err := calculateLines(lines, cur, doc.getExchangeRates()); err != nil {
+		return validation.Errors{"lines": err}
+	}
+	t.Sum = calculateLineSum(lines, cur)
+	t.Total = t.Sum
+
+	// Discount Lines
+	discounts := doc.getDiscounts()
+	calculateDiscounts(discounts, t.Sum, zero)
+	if sum := calculateDiscountSum(discounts, zero); sum != nil {
+		t.Discount = sum
+		t.Total = t.Total.Subtract(*sum)
+	}
+
+	// Charge Lines
+	charges := doc.getCharges()
+	calculateCharges(charges, t.Sum, zero)
+	if sum := calculateChargeSum(charges, zero); sum != nil {
+		t.Charge = sum
+		t.Total = t.Total.Add(*sum)
+	}
+
+	// Build list of taxable lines
+	tls := make([]tax.TaxableLine, 0)
+	for _, l := range lines {
+		tls = append(tls, l)
+	}
+	for _, l := range discounts {
+		tls = append(tls, l)
+	}
+	for _, l := range charges {
+		tls = append(tls, l)
+	}
+
+	// Now figure out the tax totals
+	var pit cbc.Code
+	if tx := doc.getTax(); tx != nil && tx.PricesInclude != "" {
+		pit = tx.PricesInclude
+	}
+	t.Taxes = new(tax.Total)
+	tc := &tax.TotalCalculator{
+		Zero:     zero,
+		Country:  doc.GetRegime(),
+		Tags:     doc.GetTags(),
+		Date:     *date,
+		Lines:    tls,
+		Includes: pit,
+
+		Currency:      cur,
+		ExchangeRates: doc.getExchangeRates(),
+	}
+	if err := tc.Calculate(t.Taxes); err != nil {
+		return err
+	}
+
+	// Remove any included taxes from the total.
+	ct := t.Taxes.Category(pit)
+	if ct != nil {
+		ti := ct.PreciseAmount()
+		t.TaxIncluded = &ti
+		t.Total = t.Total.Subtract(ti)
+	}
+
+	// Finally calculate the total with *all* the taxes.
+	t.Tax = t.Taxes.PreciseSum()
+	t.TotalWithTax = t.Total.Add(t.Tax)
+	t.Payable = t.TotalWithTax
+
+	// Outlays are not taxed, so they're only added to the payable amount
+	if sum := calculateOutlays(doc.getOutlays(), zero); sum != nil {
+		t.Outlays = sum
+		t.Payable = t.Payable.Add(*sum)
+	}
+
+	// Rounding may have been provided externally
+	if t.Rounding != nil {
+		// BT-144 in EN16931
+		t.Payable = t.Payable.Add(*t.Rounding)
+	}
+
+	// Remove taxes object if it doesn't contain any categories
+	if len(t.Taxes.Categories) == 0 {
+		t.Taxes = nil
+	}
+
+	p := doc.getPayment()
+	if p != nil {
+		p.calculateAdvances(zero, t.TotalWithTax)
+
+		// Deal with advances, if any
+		if t.Advances = p.totalAdvance(zero); t.Advances != nil {
+			v := t.Payable.Subtract(*t.Advances)
+			t.Due = &v
+		}
+	}
+
+	// Cash payments may need rounding to the smallest available coin,
+	// applied to the amount that will actually be collected.
+	if t.Rounding == nil {
+		if inc := cashRoundingIncrement(doc, cur); inc != nil {
+			t.Rounding = calculateCashRounding(t.collectable(), *inc, zero)
+			if t.Rounding != nil {
+				t.cashRounding = true
+				t.Payable = t.Payable.Add(*t.Rounding)
+				if t.Due != nil {
+					v := t.Due.Add(*t.Rounding)
+					t.Due = &v
+				}
+			}
+		}
+	}
+
+	if p != nil {
+		// Calculate any due date amounts
+		p.Terms.CalculateDues(zero, t.Payable)
+	}
+
+	t.round(zero)
+
+	// Provide totals in the regime's currency, if different
+	if r != nil && r.Currency != cur {
+		if ex := currency.MatchExchangeRate(doc.getExchangeRates(), cur, r.Currency); ex != nil {
+			t.calculateLocal(ex)
+		}
+	}
+
+	// Complements
+	if err := calculateComplements(doc.getComplements()); err != nil {
+		return validation.Errors{"complements": err}
+	}
+
+	return nil
+}
+
+func applyCustomerRates(doc billable) {
+	country := doc.getCustomer().TaxCountry()
+	if country == "" {
+		return
+	}
+	for _, l := range doc.getLines() {
+		addCountryToTaxes(l.Taxes, country)
+	}
+	for _, d := range doc.getDiscounts() {
+		addCountryToTaxes(d.Taxes, country)
+	}
+	for _, c := range doc.getCharges() {
+		addCountryToTaxes(c.Taxes, country)
+	}
+}
+
+func addCountryToTaxes(ts tax.Set, country l10n.TaxCountryCode) {
+	for _, t := range ts {
+		t.Country = country
+	}
+}
+
+func calculateComplements(comps []*schema.Object) error {
+	for _, c := range comps {
+		if err := c.Calculate(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
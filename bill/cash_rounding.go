@@ -0,0 +1,95 @@
+package bill
+
+import (
+	"fmt"
+	"math"
+
+	"github.com/invopop/gobl/currency"
+	"github.com/invopop/gobl/num"
+	"github.com/invopop/gobl/pay"
+	"github.com/invopop/validation"
+)
+
+// cashRoundingIncrement determines the increment the payable amount should be
+// rounded to when the document is expected to be paid in cash. The regime's
+// cash rounding takes priority over the currency's smallest denomination, as
+// long as the document is issued in the regime's currency. Nil is returned
+// if no rounding is required.
+func cashRoundingIncrement(doc billable, cur currency.Code) *num.Amount {
+	p := doc.getPayment()
+	if p == nil || p.Instructions == nil || !p.Instructions.Key.Has(pay.MeansKeyCash) {
+		return nil
+	}
+	if r := doc.RegimeDef(); r != nil && r.Currency == cur && r.CashRounding != nil {
+		return r.CashRounding
+	}
+	d := cur.Def()
+	if d == nil || d.SmallestDenomination <= 1 {
+		return nil
+	}
+	inc := d.SmallestDenominationAmount()
+	return &inc
+}
+
+// calculateCashRounding provides the amount that needs to be added to the
+// amount to be collected so that it can be paid with the provided increment,
+// or nil if no rounding is required.
+func calculateCashRounding(amount, inc num.Amount, zero num.Amount) *num.Amount {
+	p := amount.Rescale(zero.Exp())
+	r := roundToIncrement(p, inc).Subtract(p)
+	if r.IsZero() {
+		return nil
+	}
+	return &r
+}
+
+// roundToIncrement rounds the amount to the nearest multiple of the increment,
+// with halves rounded away from zero.
+func roundToIncrement(a, inc num.Amount) num.Amount {
+	exp := a.Exp()
+	if inc.Exp() > exp {
+		exp = inc.Exp()
+	}
+	v := a.Rescale(exp).Value()
+	i := inc.Rescale(exp).Value()
+	if i == 0 {
+		return a
+	}
+	x := int64(math.Round(float64(v)/float64(i))) * i
+	return num.MakeAmount(x, exp).Rescale(a.Exp())
+}
+
+// validateCashRounding provides a rule that ensures the amount to be
+// collected from a document paid in cash can be paid using the expected
+// increment.
+func validateCashRounding(doc billable) validation.Rule {
+	inc := cashRoundingIncrement(doc, doc.getCurrency())
+	if inc == nil {
+		return validation.Skip
+	}
+	return validation.By(func(value any) error {
+		t, ok := value.(*Totals)
+		if !ok || t == nil {
+			return nil
+		}
+		field := "payable"
+		if t.Due != nil {
+			field = "due"
+		}
+		if a := t.collectable(); !roundToIncrement(a, *inc).Equals(a) {
+			return validation.Errors{
+				field: fmt.Errorf("must be a multiple of %s for cash payments", inc.String()),
+			}
+		}
+		return nil
+	})
+}
+
+// collectable provides the amount that still needs to be collected from the
+// customer, which will be the due amount if there were any advances.
+func (t *Totals) collectable() num.Amount {
+	if t.Due != nil {
+		return *t.Due
+	}
+	return t.Payable
+}
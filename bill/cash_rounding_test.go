@@ -0,0 +1,188 @@
+package bill_test
+
+import (
+	"testing"
+
+	"github.com/invopop/gobl/bill"
+	"github.com/invopop/gobl/cal"
+	"github.com/invopop/gobl/num"
+	"github.com/invopop/gobl/org"
+	"github.com/invopop/gobl/pay"
+	"github.com/invopop/gobl/tax"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func testInvoiceCashCH(t *testing.T) *bill.Invoice {
+	t.Helper()
+	return &bill.Invoice{
+		Series:    "TEST",
+		Code:      "0002",
+		IssueDate: cal.MakeDate(2024, 6, 1),
+		Supplier: &org.Party{
+			Name: "Test Supplier",
+			TaxID: &tax.Identity{
+				Country: "CH",
+				Code:    "E100416306",
+			},
+		},
+		Lines: []*bill.Line{
+			{
+				Quantity: num.MakeAmount(1, 0),
+				Item: &org.Item{
+					Name:  "Coffee beans",
+					Price: num.MakeAmount(1033, 2),
+				},
+				Taxes: tax.Set{
+					{
+						Category: "VAT",
+						Rate:     "standard",
+					},
+				},
+			},
+		},
+		Payment: &bill.PaymentDetails{
+			Instructions: &pay.Instructions{
+				Key: pay.MeansKeyCash,
+			},
+		},
+	}
+}
+
+func TestInvoiceCashRounding(t *testing.T) {
+	t.Run("currency smallest denomination", func(t *testing.T) {
+		inv := testInvoiceCashCH(t)
+		require.NoError(t, inv.Calculate())
+		assert.Equal(t, "11.17", inv.Totals.TotalWithTax.String())
+		assert.Equal(t, "-0.02", inv.Totals.Rounding.String())
+		assert.Equal(t, "11.15", inv.Totals.Payable.String())
+		require.NoError(t, inv.Validate())
+	})
+	t.Run("rounding up", func(t *testing.T) {
+		inv := testInvoiceCashCH(t)
+		inv.Lines[0].Item.Price = num.MakeAmount(1035, 2)
+		require.NoError(t, inv.Calculate())
+		assert.Equal(t, "11.19", inv.Totals.TotalWithTax.String())
+		assert.Equal(t, "0.01", inv.Totals.Rounding.String())
+		assert.Equal(t, "11.20", inv.Totals.Payable.String())
+	})
+	t.Run("not paid in cash", func(t *testing.T) {
+		inv := testInvoiceCashCH(t)
+		inv.Payment.Instructions.Key = pay.MeansKeyCreditTransfer
+		require.NoError(t, inv.Calculate())
+		assert.Nil(t, inv.Totals.Rounding)
+		assert.Equal(t, "11.17", inv.Totals.Payable.String())
+	})
+	t.Run("regime cash rounding", func(t *testing.T) {
+		inv := testInvoiceCashCH(t)
+		inv.Supplier.TaxID = &tax.Identity{
+			Country: "BE",
+			Code:    "0413172884",
+		}
+		require.NoError(t, inv.Calculate())
+		assert.Equal(t, "12.50", inv.Totals.TotalWithTax.String())
+		assert.Nil(t, inv.Totals.Rounding)
+
+		inv.Lines[0].Item.Price = num.MakeAmount(1040, 2)
+		require.NoError(t, inv.Calculate())
+		assert.Equal(t, "12.58", inv.Totals.TotalWithTax.String())
+		assert.Equal(t, "0.02", inv.Totals.Rounding.String())
+		assert.Equal(t, "12.60", inv.Totals.Payable.String())
+	})
+	t.Run("without smallest denomination", func(t *testing.T) {
+		inv := baseInvoiceWithLines(t)
+		inv.Payment = &bill.PaymentDetails{
+			Instructions: &pay.Instructions{
+				Key: pay.MeansKeyCash,
+			},
+		}
+		require.NoError(t, inv.Calculate())
+		assert.Nil(t, inv.Totals.Rounding)
+	})
+	t.Run("invert", func(t *testing.T) {
+		inv := testInvoiceCashCH(t)
+		require.NoError(t, inv.Calculate())
+		require.NoError(t, inv.Invert())
+		assert.Equal(t, "0.02", inv.Totals.Rounding.String())
+		assert.Equal(t, "-11.15", inv.Totals.Payable.String())
+	})
+	t.Run("recalculated after price change", func(t *testing.T) {
+		inv := testInvoiceCashCH(t)
+		require.NoError(t, inv.Calculate())
+		assert.Equal(t, "-0.02", inv.Totals.Rounding.String())
+		assert.Equal(t, "11.15", inv.Totals.Payable.String())
+
+		inv.Lines[0].Item.Price = num.MakeAmount(1035, 2)
+		require.NoError(t, inv.Calculate())
+		assert.Equal(t, "11.19", inv.Totals.TotalWithTax.String())
+		assert.Equal(t, "0.01", inv.Totals.Rounding.String())
+		assert.Equal(t, "11.20", inv.Totals.Payable.String())
+		require.NoError(t, inv.Validate())
+	})
+	t.Run("recalculated after payment means change", func(t *testing.T) {
+		inv := testInvoiceCashCH(t)
+		require.NoError(t, inv.Calculate())
+		assert.Equal(t, "-0.02", inv.Totals.Rounding.String())
+
+		inv.Payment.Instructions.Key = pay.MeansKeyCreditTransfer
+		require.NoError(t, inv.Calculate())
+		assert.Nil(t, inv.Totals.Rounding)
+		assert.Equal(t, "11.17", inv.Totals.Payable.String())
+		require.NoError(t, inv.Validate())
+	})
+	t.Run("recalculated after correction", func(t *testing.T) {
+		inv := testInvoiceCashCH(t)
+		inv.Lines = append(inv.Lines, &bill.Line{
+			Quantity: num.MakeAmount(1, 0),
+			Item: &org.Item{
+				Name:  "Milk",
+				Price: num.MakeAmount(210, 2),
+			},
+			Taxes: tax.Set{
+				{
+					Category: "VAT",
+					Rate:     "reduced",
+				},
+			},
+		})
+		require.NoError(t, inv.Calculate())
+		require.NoError(t, inv.Correct(bill.Credit, bill.WithLines(&bill.LineSelection{Index: 2})))
+		require.NoError(t, inv.Validate())
+	})
+	t.Run("provided externally", func(t *testing.T) {
+		inv := testInvoiceCashCH(t)
+		rnd := num.MakeAmount(-7, 2)
+		inv.Totals = &bill.Totals{Rounding: &rnd}
+		require.NoError(t, inv.Calculate())
+		assert.Equal(t, "-0.07", inv.Totals.Rounding.String())
+		assert.Equal(t, "11.10", inv.Totals.Payable.String())
+		require.NoError(t, inv.Calculate())
+		assert.Equal(t, "11.10", inv.Totals.Payable.String(), "should not change when recalculated")
+	})
+	t.Run("with advances", func(t *testing.T) {
+		inv := testInvoiceCashCH(t)
+		inv.Payment.Advances = []*pay.Advance{
+			{
+				Description: "Deposit",
+				Amount:      num.MakeAmount(501, 2),
+			},
+		}
+		require.NoError(t, inv.Calculate())
+		assert.Equal(t, "11.17", inv.Totals.TotalWithTax.String())
+		assert.Equal(t, "-0.01", inv.Totals.Rounding.String())
+		assert.Equal(t, "11.16", inv.Totals.Payable.String())
+		assert.Equal(t, "6.15", inv.Totals.Due.String())
+		require.NoError(t, inv.Validate())
+
+		inv.Totals.Due = num.NewAmount(616, 2)
+		err := inv.Validate()
+		assert.ErrorContains(t, err, "totals: (due: must be a multiple of 0.05 for cash payments.)")
+	})
+	t.Run("validation", func(t *testing.T) {
+		inv := testInvoiceCashCH(t)
+		require.NoError(t, inv.Calculate())
+		inv.Totals.Payable = num.MakeAmount(1117, 2)
+		err := inv.Validate()
+		assert.ErrorContains(t, err, "totals: (payable: must be a multiple of 0.05 for cash payments.)")
+	})
+}
@@ -163,6 +163,7 @@ func (m *Charge) convertInto(ex *currency.ExchangeRate) *Charge {
 	accuracy := defaultCurrencyConversionAccuracy
 	m2 := *m
 	m2.Amount = m2.Amount.Upscale(accuracy).Multiply(ex.Amount)
+	m2.Taxes = convertTaxes(m.Taxes, ex)
 	return &m2
 }
 
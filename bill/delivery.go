@@ -1,35 +1,413 @@
 package bill
 
 import (
+	"context"
+	"encoding/json"
+
 	"github.com/invopop/gobl/cal"
 	"github.com/invopop/gobl/cbc"
+	"github.com/invopop/gobl/currency"
+	"github.com/invopop/gobl/i18n"
+	"github.com/invopop/gobl/internal"
+	"github.com/invopop/gobl/l10n"
 	"github.com/invopop/gobl/org"
+	"github.com/invopop/gobl/pkg/here"
+	"github.com/invopop/gobl/schema"
+	"github.com/invopop/gobl/tax"
+	"github.com/invopop/gobl/uuid"
+	"github.com/invopop/jsonschema"
 	"github.com/invopop/validation"
 )
 
-// Delivery covers the details of the destination for the products described
-// in the invoice body.
+// Constants used to help identify deliveries
+const (
+	ShortSchemaDelivery = "bill/delivery"
+)
+
+// Predefined list of the delivery type codes officially supported.
+const (
+	DeliveryTypeAdvice  cbc.Key = "advice"
+	DeliveryTypeNote    cbc.Key = "note"
+	DeliveryTypeWaybill cbc.Key = "waybill"
+	DeliveryTypeReceipt cbc.Key = "receipt"
+)
+
+// DeliveryTypes describes each of the delivery types supported.
+var DeliveryTypes = []*cbc.KeyDefinition{
+	{
+		Key: DeliveryTypeAdvice,
+		Name: i18n.String{
+			i18n.EN: "Despatch Advice",
+		},
+		Desc: i18n.String{
+			i18n.EN: "Advance notification sent by the supplier to inform the customer of goods that have been despatched.",
+		},
+		Map: cbc.CodeMap{
+			UNTDID1001Key: "351",
+		},
+	},
+	{
+		Key: DeliveryTypeNote,
+		Name: i18n.String{
+			i18n.EN: "Delivery Note",
+		},
+		Desc: i18n.String{
+			i18n.EN: "Document accompanying the goods that lists the items and quantities being delivered.",
+		},
+		Map: cbc.CodeMap{
+			UNTDID1001Key: "270",
+		},
+	},
+	{
+		Key: DeliveryTypeWaybill,
+		Name: i18n.String{
+			i18n.EN: "Waybill",
+		},
+		Desc: i18n.String{
+			i18n.EN: here.Doc(`
+				Transport document issued by, or on behalf of, the courier or carrier
+				describing the goods being transported.
+			`),
+		},
+		Map: cbc.CodeMap{
+			UNTDID1001Key: "730",
+		},
+	},
+	{
+		Key: DeliveryTypeReceipt,
+		Name: i18n.String{
+			i18n.EN: "Receiving Advice",
+		},
+		Desc: i18n.String{
+			i18n.EN: "Confirmation issued by the receiver detailing the goods that were received.",
+		},
+		Map: cbc.CodeMap{
+			UNTDID1001Key: "632",
+		},
+	},
+}
+
+var isValidDeliveryType = validation.In(typeValues(DeliveryTypes)...)
+
+// Delivery documents are used to describe the goods that have been, or will
+// be, transported from the supplier to the customer, such as despatch
+// advices and delivery notes. Deliveries share the same structure of lines as
+// orders and invoices, so that they can be easily converted into invoices once
+// the goods have been received.
+//
+// Valuation is optional: when none of the lines include a price and no
+// discounts or charges are provided, totals will not be calculated.
 type Delivery struct {
-	// The party who will receive delivery of the goods defined in the invoice and is not responsible for taxes.
+	tax.Regime
+	tax.Addons
+	tax.Tags
+
+	uuid.Identify
+
+	// Type of delivery document.
+	Type cbc.Key `json:"type" jsonschema:"title=Type" jsonschema_extras:"calculated=true"`
+	// Used as a prefix to group codes.
+	Series cbc.Code `json:"series,omitempty" jsonschema:"title=Series"`
+	// Code used to identify this delivery in the issuer's systems.
+	Code cbc.Code `json:"code" jsonschema:"title=Code"`
+	// When the delivery document was created.
+	IssueDate cal.Date `json:"issue_date" jsonschema:"title=Issue Date" jsonschema_extras:"calculated=true"`
+	// Date from which taxes should be determined, if none set, the issue date is used.
+	ValueDate *cal.Date `json:"value_date,omitempty" jsonschema:"title=Value Date"`
+	// Currency for all delivery totals.
+	Currency currency.Code `json:"currency" jsonschema:"title=Currency" jsonschema_extras:"calculated=true"`
+	// Exchange rates to be used when converting the delivery's monetary values into other currencies.
+	ExchangeRates []*currency.ExchangeRate `json:"exchange_rates,omitempty" jsonschema:"title=Exchange Rates"`
+
+	// Key information regarding previous delivery documents that this one replaces.
+	Preceding []*org.DocumentRef `json:"preceding,omitempty" jsonschema:"title=Preceding Details"`
+
+	// Tracking details of the shipment.
+	Tracking *Tracking `json:"tracking,omitempty" jsonschema:"title=Tracking"`
+
+	// Special tax configuration for calculating totals.
+	Tax *Tax `json:"tax,omitempty" jsonschema:"title=Tax"`
+
+	// The entity supplying the goods.
+	Supplier *org.Party `json:"supplier" jsonschema:"title=Supplier"`
+	// Legal entity receiving the goods.
+	Customer *org.Party `json:"customer,omitempty" jsonschema:"title=Customer"`
+	// The party who will despatch the goods, if not the same as the supplier.
+	Despatcher *org.Party `json:"despatcher,omitempty" jsonschema:"title=Despatcher"`
+	// The party who will receive the goods, if not the same as the customer.
 	Receiver *org.Party `json:"receiver,omitempty" jsonschema:"title=Receiver"`
-	// Identities is used to define specific codes or IDs that may be used to
-	// identify the delivery.
-	Identities []*org.Identity `json:"identities,omitempty" jsonschema:"title=Identities"`
-	// When the goods should be expected.
-	Date *cal.Date `json:"date,omitempty" jsonschema:"title=Date"`
-	// Period of time in which to expect delivery if a specific date is not available.
-	Period *cal.Period `json:"period,omitempty" jsonschema:"title=Period"`
-	// Additional custom data.
-	Meta *cbc.Meta `json:"meta,omitempty" jsonschema:"title=Meta"`
-}
-
-// Validate the delivery details
-func (d *Delivery) Validate() error {
-	return validation.ValidateStruct(d,
-		validation.Field(&d.Receiver),
-		validation.Field(&d.Identities),
-		validation.Field(&d.Date),
-		validation.Field(&d.Period),
-		validation.Field(&d.Meta),
+	// The courier or carrier responsible for transporting the goods.
+	Courier *org.Party `json:"courier,omitempty" jsonschema:"title=Courier"`
+
+	// List of lines representing each of the items being delivered.
+	Lines []*Line `json:"lines,omitempty" jsonschema:"title=Lines"`
+	// Discounts or allowances applied to the complete delivery
+	Discounts []*Discount `json:"discounts,omitempty" jsonschema:"title=Discounts"`
+	// Charges or surcharges applied to the complete delivery
+	Charges []*Charge `json:"charges,omitempty" jsonschema:"title=Charges"`
+
+	// Ordering details including document references and buyer or seller parties.
+	Ordering *Ordering `json:"ordering,omitempty" jsonschema:"title=Ordering Details"`
+	// Information on when, how, and to whom a final invoice would be paid.
+	Payment *PaymentDetails `json:"payment,omitempty" jsonschema:"title=Payment Details"`
+	// Specific details on when and where the goods will be delivered.
+	Delivery *DeliveryDetails `json:"delivery,omitempty" jsonschema:"title=Delivery Details"`
+
+	// Summary of all the delivery totals, including taxes (calculated), only
+	// present when the delivery has been valued.
+	Totals *Totals `json:"totals,omitempty" jsonschema:"title=Totals" jsonschema_extras:"calculated=true"`
+
+	// Unstructured information that is relevant to the delivery.
+	Notes []*cbc.Note `json:"notes,omitempty" jsonschema:"title=Notes"`
+
+	// Additional complementary objects that add relevant information to the delivery.
+	Complements []*schema.Object `json:"complements,omitempty" jsonschema:"title=Complements"`
+
+	// Additional semi-structured data that doesn't fit into the body of the delivery.
+	Meta cbc.Meta `json:"meta,omitempty" jsonschema:"title=Meta"`
+}
+
+// Validate checks to ensure the delivery is valid and contains all the information we need.
+func (dlv *Delivery) Validate() error {
+	return dlv.ValidateWithContext(context.Background())
+}
+
+// ValidateWithContext checks to ensure the delivery is valid and contains all the
+// information we need.
+func (dlv *Delivery) ValidateWithContext(ctx context.Context) error {
+	ctx = dlv.ValidationContext(ctx)
+
+	return tax.ValidateStructWithContext(ctx, dlv,
+		validation.Field(&dlv.Regime),
+		validation.Field(&dlv.Addons),
+		validation.Field(&dlv.Tags.List, tax.TagsIn(dlv.supportedTags()...)),
+		validation.Field(&dlv.UUID),
+		validation.Field(&dlv.Type,
+			validation.Required,
+			isValidDeliveryType,
+		),
+		validation.Field(&dlv.Series),
+		validation.Field(&dlv.Code,
+			validation.When(
+				internal.IsSigned(ctx),
+				validation.Required.Error("required to sign delivery"),
+			),
+		),
+		validation.Field(&dlv.IssueDate,
+			cal.DateNotZero(),
+		),
+		validation.Field(&dlv.ValueDate),
+		validation.Field(&dlv.Currency, validation.Required),
+		validation.Field(&dlv.ExchangeRates),
+		validation.Field(&dlv.Preceding),
+		validation.Field(&dlv.Tracking),
+		validation.Field(&dlv.Tax),
+		validation.Field(&dlv.Supplier,
+			validation.Required,
+			validation.By(validateInvoiceSupplier),
+		),
+		validation.Field(&dlv.Customer,
+			validation.Required,
+			validation.By(validateInvoiceCustomer),
+		),
+		validation.Field(&dlv.Despatcher),
+		validation.Field(&dlv.Receiver),
+		validation.Field(&dlv.Courier),
+		validation.Field(&dlv.Lines, validation.Required),
+		validation.Field(&dlv.Discounts),
+		validation.Field(&dlv.Charges),
+		validation.Field(&dlv.Ordering),
+		validation.Field(&dlv.Payment),
+		validation.Field(&dlv.Delivery),
+		validation.Field(&dlv.Totals),
+		validation.Field(&dlv.Notes),
+		validation.Field(&dlv.Complements),
+		validation.Field(&dlv.Meta),
 	)
 }
+
+// ValidationContext builds a context with all the validators that the delivery might
+// need for execution.
+func (dlv *Delivery) ValidationContext(ctx context.Context) context.Context {
+	if r := dlv.RegimeDef(); r != nil {
+		ctx = r.WithContext(ctx)
+	}
+	for _, a := range dlv.AddonDefs() {
+		ctx = a.WithContext(ctx)
+	}
+	return ctx
+}
+
+// Calculate performs all the normalizations and calculations required for the
+// delivery lines and, if the delivery is valued, the totals and taxes.
+func (dlv *Delivery) Calculate() error {
+	// Try to set Regime if not already prepared from the supplier's tax ID
+	if dlv.Regime.IsEmpty() {
+		dlv.SetRegime(dlv.supplierTaxCountry())
+	}
+
+	dlv.Normalize(tax.ExtractNormalizers(dlv))
+
+	valued := dlv.isValued()
+	if err := calculate(dlv); err != nil {
+		return err
+	}
+	if !valued {
+		dlv.Totals = nil
+	}
+	return nil
+}
+
+// Normalize is run as part of the Calculate method to ensure that the delivery
+// is in a consistent state before calculations are performed. This will leverage
+// any add-ons alongside the tax regime.
+func (dlv *Delivery) Normalize(normalizers tax.Normalizers) {
+	if dlv.Type == cbc.KeyEmpty {
+		dlv.Type = DeliveryTypeNote
+	}
+	dlv.Series = cbc.NormalizeCode(dlv.Series)
+	dlv.Code = cbc.NormalizeCode(dlv.Code)
+
+	normalizers.Each(dlv)
+
+	tax.Normalize(normalizers, dlv.Tax)
+	tax.Normalize(normalizers, dlv.Tracking)
+	tax.Normalize(normalizers, dlv.Supplier)
+	tax.Normalize(normalizers, dlv.Customer)
+	tax.Normalize(normalizers, dlv.Despatcher)
+	tax.Normalize(normalizers, dlv.Receiver)
+	tax.Normalize(normalizers, dlv.Courier)
+	tax.Normalize(normalizers, dlv.Preceding)
+	tax.Normalize(normalizers, dlv.Lines)
+	tax.Normalize(normalizers, dlv.Discounts)
+	tax.Normalize(normalizers, dlv.Charges)
+	tax.Normalize(normalizers, dlv.Ordering)
+	tax.Normalize(normalizers, dlv.Payment)
+}
+
+// Replicate modifies the delivery's fields to ensure that it can be used as part
+// of a replication process, keeping the base details like the parties and lines,
+// but with updated identifiers and dates.
+func (dlv *Delivery) Replicate() error {
+	dlv.UUID = uuid.Empty
+	dlv.Code = ""
+	dlv.IssueDate = cal.Today()
+	dlv.ValueDate = nil
+	dlv.Tracking = nil
+	return nil
+}
+
+// UNTDID1001 provides the official code number assigned with the delivery type.
+func (dlv *Delivery) UNTDID1001() cbc.Code {
+	return typeUNTDID1001(DeliveryTypes, dlv.Type)
+}
+
+// isValued returns true when any of the lines contain a price, or the
+// delivery includes discounts or charges, in which case totals will be
+// calculated.
+func (dlv *Delivery) isValued() bool {
+	if len(dlv.Discounts) > 0 || len(dlv.Charges) > 0 {
+		return true
+	}
+	for _, l := range dlv.Lines {
+		if l != nil && l.Item != nil && !l.Item.Price.IsZero() {
+			return true
+		}
+	}
+	return false
+}
+
+func (dlv *Delivery) supportedTags() []cbc.Key {
+	return supportedTagsFor(ShortSchemaDelivery, dlv.RegimeDef(), dlv.AddonDefs())
+}
+
+// supplierTaxCountry determines the tax country for the delivery based on the supplier tax
+// identity.
+func (dlv *Delivery) supplierTaxCountry() l10n.TaxCountryCode {
+	if dlv.Supplier == nil || dlv.Supplier.TaxID == nil {
+		return l10n.CodeEmpty.Tax()
+	}
+	return dlv.Supplier.TaxID.Country
+}
+
+// UnmarshalJSON implements the json.Unmarshaler interface and ensures the
+// regime is set when coming in from a raw JSON source.
+func (dlv *Delivery) UnmarshalJSON(data []byte) error {
+	type Alias Delivery
+	if err := json.Unmarshal(data, (*Alias)(dlv)); err != nil {
+		return err
+	}
+	if dlv.Regime.IsEmpty() {
+		dlv.SetRegime(dlv.supplierTaxCountry())
+	}
+	return nil
+}
+
+// JSONSchemaExtend extends the schema with additional property details
+func (dlv Delivery) JSONSchemaExtend(js *jsonschema.Schema) {
+	props := js.Properties
+	if its, ok := props.Get("type"); ok {
+		its.OneOf = typeSchemaOneOf(DeliveryTypes)
+	}
+	dlv.Regime.JSONSchemaExtend(js)
+	dlv.Addons.JSONSchemaExtend(js)
+	// Recommendations
+	js.Extras = map[string]any{
+		schema.Recommended: []string{
+			"$regime",
+			"lines",
+		},
+	}
+}
+
+// Internal methods used to satisfy the billable interface for calculations.
+
+func (dlv *Delivery) getIssueDate() cal.Date {
+	return dlv.IssueDate
+}
+func (dlv *Delivery) getValueDate() *cal.Date {
+	return dlv.ValueDate
+}
+func (dlv *Delivery) getTax() *Tax {
+	return dlv.Tax
+}
+func (dlv *Delivery) getCustomer() *org.Party {
+	return dlv.Customer
+}
+func (dlv *Delivery) getCurrency() currency.Code {
+	return dlv.Currency
+}
+func (dlv *Delivery) getExchangeRates() []*currency.ExchangeRate {
+	return dlv.ExchangeRates
+}
+func (dlv *Delivery) getLines() []*Line {
+	return dlv.Lines
+}
+func (dlv *Delivery) getDiscounts() []*Discount {
+	return dlv.Discounts
+}
+func (dlv *Delivery) getCharges() []*Charge {
+	return dlv.Charges
+}
+func (dlv *Delivery) getOutlays() []*Outlay {
+	return nil
+}
+func (dlv *Delivery) getPayment() *PaymentDetails {
+	return dlv.Payment
+}
+func (dlv *Delivery) getTotals() *Totals {
+	return dlv.Totals
+}
+func (dlv *Delivery) getComplements() []*schema.Object {
+	return dlv.Complements
+}
+
+func (dlv *Delivery) setIssueDate(d cal.Date) {
+	dlv.IssueDate = d
+}
+func (dlv *Delivery) setCurrency(c currency.Code) {
+	dlv.Currency = c
+}
+func (dlv *Delivery) setTotals(t *Totals) {
+	dlv.Totals = t
+}
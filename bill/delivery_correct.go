@@ -0,0 +1,45 @@
+package bill
+
+import (
+	"errors"
+
+	"github.com/invopop/gobl/schema"
+	"github.com/invopop/gobl/tax"
+)
+
+// Correct moves the key fields of the current delivery to the preceding
+// structure so that the document can be re-issued as a replacement of the
+// original delivery, usually to reflect a change in the goods being
+// delivered. The delivery type will be maintained unless a new one is provided
+// in the options.
+func (dlv *Delivery) Correct(opts ...schema.Option) error {
+	if dlv.Code == "" {
+		return errors.New("cannot correct a delivery without a code")
+	}
+	h := &correctionHeader{
+		uuid:      &dlv.UUID,
+		typ:       &dlv.Type,
+		series:    &dlv.Series,
+		code:      &dlv.Code,
+		issueDate: &dlv.IssueDate,
+		preceding: &dlv.Preceding,
+	}
+	if err := correctHeader(h, DeliveryTypes, dlv.correctionDef(), opts...); err != nil {
+		return err
+	}
+	return dlv.Calculate()
+}
+
+// CorrectionOptionsSchema provides a dynamic JSON schema of the options
+// that can be used on the delivery in order to correct it.
+func (dlv *Delivery) CorrectionOptionsSchema() (interface{}, error) {
+	var cd *tax.CorrectionDefinition
+	if dlv.RegimeDef() != nil {
+		cd = dlv.correctionDef()
+	}
+	return correctionOptionsSchema(dlv.GetRegime(), dlv.Series, cd, DeliveryTypes)
+}
+
+func (dlv *Delivery) correctionDef() *tax.CorrectionDefinition {
+	return correctionDefFor(ShortSchemaDelivery, dlv.RegimeDef(), dlv.AddonDefs())
+}
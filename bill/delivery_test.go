@@ -0,0 +1,214 @@
+package bill_test
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/invopop/gobl/bill"
+	"github.com/invopop/gobl/cal"
+	"github.com/invopop/gobl/cbc"
+	"github.com/invopop/gobl/currency"
+	"github.com/invopop/gobl/num"
+	"github.com/invopop/gobl/org"
+	"github.com/invopop/gobl/tax"
+	"github.com/invopop/jsonschema"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDeliveryCalculate(t *testing.T) {
+	t.Run("not valued", func(t *testing.T) {
+		dlv := testDeliveryStandard(t)
+		require.NoError(t, dlv.Calculate())
+		assert.Equal(t, "ES", dlv.GetRegime().String())
+		assert.Equal(t, bill.DeliveryTypeNote, dlv.Type)
+		assert.Equal(t, currency.EUR, dlv.Currency)
+		assert.Nil(t, dlv.Totals)
+		assert.Equal(t, 1, dlv.Lines[0].Index)
+		assert.Equal(t, "0.00", dlv.Lines[0].Total.String())
+		require.NoError(t, dlv.Validate())
+	})
+	t.Run("valued", func(t *testing.T) {
+		dlv := testDeliveryStandard(t)
+		dlv.Lines[0].Item.Price = num.MakeAmount(1000, 2)
+		dlv.Lines[0].Taxes = tax.Set{
+			{
+				Category: tax.CategoryVAT,
+				Rate:     tax.RateStandard,
+			},
+		}
+		require.NoError(t, dlv.Calculate())
+		require.NotNil(t, dlv.Totals)
+		assert.Equal(t, "200.00", dlv.Totals.Sum.String())
+		assert.Equal(t, "42.00", dlv.Totals.Tax.String())
+		assert.Equal(t, "242.00", dlv.Totals.Payable.String())
+		require.NoError(t, dlv.Validate())
+	})
+	t.Run("with charges", func(t *testing.T) {
+		dlv := testDeliveryStandard(t)
+		dlv.Charges = []*bill.Charge{
+			{
+				Key:    bill.ChargeKeyDelivery,
+				Amount: num.MakeAmount(1500, 2),
+			},
+		}
+		require.NoError(t, dlv.Calculate())
+		require.NotNil(t, dlv.Totals)
+		assert.Equal(t, "15.00", dlv.Totals.Payable.String())
+	})
+	t.Run("tracking", func(t *testing.T) {
+		dlv := testDeliveryStandard(t)
+		dlv.Tracking = &bill.Tracking{
+			Code: " TRK 1234 ",
+			Website: &org.Website{
+				URL: "https://example.com/track/TRK1234",
+			},
+		}
+		require.NoError(t, dlv.Calculate())
+		assert.Equal(t, "TRK 1234", dlv.Tracking.Code.String())
+		require.NoError(t, dlv.Validate())
+	})
+}
+
+func TestDeliveryValidation(t *testing.T) {
+	t.Run("missing customer", func(t *testing.T) {
+		dlv := testDeliveryStandard(t)
+		dlv.Customer = nil
+		require.NoError(t, dlv.Calculate())
+		assert.ErrorContains(t, dlv.Validate(), "customer: cannot be blank")
+	})
+	t.Run("missing lines", func(t *testing.T) {
+		dlv := testDeliveryStandard(t)
+		dlv.Lines = nil
+		require.NoError(t, dlv.Calculate())
+		assert.ErrorContains(t, dlv.Validate(), "lines: cannot be blank")
+	})
+	t.Run("invalid type", func(t *testing.T) {
+		dlv := testDeliveryStandard(t)
+		dlv.Type = bill.OrderTypePurchase
+		require.NoError(t, dlv.Calculate())
+		assert.ErrorContains(t, dlv.Validate(), "type: must be a valid value")
+	})
+	t.Run("invalid tracking website", func(t *testing.T) {
+		dlv := testDeliveryStandard(t)
+		dlv.Tracking = &bill.Tracking{
+			Website: &org.Website{},
+		}
+		require.NoError(t, dlv.Calculate())
+		assert.ErrorContains(t, dlv.Validate(), "tracking: (website: (url: cannot be blank.).)")
+	})
+}
+
+func TestDeliveryUNTDID1001(t *testing.T) {
+	dlv := testDeliveryStandard(t)
+	require.NoError(t, dlv.Calculate())
+	assert.Equal(t, cbc.Code("270"), dlv.UNTDID1001())
+	dlv.Type = bill.DeliveryTypeAdvice
+	assert.Equal(t, cbc.Code("351"), dlv.UNTDID1001())
+	dlv.Type = "foo"
+	assert.Equal(t, cbc.CodeEmpty, dlv.UNTDID1001())
+}
+
+func TestDeliveryCorrect(t *testing.T) {
+	t.Run("basic", func(t *testing.T) {
+		dlv := testDeliveryStandard(t)
+		require.NoError(t, dlv.Calculate())
+		require.NoError(t, dlv.Correct(bill.WithReason("missing box")))
+		assert.Equal(t, bill.DeliveryTypeNote, dlv.Type)
+		assert.Empty(t, dlv.Code)
+		require.Len(t, dlv.Preceding, 1)
+		pre := dlv.Preceding[0]
+		assert.Equal(t, "DN-001", pre.Code.String())
+		assert.Equal(t, "missing box", pre.Reason)
+		assert.Equal(t, bill.DeliveryTypeNote, pre.Type)
+	})
+	t.Run("invalid type", func(t *testing.T) {
+		dlv := testDeliveryStandard(t)
+		require.NoError(t, dlv.Calculate())
+		err := dlv.Correct(bill.Credit)
+		assert.ErrorContains(t, err, "invalid correction type: credit-note")
+		assert.NotEmpty(t, dlv.Code, "should not be modified")
+		assert.Empty(t, dlv.Preceding)
+	})
+	t.Run("missing code", func(t *testing.T) {
+		dlv := testDeliveryStandard(t)
+		dlv.Code = ""
+		err := dlv.Correct()
+		assert.ErrorContains(t, err, "cannot correct a delivery without a code")
+	})
+}
+
+func TestDeliveryReplicate(t *testing.T) {
+	dlv := testDeliveryStandard(t)
+	dlv.Tracking = &bill.Tracking{Code: "TRK1234"}
+	require.NoError(t, dlv.Calculate())
+	require.NoError(t, dlv.Replicate())
+	assert.Empty(t, dlv.UUID)
+	assert.Empty(t, dlv.Code)
+	assert.Nil(t, dlv.Tracking)
+	assert.Equal(t, cal.Today(), dlv.IssueDate)
+}
+
+func TestDeliveryUnmarshalJSON(t *testing.T) {
+	data := []byte(`{
+		"type": "advice",
+		"code": "DA-1",
+		"supplier": {
+			"name": "Test Supplier",
+			"tax_id": {"country": "ES", "code": "B98602642"}
+		}
+	}`)
+	dlv := new(bill.Delivery)
+	require.NoError(t, json.Unmarshal(data, dlv))
+	assert.Equal(t, "ES", dlv.GetRegime().String())
+	assert.Equal(t, bill.DeliveryTypeAdvice, dlv.Type)
+}
+
+func TestDeliveryJSONSchemaExtend(t *testing.T) {
+	js := new(jsonschema.Schema)
+	js.Properties = jsonschema.NewProperties()
+	js.Properties.Set("type", &jsonschema.Schema{})
+	dlv := bill.Delivery{}
+	dlv.JSONSchemaExtend(js)
+	prop, ok := js.Properties.Get("type")
+	require.True(t, ok)
+	require.Len(t, prop.OneOf, len(bill.DeliveryTypes))
+	assert.Equal(t, bill.DeliveryTypeAdvice.String(), prop.OneOf[0].Const)
+}
+
+func testDeliveryStandard(t *testing.T) *bill.Delivery {
+	t.Helper()
+	return &bill.Delivery{
+		Code:      "DN-001",
+		IssueDate: cal.MakeDate(2024, 11, 15),
+		Supplier: &org.Party{
+			Name: "Test Supplier",
+			TaxID: &tax.Identity{
+				Country: "ES",
+				Code:    "B98602642",
+			},
+		},
+		Customer: &org.Party{
+			Name: "Test Customer",
+			TaxID: &tax.Identity{
+				Country: "ES",
+				Code:    "54387763P",
+			},
+		},
+		Lines: []*bill.Line{
+			{
+				Quantity: num.MakeAmount(20, 0),
+				Item: &org.Item{
+					Name: "Test Item",
+					Unit: org.UnitPackage,
+				},
+				Identities: []*org.Identity{
+					{
+						Key:  org.IdentityKeyBatch,
+						Code: "B-2024-11",
+					},
+				},
+			},
+		},
+	}
+}
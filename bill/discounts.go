@@ -179,6 +179,7 @@ func (m *Discount) convertInto(ex *currency.ExchangeRate) *Discount {
 	accuracy := defaultCurrencyConversionAccuracy
 	m2 := *m
 	m2.Amount = m2.Amount.Upscale(accuracy).Multiply(ex.Amount)
+	m2.Taxes = convertTaxes(m.Taxes, ex)
 	return &m2
 }
 
@@ -0,0 +1,132 @@
+package bill
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/invopop/gobl/cal"
+	"github.com/invopop/gobl/cbc"
+	"github.com/invopop/gobl/org"
+	"github.com/invopop/gobl/schema"
+	"github.com/invopop/gobl/tax"
+	"github.com/invopop/gobl/uuid"
+	"github.com/invopop/jsonschema"
+)
+
+// Helpers shared between the order and delivery documents, which only
+// differ in their type definitions and schema.
+
+// typeValues provides the keys of the type definitions for validation.
+func typeValues(types []*cbc.KeyDefinition) []interface{} {
+	list := make([]interface{}, len(types))
+	for i, d := range types {
+		list[i] = d.Key
+	}
+	return list
+}
+
+// typeUNTDID1001 provides the official code number assigned to the type.
+func typeUNTDID1001(types []*cbc.KeyDefinition, key cbc.Key) cbc.Code {
+	for _, d := range types {
+		if d.Key == key {
+			return d.Map[UNTDID1001Key]
+		}
+	}
+	return cbc.CodeEmpty
+}
+
+// typeSchemaOneOf prepares the list of JSON schema constants for the types.
+func typeSchemaOneOf(types []*cbc.KeyDefinition) []*jsonschema.Schema {
+	list := make([]*jsonschema.Schema, len(types))
+	for i, kd := range types {
+		list[i] = &jsonschema.Schema{
+			Const:       kd.Key.String(),
+			Title:       kd.Name.String(),
+			Description: kd.Desc.String(),
+		}
+	}
+	return list
+}
+
+// supportedTagsFor provides the tags defined for the schema by the regime
+// and addons.
+func supportedTagsFor(schema string, r *tax.RegimeDef, addons []*tax.AddonDef) []cbc.Key {
+	var ts *tax.TagSet
+	if r != nil {
+		ts = ts.Merge(tax.TagSetForSchema(r.Tags, schema))
+	}
+	for _, a := range addons {
+		ts = ts.Merge(tax.TagSetForSchema(a.Tags, schema))
+	}
+	return ts.Keys()
+}
+
+// correctionHeader provides access to the header fields of an order or
+// delivery that are replaced when the document is corrected.
+type correctionHeader struct {
+	uuid      *uuid.UUID
+	typ       *cbc.Key
+	series    *cbc.Code
+	code      *cbc.Code
+	issueDate *cal.Date
+	preceding *[]*org.DocumentRef
+}
+
+// correctHeader moves the key fields of the document's header to the
+// preceding structure so that it can be re-issued as a replacement of the
+// original. The document type will be maintained unless a new one is
+// provided in the options. All the options are checked before the header
+// is modified.
+func correctHeader(h *correctionHeader, types []*cbc.KeyDefinition, cd *tax.CorrectionDefinition, opts ...schema.Option) error {
+	o := new(CorrectionOptions)
+	if err := prepareCorrectionOptions(o, opts...); err != nil {
+		return err
+	}
+	if len(o.Lines) > 0 {
+		return errors.New("line selection is only supported for invoices")
+	}
+	typ := *h.typ
+	if o.Type != cbc.KeyEmpty {
+		if !o.Type.In(cbc.DefinitionKeys(types)...) {
+			return fmt.Errorf("invalid correction type: %v", o.Type.String())
+		}
+		typ = o.Type
+	}
+
+	pre := &org.DocumentRef{
+		Identify:  uuid.Identify{UUID: *h.uuid},
+		Type:      *h.typ,
+		Series:    *h.series,
+		Code:      *h.code,
+		IssueDate: h.issueDate.Clone(),
+		Reason:    o.Reason,
+		Ext:       o.Ext,
+	}
+	if err := copyCorrectionStamps(o, cd, pre); err != nil {
+		return err
+	}
+	if len(cd.Types) > 0 && !typ.In(cd.Types...) {
+		return fmt.Errorf("invalid correction type: %v", typ.String())
+	}
+	if cd.ReasonRequired && pre.Reason == "" {
+		return errors.New("missing corrective reason")
+	}
+
+	// Prepare the basic fields
+	*h.uuid = ""
+	*h.typ = typ
+	if o.Series != "" {
+		*h.series = o.Series
+	}
+	*h.code = ""
+	if o.IssueDate != nil {
+		*h.issueDate = *o.IssueDate
+	} else {
+		*h.issueDate = cal.Today()
+	}
+
+	// Replace all previous preceding data
+	*h.preceding = []*org.DocumentRef{pre}
+
+	return nil
+}
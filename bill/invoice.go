@@ -3,7 +3,6 @@ package bill
 import (
 	"context"
 	"encoding/json"
-	"errors"
 	"fmt"
 
 	"github.com/invopop/gobl/cal"
@@ -76,13 +75,16 @@ type Invoice struct {
 	Discounts []*Discount `json:"discounts,omitempty" jsonschema:"title=Discounts"`
 	// Charges or surcharges applied to the complete invoice
 	Charges []*Charge `json:"charges,omitempty" jsonschema:"title=Charges"`
+	// Expenses paid for by the supplier but invoiced directly in the name of the
+	// customer, which are not subject to tax and added to the payable amount.
+	Outlays []*Outlay `json:"outlays,omitempty" jsonschema:"title=Outlays"`
 
 	// Ordering details including document references and buyer or seller parties.
 	Ordering *Ordering `json:"ordering,omitempty" jsonschema:"title=Ordering Details"`
 	// Information on when, how, and to whom the invoice should be paid.
-	Payment *Payment `json:"payment,omitempty" jsonschema:"title=Payment Details"`
+	Payment *PaymentDetails `json:"payment,omitempty" jsonschema:"title=Payment Details"`
 	// Specific details on delivery of the goods referenced in the invoice.
-	Delivery *Delivery `json:"delivery,omitempty" jsonschema:"title=Delivery Details"`
+	Delivery *DeliveryDetails `json:"delivery,omitempty" jsonschema:"title=Delivery Details"`
 
 	// Summary of all the invoice totals, including taxes (calculated).
 	Totals *Totals `json:"totals" jsonschema:"title=Totals" jsonschema_extras:"calculated=true"`
@@ -158,11 +160,13 @@ func (inv *Invoice) ValidateWithContext(ctx context.Context) error {
 		),
 		validation.Field(&inv.Discounts),
 		validation.Field(&inv.Charges),
+		validation.Field(&inv.Outlays),
 		validation.Field(&inv.Ordering),
 		validation.Field(&inv.Payment),
 		validation.Field(&inv.Delivery),
 		validation.Field(&inv.Totals,
 			validation.Required,
+			validateCashRounding(inv),
 		),
 		validation.Field(&inv.Notes),
 		validation.Field(&inv.Complements),
@@ -223,6 +227,9 @@ func (inv *Invoice) Invert() error {
 	for _, row := range inv.Discounts {
 		row.Amount = row.Amount.Invert()
 	}
+	for _, row := range inv.Outlays {
+		row.Amount = row.Amount.Invert()
+	}
 	if inv.Payment != nil {
 		for _, row := range inv.Payment.Advances {
 			row.Amount = row.Amount.Invert()
@@ -249,6 +256,7 @@ func (inv *Invoice) Empty() {
 	inv.Lines = make([]*Line, 0)
 	inv.Charges = make([]*Charge, 0)
 	inv.Discounts = make([]*Discount, 0)
+	inv.Outlays = nil
 	inv.Totals = nil
 	inv.Payment.ResetAdvances()
 }
@@ -293,6 +301,7 @@ func (inv *Invoice) Normalize(normalizers tax.Normalizers) {
 	tax.Normalize(normalizers, inv.Lines)
 	tax.Normalize(normalizers, inv.Discounts)
 	tax.Normalize(normalizers, inv.Charges)
+	tax.Normalize(normalizers, inv.Outlays)
 	tax.Normalize(normalizers, inv.Ordering)
 	tax.Normalize(normalizers, inv.Payment)
 }
@@ -391,165 +400,8 @@ func (inv *Invoice) supplierTaxCountry() l10n.TaxCountryCode {
 	return inv.Supplier.TaxID.Country
 }
 
-// calculate does not assume that the tax regime is available.
 func (inv *Invoice) calculate() error {
-	r := inv.RegimeDef() // may be nil!
-
-	// Normalize data
-	if inv.IssueDate.IsZero() {
-		inv.IssueDate = cal.TodayIn(r.TimeLocation())
-	}
-	date := inv.ValueDate
-	if date == nil {
-		date = &inv.IssueDate
-	}
-
-	// Convert empty or invalid currency to the regime's currency
-	if inv.Currency == currency.CodeEmpty || inv.Currency.Def() == nil {
-		if r == nil {
-			return validation.Errors{"currency": errors.New("missing")}
-		}
-		inv.Currency = r.Currency
-	}
-
-	// Prepare the totals we'll need with amounts based on currency
-	if inv.Totals == nil {
-		inv.Totals = new(Totals)
-	}
-	t := inv.Totals
-	zero := inv.Currency.Def().Zero()
-	t.reset(zero)
-
-	// Do we need to deal with the customer-rates tag?
-	if inv.HasTags(tax.TagCustomerRates) {
-		inv.applyCustomerRates()
-	}
-
-	// Lines
-	if err := calculateLines(inv.Lines, inv.Currency, inv.ExchangeRates); err != nil {
-		return validation.Errors{"lines": err}
-	}
-	t.Sum = calculateLineSum(inv.Lines, inv.Currency)
-	t.Total = t.Sum
-
-	// Discount Lines
-	calculateDiscounts(inv.Discounts, t.Sum, zero)
-	if discounts := calculateDiscountSum(inv.Discounts, zero); discounts != nil {
-		t.Discount = discounts
-		t.Total = t.Total.Subtract(*discounts)
-	}
-
-	// Charge Lines
-	calculateCharges(inv.Charges, t.Sum, zero)
-	if charges := calculateChargeSum(inv.Charges, zero); charges != nil {
-		t.Charge = charges
-		t.Total = t.Total.Add(*charges)
-	}
-
-	// Build list of taxable lines
-	tls := make([]tax.TaxableLine, 0)
-	for _, l := range inv.Lines {
-		tls = append(tls, l)
-	}
-	for _, l := range inv.Discounts {
-		tls = append(tls, l)
-	}
-	for _, l := range inv.Charges {
-		tls = append(tls, l)
-	}
-
-	// Now figure out the tax totals
-	var pit cbc.Code
-	if inv.Tax != nil && inv.Tax.PricesInclude != "" {
-		pit = inv.Tax.PricesInclude
-	}
-	t.Taxes = new(tax.Total)
-	tc := &tax.TotalCalculator{
-		Zero:     zero,
-		Country:  inv.Regime.Country,
-		Tags:     inv.GetTags(),
-		Date:     *date,
-		Lines:    tls,
-		Includes: pit,
-	}
-	if err := tc.Calculate(t.Taxes); err != nil {
-		return err
-	}
-
-	// Remove any included taxes from the total.
-	ct := t.Taxes.Category(pit)
-	if ct != nil {
-		ti := ct.PreciseAmount()
-		t.TaxIncluded = &ti
-		t.Total = t.Total.Subtract(ti)
-	}
-
-	// Finally calculate the total with *all* the taxes.
-	t.Tax = t.Taxes.PreciseSum()
-	t.TotalWithTax = t.Total.Add(t.Tax)
-	t.Payable = t.TotalWithTax
-	if t.Rounding != nil {
-		// BT-144 in EN16931
-		t.Payable = t.Payable.Add(*t.Rounding)
-	}
-
-	// Remove taxes object if it doesn't contain any categories
-	if len(t.Taxes.Categories) == 0 {
-		t.Taxes = nil
-	}
-
-	if inv.Payment != nil {
-		inv.Payment.calculateAdvances(zero, t.TotalWithTax)
-
-		// Deal with advances, if any
-		if t.Advances = inv.Payment.totalAdvance(zero); t.Advances != nil {
-			v := t.Payable.Subtract(*t.Advances)
-			t.Due = &v
-		}
-
-		// Calculate any due date amounts
-		inv.Payment.Terms.CalculateDues(zero, t.Payable)
-	}
-
-	t.round(zero)
-
-	// Complements
-	if err := calculateComplements(inv.Complements); err != nil {
-		return validation.Errors{"complements": err}
-	}
-
-	return nil
-}
-
-func (inv *Invoice) applyCustomerRates() {
-	if inv.Customer == nil || inv.Customer.TaxID == nil {
-		return
-	}
-	country := inv.Customer.TaxID.Country
-	for _, l := range inv.Lines {
-		addCountryToTaxes(l.Taxes, country)
-	}
-	for _, d := range inv.Discounts {
-		addCountryToTaxes(d.Taxes, country)
-	}
-	for _, c := range inv.Charges {
-		addCountryToTaxes(c.Taxes, country)
-	}
-}
-
-func addCountryToTaxes(ts tax.Set, country l10n.TaxCountryCode) {
-	for _, t := range ts {
-		t.Country = country
-	}
-}
-
-func calculateComplements(comps []*schema.Object) error {
-	for _, c := range comps {
-		if err := c.Calculate(); err != nil {
-			return err
-		}
-	}
-	return nil
+	return calculate(inv)
 }
 
 // UnmarshalJSON implements the json.Unmarshaler interface and provides any
@@ -594,3 +446,55 @@ func (inv Invoice) JSONSchemaExtend(js *jsonschema.Schema) {
 		},
 	}
 }
+
+// Internal methods used to satisfy the billable interface for calculations.
+
+func (inv *Invoice) getIssueDate() cal.Date {
+	return inv.IssueDate
+}
+func (inv *Invoice) getValueDate() *cal.Date {
+	return inv.ValueDate
+}
+func (inv *Invoice) getTax() *Tax {
+	return inv.Tax
+}
+func (inv *Invoice) getCustomer() *org.Party {
+	return inv.Customer
+}
+func (inv *Invoice) getCurrency() currency.Code {
+	return inv.Currency
+}
+func (inv *Invoice) getExchangeRates() []*currency.ExchangeRate {
+	return inv.ExchangeRates
+}
+func (inv *Invoice) getLines() []*Line {
+	return inv.Lines
+}
+func (inv *Invoice) getDiscounts() []*Discount {
+	return inv.Discounts
+}
+func (inv *Invoice) getCharges() []*Charge {
+	return inv.Charges
+}
+func (inv *Invoice) getOutlays() []*Outlay {
+	return inv.Outlays
+}
+func (inv *Invoice) getPayment() *PaymentDetails {
+	return inv.Payment
+}
+func (inv *Invoice) getTotals() *Totals {
+	return inv.Totals
+}
+func (inv *Invoice) getComplements() []*schema.Object {
+	return inv.Complements
+}
+
+func (inv *Invoice) setIssueDate(d cal.Date) {
+	inv.IssueDate = d
+}
+func (inv *Invoice) setCurrency(c currency.Code) {
+	inv.Currency = c
+}
+func (inv *Invoice) setTotals(t *Totals) {
+	inv.Totals = t
+}
@@ -5,6 +5,7 @@ import (
 
 	"github.com/invopop/gobl/currency"
 	"github.com/invopop/gobl/pay"
+	"github.com/invopop/gobl/tax"
 )
 
 // ConvertInto will use the defined exchange rates in the invoice to convert all the prices
@@ -40,6 +41,7 @@ func (inv *Invoice) ConvertInto(cur currency.Code) (*Invoice, error) {
 	i2.Lines = inv.convertLines(ex)
 	i2.Discounts = inv.convertDiscounts(ex)
 	i2.Charges = inv.convertCharges(ex)
+	i2.Outlays = inv.convertOutlays(ex)
 	i2.Payment = inv.convertPayment(ex)
 	i2.Currency = cur
 
@@ -109,10 +111,29 @@ func (l *Line) convertInto(ex *currency.ExchangeRate) *Line {
 		l2.Charges = rows
 	}
 
+	l2.Taxes = convertTaxes(l.Taxes, ex)
 	l2.Item = &l2i
 	return &l2
 }
 
+// convertTaxes copies the tax combos so that any fixed amounts can be
+// exchanged without modifying the originals.
+func convertTaxes(taxes tax.Set, ex *currency.ExchangeRate) tax.Set {
+	if len(taxes) == 0 {
+		return taxes
+	}
+	ts := make(tax.Set, len(taxes))
+	for i, c := range taxes {
+		c2 := *c
+		if c2.Amount != nil {
+			a := c2.Amount.Upscale(defaultCurrencyConversionAccuracy).Multiply(ex.Amount)
+			c2.Amount = &a
+		}
+		ts[i] = &c2
+	}
+	return ts
+}
+
 func (inv *Invoice) convertDiscounts(ex *currency.ExchangeRate) []*Discount {
 	if len(inv.Discounts) == 0 {
 		return nil
@@ -135,7 +156,18 @@ func (inv *Invoice) convertCharges(ex *currency.ExchangeRate) []*Charge {
 	return charges
 }
 
-func (inv *Invoice) convertPayment(ex *currency.ExchangeRate) *Payment {
+func (inv *Invoice) convertOutlays(ex *currency.ExchangeRate) []*Outlay {
+	if len(inv.Outlays) == 0 {
+		return nil
+	}
+	outlays := make([]*Outlay, len(inv.Outlays))
+	for i, o := range inv.Outlays {
+		outlays[i] = o.convertInto(ex)
+	}
+	return outlays
+}
+
+func (inv *Invoice) convertPayment(ex *currency.ExchangeRate) *PaymentDetails {
 	if inv.Payment == nil {
 		return nil
 	}
@@ -10,6 +10,7 @@ import (
 	"github.com/invopop/gobl/num"
 	"github.com/invopop/gobl/org"
 	"github.com/invopop/gobl/pay"
+	"github.com/invopop/gobl/regimes/es"
 	"github.com/invopop/gobl/tax"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -57,6 +58,45 @@ func TestInvoiceConvertInto(t *testing.T) {
 		assert.JSONEq(t, `[{"amount":"1.12","from":"EUR","to":"USD"}]`, string(ex))
 	})
 
+	t.Run("conversion with fixed amount taxes", func(t *testing.T) {
+		inv := baseInvoice(t, &bill.Line{
+			Quantity: num.MakeAmount(10, 0),
+			Item: &org.Item{
+				Name:  "Test Item",
+				Price: num.MakeAmount(1000, 2),
+			},
+			Taxes: tax.Set{
+				{
+					Category: "VAT",
+					Rate:     tax.RateStandard,
+				},
+				{
+					Category: es.TaxCategoryIEEPNR,
+					Rate:     tax.RateStandard,
+				},
+				{
+					Category: es.TaxCategoryIPSI,
+					Amount:   num.NewAmount(30, 2),
+				},
+			},
+		})
+		inv.IssueDate = cal.MakeDate(2024, 3, 1)
+		inv.Tax = nil
+		inv.ExchangeRates = []*currency.ExchangeRate{
+			{
+				From:   currency.EUR,
+				To:     currency.USD,
+				Amount: num.MakeAmount(112, 2),
+			},
+		}
+		i2, err := inv.ConvertInto(currency.USD)
+		require.NoError(t, err)
+		assert.Equal(t, "5.04", i2.Totals.Taxes.Category(es.TaxCategoryIEEPNR).Amount.String())
+		assert.Equal(t, "3.36", i2.Totals.Taxes.Category(es.TaxCategoryIPSI).Amount.String())
+		assert.Equal(t, "0.45", inv.Lines[0].Taxes[1].Amount.String())
+		assert.Equal(t, "0.30", inv.Lines[0].Taxes[2].Amount.String())
+	})
+
 	t.Run("conversion with alt prices", func(t *testing.T) {
 		lines := []*bill.Line{
 			{
@@ -148,7 +188,7 @@ func TestInvoiceConvertInto(t *testing.T) {
 					},
 				},
 			},
-			Payment: &bill.Payment{
+			Payment: &bill.PaymentDetails{
 				Advances: []*pay.Advance{
 					{
 						Description: "Test Advance",
@@ -10,6 +10,8 @@ import (
 	"github.com/invopop/gobl/cbc"
 	"github.com/invopop/gobl/data"
 	"github.com/invopop/gobl/head"
+	"github.com/invopop/gobl/l10n"
+	"github.com/invopop/gobl/num"
 	"github.com/invopop/gobl/org"
 	"github.com/invopop/gobl/schema"
 	"github.com/invopop/gobl/tax"
@@ -35,6 +37,10 @@ type CorrectionOptions struct {
 	Reason string `json:"reason,omitempty" jsonschema:"title=Reason"`
 	// Extensions for region specific requirements.
 	Ext tax.Extensions `json:"ext,omitempty" jsonschema:"title=Extensions"`
+	// Lines from the previous invoice to include in the corrective document,
+	// with optional partial quantities or amounts. All lines will be kept
+	// if empty.
+	Lines []*LineSelection `json:"lines,omitempty" jsonschema:"title=Lines"`
 
 	// In case we want to use a raw json object as a source of the options.
 	data json.RawMessage `json:"-"`
@@ -98,6 +104,15 @@ func WithExtension(key cbc.Key, code tax.ExtValue) schema.Option {
 	}
 }
 
+// WithLines limits the corrective document to the selection of lines
+// from the previous invoice, usually to credit partial returns.
+func WithLines(lines ...*LineSelection) schema.Option {
+	return func(o interface{}) {
+		opts := o.(*CorrectionOptions)
+		opts.Lines = lines
+	}
+}
+
 // WithIssueDate can be used to override the issue date of the corrective invoice
 // produced.
 func WithIssueDate(date cal.Date) schema.Option {
@@ -132,6 +147,17 @@ var Debit schema.Option = func(o interface{}) {
 // that can be used on the invoice in order to correct it. Data is
 // extracted from the tax regime associated with the supplier.
 func (inv *Invoice) CorrectionOptionsSchema() (interface{}, error) {
+	var cd *tax.CorrectionDefinition
+	if inv.RegimeDef() != nil {
+		cd = inv.correctionDef()
+	}
+	return correctionOptionsSchema(inv.GetRegime(), inv.Series, cd, InvoiceTypes)
+}
+
+// correctionOptionsSchema prepares the correction options JSON schema
+// for the regime, series, and correction definition provided, using
+// the list of type definitions to describe the available types.
+func correctionOptionsSchema(regime l10n.TaxCountryCode, series cbc.Code, cd *tax.CorrectionDefinition, types []*cbc.KeyDefinition) (interface{}, error) {
 	js := new(jsonschema.Schema)
 
 	// try to load the pre-generated schema, this is just way more efficient
@@ -145,7 +171,7 @@ func (inv *Invoice) CorrectionOptionsSchema() (interface{}, error) {
 	}
 
 	// Add our tax country code to the schema ID
-	code := strings.ToLower(inv.GetRegime().String())
+	code := strings.ToLower(regime.String())
 	id := fmt.Sprintf("%s?tax_regime=%s", js.ID.String(), code)
 	js.ID = jsonschema.ID(id)
 	js.Comments = fmt.Sprintf("Generated dynamically for %s", code)
@@ -155,13 +181,8 @@ func (inv *Invoice) CorrectionOptionsSchema() (interface{}, error) {
 	// Always recommend the series
 	recommended := []string{"series"}
 
-	// Try to load the regime and its correction definition for the document
-	// type if there is one defined.
-	r := inv.RegimeDef()
-	if r == nil {
-		return js, nil
-	}
-	cd := inv.correctionDef()
+	// Only continue if we have a regime and correction definition
+	// for the document type.
 	if cd == nil {
 		return js, nil
 	}
@@ -171,7 +192,7 @@ func (inv *Invoice) CorrectionOptionsSchema() (interface{}, error) {
 			ps.Default = cd.Types[0].String() // pick first one
 			ps.OneOf = make([]*jsonschema.Schema, len(cd.Types))
 			for i, v := range cd.Types {
-				kd := cbc.GetKeyDefinition(v, InvoiceTypes)
+				kd := cbc.GetKeyDefinition(v, types)
 				ps.OneOf[i] = &jsonschema.Schema{
 					Const:       v.String(),
 					Title:       kd.Name.String(),
@@ -181,9 +202,9 @@ func (inv *Invoice) CorrectionOptionsSchema() (interface{}, error) {
 		}
 	}
 
-	if inv.Series != "" {
+	if series != "" {
 		if ps, ok := cos.Properties.Get("series"); ok {
-			ps.Default = inv.Series // copy series from invoice
+			ps.Default = series // copy series from document
 		}
 	}
 
@@ -262,10 +283,53 @@ func (inv *Invoice) Correct(opts ...schema.Option) error {
 	if err := prepareCorrectionOptions(o, opts...); err != nil {
 		return err
 	}
+	if o.Type == cbc.KeyEmpty {
+		return errors.New("missing correction type")
+	}
 	if inv.Code == "" {
 		return errors.New("cannot correct an invoice without a code")
 	}
 
+	// Prepare the correction on a copy so that the invoice is left
+	// untouched if any of the checks fail.
+	c, err := cloneJSON(inv)
+	if err != nil {
+		return err
+	}
+	if inv.Totals != nil && c.Totals != nil {
+		// not serialized, but needed to recalculate cash rounding
+		c.Totals.cashRounding = inv.Totals.cashRounding
+	}
+	if err := c.correct(o); err != nil {
+		return err
+	}
+	*inv = *c
+	return nil
+}
+
+func (inv *Invoice) correct(o *CorrectionOptions) error {
+	var total *num.Amount
+	if len(o.Lines) > 0 {
+		// Ensure the original amounts are up to date before selecting
+		if err := inv.Calculate(); err != nil {
+			return err
+		}
+		t := inv.Totals.TotalWithTax
+		total = &t
+		lines, err := selectLines(inv.Lines, o.Lines)
+		if err != nil {
+			return fmt.Errorf("lines: %w", err)
+		}
+		inv.Lines = lines
+		if inv.Discounts, err = selectDiscounts(inv.Discounts, o.Lines); err != nil {
+			return fmt.Errorf("discounts: %w", err)
+		}
+		if inv.Charges, err = selectCharges(inv.Charges, o.Lines); err != nil {
+			return fmt.Errorf("charges: %w", err)
+		}
+		inv.Outlays = nil // reimbursed separately from the lines
+	}
+
 	// Copy and prepare the basic fields
 	pre := &org.DocumentRef{
 		Identify:  uuid.Identify{UUID: inv.UUID},
@@ -275,6 +339,7 @@ func (inv *Invoice) Correct(opts ...schema.Option) error {
 		IssueDate: inv.IssueDate.Clone(),
 		Reason:    o.Reason,
 		Ext:       o.Ext,
+		Lines:     selectedLineIndexes(o.Lines),
 	}
 	inv.UUID = ""
 	inv.Type = o.Type
@@ -299,25 +364,39 @@ func (inv *Invoice) Correct(opts ...schema.Option) error {
 	// Running a Calculate feels a bit out of place, but not performing
 	// this operation on the corrected invoice results in potentially
 	// conflicting or incomplete data.
-	return inv.Calculate()
+	if err := inv.Calculate(); err != nil {
+		return err
+	}
+
+	// Partial credits must never exceed the original
+	if total != nil && inv.Type == InvoiceTypeCreditNote {
+		if inv.Totals.TotalWithTax.Compare(*total) > 0 {
+			return fmt.Errorf("credit total %s exceeds original %s", inv.Totals.TotalWithTax, total)
+		}
+	}
+
+	return nil
 }
 
 // correctionDef tries to determine a final correction definition
 // by merge potentially multiple sources. The results include
 // a key that can be used to identify the definition.
 func (inv *Invoice) correctionDef() *tax.CorrectionDefinition {
+	return correctionDefFor(ShortSchemaInvoice, inv.RegimeDef(), inv.AddonDefs())
+}
+
+// correctionDefFor merges the correction definitions for the schema
+// provided from the regime and list of addons.
+func correctionDefFor(schema string, r *tax.RegimeDef, addons []*tax.AddonDef) *tax.CorrectionDefinition {
 	cd := &tax.CorrectionDefinition{
-		Schema: ShortSchemaInvoice,
+		Schema: schema,
 	}
-
-	r := inv.RegimeDef()
 	if r != nil {
-		cd = cd.Merge(r.Corrections.Def(ShortSchemaInvoice))
+		cd = cd.Merge(r.Corrections.Def(schema))
 	}
-	for _, a := range inv.AddonDefs() {
-		cd = cd.Merge(a.Corrections.Def(ShortSchemaInvoice))
+	for _, a := range addons {
+		cd = cd.Merge(a.Corrections.Def(schema))
 	}
-
 	return cd
 }
 
@@ -338,10 +417,6 @@ func prepareCorrectionOptions(o *CorrectionOptions, opts ...schema.Option) error
 		}
 	}
 
-	if o.Type == cbc.KeyEmpty {
-		return errors.New("missing correction type")
-	}
-
 	return nil
 }
 
@@ -349,6 +424,25 @@ func (inv *Invoice) validatePrecedingData(o *CorrectionOptions, cd *tax.Correcti
 	if cd == nil {
 		return nil
 	}
+	if err := copyCorrectionStamps(o, cd, pre); err != nil {
+		return err
+	}
+
+	if !o.Type.In(cd.Types...) {
+		return fmt.Errorf("invalid correction type: %v", o.Type.String())
+	}
+
+	if cd.ReasonRequired && pre.Reason == "" {
+		return errors.New("missing corrective reason")
+	}
+
+	return nil
+}
+
+// copyCorrectionStamps ensures the stamps required by the correction definition
+// have been provided in the options and copies them to the preceding document
+// reference.
+func copyCorrectionStamps(o *CorrectionOptions, cd *tax.CorrectionDefinition, pre *org.DocumentRef) error {
 	for _, k := range cd.Stamps {
 		var s *head.Stamp
 		for _, row := range o.Stamps {
@@ -362,14 +456,5 @@ func (inv *Invoice) validatePrecedingData(o *CorrectionOptions, cd *tax.Correcti
 		}
 		pre.Stamps = append(pre.Stamps, s)
 	}
-
-	if !o.Type.In(cd.Types...) {
-		return fmt.Errorf("invalid correction type: %v", o.Type.String())
-	}
-
-	if cd.ReasonRequired && pre.Reason == "" {
-		return errors.New("missing corrective reason")
-	}
-
 	return nil
 }
@@ -170,7 +170,7 @@ func TestCorrectionOptionsSchema(t *testing.T) {
 	require.True(t, ok)
 
 	cos := schema.Definitions["CorrectionOptions"]
-	assert.Equal(t, cos.Properties.Len(), 6)
+	assert.Equal(t, cos.Properties.Len(), 7)
 
 	pm, ok := cos.Properties.Get("ext")
 	require.True(t, ok)
@@ -182,7 +182,7 @@ func TestCorrectionOptionsSchema(t *testing.T) {
 	}
 
 	// Sorry, this is copied and pasted from the test output!
-	exp := `{"properties":{"type":{"$ref":"https://gobl.org/draft-0/cbc/key","oneOf":[{"const":"credit-note","title":"Credit Note","description":"Reflects a refund either partial or complete of the preceding document. A \ncredit note effectively *extends* the previous document."},{"const":"corrective","title":"Corrective","description":"Corrected invoice that completely *replaces* the preceding document."},{"const":"debit-note","title":"Debit Note","description":"An additional set of charges to be added to the preceding document."}],"title":"Type","description":"The type of corrective invoice to produce.","default":"credit-note"},"issue_date":{"$ref":"https://gobl.org/draft-0/cal/date","title":"Issue Date","description":"When the new corrective invoice's issue date should be set to."},"series":{"$ref":"https://gobl.org/draft-0/cbc/code","title":"Series","description":"Series to assign to the new corrective invoice.","default":"TEST"},"stamps":{"items":{"$ref":"https://gobl.org/draft-0/head/stamp"},"type":"array","title":"Stamps","description":"Stamps of the previous document to include in the preceding data."},"reason":{"type":"string","title":"Reason","description":"Human readable reason for the corrective operation."},"ext":{"properties":{"es-facturae-correction":{"oneOf":[{"const":"01","title":"Invoice code"},{"const":"02","title":"Invoice series"},{"const":"03","title":"Issue date"},{"const":"04","title":"Name and surnames/Corporate name - Issuer (Sender)"},{"const":"05","title":"Name and surnames/Corporate name - Receiver"},{"const":"06","title":"Issuer's Tax Identification Number"},{"const":"07","title":"Receiver's Tax Identification Number"},{"const":"08","title":"Supplier's address"},{"const":"09","title":"Customer's address"},{"const":"10","title":"Item line"},{"const":"11","title":"Applicable Tax Rate"},{"const":"12","title":"Applicable Tax Amount"},{"const":"13","title":"Applicable Date/Period"},{"const":"14","title":"Invoice Class"},{"const":"15","title":"Legal literals"},{"const":"16","title":"Taxable Base"},{"const":"80","title":"Calculation of tax outputs"},{"const":"81","title":"Calculation of tax inputs"},{"const":"82","title":"Taxable Base modified due to return of packages and packaging materials"},{"const":"83","title":"Taxable Base modified due to discounts and rebates"},{"const":"84","title":"Taxable Base modified due to firm court ruling or administrative decision"},{"const":"85","title":"Taxable Base modified due to unpaid outputs where there is a judgement opening insolvency proceedings"}],"type":"string","title":"FacturaE Change","description":"FacturaE requires a specific and single code that explains why the previous invoice is being corrected."}},"type":"object","title":"Extensions","description":"Extensions for region specific requirements.","recommended":["es-facturae-correction"]}},"type":"object","required":["type"],"description":"CorrectionOptions defines a structure used to pass configuration options to correct a previous invoice.","recommended":["series","ext"]}`
+	exp := `{"properties":{"type":{"$ref":"https://gobl.org/draft-0/cbc/key","oneOf":[{"const":"credit-note","title":"Credit Note","description":"Reflects a refund either partial or complete of the preceding document. A \ncredit note effectively *extends* the previous document."},{"const":"corrective","title":"Corrective","description":"Corrected invoice that completely *replaces* the preceding document."},{"const":"debit-note","title":"Debit Note","description":"An additional set of charges to be added to the preceding document."}],"title":"Type","description":"The type of corrective invoice to produce.","default":"credit-note"},"issue_date":{"$ref":"https://gobl.org/draft-0/cal/date","title":"Issue Date","description":"When the new corrective invoice's issue date should be set to."},"series":{"$ref":"https://gobl.org/draft-0/cbc/code","title":"Series","description":"Series to assign to the new corrective invoice.","default":"TEST"},"stamps":{"items":{"$ref":"https://gobl.org/draft-0/head/stamp"},"type":"array","title":"Stamps","description":"Stamps of the previous document to include in the preceding data."},"reason":{"type":"string","title":"Reason","description":"Human readable reason for the corrective operation."},"ext":{"properties":{"es-facturae-correction":{"oneOf":[{"const":"01","title":"Invoice code"},{"const":"02","title":"Invoice series"},{"const":"03","title":"Issue date"},{"const":"04","title":"Name and surnames/Corporate name - Issuer (Sender)"},{"const":"05","title":"Name and surnames/Corporate name - Receiver"},{"const":"06","title":"Issuer's Tax Identification Number"},{"const":"07","title":"Receiver's Tax Identification Number"},{"const":"08","title":"Supplier's address"},{"const":"09","title":"Customer's address"},{"const":"10","title":"Item line"},{"const":"11","title":"Applicable Tax Rate"},{"const":"12","title":"Applicable Tax Amount"},{"const":"13","title":"Applicable Date/Period"},{"const":"14","title":"Invoice Class"},{"const":"15","title":"Legal literals"},{"const":"16","title":"Taxable Base"},{"const":"80","title":"Calculation of tax outputs"},{"const":"81","title":"Calculation of tax inputs"},{"const":"82","title":"Taxable Base modified due to return of packages and packaging materials"},{"const":"83","title":"Taxable Base modified due to discounts and rebates"},{"const":"84","title":"Taxable Base modified due to firm court ruling or administrative decision"},{"const":"85","title":"Taxable Base modified due to unpaid outputs where there is a judgement opening insolvency proceedings"}],"type":"string","title":"FacturaE Change","description":"FacturaE requires a specific and single code that explains why the previous invoice is being corrected."}},"type":"object","title":"Extensions","description":"Extensions for region specific requirements.","recommended":["es-facturae-correction"]},"lines":{"items":{"$ref":"#/$defs/LineSelection"},"type":"array","title":"Lines","description":"Lines from the previous invoice to include in the corrective document,\nwith optional partial quantities or amounts. All lines will be kept\nif empty."}},"type":"object","required":["type"],"description":"CorrectionOptions defines a structure used to pass configuration options to correct a previous invoice.","recommended":["series","ext"]}`
 	data, err := json.Marshal(cos)
 	require.NoError(t, err)
 	if !assert.JSONEq(t, exp, string(data)) {
@@ -212,6 +212,144 @@ func TestCorrectWithData(t *testing.T) {
 	assert.Contains(t, err.Error(), "unexpected end of JSON input")
 }
 
+func TestInvoiceCorrectLines(t *testing.T) {
+	addLine := func(inv *bill.Invoice) {
+		inv.Lines = append(inv.Lines, &bill.Line{
+			Quantity: num.MakeAmount(2, 0),
+			Item: &org.Item{
+				Name:  "Other Item",
+				Price: num.MakeAmount(5000, 2),
+			},
+			Taxes: tax.Set{
+				{
+					Category: "VAT",
+					Rate:     "standard",
+				},
+			},
+		})
+	}
+	t.Run("partial quantity", func(t *testing.T) {
+		i := testInvoiceESForCorrection(t)
+		addLine(i)
+		qty := num.MakeAmount(3, 0)
+		err := i.Correct(
+			bill.Credit,
+			bill.WithExtension(facturae.ExtKeyCorrection, "01"),
+			bill.WithLines(&bill.LineSelection{Index: 1, Quantity: &qty}),
+		)
+		require.NoError(t, err)
+		require.Len(t, i.Lines, 1)
+		assert.Equal(t, "Test Item", i.Lines[0].Item.Name)
+		assert.Equal(t, "3", i.Lines[0].Quantity.String())
+		assert.Equal(t, "270.00", i.Totals.Payable.String())
+		assert.Equal(t, []int{1}, i.Preceding[0].Lines)
+	})
+	t.Run("partial amount", func(t *testing.T) {
+		i := testInvoiceESForCorrection(t)
+		addLine(i)
+		amount := num.MakeAmount(3000, 2)
+		err := i.Correct(
+			bill.Credit,
+			bill.WithExtension(facturae.ExtKeyCorrection, "01"),
+			bill.WithLines(
+				&bill.LineSelection{Index: 2, Amount: &amount},
+			),
+		)
+		require.NoError(t, err)
+		require.Len(t, i.Lines, 1)
+		assert.Equal(t, "Other Item", i.Lines[0].Item.Name)
+		assert.Equal(t, "2", i.Lines[0].Quantity.String())
+		assert.Equal(t, "15.00", i.Lines[0].Item.Price.String())
+		assert.Equal(t, "30.00", i.Totals.Payable.String())
+		assert.Equal(t, []int{2}, i.Preceding[0].Lines)
+	})
+	t.Run("uneven amount", func(t *testing.T) {
+		i := testInvoiceESForCorrection(t)
+		i.Tax = nil
+		addLine(i)
+		i.Lines[1].Quantity = num.MakeAmount(3, 0)
+		amount := num.MakeAmount(1000, 2)
+		err := i.Correct(
+			bill.Credit,
+			bill.WithExtension(facturae.ExtKeyCorrection, "01"),
+			bill.WithLines(&bill.LineSelection{Index: 2, Amount: &amount}),
+		)
+		require.NoError(t, err)
+		assert.Equal(t, "3.3333", i.Lines[0].Item.Price.String())
+		assert.Equal(t, "10.00", i.Totals.Sum.String())
+	})
+	t.Run("with options data", func(t *testing.T) {
+		i := testInvoiceESForCorrection(t)
+		addLine(i)
+		err := i.Correct(
+			bill.WithData(json.RawMessage(`{"type":"credit-note","ext":{"es-facturae-correction":"01"},"lines":[{"i":2,"quantity":"1"}]}`)),
+		)
+		require.NoError(t, err)
+		require.Len(t, i.Lines, 1)
+		assert.Equal(t, "50.00", i.Totals.Payable.String())
+	})
+	t.Run("exceeds original", func(t *testing.T) {
+		i := testInvoiceESForCorrection(t)
+		qty := num.MakeAmount(11, 0)
+		err := i.Correct(
+			bill.Credit,
+			bill.WithExtension(facturae.ExtKeyCorrection, "01"),
+			bill.WithLines(&bill.LineSelection{Index: 1, Quantity: &qty}),
+		)
+		assert.ErrorContains(t, err, "lines: line 1: quantity 11 exceeds original 10")
+
+		i = testInvoiceESForCorrection(t)
+		amount := num.MakeAmount(100000, 2)
+		err = i.Correct(
+			bill.Credit,
+			bill.WithExtension(facturae.ExtKeyCorrection, "01"),
+			bill.WithLines(&bill.LineSelection{Index: 1, Amount: &amount}),
+		)
+		assert.ErrorContains(t, err, "lines: line 1: amount 1000.00 exceeds original 900.00")
+
+		i = testInvoiceESForCorrection(t)
+		addLine(i)
+		i.Discounts = []*bill.Discount{
+			{
+				Reason: "Fixed",
+				Amount: num.MakeAmount(80000, 2),
+			},
+		}
+		err = i.Correct(
+			bill.Credit,
+			bill.WithExtension(facturae.ExtKeyCorrection, "01"),
+			bill.WithLines(&bill.LineSelection{Index: 1}),
+		)
+		assert.ErrorContains(t, err, "credit total 900.00 exceeds original 200.00")
+		assert.Empty(t, i.Type, "invoice should not be modified")
+		assert.NotEmpty(t, i.Code)
+		assert.Len(t, i.Lines, 2)
+		assert.Empty(t, i.Preceding)
+	})
+	t.Run("missing line", func(t *testing.T) {
+		i := testInvoiceESForCorrection(t)
+		err := i.Correct(
+			bill.Credit,
+			bill.WithExtension(facturae.ExtKeyCorrection, "01"),
+			bill.WithLines(&bill.LineSelection{Index: 2}),
+		)
+		assert.ErrorContains(t, err, "lines: line 2: not found")
+	})
+	t.Run("repeated line", func(t *testing.T) {
+		i := testInvoiceESForCorrection(t)
+		addLine(i)
+		err := i.Correct(
+			bill.Credit,
+			bill.WithExtension(facturae.ExtKeyCorrection, "01"),
+			bill.WithLines(
+				&bill.LineSelection{Index: 2},
+				&bill.LineSelection{Index: 2},
+			),
+		)
+		assert.ErrorContains(t, err, "lines: line 2: selected more than once")
+	})
+}
+
 func testInvoiceESForCorrection(t *testing.T) *bill.Invoice {
 	t.Helper()
 	i := &bill.Invoice{
@@ -0,0 +1,320 @@
+package bill
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/invopop/gobl/org"
+	"github.com/invopop/gobl/schema"
+	"github.com/invopop/gobl/tax"
+	"github.com/invopop/gobl/uuid"
+)
+
+// InvoiceSource describes a document to be used as the basis for a new
+// invoice alongside an optional selection of its lines.
+type InvoiceSource struct {
+	// Order or delivery document to copy data from.
+	Document *schema.Object `json:"doc" jsonschema:"title=Document"`
+	// Lines to copy from the source document, all lines will be used if empty.
+	Lines []*LineSelection `json:"lines,omitempty" jsonschema:"title=Lines"`
+}
+
+// NewInvoiceFrom builds a new draft standard invoice from one or more order
+// or delivery documents. Each document may be provided directly as an
+// *Order or *Delivery, wrapped inside a *schema.Object, or as an
+// *InvoiceSource to select specific lines with partial quantities or
+// amounts.
+//
+// The parties, currency, tax, payment, and delivery details will be copied
+// from the first document, and all documents must share the same supplier,
+// customer, and currency. Lines from each document will be appended in order,
+// and references to each source document added to the invoice's ordering
+// details. Only percentage based document level discounts and charges will
+// be copied when specific lines have been selected.
+//
+// The resulting invoice will be calculated, but not validated, so that it may
+// be completed with any missing details, like prices for lines coming from
+// unvalued deliveries, before being issued.
+func NewInvoiceFrom(docs ...any) (*Invoice, error) {
+	if len(docs) == 0 {
+		return nil, errors.New("no source documents provided")
+	}
+	inv := &Invoice{
+		Type: InvoiceTypeStandard,
+	}
+	for i, doc := range docs {
+		src, err := prepareInvoiceSource(doc)
+		if err != nil {
+			return nil, fmt.Errorf("source %d: %w", i, err)
+		}
+		if err := inv.addSource(src, i == 0); err != nil {
+			return nil, fmt.Errorf("source %d: %w", i, err)
+		}
+	}
+	if err := inv.Calculate(); err != nil {
+		return nil, err
+	}
+	return inv, nil
+}
+
+func prepareInvoiceSource(doc any) (*InvoiceSource, error) {
+	switch d := doc.(type) {
+	case *InvoiceSource:
+		if d == nil || d.Document == nil {
+			return nil, errors.New("missing document")
+		}
+		return d, nil
+	case *schema.Object:
+		return &InvoiceSource{Document: d}, nil
+	case *Order, *Delivery:
+		obj, err := schema.NewObject(d)
+		if err != nil {
+			return nil, err
+		}
+		return &InvoiceSource{Document: obj}, nil
+	}
+	return nil, fmt.Errorf("unsupported document type: %T", doc)
+}
+
+// addSource copies the contents of the source document into the invoice.
+func (inv *Invoice) addSource(src *InvoiceSource, first bool) error {
+	var sd *invoiceSourceData
+	switch d := src.Document.Instance().(type) {
+	case *Order:
+		sd = orderSourceData(d)
+	case *Delivery:
+		sd = deliverySourceData(d)
+	default:
+		return fmt.Errorf("unsupported document type: %T", d)
+	}
+
+	if first {
+		if err := inv.copySource(sd); err != nil {
+			return err
+		}
+	} else if err := inv.matchSource(sd); err != nil {
+		return err
+	}
+
+	lines, err := selectLines(sd.doc.getLines(), src.Lines)
+	if err != nil {
+		return err
+	}
+	inv.Lines = append(inv.Lines, lines...)
+	discounts, err := selectDiscounts(sd.doc.getDiscounts(), src.Lines)
+	if err != nil {
+		return err
+	}
+	inv.Discounts = append(inv.Discounts, discounts...)
+	charges, err := selectCharges(sd.doc.getCharges(), src.Lines)
+	if err != nil {
+		return err
+	}
+	inv.Charges = append(inv.Charges, charges...)
+
+	// Maintain the reference trail
+	o := inv.Ordering
+	if sd.ordering != nil {
+		if o.Purchases, err = appendDocumentRefs(o.Purchases, sd.ordering.Purchases...); err != nil {
+			return err
+		}
+		if o.Sales, err = appendDocumentRefs(o.Sales, sd.ordering.Sales...); err != nil {
+			return err
+		}
+		if o.Despatch, err = appendDocumentRefs(o.Despatch, sd.ordering.Despatch...); err != nil {
+			return err
+		}
+		if o.Receiving, err = appendDocumentRefs(o.Receiving, sd.ordering.Receiving...); err != nil {
+			return err
+		}
+	}
+	ref := sd.ref
+	ref.Lines = selectedLineIndexes(src.Lines)
+	var list *[]*org.DocumentRef
+	switch sd.list {
+	case sourceListPurchases:
+		list = &o.Purchases
+	case sourceListSales:
+		list = &o.Sales
+	case sourceListDespatch:
+		list = &o.Despatch
+	case sourceListReceiving:
+		list = &o.Receiving
+	}
+	if *list, err = appendDocumentRefs(*list, ref); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// copySource prepares the invoice's base details using copies of those in
+// the first source document, so that calculating the invoice will not
+// modify the source.
+func (inv *Invoice) copySource(sd *invoiceSourceData) error {
+	var err error
+	inv.Regime = sd.regime
+	inv.Addons = sd.addons
+	inv.Currency = sd.doc.getCurrency()
+	if inv.ExchangeRates, err = cloneJSON(sd.doc.getExchangeRates()); err != nil {
+		return err
+	}
+	if inv.Tax, err = cloneJSON(sd.doc.getTax()); err != nil {
+		return err
+	}
+	if inv.Supplier, err = cloneJSON(sd.supplier); err != nil {
+		return err
+	}
+	if inv.Customer, err = cloneJSON(sd.doc.getCustomer()); err != nil {
+		return err
+	}
+	if inv.Payment, err = cloneJSON(sd.doc.getPayment()); err != nil {
+		return err
+	}
+	if inv.Delivery, err = cloneJSON(sd.delivery); err != nil {
+		return err
+	}
+	inv.Ordering = new(Ordering)
+	if sd.ordering != nil {
+		if inv.Ordering, err = cloneJSON(sd.ordering); err != nil {
+			return err
+		}
+		// clear the lists that will be merged
+		inv.Ordering.Purchases = nil
+		inv.Ordering.Sales = nil
+		inv.Ordering.Despatch = nil
+		inv.Ordering.Receiving = nil
+	}
+	return nil
+}
+
+// matchSource ensures that the source document can be merged into the
+// invoice.
+func (inv *Invoice) matchSource(sd *invoiceSourceData) error {
+	if cur := sd.doc.getCurrency(); cur != inv.Currency {
+		return fmt.Errorf("currency mismatch: %s != %s", cur, inv.Currency)
+	}
+	if !sameParty(inv.Supplier, sd.supplier) {
+		return errors.New("supplier mismatch")
+	}
+	if !sameParty(inv.Customer, sd.doc.getCustomer()) {
+		return errors.New("customer mismatch")
+	}
+	return nil
+}
+
+// sameParty compares parties using their tax IDs when available, or names
+// otherwise.
+func sameParty(a, b *org.Party) bool {
+	if a == nil || b == nil {
+		return a == nil && b == nil
+	}
+	if a.TaxID != nil && b.TaxID != nil {
+		return a.TaxID.Country == b.TaxID.Country && a.TaxID.Code == b.TaxID.Code
+	}
+	return a.Name == b.Name
+}
+
+// appendDocumentRefs adds copies of the references to a new copy of the
+// list, skipping any that are already present, so that the source documents
+// are never modified through the invoice.
+func appendDocumentRefs(list []*org.DocumentRef, refs ...*org.DocumentRef) ([]*org.DocumentRef, error) {
+	out := make([]*org.DocumentRef, len(list), len(list)+len(refs))
+	copy(out, list)
+	for _, ref := range refs {
+		if ref == nil {
+			continue
+		}
+		found := false
+		for _, r := range out {
+			if r != nil && sameDocumentRef(r, ref) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			nr, err := cloneJSON(ref)
+			if err != nil {
+				return nil, err
+			}
+			out = append(out, nr)
+		}
+	}
+	return out, nil
+}
+
+// sameDocumentRef compares references using their UUIDs when available, or
+// their series and codes otherwise.
+func sameDocumentRef(a, b *org.DocumentRef) bool {
+	if !a.UUID.IsZero() && !b.UUID.IsZero() {
+		return a.UUID == b.UUID
+	}
+	return a.Code != "" && a.Series == b.Series && a.Code == b.Code
+}
+
+// sourceList identifies the ordering list in which a reference to the
+// source document should be added.
+type sourceList int
+
+const (
+	sourceListPurchases sourceList = iota
+	sourceListSales
+	sourceListDespatch
+	sourceListReceiving
+)
+
+// invoiceSourceData contains the details extracted from a source document.
+type invoiceSourceData struct {
+	doc      billable
+	regime   tax.Regime
+	addons   tax.Addons
+	supplier *org.Party
+	ordering *Ordering
+	delivery *DeliveryDetails
+	ref      *org.DocumentRef
+	list     sourceList
+}
+
+func orderSourceData(ord *Order) *invoiceSourceData {
+	sd := &invoiceSourceData{
+		doc:      ord,
+		regime:   ord.Regime,
+		addons:   ord.Addons,
+		supplier: ord.Supplier,
+		ordering: ord.Ordering,
+		delivery: ord.Delivery,
+		ref: &org.DocumentRef{
+			Identify:  uuid.Identify{UUID: ord.UUID},
+			Series:    ord.Series,
+			Code:      ord.Code,
+			IssueDate: ord.IssueDate.Clone(),
+		},
+		list: sourceListPurchases,
+	}
+	if ord.Type.In(OrderTypeSales, OrderTypeQuote) {
+		sd.list = sourceListSales
+	}
+	return sd
+}
+
+func deliverySourceData(dlv *Delivery) *invoiceSourceData {
+	sd := &invoiceSourceData{
+		doc:      dlv,
+		regime:   dlv.Regime,
+		addons:   dlv.Addons,
+		supplier: dlv.Supplier,
+		ordering: dlv.Ordering,
+		delivery: dlv.Delivery,
+		ref: &org.DocumentRef{
+			Identify:  uuid.Identify{UUID: dlv.UUID},
+			Series:    dlv.Series,
+			Code:      dlv.Code,
+			IssueDate: dlv.IssueDate.Clone(),
+		},
+		list: sourceListDespatch,
+	}
+	if dlv.Type.In(DeliveryTypeReceipt) {
+		sd.list = sourceListReceiving
+	}
+	return sd
+}
@@ -0,0 +1,262 @@
+package bill_test
+
+import (
+	"testing"
+
+	"github.com/invopop/gobl/bill"
+	"github.com/invopop/gobl/num"
+	"github.com/invopop/gobl/org"
+	"github.com/invopop/gobl/pay"
+	"github.com/invopop/gobl/schema"
+	"github.com/invopop/gobl/tax"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewInvoiceFrom(t *testing.T) {
+	t.Run("from order", func(t *testing.T) {
+		ord := testOrderStandard(t)
+		require.NoError(t, ord.Calculate())
+		inv, err := bill.NewInvoiceFrom(ord)
+		require.NoError(t, err)
+		assert.Equal(t, bill.InvoiceTypeStandard, inv.Type)
+		assert.Equal(t, "ES", inv.GetRegime().String())
+		assert.Equal(t, "Test Supplier", inv.Supplier.Name)
+		assert.Equal(t, "Test Customer", inv.Customer.Name)
+		require.Len(t, inv.Lines, 1)
+		assert.Equal(t, "900.00", inv.Lines[0].Total.String())
+		assert.Equal(t, "1089.00", inv.Totals.Payable.String())
+		require.NotNil(t, inv.Ordering)
+		require.Len(t, inv.Ordering.Purchases, 1)
+		assert.Equal(t, "PO-001", inv.Ordering.Purchases[0].Code.String())
+		assert.Equal(t, "2024-11-13", inv.Ordering.Purchases[0].IssueDate.String())
+		assert.Empty(t, inv.Ordering.Purchases[0].Lines)
+		assert.Empty(t, inv.Code)
+		require.NoError(t, inv.Validate())
+
+		// source should not be modified
+		inv.Lines[0].Quantity = num.MakeAmount(1, 0)
+		assert.Equal(t, "10", ord.Lines[0].Quantity.String())
+	})
+	t.Run("from sales order", func(t *testing.T) {
+		ord := testOrderStandard(t)
+		ord.Type = bill.OrderTypeSales
+		require.NoError(t, ord.Calculate())
+		inv, err := bill.NewInvoiceFrom(ord)
+		require.NoError(t, err)
+		assert.Empty(t, inv.Ordering.Purchases)
+		require.Len(t, inv.Ordering.Sales, 1)
+		assert.Equal(t, "PO-001", inv.Ordering.Sales[0].Code.String())
+	})
+	t.Run("from multiple deliveries", func(t *testing.T) {
+		ord := testOrderStandard(t)
+		require.NoError(t, ord.Calculate())
+		ordRef := &org.DocumentRef{Code: ord.Code, IssueDate: &ord.IssueDate}
+
+		dlv1 := testDeliveryStandard(t)
+		dlv1.Ordering = &bill.Ordering{
+			Code:      "REF-123",
+			Purchases: []*org.DocumentRef{ordRef},
+		}
+		dlv1.Lines[0].Item.Price = num.MakeAmount(1000, 2)
+		require.NoError(t, dlv1.Calculate())
+
+		dlv2 := testDeliveryStandard(t)
+		dlv2.Code = "DN-002"
+		dlv2.Ordering = &bill.Ordering{
+			Purchases: []*org.DocumentRef{ordRef},
+		}
+		dlv2.Lines[0].Quantity = num.MakeAmount(5, 0)
+		dlv2.Lines[0].Item.Price = num.MakeAmount(1000, 2)
+		require.NoError(t, dlv2.Calculate())
+
+		inv, err := bill.NewInvoiceFrom(dlv1, dlv2)
+		require.NoError(t, err)
+		require.Len(t, inv.Lines, 2)
+		assert.Equal(t, 2, inv.Lines[1].Index)
+		assert.Equal(t, "B-2024-11", inv.Lines[1].Identities[0].Code.String())
+		assert.Equal(t, "250.00", inv.Totals.Sum.String())
+		assert.Equal(t, "REF-123", inv.Ordering.Code.String())
+		require.Len(t, inv.Ordering.Purchases, 1)
+		assert.Equal(t, "PO-001", inv.Ordering.Purchases[0].Code.String())
+		require.Len(t, inv.Ordering.Despatch, 2)
+		assert.Equal(t, "DN-001", inv.Ordering.Despatch[0].Code.String())
+		assert.Equal(t, "DN-002", inv.Ordering.Despatch[1].Code.String())
+		assert.Len(t, dlv1.Ordering.Purchases, 1, "source should not be modified")
+	})
+	t.Run("references copied", func(t *testing.T) {
+		dlv1 := testDeliveryStandard(t)
+		require.NoError(t, dlv1.Calculate())
+
+		dlv2 := testDeliveryStandard(t)
+		dlv2.Code = "DN-002"
+		dlv2.Ordering = &bill.Ordering{
+			Purchases: []*org.DocumentRef{{Code: "PO-002"}},
+		}
+		require.NoError(t, dlv2.Calculate())
+
+		inv, err := bill.NewInvoiceFrom(dlv1, dlv2)
+		require.NoError(t, err)
+		require.Len(t, inv.Ordering.Purchases, 1)
+		assert.NotSame(t, dlv2.Ordering.Purchases[0], inv.Ordering.Purchases[0])
+		inv.Ordering.Purchases[0].Code = "PO-999"
+		assert.Equal(t, "PO-002", dlv2.Ordering.Purchases[0].Code.String(), "source should not be modified")
+	})
+	t.Run("from delivery receipt", func(t *testing.T) {
+		dlv := testDeliveryStandard(t)
+		dlv.Type = bill.DeliveryTypeReceipt
+		require.NoError(t, dlv.Calculate())
+		inv, err := bill.NewInvoiceFrom(dlv)
+		require.NoError(t, err)
+		assert.Empty(t, inv.Ordering.Despatch)
+		require.Len(t, inv.Ordering.Receiving, 1)
+	})
+	t.Run("from schema object", func(t *testing.T) {
+		ord := testOrderStandard(t)
+		require.NoError(t, ord.Calculate())
+		obj, err := schema.NewObject(ord)
+		require.NoError(t, err)
+		inv, err := bill.NewInvoiceFrom(obj)
+		require.NoError(t, err)
+		assert.Equal(t, "1089.00", inv.Totals.Payable.String())
+	})
+	t.Run("partial quantities", func(t *testing.T) {
+		ord := testOrderStandard(t)
+		ord.Lines[0].Discounts = []*bill.LineDiscount{
+			{
+				Amount: num.MakeAmount(5000, 2),
+				Reason: "Fixed",
+			},
+		}
+		ord.Lines = append(ord.Lines, &bill.Line{
+			Quantity: num.MakeAmount(1, 0),
+			Item: &org.Item{
+				Name:  "Other Item",
+				Price: num.MakeAmount(2000, 2),
+			},
+			Taxes: tax.Set{
+				{
+					Category: tax.CategoryVAT,
+					Rate:     tax.RateStandard,
+				},
+			},
+		})
+		ord.Discounts = []*bill.Discount{
+			{
+				Percent: num.NewPercentage(5, 2),
+				Reason:  "Global",
+			},
+		}
+		require.NoError(t, ord.Calculate())
+		qty := num.MakeAmount(4, 0)
+		inv, err := bill.NewInvoiceFrom(&bill.InvoiceSource{
+			Document: testSchemaObject(t, ord),
+			Lines: []*bill.LineSelection{
+				{Index: 1, Quantity: &qty},
+			},
+		})
+		require.NoError(t, err)
+		require.Len(t, inv.Lines, 1)
+		assert.Equal(t, "4", inv.Lines[0].Quantity.String())
+		assert.Equal(t, "20.00", inv.Lines[0].Discounts[0].Amount.String())
+		assert.Equal(t, "380.00", inv.Lines[0].Total.String())
+		require.Len(t, inv.Discounts, 1)
+		assert.Equal(t, "19.00", inv.Discounts[0].Amount.String())
+		assert.Equal(t, "5%", ord.Discounts[0].Percent.String())
+		assert.Equal(t, []int{1}, inv.Ordering.Purchases[0].Lines)
+		assert.Equal(t, "10", ord.Lines[0].Quantity.String())
+	})
+	t.Run("source unchanged after calculate", func(t *testing.T) {
+		ord := testOrderStandard(t)
+		ord.Payment = &bill.PaymentDetails{
+			Advances: []*pay.Advance{
+				{
+					Percent:     num.NewPercentage(50, 2),
+					Description: "Deposit",
+				},
+			},
+		}
+		ord.Discounts = []*bill.Discount{
+			{
+				Percent: num.NewPercentage(10, 2),
+				Reason:  "Global",
+			},
+		}
+		require.NoError(t, ord.Calculate())
+		assert.Equal(t, "499.50", ord.Payment.Advances[0].Amount.String())
+		assert.Equal(t, "90.00", ord.Discounts[0].Amount.String())
+
+		qty := num.MakeAmount(2, 0)
+		inv, err := bill.NewInvoiceFrom(&bill.InvoiceSource{
+			Document: testSchemaObject(t, ord),
+			Lines:    []*bill.LineSelection{{Index: 1, Quantity: &qty}},
+		})
+		require.NoError(t, err)
+		inv.Supplier.Name = "Other Supplier"
+		inv.Customer.Name = "Other Customer"
+		require.NoError(t, inv.Calculate())
+		assert.Equal(t, "99.90", inv.Payment.Advances[0].Amount.String())
+		assert.Equal(t, "18.00", inv.Discounts[0].Amount.String())
+
+		assert.NotSame(t, ord.Payment, inv.Payment)
+		assert.Equal(t, "499.50", ord.Payment.Advances[0].Amount.String())
+		assert.Equal(t, "90.00", ord.Discounts[0].Amount.String())
+		assert.Equal(t, "Test Supplier", ord.Supplier.Name)
+		assert.Equal(t, "Test Customer", ord.Customer.Name)
+	})
+	t.Run("selection errors", func(t *testing.T) {
+		ord := testOrderStandard(t)
+		require.NoError(t, ord.Calculate())
+		qty := num.MakeAmount(11, 0)
+		_, err := bill.NewInvoiceFrom(&bill.InvoiceSource{
+			Document: testSchemaObject(t, ord),
+			Lines:    []*bill.LineSelection{{Index: 1, Quantity: &qty}},
+		})
+		assert.ErrorContains(t, err, "source 0: line 1: quantity 11 exceeds original 10")
+		_, err = bill.NewInvoiceFrom(&bill.InvoiceSource{
+			Document: testSchemaObject(t, ord),
+			Lines:    []*bill.LineSelection{{Index: 2}},
+		})
+		assert.ErrorContains(t, err, "source 0: line 2: not found")
+		_, err = bill.NewInvoiceFrom(&bill.InvoiceSource{
+			Document: testSchemaObject(t, ord),
+			Lines:    []*bill.LineSelection{{Index: 1}, {Index: 1}},
+		})
+		assert.ErrorContains(t, err, "source 0: line 1: selected more than once")
+	})
+	t.Run("mismatches", func(t *testing.T) {
+		ord := testOrderStandard(t)
+		require.NoError(t, ord.Calculate())
+		dlv := testDeliveryStandard(t)
+		dlv.Customer.TaxID.Code = "B85905495"
+		require.NoError(t, dlv.Calculate())
+		_, err := bill.NewInvoiceFrom(ord, dlv)
+		assert.ErrorContains(t, err, "source 1: customer mismatch")
+
+		dlv = testDeliveryStandard(t)
+		dlv.Supplier.TaxID.Code = "B85905495"
+		require.NoError(t, dlv.Calculate())
+		_, err = bill.NewInvoiceFrom(ord, dlv)
+		assert.ErrorContains(t, err, "source 1: supplier mismatch")
+
+		dlv = testDeliveryStandard(t)
+		dlv.Currency = "USD"
+		_, err = bill.NewInvoiceFrom(ord, dlv)
+		assert.ErrorContains(t, err, "source 1: currency mismatch: USD != EUR")
+	})
+	t.Run("unsupported", func(t *testing.T) {
+		_, err := bill.NewInvoiceFrom()
+		assert.ErrorContains(t, err, "no source documents provided")
+		_, err = bill.NewInvoiceFrom(baseInvoiceWithLines(t))
+		assert.ErrorContains(t, err, "source 0: unsupported document type: *bill.Invoice")
+		_, err = bill.NewInvoiceFrom(testSchemaObject(t, baseInvoiceWithLines(t)))
+		assert.ErrorContains(t, err, "source 0: unsupported document type: *bill.Invoice")
+	})
+}
+
+func testSchemaObject(t *testing.T, doc any) *schema.Object {
+	t.Helper()
+	obj, err := schema.NewObject(doc)
+	require.NoError(t, err)
+	return obj
+}
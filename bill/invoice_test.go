@@ -16,6 +16,7 @@ import (
 	"github.com/invopop/gobl/num"
 	"github.com/invopop/gobl/org"
 	"github.com/invopop/gobl/pay"
+	"github.com/invopop/gobl/regimes/es"
 	"github.com/invopop/gobl/tax"
 	"github.com/invopop/jsonschema"
 	"github.com/stretchr/testify/assert"
@@ -66,6 +67,59 @@ func TestInvoiceRegimeCurrencyCLP(t *testing.T) {
 	assert.Equal(t, "10", i.Lines[0].Item.Price.String(), "should not update price precision")
 }
 
+func TestInvoiceLocalTotals(t *testing.T) {
+	lines := []*bill.Line{
+		{
+			Quantity: num.MakeAmount(3, 0),
+			Item: &org.Item{
+				Name:  "Test Item",
+				Price: num.MakeAmount(3333, 2),
+			},
+			Taxes: tax.Set{
+				{
+					Category: "VAT",
+					Rate:     tax.RateStandard,
+				},
+			},
+		},
+	}
+	t.Run("foreign currency", func(t *testing.T) {
+		i := baseInvoice(t, lines...)
+		i.Tax = nil
+		i.Currency = currency.USD
+		i.ExchangeRates = []*currency.ExchangeRate{
+			{
+				From:   currency.USD,
+				To:     currency.EUR,
+				Amount: num.MakeAmount(875967, 6),
+			},
+		}
+		require.NoError(t, i.Calculate())
+		require.NoError(t, i.Validate())
+		lt := i.Totals.Local
+		require.NotNil(t, lt)
+		assert.Equal(t, currency.EUR, lt.Currency)
+		assert.Equal(t, "99.99", i.Totals.Total.String())
+		assert.Equal(t, "87.59", lt.Sum.String())
+		assert.Equal(t, "87.59", lt.Total.String())
+		assert.Equal(t, "87.59", lt.Taxes.Categories[0].Rates[0].Base.String())
+		assert.Equal(t, "18.40", lt.Tax.String())
+		assert.Equal(t, "105.99", lt.TotalWithTax.String())
+		assert.Equal(t, "21.00", i.Totals.Taxes.Sum.String(), "should not modify original taxes")
+	})
+	t.Run("regime currency", func(t *testing.T) {
+		i := baseInvoice(t, lines...)
+		require.NoError(t, i.Calculate())
+		assert.Nil(t, i.Totals.Local)
+	})
+	t.Run("missing exchange rate", func(t *testing.T) {
+		i := baseInvoice(t, lines...)
+		i.Currency = currency.USD
+		require.NoError(t, i.Calculate())
+		assert.Nil(t, i.Totals.Local)
+	})
+}
+
 func TestInvoiceInvalidCurrency(t *testing.T) {
 	lines := []*bill.Line{
 		{
@@ -794,6 +848,43 @@ func TestCalculateTotalsWithFractions(t *testing.T) {
 	assert.Equal(t, "122.61", i.Totals.Total.String())
 }
 
+func TestCalculateFixedAmountTaxes(t *testing.T) {
+	inv := baseInvoice(t,
+		&bill.Line{
+			Quantity: num.MakeAmount(20, 0),
+			Item: &org.Item{
+				Name:  "Plastic trays",
+				Price: num.MakeAmount(250, 2),
+			},
+			Taxes: tax.Set{
+				{
+					Category: "VAT",
+					Rate:     "standard",
+				},
+				{
+					Category: es.TaxCategoryIEEPNR,
+					Rate:     "standard",
+				},
+			},
+		},
+	)
+	inv.IssueDate = cal.MakeDate(2024, 3, 1)
+	inv.Tax = nil
+	require.NoError(t, inv.Calculate())
+	require.NoError(t, inv.Validate())
+
+	ct := inv.Totals.Taxes.Category(es.TaxCategoryIEEPNR)
+	require.NotNil(t, ct)
+	assert.Equal(t, "20", ct.Rates[0].Quantity.String())
+	assert.Equal(t, "9.00", ct.Amount.String())
+	assert.Equal(t, "50.00", inv.Totals.Total.String())
+	// VAT base includes the plastic tax
+	assert.Equal(t, "59.00", inv.Totals.Taxes.Category("VAT").Rates[0].Base.String())
+	assert.Equal(t, "21.39", inv.Totals.Tax.String())
+	assert.Equal(t, "71.39", inv.Totals.Payable.String())
+	assert.Equal(t, "0.45", inv.Lines[0].Taxes[1].Amount.String())
+}
+
 func TestApplyCustomerRates(t *testing.T) {
 	t.Run("missing customer", func(t *testing.T) {
 		lines := []*bill.Line{
@@ -888,6 +979,64 @@ func TestApplyCustomerRates(t *testing.T) {
 		assert.Equal(t, "PT", inv.Discounts[0].Taxes[0].Country.String())
 		assert.Equal(t, "PT", inv.Charges[0].Taxes[0].Country.String())
 	})
+	t.Run("customer address country", func(t *testing.T) {
+		lines := []*bill.Line{
+			{
+				Quantity: num.MakeAmount(1, 0),
+				Item: &org.Item{
+					Name:  "Test Item",
+					Price: num.MakeAmount(100000, 2),
+				},
+				Taxes: tax.Set{
+					{
+						Category: "VAT",
+						Rate:     tax.RateStandard,
+					},
+				},
+			},
+		}
+		inv := baseInvoice(t, lines...)
+		inv.SetTags(tax.TagCustomerRates)
+		inv.Customer.TaxID = nil
+		inv.Customer.Addresses = []*org.Address{
+			{
+				Locality: "Lisboa",
+				Country:  "PT",
+			},
+		}
+		require.NoError(t, inv.Calculate())
+		assert.Equal(t, "PT", inv.Lines[0].Taxes[0].Country.String())
+		assert.Equal(t, "23.0%", inv.Lines[0].Taxes[0].Percent.String())
+	})
+	t.Run("customer tax ID country before address", func(t *testing.T) {
+		lines := []*bill.Line{
+			{
+				Quantity: num.MakeAmount(1, 0),
+				Item: &org.Item{
+					Name:  "Test Item",
+					Price: num.MakeAmount(100000, 2),
+				},
+				Taxes: tax.Set{
+					{
+						Category: "VAT",
+						Rate:     tax.RateStandard,
+					},
+				},
+			},
+		}
+		inv := baseInvoice(t, lines...)
+		inv.SetTags(tax.TagCustomerRates)
+		inv.Customer.TaxID.Country = "PT"
+		inv.Customer.Addresses = []*org.Address{
+			{
+				Locality: "Madrid",
+				Country:  "ES",
+			},
+		}
+		require.NoError(t, inv.Calculate())
+		assert.Equal(t, "PT", inv.Lines[0].Taxes[0].Country.String())
+		assert.Equal(t, "23.0%", inv.Lines[0].Taxes[0].Percent.String())
+	})
 }
 
 func TestCalculate(t *testing.T) {
@@ -936,7 +1085,7 @@ func TestCalculate(t *testing.T) {
 				},
 			},
 		},
-		Payment: &bill.Payment{
+		Payment: &bill.PaymentDetails{
 			Advances: []*pay.Advance{
 				{
 					Description: "Test Advance",
@@ -1004,7 +1153,7 @@ func TestCalculateInverted(t *testing.T) {
 				},
 			},
 		},
-		Payment: &bill.Payment{
+		Payment: &bill.PaymentDetails{
 			Advances: []*pay.Advance{
 				{
 					Description: "Test Advance",
@@ -23,6 +23,9 @@ type Line struct {
 	Quantity num.Amount `json:"quantity" jsonschema:"title=Quantity"`
 	// Details about what is being sold
 	Item *org.Item `json:"item" jsonschema:"title=Item"`
+	// Identities for the specific goods covered by this line, such as batch,
+	// lot, or serial numbers.
+	Identities []*org.Identity `json:"identities,omitempty" jsonschema:"title=Identities"`
 	// Result of quantity multiplied by the item's price (calculated)
 	Sum num.Amount `json:"sum" jsonschema:"title=Sum" jsonschema_extras:"calculated=true"`
 	// Discounts applied to this line
@@ -51,6 +54,11 @@ func (l *Line) GetTotal() num.Amount {
 	return l.total
 }
 
+// GetQuantity provides the line's quantity, used to calculate any fixed amount taxes.
+func (l *Line) GetQuantity() num.Amount {
+	return l.Quantity
+}
+
 // ValidateWithContext ensures the line contains everything required using
 // the provided context that should include the regime.
 func (l *Line) ValidateWithContext(ctx context.Context) error {
@@ -59,6 +67,7 @@ func (l *Line) ValidateWithContext(ctx context.Context) error {
 		validation.Field(&l.Index, validation.Required),
 		validation.Field(&l.Quantity, validation.Required),
 		validation.Field(&l.Item, validation.Required),
+		validation.Field(&l.Identities),
 		validation.Field(&l.Sum, validation.Required),
 		validation.Field(&l.Discounts),
 		validation.Field(&l.Charges),
@@ -77,6 +86,7 @@ func (l *Line) Normalize(normalizers tax.Normalizers) {
 	normalizers.Each(l)
 	tax.Normalize(normalizers, l.Taxes)
 	tax.Normalize(normalizers, l.Item)
+	tax.Normalize(normalizers, l.Identities)
 	tax.Normalize(normalizers, l.Discounts)
 	tax.Normalize(normalizers, l.Charges)
 }
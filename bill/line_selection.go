@@ -0,0 +1,191 @@
+package bill
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/invopop/gobl/num"
+	"github.com/invopop/validation"
+)
+
+// LineSelection identifies a single line from a source document, by its
+// index, alongside an optional quantity or amount to use instead of the
+// line's originals.
+type LineSelection struct {
+	// Index of the line in the source document, starting from 1.
+	Index int `json:"i" jsonschema:"title=Index"`
+	// Quantity to use instead of the line's original quantity.
+	Quantity *num.Amount `json:"quantity,omitempty" jsonschema:"title=Quantity"`
+	// Total amount to use for the line instead of the original. Any line
+	// discounts or charges will be removed and the item's price adjusted
+	// accordingly.
+	Amount *num.Amount `json:"amount,omitempty" jsonschema:"title=Amount"`
+}
+
+// Validate ensures the line selection looks correct.
+func (ls *LineSelection) Validate() error {
+	return validation.ValidateStruct(ls,
+		validation.Field(&ls.Index, validation.Required, validation.Min(1)),
+		validation.Field(&ls.Quantity, num.Positive),
+		validation.Field(&ls.Amount, num.Positive),
+	)
+}
+
+// selectLines provides copies of the lines identified in the selection, with
+// the quantities updated and any fixed line discount or charge amounts
+// prorated accordingly, or repriced to match a specific amount. If the
+// selection is empty, copies of all the lines will be returned. Each line
+// may only be selected once so that it cannot be copied beyond its
+// original quantity or amount.
+func selectLines(lines []*Line, sel []*LineSelection) ([]*Line, error) {
+	if len(sel) == 0 {
+		out := make([]*Line, len(lines))
+		for i, l := range lines {
+			nl, err := l.clone()
+			if err != nil {
+				return nil, err
+			}
+			out[i] = nl
+		}
+		return out, nil
+	}
+	out := make([]*Line, 0, len(sel))
+	seen := make(map[int]bool, len(sel))
+	for _, s := range sel {
+		if err := s.Validate(); err != nil {
+			return nil, err
+		}
+		if s.Index > len(lines) {
+			return nil, fmt.Errorf("line %d: not found", s.Index)
+		}
+		if seen[s.Index] {
+			return nil, fmt.Errorf("line %d: selected more than once", s.Index)
+		}
+		seen[s.Index] = true
+		l, err := lines[s.Index-1].clone()
+		if err != nil {
+			return nil, err
+		}
+		if s.Quantity != nil {
+			if s.Quantity.Compare(l.Quantity) > 0 {
+				return nil, fmt.Errorf("line %d: quantity %s exceeds original %s", s.Index, s.Quantity, l.Quantity)
+			}
+			l.prorate(*s.Quantity)
+		}
+		if s.Amount != nil {
+			if s.Amount.Compare(lines[s.Index-1].Total) > 0 {
+				return nil, fmt.Errorf("line %d: amount %s exceeds original %s", s.Index, s.Amount, lines[s.Index-1].Total)
+			}
+			l.reprice(*s.Amount)
+		}
+		out = append(out, l)
+	}
+	return out, nil
+}
+
+// selectDiscounts provides copies of the document level discounts that can
+// still be applied after a selection of lines has been made. Only percentage
+// based discounts are kept when lines have been selected, as fixed amounts
+// cannot be reliably split.
+func selectDiscounts(discounts []*Discount, sel []*LineSelection) ([]*Discount, error) {
+	out := make([]*Discount, 0, len(discounts))
+	for _, d := range discounts {
+		if len(sel) == 0 || d.Percent != nil {
+			nd, err := cloneJSON(d)
+			if err != nil {
+				return nil, err
+			}
+			out = append(out, nd)
+		}
+	}
+	return out, nil
+}
+
+// selectCharges is the equivalent of selectDiscounts for charges.
+func selectCharges(charges []*Charge, sel []*LineSelection) ([]*Charge, error) {
+	out := make([]*Charge, 0, len(charges))
+	for _, c := range charges {
+		if len(sel) == 0 || c.Percent != nil {
+			nc, err := cloneJSON(c)
+			if err != nil {
+				return nil, err
+			}
+			out = append(out, nc)
+		}
+	}
+	return out, nil
+}
+
+// selectedLineIndexes provides the indexes of the selected lines.
+func selectedLineIndexes(sel []*LineSelection) []int {
+	if len(sel) == 0 {
+		return nil
+	}
+	out := make([]int, len(sel))
+	for i, s := range sel {
+		out[i] = s.Index
+	}
+	return out
+}
+
+// prorate updates the line's quantity, adjusting any fixed amount
+// discounts or charges in proportion to the original quantity.
+func (l *Line) prorate(qty num.Amount) {
+	if l.Quantity.IsZero() {
+		l.Quantity = qty
+		return
+	}
+	ratio := func(a num.Amount) num.Amount {
+		return a.Upscale(defaultCurrencyConversionAccuracy).
+			Multiply(qty).
+			Divide(l.Quantity).
+			Rescale(a.Exp())
+	}
+	for _, d := range l.Discounts {
+		if d.Percent == nil {
+			d.Amount = ratio(d.Amount)
+		}
+	}
+	for _, c := range l.Charges {
+		if c.Percent == nil {
+			c.Amount = ratio(c.Amount)
+		}
+	}
+	l.Quantity = qty
+}
+
+// reprice removes any line discounts or charges and updates the item's price
+// so that the line's total will match the amount provided.
+func (l *Line) reprice(amount num.Amount) {
+	l.Discounts = nil
+	l.Charges = nil
+	if l.Item == nil || l.Quantity.IsZero() {
+		return
+	}
+	price := amount.Divide(l.Quantity)
+	if price.Multiply(l.Quantity).Compare(amount) != 0 {
+		// add some extra accuracy to avoid rounding errors
+		price = amount.Upscale(2).Divide(l.Quantity)
+	}
+	l.Item.Price = price
+}
+
+// clone makes a deep copy of the line.
+func (l *Line) clone() (*Line, error) {
+	return cloneJSON(l)
+}
+
+// cloneJSON makes a deep copy of any value by serializing and deserializing
+// its contents, so that the copy can be modified without affecting the
+// original.
+func cloneJSON[T any](v T) (T, error) {
+	var out T
+	data, err := json.Marshal(v)
+	if err != nil {
+		return out, err
+	}
+	if err := json.Unmarshal(data, &out); err != nil {
+		return out, err
+	}
+	return out, nil
+}
@@ -0,0 +1,129 @@
+package bill
+
+import (
+	"github.com/invopop/gobl/schema"
+)
+
+// Migrations for stored documents that were built with older versions of
+// GOBL. Each migration only modifies data that still uses the old structure.
+
+func init() {
+	id := schema.GOBL.Add("bill/invoice")
+	schema.RegisterMigration(&schema.Migration{
+		Schema:      id,
+		Version:     "v0.70.1",
+		Key:         "pay-advance-description",
+		Description: "Payment advance `desc` property renamed to `description`.",
+		Patterns:    []string{`"advances"`},
+		Func:        migrateAdvanceDescription,
+	})
+	schema.RegisterMigration(&schema.Migration{
+		Schema:      id,
+		Version:     "v0.78.0",
+		Key:         "pay-online-label-url",
+		Description: "Online payment instruction `name` and `addr` properties renamed to `label` and `url`.",
+		Patterns:    []string{`"online"`},
+		Func:        migrateOnlineLabelURL,
+	})
+	schema.RegisterMigration(&schema.Migration{
+		Schema:      id,
+		Version:     "v0.204.0",
+		Key:         "bill-outlay-legacy",
+		Description: "Outlays with the legacy `desc` and `paid` properties, ignored since they were removed, are deleted.",
+		Patterns:    []string{`"outlays"`},
+		Func:        migrateLegacyOutlays,
+	})
+}
+
+func migrateAdvanceDescription(data map[string]any) bool {
+	payment, ok := data["payment"].(map[string]any)
+	if !ok {
+		return false
+	}
+	changed := false
+	for _, a := range objectList(payment["advances"]) {
+		if renameField(a, "desc", "description") {
+			changed = true
+		}
+	}
+	return changed
+}
+
+func migrateOnlineLabelURL(data map[string]any) bool {
+	payment, ok := data["payment"].(map[string]any)
+	if !ok {
+		return false
+	}
+	instructions, ok := payment["instructions"].(map[string]any)
+	if !ok {
+		return false
+	}
+	changed := false
+	for _, o := range objectList(instructions["online"]) {
+		if renameField(o, "name", "label") {
+			changed = true
+		}
+		if renameField(o, "addr", "url") {
+			changed = true
+		}
+	}
+	return changed
+}
+
+// migrateLegacyOutlays removes the outlays that use the structure from
+// before they were removed in v0.204.0. Since then, they have been ignored
+// when loading and calculating documents, so removing them ensures the
+// totals of existing documents will not change.
+func migrateLegacyOutlays(data map[string]any) bool {
+	outlays := objectList(data["outlays"])
+	list := make([]any, 0, len(outlays))
+	for _, o := range outlays {
+		if _, ok := o["desc"]; ok {
+			continue
+		}
+		if _, ok := o["paid"]; ok {
+			continue
+		}
+		list = append(list, o)
+	}
+	if len(list) == len(outlays) {
+		return false
+	}
+	if len(list) == 0 {
+		delete(data, "outlays")
+	} else {
+		data["outlays"] = list
+	}
+	return true
+}
+
+// objectList provides the objects contained in an array of raw data,
+// skipping any other types of values.
+func objectList(v any) []map[string]any {
+	rows, ok := v.([]any)
+	if !ok {
+		return nil
+	}
+	list := make([]map[string]any, 0, len(rows))
+	for _, row := range rows {
+		if o, ok := row.(map[string]any); ok {
+			list = append(list, o)
+		}
+	}
+	return list
+}
+
+// renameField moves the value of the old property to the new one, unless
+// the new property has already been set, in which case the old value is
+// discarded.
+func renameField(o map[string]any, from, to string) bool {
+	v, ok := o[from]
+	if !ok {
+		return false
+	}
+	if _, ok := o[to]; !ok {
+		o[to] = v
+	}
+	delete(o, from)
+	return true
+}
@@ -0,0 +1,55 @@
+package bill_test
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/invopop/gobl/bill"
+	"github.com/invopop/gobl/schema"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPaymentMigrations(t *testing.T) {
+	data := []byte(`{
+		"$schema": "https://gobl.org/draft-0/bill/invoice",
+		"payment": {
+			"advances": [{"desc": "Deposit", "amount": "10.00"}],
+			"instructions": {
+				"key": "online",
+				"online": [{"name": "Pay now", "addr": "https://example.com/pay"}]
+			}
+		}
+	}`)
+	obj := new(schema.Object)
+	require.NoError(t, json.Unmarshal(data, obj))
+	inv := obj.Instance().(*bill.Invoice)
+	assert.Equal(t, "Deposit", inv.Payment.Advances[0].Description)
+	assert.Equal(t, "Pay now", inv.Payment.Instructions.Online[0].Label)
+	assert.Equal(t, "https://example.com/pay", inv.Payment.Instructions.Online[0].URL)
+	require.Len(t, obj.Migrated(), 2)
+	assert.Equal(t, "pay-advance-description", obj.Migrated()[0].Key)
+	assert.Equal(t, "pay-online-label-url", obj.Migrated()[1].Key)
+
+	t.Run("from version", func(t *testing.T) {
+		obj := schema.NewObjectFromVersion("v0.78.0")
+		require.NoError(t, json.Unmarshal(data, obj))
+		inv := obj.Instance().(*bill.Invoice)
+		assert.Empty(t, obj.Migrated())
+		// the types still handle their own legacy properties
+		assert.Equal(t, "Deposit", inv.Payment.Advances[0].Description)
+		assert.Equal(t, "Pay now", inv.Payment.Instructions.Online[0].Label)
+	})
+
+	t.Run("current structure", func(t *testing.T) {
+		data := []byte(`{
+			"$schema": "https://gobl.org/draft-0/bill/invoice",
+			"payment": {
+				"advances": [{"description": "Deposit", "amount": "10.00"}]
+			}
+		}`)
+		obj := new(schema.Object)
+		require.NoError(t, json.Unmarshal(data, obj))
+		assert.Empty(t, obj.Migrated())
+	})
+}
@@ -0,0 +1,369 @@
+package bill
+
+import (
+	"context"
+	"encoding/json"
+
+	"github.com/invopop/gobl/cal"
+	"github.com/invopop/gobl/cbc"
+	"github.com/invopop/gobl/currency"
+	"github.com/invopop/gobl/i18n"
+	"github.com/invopop/gobl/internal"
+	"github.com/invopop/gobl/l10n"
+	"github.com/invopop/gobl/org"
+	"github.com/invopop/gobl/pkg/here"
+	"github.com/invopop/gobl/schema"
+	"github.com/invopop/gobl/tax"
+	"github.com/invopop/gobl/uuid"
+	"github.com/invopop/jsonschema"
+	"github.com/invopop/validation"
+)
+
+// Constants used to help identify orders
+const (
+	ShortSchemaOrder = "bill/order"
+)
+
+// Predefined list of the order type codes officially supported.
+const (
+	OrderTypePurchase cbc.Key = "purchase"
+	OrderTypeSales    cbc.Key = "sales"
+	OrderTypeQuote    cbc.Key = "quote"
+)
+
+// OrderTypes describes each of the order types supported.
+var OrderTypes = []*cbc.KeyDefinition{
+	{
+		Key: OrderTypePurchase,
+		Name: i18n.String{
+			i18n.EN: "Purchase",
+		},
+		Desc: i18n.String{
+			i18n.EN: "A purchase order issued by the customer or buyer to request goods or services.",
+		},
+		Map: cbc.CodeMap{
+			UNTDID1001Key: "220",
+		},
+	},
+	{
+		Key: OrderTypeSales,
+		Name: i18n.String{
+			i18n.EN: "Sales",
+		},
+		Desc: i18n.String{
+			i18n.EN: "A sales order issued by the supplier or seller to confirm the goods or services to be provided.",
+		},
+		Map: cbc.CodeMap{
+			UNTDID1001Key: "220",
+		},
+	},
+	{
+		Key: OrderTypeQuote,
+		Name: i18n.String{
+			i18n.EN: "Quote",
+		},
+		Desc: i18n.String{
+			i18n.EN: here.Doc(`
+				An offer or quotation issued by the supplier to a potential customer, that
+				may later be accepted in order to create a purchase or sales order.
+			`),
+		},
+		Map: cbc.CodeMap{
+			UNTDID1001Key: "310",
+		},
+	},
+}
+
+var isValidOrderType = validation.In(typeValues(OrderTypes)...)
+
+// Order documents are used for the initial part of an order-to-invoice process
+// where the buyer requests goods or services from the seller, or the seller
+// confirms or offers them. Orders share the same structure of lines, discounts,
+// charges, and totals as invoices, so that they can be easily converted into
+// invoices once the goods or services have been provided.
+//
+// The supplier is always the party providing the goods or services, and the
+// customer the party receiving them, regardless of which issued the document.
+type Order struct {
+	tax.Regime
+	tax.Addons
+	tax.Tags
+
+	uuid.Identify
+
+	// Type of the order.
+	Type cbc.Key `json:"type" jsonschema:"title=Type" jsonschema_extras:"calculated=true"`
+	// Used as a prefix to group codes.
+	Series cbc.Code `json:"series,omitempty" jsonschema:"title=Series"`
+	// Code used to identify this order in the issuer's systems.
+	Code cbc.Code `json:"code" jsonschema:"title=Code"`
+	// When the order was created.
+	IssueDate cal.Date `json:"issue_date" jsonschema:"title=Issue Date" jsonschema_extras:"calculated=true"`
+	// Date from which taxes should be determined, if none set, the issue date is used.
+	ValueDate *cal.Date `json:"value_date,omitempty" jsonschema:"title=Value Date"`
+	// Currency for all order totals.
+	Currency currency.Code `json:"currency" jsonschema:"title=Currency" jsonschema_extras:"calculated=true"`
+	// Exchange rates to be used when converting the order's monetary values into other currencies.
+	ExchangeRates []*currency.ExchangeRate `json:"exchange_rates,omitempty" jsonschema:"title=Exchange Rates"`
+
+	// Key information regarding previous order documents that this one replaces.
+	Preceding []*org.DocumentRef `json:"preceding,omitempty" jsonschema:"title=Preceding Details"`
+
+	// Special tax configuration for calculating totals.
+	Tax *Tax `json:"tax,omitempty" jsonschema:"title=Tax"`
+
+	// The entity supplying the goods or services.
+	Supplier *org.Party `json:"supplier" jsonschema:"title=Supplier"`
+	// Legal entity receiving the goods or services, may be empty for quotes.
+	Customer *org.Party `json:"customer,omitempty" jsonschema:"title=Customer"`
+
+	// List of order lines representing each of the items to be ordered.
+	Lines []*Line `json:"lines,omitempty" jsonschema:"title=Lines"`
+	// Discounts or allowances applied to the complete order
+	Discounts []*Discount `json:"discounts,omitempty" jsonschema:"title=Discounts"`
+	// Charges or surcharges applied to the complete order
+	Charges []*Charge `json:"charges,omitempty" jsonschema:"title=Charges"`
+
+	// Ordering details including document references and buyer or seller parties.
+	Ordering *Ordering `json:"ordering,omitempty" jsonschema:"title=Ordering Details"`
+	// Information on when, how, and to whom a final invoice would be paid.
+	Payment *PaymentDetails `json:"payment,omitempty" jsonschema:"title=Payment Details"`
+	// Specific details on delivery of the goods to be provided.
+	Delivery *DeliveryDetails `json:"delivery,omitempty" jsonschema:"title=Delivery Details"`
+
+	// Summary of all the order totals, including taxes (calculated).
+	Totals *Totals `json:"totals" jsonschema:"title=Totals" jsonschema_extras:"calculated=true"`
+
+	// Unstructured information that is relevant to the order.
+	Notes []*cbc.Note `json:"notes,omitempty" jsonschema:"title=Notes"`
+
+	// Additional complementary objects that add relevant information to the order.
+	Complements []*schema.Object `json:"complements,omitempty" jsonschema:"title=Complements"`
+
+	// Additional semi-structured data that doesn't fit into the body of the order.
+	Meta cbc.Meta `json:"meta,omitempty" jsonschema:"title=Meta"`
+}
+
+// Validate checks to ensure the order is valid and contains all the information we need.
+func (ord *Order) Validate() error {
+	return ord.ValidateWithContext(context.Background())
+}
+
+// ValidateWithContext checks to ensure the order is valid and contains all the
+// information we need.
+func (ord *Order) ValidateWithContext(ctx context.Context) error {
+	ctx = ord.ValidationContext(ctx)
+
+	return tax.ValidateStructWithContext(ctx, ord,
+		validation.Field(&ord.Regime),
+		validation.Field(&ord.Addons),
+		validation.Field(&ord.Tags.List, tax.TagsIn(ord.supportedTags()...)),
+		validation.Field(&ord.UUID),
+		validation.Field(&ord.Type,
+			validation.Required,
+			isValidOrderType,
+		),
+		validation.Field(&ord.Series),
+		validation.Field(&ord.Code,
+			validation.When(
+				internal.IsSigned(ctx),
+				validation.Required.Error("required to sign order"),
+			),
+		),
+		validation.Field(&ord.IssueDate,
+			cal.DateNotZero(),
+		),
+		validation.Field(&ord.ValueDate),
+		validation.Field(&ord.Currency, validation.Required),
+		validation.Field(&ord.ExchangeRates),
+		validation.Field(&ord.Preceding),
+		validation.Field(&ord.Tax),
+		validation.Field(&ord.Supplier,
+			validation.Required,
+			validation.By(validateInvoiceSupplier),
+		),
+		validation.Field(&ord.Customer,
+			validation.When(
+				!ord.Type.In(OrderTypeQuote),
+				validation.Required,
+			),
+			validation.By(validateInvoiceCustomer),
+		),
+		validation.Field(&ord.Lines,
+			validation.When(
+				len(ord.Discounts) == 0 && len(ord.Charges) == 0,
+				validation.Required.Error("cannot be empty without discounts or charges"),
+			),
+		),
+		validation.Field(&ord.Discounts),
+		validation.Field(&ord.Charges),
+		validation.Field(&ord.Ordering),
+		validation.Field(&ord.Payment),
+		validation.Field(&ord.Delivery),
+		validation.Field(&ord.Totals,
+			validation.Required,
+		),
+		validation.Field(&ord.Notes),
+		validation.Field(&ord.Complements),
+		validation.Field(&ord.Meta),
+	)
+}
+
+// ValidationContext builds a context with all the validators that the order might
+// need for execution.
+func (ord *Order) ValidationContext(ctx context.Context) context.Context {
+	if r := ord.RegimeDef(); r != nil {
+		ctx = r.WithContext(ctx)
+	}
+	for _, a := range ord.AddonDefs() {
+		ctx = a.WithContext(ctx)
+	}
+	return ctx
+}
+
+// Calculate performs all the normalizations and calculations required for the order
+// totals and taxes.
+func (ord *Order) Calculate() error {
+	// Try to set Regime if not already prepared from the supplier's tax ID
+	if ord.Regime.IsEmpty() {
+		ord.SetRegime(ord.supplierTaxCountry())
+	}
+
+	ord.Normalize(tax.ExtractNormalizers(ord))
+
+	return calculate(ord)
+}
+
+// Normalize is run as part of the Calculate method to ensure that the order
+// is in a consistent state before calculations are performed. This will leverage
+// any add-ons alongside the tax regime.
+func (ord *Order) Normalize(normalizers tax.Normalizers) {
+	if ord.Type == cbc.KeyEmpty {
+		ord.Type = OrderTypePurchase
+	}
+	ord.Series = cbc.NormalizeCode(ord.Series)
+	ord.Code = cbc.NormalizeCode(ord.Code)
+
+	normalizers.Each(ord)
+
+	tax.Normalize(normalizers, ord.Tax)
+	tax.Normalize(normalizers, ord.Supplier)
+	tax.Normalize(normalizers, ord.Customer)
+	tax.Normalize(normalizers, ord.Preceding)
+	tax.Normalize(normalizers, ord.Lines)
+	tax.Normalize(normalizers, ord.Discounts)
+	tax.Normalize(normalizers, ord.Charges)
+	tax.Normalize(normalizers, ord.Ordering)
+	tax.Normalize(normalizers, ord.Payment)
+}
+
+// Replicate modifies the order's fields to ensure that it can be used as part
+// of a replication process, keeping the base details like the parties and lines,
+// but with updated identifiers and dates.
+func (ord *Order) Replicate() error {
+	ord.UUID = uuid.Empty
+	ord.Code = ""
+	ord.IssueDate = cal.Today()
+	ord.ValueDate = nil
+	return nil
+}
+
+// UNTDID1001 provides the official code number assigned with the order type.
+func (ord *Order) UNTDID1001() cbc.Code {
+	return typeUNTDID1001(OrderTypes, ord.Type)
+}
+
+func (ord *Order) supportedTags() []cbc.Key {
+	return supportedTagsFor(ShortSchemaOrder, ord.RegimeDef(), ord.AddonDefs())
+}
+
+// supplierTaxCountry determines the tax country for the order based on the supplier tax
+// identity.
+func (ord *Order) supplierTaxCountry() l10n.TaxCountryCode {
+	if ord.Supplier == nil || ord.Supplier.TaxID == nil {
+		return l10n.CodeEmpty.Tax()
+	}
+	return ord.Supplier.TaxID.Country
+}
+
+// UnmarshalJSON implements the json.Unmarshaler interface and ensures the
+// regime is set when coming in from a raw JSON source.
+func (ord *Order) UnmarshalJSON(data []byte) error {
+	type Alias Order
+	if err := json.Unmarshal(data, (*Alias)(ord)); err != nil {
+		return err
+	}
+	if ord.Regime.IsEmpty() {
+		ord.SetRegime(ord.supplierTaxCountry())
+	}
+	return nil
+}
+
+// JSONSchemaExtend extends the schema with additional property details
+func (ord Order) JSONSchemaExtend(js *jsonschema.Schema) {
+	props := js.Properties
+	if its, ok := props.Get("type"); ok {
+		its.OneOf = typeSchemaOneOf(OrderTypes)
+	}
+	ord.Regime.JSONSchemaExtend(js)
+	ord.Addons.JSONSchemaExtend(js)
+	// Recommendations
+	js.Extras = map[string]any{
+		schema.Recommended: []string{
+			"$regime",
+			"lines",
+		},
+	}
+}
+
+// Internal methods used to satisfy the billable interface for calculations.
+
+func (ord *Order) getIssueDate() cal.Date {
+	return ord.IssueDate
+}
+func (ord *Order) getValueDate() *cal.Date {
+	return ord.ValueDate
+}
+func (ord *Order) getTax() *Tax {
+	return ord.Tax
+}
+func (ord *Order) getCustomer() *org.Party {
+	return ord.Customer
+}
+func (ord *Order) getCurrency() currency.Code {
+	return ord.Currency
+}
+func (ord *Order) getExchangeRates() []*currency.ExchangeRate {
+	return ord.ExchangeRates
+}
+func (ord *Order) getLines() []*Line {
+	return ord.Lines
+}
+func (ord *Order) getDiscounts() []*Discount {
+	return ord.Discounts
+}
+func (ord *Order) getCharges() []*Charge {
+	return ord.Charges
+}
+func (ord *Order) getOutlays() []*Outlay {
+	return nil
+}
+func (ord *Order) getPayment() *PaymentDetails {
+	return ord.Payment
+}
+func (ord *Order) getTotals() *Totals {
+	return ord.Totals
+}
+func (ord *Order) getComplements() []*schema.Object {
+	return ord.Complements
+}
+
+func (ord *Order) setIssueDate(d cal.Date) {
+	ord.IssueDate = d
+}
+func (ord *Order) setCurrency(c currency.Code) {
+	ord.Currency = c
+}
+func (ord *Order) setTotals(t *Totals) {
+	ord.Totals = t
+}
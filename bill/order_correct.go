@@ -0,0 +1,45 @@
+package bill
+
+import (
+	"errors"
+
+	"github.com/invopop/gobl/schema"
+	"github.com/invopop/gobl/tax"
+)
+
+// Correct moves the key fields of the current order to the preceding
+// structure so that the document can be re-issued as a replacement of the
+// original order, usually to reflect a change requested by one of the
+// parties. The order type will be maintained unless a new one is provided
+// in the options.
+func (ord *Order) Correct(opts ...schema.Option) error {
+	if ord.Code == "" {
+		return errors.New("cannot correct an order without a code")
+	}
+	h := &correctionHeader{
+		uuid:      &ord.UUID,
+		typ:       &ord.Type,
+		series:    &ord.Series,
+		code:      &ord.Code,
+		issueDate: &ord.IssueDate,
+		preceding: &ord.Preceding,
+	}
+	if err := correctHeader(h, OrderTypes, ord.correctionDef(), opts...); err != nil {
+		return err
+	}
+	return ord.Calculate()
+}
+
+// CorrectionOptionsSchema provides a dynamic JSON schema of the options
+// that can be used on the order in order to correct it.
+func (ord *Order) CorrectionOptionsSchema() (interface{}, error) {
+	var cd *tax.CorrectionDefinition
+	if ord.RegimeDef() != nil {
+		cd = ord.correctionDef()
+	}
+	return correctionOptionsSchema(ord.GetRegime(), ord.Series, cd, OrderTypes)
+}
+
+func (ord *Order) correctionDef() *tax.CorrectionDefinition {
+	return correctionDefFor(ShortSchemaOrder, ord.RegimeDef(), ord.AddonDefs())
+}
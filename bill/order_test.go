@@ -0,0 +1,229 @@
+package bill_test
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/invopop/gobl/bill"
+	"github.com/invopop/gobl/cal"
+	"github.com/invopop/gobl/cbc"
+	"github.com/invopop/gobl/currency"
+	"github.com/invopop/gobl/num"
+	"github.com/invopop/gobl/org"
+	"github.com/invopop/gobl/tax"
+	"github.com/invopop/jsonschema"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestOrderCalculate(t *testing.T) {
+	t.Run("basic", func(t *testing.T) {
+		ord := testOrderStandard(t)
+		require.NoError(t, ord.Calculate())
+		assert.Equal(t, "ES", ord.GetRegime().String())
+		assert.Equal(t, bill.OrderTypePurchase, ord.Type)
+		assert.Equal(t, currency.EUR, ord.Currency)
+		assert.Equal(t, "900.00", ord.Totals.Sum.String())
+		assert.Equal(t, "900.00", ord.Totals.Total.String())
+		assert.Equal(t, "189.00", ord.Totals.Tax.String())
+		assert.Equal(t, "1089.00", ord.Totals.Payable.String())
+		require.NoError(t, ord.Validate())
+	})
+	t.Run("with charges", func(t *testing.T) {
+		ord := testOrderStandard(t)
+		ord.Charges = []*bill.Charge{
+			{
+				Key:    bill.ChargeKeyDelivery,
+				Amount: num.MakeAmount(1000, 2),
+				Taxes: tax.Set{
+					{
+						Category: tax.CategoryVAT,
+						Rate:     tax.RateStandard,
+					},
+				},
+			},
+		}
+		require.NoError(t, ord.Calculate())
+		assert.Equal(t, "910.00", ord.Totals.Total.String())
+		assert.Equal(t, "1101.10", ord.Totals.Payable.String())
+	})
+	t.Run("missing currency", func(t *testing.T) {
+		ord := testOrderStandard(t)
+		ord.Supplier.TaxID = nil
+		ord.Regime = tax.Regime{}
+		err := ord.Calculate()
+		assert.ErrorContains(t, err, "currency: missing")
+	})
+}
+
+func TestOrderValidation(t *testing.T) {
+	t.Run("missing customer", func(t *testing.T) {
+		ord := testOrderStandard(t)
+		ord.Customer = nil
+		require.NoError(t, ord.Calculate())
+		assert.ErrorContains(t, ord.Validate(), "customer: cannot be blank")
+	})
+	t.Run("quote without customer", func(t *testing.T) {
+		ord := testOrderStandard(t)
+		ord.Type = bill.OrderTypeQuote
+		ord.Customer = nil
+		require.NoError(t, ord.Calculate())
+		assert.NoError(t, ord.Validate())
+	})
+	t.Run("invalid type", func(t *testing.T) {
+		ord := testOrderStandard(t)
+		ord.Type = bill.InvoiceTypeStandard
+		require.NoError(t, ord.Calculate())
+		assert.ErrorContains(t, ord.Validate(), "type: must be a valid value")
+	})
+	t.Run("missing lines", func(t *testing.T) {
+		ord := testOrderStandard(t)
+		ord.Lines = nil
+		require.NoError(t, ord.Calculate())
+		assert.ErrorContains(t, ord.Validate(), "lines: cannot be empty without discounts or charges")
+	})
+}
+
+func TestOrderUNTDID1001(t *testing.T) {
+	ord := testOrderStandard(t)
+	require.NoError(t, ord.Calculate())
+	assert.Equal(t, cbc.Code("220"), ord.UNTDID1001())
+	ord.Type = bill.OrderTypeQuote
+	assert.Equal(t, cbc.Code("310"), ord.UNTDID1001())
+	ord.Type = "foo"
+	assert.Equal(t, cbc.CodeEmpty, ord.UNTDID1001())
+}
+
+func TestOrderCorrect(t *testing.T) {
+	t.Run("basic", func(t *testing.T) {
+		ord := testOrderStandard(t)
+		require.NoError(t, ord.Calculate())
+		require.NoError(t, ord.Correct(bill.WithReason("quantity changed")))
+		assert.Equal(t, bill.OrderTypePurchase, ord.Type)
+		assert.Empty(t, ord.Code)
+		assert.Equal(t, cal.Today(), ord.IssueDate)
+		require.Len(t, ord.Preceding, 1)
+		pre := ord.Preceding[0]
+		assert.Equal(t, "PO-001", pre.Code.String())
+		assert.Equal(t, "quantity changed", pre.Reason)
+		assert.Equal(t, bill.OrderTypePurchase, pre.Type)
+	})
+	t.Run("change type", func(t *testing.T) {
+		ord := testOrderStandard(t)
+		ord.Type = bill.OrderTypeQuote
+		require.NoError(t, ord.Calculate())
+		err := ord.Correct(func(o any) {
+			o.(*bill.CorrectionOptions).Type = bill.OrderTypeSales
+		})
+		require.NoError(t, err)
+		assert.Equal(t, bill.OrderTypeSales, ord.Type)
+		assert.Equal(t, bill.OrderTypeQuote, ord.Preceding[0].Type)
+	})
+	t.Run("invalid type", func(t *testing.T) {
+		ord := testOrderStandard(t)
+		require.NoError(t, ord.Calculate())
+		err := ord.Correct(bill.Credit)
+		assert.ErrorContains(t, err, "invalid correction type: credit-note")
+		assert.NotEmpty(t, ord.Code, "should not be modified")
+		assert.Empty(t, ord.Preceding)
+	})
+	t.Run("missing code", func(t *testing.T) {
+		ord := testOrderStandard(t)
+		ord.Code = ""
+		err := ord.Correct()
+		assert.ErrorContains(t, err, "cannot correct an order without a code")
+	})
+	t.Run("line selection", func(t *testing.T) {
+		ord := testOrderStandard(t)
+		err := ord.Correct(bill.WithLines(&bill.LineSelection{Index: 1}))
+		assert.ErrorContains(t, err, "line selection is only supported for invoices")
+	})
+	t.Run("options schema", func(t *testing.T) {
+		ord := testOrderStandard(t)
+		require.NoError(t, ord.Calculate())
+		out, err := ord.CorrectionOptionsSchema()
+		require.NoError(t, err)
+		js, ok := out.(*jsonschema.Schema)
+		require.True(t, ok)
+		assert.Contains(t, js.ID.String(), "?tax_regime=es")
+	})
+}
+
+func TestOrderReplicate(t *testing.T) {
+	ord := testOrderStandard(t)
+	require.NoError(t, ord.Calculate())
+	require.NoError(t, ord.Replicate())
+	assert.Empty(t, ord.UUID)
+	assert.Empty(t, ord.Code)
+	assert.Equal(t, cal.Today(), ord.IssueDate)
+}
+
+func TestOrderUnmarshalJSON(t *testing.T) {
+	data := []byte(`{
+		"type": "sales",
+		"code": "SO-1",
+		"supplier": {
+			"name": "Test Supplier",
+			"tax_id": {"country": "ES", "code": "B98602642"}
+		}
+	}`)
+	ord := new(bill.Order)
+	require.NoError(t, json.Unmarshal(data, ord))
+	assert.Equal(t, "ES", ord.GetRegime().String())
+	assert.Equal(t, bill.OrderTypeSales, ord.Type)
+}
+
+func TestOrderJSONSchemaExtend(t *testing.T) {
+	js := new(jsonschema.Schema)
+	js.Properties = jsonschema.NewProperties()
+	js.Properties.Set("type", &jsonschema.Schema{})
+	ord := bill.Order{}
+	ord.JSONSchemaExtend(js)
+	prop, ok := js.Properties.Get("type")
+	require.True(t, ok)
+	require.Len(t, prop.OneOf, len(bill.OrderTypes))
+	assert.Equal(t, bill.OrderTypePurchase.String(), prop.OneOf[0].Const)
+}
+
+func testOrderStandard(t *testing.T) *bill.Order {
+	t.Helper()
+	return &bill.Order{
+		Code:      "PO-001",
+		IssueDate: cal.MakeDate(2024, 11, 13),
+		Supplier: &org.Party{
+			Name: "Test Supplier",
+			TaxID: &tax.Identity{
+				Country: "ES",
+				Code:    "B98602642",
+			},
+		},
+		Customer: &org.Party{
+			Name: "Test Customer",
+			TaxID: &tax.Identity{
+				Country: "ES",
+				Code:    "54387763P",
+			},
+		},
+		Lines: []*bill.Line{
+			{
+				Quantity: num.MakeAmount(10, 0),
+				Item: &org.Item{
+					Name:  "Test Item",
+					Price: num.MakeAmount(10000, 2),
+				},
+				Discounts: []*bill.LineDiscount{
+					{
+						Percent: num.NewPercentage(10, 2),
+						Reason:  "Testing",
+					},
+				},
+				Taxes: tax.Set{
+					{
+						Category: tax.CategoryVAT,
+						Rate:     tax.RateStandard,
+					},
+				},
+			},
+		},
+	}
+}
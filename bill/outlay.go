@@ -0,0 +1,90 @@
+package bill
+
+import (
+	"context"
+
+	"github.com/invopop/gobl/cal"
+	"github.com/invopop/gobl/cbc"
+	"github.com/invopop/gobl/currency"
+	"github.com/invopop/gobl/num"
+	"github.com/invopop/gobl/org"
+	"github.com/invopop/gobl/tax"
+	"github.com/invopop/gobl/uuid"
+	"github.com/invopop/validation"
+)
+
+// Outlay represents a reimbursable expense that was paid for by the supplier
+// on behalf of the customer, and invoiced by a third party directly in the
+// customer's name. Outlays are not subject to tax, so they are added to the
+// invoice's payable amount after all taxes have been calculated. Known
+// as "suplidos" in Spain and "despesas reembolsáveis" in Portugal.
+type Outlay struct {
+	uuid.Identify
+	// Outlay number index inside the invoice for ordering (calculated).
+	Index int `json:"i" jsonschema:"title=Index" jsonschema_extras:"calculated=true"`
+	// When was the outlay made.
+	Date *cal.Date `json:"date,omitempty" jsonschema:"title=Date"`
+	// Invoice number or other reference detail used to identify the outlay.
+	Code cbc.Code `json:"code,omitempty" jsonschema:"title=Code"`
+	// Series of the outlay invoice.
+	Series cbc.Code `json:"series,omitempty" jsonschema:"title=Series"`
+	// Details on what the outlay was.
+	Description string `json:"description" jsonschema:"title=Description"`
+	// Who was the supplier of the outlay
+	Supplier *org.Party `json:"supplier,omitempty" jsonschema:"title=Supplier"`
+	// Amount paid by the supplier.
+	Amount num.Amount `json:"amount" jsonschema:"title=Amount"`
+	// Extension codes that apply to the outlay
+	Ext tax.Extensions `json:"ext,omitempty" jsonschema:"title=Extensions"`
+}
+
+// Normalize performs normalization on the outlay and embedded objects using
+// the provided list of normalizers.
+func (o *Outlay) Normalize(normalizers tax.Normalizers) {
+	if o == nil {
+		return
+	}
+	o.Code = cbc.NormalizeCode(o.Code)
+	o.Series = cbc.NormalizeCode(o.Series)
+	o.Ext = tax.CleanExtensions(o.Ext)
+	normalizers.Each(o)
+	tax.Normalize(normalizers, o.Supplier)
+}
+
+// ValidateWithContext ensures that the fields contained in the Outlay look correct.
+func (o *Outlay) ValidateWithContext(ctx context.Context) error {
+	return tax.ValidateStructWithContext(ctx, o,
+		validation.Field(&o.UUID),
+		validation.Field(&o.Index, validation.Required),
+		validation.Field(&o.Date),
+		validation.Field(&o.Code),
+		validation.Field(&o.Series),
+		validation.Field(&o.Description, validation.Required),
+		validation.Field(&o.Supplier),
+		validation.Field(&o.Amount, validation.Required, num.Positive),
+		validation.Field(&o.Ext),
+	)
+}
+
+func (o *Outlay) convertInto(ex *currency.ExchangeRate) *Outlay {
+	o2 := *o
+	o2.Amount = o2.Amount.
+		Upscale(defaultCurrencyConversionAccuracy).
+		Multiply(ex.Amount).
+		Downscale(defaultCurrencyConversionAccuracy)
+	return &o2
+}
+
+func calculateOutlays(outlays []*Outlay, zero num.Amount) *num.Amount {
+	if len(outlays) == 0 {
+		return nil
+	}
+	sum := zero
+	for i, o := range outlays {
+		o.Index = i + 1
+		o.Amount = o.Amount.MatchPrecision(zero)
+		sum = sum.MatchPrecision(o.Amount)
+		sum = sum.Add(o.Amount)
+	}
+	return &sum
+}
@@ -0,0 +1,138 @@
+package bill_test
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/invopop/gobl/bill"
+	"github.com/invopop/gobl/cal"
+	"github.com/invopop/gobl/currency"
+	"github.com/invopop/gobl/num"
+	"github.com/invopop/gobl/org"
+	"github.com/invopop/gobl/pay"
+	"github.com/invopop/gobl/schema"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func testOutlays() []*bill.Outlay {
+	return []*bill.Outlay{
+		{
+			Date:        cal.NewDate(2022, 6, 10),
+			Code:        " FR-0012 ",
+			Description: "Registry fees",
+			Amount:      num.MakeAmount(4550, 2),
+		},
+		{
+			Code:        "C-991",
+			Description: "Courier",
+			Amount:      num.MakeAmount(12, 0),
+		},
+	}
+}
+
+func TestInvoiceOutlays(t *testing.T) {
+	t.Run("calculate", func(t *testing.T) {
+		inv := baseInvoiceWithLines(t)
+		inv.Outlays = testOutlays()
+		require.NoError(t, inv.Calculate())
+		assert.Equal(t, 1, inv.Outlays[0].Index)
+		assert.Equal(t, "FR-0012", inv.Outlays[0].Code.String())
+		assert.Equal(t, 2, inv.Outlays[1].Index)
+		assert.Equal(t, "12.00", inv.Outlays[1].Amount.String())
+		assert.Equal(t, "1000.00", inv.Totals.TotalWithTax.String())
+		assert.Equal(t, "57.50", inv.Totals.Outlays.String())
+		assert.Equal(t, "1057.50", inv.Totals.Payable.String())
+		assert.Equal(t, "173.55", inv.Totals.Tax.String(), "outlays should not be taxed")
+		require.NoError(t, inv.Validate())
+	})
+	t.Run("without outlays", func(t *testing.T) {
+		inv := baseInvoiceWithLines(t)
+		require.NoError(t, inv.Calculate())
+		assert.Nil(t, inv.Totals.Outlays)
+		assert.Equal(t, "1000.00", inv.Totals.Payable.String())
+	})
+	t.Run("with advances", func(t *testing.T) {
+		inv := baseInvoiceWithLines(t)
+		inv.Outlays = testOutlays()
+		inv.Payment = &bill.PaymentDetails{
+			Advances: []*pay.Advance{
+				{
+					Description: "Deposit",
+					Amount:      num.MakeAmount(50000, 2),
+				},
+			},
+		}
+		require.NoError(t, inv.Calculate())
+		assert.Equal(t, "557.50", inv.Totals.Due.String())
+	})
+	t.Run("invert", func(t *testing.T) {
+		inv := baseInvoiceWithLines(t)
+		inv.Outlays = testOutlays()
+		require.NoError(t, inv.Calculate())
+		require.NoError(t, inv.Invert())
+		assert.Equal(t, "-57.50", inv.Totals.Outlays.String())
+		assert.Equal(t, "-1057.50", inv.Totals.Payable.String())
+	})
+	t.Run("convert", func(t *testing.T) {
+		inv := baseInvoiceWithLines(t)
+		inv.Outlays = testOutlays()
+		inv.ExchangeRates = []*currency.ExchangeRate{
+			{
+				From:   currency.EUR,
+				To:     currency.USD,
+				Amount: num.MakeAmount(110, 2),
+			},
+		}
+		inv2, err := inv.ConvertInto(currency.USD)
+		require.NoError(t, err)
+		assert.Equal(t, "50.05", inv2.Outlays[0].Amount.String())
+		assert.Equal(t, "63.25", inv2.Totals.Outlays.String())
+		assert.Equal(t, "45.50", inv.Outlays[0].Amount.String())
+	})
+	t.Run("validation", func(t *testing.T) {
+		inv := baseInvoiceWithLines(t)
+		inv.Outlays = []*bill.Outlay{
+			{
+				Supplier: &org.Party{Name: "Registry"},
+			},
+		}
+		require.NoError(t, inv.Calculate())
+		err := inv.Validate()
+		assert.ErrorContains(t, err, "outlays: (0: (amount: must be greater than 0; description: cannot be blank.).)")
+	})
+	t.Run("zero or negative amount", func(t *testing.T) {
+		inv := baseInvoiceWithLines(t)
+		inv.Outlays = testOutlays()
+		inv.Outlays[0].Amount = num.MakeAmount(0, 2)
+		inv.Outlays[1].Amount = num.MakeAmount(-1000, 2)
+		require.NoError(t, inv.Calculate())
+		err := inv.Validate()
+		assert.ErrorContains(t, err, "outlays: (0: (amount: must be greater than 0.); 1: (amount: must be greater than 0.).)")
+	})
+}
+
+func TestLegacyOutlaysMigration(t *testing.T) {
+	data := []byte(`{"$schema":"https://gobl.org/draft-0/bill/invoice","outlays":[{"desc":"foo","paid":"10.00"},{"desc":"bar","amount":"0"}]}`)
+	obj := new(schema.Object)
+	require.NoError(t, json.Unmarshal(data, obj))
+	inv, ok := obj.Instance().(*bill.Invoice)
+	require.True(t, ok)
+	assert.Empty(t, inv.Outlays)
+	require.Len(t, obj.Migrated(), 1)
+	assert.Equal(t, "bill-outlay-legacy", obj.Migrated()[0].Key)
+
+	data = []byte(`{"$schema":"https://gobl.org/draft-0/bill/invoice","outlays":[{"desc":"foo","paid":"10.00"},{"description":"bar","amount":"5.00"}]}`)
+	obj = new(schema.Object)
+	require.NoError(t, json.Unmarshal(data, obj))
+	inv = obj.Instance().(*bill.Invoice)
+	require.Len(t, inv.Outlays, 1)
+	assert.Equal(t, "bar", inv.Outlays[0].Description)
+	assert.Equal(t, "5.00", inv.Outlays[0].Amount.String())
+	require.Len(t, obj.Migrated(), 1)
+
+	data = []byte(`{"$schema":"https://gobl.org/draft-0/bill/invoice","outlays":[{"description":"foo","amount":"10.00"}]}`)
+	obj = new(schema.Object)
+	require.NoError(t, json.Unmarshal(data, obj))
+	assert.Empty(t, obj.Migrated())
+}
@@ -2,72 +2,352 @@ package bill
 
 import (
 	"context"
+	"encoding/json"
+	"errors"
 
+	"github.com/invopop/gobl/cal"
+	"github.com/invopop/gobl/cbc"
+	"github.com/invopop/gobl/currency"
+	"github.com/invopop/gobl/i18n"
+	"github.com/invopop/gobl/internal"
+	"github.com/invopop/gobl/l10n"
 	"github.com/invopop/gobl/num"
 	"github.com/invopop/gobl/org"
 	"github.com/invopop/gobl/pay"
+	"github.com/invopop/gobl/schema"
 	"github.com/invopop/gobl/tax"
+	"github.com/invopop/gobl/uuid"
+	"github.com/invopop/jsonschema"
 	"github.com/invopop/validation"
 )
 
-// Payment contains details as to how the invoice should be paid.
+// Constants used to help identify payments
+const (
+	ShortSchemaPayment = "bill/payment"
+)
+
+// Predefined list of the payment type codes officially supported.
+const (
+	PaymentTypeRequest cbc.Key = "request"
+	PaymentTypeAdvice  cbc.Key = "advice"
+	PaymentTypeReceipt cbc.Key = "receipt"
+)
+
+// PaymentTypes describes each of the payment types supported.
+var PaymentTypes = []*cbc.KeyDefinition{
+	{
+		Key: PaymentTypeRequest,
+		Name: i18n.String{
+			i18n.EN: "Payment Request",
+		},
+		Desc: i18n.String{
+			i18n.EN: "A request issued by the supplier asking the customer to pay one or more documents.",
+		},
+	},
+	{
+		Key: PaymentTypeAdvice,
+		Name: i18n.String{
+			i18n.EN: "Remittance Advice",
+		},
+		Desc: i18n.String{
+			i18n.EN: "Notification sent by the customer to inform the supplier of a payment that has been made.",
+		},
+		Map: cbc.CodeMap{
+			UNTDID1001Key: "481",
+		},
+	},
+	{
+		Key: PaymentTypeReceipt,
+		Name: i18n.String{
+			i18n.EN: "Receipt",
+		},
+		Desc: i18n.String{
+			i18n.EN: "Confirmation issued by the supplier that a payment has been received.",
+		},
+	},
+}
+
+var isValidPaymentType = validation.In(validPaymentTypes()...)
+
+func validPaymentTypes() []interface{} {
+	list := make([]interface{}, len(PaymentTypes))
+	for i, d := range PaymentTypes {
+		list[i] = d.Key
+	}
+	return list
+}
+
+// Payment documents are used to record the settlement of one or more
+// documents, usually invoices, between the supplier and customer. Payments
+// may be requested by the supplier, advised by the customer, or receipted
+// by the supplier once the funds have been received.
+//
+// The supplier is always the party receiving the payment, and the customer
+// the party making it, regardless of which issued the document.
 type Payment struct {
-	// The party responsible for receiving payment of the invoice, if not the supplier.
+	tax.Regime
+	tax.Addons
+	tax.Tags
+
+	uuid.Identify
+
+	// Type of payment document.
+	Type cbc.Key `json:"type" jsonschema:"title=Type" jsonschema_extras:"calculated=true"`
+	// Used as a prefix to group codes.
+	Series cbc.Code `json:"series,omitempty" jsonschema:"title=Series"`
+	// Code used to identify this payment in the issuer's systems.
+	Code cbc.Code `json:"code" jsonschema:"title=Code"`
+	// When the payment document was issued.
+	IssueDate cal.Date `json:"issue_date" jsonschema:"title=Issue Date" jsonschema_extras:"calculated=true"`
+	// When the payment was made, if different from the issue date.
+	ValueDate *cal.Date `json:"value_date,omitempty" jsonschema:"title=Value Date"`
+	// Currency in which the payment was made.
+	Currency currency.Code `json:"currency" jsonschema:"title=Currency" jsonschema_extras:"calculated=true"`
+	// Exchange rates used to convert the amounts of documents issued in other currencies.
+	ExchangeRates []*currency.ExchangeRate `json:"exchange_rates,omitempty" jsonschema:"title=Exchange Rates"`
+
+	// Key information regarding previous payment documents that this one replaces.
+	Preceding []*org.DocumentRef `json:"preceding,omitempty" jsonschema:"title=Preceding Details"`
+
+	// Extensions for additional codes that may be required by addons.
+	Ext tax.Extensions `json:"ext,omitempty" jsonschema:"title=Extensions"`
+
+	// The party receiving the payment.
+	Supplier *org.Party `json:"supplier" jsonschema:"title=Supplier"`
+	// The party making the payment.
+	Customer *org.Party `json:"customer,omitempty" jsonschema:"title=Customer"`
+	// The party responsible for issuing the payment, if not the customer.
+	Payer *org.Party `json:"payer,omitempty" jsonschema:"title=Payer"`
+	// The party receiving the payment on behalf of the supplier, if not the supplier.
 	Payee *org.Party `json:"payee,omitempty" jsonschema:"title=Payee"`
-	// Payment terms or conditions.
-	Terms *pay.Terms `json:"terms,omitempty" jsonschema:"title=Terms"`
-	// Any amounts that have been paid in advance and should be deducted from the amount due.
-	Advances []*pay.Advance `json:"advances,omitempty" jsonschema:"title=Advances"`
-	// Details on how payment should be made.
-	Instructions *pay.Instructions `json:"instructions,omitempty" jsonschema:"title=Instructions"`
+
+	// Means used to make the payment.
+	Method *pay.Instructions `json:"method,omitempty" jsonschema:"title=Method"`
+
+	// Ordering details including document references and buyer or seller parties.
+	Ordering *Ordering `json:"ordering,omitempty" jsonschema:"title=Ordering Details"`
+
+	// List of documents being paid.
+	Lines []*PaymentLine `json:"lines,omitempty" jsonschema:"title=Lines"`
+
+	// Total amount paid in the payment's currency (calculated).
+	Total num.Amount `json:"total" jsonschema:"title=Total" jsonschema_extras:"calculated=true"`
+	// Summary of the taxes included in the lines (calculated).
+	Tax *tax.Total `json:"tax,omitempty" jsonschema:"title=Tax" jsonschema_extras:"calculated=true"`
+
+	// Unstructured information that is relevant to the payment.
+	Notes []*cbc.Note `json:"notes,omitempty" jsonschema:"title=Notes"`
+
+	// Additional complementary objects that add relevant information to the payment.
+	Complements []*schema.Object `json:"complements,omitempty" jsonschema:"title=Complements"`
+
+	// Additional semi-structured data that doesn't fit into the body of the payment.
+	Meta cbc.Meta `json:"meta,omitempty" jsonschema:"title=Meta"`
 }
 
-// Normalize will try to normalize the payment's data.
-func (p *Payment) Normalize(normalizers tax.Normalizers) {
-	if p == nil {
-		return
-	}
-	normalizers.Each(p)
-	tax.Normalize(normalizers, p.Payee)
-	tax.Normalize(normalizers, p.Terms)
-	tax.Normalize(normalizers, p.Advances)
-	tax.Normalize(normalizers, p.Instructions)
+// Validate checks to ensure the payment is valid and contains all the information we need.
+func (pmt *Payment) Validate() error {
+	return pmt.ValidateWithContext(context.Background())
 }
 
-// ValidateWithContext checks to make sure the payment data looks good
-func (p *Payment) ValidateWithContext(ctx context.Context) error {
-	return tax.ValidateStructWithContext(ctx, p,
-		validation.Field(&p.Payee),
-		validation.Field(&p.Terms),
-		validation.Field(&p.Advances),
-		validation.Field(&p.Instructions),
+// ValidateWithContext checks to ensure the payment is valid and contains all the
+// information we need.
+func (pmt *Payment) ValidateWithContext(ctx context.Context) error {
+	ctx = pmt.ValidationContext(ctx)
+
+	return tax.ValidateStructWithContext(ctx, pmt,
+		validation.Field(&pmt.Regime),
+		validation.Field(&pmt.Addons),
+		validation.Field(&pmt.Tags.List, tax.TagsIn(pmt.supportedTags()...)),
+		validation.Field(&pmt.UUID),
+		validation.Field(&pmt.Type,
+			validation.Required,
+			isValidPaymentType,
+		),
+		validation.Field(&pmt.Series),
+		validation.Field(&pmt.Code,
+			validation.When(
+				internal.IsSigned(ctx),
+				validation.Required.Error("required to sign payment"),
+			),
+		),
+		validation.Field(&pmt.IssueDate,
+			cal.DateNotZero(),
+		),
+		validation.Field(&pmt.ValueDate),
+		validation.Field(&pmt.Currency, validation.Required),
+		validation.Field(&pmt.ExchangeRates),
+		validation.Field(&pmt.Preceding),
+		validation.Field(&pmt.Ext),
+		validation.Field(&pmt.Supplier,
+			validation.Required,
+			validation.By(validateInvoiceSupplier),
+		),
+		validation.Field(&pmt.Customer,
+			validation.Required,
+			validation.By(validateInvoiceCustomer),
+		),
+		validation.Field(&pmt.Payer),
+		validation.Field(&pmt.Payee),
+		validation.Field(&pmt.Method,
+			validation.When(
+				!pmt.Type.In(PaymentTypeRequest),
+				validation.Required,
+			),
+		),
+		validation.Field(&pmt.Ordering),
+		validation.Field(&pmt.Lines, validation.Required),
+		validation.Field(&pmt.Total, num.Positive),
+		validation.Field(&pmt.Tax),
+		validation.Field(&pmt.Notes),
+		validation.Field(&pmt.Complements),
+		validation.Field(&pmt.Meta),
 	)
 }
 
-// ResetAdvances clears the advances list.
-func (p *Payment) ResetAdvances() {
-	if p == nil {
-		return
+// ValidationContext builds a context with all the validators that the payment might
+// need for execution.
+func (pmt *Payment) ValidationContext(ctx context.Context) context.Context {
+	if r := pmt.RegimeDef(); r != nil {
+		ctx = r.WithContext(ctx)
 	}
-	p.Advances = make([]*pay.Advance, 0)
+	for _, a := range pmt.AddonDefs() {
+		ctx = a.WithContext(ctx)
+	}
+	return ctx
 }
 
-func (p *Payment) calculateAdvances(zero num.Amount, totalWithTax num.Amount) {
-	for _, a := range p.Advances {
-		a.CalculateFrom(totalWithTax)
-		a.Amount = a.Amount.MatchPrecision(zero)
+// Calculate performs all the normalizations and calculations required for the
+// payment totals.
+func (pmt *Payment) Calculate() error {
+	// Try to set Regime if not already prepared from the supplier's tax ID
+	if pmt.Regime.IsEmpty() {
+		pmt.SetRegime(pmt.supplierTaxCountry())
+	}
+
+	pmt.Normalize(tax.ExtractNormalizers(pmt))
+
+	return pmt.calculate()
+}
+
+// Normalize is run as part of the Calculate method to ensure that the payment
+// is in a consistent state before calculations are performed. This will leverage
+// any add-ons alongside the tax regime.
+func (pmt *Payment) Normalize(normalizers tax.Normalizers) {
+	if pmt.Type == cbc.KeyEmpty {
+		pmt.Type = PaymentTypeReceipt
+	}
+	pmt.Series = cbc.NormalizeCode(pmt.Series)
+	pmt.Code = cbc.NormalizeCode(pmt.Code)
+	pmt.Ext = tax.CleanExtensions(pmt.Ext)
+
+	normalizers.Each(pmt)
+
+	tax.Normalize(normalizers, pmt.Supplier)
+	tax.Normalize(normalizers, pmt.Customer)
+	tax.Normalize(normalizers, pmt.Payer)
+	tax.Normalize(normalizers, pmt.Payee)
+	tax.Normalize(normalizers, pmt.Preceding)
+	tax.Normalize(normalizers, pmt.Method)
+	tax.Normalize(normalizers, pmt.Ordering)
+	tax.Normalize(normalizers, pmt.Lines)
+}
+
+// calculate does not assume that the tax regime is available.
+func (pmt *Payment) calculate() error {
+	r := pmt.RegimeDef() // may be nil!
+
+	if pmt.IssueDate.IsZero() {
+		pmt.IssueDate = cal.TodayIn(r.TimeLocation())
+	}
+
+	// Convert empty or invalid currency to the regime's currency
+	if pmt.Currency == currency.CodeEmpty || pmt.Currency.Def() == nil {
+		if r == nil {
+			return validation.Errors{"currency": errors.New("missing")}
+		}
+		pmt.Currency = r.Currency
+	}
+
+	total, tt, err := calculatePaymentLines(pmt.Lines, pmt.Currency, pmt.ExchangeRates)
+	if err != nil {
+		return validation.Errors{"lines": err}
+	}
+	pmt.Total = total
+	pmt.Tax = tt
+
+	// Complements
+	if err := calculateComplements(pmt.Complements); err != nil {
+		return validation.Errors{"complements": err}
+	}
+
+	return nil
+}
+
+// UNTDID1001 provides the official code number assigned with the payment type,
+// if available.
+func (pmt *Payment) UNTDID1001() cbc.Code {
+	for _, d := range PaymentTypes {
+		if d.Key == pmt.Type {
+			return d.Map[UNTDID1001Key]
+		}
+	}
+	return cbc.CodeEmpty
+}
+
+func (pmt *Payment) supportedTags() []cbc.Key {
+	var ts *tax.TagSet
+	if r := pmt.RegimeDef(); r != nil {
+		ts = ts.Merge(tax.TagSetForSchema(r.Tags, ShortSchemaPayment))
+	}
+	for _, a := range pmt.AddonDefs() {
+		ts = ts.Merge(tax.TagSetForSchema(a.Tags, ShortSchemaPayment))
+	}
+	return ts.Keys()
+}
+
+// supplierTaxCountry determines the tax country for the payment based on the supplier tax
+// identity.
+func (pmt *Payment) supplierTaxCountry() l10n.TaxCountryCode {
+	if pmt.Supplier == nil || pmt.Supplier.TaxID == nil {
+		return l10n.CodeEmpty.Tax()
+	}
+	return pmt.Supplier.TaxID.Country
+}
+
+// UnmarshalJSON implements the json.Unmarshaler interface and ensures the
+// regime is set when coming in from a raw JSON source.
+func (pmt *Payment) UnmarshalJSON(data []byte) error {
+	type Alias Payment
+	if err := json.Unmarshal(data, (*Alias)(pmt)); err != nil {
+		return err
+	}
+	if pmt.Regime.IsEmpty() {
+		pmt.SetRegime(pmt.supplierTaxCountry())
 	}
+	return nil
 }
 
-func (p *Payment) totalAdvance(zero num.Amount) *num.Amount {
-	if p == nil || len(p.Advances) == 0 {
-		return nil
+// JSONSchemaExtend extends the schema with additional property details
+func (pmt Payment) JSONSchemaExtend(js *jsonschema.Schema) {
+	props := js.Properties
+	if its, ok := props.Get("type"); ok {
+		its.OneOf = make([]*jsonschema.Schema, len(PaymentTypes))
+		for i, kd := range PaymentTypes {
+			its.OneOf[i] = &jsonschema.Schema{
+				Const:       kd.Key.String(),
+				Title:       kd.Name.String(),
+				Description: kd.Desc.String(),
+			}
+		}
 	}
-	sum := zero
-	for _, a := range p.Advances {
-		sum = sum.MatchPrecision(a.Amount)
-		sum = sum.Add(a.Amount)
-		a.Amount = a.Amount.Rescale(zero.Exp())
+	pmt.Regime.JSONSchemaExtend(js)
+	pmt.Addons.JSONSchemaExtend(js)
+	// Recommendations
+	js.Extras = map[string]any{
+		schema.Recommended: []string{
+			"$regime",
+			"lines",
+		},
 	}
-	return &sum
 }
@@ -0,0 +1,133 @@
+package bill
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+
+	"github.com/invopop/gobl/currency"
+	"github.com/invopop/gobl/num"
+	"github.com/invopop/gobl/org"
+	"github.com/invopop/gobl/tax"
+	"github.com/invopop/gobl/uuid"
+	"github.com/invopop/validation"
+)
+
+// PaymentLine defines the details of a payment made against a specific
+// document, usually an invoice.
+type PaymentLine struct {
+	uuid.Identify
+	// Line number inside the payment (calculated)
+	Index int `json:"i" jsonschema:"title=Index" jsonschema_extras:"calculated=true"`
+	// Reference to the document being paid.
+	Document *org.DocumentRef `json:"document" jsonschema:"title=Document"`
+	// When making multiple payments for a single document, this specifies the
+	// installment number for this payment, starting from 1.
+	Installment int `json:"installment,omitempty" jsonschema:"title=Installment"`
+	// Additional human readable description of the payment line.
+	Description string `json:"description,omitempty" jsonschema:"title=Description"`
+	// Currency of the document being paid, if different from the payment's
+	// currency. All the amounts in the line will be in this currency.
+	Currency currency.Code `json:"currency,omitempty" jsonschema:"title=Currency"`
+	// Total amount payable on the document before any payments were made.
+	Payable *num.Amount `json:"payable,omitempty" jsonschema:"title=Payable"`
+	// Amount already paid on the document before this payment.
+	Advances *num.Amount `json:"advances,omitempty" jsonschema:"title=Advances"`
+	// Amount paid against the document in this payment.
+	Amount num.Amount `json:"amount" jsonschema:"title=Amount"`
+	// Amount still to be paid on the document after this payment (calculated).
+	Due *num.Amount `json:"due,omitempty" jsonschema:"title=Due" jsonschema_extras:"calculated=true"`
+	// Breakdown of the taxes included in the amount paid, required by
+	// some regimes that apply cash accounting.
+	Tax *tax.Total `json:"tax,omitempty" jsonschema:"title=Tax"`
+}
+
+// Normalize performs normalization on the line and embedded objects using the
+// provided list of normalizers.
+func (pl *PaymentLine) Normalize(normalizers tax.Normalizers) {
+	if pl == nil {
+		return
+	}
+	uuid.Normalize(&pl.UUID)
+	normalizers.Each(pl)
+	tax.Normalize(normalizers, pl.Document)
+}
+
+// ValidateWithContext ensures the payment line contains everything required.
+func (pl *PaymentLine) ValidateWithContext(ctx context.Context) error {
+	return tax.ValidateStructWithContext(ctx, pl,
+		validation.Field(&pl.UUID),
+		validation.Field(&pl.Index, validation.Required),
+		validation.Field(&pl.Document, validation.Required),
+		validation.Field(&pl.Installment, validation.Min(1)),
+		validation.Field(&pl.Currency),
+		validation.Field(&pl.Payable),
+		validation.Field(&pl.Advances, num.Min(num.AmountZero)),
+		validation.Field(&pl.Amount, validation.Required, num.Positive),
+		validation.Field(&pl.Due, num.Min(num.AmountZero)),
+		validation.Field(&pl.Tax),
+	)
+}
+
+// currency provides the line's currency, defaulting to the payment's.
+func (pl *PaymentLine) currency(cur currency.Code) currency.Code {
+	if pl.Currency == currency.CodeEmpty {
+		return cur
+	}
+	return pl.Currency
+}
+
+func (pl *PaymentLine) calculate(cur currency.Code) {
+	zero := pl.currency(cur).Def().Zero()
+	pl.Amount = pl.Amount.Rescale(zero.Exp())
+	if pl.Advances != nil {
+		a := pl.Advances.Rescale(zero.Exp())
+		pl.Advances = &a
+	}
+	pl.Due = nil
+	if pl.Payable != nil {
+		p := pl.Payable.Rescale(zero.Exp())
+		pl.Payable = &p
+		due := p.Subtract(pl.Amount)
+		if pl.Advances != nil {
+			due = due.Subtract(*pl.Advances)
+		}
+		pl.Due = &due
+	}
+}
+
+// exchange provides the line's amount and tax breakdown in the payment's
+// currency.
+func (pl *PaymentLine) exchange(cur currency.Code, rates []*currency.ExchangeRate) (num.Amount, *tax.Total, error) {
+	lcur := pl.currency(cur)
+	if lcur == cur {
+		return pl.Amount, pl.Tax, nil
+	}
+	ex := currency.MatchExchangeRate(rates, lcur, cur)
+	if ex == nil {
+		return num.AmountZero, nil, fmt.Errorf("no exchange rate found from '%v' to '%v'", lcur, cur)
+	}
+	return ex.Convert(pl.Amount), pl.Tax.Convert(ex), nil
+}
+
+func calculatePaymentLines(lines []*PaymentLine, cur currency.Code, rates []*currency.ExchangeRate) (num.Amount, *tax.Total, error) {
+	total := cur.Def().Zero()
+	var tt *tax.Total
+	for i, pl := range lines {
+		if pl == nil {
+			return total, nil, validation.Errors{strconv.Itoa(i): errors.New("missing")}
+		}
+		pl.Index = i + 1
+		pl.calculate(cur)
+		amount, lt, err := pl.exchange(cur, rates)
+		if err != nil {
+			return total, nil, validation.Errors{strconv.Itoa(i): err}
+		}
+		total = total.Add(amount)
+		if lt != nil {
+			tt = tt.Merge(lt)
+		}
+	}
+	return total, tt, nil
+}
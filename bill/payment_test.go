@@ -1,90 +1,232 @@
-package bill
+package bill_test
 
 import (
+	"encoding/json"
 	"testing"
 
+	"github.com/invopop/gobl/bill"
+	"github.com/invopop/gobl/cal"
+	"github.com/invopop/gobl/cbc"
+	"github.com/invopop/gobl/currency"
 	"github.com/invopop/gobl/num"
+	"github.com/invopop/gobl/org"
 	"github.com/invopop/gobl/pay"
 	"github.com/invopop/gobl/tax"
+	"github.com/invopop/jsonschema"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
-func TestPaymentNormalize(t *testing.T) {
-	p := &Payment{
-		Instructions: &pay.Instructions{
-			Key:    "online",
-			Detail: "Some random payment",
-			Ext: tax.Extensions{
-				"random": "",
+func TestPaymentCalculate(t *testing.T) {
+	t.Run("basic", func(t *testing.T) {
+		pmt := testPaymentStandard(t)
+		require.NoError(t, pmt.Calculate())
+		assert.Equal(t, "ES", pmt.GetRegime().String())
+		assert.Equal(t, bill.PaymentTypeReceipt, pmt.Type)
+		assert.Equal(t, currency.EUR, pmt.Currency)
+		assert.Equal(t, "1500.00", pmt.Total.String())
+		l := pmt.Lines[0]
+		assert.Equal(t, 1, l.Index)
+		assert.Equal(t, "1000.00", l.Amount.String())
+		assert.Equal(t, "210.00", l.Due.String())
+		assert.Nil(t, pmt.Lines[1].Due)
+		assert.Nil(t, pmt.Tax)
+		require.NoError(t, pmt.Validate())
+	})
+	t.Run("with advances", func(t *testing.T) {
+		pmt := testPaymentStandard(t)
+		adv := num.MakeAmount(10000, 2)
+		pmt.Lines[0].Advances = &adv
+		require.NoError(t, pmt.Calculate())
+		assert.Equal(t, "110.00", pmt.Lines[0].Due.String())
+	})
+	t.Run("with taxes", func(t *testing.T) {
+		pmt := testPaymentStandard(t)
+		pmt.Lines[0].Tax = testPaymentTax(82645, 17355)
+		pmt.Lines[1].Tax = testPaymentTax(41322, 8678)
+		require.NoError(t, pmt.Calculate())
+		require.NotNil(t, pmt.Tax)
+		assert.Equal(t, "260.33", pmt.Tax.Sum.String())
+		require.Len(t, pmt.Tax.Categories, 1)
+		assert.Equal(t, "1239.67", pmt.Tax.Categories[0].Rates[0].Base.String())
+		assert.Equal(t, "826.45", pmt.Lines[0].Tax.Categories[0].Rates[0].Base.String())
+	})
+	t.Run("foreign currency document", func(t *testing.T) {
+		pmt := testPaymentStandard(t)
+		pmt.ExchangeRates = []*currency.ExchangeRate{
+			{
+				From:   currency.USD,
+				To:     currency.EUR,
+				Amount: num.MakeAmount(900, 3),
 			},
-		},
-	}
-	p.Normalize(nil)
-	assert.Empty(t, p.Instructions.Ext)
-	assert.NotPanics(t, func() {
-		p.Normalize(nil)
+		}
+		pmt.Lines[1].Currency = currency.USD
+		pmt.Lines[1].Tax = testPaymentTax(41322, 8678)
+		require.NoError(t, pmt.Calculate())
+		assert.Equal(t, "1450.00", pmt.Total.String())
+		assert.Equal(t, "78.10", pmt.Tax.Sum.String())
+		assert.Equal(t, "86.78", pmt.Lines[1].Tax.Sum.String())
+	})
+	t.Run("missing exchange rate", func(t *testing.T) {
+		pmt := testPaymentStandard(t)
+		pmt.Lines[1].Currency = currency.USD
+		err := pmt.Calculate()
+		assert.ErrorContains(t, err, "lines: (1: no exchange rate found from 'USD' to 'EUR'.)")
+	})
+	t.Run("nil line", func(t *testing.T) {
+		pmt := testPaymentStandard(t)
+		pmt.Lines = append(pmt.Lines, nil)
+		err := pmt.Calculate()
+		assert.ErrorContains(t, err, "lines: (2: missing.)")
 	})
+	t.Run("missing currency", func(t *testing.T) {
+		pmt := testPaymentStandard(t)
+		pmt.Supplier.TaxID = nil
+		pmt.Regime = tax.Regime{}
+		err := pmt.Calculate()
+		assert.ErrorContains(t, err, "currency: missing")
+	})
+}
+
+func TestPaymentValidation(t *testing.T) {
+	t.Run("missing lines", func(t *testing.T) {
+		pmt := testPaymentStandard(t)
+		pmt.Lines = nil
+		require.NoError(t, pmt.Calculate())
+		assert.ErrorContains(t, pmt.Validate(), "lines: cannot be blank")
+	})
+	t.Run("missing method", func(t *testing.T) {
+		pmt := testPaymentStandard(t)
+		pmt.Method = nil
+		require.NoError(t, pmt.Calculate())
+		assert.ErrorContains(t, pmt.Validate(), "method: cannot be blank")
+	})
+	t.Run("request without method", func(t *testing.T) {
+		pmt := testPaymentStandard(t)
+		pmt.Type = bill.PaymentTypeRequest
+		pmt.Method = nil
+		require.NoError(t, pmt.Calculate())
+		assert.NoError(t, pmt.Validate())
+	})
+	t.Run("missing document", func(t *testing.T) {
+		pmt := testPaymentStandard(t)
+		pmt.Lines[0].Document = nil
+		require.NoError(t, pmt.Calculate())
+		assert.ErrorContains(t, pmt.Validate(), "lines: (0: (document: cannot be blank.).)")
+	})
+	t.Run("zero amount", func(t *testing.T) {
+		pmt := testPaymentStandard(t)
+		pmt.Lines[1].Amount = num.MakeAmount(0, 2)
+		require.NoError(t, pmt.Calculate())
+		assert.ErrorContains(t, pmt.Validate(), "lines: (1: (amount: must be greater than 0.).)")
+	})
+	t.Run("amount exceeds payable", func(t *testing.T) {
+		pmt := testPaymentStandard(t)
+		pmt.Lines[0].Amount = num.MakeAmount(150000, 2)
+		require.NoError(t, pmt.Calculate())
+		assert.ErrorContains(t, pmt.Validate(), "lines: (0: (due: must be no less than 0.).)")
+	})
+	t.Run("invalid type", func(t *testing.T) {
+		pmt := testPaymentStandard(t)
+		pmt.Type = bill.InvoiceTypeStandard
+		require.NoError(t, pmt.Calculate())
+		assert.ErrorContains(t, pmt.Validate(), "type: must be a valid value")
+	})
+}
+
+func TestPaymentUNTDID1001(t *testing.T) {
+	pmt := testPaymentStandard(t)
+	pmt.Type = bill.PaymentTypeAdvice
+	assert.Equal(t, cbc.Code("481"), pmt.UNTDID1001())
+	pmt.Type = bill.PaymentTypeReceipt
+	assert.Equal(t, cbc.CodeEmpty, pmt.UNTDID1001())
 }
 
-func TestPaymentCalculations(t *testing.T) {
-	zero := num.MakeAmount(0, 2)
-	total := num.MakeAmount(20000, 2)
-	p := &Payment{
-		Advances: []*pay.Advance{
+func TestPaymentUnmarshalJSON(t *testing.T) {
+	data := []byte(`{
+		"type": "advice",
+		"code": "RA-1",
+		"supplier": {
+			"name": "Test Supplier",
+			"tax_id": {"country": "ES", "code": "B98602642"}
+		}
+	}`)
+	pmt := new(bill.Payment)
+	require.NoError(t, json.Unmarshal(data, pmt))
+	assert.Equal(t, "ES", pmt.GetRegime().String())
+	assert.Equal(t, bill.PaymentTypeAdvice, pmt.Type)
+}
+
+func TestPaymentJSONSchemaExtend(t *testing.T) {
+	js := new(jsonschema.Schema)
+	js.Properties = jsonschema.NewProperties()
+	js.Properties.Set("type", &jsonschema.Schema{})
+	pmt := bill.Payment{}
+	pmt.JSONSchemaExtend(js)
+	prop, ok := js.Properties.Get("type")
+	require.True(t, ok)
+	require.Len(t, prop.OneOf, len(bill.PaymentTypes))
+	assert.Equal(t, bill.PaymentTypeRequest.String(), prop.OneOf[0].Const)
+}
+
+func testPaymentTax(base, amount int64) *tax.Total {
+	return &tax.Total{
+		Categories: []*tax.CategoryTotal{
 			{
-				Description: "Paid in advance",
-				Percent:     num.NewPercentage(10, 2),
+				Code: tax.CategoryVAT,
+				Rates: []*tax.RateTotal{
+					{
+						Key:     tax.RateStandard,
+						Base:    num.MakeAmount(base, 2),
+						Percent: num.NewPercentage(21, 2),
+						Amount:  num.MakeAmount(amount, 2),
+					},
+				},
+				Amount: num.MakeAmount(amount, 2),
 			},
 		},
+		Sum: num.MakeAmount(amount, 2),
 	}
-	p.calculateAdvances(zero, total)
-	assert.Equal(t, "20.00", p.Advances[0].Amount.String())
+}
 
-	p = &Payment{
-		Advances: []*pay.Advance{
-			{
-				Description: "Paid in advance",
-				Amount:      num.MakeAmount(10, 0),
+func testPaymentStandard(t *testing.T) *bill.Payment {
+	t.Helper()
+	payable := num.MakeAmount(121000, 2)
+	return &bill.Payment{
+		Code:      "REC-001",
+		IssueDate: cal.MakeDate(2024, 12, 2),
+		Supplier: &org.Party{
+			Name: "Test Supplier",
+			TaxID: &tax.Identity{
+				Country: "ES",
+				Code:    "B98602642",
 			},
 		},
-	}
-	assert.Equal(t, "10", p.Advances[0].Amount.String())
-	p.calculateAdvances(zero, total)
-	assert.Equal(t, "10.00", p.Advances[0].Amount.String())
-	ta := p.totalAdvance(zero)
-	assert.Equal(t, "10.00", ta.String())
-
-	p = &Payment{
-		Advances: []*pay.Advance{
+		Customer: &org.Party{
+			Name: "Test Customer",
+			TaxID: &tax.Identity{
+				Country: "ES",
+				Code:    "54387763P",
+			},
+		},
+		Method: &pay.Instructions{
+			Key: pay.MeansKeyCreditTransfer,
+		},
+		Lines: []*bill.PaymentLine{
 			{
-				Description: "Paid in advance",
-				Amount:      num.MakeAmount(10, 0),
+				Document: &org.DocumentRef{
+					Code:      "INV-001",
+					IssueDate: cal.NewDate(2024, 11, 13),
+				},
+				Payable: &payable,
+				Amount:  num.MakeAmount(1000, 0),
 			},
 			{
-				Description: "Paid in advance %",
-				Percent:     num.NewPercentage(10, 2),
+				Document: &org.DocumentRef{
+					Code: "INV-002",
+				},
+				Amount: num.MakeAmount(50000, 2),
 			},
 		},
 	}
-	p.calculateAdvances(zero, total)
-	sum := p.totalAdvance(zero)
-	assert.Equal(t, "30.00", sum.String())
-
-	t.Run("maintains precision", func(t *testing.T) {
-		zero := num.MakeAmount(0, 2)
-		total := num.MakeAmount(20845, 3)
-		p := &Payment{
-			Advances: []*pay.Advance{
-				{
-					Description: "Paid in advance",
-					Percent:     num.NewPercentage(100, 2),
-				},
-			},
-		}
-		p.calculateAdvances(zero, total)
-		a := p.totalAdvance(zero)
-
-		assert.Equal(t, "20.85", p.Advances[0].Amount.String())
-		assert.Equal(t, "20.845", a.String())
-	})
 }
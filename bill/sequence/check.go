@@ -0,0 +1,133 @@
+package sequence
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/invopop/gobl/bill"
+	"github.com/invopop/gobl/cbc"
+)
+
+// Issue types detected when checking sequences.
+const (
+	IssueFormat    cbc.Key = "format"
+	IssueDuplicate cbc.Key = "duplicate"
+	IssueGap       cbc.Key = "gap"
+	IssueDateOrder cbc.Key = "date-order"
+)
+
+// Issue describes a problem found in a sequence.
+type Issue struct {
+	// Type of issue.
+	Type cbc.Key
+	// Key of the sequence.
+	Key Key
+	// Code of the invoice with the issue, or the first missing code in the
+	// case of gaps.
+	Code cbc.Code
+	// Message describing the issue.
+	Message string
+}
+
+// Error provides the issue as a string.
+func (i *Issue) Error() string {
+	if i.Key == (Key{}) {
+		return fmt.Sprintf("%s: %s", i.Code, i.Message)
+	}
+	return fmt.Sprintf("%s: %s: %s", i.Key, i.Code, i.Message)
+}
+
+// Issues is a list of issues that can be returned as an error.
+type Issues []*Issue
+
+// Error provides all the issues as a single string.
+func (is Issues) Error() string {
+	msgs := make([]string, len(is))
+	for i, issue := range is {
+		msgs[i] = issue.Error()
+	}
+	return strings.Join(msgs, "; ")
+}
+
+type checkEntry struct {
+	number int
+	inv    *bill.Invoice
+}
+
+// Check looks for gaps, duplicate codes, and invoices with issue dates
+// earlier than previous numbers in the same sequence within the batch of
+// invoices, which do not need to be sorted. Gaps are only detected between
+// the lowest and highest numbers of each sequence. The issues found are
+// returned as an Issues error.
+func (s *Sequencer) Check(invoices ...*bill.Invoice) error {
+	var issues Issues
+	groups := make(map[Key][]*checkEntry)
+	var keys []Key
+	for _, inv := range invoices {
+		key, err := KeyFor(inv)
+		if err != nil {
+			issues = append(issues, &Issue{Type: IssueFormat, Code: inv.Code, Message: err.Error()})
+			continue
+		}
+		n, ok := s.format.Number(key, inv.Code)
+		if !ok {
+			issues = append(issues, &Issue{
+				Type:    IssueFormat,
+				Key:     key,
+				Code:    inv.Code,
+				Message: fmt.Sprintf("does not match format '%s'", s.format),
+			})
+			continue
+		}
+		if _, ok := groups[key]; !ok {
+			keys = append(keys, key)
+		}
+		groups[key] = append(groups[key], &checkEntry{number: n, inv: inv})
+	}
+	for _, key := range keys {
+		issues = append(issues, s.checkSequence(key, groups[key])...)
+	}
+	if len(issues) == 0 {
+		return nil
+	}
+	return issues
+}
+
+func (s *Sequencer) checkSequence(key Key, entries []*checkEntry) Issues {
+	var issues Issues
+	sort.SliceStable(entries, func(i, j int) bool {
+		return entries[i].number < entries[j].number
+	})
+	for i := 1; i < len(entries); i++ {
+		prev, cur := entries[i-1], entries[i]
+		code := cur.inv.Code
+		switch {
+		case cur.number == prev.number:
+			issues = append(issues, &Issue{
+				Type:    IssueDuplicate,
+				Key:     key,
+				Code:    code,
+				Message: fmt.Sprintf("number %d used more than once", cur.number),
+			})
+			continue
+		case cur.number > prev.number+1:
+			missing := cur.number - prev.number - 1
+			issues = append(issues, &Issue{
+				Type:    IssueGap,
+				Key:     key,
+				Code:    s.format.Code(key, prev.number+1),
+				Message: fmt.Sprintf("%d missing before '%s'", missing, code),
+			})
+		}
+		if cur.inv.IssueDate.Before(prev.inv.IssueDate.Date) {
+			issues = append(issues, &Issue{
+				Type:    IssueDateOrder,
+				Key:     key,
+				Code:    code,
+				Message: fmt.Sprintf("issued on %s before '%s' on %s", cur.inv.IssueDate, prev.inv.Code, prev.inv.IssueDate),
+			})
+		}
+	}
+	return issues
+}
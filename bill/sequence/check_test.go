@@ -0,0 +1,61 @@
+package sequence_test
+
+import (
+	"testing"
+
+	"github.com/invopop/gobl/bill"
+	"github.com/invopop/gobl/bill/sequence"
+	"github.com/invopop/gobl/cal"
+	"github.com/invopop/gobl/cbc"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSequencerCheck(t *testing.T) {
+	f, err := sequence.ParseFormat("{year}-{number:3}")
+	require.NoError(t, err)
+	s := sequence.New(sequence.NewMemoryStore(), sequence.WithFormat(f))
+
+	t.Run("valid", func(t *testing.T) {
+		err := s.Check(
+			testInvoice("F", "2024-002", cal.MakeDate(2024, 1, 11)),
+			testInvoice("F", "2024-001", cal.MakeDate(2024, 1, 10)),
+			testInvoice("R", "2024-001", cal.MakeDate(2024, 1, 9)),
+			testInvoice("F", "2025-001", cal.MakeDate(2025, 1, 1)),
+		)
+		assert.NoError(t, err)
+	})
+	t.Run("issues", func(t *testing.T) {
+		err := s.Check(
+			testInvoice("F", "2024-001", cal.MakeDate(2024, 1, 10)),
+			testInvoice("F", "2024-002", cal.MakeDate(2024, 1, 11)),
+			testInvoice("F", "2024-002", cal.MakeDate(2024, 1, 11)),
+			testInvoice("F", "2024-005", cal.MakeDate(2024, 1, 12)),
+			testInvoice("F", "2024-006", cal.MakeDate(2024, 1, 5)),
+			testInvoice("F", "A-1", cal.MakeDate(2024, 1, 5)),
+		)
+		require.Error(t, err)
+		issues, ok := err.(sequence.Issues)
+		require.True(t, ok)
+		types := make([]cbc.Key, len(issues))
+		for i, is := range issues {
+			types[i] = is.Type
+		}
+		assert.Equal(t, []cbc.Key{
+			sequence.IssueFormat,
+			sequence.IssueDuplicate,
+			sequence.IssueGap,
+			sequence.IssueDateOrder,
+		}, types)
+		assert.Equal(t, cbc.Code("2024-003"), issues[2].Code)
+		assert.Equal(t, "ESB98602642:F:2024: 2024-003: 2 missing before '2024-005'", issues[2].Error())
+		assert.Contains(t, err.Error(), "2024-006: issued on 2024-01-05 before '2024-005' on 2024-01-12")
+		assert.Contains(t, err.Error(), "A-1: does not match format '{year}-{number:3}'")
+	})
+	t.Run("missing supplier", func(t *testing.T) {
+		inv := testInvoice("F", "2024-001", cal.MakeDate(2024, 1, 10))
+		inv.Supplier = nil
+		err := s.Check([]*bill.Invoice{inv}...)
+		assert.ErrorContains(t, err, "supplier tax ID required")
+	})
+}
@@ -0,0 +1,106 @@
+package sequence
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/invopop/gobl/cbc"
+)
+
+// Format describes how codes are built from the sequence key and number
+// using the following placeholders:
+//
+//   - `{number}` or `{number:N}`: sequence number, padded with zeros to N
+//     digits.
+//   - `{series}`: series code.
+//   - `{year}`: four digit year.
+//   - `{yy}`: last two digits of the year.
+//
+// For example, "{series}-{year}-{number:5}" results in codes like
+// "F-2024-00001".
+type Format struct {
+	pattern string
+}
+
+var formatPlaceholder = regexp.MustCompile(`\{(number|series|year|yy)(?::(\d+))?\}`)
+
+// ParseFormat checks the pattern and prepares a new format.
+func ParseFormat(pattern string) (*Format, error) {
+	count := 0
+	for _, m := range formatPlaceholder.FindAllStringSubmatch(pattern, -1) {
+		if m[1] == "number" {
+			count++
+		} else if m[2] != "" {
+			return nil, fmt.Errorf("format '%s': padding only supported for number", pattern)
+		}
+	}
+	if count != 1 {
+		return nil, fmt.Errorf("format '%s': must contain exactly one number placeholder", pattern)
+	}
+	return &Format{pattern: pattern}, nil
+}
+
+// String provides the format's pattern.
+func (f *Format) String() string {
+	return f.pattern
+}
+
+// Code builds the code for the number in the sequence.
+func (f *Format) Code(key Key, n int) cbc.Code {
+	out := formatPlaceholder.ReplaceAllStringFunc(f.pattern, func(ph string) string {
+		m := formatPlaceholder.FindStringSubmatch(ph)
+		switch m[1] {
+		case "number":
+			if m[2] != "" {
+				return fmt.Sprintf("%0"+m[2]+"d", n)
+			}
+			return strconv.Itoa(n)
+		default:
+			return f.value(key, m[1])
+		}
+	})
+	return cbc.Code(out)
+}
+
+// Number extracts the sequence number from a code, returning false if the
+// code does not match the format for the key.
+func (f *Format) Number(key Key, code cbc.Code) (int, bool) {
+	var expr strings.Builder
+	expr.WriteString("^")
+	last := 0
+	for _, loc := range formatPlaceholder.FindAllStringSubmatchIndex(f.pattern, -1) {
+		expr.WriteString(regexp.QuoteMeta(f.pattern[last:loc[0]]))
+		name := f.pattern[loc[2]:loc[3]]
+		if name == "number" {
+			expr.WriteString(`(\d+)`)
+		} else {
+			expr.WriteString(regexp.QuoteMeta(f.value(key, name)))
+		}
+		last = loc[1]
+	}
+	expr.WriteString(regexp.QuoteMeta(f.pattern[last:]))
+	expr.WriteString("$")
+	m := regexp.MustCompile(expr.String()).FindStringSubmatch(code.String())
+	if m == nil {
+		return 0, false
+	}
+	n, err := strconv.Atoi(m[1])
+	if err != nil {
+		return 0, false
+	}
+	return n, true
+}
+
+func (f *Format) value(key Key, name string) string {
+	switch name {
+	case "series":
+		return key.Series.String()
+	case "year":
+		return fmt.Sprintf("%04d", key.Year)
+	case "yy":
+		return fmt.Sprintf("%02d", key.Year%100)
+	}
+	return ""
+}
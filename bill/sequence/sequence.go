@@ -0,0 +1,109 @@
+// Package sequence allocates strictly sequential codes to invoices per
+// supplier, series, and year, and checks batches of invoices for gaps,
+// duplicates, and numbers issued out of date order.
+package sequence
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/invopop/gobl/bill"
+	"github.com/invopop/gobl/cbc"
+)
+
+// DefaultFormat is used when no other format is provided.
+const DefaultFormat = "{number}"
+
+// Key identifies an individual sequence.
+type Key struct {
+	// Supplier's tax ID including the country prefix.
+	Supplier string
+	// Series the codes belong to, may be empty.
+	Series cbc.Code
+	// Year of the issue date.
+	Year int
+}
+
+// String provides the key as a string, useful for storage.
+func (k Key) String() string {
+	return fmt.Sprintf("%s:%s:%d", k.Supplier, k.Series, k.Year)
+}
+
+// Store is implemented by anything able to persist the last number
+// allocated to each sequence.
+type Store interface {
+	// Next reserves and provides the next number of the sequence, starting
+	// from 1. Implementations must ensure that the same number is never
+	// provided twice.
+	Next(ctx context.Context, key Key) (int, error)
+}
+
+// Sequencer assigns codes to invoices using numbers allocated by a store.
+type Sequencer struct {
+	store  Store
+	format *Format
+}
+
+// Option is used to configure the sequencer.
+type Option func(*Sequencer)
+
+// WithFormat sets the format used to build codes, see ParseFormat for
+// details.
+func WithFormat(f *Format) Option {
+	return func(s *Sequencer) {
+		s.format = f
+	}
+}
+
+// New instantiates a new sequencer using the store.
+func New(store Store, opts ...Option) *Sequencer {
+	s := &Sequencer{
+		store: store,
+	}
+	for _, opt := range opts {
+		opt(s)
+	}
+	if s.format == nil {
+		s.format, _ = ParseFormat(DefaultFormat)
+	}
+	return s
+}
+
+// KeyFor determines the sequence key of the invoice.
+func KeyFor(inv *bill.Invoice) (Key, error) {
+	if inv.Supplier == nil || inv.Supplier.TaxID == nil || inv.Supplier.TaxID.Code == "" {
+		return Key{}, errors.New("supplier tax ID required")
+	}
+	if inv.IssueDate.IsZero() {
+		return Key{}, errors.New("issue date required")
+	}
+	return Key{
+		Supplier: inv.Supplier.TaxID.String(),
+		Series:   inv.Series,
+		Year:     inv.IssueDate.Year,
+	}, nil
+}
+
+// Assign allocates the next code of the invoice's sequence. Invoices that
+// already have a code are not modified. The invoice should be calculated
+// first to ensure the issue date is set.
+func (s *Sequencer) Assign(ctx context.Context, inv *bill.Invoice) error {
+	if inv.Code != cbc.CodeEmpty {
+		return nil
+	}
+	key, err := KeyFor(inv)
+	if err != nil {
+		return err
+	}
+	n, err := s.store.Next(ctx, key)
+	if err != nil {
+		return fmt.Errorf("sequence %s: %w", key, err)
+	}
+	code := s.format.Code(key, n)
+	if err := code.Validate(); err != nil {
+		return fmt.Errorf("sequence %s: invalid code '%s': %w", key, code, err)
+	}
+	inv.Code = code
+	return nil
+}
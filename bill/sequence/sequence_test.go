@@ -0,0 +1,105 @@
+package sequence_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/invopop/gobl/bill"
+	"github.com/invopop/gobl/bill/sequence"
+	"github.com/invopop/gobl/cal"
+	"github.com/invopop/gobl/cbc"
+	"github.com/invopop/gobl/org"
+	"github.com/invopop/gobl/tax"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func testInvoice(series cbc.Code, code cbc.Code, date cal.Date) *bill.Invoice {
+	return &bill.Invoice{
+		Series:    series,
+		Code:      code,
+		IssueDate: date,
+		Supplier: &org.Party{
+			Name:  "Provide One S.L.",
+			TaxID: &tax.Identity{Country: "ES", Code: "B98602642"},
+		},
+	}
+}
+
+type failingStore struct{}
+
+func (failingStore) Next(_ context.Context, _ sequence.Key) (int, error) {
+	return 0, errors.New("unavailable")
+}
+
+func TestSequencerAssign(t *testing.T) {
+	ctx := context.Background()
+	t.Run("default format", func(t *testing.T) {
+		s := sequence.New(sequence.NewMemoryStore())
+		inv := testInvoice("", "", cal.MakeDate(2024, 1, 10))
+		require.NoError(t, s.Assign(ctx, inv))
+		assert.Equal(t, cbc.Code("1"), inv.Code)
+		inv = testInvoice("", "", cal.MakeDate(2024, 1, 11))
+		require.NoError(t, s.Assign(ctx, inv))
+		assert.Equal(t, cbc.Code("2"), inv.Code)
+	})
+	t.Run("per series and year", func(t *testing.T) {
+		f, err := sequence.ParseFormat("{series}-{year}-{number:5}")
+		require.NoError(t, err)
+		s := sequence.New(sequence.NewMemoryStore(), sequence.WithFormat(f))
+
+		invs := []*bill.Invoice{
+			testInvoice("F", "", cal.MakeDate(2024, 12, 30)),
+			testInvoice("F", "", cal.MakeDate(2024, 12, 31)),
+			testInvoice("R", "", cal.MakeDate(2024, 12, 31)),
+			testInvoice("F", "", cal.MakeDate(2025, 1, 1)),
+		}
+		for _, inv := range invs {
+			require.NoError(t, s.Assign(ctx, inv))
+		}
+		assert.Equal(t, cbc.Code("F-2024-00001"), invs[0].Code)
+		assert.Equal(t, cbc.Code("F-2024-00002"), invs[1].Code)
+		assert.Equal(t, cbc.Code("R-2024-00001"), invs[2].Code)
+		assert.Equal(t, cbc.Code("F-2025-00001"), invs[3].Code)
+	})
+	t.Run("existing code", func(t *testing.T) {
+		s := sequence.New(sequence.NewMemoryStore())
+		inv := testInvoice("", "ABC", cal.MakeDate(2024, 1, 10))
+		require.NoError(t, s.Assign(ctx, inv))
+		assert.Equal(t, cbc.Code("ABC"), inv.Code)
+	})
+	t.Run("missing data", func(t *testing.T) {
+		s := sequence.New(sequence.NewMemoryStore())
+		inv := testInvoice("", "", cal.MakeDate(2024, 1, 10))
+		inv.Supplier.TaxID = nil
+		assert.ErrorContains(t, s.Assign(ctx, inv), "supplier tax ID required")
+		inv = testInvoice("", "", cal.Date{})
+		assert.ErrorContains(t, s.Assign(ctx, inv), "issue date required")
+	})
+	t.Run("store error", func(t *testing.T) {
+		s := sequence.New(failingStore{})
+		inv := testInvoice("F", "", cal.MakeDate(2024, 1, 10))
+		assert.ErrorContains(t, s.Assign(ctx, inv), "sequence ESB98602642:F:2024: unavailable")
+		assert.Empty(t, inv.Code)
+	})
+}
+
+func TestParseFormat(t *testing.T) {
+	f, err := sequence.ParseFormat("{yy}/{number:4}")
+	require.NoError(t, err)
+	key := sequence.Key{Supplier: "ESB98602642", Year: 2024}
+	assert.Equal(t, cbc.Code("24/0012"), f.Code(key, 12))
+	n, ok := f.Number(key, "24/0012")
+	assert.True(t, ok)
+	assert.Equal(t, 12, n)
+	_, ok = f.Number(key, "23/0012")
+	assert.False(t, ok)
+
+	_, err = sequence.ParseFormat("{series}-{year}")
+	assert.ErrorContains(t, err, "must contain exactly one number placeholder")
+	_, err = sequence.ParseFormat("{number}-{number}")
+	assert.ErrorContains(t, err, "must contain exactly one number placeholder")
+	_, err = sequence.ParseFormat("{year:2}{number}")
+	assert.ErrorContains(t, err, "padding only supported for number")
+}
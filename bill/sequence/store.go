@@ -0,0 +1,110 @@
+package sequence
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sync"
+)
+
+// MemoryStore keeps the sequences in memory, mainly useful for testing or
+// short lived processes.
+type MemoryStore struct {
+	mu   sync.Mutex
+	last map[string]int
+}
+
+// NewMemoryStore instantiates a new empty memory store.
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{
+		last: make(map[string]int),
+	}
+}
+
+// Next provides the next number of the sequence.
+func (ms *MemoryStore) Next(_ context.Context, key Key) (int, error) {
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+	ms.last[key.String()]++
+	return ms.last[key.String()], nil
+}
+
+// FileStore keeps the last number of each sequence in a JSON file, which is
+// re-written after each allocation. Access is synchronized inside a single
+// process only.
+type FileStore struct {
+	mu   sync.Mutex
+	path string
+}
+
+// NewFileStore instantiates a new store using the file in the path, which
+// will be created if it does not exist.
+func NewFileStore(path string) *FileStore {
+	return &FileStore{
+		path: path,
+	}
+}
+
+// Next provides the next number of the sequence, and persists it.
+func (st *FileStore) Next(_ context.Context, key Key) (int, error) {
+	st.mu.Lock()
+	defer st.mu.Unlock()
+	last, err := st.load()
+	if err != nil {
+		return 0, err
+	}
+	last[key.String()]++
+	if err := st.save(last); err != nil {
+		return 0, err
+	}
+	return last[key.String()], nil
+}
+
+func (st *FileStore) load() (map[string]int, error) {
+	last := make(map[string]int)
+	data, err := os.ReadFile(st.path)
+	if errors.Is(err, os.ErrNotExist) {
+		return last, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(data, &last); err != nil {
+		return nil, err
+	}
+	return last, nil
+}
+
+// save writes the data into a temporary file first so that the sequences
+// are never left half written.
+func (st *FileStore) save(last map[string]int) error {
+	data, err := json.MarshalIndent(last, "", "\t")
+	if err != nil {
+		return err
+	}
+	dir := filepath.Dir(st.path)
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(dir, filepath.Base(st.path)+".*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name()) // nolint:errcheck
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close() // nolint:errcheck
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	if err := os.Chmod(tmp.Name(), fileMode); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), st.path)
+}
+
+const fileMode fs.FileMode = 0o644
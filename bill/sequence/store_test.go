@@ -0,0 +1,56 @@
+package sequence_test
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/invopop/gobl/bill/sequence"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFileStore(t *testing.T) {
+	ctx := context.Background()
+	path := filepath.Join(t.TempDir(), "seq", "sequences.json")
+	key := sequence.Key{Supplier: "ESB98602642", Series: "F", Year: 2024}
+
+	st := sequence.NewFileStore(path)
+	n, err := st.Next(ctx, key)
+	require.NoError(t, err)
+	assert.Equal(t, 1, n)
+	n, err = st.Next(ctx, key)
+	require.NoError(t, err)
+	assert.Equal(t, 2, n)
+
+	// new instances continue from the saved state
+	st = sequence.NewFileStore(path)
+	n, err = st.Next(ctx, key)
+	require.NoError(t, err)
+	assert.Equal(t, 3, n)
+	n, err = st.Next(ctx, sequence.Key{Supplier: "ESB98602642", Series: "F", Year: 2025})
+	require.NoError(t, err)
+	assert.Equal(t, 1, n)
+
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"ESB98602642:F:2024":3,"ESB98602642:F:2025":1}`, string(data))
+
+	require.NoError(t, os.WriteFile(path, []byte("bad"), 0o644))
+	_, err = st.Next(ctx, key)
+	assert.Error(t, err)
+}
+
+func TestMemoryStore(t *testing.T) {
+	ctx := context.Background()
+	st := sequence.NewMemoryStore()
+	k1 := sequence.Key{Supplier: "ESB98602642", Year: 2024}
+	k2 := sequence.Key{Supplier: "ESB98602642", Series: "R", Year: 2024}
+	n, _ := st.Next(ctx, k1)
+	assert.Equal(t, 1, n)
+	n, _ = st.Next(ctx, k2)
+	assert.Equal(t, 1, n)
+	n, _ = st.Next(ctx, k1)
+	assert.Equal(t, 2, n)
+}
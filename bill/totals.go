@@ -3,6 +3,7 @@ package bill
 import (
 	"context"
 
+	"github.com/invopop/gobl/currency"
 	"github.com/invopop/gobl/num"
 	"github.com/invopop/gobl/tax"
 	"github.com/invopop/validation"
@@ -37,6 +38,31 @@ type Totals struct {
 	Advances *num.Amount `json:"advance,omitempty" jsonschema:"title=Advance"`
 	// How much actually needs to be paid now.
 	Due *num.Amount `json:"due,omitempty" jsonschema:"title=Due"`
+	// Totals converted into the regime's currency, calculated when the document
+	// uses a different currency and a matching exchange rate is available.
+	Local *LocalTotals `json:"local,omitempty" jsonschema:"title=Local Totals"`
+
+	// cashRounding is true when the rounding was calculated automatically
+	// for a cash payment, and so should be recalculated.
+	cashRounding bool
+}
+
+// LocalTotals contains the main totals and taxes of a document converted into
+// the tax regime's currency using the matching exchange rate, as typically
+// required when declaring taxes for documents issued in foreign currencies.
+type LocalTotals struct {
+	// Currency the amounts have been converted into.
+	Currency currency.Code `json:"currency" jsonschema:"title=Currency"`
+	// Sum of all line item sums
+	Sum num.Amount `json:"sum" jsonschema:"title=Sum"`
+	// Sum of all line sums minus the discounts, plus the charges, without tax.
+	Total num.Amount `json:"total" jsonschema:"title=Total"`
+	// Summary of all the taxes with converted bases and amounts.
+	Taxes *tax.Total `json:"taxes,omitempty" jsonschema:"title=Tax Totals"`
+	// Total amount of tax.
+	Tax num.Amount `json:"tax" jsonschema:"title=Tax"`
+	// Grand total after all taxes have been applied.
+	TotalWithTax num.Amount `json:"total_with_tax" jsonschema:"title=Total with Tax"`
 }
 
 // ValidateWithContext checks the totals calculated for the invoice.
@@ -55,6 +81,7 @@ func (t *Totals) ValidateWithContext(ctx context.Context) error {
 		validation.Field(&t.Payable),
 		validation.Field(&t.Advances),
 		validation.Field(&t.Due),
+		validation.Field(&t.Local),
 	)
 }
 
@@ -69,11 +96,15 @@ func (t *Totals) reset(zero num.Amount) {
 	t.Taxes = nil
 	t.Tax = zero
 	t.TotalWithTax = zero
-	// t.Rounding = nil // may have been provided externally
+	if t.cashRounding {
+		t.Rounding = nil // otherwise may have been provided externally
+		t.cashRounding = false
+	}
 	t.Outlays = nil
 	t.Payable = zero
 	t.Advances = nil
 	t.Due = nil
+	t.Local = nil
 }
 
 // Paid is a convenience method to quickly determine if the invoice has been
@@ -110,3 +141,22 @@ func (t *Totals) round(zero num.Amount) {
 		*t.Due = t.Due.Rescale(e)
 	}
 }
+
+// calculateLocal converts the main totals using the exchange rate so that
+// the tax amounts are available in the regime's currency. The tax and
+// total with tax are determined from the converted amounts so that they
+// always add up.
+func (t *Totals) calculateLocal(ex *currency.ExchangeRate) {
+	lt := &LocalTotals{
+		Currency: ex.To,
+		Sum:      ex.Convert(t.Sum),
+		Total:    ex.Convert(t.Total),
+		Taxes:    t.Taxes.Convert(ex),
+		Tax:      ex.To.Def().Zero(),
+	}
+	if lt.Taxes != nil {
+		lt.Tax = lt.Taxes.Sum
+	}
+	lt.TotalWithTax = lt.Total.Add(lt.Tax)
+	t.Local = lt
+}
@@ -0,0 +1,39 @@
+package bill
+
+import (
+	"github.com/invopop/gobl/cbc"
+	"github.com/invopop/gobl/org"
+	"github.com/invopop/gobl/tax"
+	"github.com/invopop/validation"
+)
+
+// Tracking contains the details required to follow a shipment as it is
+// transported from the despatch location to the receiver.
+type Tracking struct {
+	// Code assigned by the courier or carrier to identify the shipment.
+	Code cbc.Code `json:"code,omitempty" jsonschema:"title=Code"`
+	// Additional identities that may be used to follow the shipment, like
+	// container or vehicle registration numbers.
+	Identities []*org.Identity `json:"identities,omitempty" jsonschema:"title=Identities"`
+	// Website where the shipment's status may be checked.
+	Website *org.Website `json:"website,omitempty" jsonschema:"title=Website"`
+}
+
+// Normalize attempts to clean and normalize the tracking data.
+func (t *Tracking) Normalize(normalizers tax.Normalizers) {
+	if t == nil {
+		return
+	}
+	t.Code = cbc.NormalizeCode(t.Code)
+	normalizers.Each(t)
+	tax.Normalize(normalizers, t.Identities)
+}
+
+// Validate ensures the tracking details look correct.
+func (t *Tracking) Validate() error {
+	return validation.ValidateStruct(t,
+		validation.Field(&t.Code),
+		validation.Field(&t.Identities),
+		validation.Field(&t.Website),
+	)
+}
@@ -4,10 +4,13 @@ import (
 	"io"
 	"os"
 
+	"github.com/invopop/gobl/bill/sequence"
 	"github.com/invopop/gobl/internal/cli"
 	"github.com/spf13/cobra"
 )
 
+const defaultSequencesFilename = "~/.gobl/sequences.json"
+
 type buildOpts struct {
 	*rootOpts
 	set        map[string]string
@@ -16,6 +19,10 @@ type buildOpts struct {
 	template   string
 	docType    string
 	envelop    bool
+	assignCode bool
+	sequences  string
+	codeFormat string
+	csv        string
 
 	// Command options
 	use   string
@@ -45,6 +52,10 @@ func (b *buildOpts) cmd() *cobra.Command {
 	f.StringVarP(&b.template, "template", "T", "", "template YAML/JSON file into which data is merged")
 	f.StringVarP(&b.docType, "type", "t", "", "specify the document type")
 	f.BoolVarP(&b.envelop, "envelop", "e", false, "insert document into an envelope")
+	f.BoolVar(&b.assignCode, "assign-code", false, "assign the next code of the supplier, series, and year sequence to invoices without one")
+	f.StringVar(&b.sequences, "sequences", defaultSequencesFilename, "file used to store the sequences when assigning codes")
+	f.StringVar(&b.codeFormat, "code-format", sequence.DefaultFormat, "format of assigned codes using the {number[:padding]}, {series}, {year}, and {yy} placeholders")
+	f.StringVar(&b.csv, "csv", "", "YAML/JSON column mapping file used to build a list of invoices from CSV input")
 
 	return cmd
 }
@@ -86,9 +97,43 @@ func (b *buildOpts) runE(cmd *cobra.Command, args []string) error {
 		},
 	}
 
+	if b.assignCode {
+		if buildOpts.Sequencer, err = b.sequencer(); err != nil {
+			return err
+		}
+	}
+
+	if b.csv != "" {
+		mapping, err := os.Open(b.csv)
+		if err != nil {
+			return err
+		}
+		defer mapping.Close() // nolint:errcheck
+		res, err := cli.BuildCSV(ctx, &cli.BuildCSVOptions{
+			BuildOptions: buildOpts,
+			Mapping:      mapping,
+		})
+		if err != nil {
+			return err
+		}
+		return encode(res, out, b.indent)
+	}
+
 	res, err := cli.Build(ctx, buildOpts)
 	if err != nil {
 		return err
 	}
 	return encode(res, out, b.indent)
 }
+
+func (b *buildOpts) sequencer() (*sequence.Sequencer, error) {
+	f, err := sequence.ParseFormat(b.codeFormat)
+	if err != nil {
+		return nil, err
+	}
+	path, err := expandHome(b.sequences)
+	if err != nil {
+		return nil, err
+	}
+	return sequence.New(sequence.NewFileStore(path), sequence.WithFormat(f)), nil
+}
@@ -64,6 +64,14 @@ func Test_build_args(t *testing.T) {
 			name: "type",
 			args: []string{"--type", "bill.Invoice"},
 		},
+		{
+			name: "assign code",
+			args: []string{"--assign-code", "--sequences", "seq.json", "--code-format", "{series}-{number:4}"},
+		},
+		{
+			name: "csv",
+			args: []string{"--csv", "mapping.yaml"},
+		},
 	}
 
 	for _, tt := range tests {
@@ -285,6 +293,37 @@ func Test_build(t *testing.T) {
 			},
 			err: "open missing.yaml: no such file or directory",
 		},
+		{
+			name: "csv",
+			args: []string{"testdata/lines.csv"},
+			opts: &buildOpts{
+				template: "testdata/lines.template.yaml",
+				csv:      "testdata/lines.mapping.yaml",
+			},
+			replace: []testy.Replacement{
+				{
+					Regexp:      regexp.MustCompile(`"uuid":.?".*"`),
+					Replacement: `"uuid": "00000000-0000-0000-0000-000000000000"`,
+				},
+			},
+		},
+		{
+			name: "csv mapping missing",
+			args: []string{"testdata/lines.csv"},
+			opts: &buildOpts{
+				csv: "missing.yaml",
+			},
+			err: "open missing.yaml: no such file or directory",
+		},
+		{
+			name: "invalid code format",
+			opts: &buildOpts{
+				assignCode: true,
+				codeFormat: "{series}",
+			},
+			args: []string{"testdata/success.json"},
+			err:  "format '{series}': must contain exactly one number placeholder",
+		},
 	}
 
 	for _, tt := range tests {
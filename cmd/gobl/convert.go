@@ -0,0 +1,61 @@
+package main
+
+import (
+	"github.com/invopop/gobl/internal/cli"
+	"github.com/spf13/cobra"
+)
+
+type convertOpts struct {
+	*rootOpts
+	format string
+}
+
+func convert(root *rootOpts) *convertOpts {
+	return &convertOpts{
+		rootOpts: root,
+	}
+}
+
+func (o *convertOpts) cmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Args:  cobra.MaximumNArgs(2),
+		RunE:  o.runE,
+		Use:   "convert [infile] [outfile]",
+		Short: "Convert a GOBL invoice into another format, or a foreign document into GOBL",
+	}
+
+	f := cmd.Flags()
+	f.StringVar(&o.format, "format", cli.ConvertFormatUBL, "format of the foreign document, either \"ubl\", \"cii\", or \"edifact\"")
+
+	return cmd
+}
+
+func (o *convertOpts) runE(cmd *cobra.Command, args []string) error {
+	ctx := commandContext(cmd)
+
+	input, err := openInput(cmd, args)
+	if err != nil {
+		return err
+	}
+	defer input.Close() // nolint:errcheck
+
+	out, err := o.openOutput(cmd, args)
+	if err != nil {
+		return err
+	}
+	defer out.Close() // nolint:errcheck
+
+	res, err := cli.Convert(ctx, &cli.ConvertOptions{
+		Format: o.format,
+		Input:  input,
+	})
+	if err != nil {
+		return err
+	}
+
+	if data, ok := res.([]byte); ok {
+		_, err = out.Write(data)
+		return err
+	}
+	return encode(res, out, o.indent)
+}
@@ -0,0 +1,126 @@
+package main
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/spf13/cobra"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_convert(t *testing.T) {
+	tests := []struct {
+		name   string
+		format string
+		args   []string
+		want   []string
+		err    string
+	}{
+		{
+			name:   "from ubl",
+			format: "ubl",
+			args:   []string{"testdata/invoice.ubl.xml"},
+			want: []string{
+				`"$schema":"https://gobl.org/draft-0/envelope"`,
+				`"code":"SAMPLE-001"`,
+			},
+		},
+		{
+			name:   "from cii",
+			format: "cii",
+			args:   []string{"testdata/invoice.cii.xml"},
+			want: []string{
+				`"$schema":"https://gobl.org/draft-0/envelope"`,
+				`"code":"SAMPLE-001"`,
+			},
+		},
+		{
+			name:   "from edifact",
+			format: "edifact",
+			args:   []string{"testdata/invoice.edi"},
+			want: []string{
+				`"$schema":"https://gobl.org/draft-0/envelope"`,
+				`"code":"SAMPLE-001"`,
+			},
+		},
+		{
+			name:   "unsupported invoice",
+			format: "ubl",
+			args:   []string{"testdata/success.json"},
+			err:    "code=422, message=unsupported: retained tax 'IRPF'",
+		},
+		{
+			name:   "unknown format",
+			format: "foo",
+			args:   []string{"testdata/invoice.ubl.xml"},
+			err:    "code=422, message=unsupported format 'foo'",
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			c := &cobra.Command{}
+			buf := &bytes.Buffer{}
+			c.SetOut(buf)
+			opts := &convertOpts{rootOpts: &rootOpts{}, format: tt.format}
+			err := opts.runE(c, tt.args)
+			if tt.err != "" {
+				assert.EqualError(t, err, tt.err)
+				return
+			}
+			require.NoError(t, err)
+			for _, w := range tt.want {
+				assert.Contains(t, buf.String(), w)
+			}
+		})
+	}
+
+	t.Run("to ubl", func(t *testing.T) {
+		c := &cobra.Command{}
+		in := &bytes.Buffer{}
+		c.SetOut(in)
+		opts := &convertOpts{rootOpts: &rootOpts{}, format: "ubl"}
+		require.NoError(t, opts.runE(c, []string{"testdata/invoice.ubl.xml"}))
+
+		c = &cobra.Command{}
+		c.SetIn(in)
+		buf := &bytes.Buffer{}
+		c.SetOut(buf)
+		require.NoError(t, opts.runE(c, nil))
+		assert.Contains(t, buf.String(), "<Invoice xmlns=")
+		assert.Contains(t, buf.String(), "<cbc:ID>SAMPLE-001</cbc:ID>")
+	})
+	t.Run("to cii", func(t *testing.T) {
+		c := &cobra.Command{}
+		in := &bytes.Buffer{}
+		c.SetOut(in)
+		opts := &convertOpts{rootOpts: &rootOpts{}, format: "cii"}
+		require.NoError(t, opts.runE(c, []string{"testdata/invoice.cii.xml"}))
+
+		c = &cobra.Command{}
+		c.SetIn(in)
+		buf := &bytes.Buffer{}
+		c.SetOut(buf)
+		require.NoError(t, opts.runE(c, nil))
+		assert.Contains(t, buf.String(), "<rsm:CrossIndustryInvoice")
+		assert.Contains(t, buf.String(), "<ram:ID>SAMPLE-001</ram:ID>")
+	})
+	t.Run("to edifact", func(t *testing.T) {
+		c := &cobra.Command{}
+		in := &bytes.Buffer{}
+		c.SetOut(in)
+		opts := &convertOpts{rootOpts: &rootOpts{}, format: "edifact"}
+		require.NoError(t, opts.runE(c, []string{"testdata/invoice.edi"}))
+
+		c = &cobra.Command{}
+		c.SetIn(in)
+		buf := &bytes.Buffer{}
+		c.SetOut(buf)
+		require.NoError(t, opts.runE(c, nil))
+		assert.Contains(t, buf.String(), "UNH+1+INVOIC:D:96A:UN'")
+		assert.Contains(t, buf.String(), "BGM+380+SAMPLE-001+9'")
+	})
+}
@@ -0,0 +1,139 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+
+	"cloud.google.com/go/civil"
+	"github.com/invopop/gobl/cal"
+	"github.com/invopop/gobl/internal/cli"
+	"github.com/invopop/gobl/num"
+	"github.com/invopop/gobl/tax/intra"
+	"github.com/spf13/cobra"
+)
+
+type intraOpts struct {
+	*rootOpts
+	taxID             string
+	start             string
+	end               string
+	arrivalThreshold  string
+	dispatchThreshold string
+	priorArrivals     string
+	priorDispatches   string
+	envelop           bool
+}
+
+func intraCmd(root *rootOpts) *intraOpts {
+	return &intraOpts{
+		rootOpts: root,
+	}
+}
+
+func (o *intraOpts) cmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Args:  cobra.MinimumNArgs(2),
+		RunE:  o.runE,
+		Use:   "intra sales-list|intrastat infile [infile...]",
+		Short: "Prepare the EC Sales List or Intrastat summary from a set of invoices",
+	}
+
+	f := cmd.Flags()
+	f.StringVarP(&o.taxID, "tax-id", "t", "", "tax ID of the party, including the country prefix")
+	f.StringVar(&o.start, "start", "", "first date of the period, as YYYY-MM-DD")
+	f.StringVar(&o.end, "end", "", "last date of the period, as YYYY-MM-DD")
+	f.StringVar(&o.arrivalThreshold, "arrival-threshold", "", "annual value of Intrastat arrivals above which they must be declared")
+	f.StringVar(&o.dispatchThreshold, "dispatch-threshold", "", "annual value of Intrastat dispatches above which they must be declared")
+	f.StringVar(&o.priorArrivals, "prior-arrivals", "", "value of Intrastat arrivals made during the year before the period")
+	f.StringVar(&o.priorDispatches, "prior-dispatches", "", "value of Intrastat dispatches made during the year before the period")
+	f.BoolVarP(&o.envelop, "envelop", "e", false, "insert the summary into an envelope")
+
+	return cmd
+}
+
+func (o *intraOpts) runE(cmd *cobra.Command, args []string) error {
+	ctx := commandContext(cmd)
+
+	period, err := parsePeriod(o.start, o.end)
+	if err != nil {
+		return err
+	}
+	iOpts := &cli.IntraOptions{
+		Type:    args[0],
+		TaxID:   o.taxID,
+		Period:  period,
+		Inputs:  make([]io.Reader, len(args)-1),
+		Envelop: o.envelop,
+	}
+	if iOpts.Intrastat, err = o.intrastatOptions(); err != nil {
+		return err
+	}
+	for i, name := range args[1:] {
+		var input io.ReadCloser = io.NopCloser(cmd.InOrStdin())
+		if name != "-" {
+			f, err := os.Open(name)
+			if err != nil {
+				return err
+			}
+			input = f
+		}
+		defer input.Close() // nolint:errcheck
+		iOpts.Inputs[i] = input
+	}
+
+	obj, err := cli.Intra(ctx, iOpts)
+	if err != nil {
+		return err
+	}
+
+	enc := json.NewEncoder(cmd.OutOrStdout())
+	if o.indent {
+		enc.SetIndent("", "\t")
+	}
+
+	return enc.Encode(obj)
+}
+
+func (o *intraOpts) intrastatOptions() (*intra.IntrastatOptions, error) {
+	opts := new(intra.IntrastatOptions)
+	flags := []struct {
+		name  string
+		value string
+		dest  *num.Amount
+	}{
+		{"arrival-threshold", o.arrivalThreshold, &opts.ArrivalThreshold},
+		{"dispatch-threshold", o.dispatchThreshold, &opts.DispatchThreshold},
+		{"prior-arrivals", o.priorArrivals, &opts.PriorArrivals},
+		{"prior-dispatches", o.priorDispatches, &opts.PriorDispatches},
+	}
+	for _, f := range flags {
+		if f.value == "" {
+			continue
+		}
+		a, err := num.AmountFromString(f.value)
+		if err != nil {
+			return nil, fmt.Errorf("invalid %s: %w", f.name, err)
+		}
+		*f.dest = a
+	}
+	return opts, nil
+}
+
+// parsePeriod builds a period from the start and end dates provided in
+// the YYYY-MM-DD format.
+func parsePeriod(start, end string) (cal.Period, error) {
+	var p cal.Period
+	s, err := civil.ParseDate(start)
+	if err != nil {
+		return p, fmt.Errorf("invalid start date %q: %w", start, err)
+	}
+	e, err := civil.ParseDate(end)
+	if err != nil {
+		return p, fmt.Errorf("invalid end date %q: %w", end, err)
+	}
+	p.Start = cal.Date{Date: s}
+	p.End = cal.Date{Date: e}
+	return p, nil
+}
@@ -0,0 +1,36 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_parsePeriod(t *testing.T) {
+	p, err := parsePeriod("2024-01-01", "2024-03-31")
+	require.NoError(t, err)
+	assert.Equal(t, "2024-01-01", p.Start.String())
+	assert.Equal(t, "2024-03-31", p.End.String())
+
+	_, err = parsePeriod("", "2024-03-31")
+	assert.ErrorContains(t, err, `invalid start date ""`)
+	_, err = parsePeriod("2024-01-01", "2024-13-01")
+	assert.ErrorContains(t, err, `invalid end date "2024-13-01"`)
+}
+
+func Test_intraOpts_intrastatOptions(t *testing.T) {
+	o := &intraOpts{
+		dispatchThreshold: "400000",
+		priorDispatches:   "120000.50",
+	}
+	opts, err := o.intrastatOptions()
+	require.NoError(t, err)
+	assert.Equal(t, "400000", opts.DispatchThreshold.String())
+	assert.Equal(t, "120000.50", opts.PriorDispatches.String())
+	assert.True(t, opts.ArrivalThreshold.IsZero())
+
+	o.arrivalThreshold = "x"
+	_, err = o.intrastatOptions()
+	assert.ErrorContains(t, err, "invalid arrival-threshold")
+}
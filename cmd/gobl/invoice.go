@@ -0,0 +1,111 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/invopop/gobl/bill"
+	"github.com/invopop/gobl/internal/cli"
+	"github.com/invopop/gobl/num"
+	"github.com/spf13/cobra"
+)
+
+type invoiceOpts struct {
+	*rootOpts
+	lines   []string // line selections in the form "source:line[=quantity]"
+	envelop bool
+}
+
+func invoice(root *rootOpts) *invoiceOpts {
+	return &invoiceOpts{
+		rootOpts: root,
+	}
+}
+
+func (o *invoiceOpts) cmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Args:  cobra.MinimumNArgs(1),
+		RunE:  o.runE,
+		Use:   "invoice infile [infile...]",
+		Short: "Build a draft invoice from one or more order or delivery documents",
+	}
+
+	f := cmd.Flags()
+	f.StringArrayVarP(&o.lines, "line", "l", nil, "Select a line to invoice as \"source:line[=quantity]\", starting from 1. All lines are used if none are selected for a source.")
+	f.BoolVarP(&o.envelop, "envelop", "e", false, "insert the invoice into an envelope")
+
+	return cmd
+}
+
+func (o *invoiceOpts) runE(cmd *cobra.Command, args []string) error {
+	ctx := commandContext(cmd)
+
+	iOpts := &cli.InvoiceOptions{
+		Sources: make([]*cli.InvoiceSource, len(args)),
+		Envelop: o.envelop,
+	}
+	for i, name := range args {
+		var input io.ReadCloser = io.NopCloser(cmd.InOrStdin())
+		if name != "-" {
+			f, err := os.Open(name)
+			if err != nil {
+				return err
+			}
+			input = f
+		}
+		defer input.Close() // nolint:errcheck
+		iOpts.Sources[i] = &cli.InvoiceSource{Input: input}
+	}
+	for _, l := range o.lines {
+		src, sel, err := parseLineSelection(l)
+		if err != nil {
+			return err
+		}
+		if src < 1 || src > len(iOpts.Sources) {
+			return fmt.Errorf("invalid line selection %q: source not found", l)
+		}
+		iOpts.Sources[src-1].Lines = append(iOpts.Sources[src-1].Lines, sel)
+	}
+
+	obj, err := cli.Invoice(ctx, iOpts)
+	if err != nil {
+		return err
+	}
+
+	enc := json.NewEncoder(cmd.OutOrStdout())
+	if o.indent {
+		enc.SetIndent("", "\t")
+	}
+
+	return enc.Encode(obj)
+}
+
+// parseLineSelection parses a line selection flag value in the form
+// "source:line[=quantity]".
+func parseLineSelection(in string) (int, *bill.LineSelection, error) {
+	srcTxt, lineTxt, ok := strings.Cut(in, ":")
+	if !ok {
+		return 0, nil, fmt.Errorf("invalid line selection %q: expected source:line[=quantity]", in)
+	}
+	src, err := strconv.Atoi(srcTxt)
+	if err != nil {
+		return 0, nil, fmt.Errorf("invalid line selection %q: %w", in, err)
+	}
+	lineTxt, qtyTxt, hasQty := strings.Cut(lineTxt, "=")
+	sel := new(bill.LineSelection)
+	if sel.Index, err = strconv.Atoi(lineTxt); err != nil {
+		return 0, nil, fmt.Errorf("invalid line selection %q: %w", in, err)
+	}
+	if hasQty {
+		qty, err := num.AmountFromString(qtyTxt)
+		if err != nil {
+			return 0, nil, fmt.Errorf("invalid line selection %q: %w", in, err)
+		}
+		sel.Quantity = &qty
+	}
+	return src, sel, nil
+}
@@ -0,0 +1,29 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_parseLineSelection(t *testing.T) {
+	src, sel, err := parseLineSelection("2:3")
+	require.NoError(t, err)
+	assert.Equal(t, 2, src)
+	assert.Equal(t, 3, sel.Index)
+	assert.Nil(t, sel.Quantity)
+
+	src, sel, err = parseLineSelection("1:4=2.5")
+	require.NoError(t, err)
+	assert.Equal(t, 1, src)
+	assert.Equal(t, 4, sel.Index)
+	assert.Equal(t, "2.5", sel.Quantity.String())
+
+	_, _, err = parseLineSelection("3")
+	assert.ErrorContains(t, err, `invalid line selection "3": expected source:line[=quantity]`)
+	_, _, err = parseLineSelection("a:1")
+	assert.ErrorContains(t, err, `invalid line selection "a:1"`)
+	_, _, err = parseLineSelection("1:1=x")
+	assert.ErrorContains(t, err, `invalid line selection "1:1=x"`)
+}
@@ -0,0 +1,71 @@
+package main
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/invopop/gobl/internal/cli"
+	"github.com/spf13/cobra"
+)
+
+type migrateOpts struct {
+	*rootOpts
+	from string
+}
+
+func migrate(root *rootOpts) *migrateOpts {
+	return &migrateOpts{
+		rootOpts: root,
+	}
+}
+
+func (o *migrateOpts) cmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Args:  cobra.MaximumNArgs(2),
+		RunE:  o.runE,
+		Use:   "migrate [infile] [outfile]",
+		Short: "Upgrade a stored document or envelope to the current version of GOBL",
+	}
+
+	f := cmd.Flags()
+	f.StringVar(&o.from, "from", "", "version of GOBL used to produce the input, so that only newer migrations are applied")
+
+	return cmd
+}
+
+func (o *migrateOpts) runE(cmd *cobra.Command, args []string) error {
+	ctx := commandContext(cmd)
+
+	input, err := openInput(cmd, args)
+	if err != nil {
+		return err
+	}
+	defer input.Close() // nolint:errcheck
+
+	res, err := cli.Migrate(ctx, &cli.MigrateOptions{
+		Input: input,
+		From:  o.from,
+	})
+	if err != nil {
+		return err
+	}
+
+	out, err := o.openOutput(cmd, args)
+	if err != nil {
+		return err
+	}
+	defer out.Close() // nolint:errcheck
+	if f, ok := out.(*os.File); ok {
+		// input has already been read, so files updated in place can be
+		// safely truncated in case the migrated output is shorter.
+		if err := f.Truncate(0); err != nil {
+			return err
+		}
+	}
+
+	for _, m := range res.Migrations {
+		fmt.Fprintf(cmd.ErrOrStderr(), "migrated: %s\n", m) // nolint:errcheck
+	}
+
+	return encode(res.Data, out, o.indent)
+}
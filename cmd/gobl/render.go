@@ -0,0 +1,60 @@
+package main
+
+import (
+	"github.com/invopop/gobl/internal/cli"
+	"github.com/spf13/cobra"
+)
+
+type renderOpts struct {
+	*rootOpts
+	lang string
+}
+
+func render(root *rootOpts) *renderOpts {
+	return &renderOpts{
+		rootOpts: root,
+	}
+}
+
+func (o *renderOpts) cmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Args:  cobra.MaximumNArgs(2),
+		RunE:  o.runE,
+		Use:   "render [infile] [outfile]",
+		Short: "Render an envelope or invoice as a self-contained HTML document",
+	}
+
+	f := cmd.Flags()
+	f.StringVarP(&o.lang, "lang", "l", "", "language of the names provided by the regime and addons")
+
+	return cmd
+}
+
+func (o *renderOpts) runE(cmd *cobra.Command, args []string) error {
+	ctx := commandContext(cmd)
+
+	input, err := openInput(cmd, args)
+	if err != nil {
+		return err
+	}
+	defer input.Close() // nolint:errcheck
+
+	out, err := o.openOutput(cmd, args)
+	if err != nil {
+		return err
+	}
+	defer out.Close() // nolint:errcheck
+
+	data, err := cli.Render(ctx, &cli.RenderOptions{
+		ParseOptions: &cli.ParseOptions{
+			Input: input,
+		},
+		Lang: o.lang,
+	})
+	if err != nil {
+		return err
+	}
+
+	_, err = out.Write(data)
+	return err
+}
@@ -0,0 +1,64 @@
+package main
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/spf13/cobra"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_render(t *testing.T) {
+	tests := []struct {
+		name string
+		lang string
+		args []string
+		want []string
+		err  string
+	}{
+		{
+			name: "envelope",
+			args: []string{"testdata/success.json"},
+			want: []string{
+				"<!DOCTYPE html>",
+				`<html lang="en">`,
+			},
+		},
+		{
+			name: "language",
+			lang: "es",
+			args: []string{"testdata/success.json"},
+			want: []string{
+				`<html lang="es">`,
+				"IVA",
+			},
+		},
+		{
+			name: "invalid language",
+			lang: "xx",
+			args: []string{"testdata/success.json"},
+			err:  "code=422, message=invalid language: must be a valid value",
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			c := &cobra.Command{}
+			buf := &bytes.Buffer{}
+			c.SetOut(buf)
+			opts := &renderOpts{rootOpts: &rootOpts{}, lang: tt.lang}
+			err := opts.runE(c, tt.args)
+			if tt.err != "" {
+				assert.EqualError(t, err, tt.err)
+				return
+			}
+			require.NoError(t, err)
+			for _, w := range tt.want {
+				assert.Contains(t, buf.String(), w)
+			}
+		})
+	}
+}
@@ -33,6 +33,11 @@ func (o *rootOpts) cmd() *cobra.Command {
 	cmd.AddCommand(sign(o).cmd())
 	cmd.AddCommand(correct(o).cmd())
 	cmd.AddCommand(replicate(o).cmd())
+	cmd.AddCommand(invoice(o).cmd())
+	cmd.AddCommand(migrate(o).cmd())
+	cmd.AddCommand(intraCmd(o).cmd())
+	cmd.AddCommand(convert(o).cmd())
+	cmd.AddCommand(render(o).cmd())
 	cmd.AddCommand(versionCmd())
 	cmd.AddCommand(serve().cmd())
 	cmd.AddCommand(keygen(o).cmd())
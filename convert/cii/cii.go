@@ -0,0 +1,434 @@
+// Package cii converts GOBL invoices to and from the UN/CEFACT Cross
+// Industry Invoice (CII) D16B XML syntax, using the EN 16931 semantic model
+// as followed by XRechnung and Factur-X/ZUGFeRD.
+package cii
+
+import (
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"io"
+
+	"github.com/invopop/gobl/convert/internal/semantic"
+)
+
+// Namespaces used by CII documents.
+const (
+	NamespaceRSM = "urn:un:unece:uncefact:data:standard:CrossIndustryInvoice:100"
+	NamespaceRAM = "urn:un:unece:uncefact:data:standard:ReusableAggregateBusinessInformationEntity:100"
+	NamespaceQDT = "urn:un:unece:uncefact:data:standard:QualifiedDataType:100"
+	NamespaceUDT = "urn:un:unece:uncefact:data:standard:UnqualifiedDataType:100"
+)
+
+// Specification identifiers used as the guideline of the document.
+const (
+	GuidelineEN16931   = semantic.GuidelineEN16931
+	GuidelineXRechnung = semantic.GuidelineXRechnung
+	GuidelinePeppol    = semantic.GuidelinePeppol
+)
+
+// BusinessProcessPeppol identifies documents exchanged through Peppol.
+const BusinessProcessPeppol = semantic.ProfilePeppol
+
+// Format of dates, which is the only one allowed by EN 16931.
+const dateFormat = "102" // YYYYMMDD
+
+// ErrUnsupported is returned when the invoice contains data that cannot be
+// represented using the EN 16931 model in CII.
+var ErrUnsupported = semantic.ErrUnsupported
+
+// Document is the root of a Cross Industry Invoice.
+type Document struct {
+	XMLName      xml.Name `xml:"rsm:CrossIndustryInvoice"`
+	RSMNamespace string   `xml:"xmlns:rsm,attr,omitempty"`
+	RAMNamespace string   `xml:"xmlns:ram,attr,omitempty"`
+	QDTNamespace string   `xml:"xmlns:qdt,attr,omitempty"`
+	UDTNamespace string   `xml:"xmlns:udt,attr,omitempty"`
+
+	Context     *Context     `xml:"rsm:ExchangedDocumentContext"`
+	Header      *Header      `xml:"rsm:ExchangedDocument"`
+	Transaction *Transaction `xml:"rsm:SupplyChainTradeTransaction"`
+}
+
+// Context identifies the business process and guideline followed.
+type Context struct {
+	BusinessProcess *IDParameter `xml:"ram:BusinessProcessSpecifiedDocumentContextParameter,omitempty"`
+	Guideline       *IDParameter `xml:"ram:GuidelineSpecifiedDocumentContextParameter"`
+}
+
+// IDParameter contains a single identifier.
+type IDParameter struct {
+	ID string `xml:"ram:ID"`
+}
+
+// Header contains the document's identification.
+type Header struct {
+	ID            string    `xml:"ram:ID"`
+	TypeCode      string    `xml:"ram:TypeCode"`
+	IssueDateTime *DateTime `xml:"ram:IssueDateTime"`
+	Notes         []*Note   `xml:"ram:IncludedNote,omitempty"`
+}
+
+// DateTime wraps a date in the format code provided.
+type DateTime struct {
+	DateString *DateString `xml:"udt:DateTimeString"`
+}
+
+// FormattedDateTime wraps a date using the qualified data type.
+type FormattedDateTime struct {
+	DateString *DateString `xml:"qdt:DateTimeString"`
+}
+
+// DateString is a date with its format code.
+type DateString struct {
+	Value  string `xml:",chardata"`
+	Format string `xml:"format,attr"`
+}
+
+// Note is a free text note.
+type Note struct {
+	Content     string `xml:"ram:Content"`
+	SubjectCode string `xml:"ram:SubjectCode,omitempty"`
+}
+
+// Transaction contains the lines, and the agreement, delivery, and
+// settlement details of the invoice.
+type Transaction struct {
+	Lines      []*Line     `xml:"ram:IncludedSupplyChainTradeLineItem"`
+	Agreement  *Agreement  `xml:"ram:ApplicableHeaderTradeAgreement"`
+	Delivery   *Delivery   `xml:"ram:ApplicableHeaderTradeDelivery"`
+	Settlement *Settlement `xml:"ram:ApplicableHeaderTradeSettlement"`
+}
+
+// Line is an invoice line.
+type Line struct {
+	LineDoc    *LineDoc        `xml:"ram:AssociatedDocumentLineDocument"`
+	Product    *Product        `xml:"ram:SpecifiedTradeProduct"`
+	Agreement  *LineAgreement  `xml:"ram:SpecifiedLineTradeAgreement"`
+	Delivery   *LineDelivery   `xml:"ram:SpecifiedLineTradeDelivery"`
+	Settlement *LineSettlement `xml:"ram:SpecifiedLineTradeSettlement"`
+}
+
+// LineDoc identifies the line.
+type LineDoc struct {
+	LineID string  `xml:"ram:LineID"`
+	Notes  []*Note `xml:"ram:IncludedNote,omitempty"`
+}
+
+// Product describes the item of the line.
+type Product struct {
+	GlobalID       *Identifier `xml:"ram:GlobalID,omitempty"`
+	SellerAssigned string      `xml:"ram:SellerAssignedID,omitempty"`
+	Name           string      `xml:"ram:Name"`
+	Description    string      `xml:"ram:Description,omitempty"`
+	Origin         *CountryID  `xml:"ram:OriginTradeCountry,omitempty"`
+}
+
+// CountryID contains an ISO 3166-1 alpha-2 code.
+type CountryID struct {
+	ID string `xml:"ram:ID"`
+}
+
+// LineAgreement contains the price of the item.
+type LineAgreement struct {
+	NetPrice *Price `xml:"ram:NetPriceProductTradePrice"`
+}
+
+// Price is the net price of an item.
+type Price struct {
+	ChargeAmount string `xml:"ram:ChargeAmount"`
+}
+
+// LineDelivery contains the quantity invoiced.
+type LineDelivery struct {
+	BilledQuantity *Quantity `xml:"ram:BilledQuantity"`
+}
+
+// Quantity is a numeric value with its UN/ECE unit code.
+type Quantity struct {
+	Value    string `xml:",chardata"`
+	UnitCode string `xml:"unitCode,attr"`
+}
+
+// LineSettlement contains the tax, allowances and charges, and total of
+// the line.
+type LineSettlement struct {
+	Tax              *TradeTax          `xml:"ram:ApplicableTradeTax"`
+	AllowanceCharges []*AllowanceCharge `xml:"ram:SpecifiedTradeAllowanceCharge,omitempty"`
+	Summation        *LineSummation     `xml:"ram:SpecifiedTradeSettlementLineMonetarySummation"`
+}
+
+// LineSummation contains the total of the line.
+type LineSummation struct {
+	LineTotalAmount string `xml:"ram:LineTotalAmount"`
+}
+
+// TradeTax describes a tax applied to the line or document.
+type TradeTax struct {
+	CalculatedAmount string `xml:"ram:CalculatedAmount,omitempty"`
+	TypeCode         string `xml:"ram:TypeCode"`
+	BasisAmount      string `xml:"ram:BasisAmount,omitempty"`
+	CategoryCode     string `xml:"ram:CategoryCode"`
+	Percent          string `xml:"ram:RateApplicablePercent,omitempty"`
+}
+
+// AllowanceCharge is a discount or charge at document or line level.
+type AllowanceCharge struct {
+	ChargeIndicator *Indicator `xml:"ram:ChargeIndicator"`
+	Percent         string     `xml:"ram:CalculationPercent,omitempty"`
+	BasisAmount     string     `xml:"ram:BasisAmount,omitempty"`
+	ActualAmount    string     `xml:"ram:ActualAmount"`
+	ReasonCode      string     `xml:"ram:ReasonCode,omitempty"`
+	Reason          string     `xml:"ram:Reason,omitempty"`
+	Tax             *TradeTax  `xml:"ram:CategoryTradeTax,omitempty"`
+}
+
+// Indicator wraps a boolean value.
+type Indicator struct {
+	Value bool `xml:"udt:Indicator"`
+}
+
+// Agreement contains the parties and references of the document.
+type Agreement struct {
+	BuyerReference string              `xml:"ram:BuyerReference,omitempty"`
+	Seller         *Party              `xml:"ram:SellerTradeParty"`
+	Buyer          *Party              `xml:"ram:BuyerTradeParty"`
+	SellerOrder    *ReferencedDocument `xml:"ram:SellerOrderReferencedDocument,omitempty"`
+	BuyerOrder     *ReferencedDocument `xml:"ram:BuyerOrderReferencedDocument,omitempty"`
+	Contract       *ReferencedDocument `xml:"ram:ContractReferencedDocument,omitempty"`
+	Project        *Project            `xml:"ram:SpecifiedProcuringProject,omitempty"`
+}
+
+// Project identifies the project the invoice refers to.
+type Project struct {
+	ID   string `xml:"ram:ID"`
+	Name string `xml:"ram:Name"`
+}
+
+// ReferencedDocument identifies another document.
+type ReferencedDocument struct {
+	IssuerAssignedID string             `xml:"ram:IssuerAssignedID"`
+	IssueDateTime    *FormattedDateTime `xml:"ram:FormattedIssueDateTime,omitempty"`
+}
+
+// Party describes a seller, buyer, payee, or delivery receiver.
+type Party struct {
+	IDs               []*Identifier      `xml:"ram:ID,omitempty"`
+	GlobalIDs         []*Identifier      `xml:"ram:GlobalID,omitempty"`
+	Name              string             `xml:"ram:Name"`
+	LegalOrganization *LegalOrganization `xml:"ram:SpecifiedLegalOrganization,omitempty"`
+	Contact           *Contact           `xml:"ram:DefinedTradeContact,omitempty"`
+	Address           *Address           `xml:"ram:PostalTradeAddress,omitempty"`
+	URI               *URI               `xml:"ram:URIUniversalCommunication,omitempty"`
+	TaxRegistrations  []*TaxRegistration `xml:"ram:SpecifiedTaxRegistration,omitempty"`
+}
+
+// Identifier is a code with the optional scheme it belongs to.
+type Identifier struct {
+	Value    string `xml:",chardata"`
+	SchemeID string `xml:"schemeID,attr,omitempty"`
+}
+
+// LegalOrganization contains the trading name of the party.
+type LegalOrganization struct {
+	TradingName string `xml:"ram:TradingBusinessName,omitempty"`
+}
+
+// Contact contains the party's contact details.
+type Contact struct {
+	PersonName string     `xml:"ram:PersonName,omitempty"`
+	Telephone  *Telephone `xml:"ram:TelephoneUniversalCommunication,omitempty"`
+	Email      *URI       `xml:"ram:EmailURIUniversalCommunication,omitempty"`
+}
+
+// Telephone contains a phone number.
+type Telephone struct {
+	CompleteNumber string `xml:"ram:CompleteNumber"`
+}
+
+// URI contains an electronic address.
+type URI struct {
+	ID *Identifier `xml:"ram:URIID"`
+}
+
+// Address is a postal address.
+type Address struct {
+	Postcode    string `xml:"ram:PostcodeCode,omitempty"`
+	LineOne     string `xml:"ram:LineOne,omitempty"`
+	LineTwo     string `xml:"ram:LineTwo,omitempty"`
+	City        string `xml:"ram:CityName,omitempty"`
+	CountryID   string `xml:"ram:CountryID"`
+	Subdivision string `xml:"ram:CountrySubDivisionName,omitempty"`
+}
+
+// TaxRegistration contains a tax identity of the party, with the "VA"
+// scheme for VAT numbers.
+type TaxRegistration struct {
+	ID *Identifier `xml:"ram:ID"`
+}
+
+// Delivery describes where and when goods were delivered.
+type Delivery struct {
+	ShipTo   *Party              `xml:"ram:ShipToTradeParty,omitempty"`
+	Event    *Event              `xml:"ram:ActualDeliverySupplyChainEvent,omitempty"`
+	Despatch *ReferencedDocument `xml:"ram:DespatchAdviceReferencedDocument,omitempty"`
+	Receipt  *ReferencedDocument `xml:"ram:ReceivingAdviceReferencedDocument,omitempty"`
+}
+
+// Event contains the date of the delivery.
+type Event struct {
+	OccurrenceDateTime *DateTime `xml:"ram:OccurrenceDateTime"`
+}
+
+// Settlement contains the payment, tax, and totals of the document.
+type Settlement struct {
+	CreditorReferenceID string                `xml:"ram:CreditorReferenceID,omitempty"`
+	PaymentReference    string                `xml:"ram:PaymentReference,omitempty"`
+	TaxCurrencyCode     string                `xml:"ram:TaxCurrencyCode,omitempty"`
+	CurrencyCode        string                `xml:"ram:InvoiceCurrencyCode"`
+	Payee               *Party                `xml:"ram:PayeeTradeParty,omitempty"`
+	PaymentMeans        []*PaymentMeans       `xml:"ram:SpecifiedTradeSettlementPaymentMeans,omitempty"`
+	Taxes               []*TradeTax           `xml:"ram:ApplicableTradeTax"`
+	Period              *Period               `xml:"ram:BillingSpecifiedPeriod,omitempty"`
+	AllowanceCharges    []*AllowanceCharge    `xml:"ram:SpecifiedTradeAllowanceCharge,omitempty"`
+	PaymentTerms        *PaymentTerms         `xml:"ram:SpecifiedTradePaymentTerms,omitempty"`
+	Summation           *Summation            `xml:"ram:SpecifiedTradeSettlementHeaderMonetarySummation"`
+	Preceding           []*ReferencedDocument `xml:"ram:InvoiceReferencedDocument,omitempty"`
+}
+
+// PaymentMeans describes how the payment is expected to be made.
+type PaymentMeans struct {
+	TypeCode         string            `xml:"ram:TypeCode"`
+	Information      string            `xml:"ram:Information,omitempty"`
+	Card             *Card             `xml:"ram:ApplicableTradeSettlementFinancialCard,omitempty"`
+	PayerAccount     *FinancialAccount `xml:"ram:PayerPartyDebtorFinancialAccount,omitempty"`
+	PayeeAccount     *FinancialAccount `xml:"ram:PayeePartyCreditorFinancialAccount,omitempty"`
+	PayeeInstitution *Institution      `xml:"ram:PayeeSpecifiedCreditorFinancialInstitution,omitempty"`
+}
+
+// Card describes the card used for the payment.
+type Card struct {
+	ID             string `xml:"ram:ID"`
+	CardholderName string `xml:"ram:CardholderName,omitempty"`
+}
+
+// FinancialAccount identifies a bank account.
+type FinancialAccount struct {
+	IBAN          string `xml:"ram:IBANID,omitempty"`
+	AccountName   string `xml:"ram:AccountName,omitempty"`
+	ProprietaryID string `xml:"ram:ProprietaryID,omitempty"`
+}
+
+// Institution identifies a financial institution by its BIC.
+type Institution struct {
+	BIC string `xml:"ram:BICID"`
+}
+
+// Period defines a range of dates.
+type Period struct {
+	Start *DateTime `xml:"ram:StartDateTime,omitempty"`
+	End   *DateTime `xml:"ram:EndDateTime,omitempty"`
+}
+
+// PaymentTerms contains the description of the terms and the due date.
+type PaymentTerms struct {
+	Description    string    `xml:"ram:Description,omitempty"`
+	DueDateTime    *DateTime `xml:"ram:DueDateDateTime,omitempty"`
+	DirectDebitRef string    `xml:"ram:DirectDebitMandateID,omitempty"`
+}
+
+// Summation contains the document totals.
+type Summation struct {
+	LineTotal      string    `xml:"ram:LineTotalAmount"`
+	ChargeTotal    string    `xml:"ram:ChargeTotalAmount,omitempty"`
+	AllowanceTotal string    `xml:"ram:AllowanceTotalAmount,omitempty"`
+	TaxBasisTotal  string    `xml:"ram:TaxBasisTotalAmount"`
+	TaxTotals      []*Amount `xml:"ram:TaxTotalAmount"`
+	RoundingAmount string    `xml:"ram:RoundingAmount,omitempty"`
+	GrandTotal     string    `xml:"ram:GrandTotalAmount"`
+	PrepaidTotal   string    `xml:"ram:TotalPrepaidAmount,omitempty"`
+	DuePayable     string    `xml:"ram:DuePayableAmount"`
+}
+
+// Amount is a monetary value with its currency.
+type Amount struct {
+	Value      string `xml:",chardata"`
+	CurrencyID string `xml:"currencyID,attr"`
+}
+
+// Bytes provides the indented XML representation of the document.
+func (d *Document) Bytes() ([]byte, error) {
+	buf := new(bytes.Buffer)
+	buf.WriteString(xml.Header)
+	enc := xml.NewEncoder(buf)
+	enc.Indent("", "  ")
+	if err := enc.Encode(d); err != nil {
+		return nil, err
+	}
+	buf.WriteString("\n")
+	return buf.Bytes(), nil
+}
+
+// Parse reads a Cross Industry Invoice from the XML data.
+func Parse(data []byte) (*Document, error) {
+	d := new(Document)
+	dec := xml.NewTokenDecoder(&prefixReader{dec: xml.NewDecoder(bytes.NewReader(data))})
+	if err := dec.Decode(d); err != nil {
+		return nil, fmt.Errorf("parsing XML: %w", err)
+	}
+	if d.Header == nil || d.Transaction == nil || d.Transaction.Settlement == nil {
+		return nil, fmt.Errorf("incomplete document")
+	}
+	return d, nil
+}
+
+// prefixReader rewrites the names of the elements read so that they are
+// prefixed according to their namespace instead of the prefix used in the
+// source, allowing the same structures to be used for parsing and
+// generating documents.
+type prefixReader struct {
+	dec *xml.Decoder
+}
+
+var namespacePrefixes = map[string]string{
+	NamespaceRSM: "rsm",
+	NamespaceRAM: "ram",
+	NamespaceQDT: "qdt",
+	NamespaceUDT: "udt",
+}
+
+func (r *prefixReader) Token() (xml.Token, error) {
+	t, err := r.dec.Token()
+	if err != nil {
+		return nil, err
+	}
+	switch v := t.(type) {
+	case xml.StartElement:
+		v.Name = prefixed(v.Name)
+		attrs := make([]xml.Attr, 0, len(v.Attr))
+		for _, a := range v.Attr {
+			if a.Name.Space == "xmlns" || (a.Name.Space == "" && a.Name.Local == "xmlns") {
+				continue
+			}
+			attrs = append(attrs, a)
+		}
+		v.Attr = attrs
+		return v, nil
+	case xml.EndElement:
+		v.Name = prefixed(v.Name)
+		return v, nil
+	}
+	if t == nil {
+		return nil, io.EOF
+	}
+	return t, nil
+}
+
+func prefixed(n xml.Name) xml.Name {
+	p, ok := namespacePrefixes[n.Space]
+	if !ok {
+		// unknown namespaces are kept apart so that they are ignored
+		return xml.Name{Space: n.Space, Local: n.Local}
+	}
+	return xml.Name{Local: p + ":" + n.Local}
+}
@@ -0,0 +1,174 @@
+package cii_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/invopop/gobl/addons/eu/en16931"
+	"github.com/invopop/gobl/addons/eu/peppol"
+	"github.com/invopop/gobl/bill"
+	"github.com/invopop/gobl/cbc"
+	"github.com/invopop/gobl/convert/cii"
+	"github.com/invopop/gobl/convert/internal/roundtrip"
+	"github.com/invopop/gobl/org"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRoundTrip(t *testing.T) {
+	roundtrip.Run(t, func(t *testing.T, inv *bill.Invoice) (*bill.Invoice, error) {
+		doc, err := cii.FromInvoice(inv)
+		if err != nil {
+			return nil, err
+		}
+		data, err := doc.Bytes()
+		require.NoError(t, err)
+
+		doc2, err := cii.Parse(data)
+		require.NoError(t, err)
+		inv2, err := doc2.Invoice()
+		require.NoError(t, err)
+		require.NoError(t, inv2.Calculate())
+
+		// compare against the converted totals as included taxes
+		// will have been removed
+		sum := doc.Transaction.Settlement.Summation
+		payable := inv2.Totals.Payable
+		if inv2.Totals.Due != nil {
+			payable = *inv2.Totals.Due
+		}
+		assert.Equal(t, sum.DuePayable, payable.String())
+		assert.Equal(t, sum.GrandTotal, inv2.Totals.TotalWithTax.String())
+		assert.Equal(t, sum.TaxTotals[0].Value, inv2.Totals.Tax.String())
+		return inv2, nil
+	},
+		"es/invoice-es-es-freelance.json",       // retained taxes
+		"es/invoice-es-es-outlays.json",         // outlays
+		"es/invoice-es-es-vateqs-provider.json", // tax surcharges
+		"it/freelance.json",                     // retained taxes
+		"mx/retentions.json",                    // retained taxes
+	)
+}
+
+func TestFromInvoice(t *testing.T) {
+	inv := roundtrip.LoadInvoice(t, "../../examples/de/out/invoice-de-de.json")
+	require.NotNil(t, inv)
+	sum := inv.Totals.Sum.String()
+	doc, err := cii.FromInvoice(inv)
+	require.NoError(t, err)
+	assert.Equal(t, cii.GuidelineXRechnung, doc.Context.Guideline.ID)
+	assert.Equal(t, "380", doc.Header.TypeCode)
+	assert.Equal(t, "EUR", doc.Transaction.Settlement.CurrencyCode)
+	seller := doc.Transaction.Agreement.Seller
+	assert.Equal(t, inv.Supplier.Name, seller.Name)
+	assert.Equal(t, "VA", seller.TaxRegistrations[0].ID.SchemeID)
+	assert.Len(t, doc.Transaction.Lines, len(inv.Lines))
+	assert.Equal(t, sum, inv.Totals.Sum.String(), "source must not be modified")
+
+	data, err := doc.Bytes()
+	require.NoError(t, err)
+	out := string(data)
+	assert.True(t, strings.HasPrefix(out, "<?xml"))
+	assert.Contains(t, out, `xmlns:rsm="`+cii.NamespaceRSM+`"`)
+	assert.Contains(t, out, `<udt:DateTimeString format="102">`)
+
+	t.Run("en16931 guideline", func(t *testing.T) {
+		inv := roundtrip.LoadInvoice(t, "../../examples/de/out/invoice-de-de.json")
+		inv.SetAddons(en16931.V2017)
+		doc, err := cii.FromInvoice(inv)
+		require.NoError(t, err)
+		assert.Equal(t, cii.GuidelineEN16931, doc.Context.Guideline.ID)
+		assert.Nil(t, doc.Context.BusinessProcess)
+	})
+
+	t.Run("peppol guideline", func(t *testing.T) {
+		inv := roundtrip.LoadInvoice(t, "../../examples/de/out/invoice-de-de.json")
+		inv.SetAddons(peppol.V3)
+		doc, err := cii.FromInvoice(inv)
+		require.NoError(t, err)
+		assert.Equal(t, cii.GuidelinePeppol, doc.Context.Guideline.ID)
+		assert.Equal(t, cii.BusinessProcessPeppol, doc.Context.BusinessProcess.ID)
+
+		inv2, err := doc.Invoice()
+		require.NoError(t, err)
+		assert.Equal(t, []cbc.Key{peppol.V3}, inv2.GetAddons())
+	})
+}
+
+func TestParse(t *testing.T) {
+	data := []byte(`<?xml version="1.0" encoding="UTF-8"?>
+<inv:CrossIndustryInvoice xmlns:inv="urn:un:unece:uncefact:data:standard:CrossIndustryInvoice:100"
+	xmlns:a="urn:un:unece:uncefact:data:standard:ReusableAggregateBusinessInformationEntity:100"
+	xmlns:u="urn:un:unece:uncefact:data:standard:UnqualifiedDataType:100">
+	<inv:ExchangedDocumentContext>
+		<a:GuidelineSpecifiedDocumentContextParameter>
+			<a:ID>urn:cen.eu:en16931:2017</a:ID>
+		</a:GuidelineSpecifiedDocumentContextParameter>
+	</inv:ExchangedDocumentContext>
+	<inv:ExchangedDocument>
+		<a:ID>INV-1</a:ID>
+		<a:TypeCode>380</a:TypeCode>
+		<a:IssueDateTime><u:DateTimeString format="102">20240301</u:DateTimeString></a:IssueDateTime>
+	</inv:ExchangedDocument>
+	<inv:SupplyChainTradeTransaction>
+		<a:IncludedSupplyChainTradeLineItem>
+			<a:AssociatedDocumentLineDocument><a:LineID>1</a:LineID></a:AssociatedDocumentLineDocument>
+			<a:SpecifiedTradeProduct><a:Name>Consulting</a:Name></a:SpecifiedTradeProduct>
+			<a:SpecifiedLineTradeAgreement>
+				<a:NetPriceProductTradePrice><a:ChargeAmount>50.00</a:ChargeAmount></a:NetPriceProductTradePrice>
+			</a:SpecifiedLineTradeAgreement>
+			<a:SpecifiedLineTradeDelivery>
+				<a:BilledQuantity unitCode="HUR">2</a:BilledQuantity>
+			</a:SpecifiedLineTradeDelivery>
+			<a:SpecifiedLineTradeSettlement>
+				<a:ApplicableTradeTax>
+					<a:TypeCode>VAT</a:TypeCode>
+					<a:CategoryCode>S</a:CategoryCode>
+					<a:RateApplicablePercent>19</a:RateApplicablePercent>
+				</a:ApplicableTradeTax>
+				<a:SpecifiedTradeSettlementLineMonetarySummation>
+					<a:LineTotalAmount>100.00</a:LineTotalAmount>
+				</a:SpecifiedTradeSettlementLineMonetarySummation>
+			</a:SpecifiedLineTradeSettlement>
+		</a:IncludedSupplyChainTradeLineItem>
+		<a:ApplicableHeaderTradeAgreement>
+			<a:SellerTradeParty>
+				<a:Name>Provide One GmbH</a:Name>
+				<a:SpecifiedTaxRegistration><a:ID schemeID="VA">DE111111125</a:ID></a:SpecifiedTaxRegistration>
+			</a:SellerTradeParty>
+		</a:ApplicableHeaderTradeAgreement>
+		<a:ApplicableHeaderTradeSettlement>
+			<a:InvoiceCurrencyCode>EUR</a:InvoiceCurrencyCode>
+			<a:SpecifiedTradeSettlementHeaderMonetarySummation>
+				<a:DuePayableAmount>119.00</a:DuePayableAmount>
+			</a:SpecifiedTradeSettlementHeaderMonetarySummation>
+		</a:ApplicableHeaderTradeSettlement>
+	</inv:SupplyChainTradeTransaction>
+</inv:CrossIndustryInvoice>`)
+	doc, err := cii.Parse(data)
+	require.NoError(t, err)
+	assert.Equal(t, "INV-1", doc.Header.ID)
+	assert.Equal(t, "119.00", doc.Transaction.Settlement.Summation.DuePayable)
+
+	inv, err := doc.Invoice()
+	require.NoError(t, err)
+	assert.Equal(t, "INV-1", inv.Code.String())
+	assert.Equal(t, "2024-03-01", inv.IssueDate.String())
+	assert.Equal(t, "DE", inv.Supplier.TaxID.Country.String())
+	assert.Equal(t, "111111125", inv.Supplier.TaxID.Code.String())
+	require.Len(t, inv.Lines, 1)
+	assert.Equal(t, org.UnitHour, inv.Lines[0].Item.Unit)
+	assert.Equal(t, "19%", inv.Lines[0].Taxes[0].Percent.String())
+
+	require.NoError(t, inv.Calculate())
+	assert.Equal(t, "119.00", inv.Totals.Payable.String())
+
+	t.Run("incomplete", func(t *testing.T) {
+		_, err := cii.Parse([]byte(`<CrossIndustryInvoice xmlns="urn:un:unece:uncefact:data:standard:CrossIndustryInvoice:100"></CrossIndustryInvoice>`))
+		assert.ErrorContains(t, err, "incomplete document")
+	})
+	t.Run("invalid", func(t *testing.T) {
+		_, err := cii.Parse([]byte(`<CrossIndustryInvoice`))
+		assert.ErrorContains(t, err, "parsing XML")
+	})
+}
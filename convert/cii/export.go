@@ -0,0 +1,465 @@
+package cii
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/invopop/gobl/bill"
+	"github.com/invopop/gobl/cal"
+	"github.com/invopop/gobl/catalogues/iso"
+	"github.com/invopop/gobl/catalogues/untdid"
+	"github.com/invopop/gobl/cbc"
+	"github.com/invopop/gobl/convert/internal/semantic"
+	"github.com/invopop/gobl/num"
+	"github.com/invopop/gobl/org"
+	"github.com/invopop/gobl/pay"
+	"github.com/invopop/gobl/regimes/common"
+	"github.com/invopop/gobl/tax"
+)
+
+// Scheme identifier of VAT numbers in party tax registrations.
+const taxSchemeVAT = "VA"
+
+// Scheme identifier of email addresses used as the electronic address.
+const schemeEmail = "EM"
+
+const defaultTaxType = "VAT"
+
+// FromInvoice converts the GOBL invoice into a Cross Industry Invoice. The
+// conversion is made from a copy of the invoice that is calculated with
+// the EN 16931 addon so that all the UNTDID codes are available, and with
+// any taxes included in prices removed. The guideline is determined from
+// the addons, so that invoices using XRechnung or Peppol are identified as such.
+func FromInvoice(src *bill.Invoice) (*Document, error) {
+	inv, err := semantic.Prepare(src)
+	if err != nil {
+		return nil, err
+	}
+
+	d := &Document{
+		RSMNamespace: NamespaceRSM,
+		RAMNamespace: NamespaceRAM,
+		QDTNamespace: NamespaceQDT,
+		UDTNamespace: NamespaceUDT,
+		Context: &Context{
+			Guideline: &IDParameter{ID: semantic.Guideline(inv)},
+		},
+		Header: &Header{
+			ID:            semantic.InvoiceID(inv.Series, inv.Code),
+			TypeCode:      semantic.DocumentTypeCode(inv),
+			IssueDateTime: newDateTime(inv.IssueDate),
+		},
+		Transaction: &Transaction{
+			Agreement:  newAgreement(inv),
+			Delivery:   newDelivery(inv),
+			Settlement: newSettlement(inv),
+		},
+	}
+	if p := semantic.Profile(inv); p != "" {
+		d.Context.BusinessProcess = &IDParameter{ID: p}
+	}
+	for _, n := range inv.Notes {
+		if n.Text != "" {
+			d.Header.Notes = append(d.Header.Notes, &Note{Content: n.Text})
+		}
+	}
+	for _, l := range inv.Lines {
+		d.Transaction.Lines = append(d.Transaction.Lines, newLine(l))
+	}
+	return d, nil
+}
+
+func newAgreement(inv *bill.Invoice) *Agreement {
+	a := &Agreement{
+		Seller: newParty(inv.Supplier),
+		Buyer:  newParty(inv.Customer),
+	}
+	if a.Buyer == nil {
+		a.Buyer = new(Party)
+	}
+	if o := inv.Ordering; o != nil {
+		a.BuyerReference = o.Code.String()
+		a.SellerOrder = firstReference(o.Sales)
+		a.BuyerOrder = firstReference(o.Purchases)
+		a.Contract = firstReference(o.Contracts)
+		if len(o.Projects) > 0 {
+			p := o.Projects[0]
+			a.Project = &Project{
+				ID:   semantic.InvoiceID(p.Series, p.Code),
+				Name: p.Description,
+			}
+			if a.Project.Name == "" {
+				a.Project.Name = a.Project.ID
+			}
+		}
+	}
+	return a
+}
+
+func newDelivery(inv *bill.Invoice) *Delivery {
+	d := new(Delivery)
+	if dd := inv.Delivery; dd != nil {
+		d.ShipTo = newParty(dd.Receiver)
+		if dd.Date != nil {
+			d.Event = &Event{OccurrenceDateTime: newDateTime(*dd.Date)}
+		}
+	}
+	if o := inv.Ordering; o != nil {
+		d.Despatch = firstReference(o.Despatch)
+		d.Receipt = firstReference(o.Receiving)
+	}
+	return d
+}
+
+func newSettlement(inv *bill.Invoice) *Settlement {
+	cur := inv.Currency
+	t := inv.Totals
+	s := &Settlement{
+		CurrencyCode: cur.String(),
+	}
+	if p := inv.Payment; p != nil {
+		if p.Payee != nil {
+			s.Payee = newParty(p.Payee)
+		}
+		if instr := p.Instructions; instr != nil {
+			s.PaymentReference = instr.Ref.String()
+			if instr.DirectDebit != nil {
+				s.CreditorReferenceID = instr.DirectDebit.Creditor
+			}
+			s.PaymentMeans = newPaymentMeans(instr)
+		}
+		s.PaymentTerms = newPaymentTerms(p)
+	}
+	if t.Taxes != nil {
+		for _, ct := range t.Taxes.Categories {
+			for _, rt := range ct.Rates {
+				tt := newTradeTax(ct.Code, rt.Ext, rt.Percent)
+				tt.CalculatedAmount = rt.Amount.String()
+				tt.BasisAmount = rt.Base.String()
+				s.Taxes = append(s.Taxes, tt)
+			}
+		}
+	}
+	if o := inv.Ordering; o != nil && o.Period != nil {
+		s.Period = &Period{
+			Start: newDateTime(o.Period.Start),
+			End:   newDateTime(o.Period.End),
+		}
+	}
+	for _, dis := range inv.Discounts {
+		s.AllowanceCharges = append(s.AllowanceCharges, &AllowanceCharge{
+			ChargeIndicator: &Indicator{Value: false},
+			Percent:         semantic.PercentValue(dis.Percent),
+			BasisAmount:     basisAmount(dis.Base, dis.Percent, t.Sum),
+			ActualAmount:    dis.Amount.String(),
+			ReasonCode:      dis.Ext[untdid.ExtKeyAllowance].String(),
+			Reason:          dis.Reason,
+			Tax:             newComboTax(dis.Taxes),
+		})
+	}
+	for _, chr := range inv.Charges {
+		s.AllowanceCharges = append(s.AllowanceCharges, &AllowanceCharge{
+			ChargeIndicator: &Indicator{Value: true},
+			Percent:         semantic.PercentValue(chr.Percent),
+			BasisAmount:     basisAmount(chr.Base, chr.Percent, t.Sum),
+			ActualAmount:    chr.Amount.String(),
+			ReasonCode:      chr.Ext[untdid.ExtKeyCharge].String(),
+			Reason:          chr.Reason,
+			Tax:             newComboTax(chr.Taxes),
+		})
+	}
+	for _, p := range inv.Preceding {
+		s.Preceding = append(s.Preceding, newReference(p))
+	}
+
+	payable := t.Payable
+	if t.Due != nil {
+		payable = *t.Due
+	}
+	s.Summation = &Summation{
+		LineTotal:      t.Sum.String(),
+		ChargeTotal:    optionalAmount(t.Charge),
+		AllowanceTotal: optionalAmount(t.Discount),
+		TaxBasisTotal:  t.Total.String(),
+		TaxTotals: []*Amount{
+			{Value: t.Tax.String(), CurrencyID: cur.String()},
+		},
+		RoundingAmount: optionalAmount(t.Rounding),
+		GrandTotal:     t.TotalWithTax.String(),
+		PrepaidTotal:   optionalAmount(t.Advances),
+		DuePayable:     payable.String(),
+	}
+	if t.Local != nil && t.Local.Currency != cur {
+		s.TaxCurrencyCode = t.Local.Currency.String()
+		s.Summation.TaxTotals = append(s.Summation.TaxTotals, &Amount{
+			Value:      t.Local.Tax.String(),
+			CurrencyID: t.Local.Currency.String(),
+		})
+	}
+	return s
+}
+
+func newPaymentMeans(instr *pay.Instructions) []*PaymentMeans {
+	base := PaymentMeans{
+		TypeCode:    instr.Ext[untdid.ExtKeyPaymentMeans].String(),
+		Information: instr.Detail,
+	}
+	if base.TypeCode == "" {
+		base.TypeCode = "1" // not defined
+	}
+	if c := instr.Card; c != nil {
+		base.Card = &Card{ID: c.Last4, CardholderName: c.Holder}
+	}
+	if dd := instr.DirectDebit; dd != nil && dd.Account != "" {
+		base.PayerAccount = &FinancialAccount{IBAN: dd.Account}
+	}
+	if len(instr.CreditTransfer) == 0 {
+		return []*PaymentMeans{&base}
+	}
+	out := make([]*PaymentMeans, 0, len(instr.CreditTransfer))
+	for _, ct := range instr.CreditTransfer {
+		pm := base // copy
+		pm.PayeeAccount = &FinancialAccount{
+			IBAN:          ct.IBAN,
+			AccountName:   ct.Name,
+			ProprietaryID: ct.Number,
+		}
+		if ct.BIC != "" {
+			pm.PayeeInstitution = &Institution{BIC: ct.BIC}
+		}
+		out = append(out, &pm)
+	}
+	return out
+}
+
+func newPaymentTerms(p *bill.PaymentDetails) *PaymentTerms {
+	pt := new(PaymentTerms)
+	if t := p.Terms; t != nil {
+		pt.Description = t.Notes
+		if pt.Description == "" {
+			pt.Description = t.Detail
+		}
+		for _, dd := range t.DueDates {
+			if dd.Date != nil {
+				pt.DueDateTime = newDateTime(*dd.Date)
+				break
+			}
+		}
+	}
+	if p.Instructions != nil && p.Instructions.DirectDebit != nil {
+		pt.DirectDebitRef = p.Instructions.DirectDebit.Ref
+	}
+	if *pt == (PaymentTerms{}) {
+		return nil
+	}
+	return pt
+}
+
+func newLine(l *bill.Line) *Line {
+	line := &Line{
+		LineDoc: &LineDoc{LineID: strconv.Itoa(l.Index)},
+		Product: new(Product),
+		Agreement: &LineAgreement{
+			NetPrice: new(Price),
+		},
+		Delivery: &LineDelivery{
+			BilledQuantity: &Quantity{
+				Value:    l.Quantity.String(),
+				UnitCode: semantic.DefaultUnitCode,
+			},
+		},
+		Settlement: &LineSettlement{
+			Tax: newComboTax(l.Taxes),
+			Summation: &LineSummation{
+				LineTotalAmount: l.Total.String(),
+			},
+		},
+	}
+	for _, n := range l.Notes {
+		if n.Text != "" {
+			line.LineDoc.Notes = append(line.LineDoc.Notes, &Note{Content: n.Text})
+		}
+	}
+	if it := l.Item; it != nil {
+		line.Product.Name = it.Name
+		line.Product.Description = it.Description
+		line.Product.SellerAssigned = it.Ref
+		if len(it.Identities) > 0 {
+			line.Product.GlobalID = newIdentifier(it.Identities[0])
+		}
+		if it.Origin != "" {
+			line.Product.Origin = &CountryID{ID: it.Origin.String()}
+		}
+		line.Agreement.NetPrice.ChargeAmount = it.Price.String()
+		line.Delivery.BilledQuantity.UnitCode = semantic.UnitCode(it.Unit)
+	}
+	if line.Settlement.Tax == nil {
+		line.Settlement.Tax = &TradeTax{TypeCode: defaultTaxType, CategoryCode: "O"}
+	}
+	for _, dis := range l.Discounts {
+		line.Settlement.AllowanceCharges = append(line.Settlement.AllowanceCharges, &AllowanceCharge{
+			ChargeIndicator: &Indicator{Value: false},
+			Percent:         semantic.PercentValue(dis.Percent),
+			BasisAmount:     basisAmount(nil, dis.Percent, l.Sum),
+			ActualAmount:    dis.Amount.String(),
+			ReasonCode:      dis.Ext[untdid.ExtKeyAllowance].String(),
+			Reason:          dis.Reason,
+		})
+	}
+	for _, chr := range l.Charges {
+		line.Settlement.AllowanceCharges = append(line.Settlement.AllowanceCharges, &AllowanceCharge{
+			ChargeIndicator: &Indicator{Value: true},
+			Percent:         semantic.PercentValue(chr.Percent),
+			BasisAmount:     basisAmount(nil, chr.Percent, l.Sum),
+			ActualAmount:    chr.Amount.String(),
+			ReasonCode:      chr.Ext[untdid.ExtKeyCharge].String(),
+			Reason:          chr.Reason,
+		})
+	}
+	return line
+}
+
+func newParty(p *org.Party) *Party {
+	if p == nil {
+		return nil
+	}
+	out := &Party{Name: p.Name}
+	for _, id := range p.Identities {
+		out.GlobalIDs = append(out.GlobalIDs, newIdentifier(id))
+	}
+	if p.Alias != "" {
+		out.LegalOrganization = &LegalOrganization{TradingName: p.Alias}
+	}
+	out.Contact = newContact(p)
+	if len(p.Addresses) > 0 {
+		out.Address = newAddress(p.Addresses[0])
+		if out.Address.CountryID == "" && p.TaxID != nil {
+			out.Address.CountryID = p.TaxID.Country.String()
+		}
+	}
+	for _, ib := range p.Inboxes {
+		if ib.Key != common.InboxKeyPEPPOL || ib.Code == cbc.CodeEmpty {
+			continue
+		}
+		id := &Identifier{Value: ib.Code.String()}
+		if s, v, ok := strings.Cut(id.Value, ":"); ok {
+			id.SchemeID, id.Value = s, v
+		} else {
+			id.SchemeID = ib.Ext[iso.ExtKeySchemeID].String()
+		}
+		out.URI = &URI{ID: id}
+		break
+	}
+	if out.URI == nil && len(p.Emails) > 0 {
+		out.URI = &URI{ID: &Identifier{Value: p.Emails[0].Address, SchemeID: schemeEmail}}
+	}
+	if p.TaxID != nil && p.TaxID.Code != cbc.CodeEmpty {
+		out.TaxRegistrations = []*TaxRegistration{
+			{ID: &Identifier{Value: p.TaxID.String(), SchemeID: taxSchemeVAT}},
+		}
+	}
+	return out
+}
+
+func newContact(p *org.Party) *Contact {
+	c := new(Contact)
+	if len(p.People) > 0 && p.People[0].Name != nil {
+		n := p.People[0].Name
+		c.PersonName = strings.TrimSpace(n.Given + " " + n.Surname)
+	}
+	if len(p.Telephones) > 0 {
+		c.Telephone = &Telephone{CompleteNumber: p.Telephones[0].Number}
+	}
+	if len(p.Emails) > 0 {
+		c.Email = &URI{ID: &Identifier{Value: p.Emails[0].Address}}
+	}
+	if *c == (Contact{}) {
+		return nil
+	}
+	return c
+}
+
+func newAddress(a *org.Address) *Address {
+	return &Address{
+		Postcode:    a.Code.String(),
+		LineOne:     strings.TrimSpace(a.Street + " " + a.Number),
+		LineTwo:     a.StreetExtra,
+		City:        a.Locality,
+		CountryID:   a.Country.String(),
+		Subdivision: a.Region,
+	}
+}
+
+func newIdentifier(id *org.Identity) *Identifier {
+	return &Identifier{
+		Value:    id.Code.String(),
+		SchemeID: semantic.IdentityScheme(id),
+	}
+}
+
+func newComboTax(set tax.Set) *TradeTax {
+	if len(set) == 0 {
+		return nil
+	}
+	c := set[0]
+	return newTradeTax(c.Category, c.Ext, c.Percent)
+}
+
+func newTradeTax(cat cbc.Code, ext tax.Extensions, p *num.Percentage) *TradeTax {
+	tt := &TradeTax{
+		TypeCode:     cat.String(),
+		CategoryCode: semantic.TaxCategoryCode(ext, p),
+	}
+	if p != nil {
+		tt.Percent = semantic.PercentValue(p)
+	} else if tt.CategoryCode != "O" {
+		tt.Percent = "0"
+	}
+	return tt
+}
+
+func firstReference(refs []*org.DocumentRef) *ReferencedDocument {
+	if len(refs) == 0 {
+		return nil
+	}
+	return newReference(refs[0])
+}
+
+func newReference(r *org.DocumentRef) *ReferencedDocument {
+	rd := &ReferencedDocument{IssuerAssignedID: semantic.InvoiceID(r.Series, r.Code)}
+	if r.IssueDate != nil {
+		rd.IssueDateTime = &FormattedDateTime{DateString: newDateString(*r.IssueDate)}
+	}
+	return rd
+}
+
+func newDateTime(d cal.Date) *DateTime {
+	return &DateTime{DateString: newDateString(d)}
+}
+
+func newDateString(d cal.Date) *DateString {
+	return &DateString{
+		Value:  fmt.Sprintf("%04d%02d%02d", d.Year, d.Month, d.Day),
+		Format: dateFormat,
+	}
+}
+
+func optionalAmount(a *num.Amount) string {
+	if a == nil {
+		return ""
+	}
+	return a.String()
+}
+
+// basisAmount provides the base used to calculate a percentage discount or
+// charge.
+func basisAmount(base *num.Amount, p *num.Percentage, sum num.Amount) string {
+	if p == nil {
+		return ""
+	}
+	if base == nil {
+		return sum.String()
+	}
+	return base.String()
+}
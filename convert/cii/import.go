@@ -0,0 +1,470 @@
+package cii
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/invopop/gobl/addons/eu/en16931"
+	"github.com/invopop/gobl/bill"
+	"github.com/invopop/gobl/cal"
+	"github.com/invopop/gobl/catalogues/untdid"
+	"github.com/invopop/gobl/cbc"
+	"github.com/invopop/gobl/convert/internal/semantic"
+	"github.com/invopop/gobl/currency"
+	"github.com/invopop/gobl/l10n"
+	"github.com/invopop/gobl/num"
+	"github.com/invopop/gobl/org"
+	"github.com/invopop/gobl/pay"
+	"github.com/invopop/gobl/regimes/common"
+	"github.com/invopop/gobl/tax"
+)
+
+// Invoice converts the CII document into a GOBL invoice that uses the
+// addon matching the guideline, or EN 16931 if not recognized. The
+// invoice is not calculated nor validated so that any problems with the
+// source data can be reviewed afterwards.
+func (d *Document) Invoice() (*bill.Invoice, error) {
+	h := d.Header
+	tx := d.Transaction
+	inv := &bill.Invoice{
+		Addons:   tax.WithAddons(en16931.V2017),
+		Code:     cbc.Code(h.ID),
+		Currency: currency.Code(tx.Settlement.CurrencyCode),
+		Type:     semantic.InvoiceType(h.TypeCode, false),
+	}
+	if d.Context != nil && d.Context.Guideline != nil {
+		inv.Addons = tax.WithAddons(semantic.GuidelineAddon(d.Context.Guideline.ID))
+	}
+	var err error
+	if inv.IssueDate, err = h.IssueDateTime.date(); err != nil {
+		return nil, fmt.Errorf("issue date: %w", err)
+	}
+	if h.TypeCode != "" {
+		inv.Tax = &bill.Tax{
+			Ext: tax.Extensions{untdid.ExtKeyDocumentType: tax.ExtValue(h.TypeCode)},
+		}
+	}
+	for _, n := range h.Notes {
+		inv.Notes = append(inv.Notes, &cbc.Note{Text: n.Content})
+	}
+
+	s := tx.Settlement
+	for _, rd := range s.Preceding {
+		ref, err := rd.documentRef()
+		if err != nil {
+			return nil, err
+		}
+		inv.Preceding = append(inv.Preceding, ref)
+	}
+	if inv.Ordering, err = tx.ordering(); err != nil {
+		return nil, err
+	}
+	if a := tx.Agreement; a != nil {
+		inv.Supplier = a.Seller.party()
+		inv.Customer = a.Buyer.party()
+	}
+	if inv.Delivery, err = tx.Delivery.details(); err != nil {
+		return nil, err
+	}
+	if inv.Payment, err = s.payment(); err != nil {
+		return nil, err
+	}
+	for _, ac := range s.AllowanceCharges {
+		if err := addAllowanceCharge(inv, ac); err != nil {
+			return nil, err
+		}
+	}
+	for _, l := range tx.Lines {
+		line, err := l.line()
+		if err != nil {
+			return nil, fmt.Errorf("line %s: %w", l.LineDoc.LineID, err)
+		}
+		inv.Lines = append(inv.Lines, line)
+	}
+	if sum := s.Summation; sum != nil && sum.RoundingAmount != "" {
+		r, err := num.AmountFromString(sum.RoundingAmount)
+		if err != nil {
+			return nil, fmt.Errorf("rounding: %w", err)
+		}
+		inv.Totals = &bill.Totals{Rounding: &r}
+	}
+	return inv, nil
+}
+
+func (tx *Transaction) ordering() (*bill.Ordering, error) {
+	o := new(bill.Ordering)
+	var err error
+	if a := tx.Agreement; a != nil {
+		o.Code = cbc.Code(a.BuyerReference)
+		if o.Sales, err = a.SellerOrder.documentRefs(); err != nil {
+			return nil, err
+		}
+		if o.Purchases, err = a.BuyerOrder.documentRefs(); err != nil {
+			return nil, err
+		}
+		if o.Contracts, err = a.Contract.documentRefs(); err != nil {
+			return nil, err
+		}
+		if p := a.Project; p != nil {
+			ref := &org.DocumentRef{Code: cbc.Code(p.ID)}
+			if p.Name != p.ID {
+				ref.Description = p.Name
+			}
+			o.Projects = []*org.DocumentRef{ref}
+		}
+	}
+	if d := tx.Delivery; d != nil {
+		if o.Despatch, err = d.Despatch.documentRefs(); err != nil {
+			return nil, err
+		}
+		if o.Receiving, err = d.Receipt.documentRefs(); err != nil {
+			return nil, err
+		}
+	}
+	if p := tx.Settlement.Period; p != nil {
+		o.Period = new(cal.Period)
+		if o.Period.Start, err = p.Start.date(); err != nil {
+			return nil, fmt.Errorf("period start: %w", err)
+		}
+		if o.Period.End, err = p.End.date(); err != nil {
+			return nil, fmt.Errorf("period end: %w", err)
+		}
+	}
+	if o.Code == cbc.CodeEmpty && o.Period == nil && len(o.Purchases) == 0 &&
+		len(o.Sales) == 0 && len(o.Despatch) == 0 && len(o.Receiving) == 0 &&
+		len(o.Contracts) == 0 && len(o.Projects) == 0 {
+		return nil, nil
+	}
+	return o, nil
+}
+
+func (s *Settlement) payment() (*bill.PaymentDetails, error) {
+	pd := new(bill.PaymentDetails)
+	if s.Payee != nil {
+		pd.Payee = s.Payee.party()
+	}
+	if pt := s.PaymentTerms; pt != nil && (pt.Description != "" || pt.DueDateTime != nil) {
+		pd.Terms = &pay.Terms{Notes: pt.Description}
+		if pt.DueDateTime != nil {
+			dt, err := pt.DueDateTime.date()
+			if err != nil {
+				return nil, fmt.Errorf("due date: %w", err)
+			}
+			pd.Terms.DueDates = []*pay.DueDate{
+				{Date: &dt, Percent: num.NewPercentage(100, 2)},
+			}
+		}
+	}
+	for i, pm := range s.PaymentMeans {
+		if i == 0 {
+			pd.Instructions = &pay.Instructions{
+				Key:    en16931.PaymentMeansKey(tax.ExtValue(pm.TypeCode)),
+				Detail: pm.Information,
+				Ref:    cbc.Code(s.PaymentReference),
+				Ext: tax.Extensions{
+					untdid.ExtKeyPaymentMeans: tax.ExtValue(pm.TypeCode),
+				},
+			}
+			if c := pm.Card; c != nil {
+				pd.Instructions.Card = &pay.Card{
+					Last4:  c.ID,
+					Holder: c.CardholderName,
+				}
+			}
+			ddRef := ""
+			if s.PaymentTerms != nil {
+				ddRef = s.PaymentTerms.DirectDebitRef
+			}
+			if pm.PayerAccount != nil || ddRef != "" || s.CreditorReferenceID != "" {
+				pd.Instructions.DirectDebit = &pay.DirectDebit{
+					Ref:      ddRef,
+					Creditor: s.CreditorReferenceID,
+				}
+				if pm.PayerAccount != nil {
+					pd.Instructions.DirectDebit.Account = pm.PayerAccount.IBAN
+				}
+			}
+		}
+		if fa := pm.PayeeAccount; fa != nil {
+			ct := &pay.CreditTransfer{
+				IBAN:   fa.IBAN,
+				Number: fa.ProprietaryID,
+				Name:   fa.AccountName,
+			}
+			if pm.PayeeInstitution != nil {
+				ct.BIC = pm.PayeeInstitution.BIC
+			}
+			pd.Instructions.CreditTransfer = append(pd.Instructions.CreditTransfer, ct)
+		}
+	}
+	if sum := s.Summation; sum != nil && sum.PrepaidTotal != "" {
+		a, err := num.AmountFromString(sum.PrepaidTotal)
+		if err != nil {
+			return nil, fmt.Errorf("prepaid amount: %w", err)
+		}
+		if !a.IsZero() {
+			pd.Advances = []*pay.Advance{
+				{Description: "Prepaid amount", Amount: a},
+			}
+		}
+	}
+	if pd.Payee == nil && pd.Terms == nil && pd.Instructions == nil && len(pd.Advances) == 0 {
+		return nil, nil
+	}
+	return pd, nil
+}
+
+func (d *Delivery) details() (*bill.DeliveryDetails, error) {
+	if d == nil || (d.ShipTo == nil && d.Event == nil) {
+		return nil, nil
+	}
+	dd := new(bill.DeliveryDetails)
+	if d.Event != nil {
+		dt, err := d.Event.OccurrenceDateTime.date()
+		if err != nil {
+			return nil, fmt.Errorf("delivery date: %w", err)
+		}
+		dd.Date = &dt
+	}
+	dd.Receiver = d.ShipTo.party()
+	return dd, nil
+}
+
+func (p *Party) party() *org.Party {
+	if p == nil || (p.Name == "" && len(p.TaxRegistrations) == 0) {
+		return nil
+	}
+	out := &org.Party{Name: p.Name}
+	if p.LegalOrganization != nil {
+		out.Alias = p.LegalOrganization.TradingName
+	}
+	for _, tr := range p.TaxRegistrations {
+		if tr.ID != nil && tr.ID.SchemeID == taxSchemeVAT {
+			out.TaxID = semantic.TaxIdentity(tr.ID.Value)
+			break
+		}
+	}
+	for _, id := range append(p.GlobalIDs, p.IDs...) {
+		out.Identities = append(out.Identities, identity(id))
+	}
+	if p.URI != nil && p.URI.ID != nil && p.URI.ID.SchemeID != schemeEmail {
+		code := p.URI.ID.Value
+		if p.URI.ID.SchemeID != "" {
+			code = p.URI.ID.SchemeID + ":" + code
+		}
+		out.Inboxes = []*org.Inbox{{Key: common.InboxKeyPEPPOL, Code: cbc.Code(code)}}
+	}
+	if a := p.Address; a != nil {
+		out.Addresses = []*org.Address{
+			{
+				Street:      a.LineOne,
+				StreetExtra: a.LineTwo,
+				Locality:    a.City,
+				Code:        cbc.Code(a.Postcode),
+				Region:      a.Subdivision,
+				Country:     l10n.ISOCountryCode(a.CountryID),
+			},
+		}
+	}
+	if c := p.Contact; c != nil {
+		if c.PersonName != "" {
+			out.People = []*org.Person{{Name: &org.Name{Given: c.PersonName}}}
+		}
+		if c.Telephone != nil {
+			out.Telephones = []*org.Telephone{{Number: c.Telephone.CompleteNumber}}
+		}
+		if c.Email != nil && c.Email.ID != nil {
+			out.Emails = []*org.Email{{Address: c.Email.ID.Value}}
+		}
+	}
+	return out
+}
+
+func identity(id *Identifier) *org.Identity {
+	return semantic.Identity(id.Value, id.SchemeID)
+}
+
+func addAllowanceCharge(inv *bill.Invoice, ac *AllowanceCharge) error {
+	amount, err := num.AmountFromString(ac.ActualAmount)
+	if err != nil {
+		return fmt.Errorf("allowance charge amount: %w", err)
+	}
+	percent, err := semantic.ParsePercent(ac.Percent)
+	if err != nil {
+		return fmt.Errorf("allowance charge percent: %w", err)
+	}
+	var base *num.Amount
+	if percent != nil && ac.BasisAmount != "" {
+		b, err := num.AmountFromString(ac.BasisAmount)
+		if err != nil {
+			return fmt.Errorf("allowance charge base: %w", err)
+		}
+		base = &b
+	}
+	var taxes tax.Set
+	if ac.Tax != nil {
+		c, err := ac.Tax.combo()
+		if err != nil {
+			return err
+		}
+		taxes = tax.Set{c}
+	}
+	code := tax.ExtValue(ac.ReasonCode)
+	if ac.ChargeIndicator != nil && ac.ChargeIndicator.Value {
+		chr := &bill.Charge{
+			Key:     en16931.ChargeKey(code),
+			Reason:  ac.Reason,
+			Base:    base,
+			Percent: percent,
+			Amount:  amount,
+			Taxes:   taxes,
+		}
+		if code != "" {
+			chr.Ext = tax.Extensions{untdid.ExtKeyCharge: code}
+		}
+		inv.Charges = append(inv.Charges, chr)
+		return nil
+	}
+	dis := &bill.Discount{
+		Key:     en16931.DiscountKey(code),
+		Reason:  ac.Reason,
+		Base:    base,
+		Percent: percent,
+		Amount:  amount,
+		Taxes:   taxes,
+	}
+	if code != "" {
+		dis.Ext = tax.Extensions{untdid.ExtKeyAllowance: code}
+	}
+	inv.Discounts = append(inv.Discounts, dis)
+	return nil
+}
+
+func (l *Line) line() (*bill.Line, error) {
+	if l.Delivery == nil || l.Delivery.BilledQuantity == nil {
+		return nil, fmt.Errorf("quantity required")
+	}
+	q := l.Delivery.BilledQuantity
+	out := &bill.Line{Item: new(org.Item)}
+	var err error
+	if out.Quantity, err = num.AmountFromString(q.Value); err != nil {
+		return nil, fmt.Errorf("quantity: %w", err)
+	}
+	out.Item.Unit = semantic.Unit(q.UnitCode)
+	if l.LineDoc != nil {
+		for _, n := range l.LineDoc.Notes {
+			out.Notes = append(out.Notes, &cbc.Note{Text: n.Content})
+		}
+	}
+	if l.Agreement != nil && l.Agreement.NetPrice != nil {
+		if out.Item.Price, err = num.AmountFromString(l.Agreement.NetPrice.ChargeAmount); err != nil {
+			return nil, fmt.Errorf("price: %w", err)
+		}
+	}
+	if p := l.Product; p != nil {
+		out.Item.Name = p.Name
+		out.Item.Description = p.Description
+		out.Item.Ref = p.SellerAssigned
+		if p.GlobalID != nil {
+			out.Item.Identities = []*org.Identity{identity(p.GlobalID)}
+		}
+		if p.Origin != nil {
+			out.Item.Origin = l10n.ISOCountryCode(p.Origin.ID)
+		}
+	}
+	st := l.Settlement
+	if st == nil {
+		return out, nil
+	}
+	if st.Tax != nil {
+		c, err := st.Tax.combo()
+		if err != nil {
+			return nil, err
+		}
+		out.Taxes = tax.Set{c}
+	}
+	for _, ac := range st.AllowanceCharges {
+		amount, err := num.AmountFromString(ac.ActualAmount)
+		if err != nil {
+			return nil, fmt.Errorf("allowance charge amount: %w", err)
+		}
+		percent, err := semantic.ParsePercent(ac.Percent)
+		if err != nil {
+			return nil, fmt.Errorf("allowance charge percent: %w", err)
+		}
+		code := tax.ExtValue(ac.ReasonCode)
+		if ac.ChargeIndicator != nil && ac.ChargeIndicator.Value {
+			chr := &bill.LineCharge{
+				Key:     en16931.ChargeKey(code),
+				Reason:  ac.Reason,
+				Percent: percent,
+				Amount:  amount,
+			}
+			if code != "" {
+				chr.Ext = tax.Extensions{untdid.ExtKeyCharge: code}
+			}
+			out.Charges = append(out.Charges, chr)
+			continue
+		}
+		dis := &bill.LineDiscount{
+			Key:     en16931.DiscountKey(code),
+			Reason:  ac.Reason,
+			Percent: percent,
+			Amount:  amount,
+		}
+		if code != "" {
+			dis.Ext = tax.Extensions{untdid.ExtKeyAllowance: code}
+		}
+		out.Discounts = append(out.Discounts, dis)
+	}
+	return out, nil
+}
+
+func (tt *TradeTax) combo() (*tax.Combo, error) {
+	return semantic.TaxCombo(cbc.Code(tt.TypeCode), tt.CategoryCode, tt.Percent)
+}
+
+func (rd *ReferencedDocument) documentRefs() ([]*org.DocumentRef, error) {
+	if rd == nil {
+		return nil, nil
+	}
+	ref, err := rd.documentRef()
+	if err != nil {
+		return nil, err
+	}
+	return []*org.DocumentRef{ref}, nil
+}
+
+func (rd *ReferencedDocument) documentRef() (*org.DocumentRef, error) {
+	ref := &org.DocumentRef{Code: cbc.Code(rd.IssuerAssignedID)}
+	if rd.IssueDateTime != nil {
+		dt, err := rd.IssueDateTime.DateString.date()
+		if err != nil {
+			return nil, fmt.Errorf("reference '%s' issue date: %w", rd.IssuerAssignedID, err)
+		}
+		ref.IssueDate = &dt
+	}
+	return ref, nil
+}
+
+func (dt *DateTime) date() (cal.Date, error) {
+	if dt == nil {
+		return cal.Date{}, fmt.Errorf("missing date")
+	}
+	return dt.DateString.date()
+}
+
+func (ds *DateString) date() (cal.Date, error) {
+	if ds == nil {
+		return cal.Date{}, fmt.Errorf("missing date")
+	}
+	if ds.Format != "" && ds.Format != dateFormat {
+		return cal.Date{}, fmt.Errorf("unsupported date format '%s'", ds.Format)
+	}
+	t, err := time.Parse("20060102", strings.TrimSpace(ds.Value))
+	if err != nil {
+		return cal.Date{}, err
+	}
+	return cal.DateOf(t), nil
+}
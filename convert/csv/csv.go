@@ -0,0 +1,366 @@
+// Package csv reads rows of invoice lines from CSV files, such as those
+// exported from spreadsheets, and groups them into partial invoices
+// using a column mapping definition.
+//
+// The partial invoices produced are plain maps with the same structure
+// as GOBL JSON documents so that they can be merged into templates and
+// built in the same way as any other input.
+package csv
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+
+	"github.com/invopop/gobl/bill"
+	"github.com/invopop/gobl/cbc"
+	"github.com/invopop/gobl/schema"
+	"github.com/invopop/validation"
+	"github.com/invopop/yaml"
+)
+
+// Supported decimal marks used in numeric columns.
+const (
+	DecimalMarkPoint = "."
+	DecimalMarkComma = ","
+)
+
+// Mapping defines how the columns of a CSV file are used to build
+// invoices. Columns are identified by the names in the header row.
+type Mapping struct {
+	// Key is the column used to group rows into invoices. When empty, all
+	// the rows are added to a single invoice.
+	Key string `json:"key,omitempty"`
+	// Comma is the field delimiter, "," by default.
+	Comma string `json:"comma,omitempty"`
+	// DecimalMark used by numeric columns, "." by default. Thousands
+	// separators are removed.
+	DecimalMark string `json:"decimal_mark,omitempty"`
+	// Invoice maps paths of invoice fields, separated with dots, to the
+	// columns that contain their values. Each invoice is expected to
+	// contain the same values in all of its rows.
+	Invoice map[string]string `json:"invoice,omitempty"`
+	// Line defines the columns used to build each invoice line.
+	Line *LineMapping `json:"line"`
+}
+
+// LineMapping defines the columns used to build a single invoice line
+// from each row.
+type LineMapping struct {
+	ItemName        string `json:"item_name"`
+	ItemRef         string `json:"item_ref,omitempty"`
+	ItemDescription string `json:"item_description,omitempty"`
+	// Quantity is the column with the line quantity, which will be 1 if
+	// not defined or empty.
+	Quantity string `json:"quantity,omitempty"`
+	Price    string `json:"price"`
+	Unit     string `json:"unit,omitempty"`
+	// TaxCategory is the column with the tax category code. Rows without
+	// a category will not have any line taxes.
+	TaxCategory string `json:"tax_category,omitempty"`
+	TaxRate     string `json:"tax_rate,omitempty"`
+	TaxPercent  string `json:"tax_percent,omitempty"`
+	// ItemExt maps extension keys to the columns with the values to add
+	// to the item.
+	ItemExt map[cbc.Key]string `json:"item_ext,omitempty"`
+	// TaxExt maps extension keys to the columns with the values to add
+	// to the line's tax combo.
+	TaxExt map[cbc.Key]string `json:"tax_ext,omitempty"`
+}
+
+// Group contains the data of a single invoice built from one or more
+// rows sharing the same key.
+type Group struct {
+	// Key is the value of the key column shared by all the rows.
+	Key string
+	// Data contains the partial invoice.
+	Data map[string]any
+}
+
+// ParseMapping parses a YAML or JSON mapping definition and ensures it
+// is valid.
+func ParseMapping(data []byte) (*Mapping, error) {
+	m := new(Mapping)
+	if err := yaml.Unmarshal(data, m); err != nil {
+		return nil, fmt.Errorf("parsing mapping: %w", err)
+	}
+	if err := m.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid mapping: %w", err)
+	}
+	return m, nil
+}
+
+// Validate ensures the mapping contains everything required to build
+// invoice lines.
+func (m *Mapping) Validate() error {
+	return validation.ValidateStruct(m,
+		validation.Field(&m.Comma, validation.In(",", ";", "\t", "|")),
+		validation.Field(&m.DecimalMark, validation.In(DecimalMarkPoint, DecimalMarkComma)),
+		validation.Field(&m.Line, validation.Required),
+	)
+}
+
+// Validate ensures the line mapping contains the required columns.
+func (lm *LineMapping) Validate() error {
+	return validation.ValidateStruct(lm,
+		validation.Field(&lm.ItemName, validation.Required),
+		validation.Field(&lm.Price, validation.Required),
+		validation.Field(&lm.TaxRate,
+			validation.When(lm.TaxCategory == "", validation.Empty),
+		),
+		validation.Field(&lm.TaxPercent,
+			validation.When(lm.TaxCategory == "", validation.Empty),
+		),
+		validation.Field(&lm.TaxExt,
+			validation.When(lm.TaxCategory == "", validation.Empty),
+		),
+	)
+}
+
+// Read reads the header and rows from the CSV data provided and groups
+// them into partial invoices, in the order they first appear.
+func (m *Mapping) Read(r io.Reader) ([]*Group, error) {
+	rd := csv.NewReader(r)
+	if m.Comma != "" {
+		rd.Comma = rune(m.Comma[0])
+	}
+	rd.FieldsPerRecord = -1
+	rd.TrimLeadingSpace = true
+
+	header, err := rd.Read()
+	if err == io.EOF {
+		return nil, errors.New("missing header row")
+	}
+	if err != nil {
+		return nil, fmt.Errorf("reading header: %w", err)
+	}
+	cols := make(map[string]int, len(header))
+	for i, name := range header {
+		if i == 0 {
+			// spreadsheets often add a byte order mark
+			name = strings.TrimPrefix(name, "\ufeff")
+		}
+		cols[strings.TrimSpace(name)] = i
+	}
+	for _, name := range m.columns() {
+		if _, ok := cols[name]; !ok {
+			return nil, fmt.Errorf("column '%s' not found", name)
+		}
+	}
+
+	var groups []*Group
+	index := make(map[string]*Group)
+	for {
+		rec, err := rd.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("reading rows: %w", err)
+		}
+		line, _ := rd.FieldPos(0)
+		row := &row{rec: rec, cols: cols, mark: m.DecimalMark}
+
+		key := row.cell(m.Key)
+		if m.Key != "" && key == "" {
+			return nil, fmt.Errorf("line %d: missing key", line)
+		}
+		g, ok := index[key]
+		if !ok {
+			g = &Group{
+				Key: key,
+				Data: map[string]any{
+					"$schema": schema.Lookup(&bill.Invoice{}).String(),
+				},
+			}
+			index[key] = g
+			groups = append(groups, g)
+		}
+		if err := g.merge(m, row); err != nil {
+			return nil, fmt.Errorf("line %d: %w", line, err)
+		}
+	}
+	if len(groups) == 0 {
+		return nil, errors.New("no rows")
+	}
+	return groups, nil
+}
+
+// Invoice provides the group's data as an invoice.
+func (g *Group) Invoice() (*bill.Invoice, error) {
+	data, err := json.Marshal(g.Data)
+	if err != nil {
+		return nil, err
+	}
+	inv := new(bill.Invoice)
+	if err := json.Unmarshal(data, inv); err != nil {
+		return nil, err
+	}
+	return inv, nil
+}
+
+func (g *Group) merge(m *Mapping, r *row) error {
+	paths := make([]string, 0, len(m.Invoice))
+	for path := range m.Invoice {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+	for _, path := range paths {
+		if v := r.cell(m.Invoice[path]); v != "" {
+			if err := setPath(g.Data, path, v); err != nil {
+				return err
+			}
+		}
+	}
+
+	line, err := m.Line.data(r)
+	if err != nil {
+		return err
+	}
+	lines, _ := g.Data["lines"].([]any)
+	g.Data["lines"] = append(lines, line)
+	return nil
+}
+
+// setPath sets the value at the dotted path, ensuring any value already
+// set by a previous row is the same.
+func setPath(data map[string]any, path, value string) error {
+	keys := strings.Split(path, ".")
+	for _, k := range keys[:len(keys)-1] {
+		next, ok := data[k]
+		if !ok {
+			next = make(map[string]any)
+			data[k] = next
+		}
+		if data, ok = next.(map[string]any); !ok {
+			return fmt.Errorf("invoice field '%s' conflicts with '%s'", path, k)
+		}
+	}
+	k := keys[len(keys)-1]
+	if prev, ok := data[k]; ok && prev != value {
+		return fmt.Errorf("invoice field '%s' differs from previous rows", path)
+	}
+	data[k] = value
+	return nil
+}
+
+func (lm *LineMapping) data(r *row) (map[string]any, error) {
+	name := r.cell(lm.ItemName)
+	if name == "" {
+		return nil, errors.New("missing item name")
+	}
+	price := r.cell(lm.Price)
+	if price == "" {
+		return nil, errors.New("missing price")
+	}
+	item := map[string]any{
+		"name":  name,
+		"price": r.number(price),
+	}
+	setCell(item, "ref", r.cell(lm.ItemRef))
+	setCell(item, "description", r.cell(lm.ItemDescription))
+	setCell(item, "unit", r.cell(lm.Unit))
+	if ext := r.ext(lm.ItemExt); len(ext) > 0 {
+		item["ext"] = ext
+	}
+
+	quantity := "1"
+	if q := r.cell(lm.Quantity); q != "" {
+		quantity = r.number(q)
+	}
+	line := map[string]any{
+		"quantity": quantity,
+		"item":     item,
+	}
+
+	if cat := r.cell(lm.TaxCategory); cat != "" {
+		combo := map[string]any{
+			"cat": cat,
+		}
+		setCell(combo, "rate", r.cell(lm.TaxRate))
+		if p := r.cell(lm.TaxPercent); p != "" {
+			combo["percent"] = r.number(strings.TrimSuffix(p, "%")) + "%"
+		}
+		if ext := r.ext(lm.TaxExt); len(ext) > 0 {
+			combo["ext"] = ext
+		}
+		line["taxes"] = []any{combo}
+	}
+	return line, nil
+}
+
+// columns provides the names of all the columns used by the mapping.
+func (m *Mapping) columns() []string {
+	lm := m.Line
+	list := []string{
+		m.Key,
+		lm.ItemName, lm.ItemRef, lm.ItemDescription,
+		lm.Quantity, lm.Price, lm.Unit,
+		lm.TaxCategory, lm.TaxRate, lm.TaxPercent,
+	}
+	for _, name := range m.Invoice {
+		list = append(list, name)
+	}
+	for _, name := range lm.ItemExt {
+		list = append(list, name)
+	}
+	for _, name := range lm.TaxExt {
+		list = append(list, name)
+	}
+	out := make([]string, 0, len(list))
+	for _, name := range list {
+		if name != "" {
+			out = append(out, name)
+		}
+	}
+	sort.Strings(out)
+	return out
+}
+
+func setCell(data map[string]any, key, value string) {
+	if value != "" {
+		data[key] = value
+	}
+}
+
+type row struct {
+	rec  []string
+	cols map[string]int
+	mark string
+}
+
+// cell provides the trimmed value of the named column, or an empty
+// string if the row is too short.
+func (r *row) cell(name string) string {
+	if name == "" {
+		return ""
+	}
+	i := r.cols[name]
+	if i >= len(r.rec) {
+		return ""
+	}
+	return strings.TrimSpace(r.rec[i])
+}
+
+// number normalizes a numeric value so that it uses a point as the
+// decimal mark without any thousands separators.
+func (r *row) number(v string) string {
+	v = strings.ReplaceAll(v, " ", "")
+	if r.mark == DecimalMarkComma {
+		v = strings.ReplaceAll(v, ".", "")
+		return strings.ReplaceAll(v, ",", ".")
+	}
+	return strings.ReplaceAll(v, ",", "")
+}
+
+func (r *row) ext(cols map[cbc.Key]string) map[string]any {
+	ext := make(map[string]any)
+	for k, name := range cols {
+		setCell(ext, k.String(), r.cell(name))
+	}
+	return ext
+}
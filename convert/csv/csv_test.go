@@ -0,0 +1,182 @@
+package csv_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/invopop/gobl/cbc"
+	"github.com/invopop/gobl/convert/csv"
+	"github.com/invopop/gobl/org"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testMapping = `
+key: Invoice
+invoice:
+  code: Invoice
+  issue_date: Date
+  customer.name: Customer
+line:
+  item_name: Description
+  quantity: Qty
+  price: Price
+  unit: Unit
+  tax_category: Tax
+  tax_rate: Rate
+  item_ext:
+    eu-intrastat-cn: CN
+`
+
+const testRows = "Invoice,Date,Customer,Description,Qty,Price,Unit,Tax,Rate,CN\n" +
+	"INV-1,2024-03-01,Acme,Development,10,90.00,h,VAT,standard,\n" +
+	"INV-2,2024-03-02,Other,Widgets,2,\"1,250.50\",,VAT,reduced,84713000\n" +
+	"INV-1,2024-03-01,Acme,Support,,20.00,,,,\n"
+
+func TestParseMapping(t *testing.T) {
+	m, err := csv.ParseMapping([]byte(testMapping))
+	require.NoError(t, err)
+	assert.Equal(t, "Invoice", m.Key)
+	assert.Equal(t, "Customer", m.Invoice["customer.name"])
+	assert.Equal(t, "Description", m.Line.ItemName)
+	assert.Equal(t, "CN", m.Line.ItemExt["eu-intrastat-cn"])
+
+	t.Run("json", func(t *testing.T) {
+		m, err := csv.ParseMapping([]byte(`{"line":{"item_name":"Name","price":"Price"}}`))
+		require.NoError(t, err)
+		assert.Equal(t, "Price", m.Line.Price)
+	})
+	t.Run("missing line", func(t *testing.T) {
+		_, err := csv.ParseMapping([]byte(`key: Invoice`))
+		assert.ErrorContains(t, err, "invalid mapping: line: cannot be blank")
+	})
+	t.Run("missing price", func(t *testing.T) {
+		_, err := csv.ParseMapping([]byte(`line: {item_name: Name}`))
+		assert.ErrorContains(t, err, "line: (price: cannot be blank.)")
+	})
+	t.Run("rate without category", func(t *testing.T) {
+		_, err := csv.ParseMapping([]byte(`line: {item_name: Name, price: Price, tax_rate: Rate}`))
+		assert.ErrorContains(t, err, "tax_rate: must be blank")
+	})
+	t.Run("invalid comma", func(t *testing.T) {
+		_, err := csv.ParseMapping([]byte(`{comma: "::", line: {item_name: Name, price: Price}}`))
+		assert.ErrorContains(t, err, "comma: must be a valid value")
+	})
+	t.Run("invalid yaml", func(t *testing.T) {
+		_, err := csv.ParseMapping([]byte(`line: [`))
+		assert.ErrorContains(t, err, "parsing mapping")
+	})
+}
+
+func TestRead(t *testing.T) {
+	m, err := csv.ParseMapping([]byte(testMapping))
+	require.NoError(t, err)
+
+	groups, err := m.Read(strings.NewReader("\ufeff" + testRows))
+	require.NoError(t, err)
+	require.Len(t, groups, 2)
+	assert.Equal(t, "INV-1", groups[0].Key)
+	assert.Equal(t, "INV-2", groups[1].Key)
+	assert.Equal(t, "https://gobl.org/draft-0/bill/invoice", groups[0].Data["$schema"])
+
+	inv, err := groups[0].Invoice()
+	require.NoError(t, err)
+	assert.Equal(t, "INV-1", inv.Code.String())
+	assert.Equal(t, "2024-03-01", inv.IssueDate.String())
+	assert.Equal(t, "Acme", inv.Customer.Name)
+	require.Len(t, inv.Lines, 2)
+	assert.Equal(t, "Development", inv.Lines[0].Item.Name)
+	assert.Equal(t, "10", inv.Lines[0].Quantity.String())
+	assert.Equal(t, "90.00", inv.Lines[0].Item.Price.String())
+	assert.Equal(t, org.UnitHour, inv.Lines[0].Item.Unit)
+	assert.Equal(t, cbc.Code("VAT"), inv.Lines[0].Taxes[0].Category)
+	assert.Equal(t, cbc.Key("standard"), inv.Lines[0].Taxes[0].Rate)
+	assert.Nil(t, inv.Lines[0].Item.Ext)
+	assert.Equal(t, "1", inv.Lines[1].Quantity.String())
+	assert.Empty(t, inv.Lines[1].Taxes)
+
+	inv, err = groups[1].Invoice()
+	require.NoError(t, err)
+	assert.Equal(t, "1250.50", inv.Lines[0].Item.Price.String())
+	assert.Equal(t, "84713000", inv.Lines[0].Item.Ext["eu-intrastat-cn"].String())
+
+	t.Run("without key", func(t *testing.T) {
+		m, err := csv.ParseMapping([]byte(`line: {item_name: Description, price: Price}`))
+		require.NoError(t, err)
+		groups, err := m.Read(strings.NewReader(testRows))
+		require.NoError(t, err)
+		require.Len(t, groups, 1)
+		assert.Len(t, groups[0].Data["lines"], 3)
+	})
+
+	t.Run("decimal comma", func(t *testing.T) {
+		m, err := csv.ParseMapping([]byte(`
+comma: ";"
+decimal_mark: ","
+line: {item_name: Name, quantity: Qty, price: Price, tax_category: Tax, tax_percent: Percent}
+`))
+		require.NoError(t, err)
+		groups, err := m.Read(strings.NewReader("Name;Qty;Price;Tax;Percent\nWidget;1,5;1.234,56;VAT;21\n"))
+		require.NoError(t, err)
+		inv, err := groups[0].Invoice()
+		require.NoError(t, err)
+		assert.Equal(t, "1.5", inv.Lines[0].Quantity.String())
+		assert.Equal(t, "1234.56", inv.Lines[0].Item.Price.String())
+		assert.Equal(t, "21%", inv.Lines[0].Taxes[0].Percent.String())
+	})
+
+	t.Run("errors", func(t *testing.T) {
+		tests := []struct {
+			name string
+			data string
+			err  string
+		}{
+			{
+				name: "empty",
+				data: "",
+				err:  "missing header row",
+			},
+			{
+				name: "no rows",
+				data: "Invoice,Date,Customer,Description,Qty,Price,Unit,Tax,Rate,CN\n",
+				err:  "no rows",
+			},
+			{
+				name: "missing column",
+				data: "Invoice,Description,Price\nINV-1,Development,10\n",
+				err:  "column 'CN' not found",
+			},
+			{
+				name: "missing key",
+				data: testRows + ",2024-03-01,Acme,Development,10,90.00,h,VAT,standard,\n",
+				err:  "line 5: missing key",
+			},
+			{
+				name: "missing item name",
+				data: testRows + "INV-1,2024-03-01,Acme,,10,90.00,h,VAT,standard,\n",
+				err:  "line 5: missing item name",
+			},
+			{
+				name: "missing price",
+				data: testRows + "INV-1,2024-03-01,Acme,Development,10,,h,VAT,standard,\n",
+				err:  "line 5: missing price",
+			},
+			{
+				name: "different values",
+				data: testRows + "INV-1,2024-03-05,Acme,Development,10,90.00,h,VAT,standard,\n",
+				err:  "line 5: invoice field 'issue_date' differs from previous rows",
+			},
+			{
+				name: "invalid quotes",
+				data: testRows + "INV-1,\"2024",
+				err:  "reading rows",
+			},
+		}
+		for _, tt := range tests {
+			t.Run(tt.name, func(t *testing.T) {
+				_, err := m.Read(strings.NewReader(tt.data))
+				assert.ErrorContains(t, err, tt.err)
+			})
+		}
+	})
+}
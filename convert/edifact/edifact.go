@@ -0,0 +1,375 @@
+// Package edifact converts GOBL invoices to and from UN/EDIFACT INVOIC
+// D.96A messages, as still required by many retail and automotive
+// trading partners. Codes are mapped using the same UNTDID catalogues as
+// the EN 16931 converters.
+package edifact
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/invopop/gobl/convert/internal/semantic"
+)
+
+// Message type identification used in the UNH segment.
+const (
+	MessageType    = "INVOIC"
+	MessageVersion = "D"
+	MessageRelease = "96A"
+	MessageAgency  = "UN"
+)
+
+// Syntax identifiers of the supported character sets.
+const (
+	SyntaxUNOB = "UNOB" // ASCII
+	SyntaxUNOC = "UNOC" // ISO 8859-1 (Latin-1)
+	SyntaxUNOW = "UNOW" // UTF-8, requires syntax version 4
+)
+
+// Default syntax identifier and version of interchanges, supporting the
+// UTF-8 character set so that any name or address can be represented.
+const (
+	DefaultSyntaxID      = SyntaxUNOW
+	DefaultSyntaxVersion = "4"
+)
+
+// Identification code qualifiers used for interchange parties.
+const (
+	QualifierGLN           = "14"
+	QualifierMutuallyAgree = "ZZZ"
+)
+
+// Default service characters, as announced in the UNA segment.
+const (
+	componentSeparator = ':'
+	elementSeparator   = '+'
+	decimalMark        = '.'
+	releaseCharacter   = '?'
+	segmentTerminator  = '\''
+)
+
+// ErrUnsupported is returned when the invoice contains data that cannot be
+// represented in an INVOIC message.
+var ErrUnsupported = semantic.ErrUnsupported
+
+// Element is a data element made of one or more components.
+type Element []string
+
+// Segment is a single segment identified by its tag.
+type Segment struct {
+	Tag      string
+	Elements []Element
+}
+
+// Interchange contains the details of the UNB interchange header and UNH
+// message header that wrap the message.
+type Interchange struct {
+	SyntaxID           string
+	SyntaxVersion      string
+	SenderID           string
+	SenderQualifier    string
+	RecipientID        string
+	RecipientQualifier string
+	// Date of preparation in YYMMDD format.
+	Date string
+	// Time of preparation in HHMM format.
+	Time string
+	// Reference is the interchange control reference.
+	Reference string
+	// MessageReference is the reference of the message in the interchange.
+	MessageReference string
+	// Association is the association assigned code of the message, such
+	// as "EAN008" for EANCOM.
+	Association string
+	// Test is true when the interchange is only intended for testing.
+	Test bool
+}
+
+// Document is a single INVOIC message with its interchange.
+type Document struct {
+	Interchange *Interchange
+	// Segments of the message, excluding the UNH and UNT service segments.
+	Segments []*Segment
+}
+
+// Get provides the component at the element and component positions,
+// starting at 0, or an empty string if not available.
+func (s *Segment) Get(element, component int) string {
+	if element >= len(s.Elements) {
+		return ""
+	}
+	e := s.Elements[element]
+	if component >= len(e) {
+		return ""
+	}
+	return e[component]
+}
+
+// Bytes provides the complete interchange, with each segment on a new
+// line, encoded using the character set of the syntax identifier. An error
+// is returned if any character cannot be represented.
+func (d *Document) Bytes() ([]byte, error) {
+	ic := d.Interchange
+	if ic == nil {
+		return nil, errors.New("missing interchange")
+	}
+	buf := new(bytes.Buffer)
+	buf.WriteString("UNA")
+	buf.WriteRune(componentSeparator)
+	buf.WriteRune(elementSeparator)
+	buf.WriteRune(decimalMark)
+	buf.WriteRune(releaseCharacter)
+	buf.WriteRune(' ')
+	buf.WriteRune(segmentTerminator)
+	buf.WriteByte('\n')
+
+	unb := &Segment{Tag: "UNB", Elements: []Element{
+		{ic.SyntaxID, ic.SyntaxVersion},
+		{ic.SenderID, ic.SenderQualifier},
+		{ic.RecipientID, ic.RecipientQualifier},
+		{ic.Date, ic.Time},
+		{ic.Reference},
+	}}
+	if ic.Test {
+		unb.Elements = append(unb.Elements, nil, nil, nil, nil, nil, Element{"1"})
+	}
+	writeSegment(buf, unb)
+	writeSegment(buf, &Segment{Tag: "UNH", Elements: []Element{
+		{ic.MessageReference},
+		{MessageType, MessageVersion, MessageRelease, MessageAgency, ic.Association},
+	}})
+	for _, s := range d.Segments {
+		writeSegment(buf, s)
+	}
+	writeSegment(buf, &Segment{Tag: "UNT", Elements: []Element{
+		{strconv.Itoa(len(d.Segments) + 2)},
+		{ic.MessageReference},
+	}})
+	writeSegment(buf, &Segment{Tag: "UNZ", Elements: []Element{
+		{"1"},
+		{ic.Reference},
+	}})
+	return encode(ic.SyntaxID, buf.Bytes())
+}
+
+// Parse reads an interchange containing a single INVOIC message. Data using
+// the Latin-1 character set is converted to UTF-8.
+func Parse(data []byte) (*Document, error) {
+	if syntaxID(data) == SyntaxUNOC {
+		data = decodeLatin1(data)
+	}
+	segs, err := split(data)
+	if err != nil {
+		return nil, err
+	}
+	if len(segs) == 0 || segs[0].Tag != "UNB" {
+		return nil, errors.New("missing UNB interchange header")
+	}
+	unb := segs[0]
+	d := &Document{
+		Interchange: &Interchange{
+			SyntaxID:           unb.Get(0, 0),
+			SyntaxVersion:      unb.Get(0, 1),
+			SenderID:           unb.Get(1, 0),
+			SenderQualifier:    unb.Get(1, 1),
+			RecipientID:        unb.Get(2, 0),
+			RecipientQualifier: unb.Get(2, 1),
+			Date:               unb.Get(3, 0),
+			Time:               unb.Get(3, 1),
+			Reference:          unb.Get(4, 0),
+			Test:               unb.Get(10, 0) == "1",
+		},
+	}
+	segs = segs[1:]
+	if len(segs) == 0 || segs[0].Tag != "UNH" {
+		return nil, errors.New("missing UNH message header")
+	}
+	unh := segs[0]
+	if t := unh.Get(1, 0); t != MessageType {
+		return nil, fmt.Errorf("unsupported message '%s'", t)
+	}
+	d.Interchange.MessageReference = unh.Get(0, 0)
+	d.Interchange.Association = unh.Get(1, 4)
+
+	for i, s := range segs[1:] {
+		switch s.Tag {
+		case "UNT":
+			if n, _ := strconv.Atoi(s.Get(0, 0)); n != i+2 {
+				return nil, fmt.Errorf("message contains %d segments, expected %s", i+2, s.Get(0, 0))
+			}
+			if rest := segs[i+2:]; len(rest) != 1 || rest[0].Tag != "UNZ" {
+				return nil, errors.New("only one message per interchange is supported")
+			}
+			return d, nil
+		case "UNH", "UNZ":
+			return nil, fmt.Errorf("unexpected %s segment", s.Tag)
+		}
+		d.Segments = append(d.Segments, s)
+	}
+	return nil, errors.New("missing UNT message trailer")
+}
+
+func (d *Document) add(tag string, elements ...Element) *Segment {
+	s := &Segment{Tag: tag, Elements: elements}
+	d.Segments = append(d.Segments, s)
+	return s
+}
+
+// writeSegment adds the segment to the buffer, removing any trailing
+// empty components and elements, and escaping service characters.
+func writeSegment(buf *bytes.Buffer, s *Segment) {
+	buf.WriteString(s.Tag)
+	elements := s.Elements
+	for len(elements) > 0 && isEmpty(elements[len(elements)-1]) {
+		elements = elements[:len(elements)-1]
+	}
+	for _, e := range elements {
+		buf.WriteRune(elementSeparator)
+		for len(e) > 0 && e[len(e)-1] == "" {
+			e = e[:len(e)-1]
+		}
+		for i, c := range e {
+			if i > 0 {
+				buf.WriteRune(componentSeparator)
+			}
+			buf.WriteString(escape(c))
+		}
+	}
+	buf.WriteRune(segmentTerminator)
+	buf.WriteByte('\n')
+}
+
+func isEmpty(e Element) bool {
+	for _, c := range e {
+		if c != "" {
+			return false
+		}
+	}
+	return true
+}
+
+func escape(s string) string {
+	if !strings.ContainsAny(s, string([]rune{componentSeparator, elementSeparator, releaseCharacter, segmentTerminator})) {
+		return s
+	}
+	var sb strings.Builder
+	for _, r := range s {
+		switch r {
+		case componentSeparator, elementSeparator, releaseCharacter, segmentTerminator:
+			sb.WriteRune(releaseCharacter)
+		}
+		sb.WriteRune(r)
+	}
+	return sb.String()
+}
+
+// encode converts the UTF-8 data into the character set of the syntax
+// identifier.
+func encode(id string, data []byte) ([]byte, error) {
+	var limit rune
+	switch id {
+	case SyntaxUNOW:
+		return data, nil
+	case SyntaxUNOB:
+		limit = 0x7F
+	case SyntaxUNOC:
+		limit = 0xFF
+	default:
+		return nil, fmt.Errorf("unsupported syntax identifier '%s'", id)
+	}
+	out := make([]byte, 0, len(data))
+	for _, r := range string(data) {
+		if r > limit {
+			return nil, fmt.Errorf("character '%c' not supported by syntax identifier %s", r, id)
+		}
+		out = append(out, byte(r))
+	}
+	return out, nil
+}
+
+// decodeLatin1 converts data from the Latin-1 character set into UTF-8.
+func decodeLatin1(data []byte) []byte {
+	var sb strings.Builder
+	for _, b := range data {
+		sb.WriteRune(rune(b))
+	}
+	return []byte(sb.String())
+}
+
+// syntaxID extracts the syntax identifier from the raw UNB segment, which
+// will always use ASCII characters.
+func syntaxID(data []byte) string {
+	i := bytes.Index(data, []byte("UNB"))
+	if i < 0 || len(data) < i+8 {
+		return ""
+	}
+	return string(data[i+4 : i+8])
+}
+
+// split reads the segments from the data using the service characters
+// of the UNA segment, if present. Line breaks between segments are
+// ignored.
+func split(data []byte) ([]*Segment, error) {
+	comp, elem, rel, term := componentSeparator, elementSeparator, releaseCharacter, segmentTerminator
+	data = bytes.TrimLeft(data, "\ufeff \r\n\t")
+	if bytes.HasPrefix(data, []byte("UNA")) {
+		if len(data) < 9 {
+			return nil, errors.New("invalid UNA segment")
+		}
+		comp, elem, rel, term = rune(data[3]), rune(data[4]), rune(data[6]), rune(data[8])
+		data = data[9:]
+	}
+
+	var segs []*Segment
+	var cur *Segment
+	var val strings.Builder
+	released := false
+	flush := func() {
+		e := &cur.Elements[len(cur.Elements)-1]
+		*e = append(*e, val.String())
+		val.Reset()
+	}
+	for _, r := range string(data) {
+		if cur == nil {
+			if r == '\r' || r == '\n' || r == ' ' || r == '\t' {
+				continue
+			}
+			cur = new(Segment)
+		}
+		switch {
+		case released:
+			val.WriteRune(r)
+			released = false
+		case r == rel:
+			released = true
+		case r == term:
+			if cur.Elements == nil {
+				cur.Tag = val.String()
+				val.Reset()
+			} else {
+				flush()
+			}
+			segs = append(segs, cur)
+			cur = nil
+		case r == elem:
+			if cur.Elements == nil {
+				cur.Tag = val.String()
+				val.Reset()
+			} else {
+				flush()
+			}
+			cur.Elements = append(cur.Elements, Element{})
+		case r == comp && cur.Elements != nil:
+			flush()
+		default:
+			val.WriteRune(r)
+		}
+	}
+	if cur != nil {
+		return nil, errors.New("unterminated segment")
+	}
+	return segs, nil
+}
@@ -0,0 +1,288 @@
+package edifact_test
+
+import (
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/invopop/gobl/bill"
+	"github.com/invopop/gobl/convert/edifact"
+	"github.com/invopop/gobl/convert/internal/roundtrip"
+	"github.com/invopop/gobl/org"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var testInterchange = &edifact.Interchange{
+	SenderID:    "SENDER",
+	RecipientID: "RECIPIENT",
+}
+
+func segment(d *edifact.Document, tag, qualifier string) *edifact.Segment {
+	for _, s := range d.Segments {
+		if s.Tag == tag && s.Get(0, 0) == qualifier {
+			return s
+		}
+	}
+	return nil
+}
+
+func TestRoundTrip(t *testing.T) {
+	roundtrip.Run(t, func(t *testing.T, inv *bill.Invoice) (*bill.Invoice, error) {
+		doc, err := edifact.FromInvoice(inv, edifact.WithInterchange(testInterchange))
+		if err != nil {
+			return nil, err
+		}
+		data, err := doc.Bytes()
+		require.NoError(t, err)
+
+		doc2, err := edifact.Parse(data)
+		require.NoError(t, err)
+		data2, err := doc2.Bytes()
+		require.NoError(t, err)
+		assert.Equal(t, string(data), string(data2))
+		inv2, err := doc2.Invoice()
+		require.NoError(t, err)
+		require.NoError(t, inv2.Calculate())
+
+		payable := inv2.Totals.Payable
+		if inv2.Totals.Due != nil {
+			payable = *inv2.Totals.Due
+		}
+		assert.Equal(t, segment(doc, "MOA", "9").Get(0, 1), payable.String())
+		assert.Equal(t, segment(doc, "MOA", "77").Get(0, 1), inv2.Totals.TotalWithTax.String())
+		assert.Equal(t, segment(doc, "MOA", "176").Get(0, 1), inv2.Totals.Tax.String())
+		return inv2, nil
+	},
+		"es/invoice-es-es-freelance.json",       // retained taxes
+		"es/invoice-es-es-outlays.json",         // outlays
+		"es/invoice-es-es-vateqs-provider.json", // tax surcharges
+		"it/freelance.json",                     // retained taxes
+		"mx/retentions.json",                    // retained taxes
+	)
+}
+
+func TestFromInvoice(t *testing.T) {
+	inv := roundtrip.LoadInvoice(t, "../../examples/de/out/invoice-de-de.json")
+	require.NotNil(t, inv)
+	sum := inv.Totals.Sum.String()
+	doc, err := edifact.FromInvoice(inv)
+	require.NoError(t, err)
+	assert.Equal(t, sum, inv.Totals.Sum.String(), "source must not be modified")
+
+	ic := doc.Interchange
+	assert.Equal(t, edifact.DefaultSyntaxID, ic.SyntaxID)
+	assert.Equal(t, inv.Supplier.TaxID.String(), ic.SenderID)
+	assert.Equal(t, edifact.QualifierMutuallyAgree, ic.SenderQualifier)
+	assert.Equal(t, inv.Customer.TaxID.String(), ic.RecipientID)
+	assert.Equal(t, inv.IssueDate.String()[2:4], ic.Date[:2])
+	assert.Equal(t, "1", ic.MessageReference)
+
+	bgm := segment(doc, "BGM", "380")
+	require.NotNil(t, bgm)
+	assert.Equal(t, "SAMPLE-001", bgm.Get(1, 0))
+	assert.Equal(t, "EUR", segment(doc, "CUX", "2").Get(0, 1))
+	su := segment(doc, "NAD", "SU")
+	require.NotNil(t, su)
+	assert.Equal(t, inv.Supplier.Name, su.Get(3, 0))
+	assert.Equal(t, "DE", su.Get(8, 0))
+	lin := segment(doc, "LIN", "1")
+	require.NotNil(t, lin)
+
+	data, err := doc.Bytes()
+	require.NoError(t, err)
+	out := string(data)
+	assert.True(t, strings.HasPrefix(out, "UNA:+.? '\nUNB+UNOW:4+"+ic.SenderID+":ZZZ+"))
+	assert.Contains(t, out, "UNH+1+INVOIC:D:96A:UN'\n")
+	assert.Contains(t, out, "UNT+"+strconv.Itoa(len(doc.Segments)+2)+"+1'\n")
+	assert.True(t, strings.HasSuffix(out, "UNZ+1+"+ic.Reference+"'\n"))
+
+	t.Run("interchange", func(t *testing.T) {
+		doc, err := edifact.FromInvoice(inv, edifact.WithInterchange(&edifact.Interchange{
+			SenderID:           "4000001000005",
+			SenderQualifier:    edifact.QualifierGLN,
+			RecipientID:        "4000002000002",
+			RecipientQualifier: edifact.QualifierGLN,
+			Date:               "240301",
+			Time:               "1230",
+			Reference:          "REF1",
+			Association:        "EAN008",
+			Test:               true,
+		}))
+		require.NoError(t, err)
+		data, err := doc.Bytes()
+		require.NoError(t, err)
+		out := string(data)
+		assert.Contains(t, out, "UNB+UNOW:4+4000001000005:14+4000002000002:14+240301:1230+REF1++++++1'\n")
+		assert.Contains(t, out, "UNH+1+INVOIC:D:96A:UN:EAN008'\n")
+
+		doc2, err := edifact.Parse(data)
+		require.NoError(t, err)
+		assert.Equal(t, doc.Interchange, doc2.Interchange)
+	})
+
+	t.Run("latin-1", func(t *testing.T) {
+		inv := roundtrip.LoadInvoice(t, "../../examples/de/out/invoice-de-de.json")
+		inv.Supplier.Name = "Müller GmbH"
+		doc, err := edifact.FromInvoice(inv, edifact.WithInterchange(&edifact.Interchange{
+			SyntaxID:      edifact.SyntaxUNOC,
+			SyntaxVersion: "3",
+		}))
+		require.NoError(t, err)
+		data, err := doc.Bytes()
+		require.NoError(t, err)
+		assert.Contains(t, string(data), "UNB+UNOC:3+")
+		assert.Contains(t, string(data), "M\xfcller GmbH")
+		assert.NotContains(t, string(data), "Müller GmbH")
+
+		doc2, err := edifact.Parse(data)
+		require.NoError(t, err)
+		assert.Equal(t, "Müller GmbH", segment(doc2, "NAD", "SU").Get(3, 0))
+
+		inv.Supplier.Name = "Łódź Sp. z o.o."
+		doc, err = edifact.FromInvoice(inv, edifact.WithInterchange(&edifact.Interchange{
+			SyntaxID: edifact.SyntaxUNOC,
+		}))
+		require.NoError(t, err)
+		_, err = doc.Bytes()
+		assert.ErrorContains(t, err, "character 'Ł' not supported by syntax identifier UNOC")
+	})
+
+	t.Run("unsupported syntax", func(t *testing.T) {
+		doc, err := edifact.FromInvoice(inv, edifact.WithInterchange(&edifact.Interchange{
+			SyntaxID: "UNOD",
+		}))
+		require.NoError(t, err)
+		_, err = doc.Bytes()
+		assert.ErrorContains(t, err, "unsupported syntax identifier 'UNOD'")
+	})
+
+	t.Run("missing recipient", func(t *testing.T) {
+		inv := roundtrip.LoadInvoice(t, "../../examples/de/out/invoice-de-de.json")
+		inv.Customer = nil
+		_, err := edifact.FromInvoice(inv)
+		assert.ErrorContains(t, err, "interchange sender and recipient required")
+	})
+}
+
+func TestParse(t *testing.T) {
+	// uses alternative service characters and release characters
+	data := []byte(`UNA|^,! ~
+UNB^UNOC|3^4000001000005|14^4000002000002|14^240301|1200^42~
+UNH^ME1^INVOIC|D|96A|UN|EAN008~
+BGM^380^INV-1^9~
+DTM^137|20240301|102~
+FTX^AAI^^^Delivery!^ on time~
+RFF^ON|PO-77~
+DTM^171|20240215|102~
+NAD^SU^4000001000005||9^^Provide One GmbH^Dorfstrasse 1^Berlin^^10115^DE~
+FII^RB^DE89370400440532013000|Provide One GmbH^COBADEFFXXX|25|17~
+RFF^VA|DE111111125~
+NAD^BY^^^Sample Consumer^^Hamburg^^20095^DE~
+CUX^2|EUR|4~
+PAT^1~
+DTM^13|20240331|102~
+LIN^1^^4012345000009|SRV~
+IMD^F^^|||Consulting~
+QTY^47|2|HUR~
+MOA^203|100,00~
+PRI^AAA|50,00~
+TAX^7^VAT^^^|||19^S~
+ALC^A^^^^95|||Loyalty~
+PCD^3|10~
+MOA^204|10~
+UNS^S~
+MOA^9|107.10~
+UNT^25^ME1~
+UNZ^1^42~`)
+	doc, err := edifact.Parse(data)
+	require.NoError(t, err)
+	assert.Equal(t, "4000001000005", doc.Interchange.SenderID)
+	assert.Equal(t, "ME1", doc.Interchange.MessageReference)
+	assert.Equal(t, "EAN008", doc.Interchange.Association)
+	assert.Len(t, doc.Segments, 23)
+
+	inv, err := doc.Invoice()
+	require.NoError(t, err)
+	assert.Equal(t, "INV-1", inv.Code.String())
+	assert.Equal(t, "2024-03-01", inv.IssueDate.String())
+	assert.Equal(t, "Delivery^ on time", inv.Notes[0].Text)
+	assert.Equal(t, "PO-77", inv.Ordering.Purchases[0].Code.String())
+	assert.Equal(t, "2024-02-15", inv.Ordering.Purchases[0].IssueDate.String())
+	assert.Equal(t, "Provide One GmbH", inv.Supplier.Name)
+	assert.Equal(t, "DE111111125", inv.Supplier.TaxID.String())
+	assert.Equal(t, "4000001000005", inv.Supplier.Identities[0].Code.String())
+	assert.Equal(t, "Berlin", inv.Supplier.Addresses[0].Locality)
+	assert.Equal(t, "DE89370400440532013000", inv.Payment.Instructions.CreditTransfer[0].IBAN)
+	assert.Equal(t, "COBADEFFXXX", inv.Payment.Instructions.CreditTransfer[0].BIC)
+	assert.Equal(t, "2024-03-31", inv.Payment.Terms.DueDates[0].Date.String())
+	assert.Equal(t, "Sample Consumer", inv.Customer.Name)
+	require.Len(t, inv.Lines, 1)
+	l := inv.Lines[0]
+	assert.Equal(t, "Consulting", l.Item.Name)
+	assert.Equal(t, org.UnitHour, l.Item.Unit)
+	assert.Equal(t, "50.00", l.Item.Price.String())
+	assert.Equal(t, "4012345000009", l.Item.Identities[0].Code.String())
+	assert.Equal(t, "19%", l.Taxes[0].Percent.String())
+	assert.Equal(t, "10%", l.Discounts[0].Percent.String())
+
+	require.NoError(t, inv.Calculate())
+	assert.Equal(t, "107.10", inv.Totals.Payable.String())
+
+	t.Run("errors", func(t *testing.T) {
+		tests := []struct {
+			name string
+			data string
+			err  string
+		}{
+			{
+				name: "empty",
+				data: "",
+				err:  "missing UNB interchange header",
+			},
+			{
+				name: "unterminated",
+				data: "UNB+UNOC:3+A+B+240301:1200+1",
+				err:  "unterminated segment",
+			},
+			{
+				name: "missing header",
+				data: "UNB+UNOC:3+A+B+240301:1200+1'BGM+380'",
+				err:  "missing UNH message header",
+			},
+			{
+				name: "other message",
+				data: "UNB+UNOC:3+A+B+240301:1200+1'UNH+1+ORDERS:D:96A:UN'",
+				err:  "unsupported message 'ORDERS'",
+			},
+			{
+				name: "missing trailer",
+				data: "UNB+UNOC:3+A+B+240301:1200+1'UNH+1+INVOIC:D:96A:UN'BGM+380'",
+				err:  "missing UNT message trailer",
+			},
+			{
+				name: "segment count",
+				data: "UNB+UNOC:3+A+B+240301:1200+1'UNH+1+INVOIC:D:96A:UN'BGM+380'UNT+2+1'UNZ+1+1'",
+				err:  "message contains 3 segments, expected 2",
+			},
+			{
+				name: "multiple messages",
+				data: "UNB+UNOC:3+A+B+240301:1200+1'UNH+1+INVOIC:D:96A:UN'UNT+2+1'UNH+2+INVOIC:D:96A:UN'UNT+2+2'UNZ+2+1'",
+				err:  "only one message per interchange is supported",
+			},
+		}
+		for _, tt := range tests {
+			t.Run(tt.name, func(t *testing.T) {
+				_, err := edifact.Parse([]byte(tt.data))
+				assert.ErrorContains(t, err, tt.err)
+			})
+		}
+	})
+
+	t.Run("invalid date", func(t *testing.T) {
+		doc, err := edifact.Parse([]byte("UNB+UNOC:3+A+B+240301:1200+1'UNH+1+INVOIC:D:96A:UN'DTM+137:2024:102'UNT+3+1'UNZ+1+1'"))
+		require.NoError(t, err)
+		_, err = doc.Invoice()
+		assert.EqualError(t, err, "DTM segment: invalid date '2024'")
+	})
+}
@@ -0,0 +1,417 @@
+package edifact
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/invopop/gobl/bill"
+	"github.com/invopop/gobl/cal"
+	"github.com/invopop/gobl/catalogues/untdid"
+	"github.com/invopop/gobl/cbc"
+	"github.com/invopop/gobl/convert/internal/semantic"
+	"github.com/invopop/gobl/num"
+	"github.com/invopop/gobl/org"
+	"github.com/invopop/gobl/pay"
+	"github.com/invopop/gobl/tax"
+)
+
+// ISO 6523 schemes of the identities mapped to EDIFACT codes.
+const (
+	schemeGLN  = "0088"
+	schemeGTIN = "0160"
+)
+
+// Code list responsible agency and item number type used for GS1 codes.
+const (
+	agencyGS1      = "9"
+	itemNumberGTIN = "SRV"
+)
+
+// Maximum lengths of text components.
+const (
+	maxNameLength = 35
+	maxTextLength = 70
+)
+
+var referencePattern = regexp.MustCompile(`[^A-Za-z0-9]`)
+
+// Option is used to configure the conversion into EDIFACT.
+type Option func(*Interchange)
+
+// WithInterchange overrides the default interchange and message header
+// details with any of the values set in the interchange provided.
+func WithInterchange(in *Interchange) Option {
+	return func(ic *Interchange) {
+		set := func(dst *string, v string) {
+			if v != "" {
+				*dst = v
+			}
+		}
+		set(&ic.SyntaxID, in.SyntaxID)
+		set(&ic.SyntaxVersion, in.SyntaxVersion)
+		set(&ic.SenderID, in.SenderID)
+		set(&ic.SenderQualifier, in.SenderQualifier)
+		set(&ic.RecipientID, in.RecipientID)
+		set(&ic.RecipientQualifier, in.RecipientQualifier)
+		set(&ic.Date, in.Date)
+		set(&ic.Time, in.Time)
+		set(&ic.Reference, in.Reference)
+		set(&ic.MessageReference, in.MessageReference)
+		set(&ic.Association, in.Association)
+		ic.Test = ic.Test || in.Test
+	}
+}
+
+// FromInvoice converts the GOBL invoice into an INVOIC message. The
+// conversion is made from a copy of the invoice that is calculated with
+// the EN 16931 addon so that all the UNTDID codes are available, and with
+// any taxes included in prices removed.
+//
+// By default, the interchange sender and recipient are identified by the
+// GLN or tax ID of the supplier and customer, and prepared on the issue
+// date using a control reference derived from the invoice code.
+func FromInvoice(src *bill.Invoice, opts ...Option) (*Document, error) {
+	inv, err := semantic.Prepare(src)
+	if err != nil {
+		return nil, err
+	}
+	d := &Document{Interchange: newInterchange(inv)}
+	for _, opt := range opts {
+		opt(d.Interchange)
+	}
+	if d.Interchange.SenderID == "" || d.Interchange.RecipientID == "" {
+		return nil, fmt.Errorf("interchange sender and recipient required")
+	}
+
+	d.add("BGM",
+		Element{semantic.DocumentTypeCode(inv)},
+		Element{semantic.InvoiceID(inv.Series, inv.Code)},
+		Element{"9"}, // original
+	)
+	d.add("DTM", dateTime("137", inv.IssueDate))
+	if inv.OperationDate != nil {
+		d.add("DTM", dateTime("131", *inv.OperationDate))
+	}
+	if dd := inv.Delivery; dd != nil && dd.Date != nil {
+		d.add("DTM", dateTime("35", *dd.Date))
+	}
+	if o := inv.Ordering; o != nil && o.Period != nil {
+		d.add("DTM", Element{"263", formatDate(o.Period.Start) + formatDate(o.Period.End), "718"})
+	}
+	if p := inv.Payment; p != nil && p.Instructions != nil {
+		if code := p.Instructions.Ext[untdid.ExtKeyPaymentMeans]; code != "" {
+			d.add("PAI", Element{"", "", code.String()})
+		}
+	}
+	for _, n := range inv.Notes {
+		d.addText("AAI", n.Text)
+	}
+	if p := inv.Payment; p != nil && p.Terms != nil {
+		d.addText("AAB", p.Terms.Notes)
+	}
+
+	if o := inv.Ordering; o != nil {
+		d.addReferences("ON", o.Purchases)
+		d.addReferences("VN", o.Sales)
+		d.addReferences("DQ", o.Despatch)
+		d.addReferences("CT", o.Contracts)
+	}
+	d.addReferences("IV", inv.Preceding)
+
+	var transfers []*pay.CreditTransfer
+	payee := inv.Supplier
+	if p := inv.Payment; p != nil {
+		if p.Instructions != nil {
+			transfers = p.Instructions.CreditTransfer
+		}
+		if p.Payee != nil {
+			payee = p.Payee
+		}
+	}
+	d.addParty("SU", inv.Supplier, transfers, payee == inv.Supplier)
+	d.addParty("BY", inv.Customer, nil, false)
+	if dd := inv.Delivery; dd != nil {
+		d.addParty("DP", dd.Receiver, nil, false)
+	}
+	if payee != inv.Supplier {
+		d.addParty("PE", payee, transfers, true)
+	}
+
+	d.add("CUX", Element{"2", inv.Currency.String(), "4"})
+	if p := inv.Payment; p != nil && p.Terms != nil {
+		for _, dd := range p.Terms.DueDates {
+			if dd.Date != nil {
+				d.add("PAT", Element{"1"})
+				d.add("DTM", dateTime("13", *dd.Date))
+				break
+			}
+		}
+	}
+
+	for _, dis := range inv.Discounts {
+		d.addAllowanceCharge("A", dis.Ext[untdid.ExtKeyAllowance], dis.Reason, dis.Percent, "204", dis.Amount)
+		if len(dis.Taxes) > 0 {
+			d.addTax(dis.Taxes[0].Category, dis.Taxes[0].Ext, dis.Taxes[0].Percent)
+		}
+	}
+	for _, chr := range inv.Charges {
+		d.addAllowanceCharge("C", chr.Ext[untdid.ExtKeyCharge], chr.Reason, chr.Percent, "23", chr.Amount)
+		if len(chr.Taxes) > 0 {
+			d.addTax(chr.Taxes[0].Category, chr.Taxes[0].Ext, chr.Taxes[0].Percent)
+		}
+	}
+
+	for _, l := range inv.Lines {
+		d.addLine(l)
+	}
+
+	d.add("UNS", Element{"S"})
+	d.add("CNT", Element{"2", strconv.Itoa(len(inv.Lines))})
+	d.addTotals(inv)
+	return d, nil
+}
+
+func newInterchange(inv *bill.Invoice) *Interchange {
+	ic := &Interchange{
+		SyntaxID:         DefaultSyntaxID,
+		SyntaxVersion:    DefaultSyntaxVersion,
+		Date:             formatDate(inv.IssueDate)[2:],
+		Time:             "0000",
+		Reference:        controlReference(inv),
+		MessageReference: "1",
+	}
+	ic.SenderID, ic.SenderQualifier = interchangeID(inv.Supplier)
+	ic.RecipientID, ic.RecipientQualifier = interchangeID(inv.Customer)
+	return ic
+}
+
+// interchangeID provides the GLN of the party if available, or its tax
+// ID otherwise.
+func interchangeID(p *org.Party) (string, string) {
+	if p == nil {
+		return "", ""
+	}
+	if gln := partyGLN(p); gln != "" {
+		return gln, QualifierGLN
+	}
+	if p.TaxID != nil && p.TaxID.Code != cbc.CodeEmpty {
+		return p.TaxID.String(), QualifierMutuallyAgree
+	}
+	return "", ""
+}
+
+// controlReference provides a reference of up to 14 alphanumeric
+// characters based on the invoice's code.
+func controlReference(inv *bill.Invoice) string {
+	ref := referencePattern.ReplaceAllString(semantic.InvoiceID(inv.Series, inv.Code), "")
+	if len(ref) > 14 {
+		ref = ref[len(ref)-14:]
+	}
+	if ref == "" {
+		ref = "1"
+	}
+	return ref
+}
+
+func (d *Document) addText(qualifier, text string) {
+	if text == "" {
+		return
+	}
+	d.add("FTX", Element{qualifier}, nil, nil, Element(chunks(text, maxTextLength, 5)))
+}
+
+func (d *Document) addReferences(qualifier string, refs []*org.DocumentRef) {
+	for _, r := range refs {
+		d.add("RFF", Element{qualifier, semantic.InvoiceID(r.Series, r.Code)})
+		if r.IssueDate != nil {
+			d.add("DTM", dateTime("171", *r.IssueDate))
+		}
+	}
+}
+
+// addParty adds the NAD segment group of the party along with the
+// financial institution of any credit transfers.
+func (d *Document) addParty(qualifier string, p *org.Party, transfers []*pay.CreditTransfer, payee bool) {
+	if p == nil {
+		return
+	}
+	id := Element{}
+	if gln := partyGLN(p); gln != "" {
+		id = Element{gln, "", agencyGS1}
+	}
+	street := Element{}
+	var city, region, code, country string
+	if len(p.Addresses) > 0 {
+		a := p.Addresses[0]
+		if a.PostOfficeBox != "" {
+			street = append(street, "PO Box "+a.PostOfficeBox)
+		}
+		if s := strings.TrimSpace(a.Street + " " + a.Number); s != "" {
+			street = append(street, chunks(s, maxNameLength, 2)...)
+		}
+		if a.StreetExtra != "" {
+			street = append(street, a.StreetExtra)
+		}
+		if len(street) > 4 {
+			street = street[:4]
+		}
+		city, region, code, country = a.Locality, a.Region, a.Code.String(), a.Country.String()
+	}
+	if country == "" && p.TaxID != nil {
+		country = p.TaxID.Country.String()
+	}
+	d.add("NAD",
+		Element{qualifier},
+		id,
+		nil,
+		Element(chunks(p.Name, maxNameLength, 5)),
+		street,
+		Element{city},
+		Element{region},
+		Element{code},
+		Element{country},
+	)
+	if payee {
+		for _, ct := range transfers {
+			account := ct.IBAN
+			if account == "" {
+				account = ct.Number
+			}
+			d.add("FII", Element{"RB"}, Element{account, ct.Name}, Element{ct.BIC, "25", "17"})
+		}
+	}
+	if p.TaxID != nil && p.TaxID.Code != cbc.CodeEmpty {
+		d.add("RFF", Element{"VA", p.TaxID.String()})
+	}
+	if len(p.People) > 0 && p.People[0].Name != nil {
+		n := p.People[0].Name
+		d.add("CTA", Element{"IC"}, Element{"", strings.TrimSpace(n.Given + " " + n.Surname)})
+	}
+	for _, t := range p.Telephones {
+		d.add("COM", Element{t.Number, "TE"})
+	}
+	for _, e := range p.Emails {
+		d.add("COM", Element{e.Address, "EM"})
+	}
+}
+
+func (d *Document) addAllowanceCharge(indicator string, code tax.ExtValue, reason string, p *num.Percentage, qualifier string, amount num.Amount) {
+	d.add("ALC", Element{indicator}, nil, nil, nil, Element{code.String(), "", "", reason})
+	if p != nil {
+		d.add("PCD", Element{"3", semantic.PercentValue(p)})
+	}
+	d.add("MOA", Element{qualifier, amount.String()})
+}
+
+func (d *Document) addTax(cat cbc.Code, ext tax.Extensions, p *num.Percentage) {
+	code := semantic.TaxCategoryCode(ext, p)
+	d.add("TAX",
+		Element{"7"},
+		Element{cat.String()},
+		nil,
+		nil,
+		Element{"", "", "", semantic.PercentValue(p)},
+		Element{code},
+	)
+}
+
+func (d *Document) addLine(l *bill.Line) {
+	it := l.Item
+	lin := d.add("LIN", Element{strconv.Itoa(l.Index)})
+	for _, id := range it.Identities {
+		if semantic.IdentityScheme(id) == schemeGTIN {
+			lin.Elements = append(lin.Elements, nil, Element{id.Code.String(), itemNumberGTIN})
+			break
+		}
+	}
+	if it.Ref != "" {
+		d.add("PIA", Element{"1"}, Element{it.Ref, "SA"})
+	}
+	d.add("IMD", Element{"F"}, nil, Element{"", "", "", it.Name, it.Description})
+	d.add("QTY", Element{"47", l.Quantity.String(), semantic.UnitCode(it.Unit)})
+	d.add("MOA", Element{"203", l.Total.String()})
+	for _, n := range l.Notes {
+		d.addText("AAI", n.Text)
+	}
+	d.add("PRI", Element{"AAA", it.Price.String()})
+	if len(l.Taxes) > 0 {
+		c := l.Taxes[0]
+		d.addTax(c.Category, c.Ext, c.Percent)
+	} else {
+		d.addTax(tax.CategoryVAT, tax.Extensions{untdid.ExtKeyTaxCategory: "O"}, nil)
+	}
+	for _, dis := range l.Discounts {
+		d.addAllowanceCharge("A", dis.Ext[untdid.ExtKeyAllowance], dis.Reason, dis.Percent, "204", dis.Amount)
+	}
+	for _, chr := range l.Charges {
+		d.addAllowanceCharge("C", chr.Ext[untdid.ExtKeyCharge], chr.Reason, chr.Percent, "23", chr.Amount)
+	}
+}
+
+func (d *Document) addTotals(inv *bill.Invoice) {
+	t := inv.Totals
+	d.add("MOA", Element{"79", t.Sum.String()})
+	if t.Discount != nil {
+		d.add("MOA", Element{"260", t.Discount.String()})
+	}
+	if t.Charge != nil {
+		d.add("MOA", Element{"259", t.Charge.String()})
+	}
+	d.add("MOA", Element{"125", t.Total.String()})
+	d.add("MOA", Element{"176", t.Tax.String()})
+	d.add("MOA", Element{"77", t.TotalWithTax.String()})
+	if t.Rounding != nil {
+		d.add("MOA", Element{"165", t.Rounding.String()})
+	}
+	if t.Advances != nil {
+		d.add("MOA", Element{"113", t.Advances.String()})
+	}
+	payable := t.Payable
+	if t.Due != nil {
+		payable = *t.Due
+	}
+	d.add("MOA", Element{"9", payable.String()})
+	if t.Taxes == nil {
+		return
+	}
+	for _, ct := range t.Taxes.Categories {
+		for _, rt := range ct.Rates {
+			d.addTax(ct.Code, rt.Ext, rt.Percent)
+			d.add("MOA", Element{"125", rt.Base.String()})
+			d.add("MOA", Element{"124", rt.Amount.String()})
+		}
+	}
+}
+
+// partyGLN provides the GS1 Global Location Number of the party, if any.
+func partyGLN(p *org.Party) string {
+	for _, id := range p.Identities {
+		if semantic.IdentityScheme(id) == schemeGLN {
+			return id.Code.String()
+		}
+	}
+	return ""
+}
+
+func dateTime(qualifier string, d cal.Date) Element {
+	return Element{qualifier, formatDate(d), "102"}
+}
+
+func formatDate(d cal.Date) string {
+	return fmt.Sprintf("%04d%02d%02d", d.Year, d.Month, d.Day)
+}
+
+// chunks splits the text into at most n parts of the maximum length,
+// truncating anything that does not fit.
+func chunks(s string, size, n int) []string {
+	r := []rune(s)
+	var out []string
+	for len(r) > 0 && len(out) < n {
+		l := min(size, len(r))
+		out = append(out, string(r[:l]))
+		r = r[l:]
+	}
+	return out
+}
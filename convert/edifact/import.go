@@ -0,0 +1,447 @@
+package edifact
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/invopop/gobl/addons/eu/en16931"
+	"github.com/invopop/gobl/bill"
+	"github.com/invopop/gobl/cal"
+	"github.com/invopop/gobl/catalogues/untdid"
+	"github.com/invopop/gobl/cbc"
+	"github.com/invopop/gobl/convert/internal/semantic"
+	"github.com/invopop/gobl/currency"
+	"github.com/invopop/gobl/l10n"
+	"github.com/invopop/gobl/num"
+	"github.com/invopop/gobl/org"
+	"github.com/invopop/gobl/pay"
+	"github.com/invopop/gobl/tax"
+)
+
+// Invoice converts the INVOIC message into a GOBL invoice that uses the
+// EN 16931 addon so that UNTDID codes are kept as extensions. Segments
+// that are not recognized are ignored. The invoice is not calculated nor
+// validated so that any problems with the source data can be reviewed
+// afterwards.
+func (d *Document) Invoice() (*bill.Invoice, error) {
+	r := &reader{
+		inv: &bill.Invoice{
+			Addons: tax.WithAddons(en16931.V2017),
+			Type:   bill.InvoiceTypeStandard,
+		},
+	}
+	for _, s := range d.Segments {
+		if err := r.read(s); err != nil {
+			return nil, fmt.Errorf("%s segment: %w", s.Tag, err)
+		}
+	}
+	return r.inv, nil
+}
+
+// reader keeps track of the segment group being read, as the meaning of
+// many segments depends on their position in the message.
+type reader struct {
+	inv     *bill.Invoice
+	party   *org.Party
+	ref     *org.DocumentRef
+	line    *bill.Line
+	alc     *allowanceCharge
+	summary bool
+}
+
+// allowanceCharge points to the fields of the discount or charge being
+// read.
+type allowanceCharge struct {
+	percent **num.Percentage
+	amount  *num.Amount
+	taxes   *tax.Set
+}
+
+func (r *reader) read(s *Segment) error { //nolint:gocyclo
+	inv := r.inv
+	switch s.Tag {
+	case "BGM":
+		code := s.Get(0, 0)
+		inv.Type = semantic.InvoiceType(code, false)
+		inv.Code = cbc.Code(s.Get(1, 0))
+		if code != "" {
+			inv.Tax = &bill.Tax{
+				Ext: tax.Extensions{untdid.ExtKeyDocumentType: tax.ExtValue(code)},
+			}
+		}
+	case "DTM":
+		return r.date(s)
+	case "PAI":
+		if code := s.Get(0, 2); code != "" {
+			instr := r.instructions()
+			instr.Key = en16931.PaymentMeansKey(tax.ExtValue(code))
+			instr.Ext = tax.Extensions{untdid.ExtKeyPaymentMeans: tax.ExtValue(code)}
+		}
+	case "FTX":
+		if len(s.Elements) < 4 {
+			return nil
+		}
+		text := strings.Join(s.Elements[3], "")
+		switch {
+		case text == "":
+		case s.Get(0, 0) == "AAB":
+			r.terms().Notes = text
+		case r.line != nil:
+			r.line.Notes = append(r.line.Notes, &cbc.Note{Text: text})
+		default:
+			inv.Notes = append(inv.Notes, &cbc.Note{Text: text})
+		}
+	case "RFF":
+		r.reference(s)
+	case "NAD":
+		r.party = newParty(s)
+		switch s.Get(0, 0) {
+		case "SU":
+			inv.Supplier = r.party
+		case "BY":
+			inv.Customer = r.party
+		case "DP":
+			r.delivery().Receiver = r.party
+		case "PE":
+			r.payment().Payee = r.party
+		default:
+			r.party = nil
+		}
+	case "FII":
+		if s.Get(0, 0) != "RB" {
+			return nil
+		}
+		ct := &pay.CreditTransfer{
+			Name: s.Get(1, 1),
+			BIC:  s.Get(2, 0),
+		}
+		if account := s.Get(1, 0); semantic.IsIBAN(account) {
+			ct.IBAN = account
+		} else {
+			ct.Number = account
+		}
+		instr := r.instructions()
+		instr.CreditTransfer = append(instr.CreditTransfer, ct)
+	case "CTA":
+		if r.party != nil && s.Get(1, 1) != "" {
+			r.party.People = append(r.party.People, &org.Person{
+				Name: &org.Name{Given: s.Get(1, 1)},
+			})
+		}
+	case "COM":
+		if r.party == nil {
+			return nil
+		}
+		switch s.Get(0, 1) {
+		case "TE":
+			r.party.Telephones = append(r.party.Telephones, &org.Telephone{Number: s.Get(0, 0)})
+		case "EM":
+			r.party.Emails = append(r.party.Emails, &org.Email{Address: s.Get(0, 0)})
+		}
+	case "CUX":
+		r.party = nil
+		inv.Currency = currency.Code(s.Get(0, 1))
+	case "PAT":
+		r.party = nil
+	case "ALC":
+		r.party = nil
+		r.allowanceCharge(s)
+	case "PCD":
+		if r.alc == nil || s.Get(0, 0) != "3" {
+			return nil
+		}
+		p, err := semantic.ParsePercent(s.Get(0, 1))
+		if err != nil {
+			return fmt.Errorf("percent: %w", err)
+		}
+		*r.alc.percent = p
+	case "TAX":
+		return r.tax(s)
+	case "MOA":
+		return r.amount(s)
+	case "LIN":
+		r.party = nil
+		r.alc = nil
+		r.line = &bill.Line{Item: new(org.Item)}
+		if s.Get(2, 1) == itemNumberGTIN {
+			r.line.Item.Identities = []*org.Identity{semantic.Identity(s.Get(2, 0), schemeGTIN)}
+		}
+		inv.Lines = append(inv.Lines, r.line)
+	case "PIA":
+		if r.line != nil && s.Get(1, 1) == "SA" {
+			r.line.Item.Ref = s.Get(1, 0)
+		}
+	case "IMD":
+		if r.line != nil {
+			r.line.Item.Name = s.Get(2, 3)
+			r.line.Item.Description = s.Get(2, 4)
+		}
+	case "QTY":
+		if r.line == nil || s.Get(0, 0) != "47" {
+			return nil
+		}
+		q, err := parseAmount(s.Get(0, 1))
+		if err != nil {
+			return fmt.Errorf("quantity: %w", err)
+		}
+		r.line.Quantity = q
+		r.line.Item.Unit = semantic.Unit(s.Get(0, 2))
+	case "PRI":
+		if r.line == nil || s.Get(0, 0) != "AAA" {
+			return nil
+		}
+		p, err := parseAmount(s.Get(0, 1))
+		if err != nil {
+			return fmt.Errorf("price: %w", err)
+		}
+		r.line.Item.Price = p
+	case "UNS":
+		r.party = nil
+		r.line = nil
+		r.alc = nil
+		r.summary = true
+	}
+	return nil
+}
+
+func (r *reader) date(s *Segment) error {
+	qualifier, value, format := s.Get(0, 0), s.Get(0, 1), s.Get(0, 2)
+	if qualifier == "263" {
+		if format != "718" || len(value) != 16 {
+			return fmt.Errorf("unsupported period '%s'", value)
+		}
+		start, err := parseDate(value[:8])
+		if err != nil {
+			return err
+		}
+		end, err := parseDate(value[8:])
+		if err != nil {
+			return err
+		}
+		r.ordering().Period = &cal.Period{Start: start, End: end}
+		return nil
+	}
+	if format != "" && format != "102" {
+		return fmt.Errorf("unsupported date format '%s'", format)
+	}
+	dt, err := parseDate(value)
+	if err != nil {
+		return err
+	}
+	switch qualifier {
+	case "137":
+		r.inv.IssueDate = dt
+	case "131":
+		r.inv.OperationDate = &dt
+	case "35":
+		r.delivery().Date = &dt
+	case "171":
+		if r.ref != nil {
+			r.ref.IssueDate = &dt
+		}
+	case "13":
+		r.terms().DueDates = append(r.terms().DueDates, &pay.DueDate{
+			Date:    &dt,
+			Percent: num.NewPercentage(100, 2),
+		})
+	}
+	return nil
+}
+
+func (r *reader) reference(s *Segment) {
+	qualifier, code := s.Get(0, 0), s.Get(0, 1)
+	if qualifier == "VA" {
+		if r.party != nil {
+			r.party.TaxID = semantic.TaxIdentity(code)
+		}
+		return
+	}
+	ref := &org.DocumentRef{Code: cbc.Code(code)}
+	switch qualifier {
+	case "ON":
+		r.ordering().Purchases = append(r.ordering().Purchases, ref)
+	case "VN":
+		r.ordering().Sales = append(r.ordering().Sales, ref)
+	case "DQ":
+		r.ordering().Despatch = append(r.ordering().Despatch, ref)
+	case "CT":
+		r.ordering().Contracts = append(r.ordering().Contracts, ref)
+	case "IV":
+		r.inv.Preceding = append(r.inv.Preceding, ref)
+	default:
+		ref = nil
+	}
+	r.ref = ref
+}
+
+func (r *reader) allowanceCharge(s *Segment) {
+	code := tax.ExtValue(s.Get(4, 0))
+	reason := s.Get(4, 3)
+	charge := s.Get(0, 0) == "C"
+	switch {
+	case r.line != nil && charge:
+		chr := &bill.LineCharge{Key: en16931.ChargeKey(code), Reason: reason}
+		if code != "" {
+			chr.Ext = tax.Extensions{untdid.ExtKeyCharge: code}
+		}
+		r.line.Charges = append(r.line.Charges, chr)
+		r.alc = &allowanceCharge{percent: &chr.Percent, amount: &chr.Amount}
+	case r.line != nil:
+		dis := &bill.LineDiscount{Key: en16931.DiscountKey(code), Reason: reason}
+		if code != "" {
+			dis.Ext = tax.Extensions{untdid.ExtKeyAllowance: code}
+		}
+		r.line.Discounts = append(r.line.Discounts, dis)
+		r.alc = &allowanceCharge{percent: &dis.Percent, amount: &dis.Amount}
+	case charge:
+		chr := &bill.Charge{Key: en16931.ChargeKey(code), Reason: reason}
+		if code != "" {
+			chr.Ext = tax.Extensions{untdid.ExtKeyCharge: code}
+		}
+		r.inv.Charges = append(r.inv.Charges, chr)
+		r.alc = &allowanceCharge{percent: &chr.Percent, amount: &chr.Amount, taxes: &chr.Taxes}
+	default:
+		dis := &bill.Discount{Key: en16931.DiscountKey(code), Reason: reason}
+		if code != "" {
+			dis.Ext = tax.Extensions{untdid.ExtKeyAllowance: code}
+		}
+		r.inv.Discounts = append(r.inv.Discounts, dis)
+		r.alc = &allowanceCharge{percent: &dis.Percent, amount: &dis.Amount, taxes: &dis.Taxes}
+	}
+}
+
+func (r *reader) tax(s *Segment) error {
+	if r.summary || s.Get(0, 0) != "7" {
+		return nil
+	}
+	var set *tax.Set
+	switch {
+	case r.alc != nil:
+		set = r.alc.taxes
+	case r.line != nil:
+		set = &r.line.Taxes
+	}
+	if set == nil {
+		return nil
+	}
+	c, err := semantic.TaxCombo(cbc.Code(s.Get(1, 0)), s.Get(5, 0), s.Get(4, 3))
+	if err != nil {
+		return err
+	}
+	*set = append(*set, c)
+	return nil
+}
+
+func (r *reader) amount(s *Segment) error {
+	qualifier := s.Get(0, 0)
+	switch {
+	case r.summary && (qualifier == "113" || qualifier == "165"):
+	case !r.summary && r.alc != nil && (qualifier == "204" || qualifier == "23"):
+	default:
+		return nil
+	}
+	a, err := parseAmount(s.Get(0, 1))
+	if err != nil {
+		return fmt.Errorf("amount '%s': %w", qualifier, err)
+	}
+	switch qualifier {
+	case "113":
+		if !a.IsZero() {
+			pd := r.payment()
+			pd.Advances = append(pd.Advances, &pay.Advance{
+				Description: "Prepaid amount",
+				Amount:      a,
+			})
+		}
+	case "165":
+		r.totals().Rounding = &a
+	default:
+		*r.alc.amount = a
+	}
+	return nil
+}
+
+func (r *reader) payment() *bill.PaymentDetails {
+	if r.inv.Payment == nil {
+		r.inv.Payment = new(bill.PaymentDetails)
+	}
+	return r.inv.Payment
+}
+
+func (r *reader) instructions() *pay.Instructions {
+	pd := r.payment()
+	if pd.Instructions == nil {
+		pd.Instructions = new(pay.Instructions)
+	}
+	return pd.Instructions
+}
+
+func (r *reader) terms() *pay.Terms {
+	pd := r.payment()
+	if pd.Terms == nil {
+		pd.Terms = new(pay.Terms)
+	}
+	return pd.Terms
+}
+
+func (r *reader) ordering() *bill.Ordering {
+	if r.inv.Ordering == nil {
+		r.inv.Ordering = new(bill.Ordering)
+	}
+	return r.inv.Ordering
+}
+
+func (r *reader) delivery() *bill.DeliveryDetails {
+	if r.inv.Delivery == nil {
+		r.inv.Delivery = new(bill.DeliveryDetails)
+	}
+	return r.inv.Delivery
+}
+
+func (r *reader) totals() *bill.Totals {
+	if r.inv.Totals == nil {
+		r.inv.Totals = new(bill.Totals)
+	}
+	return r.inv.Totals
+}
+
+func newParty(s *Segment) *org.Party {
+	p := new(org.Party)
+	if len(s.Elements) > 3 {
+		p.Name = strings.Join(s.Elements[3], "")
+	}
+	if code := s.Get(1, 0); code != "" {
+		scheme := ""
+		if s.Get(1, 2) == agencyGS1 {
+			scheme = schemeGLN
+		}
+		p.Identities = []*org.Identity{semantic.Identity(code, scheme)}
+	}
+	a := &org.Address{
+		Street:      s.Get(4, 0),
+		StreetExtra: s.Get(4, 1),
+		Locality:    s.Get(5, 0),
+		Region:      s.Get(6, 0),
+		Code:        cbc.Code(s.Get(7, 0)),
+		Country:     l10n.ISOCountryCode(s.Get(8, 0)),
+	}
+	if a.Street != "" || a.Locality != "" || a.Code != cbc.CodeEmpty || a.Country != "" {
+		p.Addresses = []*org.Address{a}
+	}
+	return p
+}
+
+func parseDate(s string) (cal.Date, error) {
+	t, err := time.Parse("20060102", strings.TrimSpace(s))
+	if err != nil {
+		return cal.Date{}, fmt.Errorf("invalid date '%s'", s)
+	}
+	return cal.DateOf(t), nil
+}
+
+// parseAmount reads a numeric value, which may use either a point or a
+// comma as the decimal mark.
+func parseAmount(s string) (num.Amount, error) {
+	return num.AmountFromString(strings.ReplaceAll(strings.TrimSpace(s), ",", "."))
+}
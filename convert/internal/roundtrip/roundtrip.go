@@ -0,0 +1,142 @@
+// Package roundtrip contains the test helpers shared by the converters to
+// check that the GOBL invoice examples can be converted into another syntax
+// and back again without losing any of their key details.
+package roundtrip
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+
+	"github.com/invopop/gobl"
+	"github.com/invopop/gobl/bill"
+	"github.com/invopop/gobl/convert/internal/semantic"
+	"github.com/invopop/gobl/org"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// ExamplesPattern is used to find the example envelopes from the converter
+// package directories.
+const ExamplesPattern = "../../examples/*/out/*.json"
+
+// Func converts the invoice into the syntax and back again, performing any
+// syntax specific checks on the way, and provides the calculated result.
+// Errors should only be returned when the original invoice could not be
+// converted.
+type Func func(t *testing.T, inv *bill.Invoice) (*bill.Invoice, error)
+
+// LoadInvoice reads the envelope at the path provided and extracts the
+// invoice, or nil if the envelope contains another type of document.
+func LoadInvoice(t *testing.T, path string) *bill.Invoice {
+	t.Helper()
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	env := new(gobl.Envelope)
+	require.NoError(t, json.Unmarshal(data, env))
+	inv, ok := env.Extract().(*bill.Invoice)
+	if !ok {
+		return nil
+	}
+	return inv
+}
+
+// Run walks through the invoice examples, converting each one with the
+// function provided and comparing the parties, lines, and payment means
+// of the result with those of the original. Examples that the syntax
+// cannot represent must be listed as unsupported using their country
+// directory and file name, like "es/invoice-es-es-outlays.json", and are
+// expected to fail with an unsupported error.
+func Run(t *testing.T, convert Func, unsupported ...string) {
+	t.Helper()
+	files, err := filepath.Glob(ExamplesPattern)
+	require.NoError(t, err)
+	converted := 0
+	for _, f := range files {
+		inv := LoadInvoice(t, f)
+		if inv == nil {
+			continue
+		}
+		name := filepath.Base(filepath.Dir(filepath.Dir(f))) + "/" + filepath.Base(f)
+		t.Run(name, func(t *testing.T) {
+			inv2, err := convert(t, inv)
+			if slices.Contains(unsupported, name) {
+				assert.ErrorIs(t, err, semantic.ErrUnsupported)
+				return
+			}
+			require.NoError(t, err)
+			AssertInvoice(t, inv, inv2)
+			converted++
+		})
+	}
+	assert.NotZero(t, converted)
+}
+
+// AssertInvoice compares the key fields of the converted invoice with those
+// of the original.
+func AssertInvoice(t *testing.T, inv, inv2 *bill.Invoice) {
+	t.Helper()
+	assert.Equal(t, inv.Type, inv2.Type)
+	assert.Equal(t, fullCode(inv), fullCode(inv2), "code")
+	assert.Equal(t, inv.IssueDate, inv2.IssueDate)
+	assert.Equal(t, inv.Currency, inv2.Currency)
+
+	t.Run("supplier", func(t *testing.T) {
+		assertParty(t, inv.Supplier, inv2.Supplier)
+	})
+	t.Run("customer", func(t *testing.T) {
+		assertParty(t, inv.Customer, inv2.Customer)
+	})
+
+	require.Len(t, inv2.Lines, len(inv.Lines))
+	for i, l := range inv.Lines {
+		l2 := inv2.Lines[i]
+		assert.Equal(t, l.Item.Name, l2.Item.Name, "line %d name", i)
+		assert.Zero(t, l.Quantity.Compare(l2.Quantity), "line %d quantity %s, got %s", i, l.Quantity, l2.Quantity)
+		if l.Item.Unit != "" {
+			assert.Equal(t, l.Item.Unit, l2.Item.Unit, "line %d unit", i)
+		}
+		require.Len(t, l2.Taxes, len(l.Taxes), "line %d taxes", i)
+		for j, c := range l.Taxes {
+			assert.Equal(t, c.Category, l2.Taxes[j].Category, "line %d tax %d category", i, j)
+		}
+	}
+
+	if inv.Payment != nil && inv.Payment.Instructions != nil {
+		require.NotNil(t, inv2.Payment, "payment")
+		require.NotNil(t, inv2.Payment.Instructions, "payment instructions")
+		assert.Equal(t, inv.Payment.Instructions.Key, inv2.Payment.Instructions.Key)
+	}
+}
+
+func assertParty(t *testing.T, p, p2 *org.Party) {
+	t.Helper()
+	if p == nil {
+		return
+	}
+	require.NotNil(t, p2)
+	assert.Equal(t, p.Name, p2.Name)
+	if p.TaxID != nil && p.TaxID.Code != "" {
+		require.NotNil(t, p2.TaxID)
+		assert.Equal(t, p.TaxID.String(), p2.TaxID.String())
+	}
+	if len(p.Addresses) > 0 && p.Addresses[0] != nil {
+		require.NotEmpty(t, p2.Addresses, "addresses")
+		assert.Equal(t, p.Addresses[0].Locality, p2.Addresses[0].Locality)
+		assert.Equal(t, p.Addresses[0].Code, p2.Addresses[0].Code)
+		if p.Addresses[0].Country != "" {
+			assert.Equal(t, p.Addresses[0].Country, p2.Addresses[0].Country)
+		}
+	}
+}
+
+// fullCode joins the series and code, as most syntaxes only provide a single
+// identifier for the document.
+func fullCode(inv *bill.Invoice) string {
+	if inv.Series == "" {
+		return inv.Code.String()
+	}
+	return inv.Series.String() + "-" + inv.Code.String()
+}
@@ -0,0 +1,318 @@
+// Package semantic contains the helpers shared by the converters that map
+// GOBL invoices to and from syntaxes of the EN 16931 semantic model, such
+// as UBL and CII.
+package semantic
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/invopop/gobl/addons/de/xrechnung"
+	"github.com/invopop/gobl/addons/eu/en16931"
+	"github.com/invopop/gobl/addons/eu/peppol"
+	"github.com/invopop/gobl/bill"
+	"github.com/invopop/gobl/catalogues/iso"
+	"github.com/invopop/gobl/catalogues/untdid"
+	"github.com/invopop/gobl/cbc"
+	"github.com/invopop/gobl/l10n"
+	"github.com/invopop/gobl/num"
+	"github.com/invopop/gobl/org"
+	"github.com/invopop/gobl/tax"
+)
+
+// Specification identifiers used for the customization or guideline of
+// the document.
+const (
+	GuidelineEN16931   = "urn:cen.eu:en16931:2017"
+	GuidelineXRechnung = GuidelineEN16931 + "#compliant#urn:xeinkauf.de:kosit:xrechnung_3.0"
+	GuidelinePeppol    = GuidelineEN16931 + "#compliant#urn:fdc:peppol.eu:2017:poacc:billing:3.0"
+)
+
+// ProfilePeppol identifies the Peppol BIS Billing 3.0 business process.
+const ProfilePeppol = "urn:fdc:peppol.eu:2017:poacc:billing:01:1.0"
+
+// DefaultUnitCode is the UN/ECE code used for items without a unit.
+const DefaultUnitCode = "C62" // one
+
+// ErrUnsupported is returned when the invoice contains data that cannot be
+// represented using the EN 16931 model.
+var ErrUnsupported = errors.New("unsupported")
+
+// Tax categories that do not define a percentage.
+var noPercentCategories = []string{"E", "AE", "K", "G", "O"}
+
+var ibanPattern = regexp.MustCompile(`^[A-Z]{2}\d{2}`)
+
+var schemeIDPattern = regexp.MustCompile(`^\d{4}$`)
+
+// guidelines maps addons to the specification they follow, in order of
+// priority.
+var guidelines = []struct {
+	addon cbc.Key
+	id    string
+}{
+	{xrechnung.V3, GuidelineXRechnung},
+	{peppol.V3, GuidelinePeppol},
+	{en16931.V2017, GuidelineEN16931},
+}
+
+// Prepare makes a calculated copy of the invoice with the EN 16931 addon
+// and prices without taxes, and ensures it does not contain any data that
+// cannot be represented.
+func Prepare(src *bill.Invoice) (*bill.Invoice, error) {
+	data, err := json.Marshal(src)
+	if err != nil {
+		return nil, err
+	}
+	inv := new(bill.Invoice)
+	if err := json.Unmarshal(data, inv); err != nil {
+		return nil, err
+	}
+	if !slices.Contains(inv.GetAddons(), en16931.V2017) {
+		inv.SetAddons(append(inv.GetAddons(), en16931.V2017)...)
+	}
+	if err := inv.Calculate(); err != nil {
+		return nil, err
+	}
+	inv, err = inv.RemoveIncludedTaxes()
+	if err != nil {
+		return nil, err
+	}
+	if err := checkSupported(inv); err != nil {
+		return nil, err
+	}
+	return inv, nil
+}
+
+func checkSupported(inv *bill.Invoice) error {
+	t := inv.Totals
+	if t.Outlays != nil && !t.Outlays.IsZero() {
+		return fmt.Errorf("%w: outlays", ErrUnsupported)
+	}
+	if t.Taxes != nil {
+		for _, ct := range t.Taxes.Categories {
+			if ct.Retained {
+				return fmt.Errorf("%w: retained tax '%s'", ErrUnsupported, ct.Code)
+			}
+			for _, rt := range ct.Rates {
+				if rt.Surcharge != nil {
+					return fmt.Errorf("%w: tax surcharges", ErrUnsupported)
+				}
+			}
+		}
+	}
+	for _, l := range inv.Lines {
+		if len(l.Taxes) > 1 {
+			return fmt.Errorf("%w: line %d has multiple taxes", ErrUnsupported, l.Index)
+		}
+		if len(l.Taxes) == 1 && l.Taxes[0].Amount != nil {
+			return fmt.Errorf("%w: line %d has a fixed amount tax", ErrUnsupported, l.Index)
+		}
+	}
+	return nil
+}
+
+// Guideline provides the specification identifier of the most specific
+// addon used by the invoice.
+func Guideline(inv *bill.Invoice) string {
+	addons := inv.GetAddons()
+	for _, g := range guidelines {
+		if slices.Contains(addons, g.addon) {
+			return g.id
+		}
+	}
+	return GuidelineEN16931
+}
+
+// GuidelineAddon provides the key of the addon that best matches the
+// specification identifier, defaulting to EN 16931.
+func GuidelineAddon(id string) cbc.Key {
+	for _, g := range guidelines {
+		if strings.HasPrefix(id, g.id) {
+			return g.addon
+		}
+	}
+	return en16931.V2017
+}
+
+// Profile provides the business process identifier of the invoice, if
+// determined by any of its addons.
+func Profile(inv *bill.Invoice) string {
+	if slices.Contains(inv.GetAddons(), peppol.V3) {
+		return ProfilePeppol
+	}
+	return ""
+}
+
+// DocumentTypeCode provides the UNTDID 1001 code of the invoice.
+func DocumentTypeCode(inv *bill.Invoice) string {
+	if inv.Tax != nil {
+		if v := inv.Tax.Ext[untdid.ExtKeyDocumentType]; v != "" {
+			return v.String()
+		}
+	}
+	for _, kd := range bill.InvoiceTypes {
+		if kd.Key == inv.Type {
+			return kd.Map[bill.UNTDID1001Key].String()
+		}
+	}
+	return ""
+}
+
+// InvoiceType determines the invoice type from the UNTDID 1001 code.
+func InvoiceType(code string, creditNote bool) cbc.Key {
+	for _, kd := range bill.InvoiceTypes {
+		if kd.Map[bill.UNTDID1001Key].String() == code {
+			return kd.Key
+		}
+	}
+	if creditNote {
+		return bill.InvoiceTypeCreditNote
+	}
+	return bill.InvoiceTypeStandard
+}
+
+// TaxCategoryCode provides the UNTDID 5305 code from the extensions, or
+// determines it from the percent when not available.
+func TaxCategoryCode(ext tax.Extensions, p *num.Percentage) string {
+	if v := ext[untdid.ExtKeyTaxCategory]; v != "" {
+		return v.String()
+	}
+	switch {
+	case p == nil:
+		return "E"
+	case p.IsZero():
+		return "Z"
+	default:
+		return "S"
+	}
+}
+
+// TaxCombo builds the tax combo for the UNTDID 5305 category code and
+// percent, which is ignored for categories without rates.
+func TaxCombo(cat cbc.Code, code, percent string) (*tax.Combo, error) {
+	if cat == cbc.CodeEmpty {
+		cat = tax.CategoryVAT
+	}
+	c := &tax.Combo{
+		Category: cat,
+		Ext:      tax.Extensions{untdid.ExtKeyTaxCategory: tax.ExtValue(code)},
+	}
+	if slices.Contains(noPercentCategories, code) {
+		return c, nil
+	}
+	p, err := ParsePercent(percent)
+	if err != nil {
+		return nil, fmt.Errorf("tax percent: %w", err)
+	}
+	if p == nil {
+		zero := num.MakePercentage(0, 2)
+		p = &zero
+	}
+	c.Percent = p
+	return c, nil
+}
+
+// UnitCode provides the UN/ECE code for the unit.
+func UnitCode(u org.Unit) string {
+	if c := u.UNECE(); c != cbc.CodeEmpty {
+		return c.String()
+	}
+	return DefaultUnitCode
+}
+
+// Unit provides the GOBL unit for the UN/ECE code, which will be kept as is
+// if there is no equivalent.
+func Unit(code string) org.Unit {
+	if code == "" || code == DefaultUnitCode {
+		return org.UnitEmpty
+	}
+	for _, def := range org.UnitDefinitions {
+		if def.UNECE.String() == code {
+			return def.Unit
+		}
+	}
+	return org.Unit(code)
+}
+
+// TaxIdentity splits the VAT number into its country prefix and code. The
+// identity is not normalized nor validated.
+func TaxIdentity(id string) *tax.Identity {
+	id = strings.ToUpper(strings.TrimSpace(id))
+	if len(id) > 2 && isAlpha(id[:2]) {
+		return &tax.Identity{
+			Country: l10n.TaxCountryCode(id[:2]),
+			Code:    cbc.Code(id[2:]),
+		}
+	}
+	return &tax.Identity{Code: cbc.Code(id)}
+}
+
+// IdentityScheme provides the ISO 6523 scheme of the identity, or its
+// type when not available.
+func IdentityScheme(id *org.Identity) string {
+	if v := id.Ext[iso.ExtKeySchemeID]; v != "" {
+		return v.String()
+	}
+	return id.Type.String()
+}
+
+// Identity builds an identity from the code and scheme, which is stored
+// as an extension when it is an ISO 6523 code, or as the type otherwise.
+func Identity(code, scheme string) *org.Identity {
+	out := &org.Identity{Code: cbc.Code(code)}
+	if schemeIDPattern.MatchString(scheme) {
+		out.Ext = tax.Extensions{iso.ExtKeySchemeID: tax.ExtValue(scheme)}
+	} else if scheme != "" {
+		out.Type = cbc.Code(scheme)
+	}
+	return out
+}
+
+// IsIBAN returns true if the account number looks like an IBAN.
+func IsIBAN(account string) bool {
+	return ibanPattern.MatchString(account)
+}
+
+// ParsePercent reads a percentage provided without the symbol.
+func ParsePercent(s string) (*num.Percentage, error) {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return nil, nil
+	}
+	p, err := num.PercentageFromString(s + "%")
+	if err != nil {
+		return nil, err
+	}
+	return &p, nil
+}
+
+// PercentValue provides the percentage without the symbol, or an empty
+// string.
+func PercentValue(p *num.Percentage) string {
+	if p == nil {
+		return ""
+	}
+	return p.StringWithoutSymbol()
+}
+
+// InvoiceID joins the series and code of a document.
+func InvoiceID(series, code cbc.Code) string {
+	if series != cbc.CodeEmpty {
+		return series.String() + "-" + code.String()
+	}
+	return code.String()
+}
+
+func isAlpha(s string) bool {
+	for _, r := range s {
+		if r < 'A' || r > 'Z' {
+			return false
+		}
+	}
+	return true
+}
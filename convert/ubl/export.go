@@ -0,0 +1,494 @@
+package ubl
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/invopop/gobl/bill"
+	"github.com/invopop/gobl/catalogues/iso"
+	"github.com/invopop/gobl/catalogues/untdid"
+	"github.com/invopop/gobl/cbc"
+	"github.com/invopop/gobl/convert/internal/semantic"
+	"github.com/invopop/gobl/currency"
+	"github.com/invopop/gobl/num"
+	"github.com/invopop/gobl/org"
+	"github.com/invopop/gobl/pay"
+	"github.com/invopop/gobl/regimes/common"
+	"github.com/invopop/gobl/tax"
+)
+
+// Default codes used when the source data does not provide them.
+const (
+	defaultPaymentMeans = "1" // not defined
+	defaultCardNetwork  = "NA"
+	defaultOrderID      = "NA"
+	defaultTaxScheme    = "VAT"
+)
+
+// FromInvoice converts the GOBL invoice into a UBL Invoice, or CreditNote
+// for credit notes. The conversion is made from a copy of the invoice that
+// is calculated with the EN 16931 addon so that all the UNTDID codes are
+// available, and with any taxes included in prices removed.
+func FromInvoice(src *bill.Invoice) (*Document, error) {
+	inv, err := semantic.Prepare(src)
+	if err != nil {
+		return nil, err
+	}
+
+	cur := inv.Currency
+	d := &Document{
+		CACNamespace:         NamespaceCAC,
+		CBCNamespace:         NamespaceCBC,
+		CustomizationID:      semantic.Guideline(inv),
+		ProfileID:            semantic.Profile(inv),
+		ID:                   semantic.InvoiceID(inv.Series, inv.Code),
+		IssueDate:            inv.IssueDate.String(),
+		DocumentCurrencyCode: cur.String(),
+		Notes:                noteTexts(inv.Notes),
+	}
+	typeCode := semantic.DocumentTypeCode(inv)
+	if inv.Type.In(bill.InvoiceTypeCreditNote) {
+		d.XMLName.Local = rootCreditNote
+		d.Namespace = NamespaceCreditNote
+		d.CreditNoteTypeCode = typeCode
+	} else {
+		d.XMLName.Local = rootInvoice
+		d.Namespace = NamespaceInvoice
+		d.InvoiceTypeCode = typeCode
+		d.DueDate = dueDate(inv.Payment)
+		if inv.ValueDate != nil {
+			d.TaxPointDate = inv.ValueDate.String()
+		}
+	}
+
+	d.addOrdering(inv.Ordering)
+	for _, p := range inv.Preceding {
+		ref := &DocumentReference{ID: semantic.InvoiceID(p.Series, p.Code)}
+		if p.IssueDate != nil {
+			ref.IssueDate = p.IssueDate.String()
+		}
+		d.BillingReferences = append(d.BillingReferences, &BillingReference{InvoiceDocumentReference: ref})
+	}
+	d.AccountingSupplierParty = &PartyWrapper{Party: newParty(inv.Supplier)}
+	if inv.Customer != nil {
+		d.AccountingCustomerParty = &PartyWrapper{Party: newParty(inv.Customer)}
+	}
+	if inv.Payment != nil && inv.Payment.Payee != nil {
+		d.PayeeParty = newParty(inv.Payment.Payee)
+	}
+	d.Delivery = newDelivery(inv.Delivery)
+	d.addPayment(inv.Payment)
+
+	for _, dis := range inv.Discounts {
+		d.AllowanceCharges = append(d.AllowanceCharges, &AllowanceCharge{
+			ChargeIndicator:           false,
+			AllowanceChargeReasonCode: dis.Ext[untdid.ExtKeyAllowance].String(),
+			AllowanceChargeReason:     dis.Reason,
+			MultiplierFactorNumeric:   semantic.PercentValue(dis.Percent),
+			Amount:                    newAmount(dis.Amount, cur),
+			BaseAmount:                baseAmount(dis.Base, dis.Percent, inv.Totals.Sum, cur),
+			TaxCategory:               newTaxCategory(firstCombo(dis.Taxes)),
+		})
+	}
+	for _, chr := range inv.Charges {
+		d.AllowanceCharges = append(d.AllowanceCharges, &AllowanceCharge{
+			ChargeIndicator:           true,
+			AllowanceChargeReasonCode: chr.Ext[untdid.ExtKeyCharge].String(),
+			AllowanceChargeReason:     chr.Reason,
+			MultiplierFactorNumeric:   semantic.PercentValue(chr.Percent),
+			Amount:                    newAmount(chr.Amount, cur),
+			BaseAmount:                baseAmount(chr.Base, chr.Percent, inv.Totals.Sum, cur),
+			TaxCategory:               newTaxCategory(firstCombo(chr.Taxes)),
+		})
+	}
+
+	d.addTotals(inv)
+
+	for _, l := range inv.Lines {
+		line := newLine(l, cur)
+		if d.IsCreditNote() {
+			line.CreditedQuantity, line.InvoicedQuantity = line.InvoicedQuantity, nil
+			d.CreditNoteLines = append(d.CreditNoteLines, line)
+		} else {
+			d.InvoiceLines = append(d.InvoiceLines, line)
+		}
+	}
+
+	return d, nil
+}
+
+func (d *Document) addOrdering(o *bill.Ordering) {
+	if o == nil {
+		return
+	}
+	d.BuyerReference = o.Code.String()
+	if o.Period != nil {
+		d.InvoicePeriod = &Period{
+			StartDate: o.Period.Start.String(),
+			EndDate:   o.Period.End.String(),
+		}
+	}
+	if len(o.Purchases) > 0 || len(o.Sales) > 0 {
+		d.OrderReference = &OrderReference{ID: defaultOrderID}
+		if len(o.Purchases) > 0 {
+			d.OrderReference.ID = semantic.InvoiceID(o.Purchases[0].Series, o.Purchases[0].Code)
+		}
+		if len(o.Sales) > 0 {
+			d.OrderReference.SalesOrderID = semantic.InvoiceID(o.Sales[0].Series, o.Sales[0].Code)
+		}
+	}
+	d.DespatchDocumentReference = newDocumentReferences(o.Despatch)
+	d.ReceiptDocumentReference = newDocumentReferences(o.Receiving)
+	d.ContractDocumentReference = newDocumentReferences(o.Contracts)
+	d.ProjectReference = newDocumentReferences(o.Projects)
+}
+
+func (d *Document) addPayment(p *bill.PaymentDetails) {
+	if p == nil {
+		return
+	}
+	if t := p.Terms; t != nil {
+		note := t.Notes
+		if note == "" {
+			note = t.Detail
+		}
+		if note != "" {
+			d.PaymentTerms = &PaymentTerms{Note: note}
+		}
+	}
+	instr := p.Instructions
+	if instr == nil {
+		return
+	}
+	base := PaymentMeans{
+		PaymentMeansCode: instr.Ext[untdid.ExtKeyPaymentMeans].String(),
+		InstructionNote:  instr.Detail,
+		PaymentID:        instr.Ref.String(),
+	}
+	if base.PaymentMeansCode == "" {
+		base.PaymentMeansCode = defaultPaymentMeans
+	}
+	if c := instr.Card; c != nil {
+		base.CardAccount = &CardAccount{
+			PrimaryAccountNumberID: c.Last4,
+			NetworkID:              defaultCardNetwork,
+			HolderName:             c.Holder,
+		}
+	}
+	if dd := instr.DirectDebit; dd != nil {
+		base.PaymentMandate = &PaymentMandate{ID: dd.Ref}
+		if dd.Account != "" {
+			base.PaymentMandate.PayerFinancialAccount = &FinancialAccount{ID: dd.Account}
+		}
+	}
+	if len(instr.CreditTransfer) == 0 {
+		d.PaymentMeans = []*PaymentMeans{&base}
+		return
+	}
+	for _, ct := range instr.CreditTransfer {
+		pm := base // copy
+		pm.PayeeFinancialAccount = newFinancialAccount(ct)
+		d.PaymentMeans = append(d.PaymentMeans, &pm)
+	}
+}
+
+func (d *Document) addTotals(inv *bill.Invoice) {
+	t := inv.Totals
+	cur := inv.Currency
+	tt := &TaxTotal{TaxAmount: newAmount(t.Tax, cur)}
+	if t.Taxes != nil {
+		for _, ct := range t.Taxes.Categories {
+			for _, rt := range ct.Rates {
+				tt.TaxSubtotals = append(tt.TaxSubtotals, &TaxSubtotal{
+					TaxableAmount: newAmount(rt.Base, cur),
+					TaxAmount:     newAmount(rt.Amount, cur),
+					TaxCategory:   taxCategory(ct.Code, rt.Ext, rt.Percent),
+				})
+			}
+		}
+	}
+	d.TaxTotals = []*TaxTotal{tt}
+	if t.Local != nil && t.Local.Currency != cur {
+		d.TaxCurrencyCode = t.Local.Currency.String()
+		d.TaxTotals = append(d.TaxTotals, &TaxTotal{
+			TaxAmount: newAmount(t.Local.Tax, t.Local.Currency),
+		})
+	}
+
+	payable := t.Payable
+	if t.Due != nil {
+		payable = *t.Due
+	}
+	d.LegalMonetaryTotal = &MonetaryTotal{
+		LineExtensionAmount:   newAmount(t.Sum, cur),
+		TaxExclusiveAmount:    newAmount(t.Total, cur),
+		TaxInclusiveAmount:    newAmount(t.TotalWithTax, cur),
+		AllowanceTotalAmount:  optionalAmount(t.Discount, cur),
+		ChargeTotalAmount:     optionalAmount(t.Charge, cur),
+		PrepaidAmount:         optionalAmount(t.Advances, cur),
+		PayableRoundingAmount: optionalAmount(t.Rounding, cur),
+		PayableAmount:         newAmount(payable, cur),
+	}
+}
+
+func newLine(l *bill.Line, cur currency.Code) *Line {
+	line := &Line{
+		ID:                  strconv.Itoa(l.Index),
+		Notes:               noteTexts(l.Notes),
+		InvoicedQuantity:    &Quantity{Value: l.Quantity.String(), UnitCode: semantic.DefaultUnitCode},
+		LineExtensionAmount: newAmount(l.Total, cur),
+		Item:                &Item{},
+		Price:               &Price{},
+	}
+	for _, dis := range l.Discounts {
+		line.AllowanceCharges = append(line.AllowanceCharges, &AllowanceCharge{
+			ChargeIndicator:           false,
+			AllowanceChargeReasonCode: dis.Ext[untdid.ExtKeyAllowance].String(),
+			AllowanceChargeReason:     dis.Reason,
+			MultiplierFactorNumeric:   semantic.PercentValue(dis.Percent),
+			Amount:                    newAmount(dis.Amount, cur),
+			BaseAmount:                baseAmount(nil, dis.Percent, l.Sum, cur),
+		})
+	}
+	for _, chr := range l.Charges {
+		line.AllowanceCharges = append(line.AllowanceCharges, &AllowanceCharge{
+			ChargeIndicator:           true,
+			AllowanceChargeReasonCode: chr.Ext[untdid.ExtKeyCharge].String(),
+			AllowanceChargeReason:     chr.Reason,
+			MultiplierFactorNumeric:   semantic.PercentValue(chr.Percent),
+			Amount:                    newAmount(chr.Amount, cur),
+			BaseAmount:                baseAmount(nil, chr.Percent, l.Sum, cur),
+		})
+	}
+	if it := l.Item; it != nil {
+		line.InvoicedQuantity.UnitCode = semantic.UnitCode(it.Unit)
+		line.Item.Name = it.Name
+		line.Item.Description = it.Description
+		if it.Ref != "" {
+			line.Item.SellersItemIdentification = &ItemID{ID: &Identifier{Value: it.Ref}}
+		}
+		if len(it.Identities) > 0 {
+			line.Item.StandardItemIdentification = &ItemID{ID: newIdentifier(it.Identities[0])}
+		}
+		if it.Origin != "" {
+			line.Item.OriginCountry = &Country{IdentificationCode: it.Origin.String()}
+		}
+		line.Price.PriceAmount = newAmount(it.Price, cur)
+	}
+	line.Item.ClassifiedTaxCategory = newTaxCategory(firstCombo(l.Taxes))
+	return line
+}
+
+func newParty(p *org.Party) *Party {
+	if p == nil {
+		return nil
+	}
+	out := &Party{
+		EndpointID: endpointID(p.Inboxes),
+		PartyLegalEntity: &PartyLegalEntity{
+			RegistrationName: p.Name,
+		},
+	}
+	for _, id := range p.Identities {
+		out.PartyIdentification = append(out.PartyIdentification, &PartyIdentification{
+			ID: newIdentifier(id),
+		})
+	}
+	if p.Alias != "" {
+		out.PartyName = &PartyName{Name: p.Alias}
+	}
+	if len(p.Addresses) > 0 {
+		out.PostalAddress = newAddress(p.Addresses[0])
+		if out.PostalAddress.Country == nil && p.TaxID != nil && p.TaxID.Country != "" {
+			out.PostalAddress.Country = &Country{IdentificationCode: p.TaxID.Country.String()}
+		}
+	}
+	if p.TaxID != nil && p.TaxID.Code != cbc.CodeEmpty {
+		out.PartyTaxScheme = []*PartyTaxScheme{
+			{
+				CompanyID: p.TaxID.String(),
+				TaxScheme: &TaxScheme{ID: defaultTaxScheme},
+			},
+		}
+	}
+	out.Contact = newContact(p)
+	return out
+}
+
+func newContact(p *org.Party) *Contact {
+	c := new(Contact)
+	if len(p.People) > 0 && p.People[0].Name != nil {
+		n := p.People[0].Name
+		c.Name = strings.TrimSpace(n.Given + " " + n.Surname)
+	}
+	if len(p.Telephones) > 0 {
+		c.Telephone = p.Telephones[0].Number
+	}
+	if len(p.Emails) > 0 {
+		c.ElectronicMail = p.Emails[0].Address
+	}
+	if *c == (Contact{}) {
+		return nil
+	}
+	return c
+}
+
+func newAddress(a *org.Address) *Address {
+	out := &Address{
+		StreetName:           strings.TrimSpace(a.Street + " " + a.Number),
+		AdditionalStreetName: a.StreetExtra,
+		CityName:             a.Locality,
+		PostalZone:           a.Code.String(),
+		CountrySubentity:     a.Region,
+	}
+	if a.Country != "" {
+		out.Country = &Country{IdentificationCode: a.Country.String()}
+	}
+	return out
+}
+
+func newDelivery(dd *bill.DeliveryDetails) *Delivery {
+	if dd == nil {
+		return nil
+	}
+	d := new(Delivery)
+	if dd.Date != nil {
+		d.ActualDeliveryDate = dd.Date.String()
+	}
+	if r := dd.Receiver; r != nil {
+		if len(r.Addresses) > 0 {
+			d.DeliveryLocation = &Location{Address: newAddress(r.Addresses[0])}
+		}
+		if r.Name != "" {
+			d.DeliveryParty = &PartyName{Name: r.Name}
+		}
+	}
+	if *d == (Delivery{}) {
+		return nil
+	}
+	return d
+}
+
+func newFinancialAccount(ct *pay.CreditTransfer) *FinancialAccount {
+	fa := &FinancialAccount{
+		ID:   ct.IBAN,
+		Name: ct.Name,
+	}
+	if fa.ID == "" {
+		fa.ID = ct.Number
+	}
+	if ct.BIC != "" {
+		fa.FinancialInstitutionBranch = &Branch{ID: ct.BIC}
+	}
+	return fa
+}
+
+func newDocumentReferences(refs []*org.DocumentRef) []*DocumentReference {
+	var out []*DocumentReference
+	for _, r := range refs {
+		ref := &DocumentReference{ID: semantic.InvoiceID(r.Series, r.Code)}
+		if r.IssueDate != nil {
+			ref.IssueDate = r.IssueDate.String()
+		}
+		out = append(out, ref)
+	}
+	return out
+}
+
+// endpointID determines the electronic address of the party from the
+// Peppol inbox, whose code may include the scheme as a prefix.
+func endpointID(inboxes []*org.Inbox) *Identifier {
+	for _, ib := range inboxes {
+		if ib.Key != common.InboxKeyPEPPOL || ib.Code == cbc.CodeEmpty {
+			continue
+		}
+		id := &Identifier{Value: ib.Code.String()}
+		if s, v, ok := strings.Cut(id.Value, ":"); ok {
+			id.SchemeID, id.Value = s, v
+		} else {
+			id.SchemeID = ib.Ext[iso.ExtKeySchemeID].String()
+		}
+		return id
+	}
+	return nil
+}
+
+func newIdentifier(id *org.Identity) *Identifier {
+	return &Identifier{
+		Value:    id.Code.String(),
+		SchemeID: semantic.IdentityScheme(id),
+	}
+}
+
+func newTaxCategory(c *tax.Combo) *TaxCategory {
+	if c == nil {
+		return nil
+	}
+	return taxCategory(c.Category, c.Ext, c.Percent)
+}
+
+func taxCategory(cat cbc.Code, ext tax.Extensions, p *num.Percentage) *TaxCategory {
+	tc := &TaxCategory{
+		ID:        semantic.TaxCategoryCode(ext, p),
+		TaxScheme: &TaxScheme{ID: cat.String()},
+	}
+	if p != nil {
+		tc.Percent = semantic.PercentValue(p)
+	} else if tc.ID != "O" {
+		tc.Percent = "0"
+	}
+	return tc
+}
+
+func firstCombo(set tax.Set) *tax.Combo {
+	if len(set) == 0 {
+		return nil
+	}
+	return set[0]
+}
+
+func dueDate(p *bill.PaymentDetails) string {
+	if p == nil || p.Terms == nil {
+		return ""
+	}
+	for _, dd := range p.Terms.DueDates {
+		if dd.Date != nil {
+			return dd.Date.String()
+		}
+	}
+	return ""
+}
+
+func noteTexts(notes []*cbc.Note) []string {
+	var out []string
+	for _, n := range notes {
+		if n.Text != "" {
+			out = append(out, n.Text)
+		}
+	}
+	return out
+}
+
+func newAmount(a num.Amount, cur currency.Code) Amount {
+	return Amount{Value: a.String(), CurrencyID: cur.String()}
+}
+
+func optionalAmount(a *num.Amount, cur currency.Code) *Amount {
+	if a == nil {
+		return nil
+	}
+	out := newAmount(*a, cur)
+	return &out
+}
+
+// baseAmount provides the base used to calculate a percentage discount or
+// charge.
+func baseAmount(base *num.Amount, p *num.Percentage, sum num.Amount, cur currency.Code) *Amount {
+	if p == nil {
+		return nil
+	}
+	if base == nil {
+		base = &sum
+	}
+	return optionalAmount(base, cur)
+}
@@ -0,0 +1,493 @@
+package ubl
+
+import (
+	"fmt"
+	"strings"
+
+	"cloud.google.com/go/civil"
+	"github.com/invopop/gobl/addons/eu/en16931"
+	"github.com/invopop/gobl/bill"
+	"github.com/invopop/gobl/cal"
+	"github.com/invopop/gobl/catalogues/untdid"
+	"github.com/invopop/gobl/cbc"
+	"github.com/invopop/gobl/convert/internal/semantic"
+	"github.com/invopop/gobl/currency"
+	"github.com/invopop/gobl/l10n"
+	"github.com/invopop/gobl/num"
+	"github.com/invopop/gobl/org"
+	"github.com/invopop/gobl/pay"
+	"github.com/invopop/gobl/regimes/common"
+	"github.com/invopop/gobl/tax"
+)
+
+// Invoice converts the UBL document into a GOBL invoice that uses the
+// addon matching the customization ID, or EN 16931 if not recognized. The invoice is not calculated nor validated so that any
+// problems with the source data can be reviewed afterwards.
+func (d *Document) Invoice() (*bill.Invoice, error) {
+	inv := &bill.Invoice{
+		Addons:   tax.WithAddons(semantic.GuidelineAddon(d.CustomizationID)),
+		Code:     cbc.Code(d.ID),
+		Currency: currency.Code(d.DocumentCurrencyCode),
+	}
+	var err error
+	if inv.IssueDate, err = parseDate(d.IssueDate); err != nil {
+		return nil, fmt.Errorf("issue date: %w", err)
+	}
+	if d.TaxPointDate != "" {
+		dt, err := parseDate(d.TaxPointDate)
+		if err != nil {
+			return nil, fmt.Errorf("tax point date: %w", err)
+		}
+		inv.ValueDate = &dt
+	}
+
+	typeCode := d.InvoiceTypeCode
+	if d.IsCreditNote() {
+		typeCode = d.CreditNoteTypeCode
+	}
+	inv.Type = semantic.InvoiceType(typeCode, d.IsCreditNote())
+	if typeCode != "" {
+		inv.Tax = &bill.Tax{
+			Ext: tax.Extensions{untdid.ExtKeyDocumentType: tax.ExtValue(typeCode)},
+		}
+	}
+	for _, n := range d.Notes {
+		inv.Notes = append(inv.Notes, &cbc.Note{Text: n})
+	}
+	for _, br := range d.BillingReferences {
+		if br.InvoiceDocumentReference == nil {
+			continue
+		}
+		ref, err := documentRef(br.InvoiceDocumentReference)
+		if err != nil {
+			return nil, err
+		}
+		inv.Preceding = append(inv.Preceding, ref)
+	}
+	if inv.Ordering, err = d.ordering(); err != nil {
+		return nil, err
+	}
+
+	if d.AccountingSupplierParty != nil {
+		inv.Supplier = d.AccountingSupplierParty.Party.party()
+	}
+	if d.AccountingCustomerParty != nil {
+		inv.Customer = d.AccountingCustomerParty.Party.party()
+	}
+	if inv.Delivery, err = d.Delivery.details(); err != nil {
+		return nil, err
+	}
+	if inv.Payment, err = d.payment(); err != nil {
+		return nil, err
+	}
+
+	for _, ac := range d.AllowanceCharges {
+		if err := addAllowanceCharge(inv, ac); err != nil {
+			return nil, err
+		}
+	}
+	lines := d.InvoiceLines
+	if d.IsCreditNote() {
+		lines = d.CreditNoteLines
+	}
+	for _, l := range lines {
+		line, err := l.line()
+		if err != nil {
+			return nil, fmt.Errorf("line %s: %w", l.ID, err)
+		}
+		inv.Lines = append(inv.Lines, line)
+	}
+
+	if mt := d.LegalMonetaryTotal; mt != nil && mt.PayableRoundingAmount != nil {
+		r, err := num.AmountFromString(mt.PayableRoundingAmount.Value)
+		if err != nil {
+			return nil, fmt.Errorf("rounding: %w", err)
+		}
+		inv.Totals = &bill.Totals{Rounding: &r}
+	}
+	return inv, nil
+}
+
+func (d *Document) ordering() (*bill.Ordering, error) {
+	o := &bill.Ordering{Code: cbc.Code(d.BuyerReference)}
+	if p := d.InvoicePeriod; p != nil {
+		o.Period = new(cal.Period)
+		var err error
+		if o.Period.Start, err = parseDate(p.StartDate); err != nil {
+			return nil, fmt.Errorf("period start: %w", err)
+		}
+		if o.Period.End, err = parseDate(p.EndDate); err != nil {
+			return nil, fmt.Errorf("period end: %w", err)
+		}
+	}
+	if r := d.OrderReference; r != nil {
+		if r.ID != "" && r.ID != defaultOrderID {
+			o.Purchases = []*org.DocumentRef{{Code: cbc.Code(r.ID)}}
+		}
+		if r.SalesOrderID != "" {
+			o.Sales = []*org.DocumentRef{{Code: cbc.Code(r.SalesOrderID)}}
+		}
+	}
+	var err error
+	if o.Despatch, err = documentRefs(d.DespatchDocumentReference); err != nil {
+		return nil, err
+	}
+	if o.Receiving, err = documentRefs(d.ReceiptDocumentReference); err != nil {
+		return nil, err
+	}
+	if o.Contracts, err = documentRefs(d.ContractDocumentReference); err != nil {
+		return nil, err
+	}
+	if o.Projects, err = documentRefs(d.ProjectReference); err != nil {
+		return nil, err
+	}
+	if o.Code == cbc.CodeEmpty && o.Period == nil && len(o.Purchases) == 0 &&
+		len(o.Sales) == 0 && len(o.Despatch) == 0 && len(o.Receiving) == 0 &&
+		len(o.Contracts) == 0 && len(o.Projects) == 0 {
+		return nil, nil
+	}
+	return o, nil
+}
+
+func (d *Document) payment() (*bill.PaymentDetails, error) {
+	pd := new(bill.PaymentDetails)
+	if d.PayeeParty != nil {
+		pd.Payee = d.PayeeParty.party()
+	}
+	if d.PaymentTerms != nil || d.DueDate != "" {
+		pd.Terms = new(pay.Terms)
+		if d.PaymentTerms != nil {
+			pd.Terms.Notes = d.PaymentTerms.Note
+		}
+		if d.DueDate != "" {
+			dt, err := parseDate(d.DueDate)
+			if err != nil {
+				return nil, fmt.Errorf("due date: %w", err)
+			}
+			pd.Terms.DueDates = []*pay.DueDate{
+				{Date: &dt, Percent: num.NewPercentage(100, 2)},
+			}
+		}
+	}
+	for i, pm := range d.PaymentMeans {
+		if i == 0 {
+			pd.Instructions = &pay.Instructions{
+				Key:    en16931.PaymentMeansKey(tax.ExtValue(pm.PaymentMeansCode)),
+				Detail: pm.InstructionNote,
+				Ref:    cbc.Code(pm.PaymentID),
+				Ext: tax.Extensions{
+					untdid.ExtKeyPaymentMeans: tax.ExtValue(pm.PaymentMeansCode),
+				},
+			}
+			if c := pm.CardAccount; c != nil {
+				pd.Instructions.Card = &pay.Card{
+					Last4:  c.PrimaryAccountNumberID,
+					Holder: c.HolderName,
+				}
+			}
+			if m := pm.PaymentMandate; m != nil {
+				pd.Instructions.DirectDebit = &pay.DirectDebit{Ref: m.ID}
+				if m.PayerFinancialAccount != nil {
+					pd.Instructions.DirectDebit.Account = m.PayerFinancialAccount.ID
+				}
+			}
+		}
+		if fa := pm.PayeeFinancialAccount; fa != nil {
+			ct := &pay.CreditTransfer{Name: fa.Name}
+			if semantic.IsIBAN(fa.ID) {
+				ct.IBAN = fa.ID
+			} else {
+				ct.Number = fa.ID
+			}
+			if fa.FinancialInstitutionBranch != nil {
+				ct.BIC = fa.FinancialInstitutionBranch.ID
+			}
+			pd.Instructions.CreditTransfer = append(pd.Instructions.CreditTransfer, ct)
+		}
+	}
+	if mt := d.LegalMonetaryTotal; mt != nil && mt.PrepaidAmount != nil {
+		a, err := num.AmountFromString(mt.PrepaidAmount.Value)
+		if err != nil {
+			return nil, fmt.Errorf("prepaid amount: %w", err)
+		}
+		if !a.IsZero() {
+			pd.Advances = []*pay.Advance{
+				{Description: "Prepaid amount", Amount: a},
+			}
+		}
+	}
+	if pd.Payee == nil && pd.Terms == nil && pd.Instructions == nil && len(pd.Advances) == 0 {
+		return nil, nil
+	}
+	return pd, nil
+}
+
+func (d *Delivery) details() (*bill.DeliveryDetails, error) {
+	if d == nil {
+		return nil, nil
+	}
+	dd := new(bill.DeliveryDetails)
+	if d.ActualDeliveryDate != "" {
+		dt, err := parseDate(d.ActualDeliveryDate)
+		if err != nil {
+			return nil, fmt.Errorf("delivery date: %w", err)
+		}
+		dd.Date = &dt
+	}
+	if d.DeliveryParty != nil || (d.DeliveryLocation != nil && d.DeliveryLocation.Address != nil) {
+		dd.Receiver = new(org.Party)
+		if d.DeliveryParty != nil {
+			dd.Receiver.Name = d.DeliveryParty.Name
+		}
+		if d.DeliveryLocation != nil && d.DeliveryLocation.Address != nil {
+			dd.Receiver.Addresses = []*org.Address{d.DeliveryLocation.Address.address()}
+		}
+	}
+	return dd, nil
+}
+
+func (p *Party) party() *org.Party {
+	if p == nil {
+		return nil
+	}
+	out := new(org.Party)
+	if p.PartyLegalEntity != nil {
+		out.Name = p.PartyLegalEntity.RegistrationName
+	}
+	if p.PartyName != nil {
+		if out.Name == "" {
+			out.Name = p.PartyName.Name
+		} else if p.PartyName.Name != out.Name {
+			out.Alias = p.PartyName.Name
+		}
+	}
+	for _, pts := range p.PartyTaxScheme {
+		if pts.TaxScheme != nil && pts.TaxScheme.ID == defaultTaxScheme {
+			out.TaxID = semantic.TaxIdentity(pts.CompanyID)
+			break
+		}
+	}
+	for _, pi := range p.PartyIdentification {
+		if pi.ID != nil {
+			out.Identities = append(out.Identities, identity(pi.ID))
+		}
+	}
+	if id := p.EndpointID; id != nil {
+		code := id.Value
+		if id.SchemeID != "" {
+			code = id.SchemeID + ":" + code
+		}
+		out.Inboxes = []*org.Inbox{{Key: common.InboxKeyPEPPOL, Code: cbc.Code(code)}}
+	}
+	if p.PostalAddress != nil {
+		out.Addresses = []*org.Address{p.PostalAddress.address()}
+	}
+	if c := p.Contact; c != nil {
+		if c.Name != "" {
+			out.People = []*org.Person{{Name: &org.Name{Given: c.Name}}}
+		}
+		if c.Telephone != "" {
+			out.Telephones = []*org.Telephone{{Number: c.Telephone}}
+		}
+		if c.ElectronicMail != "" {
+			out.Emails = []*org.Email{{Address: c.ElectronicMail}}
+		}
+	}
+	return out
+}
+
+func (a *Address) address() *org.Address {
+	out := &org.Address{
+		Street:      a.StreetName,
+		StreetExtra: a.AdditionalStreetName,
+		Locality:    a.CityName,
+		Code:        cbc.Code(a.PostalZone),
+		Region:      a.CountrySubentity,
+	}
+	if a.Country != nil {
+		out.Country = l10n.ISOCountryCode(a.Country.IdentificationCode)
+	}
+	return out
+}
+
+func identity(id *Identifier) *org.Identity {
+	return semantic.Identity(id.Value, id.SchemeID)
+}
+
+func addAllowanceCharge(inv *bill.Invoice, ac *AllowanceCharge) error {
+	amount, err := num.AmountFromString(ac.Amount.Value)
+	if err != nil {
+		return fmt.Errorf("allowance charge amount: %w", err)
+	}
+	percent, err := semantic.ParsePercent(ac.MultiplierFactorNumeric)
+	if err != nil {
+		return fmt.Errorf("allowance charge percent: %w", err)
+	}
+	var base *num.Amount
+	if percent != nil && ac.BaseAmount != nil {
+		b, err := num.AmountFromString(ac.BaseAmount.Value)
+		if err != nil {
+			return fmt.Errorf("allowance charge base: %w", err)
+		}
+		base = &b
+	}
+	combo, err := ac.TaxCategory.combo()
+	if err != nil {
+		return err
+	}
+	var taxes tax.Set
+	if combo != nil {
+		taxes = tax.Set{combo}
+	}
+	code := tax.ExtValue(ac.AllowanceChargeReasonCode)
+	if ac.ChargeIndicator {
+		chr := &bill.Charge{
+			Key:     en16931.ChargeKey(code),
+			Reason:  ac.AllowanceChargeReason,
+			Base:    base,
+			Percent: percent,
+			Amount:  amount,
+			Taxes:   taxes,
+		}
+		if code != "" {
+			chr.Ext = tax.Extensions{untdid.ExtKeyCharge: code}
+		}
+		inv.Charges = append(inv.Charges, chr)
+		return nil
+	}
+	dis := &bill.Discount{
+		Key:     en16931.DiscountKey(code),
+		Reason:  ac.AllowanceChargeReason,
+		Base:    base,
+		Percent: percent,
+		Amount:  amount,
+		Taxes:   taxes,
+	}
+	if code != "" {
+		dis.Ext = tax.Extensions{untdid.ExtKeyAllowance: code}
+	}
+	inv.Discounts = append(inv.Discounts, dis)
+	return nil
+}
+
+func (l *Line) line() (*bill.Line, error) {
+	q := l.InvoicedQuantity
+	if q == nil {
+		q = l.CreditedQuantity
+	}
+	if q == nil {
+		return nil, fmt.Errorf("quantity required")
+	}
+	out := &bill.Line{Item: new(org.Item)}
+	var err error
+	if out.Quantity, err = num.AmountFromString(q.Value); err != nil {
+		return nil, fmt.Errorf("quantity: %w", err)
+	}
+	out.Item.Unit = semantic.Unit(q.UnitCode)
+	for _, n := range l.Notes {
+		out.Notes = append(out.Notes, &cbc.Note{Text: n})
+	}
+	if l.Price != nil {
+		if out.Item.Price, err = num.AmountFromString(l.Price.PriceAmount.Value); err != nil {
+			return nil, fmt.Errorf("price: %w", err)
+		}
+	}
+	if it := l.Item; it != nil {
+		out.Item.Name = it.Name
+		out.Item.Description = it.Description
+		if it.SellersItemIdentification != nil && it.SellersItemIdentification.ID != nil {
+			out.Item.Ref = it.SellersItemIdentification.ID.Value
+		}
+		if it.StandardItemIdentification != nil && it.StandardItemIdentification.ID != nil {
+			out.Item.Identities = []*org.Identity{identity(it.StandardItemIdentification.ID)}
+		}
+		if it.OriginCountry != nil {
+			out.Item.Origin = l10n.ISOCountryCode(it.OriginCountry.IdentificationCode)
+		}
+		combo, err := it.ClassifiedTaxCategory.combo()
+		if err != nil {
+			return nil, err
+		}
+		if combo != nil {
+			out.Taxes = tax.Set{combo}
+		}
+	}
+	for _, ac := range l.AllowanceCharges {
+		amount, err := num.AmountFromString(ac.Amount.Value)
+		if err != nil {
+			return nil, fmt.Errorf("allowance charge amount: %w", err)
+		}
+		percent, err := semantic.ParsePercent(ac.MultiplierFactorNumeric)
+		if err != nil {
+			return nil, fmt.Errorf("allowance charge percent: %w", err)
+		}
+		code := tax.ExtValue(ac.AllowanceChargeReasonCode)
+		if ac.ChargeIndicator {
+			chr := &bill.LineCharge{
+				Key:     en16931.ChargeKey(code),
+				Reason:  ac.AllowanceChargeReason,
+				Percent: percent,
+				Amount:  amount,
+			}
+			if code != "" {
+				chr.Ext = tax.Extensions{untdid.ExtKeyCharge: code}
+			}
+			out.Charges = append(out.Charges, chr)
+			continue
+		}
+		dis := &bill.LineDiscount{
+			Key:     en16931.DiscountKey(code),
+			Reason:  ac.AllowanceChargeReason,
+			Percent: percent,
+			Amount:  amount,
+		}
+		if code != "" {
+			dis.Ext = tax.Extensions{untdid.ExtKeyAllowance: code}
+		}
+		out.Discounts = append(out.Discounts, dis)
+	}
+	return out, nil
+}
+
+func (tc *TaxCategory) combo() (*tax.Combo, error) {
+	if tc == nil {
+		return nil, nil
+	}
+	var cat cbc.Code
+	if tc.TaxScheme != nil {
+		cat = cbc.Code(tc.TaxScheme.ID)
+	}
+	return semantic.TaxCombo(cat, tc.ID, tc.Percent)
+}
+
+func documentRefs(refs []*DocumentReference) ([]*org.DocumentRef, error) {
+	var out []*org.DocumentRef
+	for _, r := range refs {
+		ref, err := documentRef(r)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, ref)
+	}
+	return out, nil
+}
+
+func documentRef(r *DocumentReference) (*org.DocumentRef, error) {
+	ref := &org.DocumentRef{Code: cbc.Code(r.ID)}
+	if r.IssueDate != "" {
+		dt, err := parseDate(r.IssueDate)
+		if err != nil {
+			return nil, fmt.Errorf("reference '%s' issue date: %w", r.ID, err)
+		}
+		ref.IssueDate = &dt
+	}
+	return ref, nil
+}
+
+func parseDate(s string) (cal.Date, error) {
+	d, err := civil.ParseDate(strings.TrimSpace(s))
+	if err != nil {
+		return cal.Date{}, err
+	}
+	return cal.Date{Date: d}, nil
+}
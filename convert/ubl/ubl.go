@@ -0,0 +1,397 @@
+// Package ubl converts GOBL invoices to and from the OASIS Universal
+// Business Language (UBL) 2.1 Invoice and CreditNote XML documents, using
+// the EN 16931 semantic model as followed by Peppol and most B2G
+// platforms.
+package ubl
+
+import (
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"io"
+
+	"github.com/invopop/gobl/convert/internal/semantic"
+)
+
+// Namespaces used by UBL documents.
+const (
+	NamespaceInvoice    = "urn:oasis:names:specification:ubl:schema:xsd:Invoice-2"
+	NamespaceCreditNote = "urn:oasis:names:specification:ubl:schema:xsd:CreditNote-2"
+	NamespaceCAC        = "urn:oasis:names:specification:ubl:schema:xsd:CommonAggregateComponents-2"
+	NamespaceCBC        = "urn:oasis:names:specification:ubl:schema:xsd:CommonBasicComponents-2"
+)
+
+// CustomizationEN16931 identifies documents that follow the EN 16931 core
+// invoice model without any further restrictions.
+const CustomizationEN16931 = semantic.GuidelineEN16931
+
+// Root element names of the supported documents.
+const (
+	rootInvoice    = "Invoice"
+	rootCreditNote = "CreditNote"
+)
+
+// ErrUnsupported is returned when the invoice contains data that cannot be
+// represented using the EN 16931 model in UBL.
+var ErrUnsupported = semantic.ErrUnsupported
+
+// Document contains the fields shared by the UBL Invoice and CreditNote
+// documents, in the order required by their schemas.
+type Document struct {
+	XMLName      xml.Name
+	Namespace    string `xml:"xmlns,attr,omitempty"`
+	CACNamespace string `xml:"xmlns:cac,attr,omitempty"`
+	CBCNamespace string `xml:"xmlns:cbc,attr,omitempty"`
+
+	CustomizationID           string               `xml:"cbc:CustomizationID,omitempty"`
+	ProfileID                 string               `xml:"cbc:ProfileID,omitempty"`
+	ID                        string               `xml:"cbc:ID"`
+	IssueDate                 string               `xml:"cbc:IssueDate"`
+	DueDate                   string               `xml:"cbc:DueDate,omitempty"`
+	InvoiceTypeCode           string               `xml:"cbc:InvoiceTypeCode,omitempty"`
+	CreditNoteTypeCode        string               `xml:"cbc:CreditNoteTypeCode,omitempty"`
+	Notes                     []string             `xml:"cbc:Note,omitempty"`
+	TaxPointDate              string               `xml:"cbc:TaxPointDate,omitempty"`
+	DocumentCurrencyCode      string               `xml:"cbc:DocumentCurrencyCode"`
+	TaxCurrencyCode           string               `xml:"cbc:TaxCurrencyCode,omitempty"`
+	BuyerReference            string               `xml:"cbc:BuyerReference,omitempty"`
+	InvoicePeriod             *Period              `xml:"cac:InvoicePeriod,omitempty"`
+	OrderReference            *OrderReference      `xml:"cac:OrderReference,omitempty"`
+	BillingReferences         []*BillingReference  `xml:"cac:BillingReference,omitempty"`
+	DespatchDocumentReference []*DocumentReference `xml:"cac:DespatchDocumentReference,omitempty"`
+	ReceiptDocumentReference  []*DocumentReference `xml:"cac:ReceiptDocumentReference,omitempty"`
+	ContractDocumentReference []*DocumentReference `xml:"cac:ContractDocumentReference,omitempty"`
+	ProjectReference          []*DocumentReference `xml:"cac:ProjectReference,omitempty"`
+	AccountingSupplierParty   *PartyWrapper        `xml:"cac:AccountingSupplierParty"`
+	AccountingCustomerParty   *PartyWrapper        `xml:"cac:AccountingCustomerParty,omitempty"`
+	PayeeParty                *Party               `xml:"cac:PayeeParty,omitempty"`
+	Delivery                  *Delivery            `xml:"cac:Delivery,omitempty"`
+	PaymentMeans              []*PaymentMeans      `xml:"cac:PaymentMeans,omitempty"`
+	PaymentTerms              *PaymentTerms        `xml:"cac:PaymentTerms,omitempty"`
+	AllowanceCharges          []*AllowanceCharge   `xml:"cac:AllowanceCharge,omitempty"`
+	TaxTotals                 []*TaxTotal          `xml:"cac:TaxTotal,omitempty"`
+	LegalMonetaryTotal        *MonetaryTotal       `xml:"cac:LegalMonetaryTotal"`
+	InvoiceLines              []*Line              `xml:"cac:InvoiceLine,omitempty"`
+	CreditNoteLines           []*Line              `xml:"cac:CreditNoteLine,omitempty"`
+}
+
+// Amount is a monetary value with its currency.
+type Amount struct {
+	Value      string `xml:",chardata"`
+	CurrencyID string `xml:"currencyID,attr"`
+}
+
+// Quantity is a numeric value with its UN/ECE unit code.
+type Quantity struct {
+	Value    string `xml:",chardata"`
+	UnitCode string `xml:"unitCode,attr,omitempty"`
+}
+
+// Identifier is a code with the optional scheme it belongs to.
+type Identifier struct {
+	Value    string `xml:",chardata"`
+	SchemeID string `xml:"schemeID,attr,omitempty"`
+}
+
+// Period defines a range of dates.
+type Period struct {
+	StartDate string `xml:"cbc:StartDate,omitempty"`
+	EndDate   string `xml:"cbc:EndDate,omitempty"`
+}
+
+// OrderReference points to the purchase and sales orders.
+type OrderReference struct {
+	ID           string `xml:"cbc:ID"`
+	SalesOrderID string `xml:"cbc:SalesOrderID,omitempty"`
+}
+
+// BillingReference points to a preceding invoice.
+type BillingReference struct {
+	InvoiceDocumentReference *DocumentReference `xml:"cac:InvoiceDocumentReference"`
+}
+
+// DocumentReference identifies another document.
+type DocumentReference struct {
+	ID        string `xml:"cbc:ID"`
+	IssueDate string `xml:"cbc:IssueDate,omitempty"`
+}
+
+// PartyWrapper contains the supplier or customer party.
+type PartyWrapper struct {
+	Party *Party `xml:"cac:Party"`
+}
+
+// Party describes a supplier, customer, or payee.
+type Party struct {
+	EndpointID          *Identifier            `xml:"cbc:EndpointID,omitempty"`
+	PartyIdentification []*PartyIdentification `xml:"cac:PartyIdentification,omitempty"`
+	PartyName           *PartyName             `xml:"cac:PartyName,omitempty"`
+	PostalAddress       *Address               `xml:"cac:PostalAddress,omitempty"`
+	PartyTaxScheme      []*PartyTaxScheme      `xml:"cac:PartyTaxScheme,omitempty"`
+	PartyLegalEntity    *PartyLegalEntity      `xml:"cac:PartyLegalEntity,omitempty"`
+	Contact             *Contact               `xml:"cac:Contact,omitempty"`
+}
+
+// PartyIdentification contains one of the party's identifiers.
+type PartyIdentification struct {
+	ID *Identifier `xml:"cbc:ID"`
+}
+
+// PartyName contains the trading name.
+type PartyName struct {
+	Name string `xml:"cbc:Name"`
+}
+
+// Address is a postal address.
+type Address struct {
+	StreetName           string   `xml:"cbc:StreetName,omitempty"`
+	AdditionalStreetName string   `xml:"cbc:AdditionalStreetName,omitempty"`
+	CityName             string   `xml:"cbc:CityName,omitempty"`
+	PostalZone           string   `xml:"cbc:PostalZone,omitempty"`
+	CountrySubentity     string   `xml:"cbc:CountrySubentity,omitempty"`
+	Country              *Country `xml:"cac:Country,omitempty"`
+}
+
+// Country contains an ISO 3166-1 alpha-2 code.
+type Country struct {
+	IdentificationCode string `xml:"cbc:IdentificationCode"`
+}
+
+// PartyTaxScheme contains a tax identity of the party.
+type PartyTaxScheme struct {
+	CompanyID string     `xml:"cbc:CompanyID"`
+	TaxScheme *TaxScheme `xml:"cac:TaxScheme"`
+}
+
+// TaxScheme identifies the type of tax, usually "VAT".
+type TaxScheme struct {
+	ID string `xml:"cbc:ID"`
+}
+
+// PartyLegalEntity contains the legal name of the party.
+type PartyLegalEntity struct {
+	RegistrationName string      `xml:"cbc:RegistrationName"`
+	CompanyID        *Identifier `xml:"cbc:CompanyID,omitempty"`
+}
+
+// Contact contains the party's contact details.
+type Contact struct {
+	Name           string `xml:"cbc:Name,omitempty"`
+	Telephone      string `xml:"cbc:Telephone,omitempty"`
+	ElectronicMail string `xml:"cbc:ElectronicMail,omitempty"`
+}
+
+// Delivery describes where and when goods were delivered.
+type Delivery struct {
+	ActualDeliveryDate string     `xml:"cbc:ActualDeliveryDate,omitempty"`
+	DeliveryLocation   *Location  `xml:"cac:DeliveryLocation,omitempty"`
+	DeliveryParty      *PartyName `xml:"cac:DeliveryParty>cac:PartyName,omitempty"`
+}
+
+// Location contains the address of a delivery.
+type Location struct {
+	Address *Address `xml:"cac:Address,omitempty"`
+}
+
+// PaymentMeans describes how the payment is expected to be made.
+type PaymentMeans struct {
+	PaymentMeansCode      string            `xml:"cbc:PaymentMeansCode"`
+	InstructionNote       string            `xml:"cbc:InstructionNote,omitempty"`
+	PaymentID             string            `xml:"cbc:PaymentID,omitempty"`
+	CardAccount           *CardAccount      `xml:"cac:CardAccount,omitempty"`
+	PayeeFinancialAccount *FinancialAccount `xml:"cac:PayeeFinancialAccount,omitempty"`
+	PaymentMandate        *PaymentMandate   `xml:"cac:PaymentMandate,omitempty"`
+}
+
+// CardAccount describes the card used for the payment.
+type CardAccount struct {
+	PrimaryAccountNumberID string `xml:"cbc:PrimaryAccountNumberID"`
+	NetworkID              string `xml:"cbc:NetworkID"`
+	HolderName             string `xml:"cbc:HolderName,omitempty"`
+}
+
+// FinancialAccount identifies a bank account.
+type FinancialAccount struct {
+	ID                         string  `xml:"cbc:ID"`
+	Name                       string  `xml:"cbc:Name,omitempty"`
+	FinancialInstitutionBranch *Branch `xml:"cac:FinancialInstitutionBranch,omitempty"`
+}
+
+// Branch identifies a financial institution by its BIC.
+type Branch struct {
+	ID string `xml:"cbc:ID"`
+}
+
+// PaymentMandate describes a direct debit.
+type PaymentMandate struct {
+	ID                    string            `xml:"cbc:ID,omitempty"`
+	PayerFinancialAccount *FinancialAccount `xml:"cac:PayerFinancialAccount,omitempty"`
+}
+
+// PaymentTerms contains a text description of the payment terms.
+type PaymentTerms struct {
+	Note string `xml:"cbc:Note"`
+}
+
+// AllowanceCharge is a discount or charge at document or line level.
+type AllowanceCharge struct {
+	ChargeIndicator           bool         `xml:"cbc:ChargeIndicator"`
+	AllowanceChargeReasonCode string       `xml:"cbc:AllowanceChargeReasonCode,omitempty"`
+	AllowanceChargeReason     string       `xml:"cbc:AllowanceChargeReason,omitempty"`
+	MultiplierFactorNumeric   string       `xml:"cbc:MultiplierFactorNumeric,omitempty"`
+	Amount                    Amount       `xml:"cbc:Amount"`
+	BaseAmount                *Amount      `xml:"cbc:BaseAmount,omitempty"`
+	TaxCategory               *TaxCategory `xml:"cac:TaxCategory,omitempty"`
+}
+
+// TaxTotal contains the tax amount of the document, and the breakdown by
+// category when provided in the document's currency.
+type TaxTotal struct {
+	TaxAmount    Amount         `xml:"cbc:TaxAmount"`
+	TaxSubtotals []*TaxSubtotal `xml:"cac:TaxSubtotal,omitempty"`
+}
+
+// TaxSubtotal contains the base and amount of a tax category and rate.
+type TaxSubtotal struct {
+	TaxableAmount Amount       `xml:"cbc:TaxableAmount"`
+	TaxAmount     Amount       `xml:"cbc:TaxAmount"`
+	TaxCategory   *TaxCategory `xml:"cac:TaxCategory"`
+}
+
+// TaxCategory describes the tax applied.
+type TaxCategory struct {
+	ID        string     `xml:"cbc:ID"`
+	Percent   string     `xml:"cbc:Percent,omitempty"`
+	TaxScheme *TaxScheme `xml:"cac:TaxScheme"`
+}
+
+// MonetaryTotal contains the document totals.
+type MonetaryTotal struct {
+	LineExtensionAmount   Amount  `xml:"cbc:LineExtensionAmount"`
+	TaxExclusiveAmount    Amount  `xml:"cbc:TaxExclusiveAmount"`
+	TaxInclusiveAmount    Amount  `xml:"cbc:TaxInclusiveAmount"`
+	AllowanceTotalAmount  *Amount `xml:"cbc:AllowanceTotalAmount,omitempty"`
+	ChargeTotalAmount     *Amount `xml:"cbc:ChargeTotalAmount,omitempty"`
+	PrepaidAmount         *Amount `xml:"cbc:PrepaidAmount,omitempty"`
+	PayableRoundingAmount *Amount `xml:"cbc:PayableRoundingAmount,omitempty"`
+	PayableAmount         Amount  `xml:"cbc:PayableAmount"`
+}
+
+// Line is an invoice or credit note line.
+type Line struct {
+	ID                  string             `xml:"cbc:ID"`
+	Notes               []string           `xml:"cbc:Note,omitempty"`
+	InvoicedQuantity    *Quantity          `xml:"cbc:InvoicedQuantity,omitempty"`
+	CreditedQuantity    *Quantity          `xml:"cbc:CreditedQuantity,omitempty"`
+	LineExtensionAmount Amount             `xml:"cbc:LineExtensionAmount"`
+	AllowanceCharges    []*AllowanceCharge `xml:"cac:AllowanceCharge,omitempty"`
+	Item                *Item              `xml:"cac:Item"`
+	Price               *Price             `xml:"cac:Price"`
+}
+
+// Item describes the product or service of a line.
+type Item struct {
+	Description                string       `xml:"cbc:Description,omitempty"`
+	Name                       string       `xml:"cbc:Name"`
+	SellersItemIdentification  *ItemID      `xml:"cac:SellersItemIdentification,omitempty"`
+	StandardItemIdentification *ItemID      `xml:"cac:StandardItemIdentification,omitempty"`
+	OriginCountry              *Country     `xml:"cac:OriginCountry,omitempty"`
+	ClassifiedTaxCategory      *TaxCategory `xml:"cac:ClassifiedTaxCategory,omitempty"`
+}
+
+// ItemID identifies an item.
+type ItemID struct {
+	ID *Identifier `xml:"cbc:ID"`
+}
+
+// Price is the net price of an item.
+type Price struct {
+	PriceAmount Amount `xml:"cbc:PriceAmount"`
+}
+
+// IsCreditNote returns true if the document is a credit note.
+func (d *Document) IsCreditNote() bool {
+	return d.XMLName.Local == rootCreditNote
+}
+
+// Bytes provides the indented XML representation of the document.
+func (d *Document) Bytes() ([]byte, error) {
+	buf := new(bytes.Buffer)
+	buf.WriteString(xml.Header)
+	enc := xml.NewEncoder(buf)
+	enc.Indent("", "  ")
+	if err := enc.Encode(d); err != nil {
+		return nil, err
+	}
+	buf.WriteString("\n")
+	return buf.Bytes(), nil
+}
+
+// Parse reads a UBL Invoice or CreditNote document from the XML data.
+func Parse(data []byte) (*Document, error) {
+	d := new(Document)
+	dec := xml.NewTokenDecoder(&prefixReader{dec: xml.NewDecoder(bytes.NewReader(data))})
+	if err := dec.Decode(d); err != nil {
+		return nil, fmt.Errorf("parsing XML: %w", err)
+	}
+	switch d.XMLName.Local {
+	case rootInvoice, rootCreditNote:
+	default:
+		return nil, fmt.Errorf("unsupported document '%s'", d.XMLName.Local)
+	}
+	return d, nil
+}
+
+// prefixReader rewrites the names of the elements read so that they are
+// prefixed according to their namespace instead of the prefix used in the
+// source, allowing the same structures to be used for parsing and
+// generating documents.
+type prefixReader struct {
+	dec *xml.Decoder
+}
+
+var namespacePrefixes = map[string]string{
+	NamespaceInvoice:    "",
+	NamespaceCreditNote: "",
+	NamespaceCAC:        "cac",
+	NamespaceCBC:        "cbc",
+}
+
+func (r *prefixReader) Token() (xml.Token, error) {
+	t, err := r.dec.Token()
+	if err != nil {
+		return nil, err
+	}
+	switch v := t.(type) {
+	case xml.StartElement:
+		v.Name = prefixed(v.Name)
+		attrs := make([]xml.Attr, 0, len(v.Attr))
+		for _, a := range v.Attr {
+			if a.Name.Space == "xmlns" || (a.Name.Space == "" && a.Name.Local == "xmlns") {
+				continue
+			}
+			attrs = append(attrs, a)
+		}
+		v.Attr = attrs
+		return v, nil
+	case xml.EndElement:
+		v.Name = prefixed(v.Name)
+		return v, nil
+	}
+	if t == nil {
+		return nil, io.EOF
+	}
+	return t, nil
+}
+
+func prefixed(n xml.Name) xml.Name {
+	p, ok := namespacePrefixes[n.Space]
+	if !ok {
+		// unknown namespaces are kept apart so that they are ignored
+		return xml.Name{Space: n.Space, Local: n.Local}
+	}
+	if p == "" {
+		return xml.Name{Local: n.Local}
+	}
+	return xml.Name{Local: p + ":" + n.Local}
+}
@@ -0,0 +1,170 @@
+package ubl_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/invopop/gobl/addons/eu/peppol"
+	"github.com/invopop/gobl/bill"
+	"github.com/invopop/gobl/cbc"
+	"github.com/invopop/gobl/convert/internal/roundtrip"
+	"github.com/invopop/gobl/convert/ubl"
+	"github.com/invopop/gobl/org"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRoundTrip(t *testing.T) {
+	roundtrip.Run(t, func(t *testing.T, inv *bill.Invoice) (*bill.Invoice, error) {
+		doc, err := ubl.FromInvoice(inv)
+		if err != nil {
+			return nil, err
+		}
+		data, err := doc.Bytes()
+		require.NoError(t, err)
+
+		doc2, err := ubl.Parse(data)
+		require.NoError(t, err)
+		assert.Equal(t, doc.IsCreditNote(), doc2.IsCreditNote())
+		inv2, err := doc2.Invoice()
+		require.NoError(t, err)
+		require.NoError(t, inv2.Calculate())
+
+		// compare against the converted totals as included taxes
+		// will have been removed
+		mt := doc.LegalMonetaryTotal
+		payable := inv2.Totals.Payable
+		if inv2.Totals.Due != nil {
+			payable = *inv2.Totals.Due
+		}
+		assert.Equal(t, mt.PayableAmount.Value, payable.String())
+		assert.Equal(t, mt.TaxInclusiveAmount.Value, inv2.Totals.TotalWithTax.String())
+		assert.Equal(t, doc.TaxTotals[0].TaxAmount.Value, inv2.Totals.Tax.String())
+		return inv2, nil
+	},
+		"es/invoice-es-es-freelance.json",       // retained taxes
+		"es/invoice-es-es-outlays.json",         // outlays
+		"es/invoice-es-es-vateqs-provider.json", // tax surcharges
+		"it/freelance.json",                     // retained taxes
+		"mx/retentions.json",                    // retained taxes
+	)
+}
+
+func TestFromInvoice(t *testing.T) {
+	inv := roundtrip.LoadInvoice(t, "../../examples/de/out/invoice-de-de.json")
+	require.NotNil(t, inv)
+	sum := inv.Totals.Sum.String()
+	doc, err := ubl.FromInvoice(inv)
+	require.NoError(t, err)
+	assert.False(t, doc.IsCreditNote())
+	assert.Equal(t, "Invoice", doc.XMLName.Local)
+	assert.Equal(t, ubl.CustomizationEN16931+"#compliant#urn:xeinkauf.de:kosit:xrechnung_3.0", doc.CustomizationID)
+	assert.Equal(t, "380", doc.InvoiceTypeCode)
+	assert.Equal(t, "EUR", doc.DocumentCurrencyCode)
+	require.NotNil(t, doc.AccountingSupplierParty)
+	assert.Equal(t, inv.Supplier.Name, doc.AccountingSupplierParty.Party.PartyLegalEntity.RegistrationName)
+	assert.Equal(t, "VAT", doc.AccountingSupplierParty.Party.PartyTaxScheme[0].TaxScheme.ID)
+	assert.Len(t, doc.InvoiceLines, len(inv.Lines))
+	assert.Equal(t, sum, inv.Totals.Sum.String(), "source must not be modified")
+
+	data, err := doc.Bytes()
+	require.NoError(t, err)
+	out := string(data)
+	assert.True(t, strings.HasPrefix(out, "<?xml"))
+	assert.Contains(t, out, `<Invoice xmlns="`+ubl.NamespaceInvoice+`"`)
+	assert.Contains(t, out, `<cbc:ID>`+doc.ID+`</cbc:ID>`)
+
+	t.Run("peppol", func(t *testing.T) {
+		inv := roundtrip.LoadInvoice(t, "../../examples/de/out/invoice-de-de.json")
+		inv.SetAddons(peppol.V3)
+		doc, err := ubl.FromInvoice(inv)
+		require.NoError(t, err)
+		assert.Equal(t, ubl.CustomizationEN16931+"#compliant#urn:fdc:peppol.eu:2017:poacc:billing:3.0", doc.CustomizationID)
+		assert.Equal(t, "urn:fdc:peppol.eu:2017:poacc:billing:01:1.0", doc.ProfileID)
+
+		inv2, err := doc.Invoice()
+		require.NoError(t, err)
+		assert.Equal(t, []cbc.Key{peppol.V3}, inv2.GetAddons())
+	})
+
+	t.Run("credit note", func(t *testing.T) {
+		cn := roundtrip.LoadInvoice(t, "../../examples/de/out/invoice-de-de.json")
+		cn.Type = bill.InvoiceTypeCreditNote
+		cn.Tax = nil
+		doc, err := ubl.FromInvoice(cn)
+		require.NoError(t, err)
+		assert.True(t, doc.IsCreditNote())
+		assert.Equal(t, "381", doc.CreditNoteTypeCode)
+		assert.Empty(t, doc.InvoiceLines)
+		assert.Len(t, doc.CreditNoteLines, len(cn.Lines))
+		assert.NotNil(t, doc.CreditNoteLines[0].CreditedQuantity)
+		assert.Nil(t, doc.CreditNoteLines[0].InvoicedQuantity)
+	})
+}
+
+func TestParse(t *testing.T) {
+	data := []byte(`<?xml version="1.0" encoding="UTF-8"?>
+<ubl:Invoice xmlns:ubl="urn:oasis:names:specification:ubl:schema:xsd:Invoice-2"
+	xmlns:c="urn:oasis:names:specification:ubl:schema:xsd:CommonAggregateComponents-2"
+	xmlns:b="urn:oasis:names:specification:ubl:schema:xsd:CommonBasicComponents-2"
+	xmlns:ext="urn:oasis:names:specification:ubl:schema:xsd:CommonExtensionComponents-2">
+	<ext:UBLExtensions><ext:UBLExtension><b:ID>ignored</b:ID></ext:UBLExtension></ext:UBLExtensions>
+	<b:ID>INV-1</b:ID>
+	<b:IssueDate>2024-03-01</b:IssueDate>
+	<b:InvoiceTypeCode>380</b:InvoiceTypeCode>
+	<b:DocumentCurrencyCode>EUR</b:DocumentCurrencyCode>
+	<c:AccountingSupplierParty>
+		<c:Party>
+			<c:PartyTaxScheme>
+				<b:CompanyID>DE111111125</b:CompanyID>
+				<c:TaxScheme><b:ID>VAT</b:ID></c:TaxScheme>
+			</c:PartyTaxScheme>
+			<c:PartyLegalEntity><b:RegistrationName>Provide One GmbH</b:RegistrationName></c:PartyLegalEntity>
+		</c:Party>
+	</c:AccountingSupplierParty>
+	<c:LegalMonetaryTotal>
+		<b:PayableAmount currencyID="EUR">119.00</b:PayableAmount>
+	</c:LegalMonetaryTotal>
+	<c:InvoiceLine>
+		<b:ID>1</b:ID>
+		<b:InvoicedQuantity unitCode="HUR">2</b:InvoicedQuantity>
+		<b:LineExtensionAmount currencyID="EUR">100.00</b:LineExtensionAmount>
+		<c:Item>
+			<b:Name>Consulting</b:Name>
+			<c:ClassifiedTaxCategory>
+				<b:ID>S</b:ID>
+				<b:Percent>19</b:Percent>
+				<c:TaxScheme><b:ID>VAT</b:ID></c:TaxScheme>
+			</c:ClassifiedTaxCategory>
+		</c:Item>
+		<c:Price><b:PriceAmount currencyID="EUR">50.00</b:PriceAmount></c:Price>
+	</c:InvoiceLine>
+</ubl:Invoice>`)
+	doc, err := ubl.Parse(data)
+	require.NoError(t, err)
+	assert.Equal(t, "INV-1", doc.ID)
+	assert.Equal(t, "119.00", doc.LegalMonetaryTotal.PayableAmount.Value)
+	assert.Equal(t, "EUR", doc.LegalMonetaryTotal.PayableAmount.CurrencyID)
+
+	inv, err := doc.Invoice()
+	require.NoError(t, err)
+	assert.Equal(t, "INV-1", inv.Code.String())
+	assert.Equal(t, "2024-03-01", inv.IssueDate.String())
+	assert.Equal(t, "DE", inv.Supplier.TaxID.Country.String())
+	assert.Equal(t, "111111125", inv.Supplier.TaxID.Code.String())
+	require.Len(t, inv.Lines, 1)
+	assert.Equal(t, org.UnitHour, inv.Lines[0].Item.Unit)
+	assert.Equal(t, "19%", inv.Lines[0].Taxes[0].Percent.String())
+
+	require.NoError(t, inv.Calculate())
+	assert.Equal(t, "119.00", inv.Totals.Payable.String())
+
+	t.Run("unsupported", func(t *testing.T) {
+		_, err := ubl.Parse([]byte(`<Order xmlns="urn:oasis:names:specification:ubl:schema:xsd:Order-2"></Order>`))
+		assert.ErrorContains(t, err, "unsupported document 'Order'")
+	})
+	t.Run("invalid", func(t *testing.T) {
+		_, err := ubl.Parse([]byte(`<Invoice`))
+		assert.ErrorContains(t, err, "parsing XML")
+	})
+}
@@ -125,6 +125,13 @@ func (d *Def) Zero() num.Amount {
 	return num.MakeAmount(0, d.Subunits)
 }
 
+// SmallestDenominationAmount provides the smallest coin or note available
+// for the currency as an amount, typically used as the increment to round
+// cash payments to.
+func (d *Def) SmallestDenominationAmount() num.Amount {
+	return num.MakeAmount(int64(d.SmallestDenomination), d.Subunits)
+}
+
 // Definitions provides an array of all currency definitions
 // ordered by priority.
 func Definitions() []*Def {
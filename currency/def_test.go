@@ -104,6 +104,12 @@ func TestDefZero(t *testing.T) {
 	})
 }
 
+func TestDefSmallestDenominationAmount(t *testing.T) {
+	assert.Equal(t, "0.05", currency.CHF.Def().SmallestDenominationAmount().String())
+	assert.Equal(t, "1.00", currency.SEK.Def().SmallestDenominationAmount().String())
+	assert.Equal(t, "0.01", currency.EUR.Def().SmallestDenominationAmount().String())
+}
+
 func TestDefByISONumber(t *testing.T) {
 	t.Run("with 978", func(t *testing.T) {
 		d := currency.ByISONumber("978")
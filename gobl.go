@@ -3,6 +3,7 @@ package gobl
 
 import (
 	// import all the dependencies to ensure all init() methods are called.
+	_ "github.com/invopop/gobl/acct"
 	_ "github.com/invopop/gobl/addons"
 	_ "github.com/invopop/gobl/bill"
 	_ "github.com/invopop/gobl/catalogues"
@@ -13,6 +14,9 @@ import (
 	_ "github.com/invopop/gobl/num"
 	_ "github.com/invopop/gobl/org"
 	_ "github.com/invopop/gobl/regimes"
+	_ "github.com/invopop/gobl/tax/intra"
+	_ "github.com/invopop/gobl/tax/oss"
+	_ "github.com/invopop/gobl/tax/report"
 
 	"github.com/invopop/gobl/schema"
 )
@@ -0,0 +1,196 @@
+// Package chain links the documents of a series together by recording in
+// each envelope a hash calculated from a set of the document's fields and
+// the hash of the previous record, as required by fiscal regimes that need
+// to detect documents that were removed or modified after being issued.
+package chain
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/invopop/gobl"
+	"github.com/invopop/gobl/c14n"
+	"github.com/invopop/gobl/cbc"
+	"github.com/invopop/gobl/dsig"
+	"github.com/invopop/gobl/head"
+)
+
+// DefaultProvider is the stamp provider key used to record chained hashes
+// when the config does not define one.
+const DefaultProvider cbc.Key = "chain-hash"
+
+// DefaultSeparator is placed between each of the values used to calculate
+// the hash when the config does not define one.
+const DefaultSeparator = ";"
+
+// InvoiceFields contains a typical set of invoice fields that identify
+// the document and its amounts.
+var InvoiceFields = []string{
+	"issue_date",
+	"series",
+	"code",
+	"totals.total_with_tax",
+}
+
+// Config determines how chained hashes are calculated and recorded.
+type Config struct {
+	// Provider key of the stamp the hash is stored in.
+	Provider cbc.Key
+	// Fields contains the paths to the values of the document used in the
+	// hash, with properties separated by a dot and array positions as
+	// numbers, for example "lines.0.sum".
+	Fields []string
+	// Separator placed between each value.
+	Separator string
+	// Algorithm used to generate the hash, only SHA256 is supported.
+	Algorithm dsig.DigestAlgorithm
+}
+
+// Chain keeps track of the last hash of a series so that new records can
+// be appended to it.
+type Chain struct {
+	cfg  *Config
+	last string
+}
+
+// New creates a new chain using the config that will continue from the
+// last hash provided, which should be empty for new series.
+func New(cfg *Config, last string) *Chain {
+	return &Chain{cfg: cfg, last: last}
+}
+
+// Last provides the hash of the last record in the chain.
+func (c *Chain) Last() string {
+	return c.last
+}
+
+// Append calculates the hash of the envelope's document chained to the
+// last record, and adds it to the header as a stamp. Stamps are included
+// in the signatures, so envelopes must be signed afterwards.
+func (c *Chain) Append(env *gobl.Envelope) error {
+	if env.Signed() {
+		return errors.New("envelope already signed")
+	}
+	h, err := c.cfg.Hash(env, c.last)
+	if err != nil {
+		return err
+	}
+	env.Head.AddStamp(&head.Stamp{
+		Provider: c.cfg.provider(),
+		Value:    h,
+	})
+	c.last = h
+	return nil
+}
+
+// Hash calculates the hash of the envelope's document using the fields of
+// the config and the hash of the previous record.
+func (cfg *Config) Hash(env *gobl.Envelope, prev string) (string, error) {
+	if len(cfg.Fields) == 0 {
+		return "", errors.New("fields required")
+	}
+	if cfg.Algorithm != "" && cfg.Algorithm != dsig.DigestSHA256 {
+		return "", fmt.Errorf("unsupported algorithm '%s'", cfg.Algorithm)
+	}
+	if env.Head == nil || env.Document == nil || env.Document.IsEmpty() {
+		return "", gobl.ErrNoDocument
+	}
+	data, err := documentData(env)
+	if err != nil {
+		return "", err
+	}
+	values := make([]string, 0, len(cfg.Fields)+1)
+	for _, f := range cfg.Fields {
+		v, err := fieldValue(data, f)
+		if err != nil {
+			return "", fmt.Errorf("field '%s': %w", f, err)
+		}
+		values = append(values, v)
+	}
+	values = append(values, prev)
+	in := strings.Join(values, cfg.separator())
+	return dsig.NewSHA256Digest([]byte(in)).Value, nil
+}
+
+// Stamp provides the hash recorded in the envelope, or an empty string.
+func (cfg *Config) Stamp(env *gobl.Envelope) string {
+	if env.Head == nil {
+		return ""
+	}
+	if s := env.Head.Stamp(cfg.provider()); s != nil {
+		return s.Value
+	}
+	return ""
+}
+
+func (cfg *Config) provider() cbc.Key {
+	if cfg.Provider == cbc.KeyEmpty {
+		return DefaultProvider
+	}
+	return cfg.Provider
+}
+
+func (cfg *Config) separator() string {
+	if cfg.Separator == "" {
+		return DefaultSeparator
+	}
+	return cfg.Separator
+}
+
+func documentData(env *gobl.Envelope) (any, error) {
+	data, err := json.Marshal(env.Document)
+	if err != nil {
+		return nil, gobl.ErrMarshal.WithCause(err)
+	}
+	var out any
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber() // keep amounts as they were serialized
+	if err := dec.Decode(&out); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// fieldValue provides the text representation of the value at the path.
+// Missing values are empty, and objects or arrays are provided as
+// canonical JSON.
+func fieldValue(data any, path string) (string, error) {
+	v := data
+	for _, p := range strings.Split(path, ".") {
+		switch o := v.(type) {
+		case map[string]any:
+			v = o[p]
+		case []any:
+			i, err := strconv.Atoi(p)
+			if err != nil {
+				return "", fmt.Errorf("invalid index '%s'", p)
+			}
+			if i < 0 || i >= len(o) {
+				return "", nil
+			}
+			v = o[i]
+		default:
+			return "", nil
+		}
+	}
+	switch o := v.(type) {
+	case nil:
+		return "", nil
+	case string:
+		return o, nil
+	case json.Number:
+		return o.String(), nil
+	case bool:
+		return strconv.FormatBool(o), nil
+	default:
+		out, err := c14n.MarshalJSON(o)
+		if err != nil {
+			return "", err
+		}
+		return string(out), nil
+	}
+}
@@ -0,0 +1,120 @@
+package chain_test
+
+import (
+	"encoding/json"
+	"os"
+	"testing"
+
+	"github.com/invopop/gobl"
+	"github.com/invopop/gobl/cbc"
+	"github.com/invopop/gobl/dsig"
+	"github.com/invopop/gobl/head/chain"
+	"github.com/invopop/gobl/note"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func testEnvelope(t *testing.T, title string) *gobl.Envelope {
+	t.Helper()
+	env, err := gobl.Envelop(&note.Message{
+		Title:   title,
+		Content: "Test content",
+		Meta:    cbc.Meta{"num": "1"},
+	})
+	require.NoError(t, err)
+	return env
+}
+
+func testConfig() *chain.Config {
+	return &chain.Config{
+		Fields: []string{"title", "content"},
+	}
+}
+
+func TestConfigHash(t *testing.T) {
+	env := testEnvelope(t, "First")
+	t.Run("fields and previous", func(t *testing.T) {
+		cfg := testConfig()
+		h, err := cfg.Hash(env, "")
+		require.NoError(t, err)
+		assert.Equal(t, dsig.NewSHA256Digest([]byte("First;Test content;")).Value, h)
+
+		h, err = cfg.Hash(env, "abc")
+		require.NoError(t, err)
+		assert.Equal(t, dsig.NewSHA256Digest([]byte("First;Test content;abc")).Value, h)
+	})
+	t.Run("separator", func(t *testing.T) {
+		cfg := testConfig()
+		cfg.Separator = "|"
+		h, err := cfg.Hash(env, "abc")
+		require.NoError(t, err)
+		assert.Equal(t, dsig.NewSHA256Digest([]byte("First|Test content|abc")).Value, h)
+	})
+	t.Run("objects and missing values", func(t *testing.T) {
+		cfg := &chain.Config{
+			Fields: []string{"meta", "meta.num", "foo.bar", "title.0"},
+		}
+		h, err := cfg.Hash(env, "")
+		require.NoError(t, err)
+		assert.Equal(t, dsig.NewSHA256Digest([]byte(`{"num":"1"};1;;;`)).Value, h)
+	})
+	t.Run("no fields", func(t *testing.T) {
+		_, err := new(chain.Config).Hash(env, "")
+		assert.ErrorContains(t, err, "fields required")
+	})
+	t.Run("algorithm", func(t *testing.T) {
+		cfg := testConfig()
+		cfg.Algorithm = "md5"
+		_, err := cfg.Hash(env, "")
+		assert.ErrorContains(t, err, "unsupported algorithm 'md5'")
+	})
+	t.Run("no document", func(t *testing.T) {
+		_, err := testConfig().Hash(gobl.NewEnvelope(), "")
+		assert.ErrorIs(t, err, gobl.ErrNoDocument)
+	})
+}
+
+func TestChainAppend(t *testing.T) {
+	cfg := testConfig()
+	c := chain.New(cfg, "")
+	e1 := testEnvelope(t, "First")
+	e2 := testEnvelope(t, "Second")
+	require.NoError(t, c.Append(e1))
+	require.NoError(t, c.Append(e2))
+
+	h1 := cfg.Stamp(e1)
+	h2 := cfg.Stamp(e2)
+	assert.NotEmpty(t, h1)
+	assert.Equal(t, h2, c.Last())
+	exp, err := cfg.Hash(e2, h1)
+	require.NoError(t, err)
+	assert.Equal(t, exp, h2)
+	assert.NotNil(t, e2.Head.Stamp(chain.DefaultProvider))
+
+	t.Run("custom provider", func(t *testing.T) {
+		cfg := testConfig()
+		cfg.Provider = "at-hash"
+		env := testEnvelope(t, "First")
+		require.NoError(t, chain.New(cfg, "").Append(env))
+		assert.Equal(t, h1, env.Head.Stamp("at-hash").Value)
+	})
+	t.Run("signed", func(t *testing.T) {
+		env := testEnvelope(t, "Third")
+		require.NoError(t, env.Sign(dsig.NewES256Key()))
+		err := c.Append(env)
+		assert.ErrorContains(t, err, "envelope already signed")
+		assert.Equal(t, h2, c.Last())
+	})
+}
+
+func TestInvoiceFields(t *testing.T) {
+	data, err := os.ReadFile("../../examples/es/out/invoice-es-es.json")
+	require.NoError(t, err)
+	env := new(gobl.Envelope)
+	require.NoError(t, json.Unmarshal(data, env))
+
+	cfg := &chain.Config{Fields: chain.InvoiceFields}
+	h, err := cfg.Hash(env, "prev")
+	require.NoError(t, err)
+	assert.Equal(t, dsig.NewSHA256Digest([]byte("2022-02-01;;SAMPLE-001;1970.20;prev")).Value, h)
+}
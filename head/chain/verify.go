@@ -0,0 +1,86 @@
+package chain
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/invopop/gobl"
+	"github.com/invopop/gobl/uuid"
+)
+
+// Break describes a record where the chain could not be verified.
+type Break struct {
+	// Position of the record in the series provided.
+	Index int
+	// UUID of the envelope.
+	UUID uuid.UUID
+	// Message with the details of the problem.
+	Message string
+}
+
+// Breaks contains the list of problems found while verifying a series.
+type Breaks []*Break
+
+// Error provides a description of the break.
+func (b *Break) Error() string {
+	return fmt.Sprintf("record %d (%s): %s", b.Index, b.UUID, b.Message)
+}
+
+// Error joins the descriptions of all the breaks.
+func (bs Breaks) Error() string {
+	msgs := make([]string, len(bs))
+	for i, b := range bs {
+		msgs[i] = b.Error()
+	}
+	return strings.Join(msgs, "; ")
+}
+
+// Verify walks through the envelopes of a series in order, starting from
+// the previous hash provided, and checks that the hashes recorded match
+// those calculated from each document and its predecessor. Once a break
+// is found, the recorded hash is used to continue so that each problem is
+// only reported once. Records missing a stamp may have been inserted into
+// the series or had their stamp removed, so the following record may be
+// chained to either the hash expected for them or the previous one.
+// Breaks are returned as an error.
+func (cfg *Config) Verify(prev string, envs ...*gobl.Envelope) error {
+	var breaks Breaks
+	var missing string // expected hash of the last records without a stamp
+	for i, env := range envs {
+		b := &Break{Index: i}
+		if env.Head != nil {
+			b.UUID = env.Head.UUID
+		}
+		rec := cfg.Stamp(env)
+		if rec == "" {
+			b.Message = fmt.Sprintf("missing %s stamp", cfg.provider())
+			breaks = append(breaks, b)
+			base := prev
+			if missing != "" {
+				base = missing
+			}
+			missing, _ = cfg.Hash(env, base) // errors reported by the stamp
+			continue
+		}
+		h, err := cfg.Hash(env, prev)
+		if err == nil && h != rec && missing != "" {
+			if mh, merr := cfg.Hash(env, missing); merr == nil && mh == rec {
+				h = mh
+			}
+		}
+		missing = ""
+		switch {
+		case err != nil:
+			b.Message = err.Error()
+			breaks = append(breaks, b)
+		case h != rec:
+			b.Message = fmt.Sprintf("hash mismatch, expected '%s'", h)
+			breaks = append(breaks, b)
+		}
+		prev = rec
+	}
+	if len(breaks) > 0 {
+		return breaks
+	}
+	return nil
+}
@@ -0,0 +1,76 @@
+package chain_test
+
+import (
+	"testing"
+
+	"github.com/invopop/gobl"
+	"github.com/invopop/gobl/head/chain"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func testSeries(t *testing.T, cfg *chain.Config, titles ...string) []*gobl.Envelope {
+	t.Helper()
+	c := chain.New(cfg, "")
+	envs := make([]*gobl.Envelope, len(titles))
+	for i, title := range titles {
+		envs[i] = testEnvelope(t, title)
+		require.NoError(t, c.Append(envs[i]))
+	}
+	return envs
+}
+
+func TestConfigVerify(t *testing.T) {
+	cfg := testConfig()
+	t.Run("valid", func(t *testing.T) {
+		envs := testSeries(t, cfg, "A", "B", "C")
+		assert.NoError(t, cfg.Verify("", envs...))
+	})
+	t.Run("continued series", func(t *testing.T) {
+		envs := testSeries(t, cfg, "A", "B", "C")
+		assert.NoError(t, cfg.Verify(cfg.Stamp(envs[0]), envs[1:]...))
+	})
+	t.Run("modified document", func(t *testing.T) {
+		envs := testSeries(t, cfg, "A", "B", "C")
+		envs[1] = testEnvelope(t, "B2")
+		envs[1].Head.Stamps = testSeries(t, cfg, "A", "B")[1].Head.Stamps
+		err := cfg.Verify("", envs...)
+		require.Error(t, err)
+		breaks, ok := err.(chain.Breaks)
+		require.True(t, ok)
+		require.Len(t, breaks, 1)
+		assert.Equal(t, 1, breaks[0].Index)
+		assert.Equal(t, envs[1].Head.UUID, breaks[0].UUID)
+		assert.Contains(t, breaks[0].Message, "hash mismatch")
+	})
+	t.Run("removed record", func(t *testing.T) {
+		envs := testSeries(t, cfg, "A", "B", "C", "D")
+		err := cfg.Verify("", envs[0], envs[2], envs[3])
+		breaks, ok := err.(chain.Breaks)
+		require.True(t, ok)
+		require.Len(t, breaks, 1)
+		assert.Equal(t, 1, breaks[0].Index)
+	})
+	t.Run("missing stamp", func(t *testing.T) {
+		envs := testSeries(t, cfg, "A", "B")
+		envs = append(envs[:1], testEnvelope(t, "X"), envs[1])
+		err := cfg.Verify("", envs...)
+		assert.EqualError(t, err, "record 1 ("+envs[1].Head.UUID.String()+"): missing chain-hash stamp")
+	})
+	t.Run("removed stamp", func(t *testing.T) {
+		envs := testSeries(t, cfg, "A", "B", "C")
+		envs[1].Head.Stamps = nil
+		err := cfg.Verify("", envs...)
+		assert.EqualError(t, err, "record 1 ("+envs[1].Head.UUID.String()+"): missing chain-hash stamp")
+
+		envs = testSeries(t, cfg, "A", "B", "C", "D")
+		envs[1].Head.Stamps = nil
+		envs[2].Head.Stamps = nil
+		err = cfg.Verify("", envs...)
+		breaks, ok := err.(chain.Breaks)
+		require.True(t, ok)
+		require.Len(t, breaks, 2)
+		assert.Equal(t, 1, breaks[0].Index)
+		assert.Equal(t, 2, breaks[1].Index)
+	})
+}
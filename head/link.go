@@ -2,12 +2,18 @@ package head
 
 import (
 	"fmt"
+	"regexp"
+	"strings"
 
 	"github.com/invopop/gobl/cbc"
 	"github.com/invopop/validation"
 	"github.com/invopop/validation/is"
 )
 
+// dataURLPattern matches data URLs with a MIME type and optional
+// parameters, as defined in RFC 2397.
+var dataURLPattern = regexp.MustCompile(`^data:[a-z]+/[a-z0-9.+-]+(;[a-z0-9-]+=[^;,]+)*(;base64)?,`)
+
 // Link defines a link between this document and another resource. Much like stamps,
 // links must be defined with a specific key, but do allow for additional data that
 // can help with presentation. It is important that a link once generated cannot be
@@ -24,7 +30,8 @@ type Link struct {
 	Description string `json:"description,omitempty" jsonschema:"title=Description"`
 	// Expected MIME type of the link's content.
 	MIME string `json:"mime,omitempty" jsonschema:"title=MIME Type,format=mime"`
-	// URL of the resource.
+	// URL of the resource, which may also be a data URL with the contents
+	// embedded, such as the image of a payment code.
 	URL string `json:"url" jsonschema:"title=URL,format=uri"`
 }
 
@@ -35,10 +42,19 @@ func (l *Link) Validate() error {
 		validation.Field(&l.Title),       // not required
 		validation.Field(&l.Description), // not required
 		validation.Field(&l.MIME),
-		validation.Field(&l.URL, validation.Required, is.URL),
+		validation.Field(&l.URL,
+			validation.Required,
+			validation.When(!isDataURL(l.URL), is.URL),
+			validation.When(isDataURL(l.URL), validation.Match(dataURLPattern).Error("must be a valid data URL")),
+		),
 	)
 }
 
+// isDataURL checks if the URL uses the "data" scheme.
+func isDataURL(url string) bool {
+	return strings.HasPrefix(url, "data:")
+}
+
 // LinkByKey finds the link with the given key from the provided list.
 func LinkByKey(list []*Link, k cbc.Key) *Link {
 	for _, l := range list {
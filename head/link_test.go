@@ -27,6 +27,17 @@ func TestLinkValidation(t *testing.T) {
 		require.ErrorContains(t, l.Validate(), "url: must be a valid URL")
 	})
 
+	t.Run("data url", func(t *testing.T) {
+		l := &head.Link{
+			Key:  "test",
+			MIME: "image/png",
+			URL:  "data:image/png;base64,iVBORw0KGgo=",
+		}
+		assert.NoError(t, l.Validate())
+		l.URL = "data:,missing type"
+		require.ErrorContains(t, l.Validate(), "url: must be a valid data URL")
+	})
+
 	t.Run("missing url", func(t *testing.T) {
 		l := &head.Link{
 			Key:   "test",
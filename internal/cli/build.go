@@ -4,12 +4,19 @@ import (
 	"context"
 
 	"github.com/invopop/gobl"
+	"github.com/invopop/gobl/bill"
+	"github.com/invopop/gobl/bill/sequence"
+	"github.com/invopop/gobl/cbc"
 	"github.com/invopop/gobl/schema"
 )
 
 // BuildOptions are the options used for building and validating GOBL data.
 type BuildOptions struct {
 	*ParseOptions
+
+	// When set, invoices without a code will be assigned the next code
+	// from the sequencer.
+	Sequencer *sequence.Sequencer
 }
 
 // Build builds and validates GOBL data. Only structured errors are returned,
@@ -17,7 +24,23 @@ type BuildOptions struct {
 // internal CLI functions. The object is to ensure that errors are always
 // structured in a consistent manner.
 func Build(ctx context.Context, opts *BuildOptions) (any, error) {
-	obj, err := parseGOBLData(ctx, opts.ParseOptions)
+	obj, err := build(ctx, opts.ParseOptions)
+	if err != nil {
+		return nil, err
+	}
+
+	if opts.Sequencer != nil {
+		if err := assignCode(ctx, opts.Sequencer, obj); err != nil {
+			return nil, err
+		}
+	}
+
+	return obj, nil
+}
+
+// build parses, calculates, and validates the GOBL data.
+func build(ctx context.Context, opts *ParseOptions) (any, error) {
+	obj, err := parseGOBLData(ctx, opts)
 	if err != nil {
 		return nil, wrapError(StatusUnprocessableEntity, err)
 	}
@@ -57,3 +80,47 @@ func Build(ctx context.Context, opts *BuildOptions) (any, error) {
 
 	panic("parsed data must be either an envelope or a document")
 }
+
+// assignCode provides the invoice inside the built object, if any, with the
+// next code of its sequence. Codes are only assigned once the object has
+// been calculated and validated so that invoices that are rejected never
+// use up a number. The object is then calculated and validated again so
+// that the code is normalized, checked by the regime and addons, and
+// included in the envelope's digest.
+func assignCode(ctx context.Context, seq *sequence.Sequencer, obj any) error {
+	var doc *schema.Object
+	env, isEnv := obj.(*gobl.Envelope)
+	if isEnv {
+		doc = env.Document
+	} else {
+		doc, _ = obj.(*schema.Object)
+	}
+	if doc == nil {
+		return nil
+	}
+	inv, ok := doc.Instance().(*bill.Invoice)
+	if !ok || inv.Code != cbc.CodeEmpty {
+		return nil
+	}
+	if err := seq.Assign(ctx, inv); err != nil {
+		return wrapError(StatusUnprocessableEntity, err)
+	}
+	if isEnv {
+		if err := env.Calculate(); err != nil {
+			return wrapError(StatusUnprocessableEntity, err)
+		}
+		if err := env.Validate(); err != nil {
+			return wrapError(StatusUnprocessableEntity, err)
+		}
+		return nil
+	}
+	if err := inv.Calculate(); err != nil {
+		err = gobl.ErrCalculation.WithCause(err)
+		return wrapError(StatusUnprocessableEntity, err)
+	}
+	if err := doc.Validate(); err != nil {
+		err = gobl.ErrValidation.WithCause(err)
+		return wrapError(StatusUnprocessableEntity, err)
+	}
+	return nil
+}
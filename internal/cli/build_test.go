@@ -13,8 +13,11 @@ import (
 	"gitlab.com/flimzy/testy"
 
 	"github.com/invopop/gobl"
+	"github.com/invopop/gobl/bill"
+	"github.com/invopop/gobl/bill/sequence"
 	"github.com/invopop/gobl/dsig"
 	"github.com/invopop/gobl/note"
+	"github.com/invopop/gobl/schema"
 )
 
 var (
@@ -304,6 +307,30 @@ func TestBuild(t *testing.T) {
 			},
 		}
 	})
+	tests.Add("outlays", func(t *testing.T) interface{} {
+		return tt{
+			opts: &BuildOptions{
+				ParseOptions: &ParseOptions{
+					Input:   testFileReader(t, "testdata/invoice-es-es-outlays.yaml"),
+					Envelop: true,
+				},
+			},
+		}
+	})
+	tests.Add("outlay without amount", func(t *testing.T) interface{} {
+		return tt{
+			opts: &BuildOptions{
+				ParseOptions: &ParseOptions{
+					Input:   testFileReader(t, "testdata/invoice-es-es-outlays.yaml"),
+					Envelop: true,
+					SetYAML: map[string]string{
+						"outlays": `[{"description": "Notary fees", "amount": "0"}]`,
+					},
+				},
+			},
+			err: "code=422, message=doc: (outlays: (0: (amount: must be greater than 0.).).).",
+		}
+	})
 
 	tests.Run(t, func(t *testing.T, tt tt) {
 		t.Parallel()
@@ -355,3 +382,92 @@ func TestBuildWithPartialEnvelope(t *testing.T) {
 		assert.Equal(t, "We hope you like this test message!", msg.Content)
 	})
 }
+
+func TestBuildWithSequencer(t *testing.T) {
+	data, err := os.ReadFile("testdata/invoice-es-es.yaml")
+	require.NoError(t, err)
+	data = []byte(strings.Replace(string(data), "code: \"SAMPLE-001\"\n", "", 1))
+	f, err := sequence.ParseFormat("{year}-{number:4}")
+	require.NoError(t, err)
+	seq := sequence.New(sequence.NewMemoryStore(), sequence.WithFormat(f))
+
+	build := func() *bill.Invoice {
+		t.Helper()
+		opts := &BuildOptions{
+			ParseOptions: &ParseOptions{
+				Input: strings.NewReader(string(data)),
+			},
+			Sequencer: seq,
+		}
+		got, err := Build(context.Background(), opts)
+		require.NoError(t, err)
+		inv, ok := got.(*schema.Object).Instance().(*bill.Invoice)
+		require.True(t, ok)
+		return inv
+	}
+	assert.Equal(t, "2022-0001", build().Code.String())
+	assert.Equal(t, "2022-0002", build().Code.String())
+
+	t.Run("invalid invoice", func(t *testing.T) {
+		invalid := strings.Replace(string(data), "name: \"Sample Consumer\"", "", 1)
+		opts := &BuildOptions{
+			ParseOptions: &ParseOptions{
+				Input: strings.NewReader(invalid),
+			},
+			Sequencer: seq,
+		}
+		_, err := Build(context.Background(), opts)
+		assert.ErrorContains(t, err, "customer: (name: cannot be blank.)")
+		assert.Equal(t, "2022-0003", build().Code.String(), "invalid invoice should not use up a number")
+	})
+	t.Run("envelope", func(t *testing.T) {
+		opts := &BuildOptions{
+			ParseOptions: &ParseOptions{
+				Input:   strings.NewReader(string(data)),
+				Envelop: true,
+			},
+			Sequencer: seq,
+		}
+		got, err := Build(context.Background(), opts)
+		require.NoError(t, err)
+		env := got.(*gobl.Envelope)
+		inv := env.Extract().(*bill.Invoice)
+		assert.Equal(t, "2022-0004", inv.Code.String())
+		require.NoError(t, env.Validate(), "digest should include the code")
+	})
+
+	t.Run("code format", func(t *testing.T) {
+		data, err := os.ReadFile("../../examples/br/invoice-services.json")
+		require.NoError(t, err)
+		data = []byte(strings.Replace(string(data), `"code": "1",`, `"$addons": ["br-nfse-v1"],`, 1))
+		opts := &BuildOptions{
+			ParseOptions: &ParseOptions{
+				Input: strings.NewReader(string(data)),
+			},
+			Sequencer: seq,
+		}
+		_, err = Build(context.Background(), opts)
+		assert.ErrorContains(t, err, "code: must be in a valid format")
+
+		f, err := sequence.ParseFormat("{number}")
+		require.NoError(t, err)
+		opts.Input = strings.NewReader(string(data))
+		opts.Sequencer = sequence.New(sequence.NewMemoryStore(), sequence.WithFormat(f))
+		got, err := Build(context.Background(), opts)
+		require.NoError(t, err)
+		inv := got.(*schema.Object).Instance().(*bill.Invoice)
+		assert.Equal(t, "1", inv.Code.String())
+	})
+	t.Run("existing code", func(t *testing.T) {
+		opts := &BuildOptions{
+			ParseOptions: &ParseOptions{
+				Input: testFileReader(t, "testdata/invoice-es-es.yaml"),
+			},
+			Sequencer: seq,
+		}
+		got, err := Build(context.Background(), opts)
+		require.NoError(t, err)
+		inv := got.(*schema.Object).Instance().(*bill.Invoice)
+		assert.Equal(t, "SAMPLE-001", inv.Code.String())
+	})
+}
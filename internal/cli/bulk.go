@@ -13,9 +13,12 @@ import (
 	"sync/atomic"
 	"time"
 
+	"github.com/invopop/gobl/bill"
+	"github.com/invopop/gobl/cal"
 	"github.com/invopop/gobl/data"
 	"github.com/invopop/gobl/dsig"
 	"github.com/invopop/gobl/schema"
+	"github.com/invopop/gobl/tax/intra"
 )
 
 // BulkRequest represents a single request in the stream of bulk requests.
@@ -79,6 +82,15 @@ type BuildRequest struct {
 	Envelop  bool   `json:"envelop"`
 }
 
+// BuildCSVRequest is the payload for a request to build invoices from
+// rows of CSV data using a column mapping.
+type BuildCSVRequest struct {
+	Template []byte `json:"template"`
+	Data     []byte `json:"data"`
+	Mapping  []byte `json:"mapping"`
+	Envelop  bool   `json:"envelop"`
+}
+
 // SignRequest is the payload for a sign request.
 type SignRequest struct {
 	Template   []byte           `json:"template"`
@@ -112,6 +124,63 @@ type ReplicateRequest struct {
 	Data []byte `json:"data"`
 }
 
+// MigrateRequest defines the payload used to upgrade a stored document
+// or envelope.
+type MigrateRequest struct {
+	Data []byte `json:"data"`
+	From string `json:"from"`
+}
+
+// InvoiceRequest defines the payload used to build a new invoice from
+// order or delivery documents.
+type InvoiceRequest struct {
+	Sources []*InvoiceRequestSource `json:"sources"`
+	Envelop bool                    `json:"envelop"`
+}
+
+// InvoiceRequestSource defines a single source document for an invoice
+// request alongside an optional selection of its lines.
+type InvoiceRequestSource struct {
+	Data  []byte                `json:"data"`
+	Lines []*bill.LineSelection `json:"lines,omitempty"`
+}
+
+// IntraRequest defines the payload used to prepare an intra-community
+// summary from a set of invoices.
+type IntraRequest struct {
+	Type      string                  `json:"type"`
+	TaxID     string                  `json:"tax_id"`
+	Period    cal.Period              `json:"period"`
+	Invoices  [][]byte                `json:"invoices"`
+	Intrastat *intra.IntrastatOptions `json:"intrastat,omitempty"`
+	Envelop   bool                    `json:"envelop"`
+}
+
+// ConvertRequest defines the payload used to convert an invoice to or from
+// a foreign format.
+type ConvertRequest struct {
+	Format string `json:"format"`
+	Data   []byte `json:"data"`
+}
+
+// ConvertResponse contains the document produced when converting a GOBL
+// invoice into a foreign format.
+type ConvertResponse struct {
+	Data []byte `json:"data"`
+}
+
+// RenderRequest defines the payload used to produce a human readable copy
+// of an envelope or invoice.
+type RenderRequest struct {
+	Data []byte `json:"data"`
+	Lang string `json:"lang"`
+}
+
+// RenderResponse contains the HTML document produced by a render request.
+type RenderResponse struct {
+	Data []byte `json:"data"`
+}
+
 // SchemaRequest defines a body used to request a specific JSON schema
 type SchemaRequest struct {
 	Path string `json:"path"`
@@ -215,6 +284,30 @@ func processRequest(ctx context.Context, req BulkRequest, seq int64, bulkOpts *B
 			return res
 		}
 		res.Payload, _ = marshal(env)
+	case "build-csv":
+		bld := &BuildCSVRequest{}
+		if err := json.Unmarshal(req.Payload, bld); err != nil {
+			res.Error = wrapErrorf(StatusUnprocessableEntity, "invalid payload: %w", err)
+			return res
+		}
+		opts := &BuildCSVOptions{
+			BuildOptions: &BuildOptions{
+				ParseOptions: &ParseOptions{
+					Input:   bytes.NewReader(bld.Data),
+					Envelop: bld.Envelop,
+				},
+			},
+			Mapping: bytes.NewReader(bld.Mapping),
+		}
+		if len(bld.Template) > 0 {
+			opts.Template = bytes.NewReader(bld.Template)
+		}
+		docs, err := BuildCSV(ctx, opts)
+		if err != nil {
+			res.Error = wrapError(StatusUnprocessableEntity, err)
+			return res
+		}
+		res.Payload, _ = marshal(docs)
 	case "sign":
 		bld := &SignRequest{}
 		if err := json.Unmarshal(req.Payload, bld); err != nil {
@@ -276,6 +369,108 @@ func processRequest(ctx context.Context, req BulkRequest, seq int64, bulkOpts *B
 			return res
 		}
 		res.Payload, _ = marshal(env)
+	case "migrate":
+		mr := &MigrateRequest{}
+		if err := json.Unmarshal(req.Payload, mr); err != nil {
+			res.Error = wrapErrorf(StatusUnprocessableEntity, "invalid payload: %w", err)
+			return res
+		}
+		opts := &MigrateOptions{
+			Input: bytes.NewReader(mr.Data),
+			From:  mr.From,
+		}
+		mres, err := Migrate(ctx, opts)
+		if err != nil {
+			res.Error = wrapError(StatusUnprocessableEntity, err)
+			return res
+		}
+		res.Payload, _ = marshal(mres)
+	case "invoice":
+		ir := &InvoiceRequest{}
+		if err := json.Unmarshal(req.Payload, ir); err != nil {
+			res.Error = wrapErrorf(StatusUnprocessableEntity, "invalid payload: %w", err)
+			return res
+		}
+		opts := &InvoiceOptions{
+			Sources: make([]*InvoiceSource, len(ir.Sources)),
+			Envelop: ir.Envelop,
+		}
+		for i, src := range ir.Sources {
+			if src == nil {
+				res.Error = wrapErrorf(StatusUnprocessableEntity, "invalid payload: source %d: missing", i)
+				return res
+			}
+			opts.Sources[i] = &InvoiceSource{
+				Input: bytes.NewReader(src.Data),
+				Lines: src.Lines,
+			}
+		}
+		env, err := Invoice(ctx, opts)
+		if err != nil {
+			res.Error = wrapError(StatusUnprocessableEntity, err)
+			return res
+		}
+		res.Payload, _ = marshal(env)
+	case "intra":
+		ir := &IntraRequest{}
+		if err := json.Unmarshal(req.Payload, ir); err != nil {
+			res.Error = wrapErrorf(StatusUnprocessableEntity, "invalid payload: %w", err)
+			return res
+		}
+		opts := &IntraOptions{
+			Type:      ir.Type,
+			TaxID:     ir.TaxID,
+			Period:    ir.Period,
+			Inputs:    make([]io.Reader, len(ir.Invoices)),
+			Intrastat: ir.Intrastat,
+			Envelop:   ir.Envelop,
+		}
+		for i, data := range ir.Invoices {
+			opts.Inputs[i] = bytes.NewReader(data)
+		}
+		doc, err := Intra(ctx, opts)
+		if err != nil {
+			res.Error = wrapError(StatusUnprocessableEntity, err)
+			return res
+		}
+		res.Payload, _ = marshal(doc)
+	case "convert":
+		cr := &ConvertRequest{}
+		if err := json.Unmarshal(req.Payload, cr); err != nil {
+			res.Error = wrapErrorf(StatusUnprocessableEntity, "invalid payload: %w", err)
+			return res
+		}
+		opts := &ConvertOptions{
+			Format: cr.Format,
+			Input:  bytes.NewReader(cr.Data),
+		}
+		out, err := Convert(ctx, opts)
+		if err != nil {
+			res.Error = wrapError(StatusUnprocessableEntity, err)
+			return res
+		}
+		if data, ok := out.([]byte); ok {
+			out = &ConvertResponse{Data: data}
+		}
+		res.Payload, _ = marshal(out)
+	case "render":
+		rr := &RenderRequest{}
+		if err := json.Unmarshal(req.Payload, rr); err != nil {
+			res.Error = wrapErrorf(StatusUnprocessableEntity, "invalid payload: %w", err)
+			return res
+		}
+		opts := &RenderOptions{
+			ParseOptions: &ParseOptions{
+				Input: bytes.NewReader(rr.Data),
+			},
+			Lang: rr.Lang,
+		}
+		data, err := Render(ctx, opts)
+		if err != nil {
+			res.Error = wrapError(StatusUnprocessableEntity, err)
+			return res
+		}
+		res.Payload, _ = marshal(&RenderResponse{Data: data})
 	case "keygen":
 		key := dsig.NewES256Key()
 
@@ -459,6 +459,43 @@ func TestBulk(t *testing.T) { //nolint:gocyclo
 			},
 		}
 	})
+	tests.Add("build-csv, invalid mapping", func(t *testing.T) interface{} {
+		data, err := os.ReadFile("testdata/lines.csv")
+		if err != nil {
+			t.Fatal(err)
+		}
+		req, err := json.Marshal(map[string]interface{}{
+			"action": "build-csv",
+			"req_id": "asdf",
+			"payload": map[string]interface{}{
+				"data":    data,
+				"mapping": []byte("key: Invoice"),
+			},
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		return tt{
+			opts: &BulkOptions{
+				In: bytes.NewReader(req),
+			},
+			want: []*BulkResponse{
+				{
+					ReqID: "asdf",
+					SeqID: 1,
+					Error: &Error{
+						Code:    422,
+						Message: "invalid mapping: line: cannot be blank.",
+					},
+					IsFinal: false,
+				},
+				{
+					SeqID:   2,
+					IsFinal: true,
+				},
+			},
+		}
+	})
 	tests.Add("correct, success", func(t *testing.T) interface{} {
 		payload, err := os.ReadFile("testdata/success.json")
 		if err != nil {
@@ -566,6 +603,346 @@ func TestBulk(t *testing.T) { //nolint:gocyclo
 			},
 		}
 	})
+	tests.Add("invoice, success", func(t *testing.T) interface{} {
+		payload, err := os.ReadFile("testdata/order.yaml")
+		if err != nil {
+			t.Fatal(err)
+		}
+		req, err := json.Marshal(map[string]interface{}{
+			"action": "invoice",
+			"req_id": "asdf",
+			"payload": map[string]interface{}{
+				"sources": []interface{}{
+					map[string]interface{}{
+						"data": base64.StdEncoding.EncodeToString(payload),
+						"lines": []interface{}{
+							map[string]interface{}{"i": 2, "quantity": "1"},
+						},
+					},
+				},
+			},
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		return tt{
+			opts: &BulkOptions{
+				In: bytes.NewReader(req),
+			},
+			want: []*BulkResponse{
+				{
+					ReqID: "asdf",
+					SeqID: 1,
+					Payload: json.RawMessage(`{
+						"$schema": "https://gobl.org/draft-0/bill/invoice",
+						"type": "standard"
+					}`),
+					IsFinal: false,
+				},
+				{
+					SeqID:   2,
+					IsFinal: true,
+				},
+			},
+		}
+	})
+	tests.Add("invoice, nil source", func(t *testing.T) interface{} {
+		req, err := json.Marshal(map[string]interface{}{
+			"action": "invoice",
+			"req_id": "asdf",
+			"payload": map[string]interface{}{
+				"sources": []interface{}{nil},
+			},
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		return tt{
+			opts: &BulkOptions{
+				In: bytes.NewReader(req),
+			},
+			want: []*BulkResponse{
+				{
+					ReqID:   "asdf",
+					SeqID:   1,
+					IsFinal: false,
+					Error: &Error{
+						Code:    422,
+						Message: "invalid payload: source 0: missing",
+					},
+				},
+				{
+					SeqID:   2,
+					IsFinal: true,
+				},
+			},
+		}
+	})
+	tests.Add("migrate, success", func(t *testing.T) interface{} {
+		payload, err := os.ReadFile("testdata/nosig.json")
+		if err != nil {
+			t.Fatal(err)
+		}
+		req, err := json.Marshal(map[string]interface{}{
+			"action": "migrate",
+			"req_id": "asdf",
+			"payload": map[string]interface{}{
+				"data": base64.StdEncoding.EncodeToString(payload),
+			},
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		return tt{
+			opts: &BulkOptions{
+				In: bytes.NewReader(req),
+			},
+			want: []*BulkResponse{
+				{
+					ReqID: "asdf",
+					SeqID: 1,
+					Payload: json.RawMessage(`{
+						"migrations": [
+							{
+								"schema": "https://gobl.org/draft-0/bill/invoice",
+								"version": "v0.204.0",
+								"key": "bill-outlay-legacy",
+								"description": "Outlays with the legacy ` + "`desc`" + ` and ` + "`paid`" + ` properties, ignored since they were removed, are deleted."
+							}
+						]
+					}`),
+					IsFinal: false,
+				},
+				{
+					SeqID:   2,
+					IsFinal: true,
+				},
+			},
+		}
+	})
+	tests.Add("intra, success", func(t *testing.T) interface{} {
+		payload, err := os.ReadFile("testdata/invoice-es-nl-goods.yaml")
+		if err != nil {
+			t.Fatal(err)
+		}
+		req, err := json.Marshal(map[string]interface{}{
+			"action": "intra",
+			"req_id": "asdf",
+			"payload": map[string]interface{}{
+				"type":   "sales-list",
+				"tax_id": "ESB98602642",
+				"period": map[string]interface{}{
+					"start": "2024-01-01",
+					"end":   "2024-03-31",
+				},
+				"invoices": []interface{}{
+					base64.StdEncoding.EncodeToString(payload),
+				},
+			},
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		return tt{
+			opts: &BulkOptions{
+				In: bytes.NewReader(req),
+			},
+			want: []*BulkResponse{
+				{
+					ReqID: "asdf",
+					SeqID: 1,
+					Payload: json.RawMessage(`{
+						"$schema": "https://gobl.org/draft-0/tax/intra/sales-list",
+						"currency": "EUR",
+						"total": "575.00"
+					}`),
+					IsFinal: false,
+				},
+				{
+					SeqID:   2,
+					IsFinal: true,
+				},
+			},
+		}
+	})
+	tests.Add("convert, from ubl", func(t *testing.T) interface{} {
+		payload, err := os.ReadFile("testdata/invoice.ubl.xml")
+		if err != nil {
+			t.Fatal(err)
+		}
+		req, err := json.Marshal(map[string]interface{}{
+			"action": "convert",
+			"req_id": "asdf",
+			"payload": map[string]interface{}{
+				"format": "ubl",
+				"data":   base64.StdEncoding.EncodeToString(payload),
+			},
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		return tt{
+			opts: &BulkOptions{
+				In: bytes.NewReader(req),
+			},
+			want: []*BulkResponse{
+				{
+					ReqID: "asdf",
+					SeqID: 1,
+					Payload: json.RawMessage(`{
+						"$schema": "https://gobl.org/draft-0/envelope"
+					}`),
+					IsFinal: false,
+				},
+				{
+					SeqID:   2,
+					IsFinal: true,
+				},
+			},
+		}
+	})
+	tests.Add("convert, from cii", func(t *testing.T) interface{} {
+		payload, err := os.ReadFile("testdata/invoice.cii.xml")
+		if err != nil {
+			t.Fatal(err)
+		}
+		req, err := json.Marshal(map[string]interface{}{
+			"action": "convert",
+			"req_id": "asdf",
+			"payload": map[string]interface{}{
+				"format": "cii",
+				"data":   base64.StdEncoding.EncodeToString(payload),
+			},
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		return tt{
+			opts: &BulkOptions{
+				In: bytes.NewReader(req),
+			},
+			want: []*BulkResponse{
+				{
+					ReqID: "asdf",
+					SeqID: 1,
+					Payload: json.RawMessage(`{
+						"$schema": "https://gobl.org/draft-0/envelope"
+					}`),
+					IsFinal: false,
+				},
+				{
+					SeqID:   2,
+					IsFinal: true,
+				},
+			},
+		}
+	})
+	tests.Add("convert, from edifact", func(t *testing.T) interface{} {
+		payload, err := os.ReadFile("testdata/invoice.edi")
+		if err != nil {
+			t.Fatal(err)
+		}
+		req, err := json.Marshal(map[string]interface{}{
+			"action": "convert",
+			"req_id": "asdf",
+			"payload": map[string]interface{}{
+				"format": "edifact",
+				"data":   base64.StdEncoding.EncodeToString(payload),
+			},
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		return tt{
+			opts: &BulkOptions{
+				In: bytes.NewReader(req),
+			},
+			want: []*BulkResponse{
+				{
+					ReqID: "asdf",
+					SeqID: 1,
+					Payload: json.RawMessage(`{
+						"$schema": "https://gobl.org/draft-0/envelope"
+					}`),
+					IsFinal: false,
+				},
+				{
+					SeqID:   2,
+					IsFinal: true,
+				},
+			},
+		}
+	})
+	tests.Add("render", func(t *testing.T) interface{} {
+		payload, err := os.ReadFile("testdata/success.json")
+		if err != nil {
+			t.Fatal(err)
+		}
+		req, err := json.Marshal(map[string]interface{}{
+			"action": "render",
+			"req_id": "asdf",
+			"payload": map[string]interface{}{
+				"data": payload,
+				"lang": "xx",
+			},
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		return tt{
+			opts: &BulkOptions{
+				In: bytes.NewReader(req),
+			},
+			want: []*BulkResponse{
+				{
+					ReqID: "asdf",
+					SeqID: 1,
+					Error: &Error{
+						Code:    422,
+						Message: "invalid language: must be a valid value",
+					},
+					IsFinal: false,
+				},
+				{
+					SeqID:   2,
+					IsFinal: true,
+				},
+			},
+		}
+	})
+	tests.Add("convert, unsupported format", func(t *testing.T) interface{} {
+		req, err := json.Marshal(map[string]interface{}{
+			"action": "convert",
+			"req_id": "asdf",
+			"payload": map[string]interface{}{
+				"format": "foo",
+				"data":   base64.StdEncoding.EncodeToString([]byte("<Invoice/>")),
+			},
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		return tt{
+			opts: &BulkOptions{
+				In: bytes.NewReader(req),
+			},
+			want: []*BulkResponse{
+				{
+					ReqID: "asdf",
+					SeqID: 1,
+					Error: &Error{
+						Code:    422,
+						Message: "unsupported format 'foo'",
+					},
+					IsFinal: false,
+				},
+				{
+					SeqID:   2,
+					IsFinal: true,
+				},
+			},
+		}
+	})
 	tests.Add("unknown action", func(t *testing.T) interface{} {
 		req, err := json.Marshal(map[string]interface{}{
 			"action": "frobnicate",
@@ -648,7 +1025,7 @@ func TestBulk(t *testing.T) { //nolint:gocyclo
 				// Following raw message is copied and pasted! (sorry!)
 				Payload: json.RawMessage(`{
 					"list": [
-						"https://gobl.org/draft-0/bill/correction-options", "https://gobl.org/draft-0/bill/invoice", "https://gobl.org/draft-0/cal/date", "https://gobl.org/draft-0/cal/date-time", "https://gobl.org/draft-0/cal/period", "https://gobl.org/draft-0/cbc/code", "https://gobl.org/draft-0/cbc/code-map", "https://gobl.org/draft-0/cbc/key", "https://gobl.org/draft-0/cbc/key-definition", "https://gobl.org/draft-0/cbc/meta", "https://gobl.org/draft-0/cbc/note", "https://gobl.org/draft-0/cbc/value-definition", "https://gobl.org/draft-0/currency/amount", "https://gobl.org/draft-0/currency/code", "https://gobl.org/draft-0/currency/exchange-rate", "https://gobl.org/draft-0/dsig/digest", "https://gobl.org/draft-0/dsig/signature", "https://gobl.org/draft-0/envelope", "https://gobl.org/draft-0/head/header", "https://gobl.org/draft-0/head/link", "https://gobl.org/draft-0/head/stamp", "https://gobl.org/draft-0/i18n/string", "https://gobl.org/draft-0/l10n/code", "https://gobl.org/draft-0/l10n/iso-country-code", "https://gobl.org/draft-0/l10n/tax-country-code", "https://gobl.org/draft-0/note/message", "https://gobl.org/draft-0/num/amount", "https://gobl.org/draft-0/num/percentage", "https://gobl.org/draft-0/org/address", "https://gobl.org/draft-0/org/coordinates", "https://gobl.org/draft-0/org/document-ref", "https://gobl.org/draft-0/org/email", "https://gobl.org/draft-0/org/identity", "https://gobl.org/draft-0/org/image", "https://gobl.org/draft-0/org/inbox", "https://gobl.org/draft-0/org/item", "https://gobl.org/draft-0/org/name", "https://gobl.org/draft-0/org/party", "https://gobl.org/draft-0/org/person", "https://gobl.org/draft-0/org/registration", "https://gobl.org/draft-0/org/telephone", "https://gobl.org/draft-0/org/unit", "https://gobl.org/draft-0/org/website", "https://gobl.org/draft-0/pay/advance", "https://gobl.org/draft-0/pay/instructions", "https://gobl.org/draft-0/pay/terms", "https://gobl.org/draft-0/regimes/mx/food-vouchers", "https://gobl.org/draft-0/regimes/mx/fuel-account-balance", "https://gobl.org/draft-0/schema/object", "https://gobl.org/draft-0/tax/addon-def", "https://gobl.org/draft-0/tax/catalogue-def", "https://gobl.org/draft-0/tax/extensions", "https://gobl.org/draft-0/tax/identity", "https://gobl.org/draft-0/tax/regime-def", "https://gobl.org/draft-0/tax/set", "https://gobl.org/draft-0/tax/total"
+						"https://gobl.org/draft-0/acct/chart", "https://gobl.org/draft-0/acct/entry", "https://gobl.org/draft-0/bill/correction-options", "https://gobl.org/draft-0/bill/delivery", "https://gobl.org/draft-0/bill/invoice", "https://gobl.org/draft-0/bill/order", "https://gobl.org/draft-0/bill/payment", "https://gobl.org/draft-0/cal/date", "https://gobl.org/draft-0/cal/date-time", "https://gobl.org/draft-0/cal/period", "https://gobl.org/draft-0/cbc/code", "https://gobl.org/draft-0/cbc/code-map", "https://gobl.org/draft-0/cbc/key", "https://gobl.org/draft-0/cbc/key-definition", "https://gobl.org/draft-0/cbc/meta", "https://gobl.org/draft-0/cbc/note", "https://gobl.org/draft-0/cbc/value-definition", "https://gobl.org/draft-0/currency/amount", "https://gobl.org/draft-0/currency/code", "https://gobl.org/draft-0/currency/exchange-rate", "https://gobl.org/draft-0/dsig/digest", "https://gobl.org/draft-0/dsig/signature", "https://gobl.org/draft-0/envelope", "https://gobl.org/draft-0/head/header", "https://gobl.org/draft-0/head/link", "https://gobl.org/draft-0/head/stamp", "https://gobl.org/draft-0/i18n/string", "https://gobl.org/draft-0/l10n/code", "https://gobl.org/draft-0/l10n/iso-country-code", "https://gobl.org/draft-0/l10n/tax-country-code", "https://gobl.org/draft-0/note/message", "https://gobl.org/draft-0/num/amount", "https://gobl.org/draft-0/num/percentage", "https://gobl.org/draft-0/org/address", "https://gobl.org/draft-0/org/coordinates", "https://gobl.org/draft-0/org/document-ref", "https://gobl.org/draft-0/org/email", "https://gobl.org/draft-0/org/identity", "https://gobl.org/draft-0/org/image", "https://gobl.org/draft-0/org/inbox", "https://gobl.org/draft-0/org/item", "https://gobl.org/draft-0/org/name", "https://gobl.org/draft-0/org/party", "https://gobl.org/draft-0/org/person", "https://gobl.org/draft-0/org/registration", "https://gobl.org/draft-0/org/telephone", "https://gobl.org/draft-0/org/unit", "https://gobl.org/draft-0/org/website", "https://gobl.org/draft-0/pay/advance", "https://gobl.org/draft-0/pay/instructions", "https://gobl.org/draft-0/pay/terms", "https://gobl.org/draft-0/regimes/mx/food-vouchers", "https://gobl.org/draft-0/regimes/mx/fuel-account-balance", "https://gobl.org/draft-0/schema/object", "https://gobl.org/draft-0/tax/addon-def", "https://gobl.org/draft-0/tax/catalogue-def", "https://gobl.org/draft-0/tax/extensions", "https://gobl.org/draft-0/tax/identity", "https://gobl.org/draft-0/tax/intra/intrastat", "https://gobl.org/draft-0/tax/intra/sales-list", "https://gobl.org/draft-0/tax/oss/return", "https://gobl.org/draft-0/tax/regime-def", "https://gobl.org/draft-0/tax/report", "https://gobl.org/draft-0/tax/set", "https://gobl.org/draft-0/tax/total"
 					]
 				}`),
 				IsFinal: false,
@@ -0,0 +1,140 @@
+package cli
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+
+	"github.com/invopop/gobl"
+	"github.com/invopop/gobl/bill"
+	"github.com/invopop/gobl/convert/cii"
+	"github.com/invopop/gobl/convert/edifact"
+	"github.com/invopop/gobl/convert/ubl"
+	"github.com/invopop/gobl/internal/iotools"
+	"github.com/invopop/gobl/schema"
+)
+
+// Formats supported by the converter.
+const (
+	ConvertFormatUBL     = "ubl"
+	ConvertFormatCII     = "cii"
+	ConvertFormatEDIFACT = "edifact"
+)
+
+// ConvertOptions define the options required to convert a GOBL invoice into
+// another format, or the other way around.
+type ConvertOptions struct {
+	// Format of the foreign document, either "ubl", "cii" or "edifact".
+	Format string
+	// Input contains either a GOBL invoice or envelope, or a document in
+	// the foreign format.
+	Input io.Reader
+}
+
+// Convert reads the input and converts it. GOBL invoices are converted into
+// the foreign format and returned as raw bytes, while foreign documents are
+// converted into a GOBL envelope.
+func Convert(ctx context.Context, opts *ConvertOptions) (interface{}, error) {
+	res, err := convert(ctx, opts)
+	if err != nil {
+		return nil, wrapError(http.StatusUnprocessableEntity, err)
+	}
+	return res, nil
+}
+
+func convert(ctx context.Context, opts *ConvertOptions) (interface{}, error) {
+	switch opts.Format {
+	case ConvertFormatUBL, ConvertFormatCII, ConvertFormatEDIFACT:
+	default:
+		return nil, fmt.Errorf("unsupported format '%s'", opts.Format)
+	}
+	data, err := io.ReadAll(iotools.CancelableReader(ctx, opts.Input))
+	if err != nil {
+		return nil, err
+	}
+	if isForeign(opts.Format, data) {
+		return convertFrom(opts.Format, data)
+	}
+	return convertTo(ctx, opts.Format, data)
+}
+
+func convertFrom(format string, data []byte) (*gobl.Envelope, error) {
+	var inv *bill.Invoice
+	switch format {
+	case ConvertFormatEDIFACT:
+		doc, err := edifact.Parse(data)
+		if err != nil {
+			return nil, err
+		}
+		if inv, err = doc.Invoice(); err != nil {
+			return nil, err
+		}
+	case ConvertFormatCII:
+		doc, err := cii.Parse(data)
+		if err != nil {
+			return nil, err
+		}
+		if inv, err = doc.Invoice(); err != nil {
+			return nil, err
+		}
+	default:
+		doc, err := ubl.Parse(data)
+		if err != nil {
+			return nil, err
+		}
+		if inv, err = doc.Invoice(); err != nil {
+			return nil, err
+		}
+	}
+	return gobl.Envelop(inv)
+}
+
+func convertTo(ctx context.Context, format string, data []byte) ([]byte, error) {
+	obj, err := parseGOBLData(ctx, &ParseOptions{Input: bytes.NewReader(data)})
+	if err != nil {
+		return nil, err
+	}
+	doc, ok := obj.(*schema.Object)
+	if env, isEnv := obj.(*gobl.Envelope); isEnv {
+		doc, ok = env.Document, env.Document != nil
+	}
+	var inv *bill.Invoice
+	if ok {
+		inv, ok = doc.Instance().(*bill.Invoice)
+	}
+	if !ok {
+		return nil, fmt.Errorf("invoice required")
+	}
+	switch format {
+	case ConvertFormatEDIFACT:
+		out, err := edifact.FromInvoice(inv)
+		if err != nil {
+			return nil, err
+		}
+		return out.Bytes()
+	case ConvertFormatCII:
+		out, err := cii.FromInvoice(inv)
+		if err != nil {
+			return nil, err
+		}
+		return out.Bytes()
+	default:
+		out, err := ubl.FromInvoice(inv)
+		if err != nil {
+			return nil, err
+		}
+		return out.Bytes()
+	}
+}
+
+// isForeign checks if the data looks like a document in the foreign
+// format, rather than GOBL JSON or YAML.
+func isForeign(format string, data []byte) bool {
+	data = bytes.TrimLeft(data, "\ufeff \t\r\n")
+	if format == ConvertFormatEDIFACT {
+		return bytes.HasPrefix(data, []byte("UNA")) || bytes.HasPrefix(data, []byte("UNB"))
+	}
+	return len(data) > 0 && data[0] == '<'
+}
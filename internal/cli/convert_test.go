@@ -0,0 +1,136 @@
+package cli
+
+import (
+	"bytes"
+	"context"
+	"testing"
+
+	"github.com/invopop/gobl"
+	"github.com/invopop/gobl/bill"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestConvert(t *testing.T) {
+	ctx := context.Background()
+	var xml []byte
+
+	t.Run("to ubl", func(t *testing.T) {
+		out, err := Convert(ctx, &ConvertOptions{
+			Format: ConvertFormatUBL,
+			Input:  testFileReader(t, "testdata/invoice-es-es.yaml"),
+		})
+		require.NoError(t, err)
+		data, ok := out.([]byte)
+		require.True(t, ok)
+		assert.Contains(t, string(data), "<Invoice xmlns=")
+		assert.Contains(t, string(data), "<cbc:ID>SAMPLE-001</cbc:ID>")
+		assert.Contains(t, string(data), "<cbc:CompanyID>ESB98602642</cbc:CompanyID>")
+		xml = data
+	})
+
+	t.Run("from ubl", func(t *testing.T) {
+		require.NotEmpty(t, xml)
+		out, err := Convert(ctx, &ConvertOptions{
+			Format: ConvertFormatUBL,
+			Input:  bytes.NewReader(xml),
+		})
+		require.NoError(t, err)
+		env, ok := out.(*gobl.Envelope)
+		require.True(t, ok)
+		inv, ok := env.Extract().(*bill.Invoice)
+		require.True(t, ok)
+		assert.Equal(t, "SAMPLE-001", inv.Code.String())
+		assert.Equal(t, "ES", inv.Supplier.TaxID.Country.String())
+		assert.NotNil(t, inv.Totals)
+	})
+
+	t.Run("to and from cii", func(t *testing.T) {
+		out, err := Convert(ctx, &ConvertOptions{
+			Format: ConvertFormatCII,
+			Input:  testFileReader(t, "testdata/invoice-es-es.yaml"),
+		})
+		require.NoError(t, err)
+		data, ok := out.([]byte)
+		require.True(t, ok)
+		assert.Contains(t, string(data), "<rsm:CrossIndustryInvoice")
+		assert.Contains(t, string(data), "<ram:ID>SAMPLE-001</ram:ID>")
+
+		out, err = Convert(ctx, &ConvertOptions{
+			Format: ConvertFormatCII,
+			Input:  bytes.NewReader(data),
+		})
+		require.NoError(t, err)
+		env, ok := out.(*gobl.Envelope)
+		require.True(t, ok)
+		inv, ok := env.Extract().(*bill.Invoice)
+		require.True(t, ok)
+		assert.Equal(t, "SAMPLE-001", inv.Code.String())
+		assert.Equal(t, "ES", inv.Supplier.TaxID.Country.String())
+	})
+
+	t.Run("to and from edifact", func(t *testing.T) {
+		out, err := Convert(ctx, &ConvertOptions{
+			Format: ConvertFormatEDIFACT,
+			Input:  testFileReader(t, "testdata/invoice-es-es.yaml"),
+		})
+		require.NoError(t, err)
+		data, ok := out.([]byte)
+		require.True(t, ok)
+		assert.Contains(t, string(data), "UNH+1+INVOIC:D:96A:UN'")
+		assert.Contains(t, string(data), "BGM+380+SAMPLE-001+9'")
+
+		out, err = Convert(ctx, &ConvertOptions{
+			Format: ConvertFormatEDIFACT,
+			Input:  bytes.NewReader(data),
+		})
+		require.NoError(t, err)
+		env, ok := out.(*gobl.Envelope)
+		require.True(t, ok)
+		inv, ok := env.Extract().(*bill.Invoice)
+		require.True(t, ok)
+		assert.Equal(t, "SAMPLE-001", inv.Code.String())
+		assert.Equal(t, "ES", inv.Supplier.TaxID.Country.String())
+	})
+
+	t.Run("invalid edifact", func(t *testing.T) {
+		_, err := Convert(ctx, &ConvertOptions{
+			Format: ConvertFormatEDIFACT,
+			Input:  bytes.NewReader([]byte(`UNB+UNOC:3+A+B+240301:1200+1'UNH+1+ORDERS:D:96A:UN'`)),
+		})
+		assert.ErrorContains(t, err, "unsupported message 'ORDERS'")
+	})
+
+	t.Run("cii with ubl document", func(t *testing.T) {
+		require.NotEmpty(t, xml)
+		_, err := Convert(ctx, &ConvertOptions{
+			Format: ConvertFormatCII,
+			Input:  bytes.NewReader(xml),
+		})
+		assert.ErrorContains(t, err, "parsing XML")
+	})
+
+	t.Run("unsupported format", func(t *testing.T) {
+		_, err := Convert(ctx, &ConvertOptions{
+			Format: "foo",
+			Input:  testFileReader(t, "testdata/invoice-es-es.yaml"),
+		})
+		assert.ErrorContains(t, err, "unsupported format 'foo'")
+	})
+
+	t.Run("not an invoice", func(t *testing.T) {
+		_, err := Convert(ctx, &ConvertOptions{
+			Format: ConvertFormatUBL,
+			Input:  testFileReader(t, "testdata/order.yaml"),
+		})
+		assert.ErrorContains(t, err, "invoice required")
+	})
+
+	t.Run("invalid xml", func(t *testing.T) {
+		_, err := Convert(ctx, &ConvertOptions{
+			Format: ConvertFormatUBL,
+			Input:  bytes.NewReader([]byte(`<Invoice`)),
+		})
+		assert.ErrorContains(t, err, "parsing XML")
+	})
+}
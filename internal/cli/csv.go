@@ -0,0 +1,91 @@
+package cli
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+
+	"github.com/invopop/gobl/convert/csv"
+	"github.com/invopop/gobl/internal/iotools"
+)
+
+// BuildCSVOptions are the options used for building invoices from rows of
+// CSV data provided in the input.
+type BuildCSVOptions struct {
+	*BuildOptions
+
+	// Mapping contains the YAML or JSON definition of how the CSV columns
+	// are mapped to invoice fields.
+	Mapping io.Reader
+}
+
+// BuildCSV groups the CSV rows in the input into invoices using the
+// mapping, then merges each invoice into the template along with any
+// other values before building it. Documents are returned in the order
+// their first row appears. Codes are only assigned from the sequencer once
+// every invoice has been built successfully, so that a single invalid group
+// does not leave gaps in the sequence.
+func BuildCSV(ctx context.Context, opts *BuildCSVOptions) ([]any, error) {
+	mdata, err := io.ReadAll(iotools.CancelableReader(ctx, opts.Mapping))
+	if err != nil {
+		return nil, wrapError(StatusBadRequest, err)
+	}
+	m, err := csv.ParseMapping(mdata)
+	if err != nil {
+		return nil, wrapError(StatusUnprocessableEntity, err)
+	}
+	groups, err := m.Read(iotools.CancelableReader(ctx, opts.Input))
+	if err != nil {
+		return nil, wrapErrorf(StatusUnprocessableEntity, "csv: %w", err)
+	}
+
+	var template []byte
+	if opts.Template != nil {
+		template, err = io.ReadAll(iotools.CancelableReader(ctx, opts.Template))
+		if err != nil {
+			return nil, wrapError(StatusBadRequest, err)
+		}
+	}
+
+	out := make([]any, len(groups))
+	for i, g := range groups {
+		data, err := json.Marshal(g.Data)
+		if err != nil {
+			return nil, wrapError(StatusUnprocessableEntity, err)
+		}
+		po := *opts.ParseOptions
+		po.Input = bytes.NewReader(data)
+		if template != nil {
+			po.Template = bytes.NewReader(template)
+		}
+		res, err := build(ctx, &po)
+		if err != nil {
+			return nil, csvGroupError(g, err)
+		}
+		out[i] = res
+	}
+	if opts.Sequencer != nil {
+		for i, g := range groups {
+			if err := assignCode(ctx, opts.Sequencer, out[i]); err != nil {
+				return nil, csvGroupError(g, err)
+			}
+		}
+	}
+	return out, nil
+}
+
+// csvGroupError prefixes the error's message with the group's key, if any.
+func csvGroupError(g *csv.Group, err error) *Error {
+	e := wrapError(StatusUnprocessableEntity, err)
+	if g.Key == "" {
+		return e
+	}
+	return &Error{
+		Code:    e.Code,
+		Key:     e.Key,
+		Fields:  e.Fields,
+		Message: fmt.Sprintf("%s: %s", g.Key, e.message()),
+	}
+}
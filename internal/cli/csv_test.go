@@ -0,0 +1,118 @@
+package cli
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/invopop/gobl"
+	"github.com/invopop/gobl/bill"
+	"github.com/invopop/gobl/bill/sequence"
+	"github.com/invopop/gobl/schema"
+)
+
+func TestBuildCSV(t *testing.T) {
+	opts := func(t *testing.T) *BuildCSVOptions {
+		t.Helper()
+		return &BuildCSVOptions{
+			BuildOptions: &BuildOptions{
+				ParseOptions: &ParseOptions{
+					Template: testFileReader(t, "testdata/lines.template.yaml"),
+					Input:    testFileReader(t, "testdata/lines.csv"),
+				},
+			},
+			Mapping: testFileReader(t, "testdata/lines.mapping.yaml"),
+		}
+	}
+
+	t.Run("success", func(t *testing.T) {
+		got, err := BuildCSV(context.Background(), opts(t))
+		require.NoError(t, err)
+		require.Len(t, got, 2)
+
+		inv := got[0].(*schema.Object).Instance().(*bill.Invoice)
+		assert.Equal(t, "CSV", inv.Series.String())
+		assert.Equal(t, "INV-001", inv.Code.String())
+		assert.Equal(t, "2024-03-01", inv.IssueDate.String())
+		assert.Equal(t, "Provide One S.L.", inv.Supplier.Name)
+		assert.Equal(t, "ES", inv.Customer.TaxID.Country.String())
+		assert.Equal(t, "54387763P", inv.Customer.TaxID.Code.String())
+		require.Len(t, inv.Lines, 2)
+		assert.Equal(t, "1810.00", inv.Totals.Sum.String())
+		assert.Equal(t, "2188.00", inv.Totals.Payable.String())
+
+		inv = got[1].(*schema.Object).Instance().(*bill.Invoice)
+		assert.Equal(t, "INV-002", inv.Code.String())
+		assert.Equal(t, "Provide Two S.L.", inv.Customer.Name)
+		assert.Equal(t, "1452.00", inv.Totals.Payable.String())
+	})
+
+	t.Run("envelop", func(t *testing.T) {
+		o := opts(t)
+		o.Envelop = true
+		got, err := BuildCSV(context.Background(), o)
+		require.NoError(t, err)
+		require.Len(t, got, 2)
+		env, ok := got[1].(*gobl.Envelope)
+		require.True(t, ok)
+		assert.NotEmpty(t, env.Head.Digest)
+	})
+
+	t.Run("assign codes", func(t *testing.T) {
+		f, err := sequence.ParseFormat("{series}-{number:3}")
+		require.NoError(t, err)
+		seq := sequence.New(sequence.NewMemoryStore(), sequence.WithFormat(f))
+		sopts := func(t *testing.T) *BuildCSVOptions {
+			t.Helper()
+			o := opts(t)
+			o.Mapping = strings.NewReader(`
+key: Invoice
+invoice:
+  issue_date: Date
+  customer.name: Customer
+  customer.tax_id.code: Customer Tax ID
+line: {item_name: Description, quantity: Qty, price: Price, tax_category: Tax, tax_rate: Rate}
+`)
+			o.Sequencer = seq
+			return o
+		}
+
+		o := sopts(t)
+		o.Input = strings.NewReader(strings.Join([]string{
+			"Invoice,Date,Customer,Customer Tax ID,Description,Qty,Price,Tax,Rate",
+			"A,2024-03-01,Sample Consumer,54387763P,Development services,20,90.00,VAT,standard",
+			"B,2024-03-02,Provide Two S.L.,B85905495,Hosting,1,10.00,VAT,unknown",
+		}, "\n"))
+		_, err = BuildCSV(context.Background(), o)
+		assert.ErrorContains(t, err, "code=422, message=B: ")
+
+		got, err := BuildCSV(context.Background(), sopts(t))
+		require.NoError(t, err)
+		assert.Equal(t, "CSV-001", got[0].(*schema.Object).Instance().(*bill.Invoice).Code.String(), "failed builds should not use up numbers")
+		assert.Equal(t, "CSV-002", got[1].(*schema.Object).Instance().(*bill.Invoice).Code.String())
+	})
+
+	t.Run("invalid mapping", func(t *testing.T) {
+		o := opts(t)
+		o.Mapping = strings.NewReader(`key: Invoice`)
+		_, err := BuildCSV(context.Background(), o)
+		assert.EqualError(t, err, "code=422, message=invalid mapping: line: cannot be blank.")
+	})
+
+	t.Run("invalid rows", func(t *testing.T) {
+		o := opts(t)
+		o.Input = strings.NewReader("Invoice,Description\n")
+		_, err := BuildCSV(context.Background(), o)
+		assert.EqualError(t, err, "code=422, message=csv: column 'Customer' not found")
+	})
+
+	t.Run("invalid invoice", func(t *testing.T) {
+		o := opts(t)
+		o.Template = strings.NewReader(`{"$schema": "https://gobl.org/draft-0/bill/invoice", "currency": "EUR"}`)
+		_, err := BuildCSV(context.Background(), o)
+		assert.ErrorContains(t, err, "code=422, message=INV-001: customer: (tax_id: (country: cannot be blank.).)")
+	})
+}
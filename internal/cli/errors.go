@@ -29,6 +29,10 @@ func (e *Error) Error() string {
 	if e == nil {
 		return ""
 	}
+	return fmt.Sprintf("code=%d, message=%s", e.Code, e.message())
+}
+
+func (e *Error) message() string {
 	msg := e.Message
 	if msg == "" && e.Fields != nil {
 		msg = e.Fields.Error()
@@ -39,7 +43,7 @@ func (e *Error) Error() string {
 	if msg == "" {
 		msg = "unknown-error"
 	}
-	return fmt.Sprintf("code=%d, message=%s", e.Code, msg)
+	return msg
 }
 
 // wrapError is used to ensure that we always provide a structured response
@@ -0,0 +1,95 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+
+	"github.com/invopop/gobl"
+	"github.com/invopop/gobl/bill"
+	"github.com/invopop/gobl/cal"
+	"github.com/invopop/gobl/schema"
+	"github.com/invopop/gobl/tax"
+	"github.com/invopop/gobl/tax/intra"
+)
+
+// Intra-community summary types that can be prepared.
+const (
+	IntraSalesList = "sales-list"
+	IntraIntrastat = "intrastat"
+)
+
+// IntraOptions define the options required to prepare an intra-community
+// summary from a set of invoices.
+type IntraOptions struct {
+	// Type of summary, either "sales-list" or "intrastat".
+	Type string
+	// TaxID of the party the summary is prepared for, including the
+	// country prefix.
+	TaxID string
+	// Period covered by the summary.
+	Period cal.Period
+	// Inputs containing the invoices or envelopes to include.
+	Inputs []io.Reader
+	// Intrastat thresholds, if any.
+	Intrastat *intra.IntrastatOptions
+
+	// When set to `true`, the summary is wrapped in an envelope.
+	Envelop bool
+}
+
+// Intra takes a set of invoices and prepares the EC Sales List or Intrastat
+// summary for the party with the tax ID during the period.
+func Intra(ctx context.Context, opts *IntraOptions) (interface{}, error) {
+	res, err := intraSummary(ctx, opts)
+	if err != nil {
+		return nil, wrapError(http.StatusUnprocessableEntity, err)
+	}
+	return res, nil
+}
+
+func intraSummary(ctx context.Context, opts *IntraOptions) (interface{}, error) {
+	tID, err := tax.ParseIdentity(opts.TaxID)
+	if err != nil {
+		return nil, fmt.Errorf("tax ID: %w", err)
+	}
+	invoices := make([]*bill.Invoice, len(opts.Inputs))
+	for i, in := range opts.Inputs {
+		obj, err := parseGOBLData(ctx, &ParseOptions{Input: in})
+		if err != nil {
+			return nil, err
+		}
+		doc, ok := obj.(*schema.Object)
+		if env, isEnv := obj.(*gobl.Envelope); isEnv {
+			doc, ok = env.Document, env.Document != nil
+		}
+		if ok {
+			invoices[i], ok = doc.Instance().(*bill.Invoice)
+		}
+		if !ok {
+			return nil, fmt.Errorf("input %d: invoice required", i)
+		}
+		if err := invoices[i].Calculate(); err != nil {
+			return nil, fmt.Errorf("input %d: %w", i, err)
+		}
+	}
+
+	var doc interface{}
+	switch opts.Type {
+	case IntraSalesList:
+		doc, err = intra.BuildSalesList(tID, opts.Period, invoices...)
+	case IntraIntrastat:
+		doc, err = intra.BuildIntrastat(tID, opts.Period, opts.Intrastat, invoices...)
+	default:
+		return nil, fmt.Errorf("unknown summary type '%s'", opts.Type)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if opts.Envelop {
+		return gobl.Envelop(doc)
+	}
+	return schema.NewObject(doc)
+}
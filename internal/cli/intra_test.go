@@ -0,0 +1,106 @@
+package cli
+
+import (
+	"context"
+	"io"
+	"regexp"
+	"testing"
+
+	"github.com/invopop/gobl/cal"
+	"github.com/invopop/gobl/num"
+	"github.com/invopop/gobl/tax/intra"
+	"github.com/stretchr/testify/assert"
+	"gitlab.com/flimzy/testy"
+)
+
+func TestIntra(t *testing.T) {
+	type tt struct {
+		opts *IntraOptions
+		err  string
+	}
+
+	period := cal.Period{
+		Start: cal.MakeDate(2024, 1, 1),
+		End:   cal.MakeDate(2024, 3, 31),
+	}
+
+	tests := testy.NewTable()
+
+	tests.Add("sales list", func(t *testing.T) interface{} {
+		return tt{
+			opts: &IntraOptions{
+				Type:   IntraSalesList,
+				TaxID:  "ESB98602642",
+				Period: period,
+				Inputs: []io.Reader{testFileReader(t, "testdata/invoice-es-nl-goods.yaml")},
+			},
+		}
+	})
+
+	tests.Add("intrastat", func(t *testing.T) interface{} {
+		return tt{
+			opts: &IntraOptions{
+				Type:   IntraIntrastat,
+				TaxID:  "ESB98602642",
+				Period: period,
+				Inputs: []io.Reader{testFileReader(t, "testdata/invoice-es-nl-goods.yaml")},
+				Intrastat: &intra.IntrastatOptions{
+					DispatchThreshold: num.MakeAmount(400000, 0),
+					PriorDispatches:   num.MakeAmount(500000, 0),
+				},
+				Envelop: true,
+			},
+		}
+	})
+
+	tests.Add("unknown type", func(t *testing.T) interface{} {
+		return tt{
+			opts: &IntraOptions{
+				Type:   "foo",
+				TaxID:  "ESB98602642",
+				Period: period,
+			},
+			err: "unknown summary type 'foo'",
+		}
+	})
+
+	tests.Add("not an invoice", func(t *testing.T) interface{} {
+		return tt{
+			opts: &IntraOptions{
+				Type:   IntraSalesList,
+				TaxID:  "ESB98602642",
+				Period: period,
+				Inputs: []io.Reader{testFileReader(t, "testdata/order.yaml")},
+			},
+			err: "input 0: invoice required",
+		}
+	})
+
+	tests.Run(t, func(t *testing.T, tt tt) {
+		t.Parallel()
+		got, err := Intra(context.Background(), tt.opts)
+		if tt.err == "" {
+			assert.Nil(t, err)
+		} else {
+			if assert.Error(t, err) {
+				assert.Contains(t, err.Error(), tt.err)
+			}
+		}
+		if err != nil {
+			return
+		}
+		replacements := []testy.Replacement{
+			{
+				Regexp:      regexp.MustCompile(`"uuid":.?"[^\"]+"`),
+				Replacement: `"uuid":"00000000-0000-0000-0000-000000000000"`,
+			},
+			{
+				Regexp:      regexp.MustCompile(`"val":.?"[\w\d]{64}"`),
+				Replacement: `"val":"74ffc799663823235951b43a1324c70555c0ba7e3b545c1f50af34bbcc57033b"`,
+			},
+		}
+		if d := testy.DiffAsJSON(testy.Snapshot(t), got, replacements...); d != nil {
+			t.Error(d)
+		}
+	})
+}
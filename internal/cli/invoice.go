@@ -0,0 +1,69 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+
+	"github.com/invopop/gobl"
+	"github.com/invopop/gobl/bill"
+	"github.com/invopop/gobl/schema"
+)
+
+// InvoiceSource defines a single order or delivery document to build an
+// invoice from, alongside an optional selection of its lines.
+type InvoiceSource struct {
+	Input io.Reader
+	Lines []*bill.LineSelection
+}
+
+// InvoiceOptions define the options required to build a new invoice from
+// a set of order or delivery documents.
+type InvoiceOptions struct {
+	Sources []*InvoiceSource
+
+	// When set to `true`, the new invoice is wrapped in an envelope.
+	Envelop bool
+}
+
+// Invoice takes one or more order or delivery documents as input and builds
+// a new draft invoice from their contents.
+func Invoice(ctx context.Context, opts *InvoiceOptions) (interface{}, error) {
+	res, err := invoice(ctx, opts)
+	if err != nil {
+		return nil, wrapError(http.StatusUnprocessableEntity, err)
+	}
+	return res, nil
+}
+
+func invoice(ctx context.Context, opts *InvoiceOptions) (interface{}, error) {
+	docs := make([]any, len(opts.Sources))
+	for i, src := range opts.Sources {
+		obj, err := parseGOBLData(ctx, &ParseOptions{Input: src.Input})
+		if err != nil {
+			return nil, err
+		}
+		doc, ok := obj.(*schema.Object)
+		if env, isEnv := obj.(*gobl.Envelope); isEnv {
+			doc, ok = env.Document, env.Document != nil
+		}
+		if !ok {
+			return nil, fmt.Errorf("source %d: missing document", i)
+		}
+		docs[i] = &bill.InvoiceSource{
+			Document: doc,
+			Lines:    src.Lines,
+		}
+	}
+
+	inv, err := bill.NewInvoiceFrom(docs...)
+	if err != nil {
+		return nil, err
+	}
+
+	if opts.Envelop {
+		return gobl.Envelop(inv)
+	}
+	return schema.NewObject(inv)
+}
@@ -0,0 +1,101 @@
+package cli
+
+import (
+	"context"
+	"regexp"
+	"testing"
+
+	"github.com/invopop/gobl/bill"
+	"github.com/invopop/gobl/num"
+	"github.com/stretchr/testify/assert"
+	"gitlab.com/flimzy/testy"
+)
+
+func TestInvoice(t *testing.T) {
+	type tt struct {
+		opts *InvoiceOptions
+		err  string
+	}
+
+	tests := testy.NewTable()
+
+	tests.Add("from order", func(t *testing.T) interface{} {
+		return tt{
+			opts: &InvoiceOptions{
+				Sources: []*InvoiceSource{
+					{Input: testFileReader(t, "testdata/order.yaml")},
+				},
+			},
+		}
+	})
+
+	tests.Add("partial order", func(t *testing.T) interface{} {
+		qty := num.MakeAmount(5, 0)
+		return tt{
+			opts: &InvoiceOptions{
+				Sources: []*InvoiceSource{
+					{
+						Input: testFileReader(t, "testdata/order.yaml"),
+						Lines: []*bill.LineSelection{
+							{Index: 1, Quantity: &qty},
+						},
+					},
+				},
+				Envelop: true,
+			},
+		}
+	})
+
+	tests.Add("from delivery", func(t *testing.T) interface{} {
+		return tt{
+			opts: &InvoiceOptions{
+				Sources: []*InvoiceSource{
+					{Input: testFileReader(t, "testdata/delivery.yaml")},
+				},
+			},
+		}
+	})
+
+	tests.Add("from invoice", func(t *testing.T) interface{} {
+		return tt{
+			opts: &InvoiceOptions{
+				Sources: []*InvoiceSource{
+					{Input: testFileReader(t, "testdata/invoice.json")},
+				},
+			},
+			err: "source 0: unsupported document type: *bill.Invoice",
+		}
+	})
+
+	tests.Run(t, func(t *testing.T, tt tt) {
+		t.Parallel()
+		got, err := Invoice(context.Background(), tt.opts)
+		if tt.err == "" {
+			assert.Nil(t, err)
+		} else {
+			if assert.Error(t, err) {
+				assert.Contains(t, err.Error(), tt.err)
+			}
+		}
+		if err != nil {
+			return
+		}
+		replacements := []testy.Replacement{
+			{
+				Regexp:      regexp.MustCompile(`"uuid":.?"[^\"]+"`),
+				Replacement: `"uuid":"00000000-0000-0000-0000-000000000000"`,
+			},
+			{
+				Regexp:      regexp.MustCompile(`"val":.?"[\w\d]{64}"`),
+				Replacement: `"val":"74ffc799663823235951b43a1324c70555c0ba7e3b545c1f50af34bbcc57033b"`,
+			},
+			{
+				Regexp:      regexp.MustCompile(`"issue_date":.?"[^\"]+"`),
+				Replacement: `"issue_date":"2024-05-06"`,
+			},
+		}
+		if d := testy.DiffAsJSON(testy.Snapshot(t), got, replacements...); d != nil {
+			t.Error(d)
+		}
+	})
+}
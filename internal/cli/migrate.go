@@ -0,0 +1,99 @@
+package cli
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+
+	"github.com/invopop/gobl"
+	"github.com/invopop/gobl/schema"
+)
+
+// MigrateOptions define the options required to upgrade a stored document
+// or envelope to the current version of GOBL.
+type MigrateOptions struct {
+	Input io.Reader
+
+	// From is the version of GOBL used to produce the input, if known, so
+	// that only the migrations introduced after it are applied.
+	From string
+}
+
+// MigrateResult contains the upgraded document or envelope alongside the
+// list of migrations that were applied.
+type MigrateResult struct {
+	Migrations []*schema.Migration `json:"migrations,omitempty"`
+	Data       any                 `json:"data"`
+}
+
+// Migrate applies all the registered migrations to the input document or
+// envelope, and re-calculates the result if any changes were made. Signed
+// envelopes that require migrations cannot be upgraded.
+func Migrate(ctx context.Context, opts *MigrateOptions) (*MigrateResult, error) {
+	res, err := migrate(ctx, opts)
+	if err != nil {
+		return nil, wrapError(http.StatusUnprocessableEntity, err)
+	}
+	return res, nil
+}
+
+func migrate(ctx context.Context, opts *MigrateOptions) (*MigrateResult, error) {
+	var intermediate map[string]any
+	if err := decodeInto(ctx, &intermediate, opts.Input); err != nil {
+		return nil, err
+	}
+	data, err := json.Marshal(intermediate)
+	if err != nil {
+		return nil, wrapError(StatusUnprocessableEntity, err)
+	}
+	id, err := schema.Extract(data)
+	if err != nil {
+		return nil, wrapError(StatusBadRequest, err)
+	}
+
+	if id == gobl.EnvelopeSchema {
+		env := &gobl.Envelope{
+			Document: schema.NewObjectFromVersion(opts.From),
+		}
+		if err := json.Unmarshal(data, env); err != nil {
+			return nil, wrapError(StatusBadRequest, err)
+		}
+		if env.Document == nil || env.Document.IsEmpty() {
+			return nil, errors.New("missing document")
+		}
+		res := &MigrateResult{
+			Migrations: env.Document.Migrated(),
+			Data:       env,
+		}
+		if len(res.Migrations) == 0 {
+			return res, nil
+		}
+		if env.Signed() {
+			return nil, errors.New("cannot migrate signed envelope")
+		}
+		if err := env.Calculate(); err != nil {
+			return nil, err
+		}
+		return res, nil
+	}
+
+	doc := schema.NewObjectFromVersion(opts.From)
+	if err := json.Unmarshal(data, doc); err != nil {
+		return nil, wrapError(StatusBadRequest, err)
+	}
+	if doc.IsEmpty() {
+		return nil, wrapError(StatusBadRequest, gobl.ErrUnknownSchema)
+	}
+	res := &MigrateResult{
+		Migrations: doc.Migrated(),
+		Data:       doc,
+	}
+	if len(res.Migrations) > 0 {
+		if err := doc.Calculate(); err != nil {
+			return nil, err
+		}
+	}
+	return res, nil
+}
@@ -0,0 +1,135 @@
+package cli
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"os"
+	"regexp"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gitlab.com/flimzy/testy"
+)
+
+func TestMigrate(t *testing.T) {
+	type tt struct {
+		opts       *MigrateOptions
+		migrations []string
+		err        string
+	}
+
+	tests := testy.NewTable()
+
+	tests.Add("envelope", func(t *testing.T) interface{} {
+		return tt{
+			opts: &MigrateOptions{
+				Input: testFileReader(t, "testdata/nosig.json"),
+			},
+			migrations: []string{"bill-outlay-legacy"},
+		}
+	})
+	tests.Add("document", func(t *testing.T) interface{} {
+		data, err := os.ReadFile("testdata/nosig.json")
+		require.NoError(t, err)
+		env := make(map[string]any)
+		require.NoError(t, json.Unmarshal(data, &env))
+		data, err = json.Marshal(env["doc"])
+		require.NoError(t, err)
+		return tt{
+			opts: &MigrateOptions{
+				Input: bytes.NewReader(data),
+			},
+			migrations: []string{"bill-outlay-legacy"},
+		}
+	})
+	tests.Add("from version", func(t *testing.T) interface{} {
+		return tt{
+			opts: &MigrateOptions{
+				Input: testFileReader(t, "testdata/nosig.json"),
+				From:  "v0.204.0",
+			},
+		}
+	})
+	tests.Add("invalid version", func(t *testing.T) interface{} {
+		return tt{
+			opts: &MigrateOptions{
+				Input: testFileReader(t, "testdata/nosig.json"),
+				From:  "latest",
+			},
+			err: "invalid version",
+		}
+	})
+	tests.Add("signed without changes", func(t *testing.T) interface{} {
+		return tt{
+			opts: &MigrateOptions{
+				Input: testFileReader(t, "testdata/signed.json"),
+			},
+		}
+	})
+	tests.Add("signed with changes", func(t *testing.T) interface{} {
+		data, err := os.ReadFile("testdata/nosig.json")
+		require.NoError(t, err)
+		env := make(map[string]any)
+		require.NoError(t, json.Unmarshal(data, &env))
+		data, err = os.ReadFile("testdata/signed.json")
+		require.NoError(t, err)
+		signed := make(map[string]any)
+		require.NoError(t, json.Unmarshal(data, &signed))
+		env["sigs"] = signed["sigs"]
+		data, err = json.Marshal(env)
+		require.NoError(t, err)
+		return tt{
+			opts: &MigrateOptions{
+				Input: bytes.NewReader(data),
+			},
+			err: "cannot migrate signed envelope",
+		}
+	})
+	tests.Add("unknown schema", func(t *testing.T) interface{} {
+		return tt{
+			opts: &MigrateOptions{
+				Input: bytes.NewReader([]byte(`{"$schema":"https://example.com/foo"}`)),
+			},
+			err: "unknown-schema",
+		}
+	})
+
+	tests.Run(t, func(t *testing.T, tt tt) {
+		t.Parallel()
+		got, err := Migrate(context.Background(), tt.opts)
+		if tt.err != "" {
+			if assert.Error(t, err) {
+				assert.Contains(t, err.Error(), tt.err)
+			}
+			return
+		}
+		require.NoError(t, err)
+		keys := make([]string, len(got.Migrations))
+		for i, m := range got.Migrations {
+			keys[i] = m.Key
+		}
+		if tt.migrations == nil {
+			tt.migrations = []string{}
+		}
+		assert.Equal(t, tt.migrations, keys)
+		replacements := []testy.Replacement{
+			{
+				Regexp:      regexp.MustCompile(`(?s)"sigs": \[.*\]`),
+				Replacement: `"sigs": ["signature data"]`,
+			},
+			{
+				Regexp:      regexp.MustCompile(`"uuid":.?"[^\"]+"`),
+				Replacement: `"uuid":"00000000-0000-0000-0000-000000000000"`,
+			},
+			{
+				Regexp:      regexp.MustCompile(`"val":.?"[\w\d]{64}"`),
+				Replacement: `"val":"74ffc799663823235951b43a1324c70555c0ba7e3b545c1f50af34bbcc57033b"`,
+			},
+		}
+		if d := testy.DiffAsJSON(testy.Snapshot(t), got.Data, replacements...); d != nil {
+			t.Error(d)
+		}
+	})
+}
@@ -0,0 +1,64 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+
+	"github.com/invopop/gobl"
+	"github.com/invopop/gobl/bill"
+	"github.com/invopop/gobl/i18n"
+	"github.com/invopop/gobl/render"
+	"github.com/invopop/gobl/schema"
+)
+
+// RenderOptions define the options required to produce a human readable
+// copy of an envelope or invoice.
+type RenderOptions struct {
+	*ParseOptions
+	// Lang is the language to use for names provided by the regime and
+	// addons, if available.
+	Lang string
+}
+
+// Render produces a self-contained HTML document from the envelope or
+// invoice provided in the input. Invoices outside of an envelope will be
+// calculated first.
+func Render(ctx context.Context, opts *RenderOptions) ([]byte, error) {
+	res, err := renderHTML(ctx, opts)
+	if err != nil {
+		return nil, wrapError(http.StatusUnprocessableEntity, err)
+	}
+	return res, nil
+}
+
+func renderHTML(ctx context.Context, opts *RenderOptions) ([]byte, error) {
+	obj, err := parseGOBLData(ctx, opts.ParseOptions)
+	if err != nil {
+		return nil, err
+	}
+	var ro []render.Option
+	if opts.Lang != "" {
+		lang := i18n.Lang(opts.Lang)
+		if err := lang.Validate(); err != nil {
+			return nil, fmt.Errorf("invalid language: %w", err)
+		}
+		ro = append(ro, render.WithLanguage(lang))
+	}
+
+	if env, ok := obj.(*gobl.Envelope); ok {
+		return render.Envelope(env, ro...)
+	}
+	doc, ok := obj.(*schema.Object)
+	if !ok {
+		panic("input must be either an envelope or a document")
+	}
+	inv, ok := doc.Instance().(*bill.Invoice)
+	if !ok {
+		return nil, fmt.Errorf("%w: only invoices are supported", render.ErrUnsupported)
+	}
+	if err := doc.Calculate(); err != nil {
+		return nil, err
+	}
+	return render.Invoice(inv, ro...)
+}
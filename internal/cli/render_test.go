@@ -0,0 +1,55 @@
+package cli
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRender(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("envelope", func(t *testing.T) {
+		out, err := Render(ctx, &RenderOptions{
+			ParseOptions: &ParseOptions{
+				Input: testFileReader(t, "testdata/success.json"),
+			},
+		})
+		require.NoError(t, err)
+		assert.Contains(t, string(out), "<!DOCTYPE html>")
+		assert.Contains(t, string(out), "MªF. Services")
+	})
+
+	t.Run("invoice with language", func(t *testing.T) {
+		out, err := Render(ctx, &RenderOptions{
+			ParseOptions: &ParseOptions{
+				Input: testFileReader(t, "testdata/invoice-es-es.yaml"),
+			},
+			Lang: "es",
+		})
+		require.NoError(t, err)
+		assert.Contains(t, string(out), `<html lang="es">`)
+		assert.Contains(t, string(out), "IVA")
+	})
+
+	t.Run("invalid language", func(t *testing.T) {
+		_, err := Render(ctx, &RenderOptions{
+			ParseOptions: &ParseOptions{
+				Input: testFileReader(t, "testdata/invoice-es-es.yaml"),
+			},
+			Lang: "xx",
+		})
+		assert.ErrorContains(t, err, "invalid language")
+	})
+
+	t.Run("not an invoice", func(t *testing.T) {
+		_, err := Render(ctx, &RenderOptions{
+			ParseOptions: &ParseOptions{
+				Input: testFileReader(t, "testdata/order.yaml"),
+			},
+		})
+		assert.ErrorContains(t, err, "unsupported document: only invoices are supported")
+	})
+}
@@ -106,3 +106,15 @@ func TestTaxCountryCodeSchema(t *testing.T) {
 	assert.Equal(t, l10n.AF, s.OneOf[0].Const)
 	assert.Equal(t, "Afghanistan", s.OneOf[0].Title)
 }
+
+func TestTaxCountryCodeInEU(t *testing.T) {
+	assert.True(t, l10n.TaxCountryCode("ES").InEU())
+	assert.True(t, l10n.TaxCountryCode("EL").InEU())
+	assert.True(t, l10n.TaxCountryCode("GR").InEU())
+	assert.False(t, l10n.TaxCountryCode("GB").InEU())
+	assert.False(t, l10n.TaxCountryCode("CH").InEU())
+	list := l10n.EUMemberStates()
+	assert.Len(t, list, 27)
+	list[0] = "XX"
+	assert.Equal(t, l10n.TaxCountryCode("AT"), l10n.EUMemberStates()[0])
+}
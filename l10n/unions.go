@@ -0,0 +1,25 @@
+package l10n
+
+// euMemberStates contains the tax country codes of the European Union's
+// member states. Greece uses "EL" for tax purposes.
+var euMemberStates = []TaxCountryCode{
+	"AT", "BE", "BG", "CY", "CZ", "DE", "DK", "EE", "EL", "ES", "FI", "FR", "HR", "HU",
+	"IE", "IT", "LT", "LU", "LV", "MT", "NL", "PL", "PT", "RO", "SE", "SI", "SK",
+}
+
+// EUMemberStates provides the list of tax country codes for the member
+// states of the European Union.
+func EUMemberStates() []TaxCountryCode {
+	list := make([]TaxCountryCode, len(euMemberStates))
+	copy(list, euMemberStates)
+	return list
+}
+
+// InEU returns true if the tax country code belongs to a member state of the
+// European Union, accepting Greece's ISO code alongside "EL".
+func (c TaxCountryCode) InEU() bool {
+	if c == TaxCountryCode(GR) {
+		return true
+	}
+	return c.In(euMemberStates...)
+}
@@ -22,6 +22,8 @@ const (
 	IdentityKeyOrder     cbc.Key = "order"     // order number or code
 	IdentityKeyAgreement cbc.Key = "agreement" // agreement number
 	IdentityKeyContract  cbc.Key = "contract"  // contract number
+	IdentityKeyBatch     cbc.Key = "batch"     // batch or lot number
+	IdentityKeySerial    cbc.Key = "serial"    // serial number
 	IdentityKeyPassport  cbc.Key = "passport"  // Passport number
 	IdentityKeyNational  cbc.Key = "national"  // National ID card number
 	IdentityKeyForeign   cbc.Key = "foreign"   // Foreigner ID card number
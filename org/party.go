@@ -4,6 +4,7 @@ import (
 	"context"
 
 	"github.com/invopop/gobl/cbc"
+	"github.com/invopop/gobl/l10n"
 	"github.com/invopop/gobl/schema"
 	"github.com/invopop/gobl/tax"
 	"github.com/invopop/gobl/uuid"
@@ -57,6 +58,22 @@ func (p *Party) Calculate() error {
 	return nil
 }
 
+// TaxCountry provides the country the party is registered in for tax
+// purposes, using the tax ID's country or, for parties without one such as
+// consumers, the country of the first address.
+func (p *Party) TaxCountry() l10n.TaxCountryCode {
+	if p == nil {
+		return ""
+	}
+	if p.TaxID != nil && p.TaxID.Country != "" {
+		return p.TaxID.Country
+	}
+	if len(p.Addresses) > 0 && p.Addresses[0] != nil {
+		return l10n.TaxCountryCode(p.Addresses[0].Country)
+	}
+	return ""
+}
+
 func (p *Party) normalizers() tax.Normalizers {
 	if r := p.RegimeDef(); r != nil {
 		return tax.Normalizers{r.Normalizer}
@@ -89,6 +89,25 @@ func TestPartyAddressNill(t *testing.T) {
 	assert.NoError(t, party.Validate())
 }
 
+func TestPartyTaxCountry(t *testing.T) {
+	var party *org.Party
+	assert.Empty(t, party.TaxCountry())
+
+	party = &org.Party{
+		TaxID: &tax.Identity{Country: "ES", Code: "B98602642"},
+		Addresses: []*org.Address{
+			{Country: "PT"},
+		},
+	}
+	assert.Equal(t, "ES", party.TaxCountry().String())
+
+	party.TaxID = nil
+	assert.Equal(t, "PT", party.TaxCountry().String())
+
+	party.Addresses = []*org.Address{nil}
+	assert.Empty(t, party.TaxCountry())
+}
+
 func TestPartyValidation(t *testing.T) {
 	t.Run("with regime", func(t *testing.T) {
 		party := org.Party{
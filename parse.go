@@ -9,7 +9,8 @@ import (
 // Parse unmarshals the provided data and uses the schema ID
 // to determine what type of object we're dealing with. As long as the
 // provided data contains a schema registered in GOBL, a new
-// object instance will be returned.
+// object instance will be returned with any registered migrations
+// applied.
 func Parse(data []byte) (interface{}, error) {
 	id, err := schema.Extract(data)
 	if err != nil {
@@ -20,6 +21,9 @@ func Parse(data []byte) (interface{}, error) {
 	}
 
 	obj := id.Interface()
+	if data, _, err = schema.Migrate(id, data, ""); err != nil {
+		return nil, ErrUnmarshal.WithCause(err)
+	}
 	if err := json.Unmarshal(data, obj); err != nil {
 		return nil, ErrUnmarshal.WithCause(err)
 	}
@@ -0,0 +1,309 @@
+// Package epc builds the EPC QR code defined by the European Payments
+// Council in the EPC069-12 guidelines, also known as "GiroCode", which
+// banking apps scan to prepare a SEPA credit transfer for the payment of
+// an invoice.
+package epc
+
+import (
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"math/big"
+	"regexp"
+	"strings"
+
+	"github.com/invopop/gobl/bill"
+	"github.com/invopop/gobl/cbc"
+	"github.com/invopop/gobl/currency"
+	"github.com/invopop/gobl/head"
+	"github.com/invopop/gobl/num"
+	"github.com/invopop/gobl/pkg/qr"
+	"github.com/invopop/validation"
+)
+
+// Fixed values of the header of the payload.
+const (
+	ServiceTag     = "BCD"
+	Version        = "002"
+	CharacterSet   = "1" // UTF-8
+	Identification = "SCT"
+)
+
+// Limits defined by the guidelines.
+const (
+	MaxNameLength        = 70
+	MaxReferenceLength   = 35
+	MaxTextLength        = 140
+	MaxInformationLength = 70
+	MaxPayloadSize       = 331
+)
+
+// LinkKey identifies the link to the QR code image in an envelope's
+// header.
+const LinkKey cbc.Key = "epc-qr"
+
+// DefaultScale is the number of pixels used for each module of the PNG
+// images embedded in links.
+const DefaultScale = 4
+
+var (
+	minAmount = num.MakeAmount(1, 2)
+	maxAmount = num.MakeAmount(99999999999, 2)
+)
+
+var (
+	bicPattern       = regexp.MustCompile(`^[A-Z]{6}[A-Z0-9]{2}([A-Z0-9]{3})?$`)
+	ibanPattern      = regexp.MustCompile(`^[A-Z]{2}[0-9]{2}[A-Z0-9]{11,30}$`)
+	referencePattern = regexp.MustCompile(`^RF[0-9]{2}[A-Z0-9]{1,21}$`)
+	purposePattern   = regexp.MustCompile(`^[A-Z]{4}$`)
+)
+
+// Code contains the data of a SEPA credit transfer to include in an EPC
+// QR code. Amounts are always in euros.
+type Code struct {
+	// BIC of the beneficiary's bank, optional inside the EEA.
+	BIC string
+	// Name of the beneficiary.
+	Name string
+	// IBAN of the beneficiary's account.
+	IBAN string
+	// Amount to transfer, if known.
+	Amount *num.Amount
+	// Purpose of the transfer, as an ISO 20022 code of four letters.
+	Purpose string
+	// Reference is the structured ISO 11649 creditor reference, starting
+	// with "RF".
+	Reference string
+	// Text with unstructured remittance information, only used when there
+	// is no structured reference.
+	Text string
+	// Information for the payer to be shown by the banking app.
+	Information string
+}
+
+// FromInvoice prepares the code to pay the invoice's due amount, or the
+// payable amount if there were no advances, using the first credit
+// transfer with an IBAN in the payment instructions. The beneficiary is
+// the invoice's payee or supplier, and the instructions' reference is used
+// as the remittance information, falling back to the invoice's code.
+func FromInvoice(inv *bill.Invoice) (*Code, error) {
+	if inv.Currency != currency.EUR {
+		return nil, fmt.Errorf("currency '%s' not supported, only EUR", inv.Currency)
+	}
+	if inv.Type.In(bill.InvoiceTypeCreditNote) {
+		return nil, errors.New("credit notes cannot be paid")
+	}
+	if inv.Totals == nil {
+		return nil, errors.New("missing totals, invoice must be calculated")
+	}
+	if inv.Payment == nil || inv.Payment.Instructions == nil {
+		return nil, errors.New("missing payment instructions")
+	}
+	instr := inv.Payment.Instructions
+	c := new(Code)
+	for _, ct := range instr.CreditTransfer {
+		if ct.IBAN != "" {
+			c.IBAN = ct.IBAN
+			c.BIC = ct.BIC
+			break
+		}
+	}
+	if c.IBAN == "" {
+		return nil, errors.New("missing credit transfer with an IBAN")
+	}
+	c.Name = inv.Supplier.Name
+	if inv.Payment.Payee != nil {
+		c.Name = inv.Payment.Payee.Name
+	}
+	amount := inv.Totals.Payable
+	if inv.Totals.Due != nil {
+		amount = *inv.Totals.Due
+	}
+	c.Amount = &amount
+	ref := cleanCode(instr.Ref.String())
+	if IsCreditorReference(ref) {
+		c.Reference = ref
+	} else if instr.Ref != cbc.CodeEmpty {
+		c.Text = instr.Ref.String()
+	} else {
+		c.Text = inv.Code.String()
+		if inv.Series != cbc.CodeEmpty {
+			c.Text = inv.Series.String() + "-" + c.Text
+		}
+	}
+	c.Normalize()
+	if err := c.Validate(); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+// Normalize removes spaces from account details and references, and
+// rounds the amount to cents.
+func (c *Code) Normalize() {
+	c.BIC = cleanCode(c.BIC)
+	c.IBAN = cleanCode(c.IBAN)
+	c.Reference = cleanCode(c.Reference)
+	c.Name = strings.TrimSpace(c.Name)
+	c.Text = strings.TrimSpace(c.Text)
+	c.Information = strings.TrimSpace(c.Information)
+	if c.Amount != nil {
+		a := c.Amount.Rescale(2)
+		c.Amount = &a
+	}
+}
+
+// Validate checks the code against the limits of the guidelines.
+func (c *Code) Validate() error {
+	err := validation.ValidateStruct(c,
+		validation.Field(&c.BIC, validation.Match(bicPattern)),
+		validation.Field(&c.Name,
+			validation.Required,
+			validation.RuneLength(0, MaxNameLength),
+		),
+		validation.Field(&c.IBAN,
+			validation.Required,
+			validation.Match(ibanPattern),
+			validation.By(checkMod97),
+		),
+		validation.Field(&c.Amount,
+			num.Min(minAmount),
+			num.Max(maxAmount),
+		),
+		validation.Field(&c.Purpose, validation.Match(purposePattern)),
+		validation.Field(&c.Reference,
+			validation.Length(0, MaxReferenceLength),
+			validation.Match(referencePattern),
+			validation.By(checkMod97),
+		),
+		validation.Field(&c.Text,
+			validation.When(
+				c.Reference != "",
+				validation.Empty.Error("must be blank with a reference"),
+			),
+			validation.RuneLength(0, MaxTextLength),
+		),
+		validation.Field(&c.Information, validation.RuneLength(0, MaxInformationLength)),
+	)
+	if err != nil {
+		return err
+	}
+	if n := len(c.Payload()); n > MaxPayloadSize {
+		return fmt.Errorf("payload of %d bytes exceeds the maximum of %d", n, MaxPayloadSize)
+	}
+	return nil
+}
+
+// Payload provides the text to encode in the QR code, with one field per
+// line and trailing empty lines removed.
+func (c *Code) Payload() string {
+	amount := ""
+	if c.Amount != nil {
+		amount = string(currency.EUR) + c.Amount.String()
+	}
+	lines := []string{
+		ServiceTag,
+		Version,
+		CharacterSet,
+		Identification,
+		c.BIC,
+		c.Name,
+		c.IBAN,
+		amount,
+		c.Purpose,
+		c.Reference,
+		c.Text,
+		c.Information,
+	}
+	for len(lines) > 0 && lines[len(lines)-1] == "" {
+		lines = lines[:len(lines)-1]
+	}
+	return strings.Join(lines, "\n")
+}
+
+// QR encodes the payload using the medium error correction level
+// required by the guidelines.
+func (c *Code) QR() (*qr.Code, error) {
+	if err := c.Validate(); err != nil {
+		return nil, err
+	}
+	return qr.Encode([]byte(c.Payload()), qr.LevelM)
+}
+
+// PNG provides an image of the QR code with the given number of pixels
+// per module.
+func (c *Code) PNG(scale int) ([]byte, error) {
+	q, err := c.QR()
+	if err != nil {
+		return nil, err
+	}
+	return q.PNG(scale)
+}
+
+// SVG provides a scalable image of the QR code.
+func (c *Code) SVG() ([]byte, error) {
+	q, err := c.QR()
+	if err != nil {
+		return nil, err
+	}
+	return q.SVG(), nil
+}
+
+// Link provides a header link containing the PNG image of the QR code as
+// a data URL, so that it can be added to an envelope.
+func (c *Code) Link() (*head.Link, error) {
+	data, err := c.PNG(DefaultScale)
+	if err != nil {
+		return nil, err
+	}
+	return &head.Link{
+		Key:         LinkKey,
+		Title:       "EPC QR Code",
+		Description: "Scan with a banking app to pay by SEPA credit transfer.",
+		MIME:        "image/png",
+		URL:         "data:image/png;base64," + base64.StdEncoding.EncodeToString(data),
+	}, nil
+}
+
+// IsCreditorReference returns true if the value is a valid ISO 11649
+// creditor reference.
+func IsCreditorReference(ref string) bool {
+	return referencePattern.MatchString(ref) && isMod97(ref)
+}
+
+func cleanCode(s string) string {
+	return strings.ToUpper(strings.ReplaceAll(s, " ", ""))
+}
+
+func checkMod97(value any) error {
+	s, _ := value.(string)
+	if s == "" || isMod97(s) {
+		return nil
+	}
+	return errors.New("invalid check digits")
+}
+
+// isMod97 checks the digits of IBANs and creditor references, calculated
+// after moving the first four characters to the end and replacing letters
+// with numbers.
+func isMod97(s string) bool {
+	if len(s) < 5 {
+		return false
+	}
+	var sb strings.Builder
+	for _, r := range s[4:] + s[:4] {
+		switch {
+		case r >= '0' && r <= '9':
+			sb.WriteRune(r)
+		case r >= 'A' && r <= 'Z':
+			fmt.Fprintf(&sb, "%d", r-'A'+10)
+		default:
+			return false
+		}
+	}
+	n, ok := new(big.Int).SetString(sb.String(), 10)
+	if !ok {
+		return false
+	}
+	return new(big.Int).Mod(n, big.NewInt(97)).Int64() == 1
+}
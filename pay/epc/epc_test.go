@@ -0,0 +1,262 @@
+package epc_test
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"image/png"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/invopop/gobl"
+	"github.com/invopop/gobl/bill"
+	"github.com/invopop/gobl/cbc"
+	"github.com/invopop/gobl/currency"
+	"github.com/invopop/gobl/num"
+	"github.com/invopop/gobl/org"
+	"github.com/invopop/gobl/pay/epc"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func loadInvoice(t *testing.T, path string) *bill.Invoice {
+	t.Helper()
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	env := new(gobl.Envelope)
+	require.NoError(t, json.Unmarshal(data, env))
+	inv, ok := env.Extract().(*bill.Invoice)
+	require.True(t, ok)
+	return inv
+}
+
+func TestFromInvoice(t *testing.T) {
+	t.Run("success", func(t *testing.T) {
+		inv := loadInvoice(t, "../../examples/de/out/invoice-de-de.json")
+		c, err := epc.FromInvoice(inv)
+		require.NoError(t, err)
+		assert.Equal(t, "DE89370400440532013000", c.IBAN)
+		assert.Equal(t, "Provide One GmbH", c.Name)
+		assert.Equal(t, "1927.80", c.Amount.String())
+		assert.Equal(t, "SAMPLE-001", c.Text)
+		assert.Equal(t, strings.Join([]string{
+			"BCD",
+			"002",
+			"1",
+			"SCT",
+			"",
+			"Provide One GmbH",
+			"DE89370400440532013000",
+			"EUR1927.80",
+			"",
+			"",
+			"SAMPLE-001",
+		}, "\n"), c.Payload())
+	})
+
+	t.Run("creditor reference and payee", func(t *testing.T) {
+		inv := loadInvoice(t, "../../examples/de/out/invoice-de-de.json")
+		inv.Payment.Instructions.Ref = "RF18 5390 0754 7034"
+		inv.Payment.Instructions.CreditTransfer[0].BIC = "COBADEFFXXX"
+		inv.Payment.Payee = &org.Party{Name: "Factoring Partner AG"}
+		due := num.MakeAmount(50000, 2)
+		inv.Totals.Due = &due
+		c, err := epc.FromInvoice(inv)
+		require.NoError(t, err)
+		assert.Equal(t, "BCD\n002\n1\nSCT\nCOBADEFFXXX\nFactoring Partner AG\nDE89370400440532013000\nEUR500.00\n\nRF18539007547034", c.Payload())
+	})
+
+	t.Run("unstructured reference", func(t *testing.T) {
+		inv := loadInvoice(t, "../../examples/de/out/invoice-de-de.json")
+		inv.Payment.Instructions.Ref = "INV-1234"
+		c, err := epc.FromInvoice(inv)
+		require.NoError(t, err)
+		assert.Empty(t, c.Reference)
+		assert.Equal(t, "INV-1234", c.Text)
+	})
+
+	t.Run("errors", func(t *testing.T) {
+		tests := []struct {
+			name string
+			edit func(inv *bill.Invoice)
+			err  string
+		}{
+			{
+				name: "currency",
+				edit: func(inv *bill.Invoice) { inv.Currency = currency.USD },
+				err:  "currency 'USD' not supported, only EUR",
+			},
+			{
+				name: "credit note",
+				edit: func(inv *bill.Invoice) { inv.Type = bill.InvoiceTypeCreditNote },
+				err:  "credit notes cannot be paid",
+			},
+			{
+				name: "not calculated",
+				edit: func(inv *bill.Invoice) { inv.Totals = nil },
+				err:  "missing totals, invoice must be calculated",
+			},
+			{
+				name: "no instructions",
+				edit: func(inv *bill.Invoice) { inv.Payment = nil },
+				err:  "missing payment instructions",
+			},
+			{
+				name: "no iban",
+				edit: func(inv *bill.Invoice) { inv.Payment.Instructions.CreditTransfer[0].IBAN = "" },
+				err:  "missing credit transfer with an IBAN",
+			},
+			{
+				name: "invalid iban",
+				edit: func(inv *bill.Invoice) { inv.Payment.Instructions.CreditTransfer[0].IBAN = "DE89370400440532013001" },
+				err:  "IBAN: invalid check digits.",
+			},
+			{
+				name: "long name",
+				edit: func(inv *bill.Invoice) { inv.Supplier.Name = strings.Repeat("a", 71) },
+				err:  "Name: the length must be no more than 70.",
+			},
+			{
+				name: "nothing due",
+				edit: func(inv *bill.Invoice) {
+					due := num.MakeAmount(0, 2)
+					inv.Totals.Due = &due
+				},
+				err: "Amount: must be no less than 0.01.",
+			},
+			{
+				name: "amount too large",
+				edit: func(inv *bill.Invoice) { inv.Totals.Payable = num.MakeAmount(100000000000, 2) },
+				err:  "Amount: must be no greater than 999999999.99.",
+			},
+			{
+				name: "long text",
+				edit: func(inv *bill.Invoice) { inv.Payment.Instructions.Ref = cbc.Code(strings.Repeat("A", 141)) },
+				err:  "Text: the length must be no more than 140.",
+			},
+		}
+		for _, tt := range tests {
+			t.Run(tt.name, func(t *testing.T) {
+				inv := loadInvoice(t, "../../examples/de/out/invoice-de-de.json")
+				tt.edit(inv)
+				_, err := epc.FromInvoice(inv)
+				assert.EqualError(t, err, tt.err)
+			})
+		}
+	})
+}
+
+func TestValidate(t *testing.T) {
+	valid := func() *epc.Code {
+		return &epc.Code{
+			BIC:  "BPOTBEB1",
+			Name: "Red Cross of Belgium",
+			IBAN: "BE72000000001616",
+		}
+	}
+	assert.NoError(t, valid().Validate())
+
+	tests := []struct {
+		name string
+		edit func(c *epc.Code)
+		err  string
+	}{
+		{
+			name: "bic",
+			edit: func(c *epc.Code) { c.BIC = "BPOT" },
+			err:  "BIC: must be in a valid format.",
+		},
+		{
+			name: "purpose",
+			edit: func(c *epc.Code) { c.Purpose = "charity" },
+			err:  "Purpose: must be in a valid format.",
+		},
+		{
+			name: "reference check digits",
+			edit: func(c *epc.Code) { c.Reference = "RF19539007547034" },
+			err:  "Reference: invalid check digits.",
+		},
+		{
+			name: "reference format",
+			edit: func(c *epc.Code) { c.Reference = "12345" },
+			err:  "Reference: must be in a valid format.",
+		},
+		{
+			name: "reference with text",
+			edit: func(c *epc.Code) {
+				c.Reference = "RF18539007547034"
+				c.Text = "Donation"
+			},
+			err: "Text: must be blank with a reference.",
+		},
+		{
+			name: "information",
+			edit: func(c *epc.Code) { c.Information = strings.Repeat("i", 71) },
+			err:  "Information: the length must be no more than 70.",
+		},
+		{
+			name: "payload size",
+			edit: func(c *epc.Code) {
+				c.Name = strings.Repeat("ü", 70)
+				c.Text = strings.Repeat("ü", 100)
+			},
+			err: "payload of 384 bytes exceeds the maximum of 331",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := valid()
+			tt.edit(c)
+			assert.EqualError(t, c.Validate(), tt.err)
+		})
+	}
+}
+
+func TestImages(t *testing.T) {
+	amount := num.MakeAmount(1, 0)
+	c := &epc.Code{
+		BIC:         "BPOTBEB1",
+		Name:        "Red Cross of Belgium",
+		IBAN:        "BE72000000001616",
+		Amount:      &amount,
+		Purpose:     "CHAR",
+		Text:        "Urgency fund",
+		Information: "Sample EPC QR code",
+	}
+	c.Normalize()
+	assert.Equal(t, "BCD\n002\n1\nSCT\nBPOTBEB1\nRed Cross of Belgium\nBE72000000001616\nEUR1.00\nCHAR\n\nUrgency fund\nSample EPC QR code", c.Payload())
+
+	q, err := c.QR()
+	require.NoError(t, err)
+	assert.Equal(t, 6, q.Version)
+
+	svg, err := c.SVG()
+	require.NoError(t, err)
+	assert.True(t, bytes.HasPrefix(svg, []byte("<svg ")))
+
+	l, err := c.Link()
+	require.NoError(t, err)
+	assert.Equal(t, epc.LinkKey, l.Key)
+	assert.Equal(t, "image/png", l.MIME)
+	require.True(t, strings.HasPrefix(l.URL, "data:image/png;base64,"))
+	data, err := base64.StdEncoding.DecodeString(strings.TrimPrefix(l.URL, "data:image/png;base64,"))
+	require.NoError(t, err)
+	img, err := png.Decode(bytes.NewReader(data))
+	require.NoError(t, err)
+	assert.Equal(t, (q.Size+8)*epc.DefaultScale, img.Bounds().Dx())
+
+	t.Run("invalid", func(t *testing.T) {
+		c := &epc.Code{Name: "Test"}
+		_, err := c.Link()
+		assert.EqualError(t, err, "IBAN: cannot be blank.")
+	})
+}
+
+func TestIsCreditorReference(t *testing.T) {
+	assert.True(t, epc.IsCreditorReference("RF18539007547034"))
+	assert.True(t, epc.IsCreditorReference("RF712348231"))
+	assert.False(t, epc.IsCreditorReference("RF18 5390 0754 7034"))
+	assert.False(t, epc.IsCreditorReference("RF00539007547034"))
+	assert.False(t, epc.IsCreditorReference("ABC"))
+}
@@ -0,0 +1,62 @@
+package qr
+
+import (
+	"bytes"
+	"fmt"
+	"image"
+	"image/color"
+	"image/png"
+)
+
+// Image provides the symbol as a black and white image, including the
+// quiet zone, with each module drawn as a square of scale pixels.
+func (c *Code) Image(scale int) image.Image {
+	if scale < 1 {
+		scale = 1
+	}
+	size := (c.Size + QuietZone*2) * scale
+	img := image.NewPaletted(image.Rect(0, 0, size, size), color.Palette{color.White, color.Black})
+	for y := 0; y < size; y++ {
+		for x := 0; x < size; x++ {
+			if c.Dark(x/scale-QuietZone, y/scale-QuietZone) {
+				img.SetColorIndex(x, y, 1)
+			}
+		}
+	}
+	return img
+}
+
+// PNG encodes the image of the symbol with the given scale.
+func (c *Code) PNG(scale int) ([]byte, error) {
+	buf := new(bytes.Buffer)
+	if err := png.Encode(buf, c.Image(scale)); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// SVG provides a scalable image of the symbol, including the quiet zone,
+// where each module measures one unit of the view box.
+func (c *Code) SVG() []byte {
+	size := c.Size + QuietZone*2
+	buf := new(bytes.Buffer)
+	fmt.Fprintf(buf, `<svg xmlns="http://www.w3.org/2000/svg" viewBox="0 0 %d %d" shape-rendering="crispEdges">`, size, size)
+	fmt.Fprintf(buf, `<rect width="%d" height="%d" fill="#fff"/>`, size, size)
+	buf.WriteString(`<path fill="#000" d="`)
+	for y := 0; y < c.Size; y++ {
+		for x := 0; x < c.Size; x++ {
+			if !c.Dark(x, y) {
+				continue
+			}
+			// join horizontal runs of dark modules into a single rectangle
+			w := 1
+			for c.Dark(x+w, y) {
+				w++
+			}
+			fmt.Fprintf(buf, "M%d %dh%dv1h-%dz", x+QuietZone, y+QuietZone, w, w)
+			x += w - 1
+		}
+	}
+	buf.WriteString(`"/></svg>`)
+	return buf.Bytes()
+}
@@ -0,0 +1,497 @@
+// Package qr generates QR Code symbols as defined in ISO/IEC 18004, so that
+// payment codes and similar can be produced without depending on external
+// services. Only the byte mode is supported, which is what payment standards
+// require.
+package qr
+
+import (
+	"errors"
+)
+
+// Level of error correction.
+type Level int
+
+// Error correction levels, with the approximate percentage of the symbol
+// that may be recovered.
+const (
+	LevelL Level = iota // 7%
+	LevelM              // 15%
+	LevelQ              // 25%
+	LevelH              // 30%
+)
+
+// Version range supported by the standard.
+const (
+	MinVersion = 1
+	MaxVersion = 40
+)
+
+// QuietZone is the number of light modules required around the symbol.
+const QuietZone = 4
+
+// ErrTooLong is returned when the data does not fit in a symbol of the
+// largest version.
+var ErrTooLong = errors.New("qr: data too long")
+
+// Number of error correction codewords per block, indexed by level and
+// version.
+var eccCodewordsPerBlock = [4][41]int{
+	{-1, 7, 10, 15, 20, 26, 18, 20, 24, 30, 18, 20, 24, 26, 30, 22, 24, 28, 30, 28, 28, 28, 28, 30, 30, 26, 28, 30, 30, 30, 30, 30, 30, 30, 30, 30, 30, 30, 30, 30, 30},
+	{-1, 10, 16, 26, 18, 24, 16, 18, 22, 22, 26, 30, 22, 22, 24, 24, 28, 28, 26, 26, 26, 26, 28, 28, 28, 28, 28, 28, 28, 28, 28, 28, 28, 28, 28, 28, 28, 28, 28, 28, 28},
+	{-1, 13, 22, 18, 26, 18, 24, 18, 22, 20, 24, 28, 26, 24, 20, 30, 24, 28, 28, 26, 30, 28, 30, 30, 30, 30, 28, 30, 30, 30, 30, 30, 30, 30, 30, 30, 30, 30, 30, 30, 30},
+	{-1, 17, 28, 22, 16, 22, 28, 26, 26, 24, 28, 24, 28, 22, 24, 24, 30, 28, 28, 26, 28, 30, 24, 30, 30, 30, 30, 30, 30, 30, 30, 30, 30, 30, 30, 30, 30, 30, 30, 30, 30},
+}
+
+// Number of error correction blocks, indexed by level and version.
+var eccBlocks = [4][41]int{
+	{-1, 1, 1, 1, 1, 1, 2, 2, 2, 2, 4, 4, 4, 4, 4, 6, 6, 6, 6, 7, 8, 8, 9, 9, 10, 12, 12, 12, 13, 14, 15, 16, 17, 18, 19, 19, 20, 21, 22, 24, 25},
+	{-1, 1, 1, 1, 2, 2, 4, 4, 4, 5, 5, 5, 8, 9, 9, 10, 10, 11, 13, 14, 16, 17, 17, 18, 20, 21, 23, 25, 26, 28, 29, 31, 33, 35, 37, 38, 40, 43, 45, 47, 49},
+	{-1, 1, 1, 2, 2, 4, 4, 6, 6, 8, 8, 8, 10, 12, 16, 12, 17, 16, 18, 21, 20, 23, 23, 25, 27, 29, 34, 34, 35, 38, 40, 43, 45, 48, 51, 53, 56, 59, 62, 65, 68},
+	{-1, 1, 1, 2, 4, 4, 4, 5, 6, 8, 8, 11, 11, 16, 16, 18, 16, 19, 21, 25, 25, 25, 34, 30, 32, 35, 37, 40, 42, 45, 48, 51, 54, 57, 60, 63, 66, 70, 74, 77, 81},
+}
+
+// Code is a QR Code symbol made up of a square grid of dark and light
+// modules.
+type Code struct {
+	// Version of the symbol, from 1 to 40.
+	Version int
+	// Level of error correction used.
+	Level Level
+	// Size is the number of modules on each side.
+	Size int
+
+	modules  []bool
+	function []bool
+}
+
+// Encode generates the smallest symbol able to contain the data at the
+// given error correction level, using the byte mode.
+func Encode(data []byte, level Level) (*Code, error) {
+	if level < LevelL || level > LevelH {
+		return nil, errors.New("qr: invalid level")
+	}
+	ver := MinVersion
+	for ; ver <= MaxVersion; ver++ {
+		if dataBitsRequired(len(data), ver) <= dataCodewords(ver, level)*8 {
+			break
+		}
+	}
+	if ver > MaxVersion {
+		return nil, ErrTooLong
+	}
+
+	// Segment with mode indicator, character count, and data
+	bb := new(bitBuffer)
+	bb.append(0x4, 4)
+	bb.append(len(data), countBits(ver))
+	for _, b := range data {
+		bb.append(int(b), 8)
+	}
+	capacity := dataCodewords(ver, level) * 8
+	bb.append(0, min(4, capacity-bb.len()))
+	bb.append(0, (8-bb.len()%8)%8)
+	for pad := 0xEC; bb.len() < capacity; pad ^= 0xEC ^ 0x11 {
+		bb.append(pad, 8)
+	}
+
+	c := &Code{
+		Version: ver,
+		Level:   level,
+		Size:    ver*4 + 17,
+	}
+	c.modules = make([]bool, c.Size*c.Size)
+	c.function = make([]bool, c.Size*c.Size)
+	c.drawFunctionPatterns()
+	c.drawCodewords(c.addECCAndInterleave(bb.bytes()))
+
+	best, penalty := 0, -1
+	for mask := 0; mask < 8; mask++ {
+		c.applyMask(mask)
+		c.drawFormatBits(mask)
+		if p := c.penalty(); penalty < 0 || p < penalty {
+			best, penalty = mask, p
+		}
+		c.applyMask(mask) // undo
+	}
+	c.applyMask(best)
+	c.drawFormatBits(best)
+	c.function = nil
+	return c, nil
+}
+
+// Dark returns true if the module at the x and y coordinates, starting
+// from the top left corner, is dark. Modules outside the symbol are
+// always light.
+func (c *Code) Dark(x, y int) bool {
+	if x < 0 || y < 0 || x >= c.Size || y >= c.Size {
+		return false
+	}
+	return c.modules[y*c.Size+x]
+}
+
+func (c *Code) set(x, y int, dark bool) {
+	c.modules[y*c.Size+x] = dark
+}
+
+func (c *Code) setFunction(x, y int, dark bool) {
+	c.set(x, y, dark)
+	c.function[y*c.Size+x] = true
+}
+
+func (c *Code) isFunction(x, y int) bool {
+	return c.function[y*c.Size+x]
+}
+
+func (c *Code) drawFunctionPatterns() {
+	// Timing patterns
+	for i := 0; i < c.Size; i++ {
+		c.setFunction(6, i, i%2 == 0)
+		c.setFunction(i, 6, i%2 == 0)
+	}
+
+	// Finder patterns, with their separators
+	c.drawFinderPattern(3, 3)
+	c.drawFinderPattern(c.Size-4, 3)
+	c.drawFinderPattern(3, c.Size-4)
+
+	// Alignment patterns, except where they would overlap the finders
+	pos := alignmentPositions(c.Version)
+	n := len(pos)
+	for i := 0; i < n; i++ {
+		for j := 0; j < n; j++ {
+			if (i == 0 && j == 0) || (i == 0 && j == n-1) || (i == n-1 && j == 0) {
+				continue
+			}
+			c.drawAlignmentPattern(pos[i], pos[j])
+		}
+	}
+
+	// Reserve the format areas, overwritten once the mask is chosen
+	c.drawFormatBits(0)
+	c.drawVersion()
+}
+
+func (c *Code) drawFinderPattern(x, y int) {
+	for dy := -4; dy <= 4; dy++ {
+		for dx := -4; dx <= 4; dx++ {
+			xx, yy := x+dx, y+dy
+			if xx < 0 || xx >= c.Size || yy < 0 || yy >= c.Size {
+				continue
+			}
+			d := max(abs(dx), abs(dy))
+			c.setFunction(xx, yy, d != 2 && d != 4)
+		}
+	}
+}
+
+func (c *Code) drawAlignmentPattern(x, y int) {
+	for dy := -2; dy <= 2; dy++ {
+		for dx := -2; dx <= 2; dx++ {
+			c.setFunction(x+dx, y+dy, max(abs(dx), abs(dy)) != 1)
+		}
+	}
+}
+
+// drawFormatBits adds both copies of the level and mask, protected with
+// a BCH code, alongside the single dark module.
+func (c *Code) drawFormatBits(mask int) {
+	// Format indicators of the levels are not in order: L=01, M=00, Q=11, H=10
+	data := [...]int{1, 0, 3, 2}[c.Level]<<3 | mask
+	rem := data
+	for i := 0; i < 10; i++ {
+		rem = (rem << 1) ^ ((rem >> 9) * 0x537)
+	}
+	bits := (data<<10 | rem) ^ 0x5412
+
+	for i := 0; i <= 5; i++ {
+		c.setFunction(8, i, bit(bits, i))
+	}
+	c.setFunction(8, 7, bit(bits, 6))
+	c.setFunction(8, 8, bit(bits, 7))
+	c.setFunction(7, 8, bit(bits, 8))
+	for i := 9; i < 15; i++ {
+		c.setFunction(14-i, 8, bit(bits, i))
+	}
+
+	for i := 0; i < 8; i++ {
+		c.setFunction(c.Size-1-i, 8, bit(bits, i))
+	}
+	for i := 8; i < 15; i++ {
+		c.setFunction(8, c.Size-15+i, bit(bits, i))
+	}
+	c.setFunction(8, c.Size-8, true)
+}
+
+// drawVersion adds both copies of the version information, only used
+// from version 7.
+func (c *Code) drawVersion() {
+	if c.Version < 7 {
+		return
+	}
+	rem := c.Version
+	for i := 0; i < 12; i++ {
+		rem = (rem << 1) ^ ((rem >> 11) * 0x1F25)
+	}
+	bits := c.Version<<12 | rem
+	for i := 0; i < 18; i++ {
+		a, b := c.Size-11+i%3, i/3
+		c.setFunction(a, b, bit(bits, i))
+		c.setFunction(b, a, bit(bits, i))
+	}
+}
+
+// drawCodewords places the data and error correction codewords in the
+// zigzag pattern, skipping function modules.
+func (c *Code) drawCodewords(data []byte) {
+	i := 0
+	for right := c.Size - 1; right >= 1; right -= 2 {
+		if right == 6 {
+			right = 5 // skip the vertical timing pattern
+		}
+		upward := (right+1)&2 == 0
+		for v := 0; v < c.Size; v++ {
+			y := v
+			if upward {
+				y = c.Size - 1 - v
+			}
+			for j := 0; j < 2; j++ {
+				x := right - j
+				if c.isFunction(x, y) || i >= len(data)*8 {
+					continue
+				}
+				c.set(x, y, bit(int(data[i>>3]), 7-i&7))
+				i++
+			}
+		}
+	}
+}
+
+// addECCAndInterleave splits the data into blocks, adds the error
+// correction codewords to each, and interleaves the result.
+func (c *Code) addECCAndInterleave(data []byte) []byte {
+	numBlocks := eccBlocks[c.Level][c.Version]
+	eccLen := eccCodewordsPerBlock[c.Level][c.Version]
+	raw := rawDataModules(c.Version) / 8
+	numShort := numBlocks - raw%numBlocks
+	shortLen := raw / numBlocks
+
+	div := rsDivisor(eccLen)
+	blocks := make([][]byte, numBlocks)
+	k := 0
+	for i := range blocks {
+		n := shortLen - eccLen
+		if i >= numShort {
+			n++
+		}
+		block := make([]byte, 0, shortLen+1)
+		block = append(block, data[k:k+n]...)
+		if i < numShort {
+			block = append(block, 0) // placeholder, skipped below
+		}
+		block = append(block, rsRemainder(data[k:k+n], div)...)
+		blocks[i] = block
+		k += n
+	}
+
+	out := make([]byte, 0, raw)
+	for i := 0; i < len(blocks[0]); i++ {
+		for j, block := range blocks {
+			if i != shortLen-eccLen || j >= numShort {
+				out = append(out, block[i])
+			}
+		}
+	}
+	return out
+}
+
+// applyMask inverts the data modules that match the mask's condition.
+// Applying the same mask twice reverts the change.
+func (c *Code) applyMask(mask int) {
+	for y := 0; y < c.Size; y++ {
+		for x := 0; x < c.Size; x++ {
+			var invert bool
+			switch mask {
+			case 0:
+				invert = (x+y)%2 == 0
+			case 1:
+				invert = y%2 == 0
+			case 2:
+				invert = x%3 == 0
+			case 3:
+				invert = (x+y)%3 == 0
+			case 4:
+				invert = (x/3+y/2)%2 == 0
+			case 5:
+				invert = x*y%2+x*y%3 == 0
+			case 6:
+				invert = (x*y%2+x*y%3)%2 == 0
+			case 7:
+				invert = ((x+y)%2+x*y%3)%2 == 0
+			}
+			if invert && !c.isFunction(x, y) {
+				c.modules[y*c.Size+x] = !c.modules[y*c.Size+x]
+			}
+		}
+	}
+}
+
+// penalty scores the symbol using the rules of the standard, so that the
+// mask with the lowest score may be chosen.
+func (c *Code) penalty() int {
+	p := 0
+	n := c.Size
+	// Runs of five or more modules of the same color, and finder-like
+	// patterns, in both rows and columns.
+	finder := []bool{true, false, true, true, true, false, true}
+	for _, vertical := range []bool{false, true} {
+		get := func(i, j int) bool {
+			if vertical {
+				return c.Dark(i, j)
+			}
+			return c.Dark(j, i)
+		}
+		for i := 0; i < n; i++ {
+			run := 1
+			for j := 1; j <= n; j++ {
+				if j < n && get(i, j) == get(i, j-1) {
+					run++
+					continue
+				}
+				if run >= 5 {
+					p += 3 + run - 5
+				}
+				run = 1
+			}
+			// finder patterns with four light modules on either side,
+			// which may be part of the quiet zone
+			for j := -4; j < n; j++ {
+				match := true
+				for k, d := range finder {
+					if get(i, j+4+k) != d {
+						match = false
+						break
+					}
+				}
+				if !match {
+					continue
+				}
+				before, after := true, true
+				for k := 0; k < 4; k++ {
+					if get(i, j+k) {
+						before = false
+					}
+					if get(i, j+11+k) {
+						after = false
+					}
+				}
+				if before || after {
+					p += 40
+				}
+			}
+		}
+	}
+
+	// Blocks of 2x2 modules of the same color
+	dark := 0
+	for y := 0; y < n; y++ {
+		for x := 0; x < n; x++ {
+			d := c.Dark(x, y)
+			if d {
+				dark++
+			}
+			if x < n-1 && y < n-1 && d == c.Dark(x+1, y) && d == c.Dark(x, y+1) && d == c.Dark(x+1, y+1) {
+				p += 3
+			}
+		}
+	}
+
+	// Balance of dark and light modules
+	total := n * n
+	k := (abs(dark*20-total*10)+total-1)/total - 1
+	p += k * 10
+	return p
+}
+
+// alignmentPositions provides the ascending list of center coordinates of
+// the alignment patterns.
+func alignmentPositions(ver int) []int {
+	if ver == 1 {
+		return nil
+	}
+	n := ver/7 + 2
+	step := (ver*8 + n*3 + 5) / (n*4 - 4) * 2
+	pos := make([]int, n)
+	pos[0] = 6
+	for i, p := n-1, ver*4+10; i > 0; i, p = i-1, p-step {
+		pos[i] = p
+	}
+	return pos
+}
+
+// rawDataModules is the number of modules available for data and error
+// correction codewords, including any remainder bits.
+func rawDataModules(ver int) int {
+	r := (16*ver+128)*ver + 64
+	if ver >= 2 {
+		n := ver/7 + 2
+		r -= (25*n-10)*n - 55
+		if ver >= 7 {
+			r -= 36
+		}
+	}
+	return r
+}
+
+// dataCodewords is the number of codewords available for data.
+func dataCodewords(ver int, level Level) int {
+	return rawDataModules(ver)/8 - eccCodewordsPerBlock[level][ver]*eccBlocks[level][ver]
+}
+
+func countBits(ver int) int {
+	if ver <= 9 {
+		return 8
+	}
+	return 16
+}
+
+func dataBitsRequired(n, ver int) int {
+	if n >= 1<<countBits(ver) {
+		return int(^uint(0) >> 1)
+	}
+	return 4 + countBits(ver) + n*8
+}
+
+func bit(v, i int) bool {
+	return (v>>i)&1 != 0
+}
+
+func abs(v int) int {
+	if v < 0 {
+		return -v
+	}
+	return v
+}
+
+type bitBuffer struct {
+	bits []bool
+}
+
+func (bb *bitBuffer) append(v, n int) {
+	for i := n - 1; i >= 0; i-- {
+		bb.bits = append(bb.bits, bit(v, i))
+	}
+}
+
+func (bb *bitBuffer) len() int {
+	return len(bb.bits)
+}
+
+func (bb *bitBuffer) bytes() []byte {
+	out := make([]byte, (len(bb.bits)+7)/8)
+	for i, b := range bb.bits {
+		if b {
+			out[i>>3] |= 1 << (7 - i&7)
+		}
+	}
+	return out
+}
@@ -0,0 +1,124 @@
+package qr_test
+
+import (
+	"bytes"
+	"image/png"
+	"strings"
+	"testing"
+
+	"github.com/invopop/gobl/pkg/qr"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEncode(t *testing.T) {
+	tests := []struct {
+		level   qr.Level
+		size    int
+		version int
+	}{
+		{qr.LevelL, 17, 1},
+		{qr.LevelL, 18, 2},
+		{qr.LevelM, 14, 1},
+		{qr.LevelM, 15, 2},
+		{qr.LevelM, 331, 13},
+		{qr.LevelM, 332, 14},
+		{qr.LevelQ, 1663, 40},
+		{qr.LevelH, 1273, 40},
+	}
+	for _, tt := range tests {
+		c, err := qr.Encode(bytes.Repeat([]byte("a"), tt.size), tt.level)
+		require.NoError(t, err)
+		assert.Equal(t, tt.version, c.Version, "%d bytes at level %d", tt.size, tt.level)
+		assert.Equal(t, tt.version*4+17, c.Size)
+	}
+
+	t.Run("too long", func(t *testing.T) {
+		_, err := qr.Encode(make([]byte, 2332), qr.LevelM)
+		assert.ErrorIs(t, err, qr.ErrTooLong)
+	})
+
+	t.Run("invalid level", func(t *testing.T) {
+		_, err := qr.Encode([]byte("a"), qr.Level(4))
+		assert.EqualError(t, err, "qr: invalid level")
+	})
+}
+
+func TestFunctionPatterns(t *testing.T) {
+	c, err := qr.Encode([]byte("BCD\n002\n1\nSCT"), qr.LevelM)
+	require.NoError(t, err)
+	// finder pattern in the top left corner, with its separator
+	for i, row := range []string{
+		"11111110",
+		"10000010",
+		"10111010",
+		"10111010",
+		"10111010",
+		"10000010",
+		"11111110",
+		"00000000",
+	} {
+		assert.Equal(t, row, rowString(c, i, 0, 8))
+		assert.Equal(t, reverse(row), rowString(c, i, c.Size-8, c.Size))
+	}
+	// timing pattern
+	assert.Equal(t, "10101", rowString(c, 6, 8, c.Size-8)[:5])
+	// dark module
+	assert.True(t, c.Dark(8, c.Size-8))
+	assert.False(t, c.Dark(-1, 0))
+	assert.False(t, c.Dark(c.Size, 0))
+}
+
+func TestVersionInformation(t *testing.T) {
+	c, err := qr.Encode(make([]byte, 110), qr.LevelM)
+	require.NoError(t, err)
+	require.Equal(t, 7, c.Version)
+	// 000111 110010 010100 for version 7, from the least significant bit
+	want := 0x07C94
+	for i := 0; i < 18; i++ {
+		a, b := c.Size-11+i%3, i/3
+		assert.Equal(t, want>>i&1 == 1, c.Dark(a, b), "bit %d", i)
+		assert.Equal(t, want>>i&1 == 1, c.Dark(b, a), "bit %d", i)
+	}
+}
+
+func TestImage(t *testing.T) {
+	c, err := qr.Encode([]byte("hello"), qr.LevelM)
+	require.NoError(t, err)
+
+	data, err := c.PNG(4)
+	require.NoError(t, err)
+	img, err := png.Decode(bytes.NewReader(data))
+	require.NoError(t, err)
+	size := (c.Size + 2*qr.QuietZone) * 4
+	assert.Equal(t, size, img.Bounds().Dx())
+	r, _, _, _ := img.At(0, 0).RGBA()
+	assert.NotZero(t, r, "quiet zone must be light")
+	r, _, _, _ = img.At(qr.QuietZone*4, qr.QuietZone*4).RGBA()
+	assert.Zero(t, r, "finder must be dark")
+
+	svg := string(c.SVG())
+	assert.True(t, strings.HasPrefix(svg, `<svg xmlns="http://www.w3.org/2000/svg" viewBox="0 0 29 29"`))
+	assert.Contains(t, svg, `<path fill="#000" d="M4 4h7v1h-7z`)
+	assert.True(t, strings.HasSuffix(svg, `"/></svg>`))
+}
+
+func rowString(c *qr.Code, y, from, to int) string {
+	var sb strings.Builder
+	for x := from; x < to; x++ {
+		if c.Dark(x, y) {
+			sb.WriteByte('1')
+		} else {
+			sb.WriteByte('0')
+		}
+	}
+	return sb.String()
+}
+
+func reverse(s string) string {
+	b := []byte(s)
+	for i, j := 0, len(b)-1; i < j; i, j = i+1, j-1 {
+		b[i], b[j] = b[j], b[i]
+	}
+	return string(b)
+}
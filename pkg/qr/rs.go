@@ -0,0 +1,43 @@
+package qr
+
+// rsDivisor provides the Reed-Solomon generator polynomial of the given
+// degree, without the leading coefficient, over GF(2^8) with the 0x11D
+// reduction polynomial.
+func rsDivisor(degree int) []byte {
+	out := make([]byte, degree)
+	out[degree-1] = 1
+	root := byte(1)
+	for i := 0; i < degree; i++ {
+		for j := range out {
+			out[j] = gfMultiply(out[j], root)
+			if j+1 < len(out) {
+				out[j] ^= out[j+1]
+			}
+		}
+		root = gfMultiply(root, 0x02)
+	}
+	return out
+}
+
+// rsRemainder provides the error correction codewords of the data.
+func rsRemainder(data, divisor []byte) []byte {
+	out := make([]byte, len(divisor))
+	for _, b := range data {
+		factor := b ^ out[0]
+		copy(out, out[1:])
+		out[len(out)-1] = 0
+		for i, d := range divisor {
+			out[i] ^= gfMultiply(d, factor)
+		}
+	}
+	return out
+}
+
+func gfMultiply(x, y byte) byte {
+	z := 0
+	for i := 7; i >= 0; i-- {
+		z = (z << 1) ^ ((z >> 7) * 0x11D)
+		z ^= int((y>>i)&1) * int(x)
+	}
+	return byte(z)
+}
@@ -6,6 +6,7 @@ import (
 	"github.com/invopop/gobl/cbc"
 	"github.com/invopop/gobl/currency"
 	"github.com/invopop/gobl/i18n"
+	"github.com/invopop/gobl/num"
 	"github.com/invopop/gobl/regimes/common"
 	"github.com/invopop/gobl/tax"
 )
@@ -19,6 +20,8 @@ func New() *tax.RegimeDef {
 	return &tax.RegimeDef{
 		Country:  "BE",
 		Currency: currency.EUR,
+		// Cash payments are rounded to the nearest 5 cents
+		CashRounding: num.NewAmount(5, 2),
 		Name: i18n.String{
 			i18n.EN: "Belgium",
 		},
@@ -19,6 +19,8 @@ const (
 	TaxCategoryIRPF cbc.Code = "IRPF"
 	TaxCategoryIGIC cbc.Code = "IGIC"
 	TaxCategoryIPSI cbc.Code = "IPSI"
+	// Special tax on non-reusable plastic packaging
+	TaxCategoryIEEPNR cbc.Code = "IEEPNR"
 )
 
 // Specific tax rate codes.
@@ -25,6 +25,13 @@ func (v *invoiceValidator) validate() error {
 			validation.By(v.supplier),
 			validation.Skip,
 		),
+		validation.Field(&inv.Outlays,
+			validation.Each(
+				validation.By(v.outlay),
+				validation.Skip,
+			),
+			validation.Skip,
+		),
 	)
 }
 
@@ -43,3 +50,33 @@ func (v *invoiceValidator) supplier(value interface{}) error {
 		),
 	)
 }
+
+func (v *invoiceValidator) outlay(value interface{}) error {
+	obj, _ := value.(*bill.Outlay)
+	if obj == nil {
+		return nil
+	}
+	// Outlays, or "suplidos", are only excluded from the tax base when
+	// they can be justified with a document issued in the customer's name
+	// by an identifiable third party.
+	return validation.ValidateStruct(obj,
+		validation.Field(&obj.Supplier,
+			validation.By(v.outlaySupplier),
+			validation.Skip,
+		),
+	)
+}
+
+func (v *invoiceValidator) outlaySupplier(value interface{}) error {
+	obj, _ := value.(*org.Party)
+	if obj == nil {
+		return nil
+	}
+	return validation.ValidateStruct(obj,
+		validation.Field(&obj.TaxID,
+			validation.Required,
+			tax.RequireIdentityCode,
+			validation.Skip,
+		),
+	)
+}
@@ -3,6 +3,10 @@ package es_test
 import (
 	"testing"
 
+	"github.com/invopop/gobl/bill"
+	"github.com/invopop/gobl/num"
+	"github.com/invopop/gobl/org"
+	"github.com/invopop/gobl/tax"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
@@ -23,4 +27,27 @@ func TestInvoiceValidation(t *testing.T) {
 		assert.ErrorContains(t, err, "supplier: (tax_id: cannot be blank.)")
 	})
 
+	t.Run("outlays", func(t *testing.T) {
+		inv := testInvoiceStandard(t)
+		inv.Outlays = []*bill.Outlay{
+			{
+				Description: "Notary fees",
+				Supplier: &org.Party{
+					Name: "Notary",
+				},
+				Amount: num.MakeAmount(5000, 2),
+			},
+		}
+		require.NoError(t, inv.Calculate())
+		err := inv.Validate()
+		assert.ErrorContains(t, err, "outlays: (0: (supplier: (tax_id: cannot be blank.).).)")
+
+		inv.Outlays[0].Supplier.TaxID = &tax.Identity{
+			Country: "ES",
+			Code:    "B85905495",
+		}
+		require.NoError(t, inv.Calculate())
+		require.NoError(t, inv.Validate())
+		assert.Equal(t, "50.00", inv.Totals.Outlays.String())
+	})
 }
@@ -2,6 +2,7 @@ package es
 
 import (
 	"github.com/invopop/gobl/cal"
+	"github.com/invopop/gobl/cbc"
 	"github.com/invopop/gobl/i18n"
 	"github.com/invopop/gobl/num"
 	"github.com/invopop/gobl/pkg/here"
@@ -32,6 +33,11 @@ var taxCategories = []*tax.CategoryDef{
 				to its local needs.
 			`),
 		},
+		// Special taxes applied to the same operation form part of the VAT
+		// base, as per article 78.Dos.4º of the VAT law.
+		Compound: []cbc.Code{
+			TaxCategoryIEEPNR,
+		},
 		Rates: []*tax.RateDef{
 			{
 				Key: tax.RateZero,
@@ -250,6 +256,54 @@ var taxCategories = []*tax.CategoryDef{
 		Rates: []*tax.RateDef{},
 	},
 
+	//
+	// IEEPNR
+	//
+	{
+		Code:     TaxCategoryIEEPNR,
+		Retained: false,
+		Name: i18n.String{
+			i18n.EN: "IEEPNR",
+			i18n.ES: "IEEPNR",
+		},
+		Title: i18n.String{
+			i18n.EN: "Special Tax on Non-Reusable Plastic Packaging",
+			i18n.ES: "Impuesto Especial sobre los Envases de Plástico No Reutilizables",
+		},
+		Description: &i18n.String{
+			i18n.EN: here.Doc(`
+				Excise tax applied per kilogram of non-recycled plastic contained in
+				non-reusable packaging. The combo's quantity should be used to define
+				the kilograms of non-recycled plastic when different from the line's
+				quantity.
+			`),
+		},
+		Sources: []*tax.Source{
+			{
+				Title: i18n.String{
+					i18n.EN: "Law 7/2022, Title VII",
+					i18n.ES: "Ley 7/2022, Título VII",
+				},
+				URL: "https://www.boe.es/buscar/act.php?id=BOE-A-2022-5809",
+			},
+		},
+		Rates: []*tax.RateDef{
+			{
+				Key: tax.RateStandard,
+				Name: i18n.String{
+					i18n.EN: "Standard Rate",
+					i18n.ES: "Tipo General",
+				},
+				Values: []*tax.RateValueDef{
+					{
+						Since:  cal.NewDate(2023, 1, 1),
+						Amount: num.NewAmount(45, 2),
+					},
+				},
+			},
+		},
+	},
+
 	//
 	// IRPF
 	//
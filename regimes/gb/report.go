@@ -0,0 +1,62 @@
+package gb
+
+import (
+	"github.com/invopop/gobl/cbc"
+	"github.com/invopop/gobl/i18n"
+	"github.com/invopop/gobl/num"
+	"github.com/invopop/gobl/tax"
+	"github.com/invopop/gobl/tax/report"
+)
+
+// VAT return boxes used by Making Tax Digital (MTD). Boxes 2, 8, and 9
+// only apply to the movement of goods between Northern Ireland and the EU
+// and are always provided as zero.
+
+func init() {
+	report.RegisterBoxMapper("GB", reportBoxes)
+}
+
+func reportBoxes(r *report.Report) []*report.Box {
+	zero := r.Currency.Def().Zero()
+	due := vatAmount(r.Sales, zero)
+	acq := zero
+	total := due.Add(acq)
+	reclaimed := vatAmount(r.Purchases, zero)
+	return []*report.Box{
+		newBox("1", "VAT due on sales and other outputs", due),
+		newBox("2", "VAT due on acquisitions of goods from EU member states", acq),
+		newBox("3", "Total VAT due", total),
+		newBox("4", "VAT reclaimed on purchases and other inputs", reclaimed),
+		newBox("5", "Net VAT to pay or reclaim", total.Subtract(reclaimed).Abs()),
+		newBox("6", "Total value of sales excluding VAT", summaryTotal(r.Sales, zero).Rescale(0)),
+		newBox("7", "Total value of purchases excluding VAT", summaryTotal(r.Purchases, zero).Rescale(0)),
+		newBox("8", "Total value of goods supplied to EU member states excluding VAT", zero.Rescale(0)),
+		newBox("9", "Total value of goods acquired from EU member states excluding VAT", zero.Rescale(0)),
+	}
+}
+
+func newBox(code cbc.Code, name string, amount num.Amount) *report.Box {
+	return &report.Box{
+		Code:   code,
+		Name:   i18n.String{i18n.EN: name},
+		Amount: amount,
+	}
+}
+
+func vatAmount(s *report.Summary, zero num.Amount) num.Amount {
+	if s == nil || s.Taxes == nil {
+		return zero
+	}
+	ct := s.Taxes.Category(tax.CategoryVAT)
+	if ct == nil {
+		return zero
+	}
+	return ct.Amount
+}
+
+func summaryTotal(s *report.Summary, zero num.Amount) num.Amount {
+	if s == nil {
+		return zero
+	}
+	return s.Total
+}
@@ -0,0 +1,73 @@
+package gb_test
+
+import (
+	"testing"
+
+	"github.com/invopop/gobl/bill"
+	"github.com/invopop/gobl/cal"
+	"github.com/invopop/gobl/num"
+	"github.com/invopop/gobl/org"
+	"github.com/invopop/gobl/tax"
+	"github.com/invopop/gobl/tax/report"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func testReportInvoice(t *testing.T, supplier, customer *tax.Identity, price int64) *bill.Invoice {
+	t.Helper()
+	inv := &bill.Invoice{
+		Regime:    tax.WithRegime("GB"),
+		Code:      "123",
+		IssueDate: cal.MakeDate(2024, 2, 1),
+		Supplier:  &org.Party{Name: "Supplier", TaxID: supplier},
+		Customer:  &org.Party{Name: "Customer", TaxID: customer},
+		Lines: []*bill.Line{
+			{
+				Quantity: num.MakeAmount(1, 0),
+				Item: &org.Item{
+					Name:  "Item",
+					Price: num.MakeAmount(price, 2),
+				},
+				Taxes: tax.Set{
+					{
+						Category: tax.CategoryVAT,
+						Rate:     tax.RateStandard,
+					},
+				},
+			},
+		},
+	}
+	require.NoError(t, inv.Calculate())
+	return inv
+}
+
+func TestReportBoxes(t *testing.T) {
+	own := &tax.Identity{Country: "GB", Code: "844281425"}
+	other := &tax.Identity{Country: "GB", Code: "350983637"}
+	period := cal.Period{
+		Start: cal.MakeDate(2024, 1, 1),
+		End:   cal.MakeDate(2024, 3, 31),
+	}
+	r, err := report.Build(own, period,
+		testReportInvoice(t, own, other, 100050),
+		testReportInvoice(t, other, own, 25000),
+	)
+	require.NoError(t, err)
+	require.Len(t, r.Boxes, 9)
+	amounts := make(map[string]string)
+	for _, b := range r.Boxes {
+		amounts[b.Code.String()] = b.Amount.String()
+	}
+	assert.Equal(t, map[string]string{
+		"1": "200.10",
+		"2": "0.00",
+		"3": "200.10",
+		"4": "50.00",
+		"5": "150.10",
+		"6": "1001",
+		"7": "250",
+		"8": "0",
+		"9": "0",
+	}, amounts)
+	assert.Equal(t, "Total VAT due", r.Boxes[2].Name.String())
+}
@@ -20,6 +20,8 @@ func New() *tax.RegimeDef {
 	return &tax.RegimeDef{
 		Country:  "NL",
 		Currency: currency.EUR,
+		// Cash payments are rounded to the nearest 5 cents
+		CashRounding: num.NewAmount(5, 2),
 		Name: i18n.String{
 			i18n.EN: "The Netherlands",
 			i18n.NL: "Nederland",
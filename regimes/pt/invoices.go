@@ -62,6 +62,13 @@ func (v *invoiceValidator) validate() error {
 			validation.By(v.supplier),
 			validation.Skip,
 		),
+		validation.Field(&inv.Outlays,
+			validation.Each(
+				validation.By(v.outlay),
+				validation.Skip,
+			),
+			validation.Skip,
+		),
 		validation.Field(&inv.Lines,
 			validation.Each(
 				validation.By(v.line),
@@ -114,3 +121,17 @@ func (v *invoiceValidator) item(value interface{}) error {
 		),
 	)
 }
+
+func (v *invoiceValidator) outlay(value interface{}) error {
+	obj, _ := value.(*bill.Outlay)
+	if obj == nil {
+		return nil
+	}
+	// Reimbursable expenses are excluded from the taxable amount (Art. 16.º,
+	// n.º 6, al. c) do CIVA) when paid in the name and on behalf of the
+	// customer, which requires the supporting document to be identified.
+	return validation.ValidateStruct(obj,
+		validation.Field(&obj.Date, validation.Required),
+		validation.Field(&obj.Code, validation.Required),
+	)
+}
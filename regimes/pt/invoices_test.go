@@ -70,6 +70,29 @@ func TestLineValidation(t *testing.T) {
 	assertValidationError(t, inv, "lines: (0: (item: (price: must be no less than 0.).).)")
 }
 
+func TestOutlayValidation(t *testing.T) {
+	inv := validInvoice()
+	inv.Outlays = []*bill.Outlay{
+		{
+			Description: "Custos de registo",
+			Amount:      num.MakeAmount(10, 0),
+		},
+	}
+	assertValidationError(t, inv, "outlays: (0: (code: cannot be blank; date: cannot be blank.).)")
+
+	inv = validInvoice()
+	inv.Outlays = []*bill.Outlay{
+		{
+			Date:        cal.NewDate(2023, 1, 1),
+			Code:        "FT 1/23",
+			Description: "Custos de registo",
+			Amount:      num.MakeAmount(10, 0),
+		},
+	}
+	require.NoError(t, inv.Calculate())
+	require.NoError(t, inv.Validate())
+}
+
 func assertValidationError(t *testing.T, inv *bill.Invoice, expected string) {
 	require.NoError(t, inv.Calculate())
 	err := inv.Validate()
@@ -0,0 +1,166 @@
+package render
+
+import (
+	"html/template"
+	"strings"
+
+	"github.com/invopop/gobl/bill"
+	"github.com/invopop/gobl/cbc"
+	"github.com/invopop/gobl/currency"
+	"github.com/invopop/gobl/head"
+	"github.com/invopop/gobl/i18n"
+	"github.com/invopop/gobl/num"
+	"github.com/invopop/gobl/pay"
+	"github.com/invopop/gobl/tax"
+)
+
+// invoiceData is provided to the invoice template.
+type invoiceData struct {
+	Invoice *bill.Invoice
+	Header  *head.Header
+	Lang    i18n.Lang
+}
+
+// baseFuncs are used to parse templates, and replaced with those bound to
+// each invoice during execution.
+var baseFuncs = newInvoiceFuncs(new(bill.Invoice), i18n.EN).funcMap()
+
+// invoiceFuncs provides the helpers used by templates to format amounts
+// and find the names of codes and keys.
+type invoiceFuncs struct {
+	lang   i18n.Lang
+	cur    *currency.Def
+	regime *tax.RegimeDef
+	tags   *tax.TagSet
+	qr     template.HTML
+}
+
+func newInvoiceFuncs(inv *bill.Invoice, lang i18n.Lang) *invoiceFuncs {
+	f := &invoiceFuncs{
+		lang:   lang,
+		cur:    inv.Currency.Def(),
+		regime: inv.RegimeDef(),
+	}
+	if f.cur == nil {
+		f.cur = currency.EUR.Def()
+	}
+	if f.regime != nil {
+		f.tags = f.tags.Merge(tax.TagSetForSchema(f.regime.Tags, bill.ShortSchemaInvoice))
+	}
+	for _, a := range inv.AddonDefs() {
+		f.tags = f.tags.Merge(tax.TagSetForSchema(a.Tags, bill.ShortSchemaInvoice))
+	}
+	return f
+}
+
+func (f *invoiceFuncs) funcMap() template.FuncMap {
+	return template.FuncMap{
+		"amount":   f.amount,
+		"percent":  f.percent,
+		"text":     f.text,
+		"typeName": f.typeName,
+		"tagName":  f.tagName,
+		"catName":  f.categoryName,
+		"rateName": f.rateName,
+		"payName":  f.meansName,
+		"termName": termName,
+		"isURL":    isURL,
+		"imageURL": imageURL,
+		"payQR":    f.paymentQR,
+	}
+}
+
+// amount formats the value, which may be a pointer, using the invoice's
+// currency.
+func (f *invoiceFuncs) amount(v any) string {
+	switch a := v.(type) {
+	case num.Amount:
+		return f.cur.FormatAmount(a)
+	case *num.Amount:
+		if a != nil {
+			return f.cur.FormatAmount(*a)
+		}
+	}
+	return ""
+}
+
+func (f *invoiceFuncs) percent(v any) string {
+	switch p := v.(type) {
+	case num.Percentage:
+		return f.cur.FormatPercentage(p)
+	case *num.Percentage:
+		if p != nil {
+			return f.cur.FormatPercentage(*p)
+		}
+	}
+	return ""
+}
+
+func (f *invoiceFuncs) text(s i18n.String) string {
+	return s.In(f.lang)
+}
+
+func (f *invoiceFuncs) typeName(k cbc.Key) string {
+	return f.keyName(k, bill.InvoiceTypes)
+}
+
+func (f *invoiceFuncs) tagName(k cbc.Key) string {
+	if f.tags == nil {
+		return k.String()
+	}
+	return f.keyName(k, f.tags.List)
+}
+
+func (f *invoiceFuncs) meansName(k cbc.Key) string {
+	return f.keyName(k, pay.MeansKeyDefinitions)
+}
+
+func (f *invoiceFuncs) categoryName(code cbc.Code) string {
+	if c := f.regime.CategoryDef(code); c != nil {
+		return c.Name.In(f.lang)
+	}
+	return code.String()
+}
+
+func (f *invoiceFuncs) rateName(code cbc.Code, k cbc.Key) string {
+	if k == cbc.KeyEmpty {
+		return ""
+	}
+	if r := f.regime.RateDef(code, k); r != nil {
+		return r.Name.In(f.lang)
+	}
+	return k.String()
+}
+
+func (f *invoiceFuncs) keyName(k cbc.Key, list []*cbc.KeyDefinition) string {
+	if kd := cbc.GetKeyDefinition(k, list); kd != nil {
+		return kd.Name.In(f.lang)
+	}
+	return k.String()
+}
+
+func termName(k cbc.Key) string {
+	for _, td := range pay.TermKeyDefinitions {
+		if td.Key == k {
+			return td.Title
+		}
+	}
+	return k.String()
+}
+
+func (f *invoiceFuncs) paymentQR() template.HTML {
+	return f.qr
+}
+
+// imageURL provides data URLs containing images so that they can be
+// embedded, or an empty URL for any other type of link.
+func imageURL(s string) template.URL {
+	if strings.HasPrefix(s, "data:image/") {
+		return template.URL(s) // nolint:gosec
+	}
+	return ""
+}
+
+func isURL(s string) bool {
+	return strings.HasPrefix(s, "https://") || strings.HasPrefix(s, "http://")
+}
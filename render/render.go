@@ -0,0 +1,139 @@
+// Package render produces self-contained HTML copies of GOBL documents that
+// can be read by humans, using the names defined by the tax regime and
+// addons of each document.
+//
+// Invoices to be paid by SEPA credit transfer in euros include an EPC QR
+// code in the payment section, which customers may scan with their banking
+// app.
+//
+// Templates are based on Go's html/template package. Addons may register
+// their own template using RegisterTemplate, which will be parsed on top of
+// the default so that only the blocks that need to change have to be
+// defined.
+package render
+
+import (
+	"bytes"
+	"embed"
+	"errors"
+	"fmt"
+	"html/template"
+
+	"github.com/invopop/gobl"
+	"github.com/invopop/gobl/bill"
+	"github.com/invopop/gobl/cbc"
+	"github.com/invopop/gobl/head"
+	"github.com/invopop/gobl/i18n"
+	"github.com/invopop/gobl/pay/epc"
+)
+
+//go:embed templates/*.html
+var content embed.FS
+
+// ErrUnsupported is returned when the document cannot be rendered.
+var ErrUnsupported = errors.New("unsupported document")
+
+var (
+	invoiceTemplate = template.Must(
+		template.New("invoice.html").Funcs(baseFuncs).ParseFS(content, "templates/invoice.html"),
+	)
+	addonTemplates = make(map[cbc.Key]*template.Template)
+)
+
+// Option is used to configure the output.
+type Option func(*options)
+
+type options struct {
+	lang i18n.Lang
+}
+
+// WithLanguage sets the language used for the names provided by the
+// regime and addons, when available. English is used by default.
+func WithLanguage(lang i18n.Lang) Option {
+	return func(o *options) {
+		o.lang = lang
+	}
+}
+
+// RegisterTemplate parses the source on top of the default invoice template
+// and uses the result for invoices with the addon. Any of the blocks of the
+// default template may be redefined.
+func RegisterTemplate(addon cbc.Key, src string) error {
+	t, err := invoiceTemplate.Clone()
+	if err != nil {
+		return err
+	}
+	if _, err := t.Parse(src); err != nil {
+		return fmt.Errorf("parsing template for '%s': %w", addon, err)
+	}
+	addonTemplates[addon] = t
+	return nil
+}
+
+// Envelope renders the document contained in the envelope, including the
+// stamps and links of its header.
+func Envelope(env *gobl.Envelope, opts ...Option) ([]byte, error) {
+	inv, ok := env.Extract().(*bill.Invoice)
+	if !ok {
+		return nil, fmt.Errorf("%w: only invoices are supported", ErrUnsupported)
+	}
+	return render(inv, env.Head, opts)
+}
+
+// Invoice renders the invoice, which is expected to have been calculated
+// already.
+func Invoice(inv *bill.Invoice, opts ...Option) ([]byte, error) {
+	return render(inv, nil, opts)
+}
+
+func render(inv *bill.Invoice, hdr *head.Header, opts []Option) ([]byte, error) {
+	o := &options{lang: i18n.EN}
+	for _, opt := range opts {
+		opt(o)
+	}
+	t, err := templateFor(inv).Clone()
+	if err != nil {
+		return nil, err
+	}
+	funcs := newInvoiceFuncs(inv, o.lang)
+	if hdr == nil || head.LinkByKey(hdr.Links, epc.LinkKey) == nil {
+		// links are shown with the header instead
+		funcs.qr = paymentQR(inv)
+	}
+	t.Funcs(funcs.funcMap())
+	buf := new(bytes.Buffer)
+	data := &invoiceData{
+		Invoice: inv,
+		Header:  hdr,
+		Lang:    o.lang,
+	}
+	if err := t.ExecuteTemplate(buf, "invoice.html", data); err != nil {
+		return nil, fmt.Errorf("rendering invoice: %w", err)
+	}
+	return buf.Bytes(), nil
+}
+
+// templateFor provides the template of the first addon that has one, or
+// the default.
+func templateFor(inv *bill.Invoice) *template.Template {
+	for _, k := range inv.GetAddons() {
+		if t, ok := addonTemplates[k]; ok {
+			return t
+		}
+	}
+	return invoiceTemplate
+}
+
+// paymentQR provides the SVG image of the EPC QR code of the invoice, or
+// nothing if the invoice cannot be paid with one.
+func paymentQR(inv *bill.Invoice) template.HTML {
+	c, err := epc.FromInvoice(inv)
+	if err != nil {
+		return ""
+	}
+	svg, err := c.SVG()
+	if err != nil {
+		return ""
+	}
+	return template.HTML(svg) // nolint:gosec
+}
@@ -0,0 +1,136 @@
+package render_test
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"testing"
+
+	"github.com/invopop/gobl"
+	"github.com/invopop/gobl/bill"
+	"github.com/invopop/gobl/cbc"
+	"github.com/invopop/gobl/head"
+	"github.com/invopop/gobl/i18n"
+	"github.com/invopop/gobl/pay/epc"
+	"github.com/invopop/gobl/render"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func loadEnvelope(t *testing.T, path string) *gobl.Envelope {
+	t.Helper()
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	env := new(gobl.Envelope)
+	require.NoError(t, json.Unmarshal(data, env))
+	return env
+}
+
+func TestEnvelope(t *testing.T) {
+	env := loadEnvelope(t, "../examples/es/out/invoice-es-es-freelance.json")
+	env.Head.Links = []*head.Link{
+		{Key: "verify", Title: "Verify online", URL: "https://example.com/verify?id=123"},
+	}
+	env.Head.Stamps = []*head.Stamp{
+		{Provider: "verification-code", Value: "ABC123"},
+		{Provider: "verification-url", Value: "https://example.com/qr?id=123"},
+	}
+	out, err := render.Envelope(env)
+	require.NoError(t, err)
+	html := string(out)
+	assert.Contains(t, html, "<!DOCTYPE html>")
+	assert.Contains(t, html, "<title>Invoice SAMPLE-001</title>")
+	assert.Contains(t, html, "<strong>MªF. Services</strong>")
+	assert.Contains(t, html, "VAT Standard Rate 21,0%")
+	assert.Contains(t, html, "IRPF 15,0% (retained)")
+	assert.Contains(t, html, "<td class=\"num\">€1.717,20</td>")
+	assert.Contains(t, html, "Credit Transfer")
+	assert.Contains(t, html, `<a href="https://example.com/verify?id=123">Verify online</a>`)
+	assert.Contains(t, html, "<code>ABC123</code>")
+	assert.Contains(t, html, `<a href="https://example.com/qr?id=123">`)
+
+	t.Run("language", func(t *testing.T) {
+		out, err := render.Envelope(env, render.WithLanguage(i18n.ES))
+		require.NoError(t, err)
+		html := string(out)
+		assert.Contains(t, html, `<html lang="es">`)
+		assert.Contains(t, html, "IVA Tipo General 21,0%")
+	})
+
+	t.Run("not an invoice", func(t *testing.T) {
+		env := loadEnvelope(t, "../examples/es/out/order-es-es.json")
+		_, err := render.Envelope(env)
+		assert.True(t, errors.Is(err, render.ErrUnsupported))
+	})
+}
+
+func TestInvoice(t *testing.T) {
+	env := loadEnvelope(t, "../examples/es/out/invoice-es-es.json")
+	inv, ok := env.Extract().(*bill.Invoice)
+	require.True(t, ok)
+	inv.Supplier.Name = "<script>alert(1)</script>"
+	inv.Type = bill.InvoiceTypeCreditNote
+
+	out, err := render.Invoice(inv)
+	require.NoError(t, err)
+	html := string(out)
+	assert.Contains(t, html, "<h1>Credit Note</h1>")
+	assert.Contains(t, html, "&lt;script&gt;alert(1)&lt;/script&gt;")
+	assert.NotContains(t, html, "<script>")
+	assert.NotContains(t, html, "Stamps")
+}
+
+func TestPaymentQR(t *testing.T) {
+	env := loadEnvelope(t, "../examples/de/out/invoice-de-de.json")
+	out, err := render.Envelope(env)
+	require.NoError(t, err)
+	assert.Contains(t, string(out), `<div class="payment-qr"><svg xmlns="http://www.w3.org/2000/svg"`)
+
+	t.Run("header link", func(t *testing.T) {
+		inv, ok := env.Extract().(*bill.Invoice)
+		require.True(t, ok)
+		c, err := epc.FromInvoice(inv)
+		require.NoError(t, err)
+		l, err := c.Link()
+		require.NoError(t, err)
+		env.Head.Links = []*head.Link{l}
+
+		out, err := render.Envelope(env)
+		require.NoError(t, err)
+		html := string(out)
+		assert.NotContains(t, html, `<div class="payment-qr">`)
+		assert.Contains(t, html, `<li><img src="data:image/png;base64,`)
+		assert.Contains(t, html, `alt="EPC QR Code"><br>EPC QR Code <span class="muted">Scan with a banking app`)
+	})
+
+	t.Run("not payable", func(t *testing.T) {
+		env := loadEnvelope(t, "../examples/es/out/invoice-es-es.json")
+		out, err := render.Envelope(env)
+		require.NoError(t, err)
+		assert.NotContains(t, string(out), `<div class="payment-qr">`)
+	})
+}
+
+func TestRegisterTemplate(t *testing.T) {
+	const addon cbc.Key = "test-render-v1"
+	require.NoError(t, render.RegisterTemplate(addon, `{{define "notes"}}<p>Custom notes</p>{{end}}`))
+
+	env := loadEnvelope(t, "../examples/es/out/invoice-es-es.json")
+	inv, ok := env.Extract().(*bill.Invoice)
+	require.True(t, ok)
+
+	out, err := render.Invoice(inv)
+	require.NoError(t, err)
+	assert.NotContains(t, string(out), "Custom notes")
+
+	inv.Addons.List = append(inv.Addons.List, addon)
+	out, err = render.Invoice(inv)
+	require.NoError(t, err)
+	assert.Contains(t, string(out), "<p>Custom notes</p>")
+	assert.Contains(t, string(out), "<h2>Lines</h2>")
+
+	t.Run("invalid", func(t *testing.T) {
+		err := render.RegisterTemplate(addon, `{{define "notes"}}`)
+		assert.ErrorContains(t, err, "parsing template for 'test-render-v1'")
+	})
+}
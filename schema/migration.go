@@ -0,0 +1,147 @@
+package schema
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"sort"
+
+	"github.com/Masterminds/semver/v3"
+)
+
+// Migration defines a transformation applied to the raw data of a document
+// with a specific schema so that content stored by older versions of GOBL
+// can be loaded by the current structures. Migrations must only modify data
+// that matches the old structure so that running them multiple times has no
+// additional effect.
+type Migration struct {
+	// Schema ID of the documents the migration applies to.
+	Schema ID `json:"schema"`
+	// Version of GOBL in which the structure was changed. Data produced by
+	// this version or later will not be migrated.
+	Version string `json:"version"`
+	// Key used to identify the migration in reports.
+	Key string `json:"key"`
+	// Description of what the migration does.
+	Description string `json:"description,omitempty"`
+	// Patterns contains fragments of raw JSON, such as quoted property names,
+	// that appear in data with the old structure. Documents are only decoded
+	// for migration when at least one pattern of the pending migrations is
+	// present.
+	Patterns []string `json:"-"`
+	// Func modifies the document data and returns true if any changes were
+	// made.
+	Func func(data map[string]any) bool `json:"-"`
+
+	version *semver.Version
+}
+
+// migrations contains all the registered migrations grouped by schema ID
+// and sorted by version.
+var migrations = make(map[ID][]*Migration)
+
+// RegisterMigration adds the migration to the global list of migrations that
+// will be applied to documents with a matching schema when loaded. Migrations
+// are sorted by version, with those for the same version applied in the order
+// they were registered. Invalid migrations will cause a panic.
+func RegisterMigration(m *Migration) {
+	if m.Schema == UnknownID || m.Key == "" || m.Func == nil || len(m.Patterns) == 0 {
+		panic(fmt.Sprintf("migration '%s': schema, key, patterns, and func are required", m.Key))
+	}
+	v, err := semver.NewVersion(m.Version)
+	if err != nil {
+		panic(fmt.Sprintf("migration '%s': invalid version: %s", m.Key, err))
+	}
+	m.version = v
+	list := append(migrations[m.Schema], m)
+	sort.SliceStable(list, func(i, j int) bool {
+		return list[i].version.LessThan(list[j].version)
+	})
+	migrations[m.Schema] = list
+}
+
+// Migrations provides the ordered list of migrations registered for the
+// schema ID.
+func Migrations(id ID) []*Migration {
+	return migrations[id]
+}
+
+// Migrate applies the migrations registered for the schema to the JSON data
+// provided. If the version of GOBL used to produce the data is known, only
+// the migrations introduced by later versions will be considered, otherwise
+// an empty version implies all migrations may apply. The resulting data is
+// returned alongside the list of migrations that made changes, or the
+// original data if no changes were made.
+func Migrate(id ID, data []byte, version string) ([]byte, []*Migration, error) {
+	list, err := pendingMigrations(id, data, version)
+	if err != nil {
+		return nil, nil, err
+	}
+	if len(list) == 0 {
+		return data, nil, nil
+	}
+	doc := make(map[string]any)
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber() // avoid losing precision
+	if err := dec.Decode(&doc); err != nil {
+		return nil, nil, err
+	}
+	var applied []*Migration
+	for _, m := range list {
+		if m.Func(doc) {
+			applied = append(applied, m)
+		}
+	}
+	if len(applied) == 0 {
+		return data, nil, nil
+	}
+	out, err := json.Marshal(doc)
+	if err != nil {
+		return nil, nil, err
+	}
+	return out, applied, nil
+}
+
+// pendingMigrations provides the migrations for the schema that were
+// introduced after the version, if any. Since earlier migrations may produce
+// the structures expected by later ones, the full list is provided as long
+// as the patterns of at least one of them match the data.
+func pendingMigrations(id ID, data []byte, version string) ([]*Migration, error) {
+	var from *semver.Version
+	if version != "" {
+		v, err := semver.NewVersion(version)
+		if err != nil {
+			return nil, fmt.Errorf("invalid version: %w", err)
+		}
+		from = v
+	}
+	var list []*Migration
+	match := false
+	for _, m := range migrations[id] {
+		if from != nil && !from.LessThan(m.version) {
+			continue
+		}
+		list = append(list, m)
+		if !match && m.matches(data) {
+			match = true
+		}
+	}
+	if !match {
+		return nil, nil
+	}
+	return list, nil
+}
+
+func (m *Migration) matches(data []byte) bool {
+	for _, p := range m.Patterns {
+		if bytes.Contains(data, []byte(p)) {
+			return true
+		}
+	}
+	return false
+}
+
+// String provides a human readable summary of the migration.
+func (m *Migration) String() string {
+	return fmt.Sprintf("%s (%s)", m.Key, m.Version)
+}
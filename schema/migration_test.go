@@ -0,0 +1,128 @@
+package schema_test
+
+import (
+	"testing"
+
+	"github.com/invopop/gobl/schema"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMigrate(t *testing.T) {
+	id := schema.ID("https://gobl.org/test/migration")
+	rename := func(from, to string) func(map[string]any) bool {
+		return func(data map[string]any) bool {
+			v, ok := data[from]
+			if !ok {
+				return false
+			}
+			data[to] = v
+			delete(data, from)
+			return true
+		}
+	}
+	schema.RegisterMigration(&schema.Migration{
+		Schema:   id,
+		Version:  "v0.200.0",
+		Key:      "second",
+		Patterns: []string{`"middle"`},
+		Func:     rename("middle", "new"),
+	})
+	schema.RegisterMigration(&schema.Migration{
+		Schema:   id,
+		Version:  "v0.100.0",
+		Key:      "first",
+		Patterns: []string{`"old"`},
+		Func:     rename("old", "middle"),
+	})
+
+	t.Run("ordered by version", func(t *testing.T) {
+		list := schema.Migrations(id)
+		require.Len(t, list, 2)
+		assert.Equal(t, "first", list[0].Key)
+		assert.Equal(t, "second (v0.200.0)", list[1].String())
+	})
+	t.Run("applies changes", func(t *testing.T) {
+		data, applied, err := schema.Migrate(id, []byte(`{"old":"foo","num":12.345678901234567890}`), "")
+		require.NoError(t, err)
+		assert.JSONEq(t, `{"new":"foo","num":12.345678901234567890}`, string(data))
+		require.Len(t, applied, 2)
+		assert.Equal(t, "first", applied[0].Key)
+		assert.Equal(t, "second", applied[1].Key)
+	})
+	t.Run("partial", func(t *testing.T) {
+		_, applied, err := schema.Migrate(id, []byte(`{"middle":"foo"}`), "")
+		require.NoError(t, err)
+		require.Len(t, applied, 1)
+		assert.Equal(t, "second", applied[0].Key)
+	})
+	t.Run("no changes", func(t *testing.T) {
+		in := []byte(`{"new": "foo"}`)
+		data, applied, err := schema.Migrate(id, in, "")
+		require.NoError(t, err)
+		assert.Empty(t, applied)
+		assert.Equal(t, in, data)
+	})
+	t.Run("unknown schema", func(t *testing.T) {
+		in := []byte(`{"old": "foo"}`)
+		data, applied, err := schema.Migrate("https://gobl.org/test/other", in, "")
+		require.NoError(t, err)
+		assert.Empty(t, applied)
+		assert.Equal(t, in, data)
+	})
+	t.Run("from version", func(t *testing.T) {
+		data, applied, err := schema.Migrate(id, []byte(`{"old":"foo"}`), "v0.150.0")
+		require.NoError(t, err)
+		assert.JSONEq(t, `{"old":"foo"}`, string(data), "older migration should be skipped")
+		assert.Empty(t, applied)
+
+		data, applied, err = schema.Migrate(id, []byte(`{"middle":"foo"}`), "v0.100.0")
+		require.NoError(t, err)
+		assert.JSONEq(t, `{"new":"foo"}`, string(data))
+		require.Len(t, applied, 1)
+		assert.Equal(t, "second", applied[0].Key)
+
+		in := []byte(`{"middle":"foo"}`)
+		data, applied, err = schema.Migrate(id, in, "v0.200.0")
+		require.NoError(t, err)
+		assert.Empty(t, applied)
+		assert.Equal(t, in, data)
+	})
+	t.Run("invalid version", func(t *testing.T) {
+		_, _, err := schema.Migrate(id, []byte(`{"old":"foo"}`), "bad")
+		assert.ErrorContains(t, err, "invalid version")
+	})
+	t.Run("patterns not matched", func(t *testing.T) {
+		in := []byte(`{"other":"foo"`) // invalid JSON is not decoded
+		data, applied, err := schema.Migrate(id, in, "")
+		require.NoError(t, err)
+		assert.Empty(t, applied)
+		assert.Equal(t, in, data)
+	})
+	t.Run("invalid data", func(t *testing.T) {
+		_, _, err := schema.Migrate(id, []byte(`{"old"`), "")
+		assert.Error(t, err)
+	})
+	t.Run("invalid migrations", func(t *testing.T) {
+		assert.Panics(t, func() {
+			schema.RegisterMigration(&schema.Migration{Schema: id, Version: "v1.0.0", Key: "bad"})
+		})
+		assert.Panics(t, func() {
+			schema.RegisterMigration(&schema.Migration{
+				Schema:  id,
+				Version: "v1.0.0",
+				Key:     "bad",
+				Func:    rename("a", "b"),
+			})
+		})
+		assert.Panics(t, func() {
+			schema.RegisterMigration(&schema.Migration{
+				Schema:   id,
+				Version:  "bad",
+				Key:      "bad",
+				Patterns: []string{`"a"`},
+				Func:     rename("a", "b"),
+			})
+		})
+	})
+}
@@ -30,6 +30,11 @@ const (
 type Object struct {
 	Schema  ID `json:"$schema"`
 	payload any
+
+	// version of GOBL used to produce the data, if known, so that only
+	// newer migrations are applied.
+	version  string
+	migrated []*Migration
 }
 
 // Calculable defines the methods expected of a document payload that contains a `Calculate`
@@ -63,6 +68,13 @@ func NewObject(payload interface{}) (*Object, error) {
 	return d, d.insert(payload)
 }
 
+// NewObjectFromVersion prepares an empty object that, when unmarshalled, will
+// only apply the migrations introduced after the version of GOBL used to
+// produce the data.
+func NewObjectFromVersion(version string) *Object {
+	return &Object{version: version}
+}
+
 // IsEmpty returns true if no payload has been set yet.
 func (d *Object) IsEmpty() bool {
 	return d.payload == nil
@@ -166,6 +178,12 @@ func (d *Object) insert(payload interface{}) error {
 	return nil
 }
 
+// Migrated provides the list of migrations that modified the document's
+// data when it was loaded.
+func (d *Object) Migrated() []*Migration {
+	return d.migrated
+}
+
 // UUID extracts the UUID from the payload using reflection. An empty
 // id is returned if the payload does not have a UUID field.
 func (d *Object) UUID() uuid.UUID {
@@ -205,6 +223,9 @@ func (d *Object) UnmarshalJSON(data []byte) error {
 	if d.payload == nil {
 		return ErrUnknownSchema
 	}
+	if data, d.migrated, err = Migrate(d.Schema, data, d.version); err != nil {
+		return err
+	}
 	if err := json.Unmarshal(data, d.payload); err != nil {
 		return err
 	}
@@ -6,6 +6,7 @@ import (
 
 	"github.com/invopop/gobl/cal"
 	"github.com/invopop/gobl/cbc"
+	"github.com/invopop/gobl/currency"
 	"github.com/invopop/gobl/l10n"
 	"github.com/invopop/gobl/num"
 	"github.com/invopop/validation"
@@ -27,11 +28,26 @@ type Combo struct {
 	Percent *num.Percentage `json:"percent,omitempty" jsonschema:"title=Percent" jsonschema_extras:"calculated=true"`
 	// Some countries require an additional surcharge (calculated if rate present).
 	Surcharge *num.Percentage `json:"surcharge,omitempty" jsonschema:"title=Surcharge" jsonschema_extras:"calculated=true"`
+	// Amount defines a fixed amount of tax to apply per unit, set manually or
+	// determined from the rate key (calculated if rate present), and typically
+	// used for excise duties and levies.
+	Amount *num.Amount `json:"amount,omitempty" jsonschema:"title=Amount" jsonschema_extras:"calculated=true"`
+	// Quantity of units the fixed amount applies to when different from the
+	// line's quantity, such as the total litres or kilograms of the items.
+	Quantity *num.Amount `json:"quantity,omitempty" jsonschema:"title=Quantity"`
+	// Compound lists the codes of other tax categories in the same set whose
+	// amounts should be included in this combo's base, overriding those
+	// defined by the category.
+	Compound []cbc.Code `json:"compound,omitempty" jsonschema:"title=Compound"`
 	// Local codes that apply for a given rate or percentage that need to be identified and validated.
 	Ext Extensions `json:"ext,omitempty" jsonschema:"title=Extensions"`
 
 	// Copied from the category definition, implies this tax combo is retained
 	retained bool `json:"-"`
+	// Currency of the fixed amount when copied from the regime's rate definition
+	currency currency.Code
+	// Copied from the category definition, other categories included in the base
+	compound []cbc.Code
 }
 
 // ValidateWithContext ensures the Combo has the correct details.
@@ -58,6 +74,20 @@ func (c *Combo) ValidateWithContext(ctx context.Context) error {
 			c.Percent == nil,
 			validation.Nil.Error("required with percent"),
 		)),
+		validation.Field(&c.Amount, num.Positive),
+		validation.Field(&c.Quantity,
+			validation.When(
+				c.Amount == nil,
+				validation.Nil.Error("required with amount"),
+			),
+			num.Min(num.MakeAmount(0, 0)),
+		),
+		validation.Field(&c.Compound,
+			validation.Each(
+				r.InCategories(),
+				validation.NotIn(c.Category).Error("must not include itself"),
+			),
+		),
 		validation.Field(&c.Ext),
 	)
 }
@@ -94,8 +124,9 @@ func (c *Combo) calculateForRegime(r *RegimeDef, tags []cbc.Key, date cal.Date)
 		return ErrInvalidCategory.WithMessage("'%s' not defined in regime", c.Category.String())
 	}
 	c.retained = category.Retained
+	c.compound = category.Compound
 
-	if err := c.prepareRate(category, tags, date); err != nil {
+	if err := c.prepareRate(r, category, tags, date); err != nil {
 		return err
 	}
 
@@ -110,8 +141,9 @@ func (c *Combo) calculateForRegime(r *RegimeDef, tags []cbc.Key, date cal.Date)
 
 // prepare updates the Combo object's Percent and Retained properties using the base totals
 // as a source of additional data for making decisions.
-func (c *Combo) prepareRate(category *CategoryDef, tags []cbc.Key, date cal.Date) error {
+func (c *Combo) prepareRate(r *RegimeDef, category *CategoryDef, tags []cbc.Key, date cal.Date) error {
 	// If there is no rate for the combo, there isn't much else we can do.
+	c.currency = currency.CodeEmpty
 	if c.Rate == cbc.KeyEmpty {
 		return nil
 	}
@@ -134,6 +166,7 @@ func (c *Combo) prepareRate(category *CategoryDef, tags []cbc.Key, date cal.Date
 	if rate.Exempt {
 		c.Percent = nil
 		c.Surcharge = nil
+		c.Amount = nil
 		return nil
 	}
 
@@ -147,8 +180,21 @@ func (c *Combo) prepareRate(category *CategoryDef, tags []cbc.Key, date cal.Date
 		return ErrInvalidDate.WithMessage("rate value unavailable for '%s' in '%s' on '%s'", c.Rate.String(), c.Category.String(), date.String())
 	}
 
-	p := value.Percent // copy
-	c.Percent = &p
+	if value.Amount != nil && value.Percent.IsZero() {
+		// fixed amount only
+		c.Percent = nil
+	} else {
+		p := value.Percent // copy
+		c.Percent = &p
+	}
+
+	if value.Amount != nil {
+		a := *value.Amount // copy
+		c.Amount = &a
+		c.currency = r.Currency
+	} else {
+		c.Amount = nil
+	}
 
 	if value.Surcharge != nil {
 		s := *value.Surcharge // copy
@@ -160,6 +206,15 @@ func (c *Combo) prepareRate(category *CategoryDef, tags []cbc.Key, date cal.Date
 	return nil
 }
 
+// compounds provides the list of category codes whose amounts should be
+// included in the combo's base.
+func (c *Combo) compounds() []cbc.Code {
+	if len(c.Compound) > 0 {
+		return c.Compound
+	}
+	return c.compound
+}
+
 // UnmarshalJSON is a temporary migration helper that will move the
 // first of the "tags" array used in earlier versions of GOBL into
 // the rate field.
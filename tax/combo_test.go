@@ -1,10 +1,12 @@
 package tax_test
 
 import (
+	"context"
 	"encoding/json"
 	"testing"
 
 	"github.com/invopop/gobl/cbc"
+	"github.com/invopop/gobl/num"
 	"github.com/invopop/gobl/tax"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -18,3 +20,33 @@ func TestComboUnmarshal(t *testing.T) {
 	assert.Equal(t, c.Category, cbc.Code("VAT"))
 	assert.Equal(t, c.Rate, cbc.Key("standard"))
 }
+
+func TestComboValidation(t *testing.T) {
+	t.Run("fixed amount", func(t *testing.T) {
+		c := &tax.Combo{
+			Category: tax.CategoryVAT,
+			Amount:   num.NewAmount(-10, 2),
+		}
+		err := c.ValidateWithContext(context.Background())
+		assert.ErrorContains(t, err, "amount: must be greater than 0")
+	})
+	t.Run("quantity without amount", func(t *testing.T) {
+		c := &tax.Combo{
+			Category: tax.CategoryVAT,
+			Percent:  num.NewPercentage(21, 2),
+			Quantity: num.NewAmount(2, 0),
+		}
+		err := c.ValidateWithContext(context.Background())
+		assert.ErrorContains(t, err, "quantity: required with amount")
+	})
+	t.Run("compound", func(t *testing.T) {
+		c := &tax.Combo{
+			Category: tax.CategoryVAT,
+			Percent:  num.NewPercentage(21, 2),
+			Compound: []cbc.Code{tax.CategoryVAT, "FOO"},
+		}
+		ctx := tax.RegimeDefFor("ES").WithContext(context.Background())
+		err := c.ValidateWithContext(ctx)
+		assert.ErrorContains(t, err, "compound: (0: must not include itself; 1: must be a valid value.)")
+	})
+}
@@ -19,6 +19,7 @@ const (
 	ErrInvalidRate          Error = "invalid-rate"
 	ErrInvalidDate          Error = "invalid-date"
 	ErrInvalidPricesInclude Error = "invalid-prices-include"
+	ErrInvalidCurrency      Error = "invalid-currency"
 )
 
 // Error serializes the error's message.
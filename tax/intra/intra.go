@@ -0,0 +1,99 @@
+// Package intra aggregates the invoices exchanged between businesses in
+// different member states of the European Union to prepare the EC Sales List
+// of intra-community supplies, and the Intrastat declarations of movements of
+// goods.
+//
+// Items are considered goods when they have an Intrastat commodity code or
+// their key is "goods", and services otherwise. The `eu-intrastat-v1` addon
+// should be used with invoices that include goods to ensure all the data
+// required by Intrastat is provided.
+package intra
+
+import (
+	"fmt"
+
+	"github.com/invopop/gobl/addons/eu/intrastat"
+	"github.com/invopop/gobl/bill"
+	"github.com/invopop/gobl/cal"
+	"github.com/invopop/gobl/currency"
+	"github.com/invopop/gobl/num"
+	"github.com/invopop/gobl/org"
+	"github.com/invopop/gobl/schema"
+	"github.com/invopop/gobl/tax"
+)
+
+func init() {
+	schema.Register(schema.GOBL.Add("tax/intra"),
+		SalesList{},
+		Intrastat{},
+	)
+}
+
+// defaultCurrency is used when the party's country does not have a regime.
+const defaultCurrency = currency.EUR
+
+// currencyFor determines the currency used by the party's country.
+func currencyFor(tID *tax.Identity) currency.Code {
+	if rd := tax.RegimeDefFor(tID.Country.Code()); rd != nil {
+		return rd.Currency
+	}
+	return defaultCurrency
+}
+
+func inPeriod(p cal.Period, d cal.Date) bool {
+	return !d.Before(p.Start.Date) && !d.After(p.End.Date)
+}
+
+// converter provides a function to convert amounts in the invoice's currency
+// to the currency provided.
+func converter(inv *bill.Invoice, cur currency.Code) (func(num.Amount) num.Amount, error) {
+	if inv.Currency == cur {
+		return func(a num.Amount) num.Amount { return a }, nil
+	}
+	ex := currency.MatchExchangeRate(inv.ExchangeRates, inv.Currency, cur)
+	if ex == nil {
+		return nil, fmt.Errorf("missing exchange rate from %s to %s", inv.Currency, cur)
+	}
+	return ex.Convert, nil
+}
+
+// isGoods returns true if the item should be declared as goods.
+func isGoods(item *org.Item) bool {
+	if item == nil {
+		return false
+	}
+	return item.Key == intrastat.ItemKeyGoods || item.Ext.Has(intrastat.ExtKeyCN)
+}
+
+// partyTaxID provides a copy of the party's tax identity, or nil if it does
+// not have a code.
+func partyTaxID(p *org.Party) *tax.Identity {
+	if p == nil || p.TaxID == nil || p.TaxID.Code == "" {
+		return nil
+	}
+	return &tax.Identity{
+		Country: p.TaxID.Country,
+		Code:    p.TaxID.Code,
+	}
+}
+
+func partyMatches(p *org.Party, tID *tax.Identity) bool {
+	if p == nil || p.TaxID == nil {
+		return false
+	}
+	return p.TaxID.Country == tID.Country && p.TaxID.Code == tID.Code
+}
+
+func identitiesMatch(a, b *tax.Identity) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return a.Country == b.Country && a.Code == b.Code
+}
+
+func invoiceLabel(inv *bill.Invoice) string {
+	if inv.Series != "" {
+		return fmt.Sprintf("%s-%s", inv.Series, inv.Code)
+	}
+	return inv.Code.String()
+}
@@ -0,0 +1,270 @@
+package intra_test
+
+import (
+	"testing"
+
+	"github.com/invopop/gobl/addons/eu/intrastat"
+	"github.com/invopop/gobl/bill"
+	"github.com/invopop/gobl/cal"
+	"github.com/invopop/gobl/cbc"
+	"github.com/invopop/gobl/currency"
+	"github.com/invopop/gobl/l10n"
+	"github.com/invopop/gobl/num"
+	"github.com/invopop/gobl/org"
+	"github.com/invopop/gobl/tax"
+	"github.com/invopop/gobl/tax/intra"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	_ "github.com/invopop/gobl"
+)
+
+var (
+	testSupplierTaxID = &tax.Identity{Country: "ES", Code: "B98602642"}
+	testCustomerTaxID = &tax.Identity{Country: "NL", Code: "000099995B57"}
+	testPartnerTaxID  = &tax.Identity{Country: "DE", Code: "111111125"}
+	testPeriod        = cal.Period{
+		Start: cal.MakeDate(2022, 1, 1),
+		End:   cal.MakeDate(2022, 3, 31),
+	}
+)
+
+func testGoods(name string, price int64, origin l10n.ISOCountryCode) *org.Item {
+	return &org.Item{
+		Name:   name,
+		Price:  num.MakeAmount(price, 2),
+		Origin: origin,
+		Unit:   org.UnitPiece,
+		Ext: tax.Extensions{
+			intrastat.ExtKeyCN:      "69120085",
+			intrastat.ExtKeyNetMass: "0.35",
+		},
+	}
+}
+
+func testInvoice(t *testing.T, supplier, customer *tax.Identity, items ...*org.Item) *bill.Invoice {
+	t.Helper()
+	inv := &bill.Invoice{
+		Regime:    tax.WithRegime(supplier.Country),
+		Addons:    tax.WithAddons(intrastat.V1),
+		Code:      "SAMPLE-001",
+		IssueDate: cal.MakeDate(2022, 2, 1),
+		Supplier: &org.Party{
+			Name:  "Supplier",
+			TaxID: supplier,
+		},
+		Customer: &org.Party{
+			Name:  "Customer",
+			TaxID: customer,
+		},
+	}
+	inv.SetTags(tax.TagReverseCharge)
+	for _, item := range items {
+		inv.Lines = append(inv.Lines, &bill.Line{
+			Quantity: num.MakeAmount(10, 0),
+			Item:     item,
+			Taxes: tax.Set{
+				{
+					Category: tax.CategoryVAT,
+					Rate:     tax.RateExempt,
+				},
+			},
+		})
+	}
+	require.NoError(t, inv.Calculate())
+	return inv
+}
+
+func TestBuildSalesList(t *testing.T) {
+	t.Run("goods and services", func(t *testing.T) {
+		inv := testInvoice(t, testSupplierTaxID, testCustomerTaxID,
+			testGoods("Mugs", 750, "ES"),
+			&org.Item{Name: "Design", Price: num.MakeAmount(2000, 2)},
+		)
+		inv.Discounts = []*bill.Discount{
+			{Reason: "Promotion", Amount: num.MakeAmount(500, 2)},
+		}
+		require.NoError(t, inv.Calculate())
+
+		sl, err := intra.BuildSalesList(testSupplierTaxID, testPeriod, inv)
+		require.NoError(t, err)
+		assert.NoError(t, sl.Validate())
+		assert.Equal(t, currency.EUR, sl.Currency)
+		require.Len(t, sl.Rows, 2)
+		assert.Equal(t, intra.IndicatorGoods, sl.Rows[0].Indicator)
+		assert.Equal(t, "NL000099995B57", sl.Rows[0].Customer.String())
+		assert.Equal(t, "73.64", sl.Rows[0].Amount.String(), "discount split by line totals")
+		assert.Equal(t, 1, sl.Rows[0].Count)
+		assert.Equal(t, intra.IndicatorServices, sl.Rows[1].Indicator)
+		assert.Equal(t, "196.36", sl.Rows[1].Amount.String())
+		assert.Equal(t, "270.00", sl.Total.String())
+	})
+	t.Run("credit notes and other invoices", func(t *testing.T) {
+		inv1 := testInvoice(t, testSupplierTaxID, testCustomerTaxID,
+			&org.Item{Name: "Design", Price: num.MakeAmount(2000, 2)},
+		)
+		inv2 := testInvoice(t, testSupplierTaxID, testCustomerTaxID,
+			&org.Item{Name: "Design", Price: num.MakeAmount(500, 2)},
+		)
+		inv2.Type = bill.InvoiceTypeCreditNote
+		domestic := testInvoice(t, testSupplierTaxID, &tax.Identity{Country: "ES", Code: "54387763P"},
+			&org.Item{Name: "Design", Price: num.MakeAmount(2000, 2)},
+		)
+		standard := testInvoice(t, testSupplierTaxID, testCustomerTaxID,
+			&org.Item{Name: "Design", Price: num.MakeAmount(2000, 2)},
+		)
+		standard.SetTags()
+		outside := testInvoice(t, testSupplierTaxID, testCustomerTaxID,
+			&org.Item{Name: "Design", Price: num.MakeAmount(2000, 2)},
+		)
+		outside.IssueDate = cal.MakeDate(2022, 4, 1)
+
+		sl, err := intra.BuildSalesList(testSupplierTaxID, testPeriod, inv1, inv2, domestic, standard, outside)
+		require.NoError(t, err)
+		require.Len(t, sl.Rows, 1)
+		assert.Equal(t, 2, sl.Rows[0].Count)
+		assert.Equal(t, "150.00", sl.Rows[0].Amount.String())
+		assert.Equal(t, "150.00", sl.Total.String())
+	})
+	t.Run("foreign currency", func(t *testing.T) {
+		inv := testInvoice(t, testSupplierTaxID, testCustomerTaxID,
+			&org.Item{Name: "Design", Price: num.MakeAmount(2000, 2)},
+		)
+		inv.Currency = currency.USD
+		inv.ExchangeRates = []*currency.ExchangeRate{
+			{From: currency.USD, To: currency.EUR, Amount: num.MakeAmount(90, 2)},
+		}
+		require.NoError(t, inv.Calculate())
+		sl, err := intra.BuildSalesList(testSupplierTaxID, testPeriod, inv)
+		require.NoError(t, err)
+		assert.Equal(t, "180.00", sl.Total.String())
+
+		inv.ExchangeRates = nil
+		_, err = intra.BuildSalesList(testSupplierTaxID, testPeriod, inv)
+		assert.ErrorContains(t, err, "invoice SAMPLE-001: missing exchange rate from USD to EUR")
+	})
+	t.Run("missing customer tax ID", func(t *testing.T) {
+		inv := testInvoice(t, testSupplierTaxID, &tax.Identity{Country: "NL"},
+			&org.Item{Name: "Design", Price: num.MakeAmount(2000, 2)},
+		)
+		_, err := intra.BuildSalesList(testSupplierTaxID, testPeriod, inv)
+		assert.ErrorContains(t, err, "missing customer tax ID")
+	})
+	t.Run("different supplier", func(t *testing.T) {
+		inv := testInvoice(t, testPartnerTaxID, testSupplierTaxID,
+			&org.Item{Name: "Design", Price: num.MakeAmount(2000, 2)},
+		)
+		_, err := intra.BuildSalesList(testSupplierTaxID, testPeriod, inv)
+		assert.ErrorContains(t, err, "supplier does not match tax ID")
+	})
+	t.Run("missing tax ID", func(t *testing.T) {
+		_, err := intra.BuildSalesList(nil, testPeriod)
+		assert.ErrorContains(t, err, "tax ID required")
+	})
+}
+
+func TestBuildIntrastat(t *testing.T) {
+	t.Run("dispatches", func(t *testing.T) {
+		inv1 := testInvoice(t, testSupplierTaxID, testCustomerTaxID,
+			testGoods("Mugs", 750, "ES"),
+			testGoods("Cups", 500, "ES"),
+			testGoods("Plates", 900, "PT"),
+			&org.Item{Name: "Design", Price: num.MakeAmount(2000, 2)},
+		)
+		inv2 := testInvoice(t, testSupplierTaxID, testCustomerTaxID,
+			testGoods("Mugs", 750, "ES"),
+		)
+		inv2.Type = bill.InvoiceTypeCreditNote
+
+		is, err := intra.BuildIntrastat(testSupplierTaxID, testPeriod, nil, inv1, inv2)
+		require.NoError(t, err)
+		assert.NoError(t, is.Validate())
+		assert.Nil(t, is.Arrivals)
+		require.NotNil(t, is.Dispatches)
+		rows := is.Dispatches.Rows
+		require.Len(t, rows, 2)
+		assert.Equal(t, l10n.TaxCountryCode("NL"), rows[0].Country)
+		assert.Equal(t, "NL000099995B57", rows[0].Partner.String())
+		assert.Equal(t, cbc.Code("69120085"), rows[0].Code)
+		assert.Equal(t, l10n.ISOCountryCode("ES"), rows[0].Origin)
+		assert.Equal(t, "50.00", rows[0].Value.String())
+		assert.Equal(t, "3.500", rows[0].NetMass.String())
+		assert.Equal(t, "10", rows[0].Quantity.String())
+		assert.Equal(t, org.UnitPiece, rows[0].Unit)
+		assert.Equal(t, l10n.ISOCountryCode("PT"), rows[1].Origin)
+		assert.Equal(t, "90.00", rows[1].Value.String())
+		assert.Equal(t, "140.00", is.Dispatches.Total.String())
+	})
+	t.Run("arrivals", func(t *testing.T) {
+		inv := testInvoice(t, testPartnerTaxID, testSupplierTaxID,
+			testGoods("Mugs", 750, ""),
+		)
+		is, err := intra.BuildIntrastat(testSupplierTaxID, testPeriod, nil, inv)
+		require.NoError(t, err)
+		assert.Nil(t, is.Dispatches)
+		require.NotNil(t, is.Arrivals)
+		require.Len(t, is.Arrivals.Rows, 1)
+		row := is.Arrivals.Rows[0]
+		assert.Equal(t, l10n.TaxCountryCode("DE"), row.Country)
+		assert.Nil(t, row.Partner)
+		assert.Equal(t, "75.00", row.Value.String())
+	})
+	t.Run("thresholds", func(t *testing.T) {
+		inv := testInvoice(t, testSupplierTaxID, testCustomerTaxID,
+			testGoods("Mugs", 750, "ES"),
+		)
+		opts := &intra.IntrastatOptions{
+			DispatchThreshold: num.MakeAmount(400000, 0),
+			PriorDispatches:   num.MakeAmount(399900, 0),
+		}
+		is, err := intra.BuildIntrastat(testSupplierTaxID, testPeriod, opts, inv)
+		require.NoError(t, err)
+		assert.Nil(t, is.Dispatches)
+
+		opts.PriorDispatches = num.MakeAmount(399950, 0)
+		is, err = intra.BuildIntrastat(testSupplierTaxID, testPeriod, opts, inv)
+		require.NoError(t, err)
+		assert.NotNil(t, is.Dispatches)
+	})
+	t.Run("domestic", func(t *testing.T) {
+		inv := testInvoice(t, testSupplierTaxID, &tax.Identity{Country: "ES", Code: "54387763P"},
+			testGoods("Mugs", 750, "ES"),
+		)
+		is, err := intra.BuildIntrastat(testSupplierTaxID, testPeriod, nil, inv)
+		require.NoError(t, err)
+		assert.Nil(t, is.Dispatches)
+	})
+	t.Run("missing data", func(t *testing.T) {
+		item := testGoods("Mugs", 750, "")
+		inv := testInvoice(t, testSupplierTaxID, testCustomerTaxID, item)
+		_, err := intra.BuildIntrastat(testSupplierTaxID, testPeriod, nil, inv)
+		assert.ErrorContains(t, err, "invoice SAMPLE-001: line 1: missing origin")
+
+		item.Origin = "ES"
+		delete(item.Ext, intrastat.ExtKeyNetMass)
+		_, err = intra.BuildIntrastat(testSupplierTaxID, testPeriod, nil, inv)
+		assert.ErrorContains(t, err, "line 1: missing net mass")
+
+		item.Ext = nil
+		item.Key = intrastat.ItemKeyGoods
+		_, err = intra.BuildIntrastat(testSupplierTaxID, testPeriod, nil, inv)
+		assert.ErrorContains(t, err, "line 1: missing commodity code")
+	})
+	t.Run("unrelated party", func(t *testing.T) {
+		inv := testInvoice(t, testPartnerTaxID, testCustomerTaxID,
+			testGoods("Mugs", 750, "ES"),
+		)
+		_, err := intra.BuildIntrastat(testSupplierTaxID, testPeriod, nil, inv)
+		assert.ErrorContains(t, err, "tax ID ESB98602642 not found in supplier or customer")
+	})
+}
+
+func TestInvoiceAddonValidation(t *testing.T) {
+	item := testGoods("Mugs", 750, "ES")
+	inv := testInvoice(t, testSupplierTaxID, testCustomerTaxID, item)
+	assert.NoError(t, inv.Validate())
+
+	delete(item.Ext, intrastat.ExtKeyCN)
+	err := inv.Validate()
+	assert.ErrorContains(t, err, "eu-intrastat-cn: required")
+}
@@ -0,0 +1,262 @@
+package intra
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/invopop/gobl/addons/eu/intrastat"
+	"github.com/invopop/gobl/bill"
+	"github.com/invopop/gobl/cal"
+	"github.com/invopop/gobl/cbc"
+	"github.com/invopop/gobl/currency"
+	"github.com/invopop/gobl/l10n"
+	"github.com/invopop/gobl/num"
+	"github.com/invopop/gobl/org"
+	"github.com/invopop/gobl/tax"
+	"github.com/invopop/gobl/uuid"
+	"github.com/invopop/validation"
+)
+
+// massExp is the precision used for net masses in kilograms.
+const massExp = 3
+
+// Intrastat summarizes the goods moved between the party and other member
+// states over a period, grouped as required by the Intrastat declarations.
+type Intrastat struct {
+	uuid.Identify
+	// Tax identity of the party the declaration is prepared for.
+	TaxID *tax.Identity `json:"tax_id" jsonschema:"title=Tax ID"`
+	// Period covered by the declaration, usually a month.
+	Period cal.Period `json:"period" jsonschema:"title=Period"`
+	// Currency used for all the values.
+	Currency currency.Code `json:"currency" jsonschema:"title=Currency"`
+	// Goods received from suppliers in other member states.
+	Arrivals *IntrastatFlow `json:"arrivals,omitempty" jsonschema:"title=Arrivals"`
+	// Goods supplied to customers in other member states.
+	Dispatches *IntrastatFlow `json:"dispatches,omitempty" jsonschema:"title=Dispatches"`
+}
+
+// IntrastatFlow contains the goods moved in one direction.
+type IntrastatFlow struct {
+	// Rows with the goods grouped by partner, commodity code, and origin.
+	Rows []*IntrastatRow `json:"rows" jsonschema:"title=Rows"`
+	// Total value of the goods.
+	Total num.Amount `json:"total" jsonschema:"title=Total"`
+}
+
+// IntrastatRow contains the totals of the goods with the same commodity
+// code and origin moved to or from a partner.
+type IntrastatRow struct {
+	// Member state of the partner.
+	Country l10n.TaxCountryCode `json:"country" jsonschema:"title=Country"`
+	// Tax identity of the customer, for dispatches only.
+	Partner *tax.Identity `json:"partner,omitempty" jsonschema:"title=Partner"`
+	// Combined Nomenclature (CN8) commodity code.
+	Code cbc.Code `json:"code" jsonschema:"title=Code"`
+	// Country of origin of the goods.
+	Origin l10n.ISOCountryCode `json:"origin,omitempty" jsonschema:"title=Origin"`
+	// Total value of the goods without tax.
+	Value num.Amount `json:"value" jsonschema:"title=Value"`
+	// Net mass of the goods in kilograms.
+	NetMass num.Amount `json:"net_mass" jsonschema:"title=Net Mass"`
+	// Quantity of the goods in supplementary units.
+	Quantity num.Amount `json:"quantity" jsonschema:"title=Quantity"`
+	// Unit of the quantity, if any.
+	Unit org.Unit `json:"unit,omitempty" jsonschema:"title=Unit"`
+}
+
+// IntrastatOptions contain the thresholds used to determine if the arrivals
+// and dispatches must be declared. Flows whose value during the year does not
+// exceed their threshold are not included. A zero threshold implies that all
+// movements are declared.
+type IntrastatOptions struct {
+	// Thresholds of the annual value of arrivals and dispatches, in the
+	// currency of the declaration.
+	ArrivalThreshold  num.Amount `json:"arrival_threshold"`
+	DispatchThreshold num.Amount `json:"dispatch_threshold"`
+	// Value of the arrivals and dispatches made during the year before the
+	// period. Parties that exceeded a threshold in the previous year should
+	// provide a value above it.
+	PriorArrivals   num.Amount `json:"prior_arrivals"`
+	PriorDispatches num.Amount `json:"prior_dispatches"`
+}
+
+// BuildIntrastat prepares the Intrastat declaration for the party with the tax
+// ID from the invoices issued during the period, where the party may be the
+// supplier or customer. Only the goods exchanged with partners in other member
+// states are included, using the line totals as values, and credit notes
+// are subtracted.
+func BuildIntrastat(tID *tax.Identity, period cal.Period, opts *IntrastatOptions, invoices ...*bill.Invoice) (*Intrastat, error) {
+	if tID == nil {
+		return nil, errors.New("tax ID required")
+	}
+	if opts == nil {
+		opts = new(IntrastatOptions)
+	}
+	is := &Intrastat{
+		TaxID:    tID,
+		Period:   period,
+		Currency: currencyFor(tID),
+	}
+	arrivals := is.newFlow()
+	dispatches := is.newFlow()
+	for _, inv := range invoices {
+		if err := is.add(arrivals, dispatches, inv); err != nil {
+			return nil, fmt.Errorf("invoice %s: %w", invoiceLabel(inv), err)
+		}
+	}
+	if exceeds(arrivals, opts.PriorArrivals, opts.ArrivalThreshold) {
+		is.Arrivals = arrivals
+	}
+	if exceeds(dispatches, opts.PriorDispatches, opts.DispatchThreshold) {
+		is.Dispatches = dispatches
+	}
+	return is, nil
+}
+
+// Validate ensures the declaration contains everything it needs.
+func (is *Intrastat) Validate() error {
+	return is.ValidateWithContext(context.Background())
+}
+
+// ValidateWithContext ensures the declaration contains everything it needs.
+func (is *Intrastat) ValidateWithContext(ctx context.Context) error {
+	return validation.ValidateStructWithContext(ctx, is,
+		validation.Field(&is.UUID),
+		validation.Field(&is.TaxID, validation.Required),
+		validation.Field(&is.Period),
+		validation.Field(&is.Currency, validation.Required),
+		validation.Field(&is.Arrivals),
+		validation.Field(&is.Dispatches),
+	)
+}
+
+// ValidateWithContext checks the flow's contents.
+func (f *IntrastatFlow) ValidateWithContext(ctx context.Context) error {
+	return validation.ValidateStructWithContext(ctx, f,
+		validation.Field(&f.Rows),
+	)
+}
+
+// ValidateWithContext checks the row's contents.
+func (row *IntrastatRow) ValidateWithContext(ctx context.Context) error {
+	return validation.ValidateStructWithContext(ctx, row,
+		validation.Field(&row.Country, validation.Required),
+		validation.Field(&row.Code, validation.Required),
+		validation.Field(&row.Origin),
+		validation.Field(&row.Unit),
+	)
+}
+
+func (is *Intrastat) newFlow() *IntrastatFlow {
+	return &IntrastatFlow{
+		Total: is.Currency.Def().Zero(),
+	}
+}
+
+func (is *Intrastat) add(arrivals, dispatches *IntrastatFlow, inv *bill.Invoice) error {
+	if !inPeriod(is.Period, inv.IssueDate) {
+		return nil
+	}
+	var flow *IntrastatFlow
+	var partner *org.Party
+	switch {
+	case partyMatches(inv.Supplier, is.TaxID):
+		flow, partner = dispatches, inv.Customer
+	case partyMatches(inv.Customer, is.TaxID):
+		flow, partner = arrivals, inv.Supplier
+	default:
+		return fmt.Errorf("tax ID %s not found in supplier or customer", is.TaxID)
+	}
+	pc := partner.TaxCountry()
+	if pc == is.TaxID.Country || !pc.InEU() {
+		return nil
+	}
+	conv, err := converter(inv, is.Currency)
+	if err != nil {
+		return err
+	}
+
+	var partnerID *tax.Identity
+	if flow == dispatches {
+		partnerID = partyTaxID(partner)
+	}
+	credit := inv.Type.In(bill.InvoiceTypeCreditNote)
+	for _, l := range inv.Lines {
+		if !isGoods(l.Item) {
+			continue
+		}
+		code := l.Item.Ext[intrastat.ExtKeyCN].Code()
+		if code == cbc.CodeEmpty {
+			return fmt.Errorf("line %d: missing commodity code", l.Index)
+		}
+		if flow == dispatches && l.Item.Origin == "" {
+			return fmt.Errorf("line %d: missing origin", l.Index)
+		}
+		mass, err := netMass(l)
+		if err != nil {
+			return fmt.Errorf("line %d: %w", l.Index, err)
+		}
+		value := conv(l.Total)
+		qty := l.Quantity
+		if credit {
+			value, mass, qty = value.Invert(), mass.Invert(), qty.Invert()
+		}
+		row := flow.row(is.Currency, &IntrastatRow{
+			Country: pc,
+			Partner: partnerID,
+			Code:    code,
+			Origin:  l.Item.Origin,
+			Unit:    l.Item.Unit,
+		})
+		row.Value = row.Value.Add(value)
+		row.NetMass = row.NetMass.Add(mass)
+		row.Quantity = row.Quantity.MatchPrecision(qty).Add(qty)
+		flow.Total = flow.Total.Add(value)
+	}
+	return nil
+}
+
+// row finds or adds the row that matches the grouping fields of the row
+// provided.
+func (f *IntrastatFlow) row(cur currency.Code, match *IntrastatRow) *IntrastatRow {
+	for _, row := range f.Rows {
+		if row.Country == match.Country &&
+			identitiesMatch(row.Partner, match.Partner) &&
+			row.Code == match.Code &&
+			row.Origin == match.Origin &&
+			row.Unit == match.Unit {
+			return row
+		}
+	}
+	match.Value = cur.Def().Zero()
+	match.NetMass = num.MakeAmount(0, massExp)
+	match.Quantity = num.MakeAmount(0, 0)
+	f.Rows = append(f.Rows, match)
+	return match
+}
+
+// netMass determines the total net mass of the line from the unit mass
+// provided in the item's extensions.
+func netMass(l *bill.Line) (num.Amount, error) {
+	v, ok := l.Item.Ext[intrastat.ExtKeyNetMass]
+	if !ok {
+		return num.Amount{}, errors.New("missing net mass")
+	}
+	m, err := num.AmountFromString(v.String())
+	if err != nil {
+		return num.Amount{}, fmt.Errorf("invalid net mass: %w", err)
+	}
+	return m.Upscale(massExp).Multiply(l.Quantity).Rescale(massExp), nil
+}
+
+func exceeds(f *IntrastatFlow, prior, threshold num.Amount) bool {
+	if len(f.Rows) == 0 {
+		return false
+	}
+	if threshold.IsZero() {
+		return true
+	}
+	return f.Total.Add(prior).Compare(threshold) > 0
+}
@@ -0,0 +1,190 @@
+package intra
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/invopop/gobl/bill"
+	"github.com/invopop/gobl/cal"
+	"github.com/invopop/gobl/cbc"
+	"github.com/invopop/gobl/currency"
+	"github.com/invopop/gobl/num"
+	"github.com/invopop/gobl/tax"
+	"github.com/invopop/gobl/uuid"
+	"github.com/invopop/validation"
+)
+
+// Sales list indicators used to distinguish the type of supply.
+const (
+	IndicatorGoods    cbc.Key = "goods"
+	IndicatorServices cbc.Key = "services"
+)
+
+// SalesList summarizes the intra-community supplies made by a supplier to
+// business customers in other member states over a period, as required by
+// the EC Sales List (recapitulative statement).
+type SalesList struct {
+	uuid.Identify
+	// Tax identity of the supplier.
+	TaxID *tax.Identity `json:"tax_id" jsonschema:"title=Tax ID"`
+	// Period covered by the list, usually a month or calendar quarter.
+	Period cal.Period `json:"period" jsonschema:"title=Period"`
+	// Currency used for all amounts.
+	Currency currency.Code `json:"currency" jsonschema:"title=Currency"`
+	// Rows with the value supplied to each customer by type of supply.
+	Rows []*SalesListRow `json:"rows,omitempty" jsonschema:"title=Rows"`
+	// Total value of all the supplies.
+	Total num.Amount `json:"total" jsonschema:"title=Total"`
+}
+
+// SalesListRow contains the total value of the goods or services supplied
+// to a customer.
+type SalesListRow struct {
+	// Tax identity of the customer in another member state.
+	Customer *tax.Identity `json:"customer" jsonschema:"title=Customer"`
+	// Type of supply, either goods or services.
+	Indicator cbc.Key `json:"indicator" jsonschema:"title=Indicator"`
+	// Number of invoices included.
+	Count int `json:"count" jsonschema:"title=Count"`
+	// Total value supplied, without tax.
+	Amount num.Amount `json:"amount" jsonschema:"title=Amount"`
+}
+
+// BuildSalesList prepares the EC Sales List for the supplier with the tax ID
+// from the invoices issued during the period. Only reverse charge invoices
+// for customers in other member states are included, and credit notes are
+// subtracted. Document level discounts and charges are applied to the goods
+// of an invoice if it has any, or the services otherwise.
+func BuildSalesList(tID *tax.Identity, period cal.Period, invoices ...*bill.Invoice) (*SalesList, error) {
+	if tID == nil {
+		return nil, errors.New("tax ID required")
+	}
+	sl := &SalesList{
+		TaxID:    tID,
+		Period:   period,
+		Currency: currencyFor(tID),
+	}
+	sl.Total = sl.Currency.Def().Zero()
+	for _, inv := range invoices {
+		if err := sl.add(inv); err != nil {
+			return nil, fmt.Errorf("invoice %s: %w", invoiceLabel(inv), err)
+		}
+	}
+	return sl, nil
+}
+
+// Validate ensures the sales list contains everything it needs.
+func (sl *SalesList) Validate() error {
+	return sl.ValidateWithContext(context.Background())
+}
+
+// ValidateWithContext ensures the sales list contains everything it needs.
+func (sl *SalesList) ValidateWithContext(ctx context.Context) error {
+	return validation.ValidateStructWithContext(ctx, sl,
+		validation.Field(&sl.UUID),
+		validation.Field(&sl.TaxID, validation.Required),
+		validation.Field(&sl.Period),
+		validation.Field(&sl.Currency, validation.Required),
+		validation.Field(&sl.Rows),
+	)
+}
+
+// ValidateWithContext checks the row's contents.
+func (row *SalesListRow) ValidateWithContext(ctx context.Context) error {
+	return validation.ValidateStructWithContext(ctx, row,
+		validation.Field(&row.Customer, validation.Required),
+		validation.Field(&row.Indicator,
+			validation.Required,
+			validation.In(IndicatorGoods, IndicatorServices),
+		),
+	)
+}
+
+func (sl *SalesList) add(inv *bill.Invoice) error {
+	if !inPeriod(sl.Period, inv.IssueDate) {
+		return nil
+	}
+	if !partyMatches(inv.Supplier, sl.TaxID) {
+		return errors.New("supplier does not match tax ID")
+	}
+	if inv.Totals == nil {
+		return errors.New("missing totals")
+	}
+	if !inv.HasTags(tax.TagReverseCharge) {
+		return nil
+	}
+	cc := inv.Customer.TaxCountry()
+	if cc == sl.TaxID.Country || !cc.InEU() {
+		return nil
+	}
+	customer := partyTaxID(inv.Customer)
+	if customer == nil {
+		return errors.New("missing customer tax ID")
+	}
+	conv, err := converter(inv, sl.Currency)
+	if err != nil {
+		return err
+	}
+
+	hasGoods := false
+	goods := num.MakeAmount(0, inv.Totals.Sum.Exp())
+	services := goods
+	for _, l := range inv.Lines {
+		if isGoods(l.Item) {
+			hasGoods = true
+			goods = goods.Add(l.Total)
+			continue
+		}
+		services = services.Add(l.Total)
+	}
+	total := inv.Totals.Total
+	amounts := make(map[cbc.Key]num.Amount)
+	switch {
+	case !hasGoods:
+		amounts[IndicatorServices] = total
+	case services.IsZero():
+		amounts[IndicatorGoods] = total
+	default:
+		// Document level discounts and charges are split between goods
+		// and services in proportion to their line totals.
+		if sum := goods.Add(services); !sum.IsZero() {
+			services = total.Upscale(4).Multiply(services).Divide(sum).Rescale(total.Exp())
+		}
+		amounts[IndicatorGoods] = total.Subtract(services)
+		amounts[IndicatorServices] = services
+	}
+
+	credit := inv.Type.In(bill.InvoiceTypeCreditNote)
+	for _, ind := range []cbc.Key{IndicatorGoods, IndicatorServices} {
+		a, ok := amounts[ind]
+		if !ok {
+			continue
+		}
+		a = conv(a)
+		if credit {
+			a = a.Invert()
+		}
+		row := sl.row(customer, ind)
+		row.Count++
+		row.Amount = row.Amount.Add(a)
+		sl.Total = sl.Total.Add(a)
+	}
+	return nil
+}
+
+// row finds or creates the row for the customer and indicator.
+func (sl *SalesList) row(customer *tax.Identity, ind cbc.Key) *SalesListRow {
+	for _, row := range sl.Rows {
+		if row.Indicator == ind && identitiesMatch(row.Customer, customer) {
+			return row
+		}
+	}
+	row := &SalesListRow{
+		Customer:  customer,
+		Indicator: ind,
+		Amount:    sl.Currency.Def().Zero(),
+	}
+	sl.Rows = append(sl.Rows, row)
+	return row
+}
@@ -0,0 +1,53 @@
+// Package oss helps determine the place of supply of business to consumer
+// (B2C) distance sales of goods and telecommunications, broadcasting, and
+// electronic (TBE) services inside the European Union, and prepare
+// the periodic returns for the One-Stop-Shop (OSS) and Import One-Stop-Shop
+// (IOSS) schemes.
+package oss
+
+import (
+	"github.com/invopop/gobl/cbc"
+	"github.com/invopop/gobl/currency"
+	"github.com/invopop/gobl/num"
+	"github.com/invopop/gobl/schema"
+)
+
+func init() {
+	schema.Register(schema.GOBL.Add("tax/oss"),
+		Return{},
+	)
+}
+
+// One-Stop-Shop schemes
+const (
+	// SchemeUnion is used by suppliers established in the EU for intra-EU
+	// distance sales of goods and B2C services.
+	SchemeUnion cbc.Key = "union"
+	// SchemeNonUnion is used by suppliers established outside the EU for B2C
+	// services provided to consumers inside the EU.
+	SchemeNonUnion cbc.Key = "non-union"
+	// SchemeImport (IOSS) is used for distance sales of goods imported from
+	// outside the EU in consignments that do not exceed the import limit.
+	SchemeImport cbc.Key = "import"
+)
+
+// Schemes contains the list of supported OSS schemes.
+var Schemes = []cbc.Key{
+	SchemeUnion,
+	SchemeNonUnion,
+	SchemeImport,
+}
+
+var (
+	// DefaultThreshold is the EU wide annual limit in EUR of cross-border
+	// B2C sales under which suppliers may continue to apply their own
+	// country's VAT.
+	DefaultThreshold = num.MakeAmount(10000, 0)
+
+	// ImportLimit is the maximum value in EUR of a consignment of imported
+	// goods that may be declared using the import scheme.
+	ImportLimit = num.MakeAmount(150, 0)
+)
+
+// baseCurrency is used for thresholds and limits.
+const baseCurrency = currency.EUR
@@ -0,0 +1,244 @@
+package oss_test
+
+import (
+	"testing"
+
+	"github.com/invopop/gobl/bill"
+	"github.com/invopop/gobl/cal"
+	"github.com/invopop/gobl/cbc"
+	"github.com/invopop/gobl/currency"
+	"github.com/invopop/gobl/l10n"
+	"github.com/invopop/gobl/num"
+	"github.com/invopop/gobl/org"
+	"github.com/invopop/gobl/tax"
+	"github.com/invopop/gobl/tax/oss"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	_ "github.com/invopop/gobl"
+)
+
+var testSupplierTaxID = &tax.Identity{Country: "ES", Code: "B98602642"}
+
+func testInvoice(t *testing.T, supplier *tax.Identity, customer *org.Party, price int64) *bill.Invoice {
+	t.Helper()
+	inv := &bill.Invoice{
+		Regime:    tax.WithRegime(supplier.Country),
+		Code:      "123",
+		IssueDate: cal.MakeDate(2024, 5, 10),
+		Supplier: &org.Party{
+			Name:  "Supplier",
+			TaxID: supplier,
+		},
+		Customer: customer,
+		Lines: []*bill.Line{
+			{
+				Quantity: num.MakeAmount(1, 0),
+				Item: &org.Item{
+					Name:  "Item",
+					Price: num.MakeAmount(price, 2),
+				},
+				Taxes: tax.Set{
+					{
+						Category: tax.CategoryVAT,
+						Rate:     tax.RateStandard,
+					},
+				},
+			},
+		},
+	}
+	require.NoError(t, inv.Calculate())
+	return inv
+}
+
+func testConsumer(country l10n.ISOCountryCode) *org.Party {
+	return &org.Party{
+		Name: "Consumer",
+		Addresses: []*org.Address{
+			{
+				Locality: "Somewhere",
+				Country:  country,
+			},
+		},
+	}
+}
+
+func TestPlaceOfSupply(t *testing.T) {
+	t.Run("domestic", func(t *testing.T) {
+		inv := testInvoice(t, testSupplierTaxID, testConsumer("ES"), 10000)
+		s, err := oss.PlaceOfSupply(inv, nil)
+		require.NoError(t, err)
+		assert.Equal(t, l10n.TaxCountryCode("ES"), s.Country)
+		assert.Empty(t, s.Scheme)
+	})
+	t.Run("below threshold", func(t *testing.T) {
+		inv := testInvoice(t, testSupplierTaxID, testConsumer("PT"), 10000)
+		s, err := oss.PlaceOfSupply(inv, &oss.Options{
+			Sales: num.MakeAmount(9900, 0),
+		})
+		require.NoError(t, err)
+		assert.Equal(t, l10n.TaxCountryCode("ES"), s.Country)
+		assert.Empty(t, s.Scheme)
+	})
+	t.Run("above threshold", func(t *testing.T) {
+		inv := testInvoice(t, testSupplierTaxID, testConsumer("PT"), 10000)
+		s, err := oss.PlaceOfSupply(inv, &oss.Options{
+			Sales: num.MakeAmount(9901, 0),
+		})
+		require.NoError(t, err)
+		assert.Equal(t, l10n.TaxCountryCode("PT"), s.Country)
+		assert.Equal(t, oss.SchemeUnion, s.Scheme)
+	})
+	t.Run("custom threshold", func(t *testing.T) {
+		inv := testInvoice(t, testSupplierTaxID, testConsumer("PT"), 10000)
+		s, err := oss.PlaceOfSupply(inv, &oss.Options{
+			Threshold: num.NewAmount(0, 0),
+		})
+		require.NoError(t, err)
+		assert.Equal(t, oss.SchemeUnion, s.Scheme)
+	})
+	t.Run("other services", func(t *testing.T) {
+		inv := testInvoice(t, testSupplierTaxID, testConsumer("PT"), 10000)
+		s, err := oss.PlaceOfSupply(inv, &oss.Options{
+			Sales:    num.MakeAmount(20000, 0),
+			Services: true,
+		})
+		require.NoError(t, err)
+		assert.Equal(t, l10n.TaxCountryCode("ES"), s.Country)
+		assert.Empty(t, s.Scheme)
+	})
+	t.Run("business customer", func(t *testing.T) {
+		inv := testInvoice(t, testSupplierTaxID, &org.Party{
+			Name:  "Business",
+			TaxID: &tax.Identity{Country: "DE", Code: "111111125"},
+		}, 10000)
+		s, err := oss.PlaceOfSupply(inv, &oss.Options{Sales: num.MakeAmount(20000, 0)})
+		require.NoError(t, err)
+		assert.Equal(t, l10n.TaxCountryCode("DE"), s.Country)
+		assert.Empty(t, s.Scheme)
+	})
+	t.Run("consumer outside EU", func(t *testing.T) {
+		inv := testInvoice(t, testSupplierTaxID, testConsumer("US"), 10000)
+		s, err := oss.PlaceOfSupply(inv, &oss.Options{Sales: num.MakeAmount(20000, 0)})
+		require.NoError(t, err)
+		assert.Equal(t, l10n.TaxCountryCode("ES"), s.Country)
+		assert.Empty(t, s.Scheme)
+	})
+	t.Run("non-union services", func(t *testing.T) {
+		gb := &tax.Identity{Country: "GB", Code: "844281425"}
+		inv := testInvoice(t, gb, testConsumer("FR"), 10000)
+		s, err := oss.PlaceOfSupply(inv, nil)
+		require.NoError(t, err)
+		assert.Equal(t, l10n.TaxCountryCode("FR"), s.Country)
+		assert.Equal(t, oss.SchemeNonUnion, s.Scheme)
+	})
+	t.Run("imports", func(t *testing.T) {
+		gb := &tax.Identity{Country: "GB", Code: "844281425"}
+		inv := testInvoice(t, gb, testConsumer("FR"), 10000)
+		inv.Currency = currency.EUR
+		require.NoError(t, inv.Calculate())
+		s, err := oss.PlaceOfSupply(inv, &oss.Options{Imports: true})
+		require.NoError(t, err)
+		assert.Equal(t, oss.SchemeImport, s.Scheme)
+
+		inv.Lines[0].Item.Price = num.MakeAmount(15001, 2)
+		require.NoError(t, inv.Calculate())
+		s, err = oss.PlaceOfSupply(inv, &oss.Options{Imports: true})
+		require.NoError(t, err)
+		assert.Equal(t, l10n.TaxCountryCode("FR"), s.Country)
+		assert.Empty(t, s.Scheme)
+	})
+	t.Run("missing exchange rate", func(t *testing.T) {
+		gb := &tax.Identity{Country: "GB", Code: "844281425"}
+		inv := testInvoice(t, gb, testConsumer("FR"), 10000)
+		_, err := oss.PlaceOfSupply(inv, &oss.Options{Imports: true})
+		assert.ErrorContains(t, err, "missing exchange rate from GBP to EUR")
+	})
+	t.Run("errors", func(t *testing.T) {
+		inv := testInvoice(t, testSupplierTaxID, testConsumer("PT"), 10000)
+		inv.Totals = nil
+		_, err := oss.PlaceOfSupply(inv, nil)
+		assert.ErrorContains(t, err, "invoice must be calculated")
+		inv.Supplier.TaxID = nil
+		_, err = oss.PlaceOfSupply(inv, nil)
+		assert.ErrorContains(t, err, "supplier tax ID country required")
+	})
+}
+
+func TestApply(t *testing.T) {
+	opts := &oss.Options{Sales: num.MakeAmount(20000, 0)}
+	inv := testInvoice(t, testSupplierTaxID, testConsumer("PT"), 10000)
+	s, err := oss.Apply(inv, opts)
+	require.NoError(t, err)
+	assert.Equal(t, oss.SchemeUnion, s.Scheme)
+	assert.True(t, inv.HasTags(tax.TagCustomerRates))
+	assert.Equal(t, "PT", inv.Lines[0].Taxes[0].Country.String())
+	assert.Equal(t, "23.0%", inv.Lines[0].Taxes[0].Percent.String())
+	require.NoError(t, inv.Validate())
+
+	s, err = oss.Apply(inv, &oss.Options{})
+	require.NoError(t, err)
+	assert.Empty(t, s.Scheme)
+	assert.Equal(t, l10n.TaxCountryCode("ES"), s.Country)
+	assert.False(t, inv.HasTags(tax.TagCustomerRates))
+	assert.Empty(t, inv.Lines[0].Taxes[0].Country)
+	assert.Equal(t, "21.0%", inv.Lines[0].Taxes[0].Percent.String())
+	require.NoError(t, inv.Validate())
+}
+
+func TestQuarter(t *testing.T) {
+	p := oss.Quarter(2024, 2)
+	assert.Equal(t, "2024-04-01", p.Start.String())
+	assert.Equal(t, "2024-06-30", p.End.String())
+	assert.Equal(t, "Q2 2024", p.Label)
+	p = oss.Quarter(2024, 4)
+	assert.Equal(t, "2024-12-31", p.End.String())
+}
+
+func TestBuildReturn(t *testing.T) {
+	opts := &oss.Options{Sales: num.MakeAmount(20000, 0)}
+	invoices := make([]*bill.Invoice, 0)
+	for _, c := range []l10n.ISOCountryCode{"PT", "PT", "FR", "ES"} {
+		inv := testInvoice(t, testSupplierTaxID, testConsumer(c), 10000)
+		_, err := oss.Apply(inv, opts)
+		require.NoError(t, err)
+		invoices = append(invoices, inv)
+	}
+	cn := testInvoice(t, testSupplierTaxID, testConsumer("FR"), 5000)
+	cn.Type = bill.InvoiceTypeCreditNote
+	_, err := oss.Apply(cn, opts)
+	require.NoError(t, err)
+	invoices = append(invoices, cn)
+	outside := testInvoice(t, testSupplierTaxID, testConsumer("PT"), 10000)
+	outside.IssueDate = cal.MakeDate(2024, 7, 1)
+	_, err = oss.Apply(outside, opts)
+	require.NoError(t, err)
+	invoices = append(invoices, outside)
+
+	r, err := oss.BuildReturn(oss.SchemeUnion, testSupplierTaxID, oss.Quarter(2024, 2), invoices...)
+	require.NoError(t, err)
+	require.NoError(t, r.Validate())
+	assert.Equal(t, currency.EUR, r.Currency)
+	require.Len(t, r.Rows, 2)
+	assert.Equal(t, l10n.TaxCountryCode("PT"), r.Rows[0].Country)
+	assert.Equal(t, cbc.Key("standard"), r.Rows[0].Key)
+	assert.Equal(t, "200.00", r.Rows[0].Base.String())
+	assert.Equal(t, "46.00", r.Rows[0].Amount.String())
+	assert.Equal(t, l10n.TaxCountryCode("FR"), r.Rows[1].Country)
+	assert.Equal(t, "50.00", r.Rows[1].Base.String())
+	assert.Equal(t, "10.00", r.Rows[1].Amount.String())
+	assert.Equal(t, "56.00", r.Total.String())
+
+	t.Run("errors", func(t *testing.T) {
+		_, err := oss.BuildReturn(oss.SchemeUnion, nil, oss.Quarter(2024, 2))
+		assert.ErrorContains(t, err, "tax ID required")
+		other := &tax.Identity{Country: "ES", Code: "54387763P"}
+		_, err = oss.BuildReturn(oss.SchemeUnion, other, oss.Quarter(2024, 2), invoices...)
+		assert.ErrorContains(t, err, "invoice 123: supplier does not match tax ID")
+	})
+	t.Run("invalid scheme", func(t *testing.T) {
+		r, err := oss.BuildReturn("foo", testSupplierTaxID, oss.Quarter(2024, 2))
+		require.NoError(t, err)
+		assert.ErrorContains(t, r.Validate(), "scheme: must be a valid value")
+	})
+}
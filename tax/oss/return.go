@@ -0,0 +1,195 @@
+package oss
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/invopop/gobl/bill"
+	"github.com/invopop/gobl/cal"
+	"github.com/invopop/gobl/cbc"
+	"github.com/invopop/gobl/currency"
+	"github.com/invopop/gobl/l10n"
+	"github.com/invopop/gobl/num"
+	"github.com/invopop/gobl/tax"
+	"github.com/invopop/gobl/uuid"
+	"github.com/invopop/validation"
+)
+
+// Return summarizes the VAT charged at the rates of other member states
+// over a period, ready to be declared through one of the OSS schemes.
+type Return struct {
+	uuid.Identify
+	// OSS scheme the return is made for.
+	Scheme cbc.Key `json:"scheme" jsonschema:"title=Scheme"`
+	// Tax identity of the supplier registered in the scheme.
+	TaxID *tax.Identity `json:"tax_id" jsonschema:"title=Tax ID"`
+	// Period covered by the return, usually a calendar quarter, or month for
+	// the import scheme.
+	Period cal.Period `json:"period" jsonschema:"title=Period"`
+	// Currency used for all amounts.
+	Currency currency.Code `json:"currency" jsonschema:"title=Currency"`
+	// Rows with the VAT due for each member state of consumption and rate.
+	Rows []*Row `json:"rows,omitempty" jsonschema:"title=Rows"`
+	// Total amount of VAT due.
+	Total num.Amount `json:"total" jsonschema:"title=Total"`
+}
+
+// Row contains the taxable base and VAT due in a member state of
+// consumption for a specific rate.
+type Row struct {
+	// Member state of consumption.
+	Country l10n.TaxCountryCode `json:"country" jsonschema:"title=Country"`
+	// Rate key, if any.
+	Key cbc.Key `json:"key,omitempty" jsonschema:"title=Key"`
+	// Percent applied.
+	Percent *num.Percentage `json:"percent,omitempty" jsonschema:"title=Percent"`
+	// Taxable base.
+	Base num.Amount `json:"base" jsonschema:"title=Base"`
+	// VAT due.
+	Amount num.Amount `json:"amount" jsonschema:"title=Amount"`
+}
+
+// Quarter provides the period for the quarter, 1 to 4, of the year.
+func Quarter(year, quarter int) cal.Period {
+	start := cal.MakeDate(year, 1, 1).Add(0, (quarter-1)*3, 0)
+	return cal.Period{
+		Label: fmt.Sprintf("Q%d %d", quarter, year),
+		Start: start,
+		End:   start.Add(0, 3, -1),
+	}
+}
+
+// BuildReturn prepares the OSS return for the scheme and supplier from the
+// invoices issued during the period. Only VAT charged at the rates of a
+// member state different from the supplier's is included, and credit notes
+// are subtracted.
+func BuildReturn(scheme cbc.Key, tID *tax.Identity, period cal.Period, invoices ...*bill.Invoice) (*Return, error) {
+	if tID == nil {
+		return nil, errors.New("tax ID required")
+	}
+	r := &Return{
+		Scheme:   scheme,
+		TaxID:    tID,
+		Period:   period,
+		Currency: returnCurrency(scheme, tID),
+	}
+	r.Total = r.Currency.Def().Zero()
+	for _, inv := range invoices {
+		if err := r.add(inv); err != nil {
+			return nil, fmt.Errorf("invoice %s: %w", inv.Code, err)
+		}
+	}
+	return r, nil
+}
+
+// Validate ensures the return contains everything it needs.
+func (r *Return) Validate() error {
+	return r.ValidateWithContext(context.Background())
+}
+
+// ValidateWithContext ensures the return contains everything it needs.
+func (r *Return) ValidateWithContext(ctx context.Context) error {
+	return validation.ValidateStructWithContext(ctx, r,
+		validation.Field(&r.UUID),
+		validation.Field(&r.Scheme, validation.Required, validation.In(schemeList()...)),
+		validation.Field(&r.TaxID, validation.Required),
+		validation.Field(&r.Period),
+		validation.Field(&r.Currency, validation.Required),
+		validation.Field(&r.Rows),
+	)
+}
+
+// ValidateWithContext checks the row's contents.
+func (row *Row) ValidateWithContext(ctx context.Context) error {
+	return validation.ValidateStructWithContext(ctx, row,
+		validation.Field(&row.Country, validation.Required),
+	)
+}
+
+func (r *Return) add(inv *bill.Invoice) error {
+	if inv.IssueDate.Before(r.Period.Start.Date) || inv.IssueDate.After(r.Period.End.Date) {
+		return nil
+	}
+	if inv.Supplier == nil || inv.Supplier.TaxID == nil ||
+		inv.Supplier.TaxID.Country != r.TaxID.Country || inv.Supplier.TaxID.Code != r.TaxID.Code {
+		return errors.New("supplier does not match tax ID")
+	}
+	if inv.Totals == nil {
+		return errors.New("missing totals")
+	}
+	taxes := inv.Totals.Taxes
+	if inv.Currency != r.Currency {
+		if inv.Totals.Local == nil || inv.Totals.Local.Currency != r.Currency {
+			return fmt.Errorf("missing totals in %s", r.Currency)
+		}
+		taxes = inv.Totals.Local.Taxes
+	}
+	ct := taxes.Category(tax.CategoryVAT)
+	if ct == nil {
+		return nil
+	}
+	credit := inv.Type.In(bill.InvoiceTypeCreditNote)
+	for _, rt := range ct.Rates {
+		if rt.Country == "" || rt.Country == r.TaxID.Country || !rt.Country.InEU() {
+			continue
+		}
+		base, amount := rt.Base, rt.Amount
+		if credit {
+			base, amount = base.Invert(), amount.Invert()
+		}
+		row := r.row(rt)
+		row.Base = row.Base.Add(base)
+		row.Amount = row.Amount.Add(amount)
+		r.Total = r.Total.Add(amount)
+	}
+	return nil
+}
+
+// row finds or creates the row matching the rate total.
+func (r *Return) row(rt *tax.RateTotal) *Row {
+	for _, row := range r.Rows {
+		if row.Country == rt.Country && row.Key == rt.Key && percentsMatch(row.Percent, rt.Percent) {
+			return row
+		}
+	}
+	zero := r.Currency.Def().Zero()
+	row := &Row{
+		Country: rt.Country,
+		Key:     rt.Key,
+		Base:    zero,
+		Amount:  zero,
+	}
+	if rt.Percent != nil {
+		p := *rt.Percent
+		row.Percent = &p
+	}
+	r.Rows = append(r.Rows, row)
+	return row
+}
+
+// returnCurrency determines the currency of the return, which is the euro
+// except for suppliers established in member states with their own.
+func returnCurrency(scheme cbc.Key, tID *tax.Identity) currency.Code {
+	if scheme == SchemeUnion {
+		if rd := tax.RegimeDefFor(tID.Country.Code()); rd != nil && tID.Country.InEU() {
+			return rd.Currency
+		}
+	}
+	return baseCurrency
+}
+
+func percentsMatch(a, b *num.Percentage) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return a.Equals(*b)
+}
+
+func schemeList() []any {
+	list := make([]any, len(Schemes))
+	for i, s := range Schemes {
+		list[i] = s
+	}
+	return list
+}
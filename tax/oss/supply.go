@@ -0,0 +1,172 @@
+package oss
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/invopop/gobl/bill"
+	"github.com/invopop/gobl/cbc"
+	"github.com/invopop/gobl/currency"
+	"github.com/invopop/gobl/l10n"
+	"github.com/invopop/gobl/num"
+	"github.com/invopop/gobl/tax"
+)
+
+// Options contain the details about the supplier's activity that cannot be
+// determined from a single invoice.
+type Options struct {
+	// Threshold in EUR of cross-border B2C sales inside the EU after which
+	// the customer's country rates must be applied. Defaults to the
+	// DefaultThreshold if empty.
+	Threshold *num.Amount
+	// Sales is the total value in EUR excluding VAT of the cross-border B2C
+	// sales made by the supplier inside the EU during the current calendar
+	// year, before the invoice. Suppliers that exceeded the threshold in the
+	// previous year, or opted in to the scheme, should provide a value above
+	// the threshold.
+	Sales num.Amount
+	// Imports should be true when the invoice covers goods that are shipped
+	// to the customer from outside the EU.
+	Imports bool
+	// Services should be true when the invoice covers services other than
+	// telecommunications, broadcasting, and electronically supplied (TBE)
+	// services, which are taxed in the supplier's country when provided to
+	// consumers and may not be declared using the OSS schemes.
+	Services bool
+}
+
+// Supply describes where the VAT of an invoice is due.
+type Supply struct {
+	// Country whose VAT rates should be applied.
+	Country l10n.TaxCountryCode
+	// Scheme used to declare the VAT, or empty if no OSS scheme applies.
+	Scheme cbc.Key
+}
+
+// PlaceOfSupply determines the country where the VAT of the calculated
+// invoice is due, and the OSS scheme that should be used to declare it, if
+// any. Customers are considered consumers when they do not have a tax ID
+// code, and their country is determined from the tax ID or first address.
+//
+// Only distance sales of goods and TBE services are expected by default, use
+// the Services option for other types of services.
+func PlaceOfSupply(inv *bill.Invoice, opts *Options) (*Supply, error) {
+	if opts == nil {
+		opts = new(Options)
+	}
+	if inv.Supplier == nil || inv.Supplier.TaxID == nil || inv.Supplier.TaxID.Country == "" {
+		return nil, errors.New("supplier tax ID country required")
+	}
+	if inv.Totals == nil {
+		return nil, errors.New("invoice must be calculated")
+	}
+	sc := inv.Supplier.TaxID.Country
+	cc := inv.Customer.TaxCountry()
+	if cc == "" || cc == sc {
+		return &Supply{Country: sc}, nil
+	}
+	if inv.Customer.TaxID != nil && inv.Customer.TaxID.Code != "" {
+		// business customers are responsible for the VAT in their country
+		return &Supply{Country: cc}, nil
+	}
+	if !cc.InEU() || opts.Services {
+		return &Supply{Country: sc}, nil
+	}
+
+	if !sc.InEU() && !opts.Imports {
+		return &Supply{Country: cc, Scheme: SchemeNonUnion}, nil
+	}
+	total, err := baseTotal(inv)
+	if err != nil {
+		return nil, err
+	}
+	if sc.InEU() {
+		threshold := DefaultThreshold
+		if opts.Threshold != nil {
+			threshold = *opts.Threshold
+		}
+		sales := opts.Sales.Add(total)
+		if sales.Compare(threshold) <= 0 {
+			return &Supply{Country: sc}, nil
+		}
+		return &Supply{Country: cc, Scheme: SchemeUnion}, nil
+	}
+	if total.Compare(ImportLimit) > 0 {
+		// import VAT is paid at customs
+		return &Supply{Country: cc}, nil
+	}
+	return &Supply{Country: cc, Scheme: SchemeImport}, nil
+}
+
+// Apply determines the place of supply of the invoice and sets or removes
+// the customer rates tag accordingly, before re-calculating. When the tag is
+// removed, the customer's country assigned to the taxes is also removed so
+// that the supplier's rates apply again. The supply details are returned.
+func Apply(inv *bill.Invoice, opts *Options) (*Supply, error) {
+	if inv.Totals == nil {
+		if err := inv.Calculate(); err != nil {
+			return nil, err
+		}
+	}
+	s, err := PlaceOfSupply(inv, opts)
+	if err != nil {
+		return nil, err
+	}
+	tags := make([]cbc.Key, 0, len(inv.GetTags())+1)
+	for _, t := range inv.GetTags() {
+		if t != tax.TagCustomerRates {
+			tags = append(tags, t)
+		}
+	}
+	if s.Scheme != cbc.KeyEmpty {
+		tags = append(tags, tax.TagCustomerRates)
+	} else if inv.HasTags(tax.TagCustomerRates) {
+		removeCustomerRates(inv)
+	}
+	inv.SetTags(tags...)
+	if err := inv.Calculate(); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// removeCustomerRates clears the country previously assigned to the invoice's
+// taxes by the customer rates tag.
+func removeCustomerRates(inv *bill.Invoice) {
+	country := inv.Customer.TaxCountry()
+	if country == "" {
+		return
+	}
+	sets := make([]tax.Set, 0, len(inv.Lines)+len(inv.Discounts)+len(inv.Charges))
+	for _, l := range inv.Lines {
+		sets = append(sets, l.Taxes)
+	}
+	for _, d := range inv.Discounts {
+		sets = append(sets, d.Taxes)
+	}
+	for _, c := range inv.Charges {
+		sets = append(sets, c.Taxes)
+	}
+	for _, ts := range sets {
+		for _, tc := range ts {
+			if tc.Country == country {
+				tc.Country = ""
+			}
+		}
+	}
+}
+
+// baseTotal provides the invoice's total without tax in EUR.
+func baseTotal(inv *bill.Invoice) (num.Amount, error) {
+	t := inv.Totals
+	if inv.Currency == baseCurrency {
+		return t.Total, nil
+	}
+	if t.Local != nil && t.Local.Currency == baseCurrency {
+		return t.Local.Total, nil
+	}
+	if ex := currency.MatchExchangeRate(inv.ExchangeRates, inv.Currency, baseCurrency); ex != nil {
+		return ex.Convert(t.Total), nil
+	}
+	return num.Amount{}, fmt.Errorf("missing exchange rate from %s to %s", inv.Currency, baseCurrency)
+}
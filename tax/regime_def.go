@@ -53,6 +53,10 @@ type RegimeDef struct {
 	// `sum-then-round`.
 	CalculatorRoundingRule CalculatorRoundingRule `json:"calculator_rounding_rule,omitempty" jsonschema:"title=Calculator Rounding Rule"`
 
+	// Increment payable amounts in the regime's currency should be rounded to
+	// when paid in cash, if different from the currency's smallest denomination.
+	CashRounding *num.Amount `json:"cash_rounding,omitempty" jsonschema:"title=Cash Rounding"`
+
 	// Tags that can be applied at the document level to identify additional
 	// considerations.
 	Tags []*TagSet `json:"tags,omitempty" jsonschema:"title=Tags"`
@@ -114,6 +118,12 @@ type CategoryDef struct {
 	// income.
 	Retained bool `json:"retained,omitempty" jsonschema:"title=Retained"`
 
+	// Compound lists the codes of other tax categories whose amounts, when
+	// applied to the same line, should be included in the base this
+	// category's rates are applied to. Typically used when excise duties
+	// form part of the VAT base.
+	Compound []cbc.Code `json:"compound,omitempty" jsonschema:"title=Compound"`
+
 	// Specific tax definitions inside this category. Order is important.
 	Rates []*RateDef `json:"rates,omitempty" jsonschema:"title=Rates"`
 
@@ -190,6 +200,9 @@ type RateValueDef struct {
 	Since *cal.Date `json:"since,omitempty" jsonschema:"title=Since"`
 	// Percent rate that should be applied
 	Percent num.Percentage `json:"percent" jsonschema:"title=Percent"`
+	// Fixed amount of tax to apply per unit, in the regime's currency, used
+	// for excise duties and levies either alone or alongside the percent.
+	Amount *num.Amount `json:"amount,omitempty" jsonschema:"title=Amount"`
 	// An additional surcharge to apply.
 	Surcharge *num.Percentage `json:"surcharge,omitempty" jsonschema:"title=Surcharge"`
 	// When true, this value should no longer be used.
@@ -279,6 +292,7 @@ func (r *RegimeDef) ValidateWithContext(ctx context.Context) error {
 		validation.Field(&r.Country),
 		validation.Field(&r.Zone),
 		validation.Field(&r.Currency),
+		validation.Field(&r.CashRounding, num.Positive),
 		validation.Field(&r.Tags),
 		validation.Field(&r.TaxIdentityTypeKeys),
 		validation.Field(&r.IdentityKeys),
@@ -370,6 +384,12 @@ func (c *CategoryDef) ValidateWithContext(ctx context.Context) error {
 		validation.Field(&c.Title, validation.Required),
 		validation.Field(&c.Description),
 		validation.Field(&c.Sources),
+		validation.Field(&c.Compound,
+			validation.Each(
+				r.InCategories(),
+				validation.NotIn(c.Code).Error("must not include itself"),
+			),
+		),
 		validation.Field(&c.Rates),
 		validation.Field(&c.Extensions,
 			validation.Each(cbc.InKeyDefs(r.Extensions)),
@@ -406,7 +426,10 @@ func (r *RateDef) ValidateWithContext(ctx context.Context) error {
 // Validate ensures the tax rate contains all the required fields.
 func (rv *RateValueDef) Validate() error {
 	return validation.ValidateStruct(rv,
-		validation.Field(&rv.Percent, validation.Required),
+		validation.Field(&rv.Percent,
+			validation.When(rv.Amount == nil, validation.Required),
+		),
+		validation.Field(&rv.Amount, num.Positive),
 	)
 }
 
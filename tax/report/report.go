@@ -0,0 +1,222 @@
+// Package report provides the models and methods used to aggregate the taxes
+// of a set of invoices over a period of time as required to prepare tax
+// returns.
+package report
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/invopop/gobl/bill"
+	"github.com/invopop/gobl/cal"
+	"github.com/invopop/gobl/cbc"
+	"github.com/invopop/gobl/currency"
+	"github.com/invopop/gobl/i18n"
+	"github.com/invopop/gobl/l10n"
+	"github.com/invopop/gobl/num"
+	"github.com/invopop/gobl/org"
+	"github.com/invopop/gobl/schema"
+	"github.com/invopop/gobl/tax"
+	"github.com/invopop/gobl/uuid"
+	"github.com/invopop/validation"
+)
+
+func init() {
+	schema.Register(schema.GOBL.Add("tax"),
+		Report{},
+	)
+}
+
+// Report summarizes the taxes of all the invoices issued or received by a
+// party over a period, as typically required to prepare a tax return.
+// Amounts are always provided in the regime's currency.
+type Report struct {
+	uuid.Identify
+	tax.Regime
+	// Tax identity of the party the report was prepared for.
+	TaxID *tax.Identity `json:"tax_id" jsonschema:"title=Tax ID"`
+	// Period covered by the report, invoices are included according to
+	// their issue date.
+	Period cal.Period `json:"period" jsonschema:"title=Period"`
+	// Currency used for all the amounts.
+	Currency currency.Code `json:"currency" jsonschema:"title=Currency"`
+	// Summary of the invoices issued by the party as supplier.
+	Sales *Summary `json:"sales,omitempty" jsonschema:"title=Sales"`
+	// Summary of the invoices received by the party as customer.
+	Purchases *Summary `json:"purchases,omitempty" jsonschema:"title=Purchases"`
+	// Boxes provided by the regime to complete the official tax return.
+	Boxes []*Box `json:"boxes,omitempty" jsonschema:"title=Boxes" jsonschema_extras:"calculated=true"`
+}
+
+// Box contains the amount for one of the boxes of an official tax return
+// form, as defined by the regime.
+type Box struct {
+	// Code used by the form to identify the box.
+	Code cbc.Code `json:"code" jsonschema:"title=Code"`
+	// Name of the box.
+	Name i18n.String `json:"name,omitempty" jsonschema:"title=Name"`
+	// Amount to declare.
+	Amount num.Amount `json:"amount" jsonschema:"title=Amount"`
+}
+
+// BoxMapper is used by regimes to determine the boxes of their official tax
+// return forms from a report.
+type BoxMapper func(r *Report) []*Box
+
+var boxMappers = make(map[l10n.TaxCountryCode]BoxMapper)
+
+// RegisterBoxMapper sets the box mapper used for reports prepared for the
+// regime's country.
+func RegisterBoxMapper(country l10n.TaxCountryCode, m BoxMapper) {
+	boxMappers[country] = m
+}
+
+// Build prepares a new report for the party with the tax ID using the
+// invoices issued inside the period. Invoices outside the period are
+// ignored, and those where the party is neither the supplier nor the
+// customer will cause an error.
+func Build(tID *tax.Identity, period cal.Period, invoices ...*bill.Invoice) (*Report, error) {
+	if tID == nil {
+		return nil, errors.New("tax ID required")
+	}
+	r := &Report{
+		Regime: tax.WithRegime(tID.Country),
+		TaxID:  tID,
+		Period: period,
+	}
+	for _, inv := range invoices {
+		if err := r.Add(inv); err != nil {
+			return nil, err
+		}
+	}
+	if err := r.Calculate(); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+// Add includes the invoice in the report's sales or purchases according to
+// the role of the report's party. Credit notes are subtracted.
+func (r *Report) Add(inv *bill.Invoice) error {
+	if err := r.prepare(); err != nil {
+		return err
+	}
+	if !r.inPeriod(inv.IssueDate) {
+		return nil
+	}
+	if inv.Totals == nil {
+		return fmt.Errorf("invoice %s: missing totals", invoiceLabel(inv))
+	}
+	total, taxes, err := r.totalsFor(inv)
+	if err != nil {
+		return fmt.Errorf("invoice %s: %w", invoiceLabel(inv), err)
+	}
+	if inv.Type.In(bill.InvoiceTypeCreditNote) {
+		total = total.Invert()
+		taxes = invertTotal(taxes)
+	}
+	switch {
+	case partyMatches(inv.Supplier, r.TaxID):
+		if r.Sales == nil {
+			r.Sales = newSummary(r.zero())
+		}
+		r.Sales.add(r.counterpartCountry(inv.Customer), total, taxes)
+	case partyMatches(inv.Customer, r.TaxID):
+		if r.Purchases == nil {
+			r.Purchases = newSummary(r.zero())
+		}
+		r.Purchases.add(r.counterpartCountry(inv.Supplier), total, taxes)
+	default:
+		return fmt.Errorf("invoice %s: tax ID %s not found in supplier or customer", invoiceLabel(inv), r.TaxID)
+	}
+	return nil
+}
+
+// Calculate determines the boxes of the official tax return form, if the
+// regime defines them.
+func (r *Report) Calculate() error {
+	if err := r.prepare(); err != nil {
+		return err
+	}
+	r.Boxes = nil
+	if m, ok := boxMappers[r.GetRegime()]; ok {
+		r.Boxes = m(r)
+	}
+	return nil
+}
+
+// Validate ensures the report contains everything it needs.
+func (r *Report) Validate() error {
+	return r.ValidateWithContext(context.Background())
+}
+
+// ValidateWithContext ensures the report contains everything it needs.
+func (r *Report) ValidateWithContext(ctx context.Context) error {
+	return validation.ValidateStructWithContext(ctx, r,
+		validation.Field(&r.UUID),
+		validation.Field(&r.Regime, validation.Required),
+		validation.Field(&r.TaxID, validation.Required),
+		validation.Field(&r.Period),
+		validation.Field(&r.Currency, validation.Required),
+		validation.Field(&r.Sales),
+		validation.Field(&r.Purchases),
+		validation.Field(&r.Boxes),
+	)
+}
+
+func (r *Report) prepare() error {
+	if r.Currency != currency.CodeEmpty {
+		return nil
+	}
+	rd := r.RegimeDef()
+	if rd == nil {
+		return fmt.Errorf("unknown regime '%s'", r.GetRegime())
+	}
+	r.Currency = rd.Currency
+	return nil
+}
+
+func (r *Report) zero() num.Amount {
+	return r.Currency.Def().Zero()
+}
+
+func (r *Report) inPeriod(d cal.Date) bool {
+	return !d.Before(r.Period.Start.Date) && !d.After(r.Period.End.Date)
+}
+
+// totalsFor provides the total without tax and the tax totals of the invoice
+// in the report's currency.
+func (r *Report) totalsFor(inv *bill.Invoice) (num.Amount, *tax.Total, error) {
+	t := inv.Totals
+	if inv.Currency == r.Currency {
+		return t.Total, t.Taxes, nil
+	}
+	if t.Local != nil && t.Local.Currency == r.Currency {
+		return t.Local.Total, t.Local.Taxes, nil
+	}
+	return num.Amount{}, nil, fmt.Errorf("missing totals in %s", r.Currency)
+}
+
+// counterpartCountry determines the country of the other party involved in
+// the invoice, which defaults to the report's country.
+func (r *Report) counterpartCountry(p *org.Party) l10n.TaxCountryCode {
+	if p != nil && p.TaxID != nil && p.TaxID.Country != "" {
+		return p.TaxID.Country
+	}
+	return r.GetRegime()
+}
+
+func partyMatches(p *org.Party, tID *tax.Identity) bool {
+	if p == nil || p.TaxID == nil {
+		return false
+	}
+	return p.TaxID.Country == tID.Country && p.TaxID.Code == tID.Code
+}
+
+func invoiceLabel(inv *bill.Invoice) string {
+	if inv.Series != "" {
+		return fmt.Sprintf("%s-%s", inv.Series, inv.Code)
+	}
+	return inv.Code.String()
+}
@@ -0,0 +1,162 @@
+package report_test
+
+import (
+	"testing"
+
+	"github.com/invopop/gobl/bill"
+	"github.com/invopop/gobl/cal"
+	"github.com/invopop/gobl/cbc"
+	"github.com/invopop/gobl/currency"
+	"github.com/invopop/gobl/l10n"
+	"github.com/invopop/gobl/num"
+	"github.com/invopop/gobl/org"
+	"github.com/invopop/gobl/tax"
+	"github.com/invopop/gobl/tax/report"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	_ "github.com/invopop/gobl"
+)
+
+var (
+	testOwnTaxID   = &tax.Identity{Country: "ES", Code: "B98602642"}
+	testOtherTaxID = &tax.Identity{Country: "ES", Code: "54387763P"}
+	testPeriod     = cal.Period{
+		Start: cal.MakeDate(2024, 1, 1),
+		End:   cal.MakeDate(2024, 3, 31),
+	}
+)
+
+func testInvoice(t *testing.T, supplier, customer *tax.Identity, price int64, rate cbc.Key) *bill.Invoice {
+	t.Helper()
+	inv := &bill.Invoice{
+		Regime:    tax.WithRegime(supplier.Country),
+		Code:      "123",
+		IssueDate: cal.MakeDate(2024, 2, 1),
+		Supplier: &org.Party{
+			Name:  "Supplier",
+			TaxID: supplier,
+		},
+		Customer: &org.Party{
+			Name:  "Customer",
+			TaxID: customer,
+		},
+		Lines: []*bill.Line{
+			{
+				Quantity: num.MakeAmount(1, 0),
+				Item: &org.Item{
+					Name:  "Item",
+					Price: num.MakeAmount(price, 2),
+				},
+				Taxes: tax.Set{
+					{
+						Category: tax.CategoryVAT,
+						Rate:     rate,
+					},
+				},
+			},
+		},
+	}
+	require.NoError(t, inv.Calculate())
+	return inv
+}
+
+func TestBuild(t *testing.T) {
+	t.Run("sales and purchases", func(t *testing.T) {
+		inv1 := testInvoice(t, testOwnTaxID, testOtherTaxID, 10000, tax.RateStandard)
+		inv2 := testInvoice(t, testOwnTaxID, testOtherTaxID, 5000, tax.RateReduced)
+		inv3 := testInvoice(t, testOtherTaxID, testOwnTaxID, 2000, tax.RateStandard)
+		r, err := report.Build(testOwnTaxID, testPeriod, inv1, inv2, inv3)
+		require.NoError(t, err)
+		require.NoError(t, r.Validate())
+		assert.Equal(t, l10n.TaxCountryCode("ES"), r.GetRegime())
+		assert.Equal(t, currency.EUR, r.Currency)
+
+		require.NotNil(t, r.Sales)
+		assert.Equal(t, 2, r.Sales.Count)
+		assert.Equal(t, "150.00", r.Sales.Total.String())
+		vat := r.Sales.Taxes.Category(tax.CategoryVAT)
+		require.Len(t, vat.Rates, 2)
+		assert.Equal(t, "100.00", vat.Rates[0].Base.String())
+		assert.Equal(t, "21.00", vat.Rates[0].Amount.String())
+		assert.Equal(t, "50.00", vat.Rates[1].Base.String())
+		assert.Equal(t, "5.00", vat.Rates[1].Amount.String())
+		assert.Equal(t, "26.00", r.Sales.Taxes.Sum.String())
+		require.Len(t, r.Sales.Countries, 1)
+		assert.Equal(t, l10n.TaxCountryCode("ES"), r.Sales.Countries[0].Country)
+
+		require.NotNil(t, r.Purchases)
+		assert.Equal(t, 1, r.Purchases.Count)
+		assert.Equal(t, "4.20", r.Purchases.Taxes.Sum.String())
+	})
+	t.Run("credit notes", func(t *testing.T) {
+		inv := testInvoice(t, testOwnTaxID, testOtherTaxID, 10000, tax.RateStandard)
+		cn := testInvoice(t, testOwnTaxID, testOtherTaxID, 4000, tax.RateStandard)
+		cn.Type = bill.InvoiceTypeCreditNote
+		r, err := report.Build(testOwnTaxID, testPeriod, inv, cn)
+		require.NoError(t, err)
+		assert.Equal(t, 2, r.Sales.Count)
+		assert.Equal(t, "60.00", r.Sales.Total.String())
+		rt := r.Sales.Taxes.Category(tax.CategoryVAT).Rates[0]
+		assert.Equal(t, "60.00", rt.Base.String())
+		assert.Equal(t, "12.60", rt.Amount.String())
+		assert.Equal(t, "12.60", r.Sales.Taxes.Sum.String())
+		assert.Equal(t, "40.00", cn.Totals.Total.String(), "should not modify credit note")
+	})
+	t.Run("countries", func(t *testing.T) {
+		foreign := &tax.Identity{Country: "PT", Code: "545259045"}
+		inv1 := testInvoice(t, testOwnTaxID, testOtherTaxID, 10000, tax.RateStandard)
+		inv2 := testInvoice(t, testOwnTaxID, foreign, 5000, tax.RateStandard)
+		r, err := report.Build(testOwnTaxID, testPeriod, inv1, inv2)
+		require.NoError(t, err)
+		require.Len(t, r.Sales.Countries, 2)
+		pt := r.Sales.Country("PT")
+		require.NotNil(t, pt)
+		assert.Equal(t, 1, pt.Count)
+		assert.Equal(t, "50.00", pt.Total.String())
+		assert.Equal(t, "10.50", pt.Taxes.Sum.String())
+		assert.Nil(t, r.Sales.Country("FR"))
+	})
+	t.Run("foreign currency", func(t *testing.T) {
+		inv := testInvoice(t, testOwnTaxID, testOtherTaxID, 10000, tax.RateStandard)
+		inv.Currency = currency.USD
+		inv.ExchangeRates = []*currency.ExchangeRate{
+			{
+				From:   currency.USD,
+				To:     currency.EUR,
+				Amount: num.MakeAmount(9, 1),
+			},
+		}
+		require.NoError(t, inv.Calculate())
+		r, err := report.Build(testOwnTaxID, testPeriod, inv)
+		require.NoError(t, err)
+		assert.Equal(t, "90.00", r.Sales.Total.String())
+		assert.Equal(t, "18.90", r.Sales.Taxes.Sum.String())
+
+		inv.Totals.Local = nil
+		_, err = report.Build(testOwnTaxID, testPeriod, inv)
+		assert.ErrorContains(t, err, "invoice 123: missing totals in EUR")
+	})
+	t.Run("outside period", func(t *testing.T) {
+		inv := testInvoice(t, testOwnTaxID, testOtherTaxID, 10000, tax.RateStandard)
+		inv.IssueDate = cal.MakeDate(2024, 4, 1)
+		r, err := report.Build(testOwnTaxID, testPeriod, inv)
+		require.NoError(t, err)
+		assert.Nil(t, r.Sales)
+	})
+	t.Run("errors", func(t *testing.T) {
+		_, err := report.Build(nil, testPeriod)
+		assert.ErrorContains(t, err, "tax ID required")
+
+		inv := testInvoice(t, testOtherTaxID, testOtherTaxID, 10000, tax.RateStandard)
+		_, err = report.Build(testOwnTaxID, testPeriod, inv)
+		assert.ErrorContains(t, err, "invoice 123: tax ID ESB98602642 not found in supplier or customer")
+
+		inv.Totals = nil
+		_, err = report.Build(testOwnTaxID, testPeriod, inv)
+		assert.ErrorContains(t, err, "invoice 123: missing totals")
+
+		_, err = report.Build(&tax.Identity{Country: "ZZ", Code: "123"}, testPeriod)
+		assert.ErrorContains(t, err, "unknown regime 'ZZ'")
+	})
+}
@@ -0,0 +1,129 @@
+package report
+
+import (
+	"context"
+
+	"github.com/invopop/gobl/l10n"
+	"github.com/invopop/gobl/num"
+	"github.com/invopop/gobl/tax"
+	"github.com/invopop/validation"
+)
+
+// Summary aggregates the totals and taxes of a set of invoices. Taxes are
+// grouped by category, rate, percent, and extensions, as in the invoices
+// themselves.
+type Summary struct {
+	// Number of invoices included.
+	Count int `json:"count" jsonschema:"title=Count"`
+	// Sum of the invoice totals without tax.
+	Total num.Amount `json:"total" jsonschema:"title=Total"`
+	// Combined tax totals.
+	Taxes *tax.Total `json:"taxes,omitempty" jsonschema:"title=Taxes"`
+	// Totals split by the country of the other party.
+	Countries []*CountrySummary `json:"countries,omitempty" jsonschema:"title=Countries"`
+}
+
+// CountrySummary aggregates the totals and taxes of the invoices made with
+// parties from a specific country.
+type CountrySummary struct {
+	// Tax country code of the other party.
+	Country l10n.TaxCountryCode `json:"country" jsonschema:"title=Country"`
+	// Number of invoices included.
+	Count int `json:"count" jsonschema:"title=Count"`
+	// Sum of the invoice totals without tax.
+	Total num.Amount `json:"total" jsonschema:"title=Total"`
+	// Combined tax totals.
+	Taxes *tax.Total `json:"taxes,omitempty" jsonschema:"title=Taxes"`
+}
+
+// ValidateWithContext checks the summary's contents.
+func (s *Summary) ValidateWithContext(ctx context.Context) error {
+	return validation.ValidateStructWithContext(ctx, s,
+		validation.Field(&s.Taxes),
+		validation.Field(&s.Countries),
+	)
+}
+
+// ValidateWithContext checks the country summary's contents.
+func (cs *CountrySummary) ValidateWithContext(ctx context.Context) error {
+	return validation.ValidateStructWithContext(ctx, cs,
+		validation.Field(&cs.Country, validation.Required),
+		validation.Field(&cs.Taxes),
+	)
+}
+
+// Country provides the summary for the country, or nil.
+func (s *Summary) Country(country l10n.TaxCountryCode) *CountrySummary {
+	if s == nil {
+		return nil
+	}
+	for _, cs := range s.Countries {
+		if cs.Country == country {
+			return cs
+		}
+	}
+	return nil
+}
+
+func newSummary(zero num.Amount) *Summary {
+	return &Summary{
+		Total: zero,
+	}
+}
+
+func (s *Summary) add(country l10n.TaxCountryCode, total num.Amount, taxes *tax.Total) {
+	s.Count++
+	s.Total = s.Total.Add(total)
+	s.Taxes = mergeTotal(s.Taxes, taxes)
+
+	cs := s.Country(country)
+	if cs == nil {
+		cs = &CountrySummary{
+			Country: country,
+			Total:   num.MakeAmount(0, total.Exp()),
+		}
+		s.Countries = append(s.Countries, cs)
+	}
+	cs.Count++
+	cs.Total = cs.Total.Add(total)
+	cs.Taxes = mergeTotal(cs.Taxes, taxes)
+}
+
+func mergeTotal(t, t2 *tax.Total) *tax.Total {
+	if t2 == nil {
+		return t
+	}
+	if t == nil {
+		return new(tax.Total).Merge(t2)
+	}
+	return t.Merge(t2)
+}
+
+// invertTotal provides a copy of the tax total with all the amounts inverted
+// so that they may be subtracted when merged.
+func invertTotal(t *tax.Total) *tax.Total {
+	if t == nil {
+		return nil
+	}
+	nt := new(tax.Total).Merge(t)
+	for _, ct := range nt.Categories {
+		for _, rt := range ct.Rates {
+			rt.Base = rt.Base.Invert()
+			rt.Amount = rt.Amount.Invert()
+			if rt.Quantity != nil {
+				q := rt.Quantity.Invert()
+				rt.Quantity = &q
+			}
+			if rt.Surcharge != nil {
+				rt.Surcharge.Amount = rt.Surcharge.Amount.Invert()
+			}
+		}
+		ct.Amount = ct.Amount.Invert()
+		if ct.Surcharge != nil {
+			s := ct.Surcharge.Invert()
+			ct.Surcharge = &s
+		}
+	}
+	nt.Sum = nt.Sum.Invert()
+	return nt
+}
@@ -2,6 +2,7 @@ package tax
 
 import (
 	"github.com/invopop/gobl/cbc"
+	"github.com/invopop/gobl/currency"
 	"github.com/invopop/gobl/l10n"
 	"github.com/invopop/gobl/num"
 )
@@ -35,7 +36,11 @@ type RateTotal struct {
 	Percent *num.Percentage `json:"percent,omitempty" jsonschema:"title=Percent"`
 	// Surcharge applied to the rate.
 	Surcharge *RateTotalSurcharge `json:"surcharge,omitempty" jsonschema:"title=Surcharge"`
-	// Total amount of rate, excluding surcharges
+	// Fixed amount of tax applied per unit, if any.
+	UnitAmount *num.Amount `json:"unit_amount,omitempty" jsonschema:"title=Unit Amount"`
+	// Total quantity of units the fixed amount was applied to.
+	Quantity *num.Amount `json:"quantity,omitempty" jsonschema:"title=Quantity"`
+	// Total amount of rate, including any fixed amounts and excluding surcharges
 	Amount num.Amount `json:"amount" jsonschema:"title=Amount"`
 }
 
@@ -78,6 +83,82 @@ func (t *Total) PreciseSum() num.Amount {
 	return t.Sum
 }
 
+// Convert provides a new total with all the bases and amounts converted using
+// the exchange rate. Category amounts and the final sum are determined from
+// the converted rate amounts so that they always add up. The original total
+// will not be modified.
+func (t *Total) Convert(ex *currency.ExchangeRate) *Total {
+	if t == nil {
+		return nil
+	}
+	zero := ex.To.Def().Zero()
+	nt := &Total{
+		Categories: make([]*CategoryTotal, len(t.Categories)),
+		Sum:        zero,
+	}
+	for i, ct := range t.Categories {
+		nct := &CategoryTotal{
+			Code:     ct.Code,
+			Retained: ct.Retained,
+			Rates:    make([]*RateTotal, len(ct.Rates)),
+			Amount:   zero,
+		}
+		for j, rt := range ct.Rates {
+			nrt := &RateTotal{
+				Key:     rt.Key,
+				Country: rt.Country,
+				Base:    ex.Convert(rt.Base),
+				Amount:  ex.Convert(rt.Amount),
+			}
+			if len(rt.Ext) > 0 {
+				nrt.Ext = make(Extensions, len(rt.Ext))
+				for k, v := range rt.Ext {
+					nrt.Ext[k] = v
+				}
+			}
+			if rt.Percent != nil {
+				p := *rt.Percent
+				nrt.Percent = &p
+			}
+			if rt.Quantity != nil {
+				q := *rt.Quantity
+				nrt.Quantity = &q
+			}
+			if rt.UnitAmount != nil {
+				a := rt.UnitAmount.Upscale(2).Multiply(ex.Amount)
+				nrt.UnitAmount = &a
+			}
+			if rt.Surcharge != nil {
+				nrt.Surcharge = &RateTotalSurcharge{
+					Percent: rt.Surcharge.Percent,
+					Amount:  ex.Convert(rt.Surcharge.Amount),
+				}
+				s := zero
+				if nct.Surcharge != nil {
+					s = *nct.Surcharge
+				}
+				s = s.Add(nrt.Surcharge.Amount)
+				nct.Surcharge = &s
+			}
+			nct.Amount = nct.Amount.Add(nrt.Amount)
+			nct.Rates[j] = nrt
+		}
+		if ct.Retained {
+			nt.Sum = nt.Sum.Subtract(nct.Amount)
+			if nct.Surcharge != nil {
+				nt.Sum = nt.Sum.Subtract(*nct.Surcharge)
+			}
+		} else {
+			nt.Sum = nt.Sum.Add(nct.Amount)
+			if nct.Surcharge != nil {
+				nt.Sum = nt.Sum.Add(*nct.Surcharge)
+			}
+		}
+		nt.Categories[i] = nct
+	}
+	return nt
+}
+
 // newCategoryTotal prepares a category total calculation.
 func newCategoryTotal(c *Combo, zero num.Amount) *CategoryTotal {
 	ct := new(CategoryTotal)
@@ -99,6 +180,10 @@ func newRateTotal(c *Combo, zero num.Amount) *RateTotal {
 		pc := *c.Percent
 		rt.Percent = &pc
 	}
+	if c.Amount != nil {
+		a := *c.Amount
+		rt.UnitAmount = &a
+	}
 	rt.Base = zero
 	rt.Amount = zero
 	if c.Surcharge != nil {
@@ -129,6 +214,9 @@ func (rt *RateTotal) matches(c *Combo) bool {
 	if rt.Country != c.Country {
 		return false
 	}
+	if !amountsMatch(rt.UnitAmount, c.Amount) {
+		return false
+	}
 	if rt.Percent == nil || c.Percent == nil {
 		return rt.Percent == nil && c.Percent == nil
 	}
@@ -172,3 +260,122 @@ func (t *Total) rateTotalFor(c *Combo, zero num.Amount) *RateTotal {
 
 	return rateTotal
 }
+
+// Merge provides a new total that combines the categories and rates of the
+// current total with those of the provided total, adding together the bases
+// and amounts of any rates that match. Neither of the original totals will
+// be modified. This is useful for documents like payments that need to
+// summarize the taxes from multiple sources.
+func (t *Total) Merge(t2 *Total) *Total {
+	nt := new(Total)
+	nt.merge(t)
+	nt.merge(t2)
+	return nt
+}
+
+func (t *Total) merge(t2 *Total) {
+	if t2 == nil {
+		return
+	}
+	for _, ct2 := range t2.Categories {
+		ct := t.Category(ct2.Code)
+		if ct == nil {
+			ct = &CategoryTotal{
+				Code:     ct2.Code,
+				Retained: ct2.Retained,
+				Rates:    make([]*RateTotal, 0),
+				Amount:   num.MakeAmount(0, ct2.Amount.Exp()),
+			}
+			t.Categories = append(t.Categories, ct)
+		}
+		for _, rt2 := range ct2.Rates {
+			ct.mergeRate(rt2)
+		}
+		ct.Amount = ct.Amount.MatchPrecision(ct2.Amount).Add(ct2.Amount)
+		if ct2.Surcharge != nil {
+			if ct.Surcharge == nil {
+				s := num.MakeAmount(0, ct2.Surcharge.Exp())
+				ct.Surcharge = &s
+			}
+			s := ct.Surcharge.MatchPrecision(*ct2.Surcharge).Add(*ct2.Surcharge)
+			ct.Surcharge = &s
+		}
+	}
+	t.Sum = t.Sum.MatchPrecision(t2.Sum).Add(t2.Sum)
+}
+
+func (ct *CategoryTotal) mergeRate(rt2 *RateTotal) {
+	var rt *RateTotal
+	for _, r := range ct.Rates {
+		if r.matchesRate(rt2) {
+			rt = r
+			break
+		}
+	}
+	if rt == nil {
+		rt = &RateTotal{
+			Key:     rt2.Key,
+			Country: rt2.Country,
+			Ext:     rt2.Ext,
+			Base:    num.MakeAmount(0, rt2.Base.Exp()),
+			Amount:  num.MakeAmount(0, rt2.Amount.Exp()),
+		}
+		if rt2.Percent != nil {
+			p := *rt2.Percent
+			rt.Percent = &p
+		}
+		if rt2.Surcharge != nil {
+			rt.Surcharge = &RateTotalSurcharge{
+				Percent: rt2.Surcharge.Percent,
+				Amount:  num.MakeAmount(0, rt2.Surcharge.Amount.Exp()),
+			}
+		}
+		if rt2.UnitAmount != nil {
+			a := *rt2.UnitAmount
+			rt.UnitAmount = &a
+		}
+		ct.Rates = append(ct.Rates, rt)
+	}
+	if rt2.Quantity != nil {
+		q := *rt2.Quantity
+		if rt.Quantity != nil {
+			q = rt.Quantity.MatchPrecision(q).Add(q)
+		}
+		rt.Quantity = &q
+	}
+	rt.Base = rt.Base.MatchPrecision(rt2.Base).Add(rt2.Base)
+	rt.Amount = rt.Amount.MatchPrecision(rt2.Amount).Add(rt2.Amount)
+	if rt.Surcharge != nil && rt2.Surcharge != nil {
+		rt.Surcharge.Amount = rt.Surcharge.Amount.MatchPrecision(rt2.Surcharge.Amount).Add(rt2.Surcharge.Amount)
+	}
+}
+
+// matchesRate checks if the two rate totals can be grouped together.
+func (rt *RateTotal) matchesRate(rt2 *RateTotal) bool {
+	if rt.Key != rt2.Key || rt.Country != rt2.Country || !rt.Ext.Equals(rt2.Ext) {
+		return false
+	}
+	if !amountsMatch(rt.UnitAmount, rt2.UnitAmount) {
+		return false
+	}
+	if rt.Percent == nil || rt2.Percent == nil {
+		if rt.Percent != nil || rt2.Percent != nil {
+			return false
+		}
+	} else if !rt.Percent.Equals(*rt2.Percent) {
+		return false
+	}
+	if rt.Surcharge == nil || rt2.Surcharge == nil {
+		return rt.Surcharge == nil && rt2.Surcharge == nil
+	}
+	return rt.Surcharge.Percent.Equals(rt2.Surcharge.Percent)
+}
+
+// amountsMatch checks if two optional amounts are either both empty or
+// contain the same value.
+func amountsMatch(a, b *num.Amount) bool {
+	if a == nil || b == nil {
+		return a == nil && b == nil
+	}
+	return a.Equals(*b)
+}
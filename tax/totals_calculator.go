@@ -3,6 +3,7 @@ package tax
 import (
 	"github.com/invopop/gobl/cal"
 	"github.com/invopop/gobl/cbc"
+	"github.com/invopop/gobl/currency"
 	"github.com/invopop/gobl/l10n"
 	"github.com/invopop/gobl/num"
 )
@@ -34,6 +35,11 @@ type TotalCalculator struct {
 	Date     cal.Date
 	Lines    []TaxableLine
 	Includes cbc.Code // Tax included in price
+
+	// Currency and exchange rates are used to convert fixed amounts
+	// defined by a regime in a different currency.
+	Currency      currency.Code
+	ExchangeRates []*currency.ExchangeRate
 }
 
 // TaxableLine defines what we expect from a line in order to subsequently calculate
@@ -43,6 +49,14 @@ type TaxableLine interface {
 	GetTotal() num.Amount
 }
 
+// TaxableLineQuantity is an optional interface that taxable lines may implement
+// to provide the number of units fixed amount taxes should be applied to. Fixed
+// amounts on lines without a quantity, such as document level discounts or
+// charges, will only be applied if the tax combo defines its own quantity.
+type TaxableLineQuantity interface {
+	GetQuantity() num.Amount
+}
+
 // Calculate the totals
 func (tc *TotalCalculator) Calculate(t *Total) error {
 	// reset
@@ -74,10 +88,38 @@ func (tc *TotalCalculator) prepareLines(taxLines []*taxLine) error {
 			if err := combo.calculate(tc.Country, tc.Tags, tc.Date); err != nil {
 				return err
 			}
+			if err := tc.convertAmount(combo); err != nil {
+				return err
+			}
 			// always add 2 decimal places for all tax calculations
 			tl.total = tl.total.RescaleUp(tc.Zero.Exp() + 2)
 		}
+		if err := tl.checkCompounds(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// convertAmount ensures fixed amounts copied from a regime that uses a different
+// currency are converted into the document's currency using the exchange rates.
+func (tc *TotalCalculator) convertAmount(c *Combo) error {
+	if c.Amount == nil || c.currency == currency.CodeEmpty || tc.Currency == currency.CodeEmpty {
+		return nil
+	}
+	if c.currency == tc.Currency {
+		return nil
 	}
+	a := c.Amount.Upscale(2)
+	if er := currency.MatchExchangeRate(tc.ExchangeRates, c.currency, tc.Currency); er != nil {
+		a = a.Multiply(er.Amount)
+	} else if er := currency.MatchExchangeRate(tc.ExchangeRates, tc.Currency, c.currency); er != nil {
+		a = a.Divide(er.Amount)
+	} else {
+		return ErrInvalidCurrency.WithMessage("no exchange rate defined for '%s' to '%s'", c.currency.String(), tc.Currency.String())
+	}
+	c.Amount = &a
+	c.currency = tc.Currency
 	return nil
 }
 
@@ -92,6 +134,14 @@ func (tc *TotalCalculator) removeIncludedTaxes(taxLines []*taxLine) error {
 			if c.retained {
 				return ErrInvalidPricesInclude.WithMessage("cannot include retained category '%s'", tc.Includes.String())
 			}
+			for _, code := range c.compounds() {
+				if tl.taxes.Get(code) != nil {
+					return ErrInvalidPricesInclude.WithMessage("cannot include category '%s' compounded with '%s'", tc.Includes.String(), code.String())
+				}
+			}
+			if fa := tl.fixedAmount(c); fa != nil {
+				tl.total = tl.total.Subtract(*fa)
+			}
 			if c.Percent == nil {
 				// no taxes, skip
 				continue
@@ -106,9 +156,18 @@ func (tc *TotalCalculator) calculateBaseRateTotals(taxLines []*taxLine, t *Total
 	// Go through each line and add the total to the base of each tax
 	for _, tl := range taxLines {
 		for _, c := range tl.taxes {
+			base := tl.baseFor(c)
 			rt := t.rateTotalFor(c, tc.Zero)
-			rt.Base = tc.matchPrecision(rt.Base, tl.total)
-			rt.Base = rt.Base.Add(tl.total)
+			rt.Base = tc.matchPrecision(rt.Base, base)
+			rt.Base = rt.Base.Add(base)
+			if q := tl.units(c); q != nil && rt.UnitAmount != nil {
+				if rt.Quantity == nil {
+					rt.Quantity = q
+				} else {
+					x := rt.Quantity.MatchPrecision(*q).Add(*q)
+					rt.Quantity = &x
+				}
+			}
 		}
 	}
 }
@@ -138,12 +197,20 @@ func (tc *TotalCalculator) calculateBaseCategoryTotal(ct *CategoryTotal) {
 	zero := tc.Zero
 	ct.Amount = zero
 	for _, rt := range ct.Rates {
-		if rt.Percent == nil {
+		if rt.Percent == nil && rt.UnitAmount == nil {
 			rt.Amount = zero
 			continue // exempt, nothing else to do
 		}
 		base := rt.Base
-		rt.Amount = rt.Percent.Of(rt.Base)
+		rt.Amount = zero
+		if rt.Percent != nil {
+			rt.Amount = rt.Percent.Of(rt.Base)
+		}
+		if rt.UnitAmount != nil && rt.Quantity != nil {
+			fa := rt.UnitAmount.Upscale(rt.Quantity.Exp()).Multiply(*rt.Quantity)
+			rt.Amount = tc.matchPrecision(rt.Amount, fa)
+			rt.Amount = rt.Amount.Add(fa)
+		}
 		ct.Amount = tc.matchPrecision(ct.Amount, rt.Amount)
 		ct.Amount = ct.Amount.Add(rt.Amount)
 		if rt.Surcharge != nil {
@@ -198,8 +265,85 @@ func (tc *TotalCalculator) round(t *Total) {
 
 // taxLine is used to replace
 type taxLine struct {
-	total num.Amount
-	taxes Set
+	total    num.Amount
+	quantity *num.Amount
+	taxes    Set
+}
+
+// units provides the number of units the combo's fixed amount should be
+// applied to, or nil if there is no fixed amount or quantity.
+func (tl *taxLine) units(c *Combo) *num.Amount {
+	if c.Amount == nil {
+		return nil
+	}
+	if c.Quantity != nil {
+		return c.Quantity
+	}
+	return tl.quantity
+}
+
+// fixedAmount provides the total fixed amount of tax the combo will apply
+// to the line, if any.
+func (tl *taxLine) fixedAmount(c *Combo) *num.Amount {
+	q := tl.units(c)
+	if q == nil {
+		return nil
+	}
+	a := c.Amount.Upscale(q.Exp()).Multiply(*q)
+	return &a
+}
+
+// baseFor provides the line's base for the combo, including the amounts of any
+// other categories on the same line that it compounds.
+func (tl *taxLine) baseFor(c *Combo) num.Amount {
+	base := tl.total
+	for _, code := range c.compounds() {
+		if d := tl.taxes.Get(code); d != nil {
+			base = base.Add(tl.amountFor(d))
+		}
+	}
+	return base
+}
+
+// amountFor provides the precise amount of tax the combo applies to the line.
+func (tl *taxLine) amountFor(c *Combo) num.Amount {
+	base := tl.baseFor(c)
+	a := num.MakeAmount(0, base.Exp())
+	if c.Percent != nil {
+		a = c.Percent.Of(base)
+	}
+	if fa := tl.fixedAmount(c); fa != nil {
+		a = a.MatchPrecision(*fa).Add(*fa)
+	}
+	return a
+}
+
+// checkCompounds ensures the compound categories of the line's taxes do not
+// refer back to themselves.
+func (tl *taxLine) checkCompounds() error {
+	for _, c := range tl.taxes {
+		if err := tl.checkCompoundPath(c, nil); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (tl *taxLine) checkCompoundPath(c *Combo, path []cbc.Code) error {
+	for _, code := range path {
+		if code == c.Category {
+			return ErrInvalidCategory.WithMessage("circular compound reference to '%s'", c.Category.String())
+		}
+	}
+	path = append(path, c.Category)
+	for _, code := range c.compounds() {
+		if d := tl.taxes.Get(code); d != nil {
+			if err := tl.checkCompoundPath(d, path); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
 }
 
 func mapTaxLines(lines []TaxableLine) []*taxLine {
@@ -209,6 +353,10 @@ func mapTaxLines(lines []TaxableLine) []*taxLine {
 			total: v.GetTotal(),
 			taxes: v.GetTaxes(),
 		}
+		if ql, ok := v.(TaxableLineQuantity); ok {
+			q := ql.GetQuantity()
+			tls[i].quantity = &q
+		}
 	}
 	return tls
 }
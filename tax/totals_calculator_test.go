@@ -9,6 +9,7 @@ import (
 	"github.com/invopop/gobl/addons/pt/saft"
 	"github.com/invopop/gobl/cal"
 	"github.com/invopop/gobl/cbc"
+	"github.com/invopop/gobl/currency"
 	"github.com/invopop/gobl/l10n"
 	"github.com/invopop/gobl/num"
 	"github.com/invopop/gobl/regimes/es"
@@ -1288,3 +1289,246 @@ func TestTotalBySumCalculate(t *testing.T) {
 	}
 
 }
+
+type taxableQuantityLine struct {
+	taxableLine
+	quantity num.Amount
+}
+
+func (tl *taxableQuantityLine) GetQuantity() num.Amount {
+	return tl.quantity
+}
+
+func TestTotalCalculatorFixedAmounts(t *testing.T) {
+	date := cal.MakeDate(2023, 6, 1)
+	zero := num.MakeAmount(0, 2)
+	calculate := func(t *testing.T, tc *tax.TotalCalculator) *tax.Total {
+		t.Helper()
+		tc.Country = l10n.ES.Tax()
+		tc.Zero = zero
+		tc.Date = date
+		tot := new(tax.Total)
+		require.NoError(t, tc.Calculate(tot))
+		return tot
+	}
+
+	t.Run("regime amount with combo quantity", func(t *testing.T) {
+		lines := []tax.TaxableLine{
+			&taxableQuantityLine{
+				taxableLine: taxableLine{
+					taxes: tax.Set{
+						{Category: tax.CategoryVAT, Rate: tax.RateStandard},
+						{Category: es.TaxCategoryIEEPNR, Rate: tax.RateStandard, Quantity: num.NewAmount(25, 1)},
+					},
+					amount: num.MakeAmount(10000, 2),
+				},
+				quantity: num.MakeAmount(10, 0),
+			},
+		}
+		tot := calculate(t, &tax.TotalCalculator{Lines: lines})
+		ct := tot.Category(es.TaxCategoryIEEPNR)
+		require.NotNil(t, ct)
+		require.Len(t, ct.Rates, 1)
+		rt := ct.Rates[0]
+		assert.Nil(t, rt.Percent)
+		assert.Equal(t, "0.45", rt.UnitAmount.String())
+		assert.Equal(t, "2.5", rt.Quantity.String())
+		assert.Equal(t, "100.00", rt.Base.String())
+		assert.Equal(t, "1.13", rt.Amount.String())
+		// VAT base compounds the plastic tax
+		assert.Equal(t, "101.13", tot.Category(tax.CategoryVAT).Rates[0].Base.String())
+		assert.Equal(t, "22.36", tot.Sum.String())
+		combo := lines[0].GetTaxes()[1]
+		assert.Nil(t, combo.Percent)
+		assert.Equal(t, "0.45", combo.Amount.String())
+	})
+
+	t.Run("line quantities grouped", func(t *testing.T) {
+		lines := []tax.TaxableLine{
+			&taxableQuantityLine{
+				taxableLine: taxableLine{
+					taxes:  tax.Set{{Category: es.TaxCategoryIPSI, Amount: num.NewAmount(30, 2)}},
+					amount: num.MakeAmount(5000, 2),
+				},
+				quantity: num.MakeAmount(10, 0),
+			},
+			&taxableQuantityLine{
+				taxableLine: taxableLine{
+					taxes:  tax.Set{{Category: es.TaxCategoryIPSI, Amount: num.NewAmount(30, 2)}},
+					amount: num.MakeAmount(2500, 2),
+				},
+				quantity: num.MakeAmount(5, 0),
+			},
+			&taxableQuantityLine{
+				taxableLine: taxableLine{
+					taxes:  tax.Set{{Category: es.TaxCategoryIPSI, Amount: num.NewAmount(10, 2)}},
+					amount: num.MakeAmount(1000, 2),
+				},
+				quantity: num.MakeAmount(2, 0),
+			},
+			// without quantity, no fixed amount
+			&taxableLine{
+				taxes:  tax.Set{{Category: es.TaxCategoryIPSI, Amount: num.NewAmount(30, 2)}},
+				amount: num.MakeAmount(-1000, 2),
+			},
+		}
+		tot := calculate(t, &tax.TotalCalculator{Lines: lines})
+		ct := tot.Category(es.TaxCategoryIPSI)
+		require.Len(t, ct.Rates, 2)
+		assert.Equal(t, "15", ct.Rates[0].Quantity.String())
+		assert.Equal(t, "65.00", ct.Rates[0].Base.String())
+		assert.Equal(t, "4.50", ct.Rates[0].Amount.String())
+		assert.Equal(t, "0.20", ct.Rates[1].Amount.String())
+		assert.Equal(t, "4.70", ct.Amount.String())
+	})
+
+	t.Run("with percent", func(t *testing.T) {
+		lines := []tax.TaxableLine{
+			&taxableQuantityLine{
+				taxableLine: taxableLine{
+					taxes: tax.Set{
+						{Category: es.TaxCategoryIPSI, Percent: num.NewPercentage(10, 2), Amount: num.NewAmount(30, 2)},
+					},
+					amount: num.MakeAmount(11030, 2),
+				},
+				quantity: num.MakeAmount(1, 0),
+			},
+		}
+		tot := calculate(t, &tax.TotalCalculator{Lines: lines, Includes: es.TaxCategoryIPSI})
+		rt := tot.Category(es.TaxCategoryIPSI).Rates[0]
+		assert.Equal(t, "100.00", rt.Base.String())
+		assert.Equal(t, "10.30", rt.Amount.String())
+	})
+
+	t.Run("currency conversion", func(t *testing.T) {
+		lines := []tax.TaxableLine{
+			&taxableQuantityLine{
+				taxableLine: taxableLine{
+					taxes:  tax.Set{{Category: es.TaxCategoryIEEPNR, Rate: tax.RateStandard}},
+					amount: num.MakeAmount(10000, 2),
+				},
+				quantity: num.MakeAmount(2, 0),
+			},
+		}
+		tot := calculate(t, &tax.TotalCalculator{
+			Lines:    lines,
+			Currency: currency.USD,
+			ExchangeRates: []*currency.ExchangeRate{
+				{From: currency.USD, To: currency.EUR, Amount: num.MakeAmount(9, 1)},
+			},
+		})
+		assert.Equal(t, "0.5000", lines[0].GetTaxes()[0].Amount.String())
+		assert.Equal(t, "1.00", tot.Sum.String())
+
+		tc := &tax.TotalCalculator{
+			Country:  l10n.ES.Tax(),
+			Zero:     zero,
+			Date:     date,
+			Lines:    lines,
+			Currency: currency.USD,
+		}
+		err := tc.Calculate(new(tax.Total))
+		assert.ErrorIs(t, err, tax.ErrInvalidCurrency)
+		assert.ErrorContains(t, err, "no exchange rate defined for 'EUR' to 'USD'")
+	})
+}
+
+func TestTotalCalculatorCompound(t *testing.T) {
+	date := cal.MakeDate(2024, 6, 1)
+	zero := num.MakeAmount(0, 2)
+	calculate := func(lines []tax.TaxableLine, includes cbc.Code) (*tax.Total, error) {
+		tc := &tax.TotalCalculator{
+			Country:  l10n.ES.Tax(),
+			Zero:     zero,
+			Date:     date,
+			Lines:    lines,
+			Includes: includes,
+		}
+		tot := new(tax.Total)
+		return tot, tc.Calculate(tot)
+	}
+
+	t.Run("from category definition", func(t *testing.T) {
+		lines := []tax.TaxableLine{
+			&taxableQuantityLine{
+				taxableLine: taxableLine{
+					taxes: tax.Set{
+						{Category: tax.CategoryVAT, Rate: tax.RateStandard},
+						{Category: es.TaxCategoryIEEPNR, Rate: tax.RateStandard},
+					},
+					amount: num.MakeAmount(10000, 2),
+				},
+				quantity: num.MakeAmount(20, 0),
+			},
+			&taxableLine{
+				taxes: tax.Set{
+					{Category: tax.CategoryVAT, Rate: tax.RateStandard},
+				},
+				amount: num.MakeAmount(5000, 2),
+			},
+		}
+		tot, err := calculate(lines, "")
+		require.NoError(t, err)
+		vat := tot.Category(tax.CategoryVAT)
+		assert.Equal(t, "159.00", vat.Rates[0].Base.String())
+		assert.Equal(t, "33.39", vat.Amount.String())
+		assert.Equal(t, "9.00", tot.Category(es.TaxCategoryIEEPNR).Amount.String())
+		assert.Equal(t, "42.39", tot.Sum.String())
+		assert.Empty(t, lines[0].GetTaxes()[0].Compound, "category compounds should not be copied")
+	})
+
+	t.Run("from combo with percentages", func(t *testing.T) {
+		lines := []tax.TaxableLine{
+			&taxableLine{
+				taxes: tax.Set{
+					{Category: tax.CategoryVAT, Percent: num.NewPercentage(21, 2), Compound: []cbc.Code{es.TaxCategoryIPSI}},
+					{Category: es.TaxCategoryIPSI, Percent: num.NewPercentage(10, 2), Compound: []cbc.Code{es.TaxCategoryIGIC}},
+					{Category: es.TaxCategoryIGIC, Percent: num.NewPercentage(5, 2)},
+				},
+				amount: num.MakeAmount(10000, 2),
+			},
+		}
+		tot, err := calculate(lines, "")
+		require.NoError(t, err)
+		assert.Equal(t, "100.00", tot.Category(es.TaxCategoryIGIC).Rates[0].Base.String())
+		assert.Equal(t, "5.00", tot.Category(es.TaxCategoryIGIC).Amount.String())
+		assert.Equal(t, "105.00", tot.Category(es.TaxCategoryIPSI).Rates[0].Base.String())
+		assert.Equal(t, "10.50", tot.Category(es.TaxCategoryIPSI).Amount.String())
+		assert.Equal(t, "110.50", tot.Category(tax.CategoryVAT).Rates[0].Base.String())
+		assert.Equal(t, "23.21", tot.Category(tax.CategoryVAT).Amount.String())
+		assert.Equal(t, "38.71", tot.Sum.String())
+	})
+
+	t.Run("circular", func(t *testing.T) {
+		lines := []tax.TaxableLine{
+			&taxableLine{
+				taxes: tax.Set{
+					{Category: tax.CategoryVAT, Percent: num.NewPercentage(21, 2), Compound: []cbc.Code{es.TaxCategoryIPSI}},
+					{Category: es.TaxCategoryIPSI, Percent: num.NewPercentage(10, 2), Compound: []cbc.Code{tax.CategoryVAT}},
+				},
+				amount: num.MakeAmount(10000, 2),
+			},
+		}
+		_, err := calculate(lines, "")
+		assert.ErrorIs(t, err, tax.ErrInvalidCategory)
+		assert.ErrorContains(t, err, "circular compound reference to 'VAT'")
+	})
+
+	t.Run("prices include", func(t *testing.T) {
+		lines := []tax.TaxableLine{
+			&taxableQuantityLine{
+				taxableLine: taxableLine{
+					taxes: tax.Set{
+						{Category: tax.CategoryVAT, Rate: tax.RateStandard},
+						{Category: es.TaxCategoryIEEPNR, Rate: tax.RateStandard},
+					},
+					amount: num.MakeAmount(10000, 2),
+				},
+				quantity: num.MakeAmount(20, 0),
+			},
+		}
+		_, err := calculate(lines, tax.CategoryVAT)
+		assert.ErrorIs(t, err, tax.ErrInvalidPricesInclude)
+		assert.ErrorContains(t, err, "cannot include category 'VAT' compounded with 'IEEPNR'")
+	})
+}
@@ -1,8 +1,13 @@
 package tax_test
 
 import (
+	"testing"
+
+	"github.com/invopop/gobl/currency"
 	"github.com/invopop/gobl/num"
 	"github.com/invopop/gobl/tax"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 // taxableLine is a very simple implementation of what the totals calculator requires.
@@ -18,3 +23,214 @@ func (tl *taxableLine) GetTaxes() tax.Set {
 func (tl *taxableLine) GetTotal() num.Amount {
 	return tl.amount
 }
+
+func TestTotalMerge(t *testing.T) {
+	t1 := &tax.Total{
+		Categories: []*tax.CategoryTotal{
+			{
+				Code: tax.CategoryVAT,
+				Rates: []*tax.RateTotal{
+					{
+						Key:     tax.RateStandard,
+						Base:    num.MakeAmount(10000, 2),
+						Percent: num.NewPercentage(21, 2),
+						Amount:  num.MakeAmount(2100, 2),
+					},
+				},
+				Amount: num.MakeAmount(2100, 2),
+			},
+		},
+		Sum: num.MakeAmount(2100, 2),
+	}
+	t2 := &tax.Total{
+		Categories: []*tax.CategoryTotal{
+			{
+				Code: tax.CategoryVAT,
+				Rates: []*tax.RateTotal{
+					{
+						Key:     tax.RateStandard,
+						Base:    num.MakeAmount(5000, 2),
+						Percent: num.NewPercentage(21, 2),
+						Amount:  num.MakeAmount(1050, 2),
+					},
+					{
+						Key:     tax.RateReduced,
+						Base:    num.MakeAmount(1000, 2),
+						Percent: num.NewPercentage(10, 2),
+						Amount:  num.MakeAmount(100, 2),
+					},
+				},
+				Amount: num.MakeAmount(1150, 2),
+			},
+			{
+				Code:     "IRPF",
+				Retained: true,
+				Rates: []*tax.RateTotal{
+					{
+						Base:    num.MakeAmount(5000, 2),
+						Percent: num.NewPercentage(15, 2),
+						Amount:  num.MakeAmount(750, 2),
+					},
+				},
+				Amount: num.MakeAmount(750, 2),
+			},
+		},
+		Sum: num.MakeAmount(400, 2),
+	}
+
+	t.Run("combined", func(t *testing.T) {
+		tt := t1.Merge(t2)
+		require.Len(t, tt.Categories, 2)
+		vat := tt.Category(tax.CategoryVAT)
+		require.Len(t, vat.Rates, 2)
+		assert.Equal(t, "150.00", vat.Rates[0].Base.String())
+		assert.Equal(t, "31.50", vat.Rates[0].Amount.String())
+		assert.Equal(t, "10.00", vat.Rates[1].Base.String())
+		assert.Equal(t, "32.50", vat.Amount.String())
+		irpf := tt.Category("IRPF")
+		assert.True(t, irpf.Retained)
+		assert.Equal(t, "7.50", irpf.Amount.String())
+		assert.Equal(t, "25.00", tt.Sum.String())
+		// originals untouched
+		assert.Equal(t, "100.00", t1.Categories[0].Rates[0].Base.String())
+		assert.Equal(t, "21.00", t1.Sum.String())
+	})
+	t.Run("fixed amounts", func(t *testing.T) {
+		fixed := func(qty int64) *tax.Total {
+			return &tax.Total{
+				Categories: []*tax.CategoryTotal{
+					{
+						Code: "IEEPNR",
+						Rates: []*tax.RateTotal{
+							{
+								Key:        tax.RateStandard,
+								Base:       num.MakeAmount(10000, 2),
+								UnitAmount: num.NewAmount(45, 2),
+								Quantity:   num.NewAmount(qty, 0),
+								Amount:     num.MakeAmount(45*qty, 2),
+							},
+						},
+						Amount: num.MakeAmount(45*qty, 2),
+					},
+				},
+				Sum: num.MakeAmount(45*qty, 2),
+			}
+		}
+		tt := fixed(2).Merge(fixed(3))
+		ct := tt.Category("IEEPNR")
+		require.Len(t, ct.Rates, 1)
+		assert.Equal(t, "5", ct.Rates[0].Quantity.String())
+		assert.Equal(t, "0.45", ct.Rates[0].UnitAmount.String())
+		assert.Equal(t, "2.25", ct.Rates[0].Amount.String())
+
+		other := fixed(1)
+		other.Categories[0].Rates[0].UnitAmount = num.NewAmount(50, 2)
+		tt = tt.Merge(other)
+		assert.Len(t, tt.Category("IEEPNR").Rates, 2)
+	})
+	t.Run("with nil", func(t *testing.T) {
+		var tn *tax.Total
+		tt := tn.Merge(t1)
+		assert.Equal(t, "21.00", tt.Sum.String())
+		tt = t1.Merge(nil)
+		assert.Equal(t, "21.00", tt.Sum.String())
+	})
+}
+
+func TestTotalConvert(t *testing.T) {
+	ex := &currency.ExchangeRate{
+		From:   currency.USD,
+		To:     currency.EUR,
+		Amount: num.MakeAmount(9, 1),
+	}
+	t.Run("nil", func(t *testing.T) {
+		var tt *tax.Total
+		assert.Nil(t, tt.Convert(ex))
+	})
+	t.Run("with retained and surcharges", func(t *testing.T) {
+		tt := &tax.Total{
+			Categories: []*tax.CategoryTotal{
+				{
+					Code: tax.CategoryVAT,
+					Rates: []*tax.RateTotal{
+						{
+							Key:     tax.RateStandard,
+							Base:    num.MakeAmount(10005, 2),
+							Percent: num.NewPercentage(21, 2),
+							Amount:  num.MakeAmount(2101, 2),
+							Surcharge: &tax.RateTotalSurcharge{
+								Percent: num.MakePercentage(52, 3),
+								Amount:  num.MakeAmount(520, 2),
+							},
+						},
+						{
+							Key:     tax.RateReduced,
+							Base:    num.MakeAmount(1005, 2),
+							Percent: num.NewPercentage(10, 2),
+							Amount:  num.MakeAmount(101, 2),
+						},
+					},
+					Amount:    num.MakeAmount(2202, 2),
+					Surcharge: num.NewAmount(520, 2),
+				},
+				{
+					Code:     "IRPF",
+					Retained: true,
+					Rates: []*tax.RateTotal{
+						{
+							Base:    num.MakeAmount(10005, 2),
+							Percent: num.NewPercentage(15, 2),
+							Amount:  num.MakeAmount(1501, 2),
+						},
+					},
+					Amount: num.MakeAmount(1501, 2),
+				},
+			},
+			Sum: num.MakeAmount(1221, 2),
+		}
+		nt := tt.Convert(ex)
+		vat := nt.Category(tax.CategoryVAT)
+		assert.Equal(t, "90.05", vat.Rates[0].Base.String())
+		assert.Equal(t, "18.91", vat.Rates[0].Amount.String())
+		assert.Equal(t, "4.68", vat.Rates[0].Surcharge.Amount.String())
+		assert.Equal(t, "0.91", vat.Rates[1].Amount.String())
+		assert.Equal(t, "19.82", vat.Amount.String())
+		assert.Equal(t, "4.68", vat.Surcharge.String())
+		irpf := nt.Category("IRPF")
+		assert.True(t, irpf.Retained)
+		assert.Equal(t, "13.51", irpf.Amount.String())
+		assert.Equal(t, "10.99", nt.Sum.String())
+		assert.Equal(t, "100.05", tt.Categories[0].Rates[0].Base.String(), "should not modify original")
+	})
+	t.Run("with quantities and extensions", func(t *testing.T) {
+		tt := &tax.Total{
+			Categories: []*tax.CategoryTotal{
+				{
+					Code: "IEE",
+					Rates: []*tax.RateTotal{
+						{
+							Ext:        tax.Extensions{"es-tbai-product": "goods"},
+							Base:       num.MakeAmount(10000, 2),
+							Quantity:   num.NewAmount(20, 0),
+							UnitAmount: num.NewAmount(50, 2),
+							Amount:     num.MakeAmount(1000, 2),
+						},
+					},
+					Amount: num.MakeAmount(1000, 2),
+				},
+			},
+			Sum: num.MakeAmount(1000, 2),
+		}
+		nt := tt.Convert(ex)
+		rt := nt.Categories[0].Rates[0]
+		assert.Equal(t, "20", rt.Quantity.String())
+		assert.Equal(t, "9.00", rt.Amount.String())
+		assert.Equal(t, tax.ExtValue("goods"), rt.Ext["es-tbai-product"])
+
+		*rt.Quantity = num.MakeAmount(10, 0)
+		rt.Ext["es-tbai-product"] = "services"
+		ort := tt.Categories[0].Rates[0]
+		assert.Equal(t, "20", ort.Quantity.String(), "should not modify original")
+		assert.Equal(t, tax.ExtValue("goods"), ort.Ext["es-tbai-product"], "should not modify original")
+	})
+}